@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,16 +18,24 @@ import (
 	"carbon-scribe/project-portal/project-portal-backend/internal/auth"
 	"carbon-scribe/project-portal/project-portal-backend/internal/collaboration"
 	"carbon-scribe/project-portal/project-portal-backend/internal/compliance"
+	auditpkg "carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
 	"carbon-scribe/project-portal/project-portal-backend/internal/config"
 	"carbon-scribe/project-portal/project-portal-backend/internal/documents"
 	"carbon-scribe/project-portal/project-portal-backend/internal/geospatial"
 	"carbon-scribe/project-portal/project-portal-backend/internal/health"
 	"carbon-scribe/project-portal/project-portal-backend/internal/integration"
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
 	"carbon-scribe/project-portal/project-portal-backend/internal/project"
 	"carbon-scribe/project-portal/project-portal-backend/internal/reports"
 	"carbon-scribe/project-portal/project-portal-backend/internal/search"
+	"carbon-scribe/project-portal/project-portal-backend/internal/search/analytics"
 	"carbon-scribe/project-portal/project-portal-backend/internal/settings"
 	"carbon-scribe/project-portal/project-portal-backend/pkg/elastic"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/mail"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
 	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
 
 	"github.com/gin-gonic/gin"
@@ -74,13 +86,56 @@ func main() {
 	// Initialize all services
 	searchRepo := search.NewRepository(esClient)
 	searchService := search.NewService(searchRepo)
-	searchHandler := search.NewHandler(searchService)
+	searchService.SetTracker(analytics.NewDBTracker(db))
 
-	authHandler := &auth.Handler{}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("❌ Failed to get underlying DB: %v", err)
+	}
+	tokenService, err := auth.NewTokenService(cfg.Auth)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize token service: %v", err)
+	}
+	keyStore, err := newKeyStore(cfg, sqlDB)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize JWT key store: %v", err)
+	}
+	rotationCtx, stopRotation := context.WithCancel(context.Background())
+	defer stopRotation()
+	tokenService.StartRotation(rotationCtx, keyStore, cfg.Auth.RotationInterval)
+
+	mailer, err := mail.New(mail.Config{
+		Driver:       cfg.Mail.Driver,
+		From:         cfg.Mail.From,
+		SMTPHost:     cfg.Mail.SMTPHost,
+		SMTPPort:     cfg.Mail.SMTPPort,
+		SMTPUsername: cfg.Mail.SMTPUsername,
+		SMTPPassword: cfg.Mail.SMTPPassword,
+		FileDir:      cfg.Mail.FileDir,
+	})
+	appBaseURL := strings.TrimRight(cfg.Mail.AppBaseURL, "/")
+	var authMailer auth.Mailer
+	if err != nil {
+		log.Printf("⚠️  Mail: %v — account and invitation emails will not be sent", err)
+	} else {
+		log.Printf("✅ Mail sender initialized (%s)", cfg.Mail.Driver)
+		authMailer = auth.NewSenderMailer(mailer, appBaseURL)
+	}
+
+	authRepo := auth.NewRepository(sqlDB)
+	authService := auth.NewAuthService(authRepo, tokenService, authMailer)
+	authHandler := auth.NewHandler(authService)
 
 	collabRepo := collaboration.NewRepository(db)
 	collabService := collaboration.NewService(collabRepo)
+	if mailer != nil {
+		collabService.SetMailer(mailer, appBaseURL+"/invitations/accept")
+	}
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	collabService.StartInvitationSweep(sweepCtx, 15*time.Minute)
 	collabHandler := collaboration.NewHandler(collabService)
+	searchHandler := search.NewHandler(searchService, collabService)
 
 	healthRepo := health.NewRepository(db)
 	healthService := health.NewService(healthRepo)
@@ -88,56 +143,108 @@ func main() {
 
 	integrationRepo := integration.NewRepository(db)
 	integrationService := integration.NewService(integrationRepo)
+	if err := configureIntegrationSecrets(cfg, integrationService); err != nil {
+		log.Fatalf("❌ Failed to configure integration secrets: %v", err)
+	}
 	integrationHandler := integration.NewHandler(integrationService)
 
 	reportsRepo := reports.NewRepository(db)
 	reportsService := reports.NewService(reportsRepo, nil) // Exporter can be added later
 	reportsHandler := reports.NewHandler(reportsService)
 
-	projectRepo := project.NewRepository(db)
-	projectService := project.NewService(projectRepo)
-	projectHandler := project.NewHandler(projectService)
-
-	// Initialize document management service
-	var docsHandler *documents.Handler
-	s3Client, s3Err := storage.NewS3Client(storage.S3Config{
-		Region:          cfg.AWS.Region,
-		AccessKeyID:     cfg.AWS.AccessKeyID,
-		SecretAccessKey: cfg.AWS.SecretAccessKey,
-		BucketName:      cfg.Storage.S3BucketName,
-		Endpoint:        cfg.AWS.Endpoint,
-	})
-	if s3Err != nil {
-		log.Printf("⚠️  Documents: S3 client init failed (%v) — document upload will be unavailable", s3Err)
-	} else {
-		log.Println("✅ S3 client initialized")
-		docStorageSvc := documents.NewStorageService(s3Client)
-		docRepo := documents.NewRepository(db)
-
-		// Optional IPFS pinning.
-		var ipfsUploader *documents.IPFSUploader
-		if cfg.Storage.IPFSEnabled {
-			ipfsClient := storage.NewIPFSClient(cfg.Storage.IPFSNodeURL)
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			if ipfsClient.IsAvailable(ctx) {
-				log.Printf("✅ IPFS node reachable at %s", cfg.Storage.IPFSNodeURL)
-				ipfsUploader = documents.NewIPFSUploader(ipfsClient)
-			} else {
-				log.Printf("⚠️  IPFS node at %s not reachable — pinning disabled", cfg.Storage.IPFSNodeURL)
-			}
-			cancel()
+	// Initialize document management service. The backend is chosen by
+	// STORAGE_DRIVER; document routes are available with every driver.
+	docRepo := documents.NewRepository(db)
+	objectStore, err := newObjectStore(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize document storage: %v", err)
+	}
+	log.Printf("✅ Document storage initialized (%s)", objectStore.BucketName())
+	docStorageSvc := documents.NewStorageService(objectStore)
+
+	// Optional IPFS pinning.
+	var ipfsUploader *documents.IPFSUploader
+	if cfg.Storage.IPFSEnabled {
+		ipfsClient := storage.NewIPFSClient(cfg.Storage.IPFSNodeURL)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if ipfsClient.IsAvailable(ctx) {
+			log.Printf("✅ IPFS node reachable at %s", cfg.Storage.IPFSNodeURL)
+			ipfsUploader = documents.NewIPFSUploader(ipfsClient)
+		} else {
+			log.Printf("⚠️  IPFS node at %s not reachable — pinning disabled", cfg.Storage.IPFSNodeURL)
 		}
+		cancel()
+	}
 
-		docSvc := documents.NewServiceWithIPFS(docRepo, docStorageSvc, ipfsUploader)
-		docsHandler = documents.NewHandler(docSvc)
+	docSvc := documents.NewServiceWithIPFS(docRepo, docStorageSvc, ipfsUploader)
+	docSvc.StartEscalationSweep(sweepCtx, 15*time.Minute)
+	docSvc.StartIntegritySweep(sweepCtx, time.Hour, 100)
+	docSvc.StartUploadSweep(sweepCtx, 15*time.Minute, 50)
+	if err := enableDocumentSigning(cfg, docSvc); err != nil {
+		log.Fatalf("Failed to configure document signing: %v", err)
+	}
+	// Full-text indexing of uploads needs Elasticsearch; without it uploads
+	// are stored as before and document search returns nothing.
+	docPurger := documents.NewPurger(docStorageSvc)
+	if esClient != nil {
+		if err := searchService.SyncIndex(context.Background()); err != nil {
+			log.Printf("⚠️  Search index setup failed: %v", err)
+		}
+		docSvc.SetTextIndexer(searchService)
+		docPurger.SetTextIndexer(searchService)
+		docSvc.StartTextExtraction(sweepCtx, time.Minute, 20)
 	}
+	docsHandler := documents.NewHandler(docSvc, collabService)
 	complianceRepo := compliance.NewRepository(db)
 	complianceService := compliance.NewService(complianceRepo)
+	if err := configureCompliance(cfg, complianceService, objectStore); err != nil {
+		log.Fatalf("❌ Failed to configure compliance: %v", err)
+	}
+	complianceService.SetAccountChecker(authRepo)
+	complianceService.StartExportSweep(sweepCtx, time.Hour, 50)
+	complianceService.StartRequestWorker(sweepCtx, 5*time.Minute, 20)
+	if err := configureRetention(cfg, db, complianceService); err != nil {
+		log.Fatalf("❌ Failed to configure retention: %v", err)
+	}
+	complianceService.StartRetentionSweep(sweepCtx, time.Hour)
+	complianceService.StartCheckpointSweep(sweepCtx, time.Hour)
+	// Privacy requests find, export and erase a user's data through each
+	// module's data source.
+	for _, source := range []requests.Adapter{
+		settings.NewPrivacySource(db),
+		collaboration.NewPrivacySource(db),
+		documents.NewPrivacySource(db),
+		compliance.NewPrivacySource(db),
+		analytics.NewPrivacySource(db),
+	} {
+		complianceService.RegisterDataSource(source)
+	}
 	complianceHandler := compliance.NewHandler(complianceService)
 
 	geospatialRepo := geospatial.NewRepository(db)
 	geospatialService := geospatial.NewService(geospatialRepo)
-	geospatialHandler := geospatial.NewHandler(geospatialService)
+	docSvc.SetBoundarySource(geospatialService)
+	geospatialHandler := geospatial.NewHandler(geospatialService, collabService, collabService)
+
+	// Projects depend on collaboration (membership, activity feed), documents
+	// and geospatial for lifecycle guards. Purging walks every module that
+	// stores project data and is blocked by legal holds.
+	projectRepo := project.NewRepository(db)
+	projectService := project.NewService(projectRepo, project.Dependencies{
+		Members:       collabService,
+		Documents:     docRepo,
+		Boundaries:    geospatialService,
+		BoundaryStore: geospatialService,
+		Activity:      collabService,
+		Compliance:    complianceService,
+		Purgers: []project.DataPurger{
+			docPurger,
+			collabService,
+			geospatialService,
+			reportsService,
+		},
+	})
+	projectHandler := project.NewHandler(projectService, collabService)
 	settingsRepo := settings.NewRepository(db)
 	settingsService, err := settings.NewService(settingsRepo, settings.Config{
 		EncryptionKeyHex: cfg.Settings.EncryptionKeyHex,
@@ -193,14 +300,23 @@ func main() {
 	// Auth routes
 	auth.RegisterRoutes(router, authHandler)
 
+	// Every /api/v1 route requires a valid access token. Project-scoped
+	// routes additionally check the caller's membership role.
+	authRequired := middleware.AuthRequired(tokenService)
+
 	// Collaboration routes
-	collaboration.RegisterRoutes(router, collabHandler)
+	collaboration.RegisterRoutes(router, collabHandler, authRequired)
 
 	// Integration routes
-	integration.RegisterRoutes(router, integrationHandler)
+	integration.RegisterRoutes(router, integrationHandler, authRequired)
+
+	// Signed download URLs for local and in-memory document storage
+	documents.RegisterObjectRoutes(router, docsHandler)
+	// External document share links, authorized by their token
+	documents.RegisterShareRoutes(router, docsHandler)
 
 	// API v1 routes (for reports and future APIs)
-	v1 := router.Group("/api/v1")
+	v1 := router.Group("/api/v1", authRequired)
 	{
 		// Register projects routes under v1
 		projectHandler.RegisterRoutes(v1)
@@ -214,10 +330,8 @@ func main() {
 		// Register search routes under v1
 		searchHandler.RegisterRoutes(v1)
 
-		// Register document management routes (only if S3 is available)
-		if docsHandler != nil {
-			documents.RegisterRoutes(v1, docsHandler)
-		}
+		// Register document management routes under v1
+		documents.RegisterRoutes(v1, docsHandler)
 		// Register compliance routes under v1
 		complianceHandler.RegisterRoutes(v1)
 		// Register geospatial routes under v1
@@ -316,12 +430,216 @@ func initDatabase(config *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// configureIntegrationSecrets sets the incoming webhook secret and the
+// OAuth2 state key. In debug mode a missing state key is replaced by a random
+// one, so authorizations in flight fail after a restart.
+func configureIntegrationSecrets(cfg *config.Config, svc *integration.Service) error {
+	webhookSecret := []byte(cfg.Integration.WebhookSecret)
+	if len(webhookSecret) == 0 {
+		log.Println("⚠️  INTEGRATION_WEBHOOK_SECRET not set — incoming webhooks will be rejected")
+	}
+	stateKey := []byte(cfg.Integration.OAuthStateSecret)
+	if len(stateKey) == 0 {
+		if cfg.Debug {
+			log.Println("⚠️  INTEGRATION_OAUTH_STATE_SECRET not set — using a random key for development")
+			stateKey = make([]byte, 32)
+			if _, err := rand.Read(stateKey); err != nil {
+				return err
+			}
+		} else {
+			log.Println("WARNING: INTEGRATION_OAUTH_STATE_SECRET not set — OAuth2 integrations are disabled")
+		}
+	}
+	svc.SetSecrets(webhookSecret, stateKey)
+	return nil
+}
+
+// newObjectStore builds the document storage backend named by
+// STORAGE_DRIVER. Local and memory stores sign their own download URLs; a
+// random secret is used when none is configured, so URLs do not survive a
+// restart.
+func newObjectStore(cfg *config.Config) (storage.ObjectStore, error) {
+	switch cfg.Storage.Driver {
+	case "s3", "":
+		return storage.NewS3Client(storage.S3Config{
+			Region:          cfg.AWS.Region,
+			AccessKeyID:     cfg.AWS.AccessKeyID,
+			SecretAccessKey: cfg.AWS.SecretAccessKey,
+			BucketName:      cfg.Storage.S3BucketName,
+			Endpoint:        cfg.AWS.Endpoint,
+		})
+	case "local", "memory":
+		secret := []byte(cfg.Storage.SigningSecret)
+		if len(secret) == 0 {
+			log.Println("⚠️  STORAGE_SIGNING_SECRET not set — using a random key; download URLs will not survive a restart")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+		signer := storage.NewURLSigner(secret, strings.TrimRight(cfg.Storage.PublicBaseURL, "/")+"/api/v1/storage/objects")
+		if cfg.Storage.Driver == "memory" {
+			return storage.NewMemoryStore(signer), nil
+		}
+		return storage.NewLocalStore(cfg.Storage.LocalDir, signer)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.Storage.Driver)
+	}
+}
+
+// newKeyStore builds the store rotated JWT keys are shared through. Without
+// a key a fixed development key encrypts them, as for settings secrets.
+func newKeyStore(cfg *config.Config, sqlDB *sql.DB) (*auth.KeyStore, error) {
+	key := []byte("settings-dev-encryption-key-32!!")
+	if hexKey := strings.TrimSpace(cfg.Auth.KeyVaultHex); hexKey != "" {
+		var err error
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			return nil, fmt.Errorf("invalid JWT_KEY_VAULT_KEY_HEX: %w", err)
+		}
+	} else if cfg.Auth.RotationInterval > 0 {
+		log.Println("⚠️  JWT_KEY_VAULT_KEY_HEX not set — rotated JWT keys are encrypted with the development key")
+	}
+	vault, err := encryption.NewVault(key)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyStore(sqlDB, vault), nil
+}
+
+// enableDocumentSigning sets up the vault for signing keys and, when
+// SIGNING_TSA_URL is set, the timestamp authority, and configures
+// revocation checking for verification. Without a key a fixed development
+// key is used, as for settings secrets.
+func enableDocumentSigning(cfg *config.Config, docSvc *documents.Service) error {
+	key := []byte("settings-dev-encryption-key-32!!")
+	if hexKey := strings.TrimSpace(cfg.Signing.VaultKeyHex); hexKey != "" {
+		var err error
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			return fmt.Errorf("invalid SIGNING_VAULT_KEY_HEX: %w", err)
+		}
+	} else {
+		log.Println("⚠️  SIGNING_VAULT_KEY_HEX not set — signing keys are encrypted with the development key")
+	}
+	vault, err := encryption.NewVault(key)
+	if err != nil {
+		return err
+	}
+	var tsa *security.TSAClient
+	if cfg.Signing.TSAURL != "" {
+		tsa = &security.TSAClient{
+			URL:      cfg.Signing.TSAURL,
+			Username: cfg.Signing.TSAUsername,
+			Password: cfg.Signing.TSAPassword,
+		}
+	}
+	docSvc.SetSigning(vault, tsa)
+	if err := docSvc.SetRevocationChecking(strings.ToLower(strings.TrimSpace(cfg.Signing.RevocationCheck))); err != nil {
+		return fmt.Errorf("invalid SIGNING_REVOCATION_CHECK: %w", err)
+	}
+	return nil
+}
+
+// configureCompliance sets the deletion certificate key and the vault and
+// store for encrypted privacy export packages. Without keys fixed
+// development keys are used, except for audit signing, which requires
+// configured keys outside debug mode.
+func configureCompliance(cfg *config.Config, svc *compliance.Service, store storage.ObjectStore) error {
+	if keyHex := strings.TrimSpace(cfg.Compliance.CertificateKeyHex); keyHex != "" {
+		key, err := hex.DecodeString(keyHex)
+		if err != nil {
+			return fmt.Errorf("invalid COMPLIANCE_CERTIFICATE_KEY_HEX: %w", err)
+		}
+		svc.SetCertificateKey(key)
+	} else {
+		log.Println("⚠️  COMPLIANCE_CERTIFICATE_KEY_HEX not set — deletion certificates are signed with the development key")
+	}
+
+	keys, err := auditpkg.ParseKeyring(cfg.Compliance.AuditKeys, strings.TrimSpace(cfg.Compliance.AuditKeyID))
+	if err != nil {
+		return fmt.Errorf("invalid COMPLIANCE_AUDIT_KEYS: %w", err)
+	}
+	if keys.ActiveID() == "" {
+		if !cfg.Debug {
+			return errors.New("COMPLIANCE_AUDIT_KEYS must be set outside debug mode")
+		}
+		log.Println("⚠️  COMPLIANCE_AUDIT_KEYS not set — audit entries are signed with the development key")
+		keys = auditpkg.DevKeyring()
+	}
+	svc.SetAuditKeys(keys)
+	switch cfg.Compliance.AuditAnchor {
+	case "stellar-local":
+		svc.SetAuditAnchor(auditpkg.NewLocalStellarAnchor())
+	case "none", "":
+	default:
+		return fmt.Errorf("unknown COMPLIANCE_AUDIT_ANCHOR %q", cfg.Compliance.AuditAnchor)
+	}
+
+	key := []byte("settings-dev-encryption-key-32!!")
+	if keyHex := strings.TrimSpace(cfg.Compliance.ExportKeyHex); keyHex != "" {
+		var err error
+		if key, err = hex.DecodeString(keyHex); err != nil {
+			return fmt.Errorf("invalid COMPLIANCE_EXPORT_KEY_HEX: %w", err)
+		}
+	} else {
+		log.Println("⚠️  COMPLIANCE_EXPORT_KEY_HEX not set — privacy exports are encrypted with the development key")
+	}
+	vault, err := encryption.NewVault(key)
+	if err != nil {
+		return err
+	}
+	svc.SetExportStorage(store, vault)
+	return nil
+}
+
+// configureRetention sets up the enforcer that applies retention policies to
+// each module's tables, archiving to a bucket or a local directory.
+// Archives are never served by URL, so the local store has no signer.
+func configureRetention(cfg *config.Config, db *gorm.DB, svc *compliance.Service) error {
+	var store storage.ObjectStore
+	switch cfg.Compliance.ArchiveDriver {
+	case "s3":
+		s3, err := storage.NewS3Client(storage.S3Config{
+			Region:          cfg.AWS.Region,
+			AccessKeyID:     cfg.AWS.AccessKeyID,
+			SecretAccessKey: cfg.AWS.SecretAccessKey,
+			BucketName:      cfg.Compliance.ArchiveBucket,
+			Endpoint:        cfg.AWS.Endpoint,
+		})
+		if err != nil {
+			return err
+		}
+		store = s3
+	case "local", "":
+		local, err := storage.NewLocalStore(cfg.Compliance.ArchiveDir, nil)
+		if err != nil {
+			return err
+		}
+		store = local
+	default:
+		return fmt.Errorf("unknown RETENTION_ARCHIVE_DRIVER %q", cfg.Compliance.ArchiveDriver)
+	}
+	archiver, err := retention.NewArchiver(store, cfg.Compliance.ArchiveFormat)
+	if err != nil {
+		return err
+	}
+
+	enforcer := retention.NewEnforcer(db, archiver)
+	enforcer.Register(settings.RetentionTargets()...)
+	enforcer.Register(collaboration.RetentionTargets()...)
+	enforcer.Register(documents.RetentionTargets()...)
+	enforcer.Register(compliance.RetentionTargets()...)
+	enforcer.Register(analytics.RetentionTargets()...)
+	svc.SetRetentionEnforcer(enforcer)
+	return nil
+}
+
 // runAllMigrations runs migrations for all modules
 func runAllMigrations(db *gorm.DB) error {
 	// Auto-migrate all models from all modules
 	err := db.AutoMigrate(
 		// Project models
 		&project.Project{},
+		&project.CreditCalculation{},
 
 		// Collaboration models
 		&collaboration.ProjectMember{},
@@ -360,8 +678,14 @@ func runAllMigrations(db *gorm.DB) error {
 		&compliance.PrivacyPreference{},
 		&compliance.ConsentRecord{},
 		&compliance.AuditLog{},
+		&compliance.AuditChainState{},
+		&compliance.AuditCheckpoint{},
 		&compliance.RetentionSchedule{},
 		&compliance.LegalHold{},
+		&compliance.DeletionCertificate{},
+
+		// Search models
+		&analytics.Event{},
 
 		// Settings models
 		&settings.UserProfile{},
@@ -376,6 +700,19 @@ func runAllMigrations(db *gorm.DB) error {
 		return err
 	}
 
+	// User accounts and session tokens (raw SQL; the auth repository uses database/sql)
+	if err := runAuthDDL(db); err != nil {
+		return err
+	}
+
+	// Append-only project lifecycle history
+	if err := runProjectLifecycleDDL(db); err != nil {
+		return err
+	}
+	if err := runProjectListDDL(db); err != nil {
+		return err
+	}
+
 	// Enable TimescaleDB extension and create hypertables
 	db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb")
 
@@ -409,13 +746,115 @@ func runAllMigrations(db *gorm.DB) error {
 	return nil
 }
 
+func runAuthDDL(db *gorm.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			email VARCHAR(255) NOT NULL UNIQUE,
+			password_hash VARCHAR(255) NOT NULL,
+			full_name VARCHAR(255) NOT NULL DEFAULT '',
+			role VARCHAR(50) NOT NULL DEFAULT 'user',
+			email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ NOT NULL,
+			revoked_at TIMESTAMPTZ,
+			replaced_by UUID,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens (user_id)",
+		`CREATE TABLE IF NOT EXISTS user_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			purpose VARCHAR(50) NOT NULL,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_user_tokens_user_purpose ON user_tokens (user_id, purpose)",
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("auth ddl failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func runProjectLifecycleDDL(db *gorm.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS project_status_history (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			from_status VARCHAR(50) NOT NULL,
+			to_status VARCHAR(50) NOT NULL,
+			performed_by VARCHAR(255),
+			comment TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_project_status_history_project ON project_status_history (project_id, created_at)",
+		`CREATE OR REPLACE FUNCTION project_status_history_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'project_status_history is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS trg_project_status_history_immutable ON project_status_history",
+		`CREATE TRIGGER trg_project_status_history_immutable
+			BEFORE UPDATE ON project_status_history
+			FOR EACH ROW EXECUTE FUNCTION project_status_history_immutable()`,
+		// Map the legacy free-form statuses onto lifecycle stages.
+		"UPDATE projects SET status = 'draft' WHERE status IS NULL OR status IN ('', 'pending')",
+		"UPDATE projects SET status = 'monitoring' WHERE status = 'active'",
+		"UPDATE projects SET status = 'closed' WHERE status = 'completed'",
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("project lifecycle ddl failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// runProjectListDDL adds the indexes behind project list filters, sorting
+// and full-text search. The search expression must match project.Filter.
+func runProjectListDDL(db *gorm.DB) error {
+	stmts := []string{
+		"CREATE INDEX IF NOT EXISTS idx_projects_created_at_id ON projects (created_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_type ON projects (type)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_start_date ON projects (start_date)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_area ON projects (area)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_carbon_credits ON projects (carbon_credits)",
+		`CREATE INDEX IF NOT EXISTS idx_projects_search ON projects USING GIN (
+			to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(type, '') || ' ' || coalesce(location, ''))
+		)`,
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("project list ddl failed: %w", err)
+		}
+	}
+	return nil
+}
+
 func runGeospatialDDL(db *gorm.DB) error {
 	stmts := []string{
 		"CREATE EXTENSION IF NOT EXISTS postgis",
 		"CREATE EXTENSION IF NOT EXISTS postgis_topology",
 		`CREATE TABLE IF NOT EXISTS project_geometries (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			project_id UUID NOT NULL UNIQUE REFERENCES projects(id) ON DELETE CASCADE,
+			project_id UUID NOT NULL UNIQUE REFERENCES projects(id) ON DELETE RESTRICT,
 			geometry GEOGRAPHY(GEOMETRY, 4326) NOT NULL,
 			centroid GEOGRAPHY(POINT, 4326) NOT NULL,
 			bounding_box GEOGRAPHY(POLYGON, 4326),
@@ -432,6 +871,16 @@ func runGeospatialDDL(db *gorm.DB) error {
 			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 		)`,
+		// Boundaries are removed only by an explicit project purge, never by
+		// cascade; upgrade databases created with ON DELETE CASCADE.
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'project_geometries_project_id_fkey' AND confdeltype = 'c') THEN
+				ALTER TABLE project_geometries DROP CONSTRAINT project_geometries_project_id_fkey;
+				ALTER TABLE project_geometries ADD CONSTRAINT project_geometries_project_id_fkey
+					FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE RESTRICT;
+			END IF;
+		END $$`,
 		"CREATE INDEX IF NOT EXISTS idx_project_geometries_geometry ON project_geometries USING GIST (geometry)",
 		"CREATE INDEX IF NOT EXISTS idx_project_geometries_centroid ON project_geometries USING GIST (centroid)",
 		`CREATE TABLE IF NOT EXISTS administrative_boundaries (
@@ -522,7 +971,7 @@ func corsMiddleware() gin.HandlerFunc {
 
 		c.Writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-User-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -1,18 +0,0 @@
-//go:build future
-// +build future
-
-package workers
-
-// ComplianceRequestWorker processes pending privacy data subject requests.
-//
-// Responsibilities:
-// - Poll for requests in "received" status
-// - Verify requester identity
-// - For export requests: discover data, generate export package, update request
-// - For deletion requests: check legal holds, execute deletion, update request
-// - Send notifications on completion or failure
-// - Enforce jurisdiction-specific response deadlines (e.g., GDPR 30 days)
-//
-// This worker should run continuously or on a short interval (e.g., every 5 minutes).
-//
-// Implementation pending: requires database connection and compliance service setup.
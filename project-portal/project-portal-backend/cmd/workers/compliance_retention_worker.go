@@ -1,17 +0,0 @@
-//go:build future
-// +build future
-
-package workers
-
-// ComplianceRetentionWorker runs on a schedule to enforce data retention policies.
-//
-// Responsibilities:
-// - Fetch due retention schedules from the database
-// - Check for legal holds before executing deletion/anonymization
-// - Execute the appropriate retention action (archive, anonymize, delete)
-// - Update schedule records with results
-// - Log all actions to the audit trail
-//
-// This worker should be run as a cron job (e.g., daily at 2 AM UTC).
-//
-// Implementation pending: requires database connection and compliance service setup.
@@ -1,24 +1,198 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-type Handler struct{}
+type Handler struct {
+	service *AuthService
+}
+
+func NewHandler(service *AuthService) *Handler {
+	return &Handler{service: service}
+}
 
 // Ping endpoint
 func (h *Handler) Ping(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "auth service alive!"})
 }
 
-// Dummy register endpoint
+// Register handles POST /auth/register
 func (h *Handler) Register(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "register endpoint works"})
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.Register(c.Request.Context(), &req)
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "registration successful, check your email to verify your account",
+		"user":    user,
+	})
 }
 
-// Dummy login endpoint
+// Login handles POST /auth/login
 func (h *Handler) Login(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "login endpoint works"})
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.Login(c.Request.Context(), &req, sessionMeta(c))
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Refresh handles POST /auth/refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.Refresh(c.Request.Context(), req.RefreshToken, sessionMeta(c))
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout handles POST /auth/logout
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll handles POST /auth/logout-all (requires a valid access token)
+func (h *Handler) LogoutAll(c *gin.Context) {
+	if err := h.service.LogoutAll(c.Request.Context(), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
+
+// Me handles GET /auth/me (requires a valid access token)
+func (h *Handler) Me(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// VerifyEmail handles POST /auth/verify-email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified", "user": user})
+}
+
+// ResendVerification handles POST /auth/verify-email/resend
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the account exists and is unverified, a new link has been sent"})
+}
+
+// ForgotPassword handles POST /auth/forgot-password
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the account exists, a reset link has been sent"})
+}
+
+// ResetPassword handles POST /auth/reset-password
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		writeAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated, please log in again"})
+}
+
+// JWKS handles GET /.well-known/jwks.json and GET /auth/jwks
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.service.Tokens().JWKS())
+}
+
+// --- helpers ---
+
+func sessionMeta(c *gin.Context) SessionMeta {
+	return SessionMeta{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// writeAuthError maps service errors to HTTP status codes.
+func writeAuthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUserExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
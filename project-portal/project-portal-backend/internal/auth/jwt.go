@@ -1,13 +1,19 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/config"
+
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("supersecretkey") // Replace with env var in production
-
 // Claims struct
 type Claims struct {
 	UserID string `json:"user_id"`
@@ -16,34 +22,218 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT generates a JWT token for a user
-func GenerateJWT(user *User) (string, error) {
+// TokenService is the single place access tokens are signed and verified.
+type TokenService struct {
+	keys       *KeySet
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenService builds a TokenService from the auth configuration.
+func NewTokenService(cfg config.AuthConfig) (*TokenService, error) {
+	keys, err := LoadKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenService{
+		keys:       keys,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}, nil
+}
+
+// LoadKeySet builds the key set described by cfg. When no key material is
+// configured an ephemeral key is generated, which is only suitable for local
+// development because tokens stop validating on restart.
+func LoadKeySet(cfg config.AuthConfig) (*KeySet, error) {
+	active, err := loadActiveKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []*SigningKey
+	for kid, value := range cfg.JWTPreviousKeys {
+		k, err := loadPreviousKey(cfg.JWTAlgorithm, kid, value)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, k)
+	}
+	return NewKeySet(active, cfg.RotationGrace, previous...)
+}
+
+func loadActiveKey(cfg config.AuthConfig) (*SigningKey, error) {
+	switch cfg.JWTAlgorithm {
+	case AlgHS256:
+		if cfg.JWTSecret == "" {
+			log.Println("⚠️  JWT_SECRET not set — using an ephemeral HS256 secret (tokens will not survive a restart)")
+			return GenerateKey(AlgHS256)
+		}
+		return NewHMACKey(cfg.JWTKeyID, []byte(cfg.JWTSecret))
+	case AlgRS256, AlgEdDSA:
+		if cfg.JWTPrivateKeyFile == "" {
+			log.Printf("⚠️  JWT_PRIVATE_KEY_FILE not set — using an ephemeral %s key (tokens will not survive a restart)", cfg.JWTAlgorithm)
+			return GenerateKey(cfg.JWTAlgorithm)
+		}
+		data, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWT_PRIVATE_KEY_FILE: %w", err)
+		}
+		key, err := ParsePrivateKeyPEM(cfg.JWTKeyID, data)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != cfg.JWTAlgorithm {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE holds a %s key but JWT_ALGORITHM is %s", key.Algorithm, cfg.JWTAlgorithm)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q (use HS256, RS256 or EdDSA)", cfg.JWTAlgorithm)
+	}
+}
+
+// loadPreviousKey interprets value as an HS256 secret when the configured
+// algorithm is HS256 and as a PEM file path otherwise.
+func loadPreviousKey(alg, kid, value string) (*SigningKey, error) {
+	if alg == AlgHS256 {
+		return NewHMACKey(kid, []byte(value))
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("reading previous key %q: %w", kid, err)
+	}
+	return ParsePrivateKeyPEM(kid, data)
+}
+
+// GenerateAccessToken signs an access token for user with the active key and
+// returns it with its expiry time.
+func (t *TokenService) GenerateAccessToken(user *User) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(t.accessTTL)
 	claims := &Claims{
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Subject:   user.ID,
+			Issuer:    t.issuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	if t.audience != "" {
+		claims.Audience = jwt.ClaimStrings{t.audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	key := t.keys.Active()
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signingKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
-// ValidateJWT parses and validates a JWT token string
-func ValidateJWT(tokenStr string) (*Claims, error) {
+// ValidateAccessToken parses and validates a token string. The kid header
+// selects the verification key and the token's alg must match that key.
+func (t *TokenService) ValidateAccessToken(tokenStr string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}),
+		jwt.WithExpirationRequired(),
+	}
+	if t.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(t.issuer))
+	}
+	if t.audience != "" {
+		opts = append(opts, jwt.WithAudience(t.audience))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token has no kid header")
+		}
+		key, ok := t.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown or expired signing key %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("token alg %q does not match key %q", token.Method.Alg(), kid)
+		}
+		return key.verificationKey(), nil
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
+	return claims, nil
+}
+
+// JWKS returns the public verification keys for offline verifiers.
+func (t *TokenService) JWKS() JWKS {
+	return t.keys.JWKS()
+}
+
+// RefreshTTL is the lifetime of refresh tokens issued alongside access tokens.
+func (t *TokenService) RefreshTTL() time.Duration {
+	return t.refreshTTL
+}
+
+const (
+	// keySyncInterval is how often each instance reloads the stored keys.
+	keySyncInterval = time.Minute
+	// keyPublishLead is how long a new key is stored before it signs, so
+	// every instance has loaded it first.
+	keyPublishLead = 3 * keySyncInterval
+)
 
-	return nil, err
+// StartRotation rotates the signing key through store every interval until
+// ctx is done. Every instance reloads the stored keys each minute and signs
+// with the newest active one, so replicas share the same keys; one of them
+// generates each new key.
+func (t *TokenService) StartRotation(ctx context.Context, store *KeyStore, interval time.Duration) {
+	if interval <= 0 || store == nil {
+		return
+	}
+	sync := func() {
+		key, err := store.Rotate(ctx, t.keys.Active().Algorithm, interval, keyPublishLead)
+		if err != nil {
+			log.Printf("WARNING: JWT key rotation failed: %v", err)
+		} else if key != nil {
+			log.Printf("🔑 JWT signing key published (kid=%s, signing from %s)", key.ID, key.NotBefore.Format(time.RFC3339))
+		}
+		keys, err := store.Load(ctx, t.keys.grace)
+		if err != nil {
+			log.Printf("WARNING: loading JWT signing keys failed: %v", err)
+			return
+		}
+		t.keys.Sync(keys)
+		if err := store.Purge(ctx, t.keys.grace); err != nil {
+			log.Printf("WARNING: purging JWT signing keys failed: %v", err)
+		}
+	}
+	sync()
+	go func() {
+		ticker := time.NewTicker(keySyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sync()
+			}
+		}
+	}()
 }
@@ -0,0 +1,322 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minHMACSecretLen is the shortest HS256 secret we accept (256 bits).
+const minHMACSecretLen = 32
+
+// SigningKey is a single JWT key identified by its kid. HS256 keys hold a shared
+// secret; RS256 and EdDSA keys hold a private key whose public half is published
+// through JWKS.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	// NotBefore is when a key from a KeyStore starts signing. It is
+	// accepted for verification as soon as it is loaded.
+	NotBefore time.Time
+	RetiredAt *time.Time
+
+	secret  []byte
+	private crypto.Signer
+}
+
+// NewHMACKey creates an HS256 key from a shared secret.
+func NewHMACKey(kid string, secret []byte) (*SigningKey, error) {
+	if len(secret) < minHMACSecretLen {
+		return nil, fmt.Errorf("HS256 secret for key %q must be at least %d bytes", kid, minHMACSecretLen)
+	}
+	return &SigningKey{ID: kid, Algorithm: AlgHS256, CreatedAt: time.Now().UTC(), secret: secret}, nil
+}
+
+// ParsePrivateKeyPEM loads an RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8)
+// private key. The algorithm is inferred from the key type.
+func ParsePrivateKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block found", kid)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+
+	key := &SigningKey{ID: kid, CreatedAt: time.Now().UTC()}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %q: RSA keys must be at least 2048 bits", kid)
+		}
+		key.Algorithm = AlgRS256
+		key.private = k
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgEdDSA
+		key.private = k
+	default:
+		return nil, fmt.Errorf("key %q: unsupported private key type %T", kid, parsed)
+	}
+	return key, nil
+}
+
+// GenerateKey creates a fresh key for the given algorithm. The kid is derived
+// from the key material so that it is stable for the lifetime of the key.
+func GenerateKey(alg string) (*SigningKey, error) {
+	key := &SigningKey{Algorithm: alg, CreatedAt: time.Now().UTC()}
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generating HS256 secret: %w", err)
+		}
+		key.secret = secret
+	case AlgRS256:
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("generating RSA key: %w", err)
+		}
+		key.private = k
+	case AlgEdDSA:
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generating Ed25519 key: %w", err)
+		}
+		key.private = k
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	key.ID = key.thumbprint()
+	return key, nil
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+func (k *SigningKey) signingKey() any {
+	if k.Algorithm == AlgHS256 {
+		return k.secret
+	}
+	return k.private
+}
+
+func (k *SigningKey) verificationKey() any {
+	if k.Algorithm == AlgHS256 {
+		return k.secret
+	}
+	return k.private.Public()
+}
+
+// thumbprint returns a short, URL-safe identifier derived from the key material.
+func (k *SigningKey) thumbprint() string {
+	var material []byte
+	switch pub := k.verificationKey().(type) {
+	case []byte:
+		material = pub
+	case *rsa.PublicKey:
+		material = pub.N.Bytes()
+	case ed25519.PublicKey:
+		material = pub
+	}
+	sum := sha256.Sum256(material)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// KeySet holds the active signing key and any retired keys that are still
+// accepted for verification during the rotation grace window.
+type KeySet struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+	grace  time.Duration
+}
+
+// NewKeySet creates a key set signing with active. Any previous keys are
+// retired immediately and remain valid for verification for grace.
+func NewKeySet(active *SigningKey, grace time.Duration, previous ...*SigningKey) (*KeySet, error) {
+	if active == nil {
+		return nil, errors.New("key set requires an active signing key")
+	}
+	ks := &KeySet{active: active, keys: map[string]*SigningKey{active.ID: active}, grace: grace}
+	now := time.Now().UTC()
+	for _, k := range previous {
+		if _, dup := ks.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		retired := now
+		k.RetiredAt = &retired
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+// Active returns the key used to sign new tokens.
+func (ks *KeySet) Active() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+// Lookup returns the key for kid if it is active or still inside its grace window.
+func (ks *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	if k.RetiredAt != nil && time.Since(*k.RetiredAt) > ks.grace {
+		return nil, false
+	}
+	return k, true
+}
+
+// Rotate makes next the active key. The previous active key is retired and
+// keeps verifying tokens until the grace window elapses.
+func (ks *KeySet) Rotate(next *SigningKey) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, dup := ks.keys[next.ID]; dup {
+		return fmt.Errorf("duplicate key id %q", next.ID)
+	}
+	now := time.Now().UTC()
+	ks.active.RetiredAt = &now
+	ks.keys[next.ID] = next
+	ks.active = next
+	ks.pruneLocked(now)
+	return nil
+}
+
+// Sync adopts the keys loaded from a KeyStore. The newest of them whose
+// NotBefore has passed becomes active, retiring the key it replaces; until
+// one has, the configured key keeps signing. Keys not yet active already
+// verify, so tokens from instances that switched first are accepted.
+func (ks *KeySet) Sync(shared []*SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	now := time.Now().UTC()
+	var next *SigningKey
+	for _, k := range shared {
+		if cur, ok := ks.keys[k.ID]; ok {
+			cur.RetiredAt = k.RetiredAt
+			k = cur
+		} else {
+			ks.keys[k.ID] = k
+		}
+		if !k.NotBefore.After(now) && (next == nil || k.NotBefore.After(next.NotBefore)) {
+			next = k
+		}
+	}
+	if next != nil && next != ks.active {
+		if ks.active.RetiredAt == nil {
+			retired := next.NotBefore
+			ks.active.RetiredAt = &retired
+		}
+		ks.active = next
+	}
+	ks.pruneLocked(now)
+}
+
+// Prune drops retired keys whose grace window has elapsed.
+func (ks *KeySet) Prune() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.pruneLocked(time.Now().UTC())
+}
+
+func (ks *KeySet) pruneLocked(now time.Time) {
+	for id, k := range ks.keys {
+		if k.RetiredAt != nil && now.Sub(*k.RetiredAt) > ks.grace {
+			delete(ks.keys, id)
+		}
+	}
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that verifiers should currently accept.
+// Symmetric HS256 keys are never published.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	out := JWKS{Keys: []JWK{}}
+	now := time.Now().UTC()
+	for _, k := range ks.keys {
+		if k.Algorithm == AlgHS256 {
+			continue
+		}
+		if k.RetiredAt != nil && now.Sub(*k.RetiredAt) > ks.grace {
+			continue
+		}
+		jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+		switch pub := k.verificationKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		out.Keys = append(out.Keys, jwk)
+	}
+	sort.Slice(out.Keys, func(i, j int) bool { return out.Keys[i].Kid < out.Keys[j].Kid })
+	return out
+}
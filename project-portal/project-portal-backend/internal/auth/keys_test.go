@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTokenService(t *testing.T, alg string, grace time.Duration) *TokenService {
+	t.Helper()
+	key, err := GenerateKey(alg)
+	if err != nil {
+		t.Fatalf("GenerateKey(%s) error: %v", alg, err)
+	}
+	keys, err := NewKeySet(key, grace)
+	if err != nil {
+		t.Fatalf("NewKeySet error: %v", err)
+	}
+	return &TokenService{keys: keys, issuer: "test", audience: "test-aud", accessTTL: time.Minute}
+}
+
+func TestTokenServiceRoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgHS256, AlgRS256, AlgEdDSA} {
+		ts := newTestTokenService(t, alg, time.Hour)
+		token, _, err := ts.GenerateAccessToken(&User{ID: "u-1", Email: "a@b.c", Role: RoleUser})
+		if err != nil {
+			t.Fatalf("%s: GenerateAccessToken error: %v", alg, err)
+		}
+		claims, err := ts.ValidateAccessToken(token)
+		if err != nil {
+			t.Fatalf("%s: ValidateAccessToken error: %v", alg, err)
+		}
+		if claims.UserID != "u-1" || claims.Subject != "u-1" {
+			t.Fatalf("%s: unexpected claims: %+v", alg, claims)
+		}
+	}
+}
+
+func TestKeySetRotationGrace(t *testing.T) {
+	ts := newTestTokenService(t, AlgEdDSA, time.Hour)
+	oldToken, _, err := ts.GenerateAccessToken(&User{ID: "u-1"})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken error: %v", err)
+	}
+	oldKID := ts.keys.Active().ID
+
+	next, err := GenerateKey(AlgEdDSA)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %v", err)
+	}
+	if err := ts.keys.Rotate(next); err != nil {
+		t.Fatalf("Rotate error: %v", err)
+	}
+
+	if _, err := ts.ValidateAccessToken(oldToken); err != nil {
+		t.Fatalf("token signed before rotation should validate within grace: %v", err)
+	}
+	if got := len(ts.JWKS().Keys); got != 2 {
+		t.Fatalf("expected 2 keys in JWKS during grace, got %d", got)
+	}
+
+	// Push the retired key past its grace window.
+	expired := time.Now().Add(-2 * time.Hour)
+	k, _ := ts.keys.Lookup(oldKID)
+	k.RetiredAt = &expired
+	if _, err := ts.ValidateAccessToken(oldToken); err == nil {
+		t.Fatal("token signed with an expired key should be rejected")
+	}
+	ts.keys.Prune()
+	if got := len(ts.JWKS().Keys); got != 1 {
+		t.Fatalf("expected 1 key in JWKS after prune, got %d", got)
+	}
+}
+
+func TestJWKSExcludesSymmetricKeys(t *testing.T) {
+	ts := newTestTokenService(t, AlgHS256, time.Hour)
+	if got := len(ts.JWKS().Keys); got != 0 {
+		t.Fatalf("HS256 keys must never be published, got %d", got)
+	}
+}
+
+func TestValidateRejectsForeignIssuer(t *testing.T) {
+	ts := newTestTokenService(t, AlgHS256, time.Hour)
+	token, _, err := ts.GenerateAccessToken(&User{ID: "u-1"})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken error: %v", err)
+	}
+	other := &TokenService{keys: ts.keys, issuer: "someone-else", accessTTL: time.Minute}
+	if _, err := other.ValidateAccessToken(token); err == nil {
+		t.Fatal("expected issuer mismatch to be rejected")
+	}
+}
+
+func TestKeySetSyncAdoptsSharedKeys(t *testing.T) {
+	ts := newTestTokenService(t, AlgHS256, time.Hour)
+	configured := ts.keys.Active()
+
+	current, _ := GenerateKey(AlgHS256)
+	current.NotBefore = time.Now().Add(-time.Minute)
+	pending, _ := GenerateKey(AlgHS256)
+	pending.NotBefore = time.Now().Add(time.Minute)
+	ts.keys.Sync([]*SigningKey{current, pending})
+
+	if ts.keys.Active().ID != current.ID {
+		t.Fatalf("active key = %s, want the newest key already signing", ts.keys.Active().ID)
+	}
+	if configured.RetiredAt == nil || !configured.RetiredAt.Equal(current.NotBefore) {
+		t.Fatalf("configured key should retire when the shared key starts signing: %v", configured.RetiredAt)
+	}
+
+	// Another instance may already sign with the pending key.
+	other := &TokenService{keys: &KeySet{active: pending, keys: map[string]*SigningKey{pending.ID: pending}}, issuer: "test", audience: "test-aud", accessTTL: time.Minute}
+	token, _, err := other.GenerateAccessToken(&User{ID: "u-1"})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken error: %v", err)
+	}
+	if _, err := ts.ValidateAccessToken(token); err != nil {
+		t.Fatalf("token signed with a pending shared key should validate: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+)
+
+// keyRotationLock serializes rotations across instances.
+const keyRotationLock = 0x6a77746b657973
+
+// KeyStore keeps generated signing keys in the database, encrypted with a
+// vault, so every instance signs and verifies with the same keys. A new key
+// is stored ahead of its NotBefore time so that instances load it, and can
+// verify its tokens, before any instance signs with it.
+type KeyStore struct {
+	db    *sql.DB
+	vault *encryption.Vault
+}
+
+// NewKeyStore creates a KeyStore.
+func NewKeyStore(db *sql.DB, vault *encryption.Vault) *KeyStore {
+	return &KeyStore{db: db, vault: vault}
+}
+
+// Load returns the stored keys that have not been retired for longer than
+// grace, oldest first.
+func (s *KeyStore) Load(ctx context.Context, grace time.Duration) ([]*SigningKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT kid, algorithm, key_material, not_before, retired_at, created_at
+		FROM jwt_signing_keys
+		WHERE retired_at IS NULL OR retired_at > $1
+		ORDER BY not_before`,
+		time.Now().UTC().Add(-grace),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*SigningKey
+	for rows.Next() {
+		var (
+			kid, alg  string
+			material  []byte
+			notBefore time.Time
+			retiredAt *time.Time
+			createdAt time.Time
+		)
+		if err := rows.Scan(&kid, &alg, &material, &notBefore, &retiredAt, &createdAt); err != nil {
+			return nil, err
+		}
+		key, err := s.openKey(kid, alg, material)
+		if err != nil {
+			return nil, err
+		}
+		key.NotBefore, key.RetiredAt, key.CreatedAt = notBefore.UTC(), retiredAt, createdAt.UTC()
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Rotate stores a new alg key when the newest stored key is older than
+// interval. The new key signs from lead from now, when the key before it
+// retires. It returns the new key, or nil when no rotation was due.
+func (s *KeyStore) Rotate(ctx context.Context, alg string, interval, lead time.Duration) (*SigningKey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, keyRotationLock); err != nil {
+		return nil, err
+	}
+	var newest string
+	var notBefore time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT kid, not_before FROM jwt_signing_keys ORDER BY not_before DESC LIMIT 1`,
+	).Scan(&newest, &notBefore)
+	now := time.Now().UTC()
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	case notBefore.After(now.Add(-interval)):
+		return nil, nil
+	}
+
+	key, err := GenerateKey(alg)
+	if err != nil {
+		return nil, err
+	}
+	key.NotBefore = now.Add(lead)
+	material, err := s.sealKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO jwt_signing_keys (kid, algorithm, key_material, not_before) VALUES ($1, $2, $3, $4)`,
+		key.ID, key.Algorithm, material, key.NotBefore,
+	); err != nil {
+		return nil, err
+	}
+	if newest != "" {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE jwt_signing_keys SET retired_at = $1 WHERE kid = $2`,
+			key.NotBefore, newest,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Purge deletes keys retired for longer than grace.
+func (s *KeyStore) Purge(ctx context.Context, grace time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM jwt_signing_keys WHERE retired_at < $1`,
+		time.Now().UTC().Add(-grace),
+	)
+	return err
+}
+
+// sealKey encrypts a key's secret or PKCS#8 private key.
+func (s *KeyStore) sealKey(k *SigningKey) ([]byte, error) {
+	raw := k.secret
+	if k.Algorithm != AlgHS256 {
+		var err error
+		if raw, err = x509.MarshalPKCS8PrivateKey(k.private); err != nil {
+			return nil, fmt.Errorf("encoding key %q: %w", k.ID, err)
+		}
+	}
+	return s.vault.Encrypt(raw)
+}
+
+func (s *KeyStore) openKey(kid, alg string, material []byte) (*SigningKey, error) {
+	raw, err := s.vault.Decrypt(material)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting key %q: %w", kid, err)
+	}
+	key := &SigningKey{ID: kid, Algorithm: alg}
+	if alg == AlgHS256 {
+		key.secret = raw
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.private = k
+	case ed25519.PrivateKey:
+		key.private = k
+	default:
+		return nil, fmt.Errorf("key %q: unsupported private key type %T", kid, parsed)
+	}
+	return key, nil
+}
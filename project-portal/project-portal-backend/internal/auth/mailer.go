@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/mail"
+)
+
+// Mailer delivers account emails. Implementations receive the raw single-use
+// token and are responsible for turning it into a link.
+type Mailer interface {
+	SendVerificationEmail(ctx context.Context, user *User, token string) error
+	SendPasswordResetEmail(ctx context.Context, user *User, token string) error
+}
+
+// LogMailer records that an account email was due without sending it. It is
+// the fallback when no mail transport is configured. Tokens are never
+// logged, since anyone reading the log could use them.
+type LogMailer struct{}
+
+func (LogMailer) SendVerificationEmail(_ context.Context, user *User, _ string) error {
+	log.Printf("📧 [auth] email verification for %s not sent: no mail sender configured", user.Email)
+	return nil
+}
+
+func (LogMailer) SendPasswordResetEmail(_ context.Context, user *User, _ string) error {
+	log.Printf("📧 [auth] password reset for %s not sent: no mail sender configured", user.Email)
+	return nil
+}
+
+// SenderMailer delivers account emails through a mail.Sender, linking to
+// the frontend's verification and reset pages under baseURL.
+type SenderMailer struct {
+	sender  mail.Sender
+	baseURL string
+}
+
+// NewSenderMailer creates a SenderMailer. baseURL is the frontend origin
+// without a trailing slash.
+func NewSenderMailer(sender mail.Sender, baseURL string) *SenderMailer {
+	return &SenderMailer{sender: sender, baseURL: baseURL}
+}
+
+func (m *SenderMailer) SendVerificationEmail(ctx context.Context, user *User, token string) error {
+	link := m.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return m.sender.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your CarbonScribe email address",
+		Text:    fmt.Sprintf("Confirm your email address here:\n%s\n", link),
+		HTML:    fmt.Sprintf("<p><a href=\"%s\">Confirm your email address</a></p>", html.EscapeString(link)),
+	})
+}
+
+func (m *SenderMailer) SendPasswordResetEmail(ctx context.Context, user *User, token string) error {
+	link := m.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return m.sender.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your CarbonScribe password",
+		Text: fmt.Sprintf("Choose a new password here:\n%s\n\n"+
+			"If you did not ask to reset your password, ignore this email.\n", link),
+		HTML: fmt.Sprintf("<p><a href=\"%s\">Choose a new password</a></p>"+
+			"<p>If you did not ask to reset your password, ignore this email.</p>", html.EscapeString(link)),
+	})
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // AuthMiddleware validates JWT tokens in the Authorization header
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tokens *TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,7 +27,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenStr := parts[1]
 
-		claims, err := ValidateJWT(tokenStr)
+		claims, err := tokens.ValidateAccessToken(tokenStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			c.Abort()
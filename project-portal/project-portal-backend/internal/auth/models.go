@@ -15,3 +15,92 @@ type User struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
+
+// Default role assigned to self-registered accounts.
+const RoleUser = "user"
+
+// Purposes for single-use tokens stored in user_tokens.
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+// RefreshToken is a long-lived, rotating session credential. Only the SHA-256
+// hash of the opaque token is persisted.
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *string    `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// UserToken is a single-use token for email verification or password reset.
+type UserToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RegisterRequest is the JSON body for POST /auth/register.
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	FullName string `json:"full_name" binding:"required"`
+}
+
+// LoginRequest is the JSON body for POST /auth/login.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest is the JSON body for POST /auth/refresh and POST /auth/logout.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// VerifyEmailRequest is the JSON body for POST /auth/verify-email.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailRequest is the JSON body for endpoints that only take an email address.
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest is the JSON body for POST /auth/reset-password.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// TokenPair is returned after a successful login or refresh.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// AuthResponse wraps the authenticated user with a fresh token pair.
+type AuthResponse struct {
+	User   *User      `json:"user"`
+	Tokens *TokenPair `json:"tokens"`
+}
+
+// SessionMeta carries request context recorded alongside a refresh token.
+type SessionMeta struct {
+	IPAddress string
+	UserAgent string
+}
@@ -1,6 +1,14 @@
 package auth
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a user or token row does not exist.
+var ErrNotFound = errors.New("not found")
 
 type Repository struct {
 	DB *sql.DB
@@ -10,28 +18,29 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{DB: db}
 }
 
-func (r *Repository) CreateUser(user *User) error {
+// ─── Users ────────────────────────────────────────────────────────────────────
+
+func (r *Repository) CreateUser(ctx context.Context, user *User) error {
 	query := `
 		INSERT INTO users (email, password_hash, full_name, role)
 		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
+		RETURNING id, email_verified, is_active, created_at, updated_at
 	`
-	return r.DB.QueryRow(
+	return r.DB.QueryRowContext(
+		ctx,
 		query,
 		user.Email,
 		user.PasswordHash,
 		user.FullName,
 		user.Role,
-	).Scan(&user.ID, &user.CreatedAt)
+	).Scan(&user.ID, &user.EmailVerified, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
 }
 
-func (r *Repository) GetUserByEmail(email string) (*User, error) {
+const userColumns = `id, email, password_hash, full_name, role, email_verified, is_active, created_at, updated_at`
+
+func scanUser(row *sql.Row) (*User, error) {
 	user := &User{}
-	query := `
-		SELECT id, email, password_hash, full_name, role, email_verified, is_active, created_at
-		FROM users WHERE email = $1
-	`
-	err := r.DB.QueryRow(query, email).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -40,9 +49,204 @@ func (r *Repository) GetUserByEmail(email string) (*User, error) {
 		&user.EmailVerified,
 		&user.IsActive,
 		&user.CreatedAt,
+		&user.UpdatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return scanUser(r.DB.QueryRowContext(ctx, query, email))
+}
+
+func (r *Repository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return scanUser(r.DB.QueryRowContext(ctx, query, id))
+}
+
+// AccountVerified reports whether the user exists, is active and has
+// verified their email address. Privacy requests use it to confirm a
+// requester's identity.
+func (r *Repository) AccountVerified(ctx context.Context, userID string) (bool, error) {
+	user, err := r.GetUserByID(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.IsActive && user.EmailVerified, nil
+}
+
+func (r *Repository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		passwordHash, userID,
+	)
+	return err
+}
+
+func (r *Repository) MarkEmailVerified(ctx context.Context, userID string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE users SET email_verified = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		userID,
+	)
+	return err
+}
+
+// ─── Refresh tokens ───────────────────────────────────────────────────────────
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.UserAgent,
+		token.IPAddress,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	token := &RefreshToken{}
+	query := `
+		SELECT id, user_id, token_hash, user_agent, ip_address, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = $1
+	`
+	err := r.DB.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.UserAgent,
+		&token.IPAddress,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.ReplacedBy,
+		&token.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken marks a single refresh token as revoked. It reports
+// whether this call revoked it, so of two concurrent callers only one sees
+// true.
+func (r *Repository) RevokeRefreshToken(ctx context.Context, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`,
+		time.Now().UTC(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// SetRefreshTokenReplacement records the token issued in place of a rotated
+// one.
+func (r *Repository) SetRefreshTokenReplacement(ctx context.Context, id, replacedBy string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE refresh_tokens SET replaced_by = $1 WHERE id = $2`,
+		replacedBy, id,
+	)
+	return err
+}
+
+// RevokeAllRefreshTokens revokes every active refresh token a user holds.
+func (r *Repository) RevokeAllRefreshTokens(ctx context.Context, userID string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`,
+		time.Now().UTC(), userID,
+	)
+	return err
+}
+
+// ─── Single-use tokens ────────────────────────────────────────────────────────
+
+func (r *Repository) CreateUserToken(ctx context.Context, token *UserToken) error {
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *Repository) GetUserToken(ctx context.Context, purpose, tokenHash string) (*UserToken, error) {
+	token := &UserToken{}
+	query := `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens WHERE purpose = $1 AND token_hash = $2
+	`
+	err := r.DB.QueryRowContext(ctx, query, purpose, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ConsumeUserToken marks a token as used. It reports false when the token was
+// already consumed by a concurrent request.
+func (r *Repository) ConsumeUserToken(ctx context.Context, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx,
+		`UPDATE user_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL`,
+		time.Now().UTC(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// InvalidateUserTokens marks all outstanding tokens of a purpose as used, so
+// only the most recently issued link works.
+func (r *Repository) InvalidateUserTokens(ctx context.Context, userID, purpose string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`,
+		time.Now().UTC(), userID, purpose,
+	)
+	return err
+}
@@ -3,11 +3,27 @@ package auth
 import "github.com/gin-gonic/gin"
 
 func RegisterRoutes(r *gin.Engine, handler *Handler) {
+	r.GET("/.well-known/jwks.json", handler.JWKS)
+
 	authGroup := r.Group("/auth")
 	{
 		authGroup.GET("/ping", handler.Ping)
 		authGroup.POST("/register", handler.Register)
 		authGroup.POST("/login", handler.Login)
+		authGroup.POST("/refresh", handler.Refresh)
+		authGroup.POST("/logout", handler.Logout)
+		authGroup.POST("/verify-email", handler.VerifyEmail)
+		authGroup.POST("/verify-email/resend", handler.ResendVerification)
+		authGroup.POST("/forgot-password", handler.ForgotPassword)
+		authGroup.POST("/reset-password", handler.ResetPassword)
+
+		// Endpoints that require a valid access token
+		requireAuth := AuthMiddleware(handler.service.Tokens())
+		authGroup.GET("/me", requireAuth, handler.Me)
+		authGroup.POST("/logout-all", requireAuth, handler.LogoutAll)
+
+		// Public verification keys for other CarbonScribe services
+		authGroup.GET("/jwks", handler.JWKS)
 
 		// Submission endpoints
 		authGroup.POST("/submit", SubmitQuest)
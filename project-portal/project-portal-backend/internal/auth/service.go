@@ -1,32 +1,351 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/utils"
+)
+
+const (
+	verificationTokenTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrInvalidToken       = errors.New("invalid or expired token")
 )
 
 type AuthService struct {
-	users map[string]string // map[email]password
+	repo   *Repository
+	tokens *TokenService
+	mailer Mailer
+}
+
+// NewAuthService creates an AuthService. A nil mailer falls back to LogMailer.
+func NewAuthService(repo *Repository, tokens *TokenService, mailer Mailer) *AuthService {
+	if mailer == nil {
+		mailer = LogMailer{}
+	}
+	return &AuthService{repo: repo, tokens: tokens, mailer: mailer}
+}
+
+// Tokens returns the token service used to sign and verify access tokens.
+func (s *AuthService) Tokens() *TokenService {
+	return s.tokens
 }
 
-func NewAuthService() *AuthService {
-	return &AuthService{users: make(map[string]string)}
+// Register creates a new account with a bcrypt-hashed password and sends an
+// email verification link.
+func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*User, error) {
+	email := normalizeEmail(req.Email)
+	if _, err := s.repo.GetUserByEmail(ctx, email); err == nil {
+		return nil, ErrUserExists
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("hashing password: %w", err)
+	}
+
+	user := &User{
+		Email:        email,
+		PasswordHash: hash,
+		FullName:     strings.TrimSpace(req.FullName),
+		Role:         RoleUser,
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("creating user: %w", err)
+	}
+
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("WARNING: failed to send verification email to %s: %v", user.Email, err)
+	}
+	return user, nil
 }
 
-func (s *AuthService) Register(email, password string) error {
-	if _, exists := s.users[email]; exists {
-		return errors.New("user already exists")
+// Login checks credentials and issues a new access/refresh token pair.
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest, meta SessionMeta) (*AuthResponse, error) {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("looking up user: %w", err)
 	}
-	s.users[email] = password
-	return nil
+	if err := utils.CheckPassword(req.Password, user.PasswordHash); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	tokens, err := s.issueTokens(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResponse{User: user, Tokens: tokens}, nil
 }
 
-func (s *AuthService) Login(email, password string) error {
-	pass, exists := s.users[email]
-	if !exists {
-		return errors.New("user not found")
+// Refresh rotates a refresh token: the presented token is revoked and a new pair
+// is issued. Presenting an already-revoked token is treated as theft and revokes
+// every session the user holds.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta SessionMeta) (*AuthResponse, error) {
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("looking up refresh token: %w", err)
+	}
+	if stored.RevokedAt != nil {
+		return nil, s.refreshTokenReused(ctx, stored.UserID)
 	}
-	if pass != password {
-		return errors.New("invalid password")
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
 	}
-	return nil
+
+	user, err := s.repo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	// Revoke before issuing: a concurrent refresh with the same token that
+	// loses the race is treated as reuse.
+	revoked, err := s.repo.RevokeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("revoking refresh token: %w", err)
+	}
+	if !revoked {
+		return nil, s.refreshTokenReused(ctx, stored.UserID)
+	}
+
+	tokens, newID, err := s.issueTokensWithID(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetRefreshTokenReplacement(ctx, stored.ID, newID); err != nil {
+		log.Printf("WARNING: failed to record replacement for refresh token %s: %v", stored.ID, err)
+	}
+	return &AuthResponse{User: user, Tokens: tokens}, nil
+}
+
+// refreshTokenReused revokes every session of a user whose already-rotated
+// refresh token was presented again, since it may have been stolen.
+func (s *AuthService) refreshTokenReused(ctx context.Context, userID string) error {
+	if err := s.repo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		log.Printf("WARNING: failed to revoke sessions for user %s after token reuse: %v", userID, err)
+	}
+	return ErrInvalidToken
+}
+
+// Logout revokes the presented refresh token. Unknown tokens are ignored so the
+// endpoint is idempotent.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("looking up refresh token: %w", err)
+	}
+	_, err = s.repo.RevokeRefreshToken(ctx, stored.ID)
+	return err
+}
+
+// LogoutAll revokes every refresh token held by the user.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	return s.repo.RevokeAllRefreshTokens(ctx, userID)
+}
+
+// GetUser returns a user by ID.
+func (s *AuthService) GetUser(ctx context.Context, userID string) (*User, error) {
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// VerifyEmail consumes an email verification token and marks the address verified.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	userToken, err := s.consumeToken(ctx, TokenPurposeEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.MarkEmailVerified(ctx, userToken.UserID); err != nil {
+		return nil, fmt.Errorf("marking email verified: %w", err)
+	}
+	return s.repo.GetUserByID(ctx, userToken.UserID)
+}
+
+// ResendVerification issues a fresh verification link. It does not reveal
+// whether the address is registered.
+func (s *AuthService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return s.sendVerification(ctx, user)
+}
+
+// RequestPasswordReset emails a password reset link. It does not reveal whether
+// the address is registered.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	raw, err := s.createUserToken(ctx, user.ID, TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.SendPasswordResetEmail(ctx, user, raw)
+}
+
+// ResetPassword consumes a reset token, stores the new password hash and revokes
+// all existing sessions.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userToken, err := s.consumeToken(ctx, TokenPurposePasswordReset, token)
+	if err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, userToken.UserID, hash); err != nil {
+		return fmt.Errorf("updating password: %w", err)
+	}
+	return s.repo.RevokeAllRefreshTokens(ctx, userToken.UserID)
+}
+
+// ─── helpers ──────────────────────────────────────────────────────────────────
+
+func (s *AuthService) issueTokens(ctx context.Context, user *User, meta SessionMeta) (*TokenPair, error) {
+	pair, _, err := s.issueTokensWithID(ctx, user, meta)
+	return pair, err
+}
+
+func (s *AuthService) issueTokensWithID(ctx context.Context, user *User, meta SessionMeta) (*TokenPair, string, error) {
+	access, accessExpiresAt, err := s.tokens.GenerateAccessToken(user)
+	if err != nil {
+		return nil, "", fmt.Errorf("signing access token: %w", err)
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	refresh := &RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		UserAgent: meta.UserAgent,
+		IPAddress: meta.IPAddress,
+		ExpiresAt: time.Now().UTC().Add(s.tokens.RefreshTTL()),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, refresh); err != nil {
+		return nil, "", fmt.Errorf("storing refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     raw,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExpiresAt,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, refresh.ID, nil
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, user *User) error {
+	raw, err := s.createUserToken(ctx, user.ID, TokenPurposeEmailVerification, verificationTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.SendVerificationEmail(ctx, user, raw)
+}
+
+// createUserToken invalidates any outstanding token of the same purpose and
+// stores a new one, returning the raw value to send to the user.
+func (s *AuthService) createUserToken(ctx context.Context, userID, purpose string, ttl time.Duration) (string, error) {
+	if err := s.repo.InvalidateUserTokens(ctx, userID, purpose); err != nil {
+		return "", fmt.Errorf("invalidating previous tokens: %w", err)
+	}
+	raw, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateUserToken(ctx, &UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}); err != nil {
+		return "", fmt.Errorf("storing %s token: %w", purpose, err)
+	}
+	return raw, nil
+}
+
+func (s *AuthService) consumeToken(ctx context.Context, purpose, raw string) (*UserToken, error) {
+	userToken, err := s.repo.GetUserToken(ctx, purpose, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("looking up token: %w", err)
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	ok, err := s.repo.ConsumeUserToken(ctx, userToken.ID)
+	if err != nil {
+		return nil, fmt.Errorf("consuming token: %w", err)
+	}
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return userToken, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// randomToken returns 32 bytes of randomness encoded as URL-safe base64.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
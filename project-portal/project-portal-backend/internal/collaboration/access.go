@@ -0,0 +1,68 @@
+package collaboration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"gorm.io/gorm"
+)
+
+// ProjectAccess resolves a user's role and permissions on a project from their
+// membership. It implements middleware.Authorizer.
+func (s *Service) ProjectAccess(ctx context.Context, projectID, userID string) (*middleware.ProjectAccess, error) {
+	member, err := s.repo.GetMember(ctx, projectID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, middleware.ErrNotMember
+		}
+		return nil, err
+	}
+	return middleware.NewProjectAccess(projectID, userID, member.Role, member.Permissions), nil
+}
+
+// AddOwner makes userID the Owner of a newly created project.
+func (s *Service) AddOwner(ctx context.Context, projectID, userID string) error {
+	return addOwner(ctx, s.repo, projectID, userID)
+}
+
+// AddOwnerTx is AddOwner inside the caller's transaction, used when projects
+// are created in bulk.
+func (s *Service) AddOwnerTx(ctx context.Context, tx *gorm.DB, projectID, userID string) error {
+	return addOwner(ctx, NewRepository(tx), projectID, userID)
+}
+
+func addOwner(ctx context.Context, repo Repository, projectID, userID string) error {
+	now := time.Now()
+	if err := repo.AddMember(ctx, &ProjectMember{
+		ProjectID: projectID,
+		UserID:    userID,
+		Role:      RoleOwner,
+		JoinedAt:  now,
+		UpdatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	_ = repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    userID,
+		Type:      "system",
+		Action:    "member_added",
+		Metadata:  map[string]any{"role": RoleOwner},
+		CreatedAt: now,
+	})
+	return nil
+}
+
+// ListUserProjectIDs returns the IDs of every project the user is a member of.
+func (s *Service) ListUserProjectIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.repo.ListUserProjectIDs(ctx, userID)
+}
+
+// ListOwnedProjectIDs returns the IDs of every project the user owns.
+func (s *Service) ListOwnedProjectIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.repo.ListUserProjectIDsByRole(ctx, userID, RoleOwner)
+}
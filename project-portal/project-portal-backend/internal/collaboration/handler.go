@@ -1,9 +1,12 @@
 package collaboration
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -28,16 +31,105 @@ func (h *Handler) InviteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
+		return
+	}
 
-	invite, err := h.service.InviteUser(c.Request.Context(), projectID, req.Email, req.Role)
+	actor, _ := middleware.CurrentProjectAccess(c)
+	invite, err := h.service.InviteUser(c.Request.Context(), actor, projectID, req.Email, req.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		invitationError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, invite)
 }
 
+// InvitationTokenRequest carries the secret from an invitation email.
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// GetInvitation handles GET /invitations/:token so the invitee can see what
+// they were invited to before responding.
+func (h *Handler) GetInvitation(c *gin.Context) {
+	invite, err := h.service.GetInvitationByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		invitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invite)
+}
+
+// AcceptInvitation handles POST /invitations/accept. The caller's token email
+// must match the invitation.
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := middleware.CurrentUserID(c)
+	member, err := h.service.AcceptInvitation(c.Request.Context(), req.Token, userID, middleware.CurrentEmail(c))
+	if err != nil {
+		invitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// DeclineInvitation handles POST /invitations/decline
+func (h *Handler) DeclineInvitation(c *gin.Context) {
+	var req InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := middleware.CurrentUserID(c)
+	if err := h.service.DeclineInvitation(c.Request.Context(), req.Token, userID, middleware.CurrentEmail(c)); err != nil {
+		invitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// ResendInvitation handles POST /projects/:id/invitations/:invitationId/resend
+func (h *Handler) ResendInvitation(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	invite, err := h.service.ResendInvitation(c.Request.Context(), c.Param("id"), c.Param("invitationId"), userID)
+	if err != nil {
+		invitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invite)
+}
+
+// RevokeInvitation handles DELETE /projects/:id/invitations/:invitationId
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	if err := h.service.RevokeInvitation(c.Request.Context(), c.Param("id"), c.Param("invitationId"), userID); err != nil {
+		invitationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func invitationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvitationEmail), errors.Is(err, ErrRoleEscalation):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvitationClosed), errors.Is(err, ErrInvitationExists), errors.Is(err, ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (h *Handler) GetActivities(c *gin.Context) {
 	projectID := c.Param("id")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
@@ -58,7 +150,10 @@ func (h *Handler) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	// Assume UserID is set from auth middleware context, but for now take from body
+	if !h.authorize(c, comment.ProjectID, middleware.PermCollaborate) {
+		return
+	}
+	comment.UserID, _ = middleware.CurrentUserID(c)
 
 	if err := h.service.AddComment(c.Request.Context(), &comment); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -74,6 +169,10 @@ func (h *Handler) CreateTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.authorize(c, task.ProjectID, middleware.PermCollaborate) {
+		return
+	}
+	task.CreatedBy, _ = middleware.CurrentUserID(c)
 
 	if err := h.service.CreateTask(c.Request.Context(), &task); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -89,6 +188,10 @@ func (h *Handler) CreateResource(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.authorize(c, resource.ProjectID, middleware.PermCollaborate) {
+		return
+	}
+	resource.UploadedBy, _ = middleware.CurrentUserID(c)
 
 	if err := h.service.AddResource(c.Request.Context(), &resource); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -108,16 +211,78 @@ func (h *Handler) ListMembers(c *gin.Context) {
 	c.JSON(http.StatusOK, members)
 }
 
+// AddMember handles POST /projects/:id/members
+func (h *Handler) AddMember(c *gin.Context) {
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor, _ := middleware.CurrentProjectAccess(c)
+	member, err := h.service.AddMember(c.Request.Context(), actor, c.Param("id"), &req)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember handles PATCH /projects/:id/members/:userId
+func (h *Handler) UpdateMember(c *gin.Context) {
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor, _ := middleware.CurrentProjectAccess(c)
+	member, err := h.service.UpdateMember(c.Request.Context(), actor, c.Param("id"), c.Param("userId"), &req)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
 func (h *Handler) RemoveMember(c *gin.Context) {
-	projectID := c.Param("id")
-	userID := c.Param("userId")
-	if err := h.service.RemoveMember(c.Request.Context(), projectID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	actor, _ := middleware.CurrentProjectAccess(c)
+	if err := h.service.RemoveMember(c.Request.Context(), actor, c.Param("id"), c.Param("userId")); err != nil {
+		memberError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
+// TransferOwnership handles POST /projects/:id/transfer-ownership
+func (h *Handler) TransferOwnership(c *gin.Context) {
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor, _ := middleware.CurrentProjectAccess(c)
+	member, err := h.service.TransferOwnership(c.Request.Context(), actor, c.Param("id"), &req)
+	if err != nil {
+		memberError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+func memberError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRoleEscalation):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrLastOwner), errors.Is(err, ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (h *Handler) ListInvitations(c *gin.Context) {
 	projectID := c.Param("id")
 	invitations, err := h.service.ListInvitations(c.Request.Context(), projectID)
@@ -155,6 +320,9 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
 	}
+	if !h.authorize(c, existing.ProjectID, middleware.PermCollaborate) {
+		return
+	}
 	var patch Task
 	if err := c.ShouldBindJSON(&patch); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -195,3 +363,17 @@ func (h *Handler) ListResources(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, resources)
 }
+
+// authorize checks perm on a project named in the request body and writes the
+// error response when it is missing.
+func (h *Handler) authorize(c *gin.Context, projectID, perm string) bool {
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_id is required"})
+		return false
+	}
+	if _, status, err := middleware.Authorize(c, h.service, projectID, perm); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package collaboration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/mail"
+
+	"gorm.io/gorm"
+)
+
+// InvitationTTL is how long an invitation token stays valid.
+const InvitationTTL = 48 * time.Hour
+
+var (
+	// ErrInvitationNotFound is returned for unknown tokens and IDs.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationClosed is returned when the invitation was already
+	// accepted, declined, revoked or has expired.
+	ErrInvitationClosed = errors.New("invitation is no longer pending")
+	// ErrInvitationExists is returned when the email already has a pending
+	// invitation to the project. Resend it instead.
+	ErrInvitationExists = errors.New("a pending invitation already exists for this email")
+	// ErrInvitationEmail is returned when someone other than the invitee
+	// tries to respond.
+	ErrInvitationEmail = errors.New("invitation was sent to a different email address")
+	// ErrAlreadyMember is returned when the invitee already belongs to the
+	// project.
+	ErrAlreadyMember = errors.New("user is already a member of this project")
+)
+
+// SetMailer enables invitation emails. acceptURL is the frontend page that
+// receives the token as ?token=.
+func (s *Service) SetMailer(sender mail.Sender, acceptURL string) {
+	s.mailer = sender
+	s.acceptURL = acceptURL
+}
+
+// InviteUser creates an invitation for a user and emails them the token.
+// The role may not outrank the inviter's. A failed email is logged; the
+// invitation can be resent.
+func (s *Service) InviteUser(ctx context.Context, actor *middleware.ProjectAccess, projectID, email, role string) (*ProjectInvitation, error) {
+	if err := checkGrant(actor, role, nil); err != nil {
+		return nil, err
+	}
+	invitedBy := actor.UserID
+	if _, err := s.repo.FindPendingInvitation(ctx, projectID, email); err == nil {
+		return nil, ErrInvitationExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	token, hash := newInvitationToken()
+	now := time.Now()
+	invite := &ProjectInvitation{
+		ProjectID: projectID,
+		Email:     email,
+		Role:      role,
+		Token:     hash,
+		Status:    InvitationPending,
+		InvitedBy: invitedBy,
+		ExpiresAt: now.Add(InvitationTTL),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateInvitation(ctx, invite); err != nil {
+		return nil, err
+	}
+
+	// Log activity
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    invitedBy,
+		Type:      "user",
+		Action:    "user_invited",
+		Metadata:  map[string]any{"email": email, "role": role},
+		CreatedAt: now,
+	})
+
+	if err := s.sendInvitation(ctx, invite, token); err != nil {
+		log.Printf("⚠️  Invitation %s: email not sent: %v", invite.ID, err)
+	}
+	return invite, nil
+}
+
+// GetInvitationByToken returns the invitation a token refers to, marking it
+// expired if its time has passed.
+func (s *Service) GetInvitationByToken(ctx context.Context, token string) (*ProjectInvitation, error) {
+	invite, err := s.repo.GetInvitationByToken(ctx, hashInvitationToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invite.Status == InvitationPending && !time.Now().Before(invite.ExpiresAt) {
+		_ = s.repo.SetInvitationStatus(ctx, invite.ID, InvitationPending, InvitationExpired, time.Now())
+		invite.Status = InvitationExpired
+	}
+	return invite, nil
+}
+
+// AcceptInvitation redeems a token for the signed-in user, whose email must
+// match the invitation, and makes them a member with the invited role.
+func (s *Service) AcceptInvitation(ctx context.Context, token, userID, email string) (*ProjectMember, error) {
+	invite, err := s.respondable(ctx, token, email)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetMember(ctx, invite.ProjectID, userID); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	member := &ProjectMember{
+		ProjectID: invite.ProjectID,
+		UserID:    userID,
+		Role:      invite.Role,
+		JoinedAt:  now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.AcceptInvitation(ctx, invite, member); err != nil {
+		return nil, err
+	}
+
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: invite.ProjectID,
+		UserID:    userID,
+		Type:      "user",
+		Action:    "invitation_accepted",
+		Metadata:  map[string]any{"invitation_id": invite.ID, "role": invite.Role},
+		CreatedAt: now,
+	})
+	return member, nil
+}
+
+// DeclineInvitation closes the invitation on behalf of the invitee.
+func (s *Service) DeclineInvitation(ctx context.Context, token, userID, email string) error {
+	invite, err := s.respondable(ctx, token, email)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.repo.SetInvitationStatus(ctx, invite.ID, InvitationPending, InvitationDeclined, now); err != nil {
+		return err
+	}
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: invite.ProjectID,
+		UserID:    userID,
+		Type:      "user",
+		Action:    "invitation_declined",
+		Metadata:  map[string]any{"invitation_id": invite.ID},
+		CreatedAt: now,
+	})
+	return nil
+}
+
+// ResendInvitation issues a new token and expiry for a pending or expired
+// invitation and emails it again. The previous token stops working.
+func (s *Service) ResendInvitation(ctx context.Context, projectID, invitationID, userID string) (*ProjectInvitation, error) {
+	invite, err := s.getInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	token, hash := newInvitationToken()
+	expiresAt := time.Now().Add(InvitationTTL)
+	if err := s.repo.RenewInvitation(ctx, invite.ID, hash, expiresAt); err != nil {
+		return nil, err
+	}
+	invite.Status, invite.ExpiresAt = InvitationPending, expiresAt
+
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    userID,
+		Type:      "user",
+		Action:    "invitation_resent",
+		Metadata:  map[string]any{"invitation_id": invite.ID, "email": invite.Email},
+		CreatedAt: time.Now(),
+	})
+
+	if err := s.sendInvitation(ctx, invite, token); err != nil {
+		return nil, fmt.Errorf("sending invitation: %w", err)
+	}
+	return invite, nil
+}
+
+// RevokeInvitation cancels a pending invitation.
+func (s *Service) RevokeInvitation(ctx context.Context, projectID, invitationID, userID string) error {
+	invite, err := s.getInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.repo.SetInvitationStatus(ctx, invite.ID, InvitationPending, InvitationRevoked, now); err != nil {
+		return err
+	}
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    userID,
+		Type:      "user",
+		Action:    "invitation_revoked",
+		Metadata:  map[string]any{"invitation_id": invite.ID, "email": invite.Email},
+		CreatedAt: now,
+	})
+	return nil
+}
+
+// ExpireInvitations marks stale pending invitations as expired.
+func (s *Service) ExpireInvitations(ctx context.Context) (int64, error) {
+	return s.repo.ExpireInvitations(ctx, time.Now())
+}
+
+// StartInvitationSweep expires stale invitations every interval until ctx is
+// done.
+func (s *Service) StartInvitationSweep(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.ExpireInvitations(ctx)
+				if err != nil {
+					log.Printf("WARNING: invitation expiry sweep failed: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("✉️  Expired %d stale invitation(s)", n)
+				}
+			}
+		}
+	}()
+}
+
+// respondable loads the invitation for token and checks that it is pending
+// and addressed to email.
+func (s *Service) respondable(ctx context.Context, token, email string) (*ProjectInvitation, error) {
+	invite, err := s.GetInvitationByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if invite.Status != InvitationPending {
+		return nil, fmt.Errorf("%w: %s", ErrInvitationClosed, invite.Status)
+	}
+	if !strings.EqualFold(strings.TrimSpace(invite.Email), strings.TrimSpace(email)) {
+		return nil, ErrInvitationEmail
+	}
+	return invite, nil
+}
+
+func (s *Service) getInvitation(ctx context.Context, projectID, id string) (*ProjectInvitation, error) {
+	invite, err := s.repo.GetInvitation(ctx, projectID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationNotFound
+	}
+	return invite, err
+}
+
+func (s *Service) sendInvitation(ctx context.Context, invite *ProjectInvitation, token string) error {
+	if s.mailer == nil {
+		return errors.New("no mail sender configured")
+	}
+	link := s.acceptURL + "?token=" + url.QueryEscape(token)
+	expires := invite.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST")
+	return s.mailer.Send(ctx, mail.Message{
+		To:      []string{invite.Email},
+		Subject: "You're invited to a CarbonScribe project",
+		Text: fmt.Sprintf("You have been invited to join a CarbonScribe project as %s.\n\n"+
+			"Accept or decline the invitation here:\n%s\n\nThe link expires on %s.\n",
+			invite.Role, link, expires),
+		HTML: fmt.Sprintf("<p>You have been invited to join a CarbonScribe project as <strong>%s</strong>.</p>"+
+			"<p><a href=\"%s\">Accept or decline the invitation</a></p><p>The link expires on %s.</p>",
+			html.EscapeString(invite.Role), html.EscapeString(link), expires),
+	})
+}
+
+// newInvitationToken returns a random token for the email and the hash that
+// is stored.
+func newInvitationToken() (token, hash string) {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashInvitationToken(token)
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
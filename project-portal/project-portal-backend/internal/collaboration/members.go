@@ -0,0 +1,224 @@
+package collaboration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMemberNotFound is returned when the user has no membership in the
+	// project.
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrLastOwner is returned when a change would leave the project without
+	// an Owner.
+	ErrLastOwner = errors.New("a project must keep at least one Owner")
+	// ErrRoleEscalation is returned when the caller tries to grant a role or
+	// permission they do not hold, or to change someone who outranks them.
+	ErrRoleEscalation = errors.New("cannot grant or manage access above your own role")
+	// ErrInvalidRole is returned for unknown roles and permissions.
+	ErrInvalidRole = errors.New("invalid role or permission")
+)
+
+// AddMemberRequest is the body for POST /projects/:id/members.
+type AddMemberRequest struct {
+	UserID      string   `json:"user_id" binding:"required"`
+	Role        string   `json:"role" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateMemberRequest is the body for PATCH /projects/:id/members/:userId.
+// Omitted fields are left unchanged; an empty permissions list clears extra
+// grants.
+type UpdateMemberRequest struct {
+	Role        *string   `json:"role"`
+	Permissions *[]string `json:"permissions"`
+}
+
+// TransferOwnershipRequest is the body for POST
+// /projects/:id/transfer-ownership. The caller keeps PreviousOwnerRole,
+// Manager by default.
+type TransferOwnershipRequest struct {
+	UserID            string `json:"user_id" binding:"required"`
+	PreviousOwnerRole string `json:"previous_owner_role"`
+}
+
+// AddMember adds an existing user to the project directly, without an
+// invitation.
+func (s *Service) AddMember(ctx context.Context, actor *middleware.ProjectAccess, projectID string, req *AddMemberRequest) (*ProjectMember, error) {
+	if err := checkGrant(actor, req.Role, req.Permissions); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetMember(ctx, projectID, req.UserID); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	member := &ProjectMember{
+		ProjectID:   projectID,
+		UserID:      req.UserID,
+		Role:        req.Role,
+		Permissions: req.Permissions,
+		JoinedAt:    now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    actor.UserID,
+		Type:      "user",
+		Action:    "member_added",
+		Metadata:  map[string]any{"member_id": req.UserID, "role": req.Role, "permissions": req.Permissions},
+		CreatedAt: now,
+	})
+	return member, nil
+}
+
+// UpdateMember changes a member's role or extra permissions. The caller may
+// not manage members who outrank them, nor grant more than they hold.
+func (s *Service) UpdateMember(ctx context.Context, actor *middleware.ProjectAccess, projectID, userID string, req *UpdateMemberRequest) (*ProjectMember, error) {
+	member, err := s.getMember(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if middleware.RoleRank(member.Role) > middleware.RoleRank(actor.Role) {
+		return nil, ErrRoleEscalation
+	}
+
+	before := memberSnapshot(member)
+	if req.Role != nil {
+		member.Role = *req.Role
+	}
+	if req.Permissions != nil {
+		member.Permissions = *req.Permissions
+	}
+	if err := checkGrant(actor, member.Role, member.Permissions); err != nil {
+		return nil, err
+	}
+	member.UpdatedAt = time.Now()
+
+	if err := s.repo.ChangeMembers(ctx, projectID, []*ProjectMember{member}, nil); err != nil {
+		return nil, err
+	}
+	s.logMemberChange(ctx, projectID, actor.UserID, "member_role_changed", userID, before, memberSnapshot(member))
+	return member, nil
+}
+
+// RemoveMember removes a member. The last Owner cannot be removed, and
+// members who outrank the caller cannot be removed by them.
+func (s *Service) RemoveMember(ctx context.Context, actor *middleware.ProjectAccess, projectID, userID string) error {
+	member, err := s.getMember(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+	if middleware.RoleRank(member.Role) > middleware.RoleRank(actor.Role) {
+		return ErrRoleEscalation
+	}
+	if err := s.repo.ChangeMembers(ctx, projectID, nil, []string{userID}); err != nil {
+		return err
+	}
+	s.logMemberChange(ctx, projectID, actor.UserID, "member_removed", userID, memberSnapshot(member), nil)
+	return nil
+}
+
+// TransferOwnership makes another member an Owner and steps the caller down
+// to req.PreviousOwnerRole in one transaction. A platform admin acting
+// without a membership only promotes the target.
+func (s *Service) TransferOwnership(ctx context.Context, actor *middleware.ProjectAccess, projectID string, req *TransferOwnershipRequest) (*ProjectMember, error) {
+	if actor.Role != RoleOwner {
+		return nil, ErrRoleEscalation
+	}
+	demoteTo := req.PreviousOwnerRole
+	if demoteTo == "" {
+		demoteTo = RoleManager
+	}
+	if !middleware.IsValidRole(demoteTo) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRole, demoteTo)
+	}
+	if req.UserID == actor.UserID {
+		return nil, fmt.Errorf("%w: cannot transfer ownership to yourself", ErrInvalidRole)
+	}
+
+	target, err := s.getMember(ctx, projectID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	targetBefore := memberSnapshot(target)
+	target.Role, target.UpdatedAt = RoleOwner, now
+	save := []*ProjectMember{target}
+
+	current, err := s.repo.GetMember(ctx, projectID, actor.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var currentBefore map[string]any
+	if current != nil {
+		currentBefore = memberSnapshot(current)
+		current.Role, current.UpdatedAt = demoteTo, now
+		save = append(save, current)
+	}
+
+	if err := s.repo.ChangeMembers(ctx, projectID, save, nil); err != nil {
+		return nil, err
+	}
+	s.logMemberChange(ctx, projectID, actor.UserID, "ownership_transferred", target.UserID, targetBefore, memberSnapshot(target))
+	if current != nil {
+		s.logMemberChange(ctx, projectID, actor.UserID, "member_role_changed", current.UserID, currentBefore, memberSnapshot(current))
+	}
+	return target, nil
+}
+
+// checkGrant rejects unknown roles and permissions, roles ranked above the
+// actor's and permissions the actor does not hold.
+func checkGrant(actor *middleware.ProjectAccess, role string, perms []string) error {
+	if !middleware.IsValidRole(role) {
+		return fmt.Errorf("%w: %s", ErrInvalidRole, role)
+	}
+	if middleware.RoleRank(role) > middleware.RoleRank(actor.Role) {
+		return ErrRoleEscalation
+	}
+	for _, p := range perms {
+		if !middleware.IsValidPermission(p) {
+			return fmt.Errorf("%w: %s", ErrInvalidRole, p)
+		}
+		if !actor.Can(p) {
+			return ErrRoleEscalation
+		}
+	}
+	return nil
+}
+
+func (s *Service) getMember(ctx context.Context, projectID, userID string) (*ProjectMember, error) {
+	member, err := s.repo.GetMember(ctx, projectID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	return member, err
+}
+
+func memberSnapshot(m *ProjectMember) map[string]any {
+	return map[string]any{"role": m.Role, "permissions": slices.Clone([]string(m.Permissions))}
+}
+
+func (s *Service) logMemberChange(ctx context.Context, projectID, actorID, action, memberID string, before, after map[string]any) {
+	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+		ProjectID: projectID,
+		UserID:    actorID,
+		Type:      "user",
+		Action:    action,
+		Metadata:  map[string]any{"member_id": memberID, "before": before, "after": after},
+		CreatedAt: time.Now(),
+	})
+}
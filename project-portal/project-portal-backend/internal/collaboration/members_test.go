@@ -0,0 +1,30 @@
+package collaboration
+
+import (
+	"errors"
+	"testing"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+)
+
+func TestCheckGrant(t *testing.T) {
+	manager := middleware.NewProjectAccess("p", "m", RoleManager, nil)
+	cases := []struct {
+		name  string
+		role  string
+		perms []string
+		want  error
+	}{
+		{"same role", RoleManager, nil, nil},
+		{"lower role with held permission", RoleViewer, []string{middleware.PermDocumentsReview}, nil},
+		{"above own role", RoleOwner, nil, ErrRoleEscalation},
+		{"permission not held", RoleViewer, []string{middleware.PermProjectDelete}, ErrRoleEscalation},
+		{"unknown role", "Admin", nil, ErrInvalidRole},
+		{"unknown permission", RoleViewer, []string{"everything"}, ErrInvalidRole},
+	}
+	for _, tc := range cases {
+		if err := checkGrant(manager, tc.role, tc.perms); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
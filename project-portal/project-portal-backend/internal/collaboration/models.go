@@ -3,15 +3,18 @@ package collaboration
 import (
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 // Role definitions
 const (
-	RoleOwner       = "Owner"
-	RoleManager     = "Manager"
-	RoleContributor = "Contributor"
-	RoleViewer      = "Viewer"
+	RoleOwner       = middleware.RoleOwner
+	RoleManager     = middleware.RoleManager
+	RoleContributor = middleware.RoleContributor
+	RoleViewer      = middleware.RoleViewer
 )
 
 // ProjectMember represents a user's membership in a project
@@ -20,24 +23,37 @@ type ProjectMember struct {
 	ProjectID   string         `gorm:"index;not null" json:"project_id"`
 	UserID      string         `gorm:"index;not null" json:"user_id"`
 	Role        string         `gorm:"not null" json:"role"`
-	Permissions []string       `gorm:"type:text[]" json:"permissions"`
+	Permissions pq.StringArray `gorm:"type:text[]" json:"permissions"`
 	JoinedAt    time.Time      `json:"joined_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// ProjectInvitation represents a pending invitation
+// Invitation statuses. Only pending invitations can be accepted, declined
+// or revoked; pending and expired ones can be resent.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// ProjectInvitation represents a pending invitation. Token holds the SHA-256
+// of the secret sent by email, never the secret itself.
 type ProjectInvitation struct {
-	ID        string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	ProjectID string         `gorm:"index;not null" json:"project_id"`
-	Email     string         `gorm:"index;not null" json:"email"`
-	Role      string         `gorm:"not null" json:"role"`
-	Token     string         `gorm:"uniqueIndex;not null" json:"-"`
-	Status    string         `gorm:"default:'pending'" json:"status"` // pending, accepted, expired
-	ExpiresAt time.Time      `json:"expires_at"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	ProjectID   string         `gorm:"index;not null" json:"project_id"`
+	Email       string         `gorm:"index;not null" json:"email"`
+	Role        string         `gorm:"not null" json:"role"`
+	Token       string         `gorm:"uniqueIndex;not null" json:"-"`
+	Status      string         `gorm:"default:'pending';index:idx_invitations_status_expiry,priority:1" json:"status"` // pending, accepted, declined, revoked, expired
+	InvitedBy   string         `json:"invited_by,omitempty"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+	ExpiresAt   time.Time      `gorm:"index:idx_invitations_status_expiry,priority:2" json:"expires_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // ActivityLog represents an event in the project
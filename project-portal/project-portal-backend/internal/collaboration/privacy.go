@@ -0,0 +1,64 @@
+package collaboration
+
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests/datasource"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+
+	"gorm.io/gorm"
+)
+
+// NewPrivacySource returns the data source privacy requests use to find,
+// export and erase a user's collaboration data. Memberships are deleted;
+// comments, tasks, resources and activity keep their place in the project
+// with the user's ID replaced and comment text redacted. Rows in projects
+// under legal hold are left as they are.
+func NewPrivacySource(db *gorm.DB) *datasource.Source {
+	erased := func(col string) func(string) map[string]any {
+		return func(string) map[string]any { return map[string]any{col: requests.ErasedUserID} }
+	}
+	return datasource.New("collaboration", db,
+		datasource.Table{Name: "project_members", Category: requests.CategoryProjectData, Match: "user_id = ?", Project: "project_id", TimeColumn: "joined_at"},
+		datasource.Table{Name: "comments", Category: requests.CategoryProjectData, Match: "user_id = ?", Project: "project_id",
+			Anonymize: func(string) map[string]any {
+				return map[string]any{
+					"user_id":     requests.ErasedUserID,
+					"content":     "[deleted]",
+					"attachments": gorm.Expr("'{}'"),
+					"location":    nil,
+				}
+			}},
+		datasource.Table{Name: "comments", Dataset: "comment_mentions", Category: requests.CategoryProjectData, Match: "? = ANY(mentions)", Project: "project_id", NoExport: true,
+			Anonymize: func(userID string) map[string]any {
+				return map[string]any{"mentions": gorm.Expr("array_remove(mentions, ?)", userID)}
+			}},
+		datasource.Table{Name: "comments", Dataset: "resolved_comments", Category: requests.CategoryProjectData, Match: "resolved_by = ?", Project: "project_id", NoExport: true,
+			Anonymize: func(string) map[string]any { return map[string]any{"resolved_by": nil} }},
+		datasource.Table{Name: "tasks", Dataset: "assigned_tasks", Category: requests.CategoryProjectData, Match: "assigned_to = ?", Project: "project_id",
+			Anonymize: func(string) map[string]any { return map[string]any{"assigned_to": nil} }},
+		datasource.Table{Name: "tasks", Dataset: "created_tasks", Category: requests.CategoryProjectData, Match: "created_by = ?", Project: "project_id",
+			Anonymize: erased("created_by")},
+		datasource.Table{Name: "shared_resources", Category: requests.CategoryProjectData, Match: "uploaded_by = ?", Project: "project_id",
+			Anonymize: erased("uploaded_by")},
+		datasource.Table{Name: "resource_bookings", Category: requests.CategoryProjectData, Match: "booked_by = ?",
+			Project:   "(SELECT r.project_id FROM shared_resources r WHERE r.id = resource_bookings.resource_id)",
+			Anonymize: erased("booked_by")},
+		datasource.Table{Name: "project_invitations", Dataset: "sent_invitations", Category: requests.CategoryProjectData, Match: "invited_by = ?", Project: "project_id",
+			Omit: []string{"token"}, Anonymize: erased("invited_by")},
+		datasource.Table{Name: "activity_logs", Category: requests.CategorySystemLogs, Match: "user_id = ?", Project: "project_id",
+			Anonymize: func(string) map[string]any {
+				return map[string]any{"user_id": requests.ErasedUserID, "metadata": nil}
+			}},
+	)
+}
+
+// RetentionTargets returns the collaboration tables retention policies act
+// on. Only the activity feed ages out; project content is governed by the
+// project lifecycle.
+func RetentionTargets() []retention.Target {
+	return []retention.Target{
+		{Table: "activity_logs", Category: requests.CategorySystemLogs, UserColumn: "user_id", Project: "project_id",
+			Anonymize:  map[string]any{"user_id": requests.ErasedUserID, "metadata": nil},
+			Anonymized: "user_id = '" + requests.ErasedUserID + "'"},
+	}
+}
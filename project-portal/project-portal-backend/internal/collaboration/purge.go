@@ -0,0 +1,31 @@
+package collaboration
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PurgeProjectData permanently removes every collaboration row for a
+// project, including soft-deleted ones, inside the caller's transaction. It
+// implements project.DataPurger.
+func (s *Service) PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error) {
+	pid := projectID.String()
+	tx = tx.WithContext(ctx).Unscoped()
+
+	tasks := tx.Model(&Task{}).Select("id").Where("project_id = ?", pid)
+	if err := tx.Where("task_id IN (?) OR depends_on_task_id IN (?)", tasks, tasks).Delete(&TaskDependency{}).Error; err != nil {
+		return nil, err
+	}
+	resources := tx.Model(&SharedResource{}).Select("id").Where("project_id = ?", pid)
+	if err := tx.Where("resource_id IN (?)", resources).Delete(&ResourceBooking{}).Error; err != nil {
+		return nil, err
+	}
+	for _, model := range []any{&Task{}, &SharedResource{}, &Comment{}, &ActivityLog{}, &ProjectInvitation{}, &ProjectMember{}} {
+		if err := tx.Where("project_id = ?", pid).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
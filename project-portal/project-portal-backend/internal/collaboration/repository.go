@@ -2,8 +2,10 @@ package collaboration
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
@@ -13,11 +15,20 @@ type Repository interface {
 	ListMembers(ctx context.Context, projectID string) ([]ProjectMember, error)
 	UpdateMember(ctx context.Context, member *ProjectMember) error
 	RemoveMember(ctx context.Context, projectID, userID string) error
+	ChangeMembers(ctx context.Context, projectID string, save []*ProjectMember, remove []string) error
+	ListUserProjectIDs(ctx context.Context, userID string) ([]string, error)
+	ListUserProjectIDsByRole(ctx context.Context, userID, role string) ([]string, error)
 
 	// Invitation
 	CreateInvitation(ctx context.Context, invite *ProjectInvitation) error
+	GetInvitation(ctx context.Context, projectID, id string) (*ProjectInvitation, error)
 	GetInvitationByToken(ctx context.Context, token string) (*ProjectInvitation, error)
+	FindPendingInvitation(ctx context.Context, projectID, email string) (*ProjectInvitation, error)
 	ListInvitations(ctx context.Context, projectID string) ([]ProjectInvitation, error)
+	SetInvitationStatus(ctx context.Context, id, from, to string, at time.Time) error
+	RenewInvitation(ctx context.Context, id, token string, expiresAt time.Time) error
+	AcceptInvitation(ctx context.Context, invite *ProjectInvitation, member *ProjectMember) error
+	ExpireInvitations(ctx context.Context, now time.Time) (int64, error)
 
 	// Activity
 	CreateActivity(ctx context.Context, activity *ActivityLog) error
@@ -76,6 +87,54 @@ func (r *repository) RemoveMember(ctx context.Context, projectID, userID string)
 	return r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&ProjectMember{}).Error
 }
 
+// ChangeMembers saves and removes memberships of one project in a single
+// transaction and fails with ErrLastOwner if no Owner would remain. The
+// project's Owner rows are locked first so concurrent changes cannot both
+// pass the check.
+func (r *repository) ChangeMembers(ctx context.Context, projectID string, save []*ProjectMember, remove []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var owners []ProjectMember
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("project_id = ? AND role = ?", projectID, RoleOwner).Find(&owners).Error; err != nil {
+			return err
+		}
+		for _, m := range save {
+			if err := tx.Save(m).Error; err != nil {
+				return err
+			}
+		}
+		if len(remove) > 0 {
+			if err := tx.Where("project_id = ? AND user_id IN ?", projectID, remove).Delete(&ProjectMember{}).Error; err != nil {
+				return err
+			}
+		}
+		var remaining int64
+		if err := tx.Model(&ProjectMember{}).Where("project_id = ? AND role = ?", projectID, RoleOwner).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return ErrLastOwner
+		}
+		return nil
+	})
+}
+
+func (r *repository) ListUserProjectIDs(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&ProjectMember{}).Where("user_id = ?", userID).Pluck("project_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *repository) ListUserProjectIDsByRole(ctx context.Context, userID, role string) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&ProjectMember{}).Where("user_id = ? AND role = ?", userID, role).Pluck("project_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // Invitation
 
 func (r *repository) CreateInvitation(ctx context.Context, invite *ProjectInvitation) error {
@@ -90,6 +149,83 @@ func (r *repository) GetInvitationByToken(ctx context.Context, token string) (*P
 	return &invite, nil
 }
 
+func (r *repository) GetInvitation(ctx context.Context, projectID, id string) (*ProjectInvitation, error) {
+	var invite ProjectInvitation
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", id, projectID).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// FindPendingInvitation returns the unexpired pending invitation for email,
+// matched case-insensitively.
+func (r *repository) FindPendingInvitation(ctx context.Context, projectID, email string) (*ProjectInvitation, error) {
+	var invite ProjectInvitation
+	if err := r.db.WithContext(ctx).
+		Where("project_id = ? AND lower(email) = lower(?) AND status = ? AND expires_at > ?", projectID, email, InvitationPending, time.Now()).
+		First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// SetInvitationStatus moves an invitation from status from to status to. It
+// returns ErrInvitationClosed when the invitation is no longer in from.
+func (r *repository) SetInvitationStatus(ctx context.Context, id, from, to string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&ProjectInvitation{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{"status": to, "responded_at": at, "updated_at": at})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+// RenewInvitation replaces the token and expiry of a pending or expired
+// invitation and makes it pending again.
+func (r *repository) RenewInvitation(ctx context.Context, id, token string, expiresAt time.Time) error {
+	res := r.db.WithContext(ctx).Model(&ProjectInvitation{}).
+		Where("id = ? AND status IN ?", id, []string{InvitationPending, InvitationExpired}).
+		Updates(map[string]any{"token": token, "expires_at": expiresAt, "status": InvitationPending, "updated_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+// AcceptInvitation marks the invitation accepted and creates the membership
+// in one transaction. The status update is conditional so a token can only
+// be redeemed once.
+func (r *repository) AcceptInvitation(ctx context.Context, invite *ProjectInvitation, member *ProjectMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&ProjectInvitation{}).
+			Where("id = ? AND status = ? AND expires_at > ?", invite.ID, InvitationPending, member.JoinedAt).
+			Updates(map[string]any{"status": InvitationAccepted, "responded_at": member.JoinedAt, "updated_at": member.JoinedAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvitationClosed
+		}
+		return tx.Create(member).Error
+	})
+}
+
+// ExpireInvitations marks every pending invitation past its expiry as
+// expired and returns how many changed.
+func (r *repository) ExpireInvitations(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&ProjectInvitation{}).
+		Where("status = ? AND expires_at <= ?", InvitationPending, now).
+		Updates(map[string]any{"status": InvitationExpired, "updated_at": now})
+	return res.RowsAffected, res.Error
+}
+
 func (r *repository) ListInvitations(ctx context.Context, projectID string) ([]ProjectInvitation, error) {
 	var invites []ProjectInvitation
 	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Find(&invites).Error; err != nil {
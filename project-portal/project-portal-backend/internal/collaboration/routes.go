@@ -1,34 +1,53 @@
 package collaboration
 
-import "github.com/gin-gonic/gin"
-
-func RegisterRoutes(r *gin.Engine, h *Handler) {
-	v1 := r.Group("/api/v1/collaboration")
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes mounts the collaboration API. Every route requires a valid
+// access token; project-scoped routes additionally check the caller's role.
+// Routes that take the project from the request body authorize in the handler.
+func RegisterRoutes(r *gin.Engine, h *Handler, authRequired gin.HandlerFunc) {
+	v1 := r.Group("/api/v1/collaboration", authRequired)
+	byParam := middleware.ProjectFromParam("id")
+	can := func(perm string) gin.HandlerFunc {
+		return middleware.RequireProjectPermission(h.service, perm, byParam)
+	}
 	{
 		// Project members
-		v1.GET("/projects/:id/members", h.ListMembers)
-		v1.DELETE("/projects/:id/members/:userId", h.RemoveMember)
+		v1.GET("/projects/:id/members", can(middleware.PermMembersRead), h.ListMembers)
+		v1.POST("/projects/:id/members", can(middleware.PermMembersManage), h.AddMember)
+		v1.PATCH("/projects/:id/members/:userId", can(middleware.PermMembersManage), h.UpdateMember)
+		v1.DELETE("/projects/:id/members/:userId", can(middleware.PermMembersManage), h.RemoveMember)
+		v1.POST("/projects/:id/transfer-ownership", can(middleware.PermProjectDelete), h.TransferOwnership)
 
 		// Project invitations
-		v1.POST("/projects/:id/invite", h.InviteUser)
-		v1.GET("/projects/:id/invitations", h.ListInvitations)
+		v1.POST("/projects/:id/invite", can(middleware.PermMembersInvite), h.InviteUser)
+		v1.GET("/projects/:id/invitations", can(middleware.PermMembersRead), h.ListInvitations)
+		v1.POST("/projects/:id/invitations/:invitationId/resend", can(middleware.PermMembersInvite), h.ResendInvitation)
+		v1.DELETE("/projects/:id/invitations/:invitationId", can(middleware.PermMembersInvite), h.RevokeInvitation)
 
-		// Activity feed
-		v1.GET("/projects/:id/activities", h.GetActivities)
+		// Responding to an invitation; the invitee is not a member yet.
+		v1.GET("/invitations/:token", h.GetInvitation)
+		v1.POST("/invitations/accept", h.AcceptInvitation)
+		v1.POST("/invitations/decline", h.DeclineInvitation)
 
+		// Activity feed
+		v1.GET("/projects/:id/activities", can(middleware.PermProjectRead), h.GetActivities)
 
 		// Comments
-		v1.GET("/projects/:id/comments", h.ListComments)
+		v1.GET("/projects/:id/comments", can(middleware.PermProjectRead), h.ListComments)
 		v1.POST("/comments", h.CreateComment)
 
-
 		// Tasks
-		v1.GET("/projects/:id/tasks", h.ListTasks)
+		v1.GET("/projects/:id/tasks", can(middleware.PermProjectRead), h.ListTasks)
 		v1.POST("/tasks", h.CreateTask)
 		v1.PATCH("/tasks/:id", h.UpdateTask)
 
 		// Resources
-		v1.GET("/projects/:id/resources", h.ListResources)
+		v1.GET("/projects/:id/resources", can(middleware.PermProjectRead), h.ListResources)
 		v1.POST("/resources", h.CreateResource)
 	}
 }
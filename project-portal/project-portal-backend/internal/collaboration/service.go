@@ -4,44 +4,34 @@ import (
 	"context"
 	"time"
 
-	"github.com/google/uuid"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/mail"
 )
 
 type Service struct {
-	repo Repository
+	repo      Repository
+	mailer    mail.Sender
+	acceptURL string
 }
 
 func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
-// InviteUser creates an invitation for a user
-func (s *Service) InviteUser(ctx context.Context, projectID, email, role string) (*ProjectInvitation, error) {
-	token := uuid.New().String()
-	invite := &ProjectInvitation{
-		ProjectID: projectID,
-		Email:     email,
-		Role:      role,
-		Token:     token,
-		Status:    "pending",
-		ExpiresAt: time.Now().Add(48 * time.Hour),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err := s.repo.CreateInvitation(ctx, invite); err != nil {
-		return nil, err
+// RecordActivity appends an event raised by another module to the project's
+// activity feed.
+func (s *Service) RecordActivity(ctx context.Context, projectID, userID, action string, metadata map[string]any) error {
+	activityType := "user"
+	if userID == "" {
+		activityType = "system"
 	}
-
-	// Log activity
-	_ = s.repo.CreateActivity(ctx, &ActivityLog{
+	return s.repo.CreateActivity(ctx, &ActivityLog{
 		ProjectID: projectID,
-		Type:      "system",
-		Action:    "user_invited",
-		Metadata:  map[string]any{"email": email, "role": role},
+		UserID:    userID,
+		Type:      activityType,
+		Action:    action,
+		Metadata:  metadata,
 		CreatedAt: time.Now(),
 	})
-
-	return invite, nil
 }
 
 func (s *Service) ListProjectActivities(ctx context.Context, projectID string, limit, offset int) ([]ActivityLog, error) {
@@ -89,10 +79,6 @@ func (s *Service) ListMembers(ctx context.Context, projectID string) ([]ProjectM
 	return s.repo.ListMembers(ctx, projectID)
 }
 
-func (s *Service) RemoveMember(ctx context.Context, projectID, userID string) error {
-	return s.repo.RemoveMember(ctx, projectID, userID)
-}
-
 func (s *Service) ListInvitations(ctx context.Context, projectID string) ([]ProjectInvitation, error) {
 	return s.repo.ListInvitations(ctx, projectID)
 }
@@ -1,6 +1,8 @@
 package audit
 
 import (
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -28,17 +30,25 @@ type Anomaly struct {
 	Details     map[string]interface{} `json:"details"`
 }
 
+// DefaultAnomalyThresholds returns the thresholds NewAnomalyDetector uses.
+func DefaultAnomalyThresholds() AnomalyThresholds {
+	return AnomalyThresholds{
+		MaxAccessPerHour:       100,
+		MaxSensitivePerDay:     20,
+		UnusualHoursStart:      22,
+		UnusualHoursEnd:        6,
+		AccessVolumeMultiplier: 3.0,
+	}
+}
+
 // NewAnomalyDetector creates a detector with default thresholds.
 func NewAnomalyDetector() *AnomalyDetector {
-	return &AnomalyDetector{
-		thresholds: AnomalyThresholds{
-			MaxAccessPerHour:       100,
-			MaxSensitivePerDay:     20,
-			UnusualHoursStart:      22,
-			UnusualHoursEnd:        6,
-			AccessVolumeMultiplier: 3.0,
-		},
-	}
+	return NewAnomalyDetectorWithThresholds(DefaultAnomalyThresholds())
+}
+
+// NewAnomalyDetectorWithThresholds creates a detector with custom thresholds.
+func NewAnomalyDetectorWithThresholds(t AnomalyThresholds) *AnomalyDetector {
+	return &AnomalyDetector{thresholds: t}
 }
 
 // AnalyzeAccessVolume checks if the access count exceeds the hourly threshold.
@@ -61,9 +71,7 @@ func (ad *AnomalyDetector) AnalyzeAccessVolume(accessCount int64) *Anomaly {
 // AnalyzeUnusualHours checks if current access is during unusual hours.
 func (ad *AnomalyDetector) AnalyzeUnusualHours(accessCount int64) *Anomaly {
 	hour := time.Now().Hour()
-	isUnusual := hour >= ad.thresholds.UnusualHoursStart || hour < ad.thresholds.UnusualHoursEnd
-
-	if isUnusual && accessCount > 0 {
+	if ad.unusualHour(hour) && accessCount > 0 {
 		return &Anomaly{
 			Type:        "unusual_hours_access",
 			Severity:    "low",
@@ -94,3 +102,176 @@ func (ad *AnomalyDetector) AnalyzeSensitiveSpike(sensitiveAccessCount int64) *An
 	}
 	return nil
 }
+
+func (ad *AnomalyDetector) unusualHour(hour int) bool {
+	return hour >= ad.thresholds.UnusualHoursStart || hour < ad.thresholds.UnusualHoursEnd
+}
+
+// AccessEvent is one recorded access, the input to the history analyses.
+type AccessEvent struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	IP       string
+	At       time.Time
+}
+
+// AnalyzeBulkAccess reports actors with more than MaxAccessPerHour events
+// in any sliding hour. events must be sorted by time.
+func (ad *AnomalyDetector) AnalyzeBulkAccess(events []AccessEvent) []Anomaly {
+	byActor := groupByActor(events)
+	var out []Anomaly
+	for _, actor := range sortedActors(byActor) {
+		evs := byActor[actor]
+		peak, peakStart, start := 0, 0, 0
+		for end := range evs {
+			for evs[end].At.Sub(evs[start].At) >= time.Hour {
+				start++
+			}
+			if n := end - start + 1; n > peak {
+				peak, peakStart = n, start
+			}
+		}
+		if peak <= ad.thresholds.MaxAccessPerHour {
+			continue
+		}
+		targets := map[string]bool{}
+		for _, ev := range evs[peakStart : peakStart+peak] {
+			targets[ev.TargetID] = true
+		}
+		out = append(out, Anomaly{
+			Type:        "bulk_access",
+			Severity:    "high",
+			Description: fmt.Sprintf("%d accesses to %d items within one hour", peak, len(targets)),
+			ActorID:     actor,
+			DetectedAt:  evs[peakStart+peak-1].At,
+			Details: map[string]interface{}{
+				"access_count":   peak,
+				"distinct_items": len(targets),
+				"window_start":   evs[peakStart].At,
+				"threshold":      ad.thresholds.MaxAccessPerHour,
+			},
+		})
+	}
+	return out
+}
+
+// AnalyzeNewIPs reports accesses from addresses an actor has not used
+// before. known maps actor to the addresses seen in their history; actors
+// without history are skipped, since every address is new to them.
+func (ad *AnomalyDetector) AnalyzeNewIPs(events []AccessEvent, known map[string]map[string]bool) []Anomaly {
+	type first struct {
+		at    time.Time
+		count int
+	}
+	seen := map[string]map[string]*first{}
+	for _, ev := range events {
+		prior := known[ev.ActorID]
+		if ev.IP == "" || len(prior) == 0 || prior[ev.IP] {
+			continue
+		}
+		if seen[ev.ActorID] == nil {
+			seen[ev.ActorID] = map[string]*first{}
+		}
+		if f := seen[ev.ActorID][ev.IP]; f != nil {
+			f.count++
+		} else {
+			seen[ev.ActorID][ev.IP] = &first{at: ev.At, count: 1}
+		}
+	}
+	var out []Anomaly
+	for _, actor := range sortedActors(seen) {
+		ips := make([]string, 0, len(seen[actor]))
+		for ip := range seen[actor] {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		for _, ip := range ips {
+			f := seen[actor][ip]
+			out = append(out, Anomaly{
+				Type:        "new_ip_access",
+				Severity:    "medium",
+				Description: fmt.Sprintf("Access from previously unseen address %s", ip),
+				ActorID:     actor,
+				DetectedAt:  f.at,
+				Details: map[string]interface{}{
+					"ip_address":   ip,
+					"access_count": f.count,
+					"known_ips":    len(known[actor]),
+				},
+			})
+		}
+	}
+	return out
+}
+
+// AnalyzeHistoryUnusualHours reports actors with accesses during unusual
+// hours (in UTC).
+func (ad *AnomalyDetector) AnalyzeHistoryUnusualHours(events []AccessEvent) []Anomaly {
+	byActor := groupByActor(events)
+	var out []Anomaly
+	for _, actor := range sortedActors(byActor) {
+		var count int
+		var firstAt time.Time
+		for _, ev := range byActor[actor] {
+			if ad.unusualHour(ev.At.UTC().Hour()) {
+				if count == 0 {
+					firstAt = ev.At
+				}
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		out = append(out, Anomaly{
+			Type:        "unusual_hours_access",
+			Severity:    "low",
+			Description: fmt.Sprintf("%d accesses between %02d:00 and %02d:00 UTC", count, ad.thresholds.UnusualHoursStart, ad.thresholds.UnusualHoursEnd),
+			ActorID:     actor,
+			DetectedAt:  firstAt,
+			Details: map[string]interface{}{
+				"access_count": count,
+			},
+		})
+	}
+	return out
+}
+
+// AnalyzeVolumeSpike checks whether an actor's daily access rate exceeds
+// AccessVolumeMultiplier times their baseline rate. Actors without a
+// baseline are not flagged.
+func (ad *AnomalyDetector) AnalyzeVolumeSpike(actorID string, dailyRate, baselineDailyRate float64) *Anomaly {
+	if baselineDailyRate <= 0 || dailyRate <= baselineDailyRate*ad.thresholds.AccessVolumeMultiplier {
+		return nil
+	}
+	return &Anomaly{
+		Type:        "access_volume_spike",
+		Severity:    "medium",
+		Description: fmt.Sprintf("Access rate of %.1f per day is %.1fx the usual %.1f", dailyRate, dailyRate/baselineDailyRate, baselineDailyRate),
+		ActorID:     actorID,
+		DetectedAt:  time.Now(),
+		Details: map[string]interface{}{
+			"daily_rate":          dailyRate,
+			"baseline_daily_rate": baselineDailyRate,
+			"multiplier":          ad.thresholds.AccessVolumeMultiplier,
+		},
+	}
+}
+
+func groupByActor(events []AccessEvent) map[string][]AccessEvent {
+	out := map[string][]AccessEvent{}
+	for _, ev := range events {
+		out[ev.ActorID] = append(out[ev.ActorID], ev)
+	}
+	return out
+}
+
+func sortedActors[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeBulkAccess(t *testing.T) {
+	ad := NewAnomalyDetectorWithThresholds(AnomalyThresholds{MaxAccessPerHour: 3})
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var events []AccessEvent
+	// alice: 4 downloads within 30 minutes; bob: 4 spread over 4 hours.
+	for i := 0; i < 4; i++ {
+		events = append(events,
+			AccessEvent{ActorID: "alice", TargetID: string(rune('a' + i)), At: start.Add(time.Duration(i) * 10 * time.Minute)},
+			AccessEvent{ActorID: "bob", TargetID: "x", At: start.Add(time.Duration(i) * time.Hour)},
+		)
+	}
+	got := ad.AnalyzeBulkAccess(events)
+	if len(got) != 1 || got[0].ActorID != "alice" || got[0].Details["access_count"] != 4 || got[0].Details["distinct_items"] != 4 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestAnalyzeNewIPs(t *testing.T) {
+	ad := NewAnomalyDetector()
+	at := time.Now()
+	events := []AccessEvent{
+		{ActorID: "alice", IP: "10.0.0.1", At: at},
+		{ActorID: "alice", IP: "203.0.113.9", At: at},
+		{ActorID: "alice", IP: "203.0.113.9", At: at.Add(time.Minute)},
+		{ActorID: "newcomer", IP: "198.51.100.1", At: at},
+	}
+	known := map[string]map[string]bool{"alice": {"10.0.0.1": true}}
+	got := ad.AnalyzeNewIPs(events, known)
+	if len(got) != 1 || got[0].ActorID != "alice" || got[0].Details["ip_address"] != "203.0.113.9" || got[0].Details["access_count"] != 2 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestAnalyzeHistoryUnusualHours(t *testing.T) {
+	ad := NewAnomalyDetector() // 22:00-06:00
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	events := []AccessEvent{
+		{ActorID: "alice", At: day.Add(3 * time.Hour)},
+		{ActorID: "alice", At: day.Add(23 * time.Hour)},
+		{ActorID: "bob", At: day.Add(14 * time.Hour)},
+	}
+	got := ad.AnalyzeHistoryUnusualHours(events)
+	if len(got) != 1 || got[0].ActorID != "alice" || got[0].Details["access_count"] != 2 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestAnalyzeVolumeSpike(t *testing.T) {
+	ad := NewAnomalyDetector() // 3x
+	if a := ad.AnalyzeVolumeSpike("alice", 31, 10); a == nil || a.Type != "access_volume_spike" {
+		t.Errorf("spike not reported: %+v", a)
+	}
+	if a := ad.AnalyzeVolumeSpike("alice", 29, 10); a != nil {
+		t.Errorf("normal rate reported: %+v", a)
+	}
+	if a := ad.AnalyzeVolumeSpike("alice", 500, 0); a != nil {
+		t.Errorf("actor without baseline reported: %+v", a)
+	}
+}
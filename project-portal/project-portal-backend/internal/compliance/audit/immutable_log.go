@@ -3,41 +3,72 @@ package audit
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrHashMismatch is returned when an entry's hash does not cover its
+	// contents and the previous entry's hash.
+	ErrHashMismatch = errors.New("hash does not match entry")
+	// ErrBadSignature is returned when an entry's signature does not match.
+	ErrBadSignature = errors.New("signature does not match entry")
+	// ErrLegacyEntry is returned for an entry in the legacy format past the
+	// point where the chain started using configured keys.
+	ErrLegacyEntry = errors.New("legacy-format entry after the legacy boundary")
 )
 
 // ImmutableLog provides cryptographic integrity for audit log entries
-// using hash chains and signatures (WORM pattern).
+// using hash chains and signatures (WORM pattern). Each entry's hash covers
+// its sequence number, contents and the previous entry's hash, and is
+// signed with a key from the keyring.
 type ImmutableLog struct {
-	signingKey []byte
+	keys *Keyring
 }
 
-// NewImmutableLog creates an immutable log handler.
-func NewImmutableLog() *ImmutableLog {
-	return &ImmutableLog{
-		signingKey: []byte("compliance-audit-signing-key"),
-	}
+// NewImmutableLog creates an immutable log handler signing with keys.
+func NewImmutableLog(keys *Keyring) *ImmutableLog {
+	return &ImmutableLog{keys: keys}
 }
 
 // ComputeHash generates the hash chain entry by hashing the current log
 // combined with the previous hash, creating tamper-evident linkage.
+// Entries without a key ID predate sequencing and use the original format,
+// which covers only the event summary.
 func (il *ImmutableLog) ComputeHash(entry AuditLogEntry, previousHash string) string {
-	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s",
-		entry.EventTime.UTC().Format("2006-01-02T15:04:05Z"),
-		entry.EventType,
-		entry.EventAction,
-		entry.ServiceName,
-		entry.ActorID,
-		entry.TargetID,
-		previousHash,
-	)
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("%x", hash)
+	if entry.KeyID == "" {
+		data := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s",
+			entry.EventTime.UTC().Format("2006-01-02T15:04:05Z"),
+			entry.EventType,
+			entry.EventAction,
+			entry.ServiceName,
+			entry.ActorID,
+			entry.TargetID,
+			previousHash,
+		)
+		hash := sha256.Sum256([]byte(data))
+		return fmt.Sprintf("%x", hash)
+	}
+	hash := sha256.Sum256(canonicalEntry(entry, previousHash))
+	return hex.EncodeToString(hash[:])
 }
 
-// Sign produces an HMAC signature of the log entry for tamper detection.
-func (il *ImmutableLog) Sign(entry AuditLogEntry) string {
+// Sign produces an HMAC signature of the log entry for tamper detection
+// with the key the entry names. New entries always name a configured key.
+func (il *ImmutableLog) Sign(entry AuditLogEntry) (string, error) {
+	if entry.KeyID == "" {
+		return "", ErrNoSigningKey
+	}
+	return il.mac(entry.KeyID, []byte("audit|"+strconv.FormatInt(entry.Sequence, 10)+"|"+entry.HashChain))
+}
+
+// legacySignature is the signature entries carried before keys came from
+// configuration. It is only used to verify them.
+func legacySignature(entry AuditLogEntry) string {
 	payload, _ := json.Marshal(map[string]interface{}{
 		"event_time":   entry.EventTime.UTC().Format("2006-01-02T15:04:05Z"),
 		"event_type":   entry.EventType,
@@ -45,29 +76,147 @@ func (il *ImmutableLog) Sign(entry AuditLogEntry) string {
 		"service_name": entry.ServiceName,
 		"hash_chain":   entry.HashChain,
 	})
-
-	mac := hmac.New(sha256.New, il.signingKey)
+	mac := hmac.New(sha256.New, legacyKey)
 	mac.Write(payload)
-	return fmt.Sprintf("%x", mac.Sum(nil))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// VerifyChain validates that a sequence of entries has not been tampered with.
-func (il *ImmutableLog) VerifyChain(entries []AuditLogEntry) (bool, int) {
-	if len(entries) == 0 {
-		return true, 0
+// SignCheckpoint signs a checkpoint's range and Merkle root with the active
+// key, returning the key ID and signature.
+func (il *ImmutableLog) SignCheckpoint(from, to int64, root, lastHash string) (string, string, error) {
+	id := il.keys.ActiveID()
+	if id == "" {
+		return "", "", ErrNoSigningKey
+	}
+	sig, err := il.mac(id, checkpointPayload(from, to, root, lastHash))
+	return id, sig, err
+}
+
+// VerifyCheckpoint checks a checkpoint signature made with keyID.
+func (il *ImmutableLog) VerifyCheckpoint(keyID string, from, to int64, root, lastHash, signature string) error {
+	expected, err := il.mac(keyID, checkpointPayload(from, to, root, lastHash))
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// VerifyEntry checks that an entry follows previousHash and carries a
+// valid signature. The legacy format, signed with a key anyone can read,
+// is only accepted up to sequence legacyUntil, the last entry written
+// before keys came from configuration.
+func (il *ImmutableLog) VerifyEntry(entry AuditLogEntry, previousHash string, legacyUntil int64) error {
+	if entry.KeyID == "" && entry.Sequence > legacyUntil {
+		return ErrLegacyEntry
+	}
+	if entry.HashChain != il.ComputeHash(entry, previousHash) {
+		return ErrHashMismatch
 	}
+	var expected string
+	if entry.KeyID == "" {
+		expected = legacySignature(entry)
+	} else {
+		var err error
+		if expected, err = il.Sign(entry); err != nil {
+			return err
+		}
+	}
+	if !hmac.Equal([]byte(entry.Signature), []byte(expected)) {
+		return ErrBadSignature
+	}
+	return nil
+}
 
+// VerifyChain validates that a sequence of entries has not been tampered
+// with. It returns the index of the first bad entry, or -1.
+func (il *ImmutableLog) VerifyChain(entries []AuditLogEntry, legacyUntil int64) (bool, int) {
 	for i := 1; i < len(entries); i++ {
-		expectedHash := il.ComputeHash(entries[i], entries[i-1].HashChain)
-		if entries[i].HashChain != expectedHash {
+		if il.VerifyEntry(entries[i], entries[i-1].HashChain, legacyUntil) != nil {
 			return false, i
 		}
 	}
 	return true, -1
 }
 
-// VerifySignature checks the HMAC signature of a single entry.
-func (il *ImmutableLog) VerifySignature(entry AuditLogEntry) bool {
-	expected := il.Sign(entry)
-	return hmac.Equal([]byte(entry.Signature), []byte(expected))
+func (il *ImmutableLog) mac(keyID string, payload []byte) (string, error) {
+	key, err := il.keys.key(keyID)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// canonicalEntry is the byte form an entry's hash covers. Values are
+// normalized through JSON so they hash the same after a round trip through
+// the database.
+func canonicalEntry(entry AuditLogEntry, previousHash string) []byte {
+	payload, _ := json.Marshal(struct {
+		Sequence         int64  `json:"sequence"`
+		KeyID            string `json:"key_id"`
+		EventTime        string `json:"event_time"`
+		EventType        string `json:"event_type"`
+		EventAction      string `json:"event_action"`
+		ActorID          string `json:"actor_id"`
+		ActorType        string `json:"actor_type"`
+		ActorIP          string `json:"actor_ip"`
+		TargetType       string `json:"target_type"`
+		TargetID         string `json:"target_id"`
+		TargetOwnerID    string `json:"target_owner_id"`
+		DataCategory     string `json:"data_category"`
+		SensitivityLevel string `json:"sensitivity_level"`
+		ServiceName      string `json:"service_name"`
+		Endpoint         string `json:"endpoint"`
+		HTTPMethod       string `json:"http_method"`
+		OldValues        any    `json:"old_values"`
+		NewValues        any    `json:"new_values"`
+		PermissionUsed   string `json:"permission_used"`
+		PreviousHash     string `json:"previous_hash"`
+	}{
+		Sequence:         entry.Sequence,
+		KeyID:            entry.KeyID,
+		EventTime:        entry.EventTime.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		EventType:        entry.EventType,
+		EventAction:      entry.EventAction,
+		ActorID:          entry.ActorID,
+		ActorType:        entry.ActorType,
+		ActorIP:          entry.ActorIP,
+		TargetType:       entry.TargetType,
+		TargetID:         entry.TargetID,
+		TargetOwnerID:    entry.TargetOwnerID,
+		DataCategory:     entry.DataCategory,
+		SensitivityLevel: entry.SensitivityLevel,
+		ServiceName:      entry.ServiceName,
+		Endpoint:         entry.Endpoint,
+		HTTPMethod:       entry.HTTPMethod,
+		OldValues:        normalizeValues(entry.OldValues),
+		NewValues:        normalizeValues(entry.NewValues),
+		PermissionUsed:   entry.PermissionUsed,
+		PreviousHash:     previousHash,
+	})
+	return payload
+}
+
+// normalizeValues returns values as they read back from a JSON column.
+func normalizeValues(values map[string]interface{}) any {
+	if values == nil {
+		return nil
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+func checkpointPayload(from, to int64, root, lastHash string) []byte {
+	return []byte(fmt.Sprintf("checkpoint|%d|%d|%s|%s", from, to, root, lastHash))
 }
@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	keyA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	keyB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestParseKeyring(t *testing.T) {
+	kr, err := ParseKeyring("2026a:"+keyA+", 2026b:"+keyB, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.ActiveID() != "2026b" {
+		t.Errorf("active key = %s, want the last listed", kr.ActiveID())
+	}
+	if kr, err = ParseKeyring("2026a:"+keyA+",2026b:"+keyB, "2026a"); err != nil || kr.ActiveID() != "2026a" {
+		t.Errorf("explicit active key: %v, %v", kr, err)
+	}
+	if kr, err = ParseKeyring("", ""); err != nil || kr.ActiveID() != "" {
+		t.Errorf("empty spec should give a keyring that cannot sign: %v", err)
+	}
+	if _, err := (&Logger{immutable: NewImmutableLog(kr)}).BuildEntry(AuditLogEntry{EventType: "consent"}, 1, ""); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("building without a signing key: %v", err)
+	}
+	if _, _, err := NewImmutableLog(kr).SignCheckpoint(1, 2, "root", "last"); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("checkpoint without a signing key: %v", err)
+	}
+	for _, spec := range []string{"nokey", "short:abcd", "bad:zz" + keyA, LegacyKeyID + ":" + keyA, DevKeyID + ":" + keyA} {
+		if _, err := ParseKeyring(spec, ""); err == nil {
+			t.Errorf("ParseKeyring(%q) accepted", spec)
+		}
+	}
+	if _, err := ParseKeyring("2026a:"+keyA, "missing"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown active key: %v", err)
+	}
+}
+
+func TestChainAcrossKeyRotation(t *testing.T) {
+	old, _ := ParseKeyring("2026a:"+keyA, "")
+	rotated, _ := ParseKeyring("2026a:"+keyA+",2026b:"+keyB, "")
+	logger := &Logger{immutable: NewImmutableLog(old)}
+
+	first, err := logger.BuildEntry(AuditLogEntry{
+		EventTime:   time.Date(2026, 10, 17, 9, 0, 0, 123456789, time.UTC),
+		EventType:   "data_access",
+		EventAction: "read",
+		ServiceName: "documents",
+		NewValues:   map[string]any{"count": 3, "when": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetKeyring(rotated)
+	second, err := logger.BuildEntry(AuditLogEntry{EventType: "data_export", EventAction: "generate", ServiceName: "compliance"}, 2, first.HashChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.KeyID != "2026a" || second.KeyID != "2026b" {
+		t.Fatalf("key IDs = %s, %s", first.KeyID, second.KeyID)
+	}
+
+	// Values read back from a JSON column must still verify.
+	raw, _ := json.Marshal(first.NewValues)
+	var stored map[string]any
+	_ = json.Unmarshal(raw, &stored)
+	readBack := first
+	readBack.NewValues = stored
+
+	il := logger.Immutable()
+	if err := il.VerifyEntry(readBack, "", 0); err != nil {
+		t.Errorf("first entry: %v", err)
+	}
+	if ok, i := il.VerifyChain([]AuditLogEntry{readBack, second}, 0); !ok {
+		t.Errorf("chain broken at %d", i)
+	}
+
+	tampered := second
+	tampered.EventAction = "delete"
+	if err := il.VerifyEntry(tampered, first.HashChain, 0); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("tampered entry: %v", err)
+	}
+	resigned := second
+	resigned.Sequence = 3
+	if err := il.VerifyEntry(resigned, first.HashChain, 0); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("resequenced entry: %v", err)
+	}
+	forged := second
+	forged.Signature = strings.Repeat("0", 64)
+	if err := il.VerifyEntry(forged, first.HashChain, 0); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("forged signature: %v", err)
+	}
+	if err := NewImmutableLog(old).VerifyEntry(second, first.HashChain, 0); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("verifying without the signing key: %v", err)
+	}
+}
+
+func TestLegacyEntriesVerify(t *testing.T) {
+	il := NewImmutableLog(NewKeyring())
+	entry := AuditLogEntry{
+		Sequence:    5,
+		EventTime:   time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		EventType:   "consent",
+		EventAction: "record",
+		ServiceName: "compliance",
+	}
+	entry.HashChain = il.ComputeHash(entry, "prev")
+	entry.Signature = legacySignature(entry)
+	if _, err := il.Sign(entry); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("signing in the legacy format: %v", err)
+	}
+	rotated, _ := ParseKeyring("2026a:"+keyA, "")
+	if err := NewImmutableLog(rotated).VerifyEntry(entry, "prev", 5); err != nil {
+		t.Errorf("legacy entry after rotation: %v", err)
+	}
+	// Anyone can sign in the legacy format, so it is refused past the
+	// boundary, and the legacy key cannot be named by a keyed entry.
+	if err := il.VerifyEntry(entry, "prev", 4); !errors.Is(err, ErrLegacyEntry) {
+		t.Errorf("legacy entry past the boundary: %v", err)
+	}
+	relabelled := entry
+	relabelled.KeyID = LegacyKeyID
+	relabelled.HashChain = il.ComputeHash(relabelled, "prev")
+	if err := il.VerifyEntry(relabelled, "prev", 5); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("entry naming the legacy key: %v", err)
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	leaf := func(b byte) string {
+		sum := sha256.Sum256([]byte{b})
+		return hex.EncodeToString(sum[:])
+	}
+	h := func(prefix byte, parts ...string) []byte {
+		buf := []byte{prefix}
+		for _, p := range parts {
+			raw, _ := hex.DecodeString(p)
+			buf = append(buf, raw...)
+		}
+		sum := sha256.Sum256(buf)
+		return sum[:]
+	}
+	a, b, c := leaf(1), leaf(2), leaf(3)
+	la, lb, lc := hex.EncodeToString(h(0, a)), hex.EncodeToString(h(0, b)), hex.EncodeToString(h(0, c))
+	want := hex.EncodeToString(h(1, hex.EncodeToString(h(1, la, lb)), lc))
+
+	got, err := MerkleRoot([]string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("root = %s, want %s", got, want)
+	}
+	if single, _ := MerkleRoot([]string{a}); single != la {
+		t.Errorf("single-leaf root = %s, want %s", single, la)
+	}
+	if swapped, _ := MerkleRoot([]string{b, a, c}); swapped == got {
+		t.Error("root does not depend on order")
+	}
+	if _, err := MerkleRoot([]string{"not-hex"}); err == nil {
+		t.Error("non-hex leaf accepted")
+	}
+}
+
+func TestCheckpointSignatureAndAnchor(t *testing.T) {
+	kr, _ := ParseKeyring("2026a:"+keyA, "")
+	il := NewImmutableLog(kr)
+	root := strings.Repeat("ab", 32)
+	keyID, sig, err := il.SignCheckpoint(1, 10, root, "last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := il.VerifyCheckpoint(keyID, 1, 10, root, "last", sig); err != nil {
+		t.Errorf("checkpoint signature: %v", err)
+	}
+	if err := il.VerifyCheckpoint(keyID, 1, 11, root, "last", sig); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("extended checkpoint: %v", err)
+	}
+
+	receipt, err := NewLocalStellarAnchor().Anchor(context.Background(), root)
+	if err != nil || receipt.Memo != root || receipt.Network != "stellar-local" || len(receipt.TxID) != 64 {
+		t.Errorf("anchor receipt = %+v, %v", receipt, err)
+	}
+	if _, err := NewLocalStellarAnchor().Anchor(context.Background(), "abcd"); err == nil {
+		t.Error("short memo accepted")
+	}
+}
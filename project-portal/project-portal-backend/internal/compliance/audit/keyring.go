@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LegacyKeyID is reserved for the key that signed entries written before
+// keys came from configuration. That key is public, so it only verifies
+// entries up to the chain's legacy boundary and never signs.
+const LegacyKeyID = "legacy"
+
+var legacyKey = []byte("compliance-audit-signing-key")
+
+// DevKeyID names the fixed key used for development when no key is
+// configured.
+const DevKeyID = "dev"
+
+var devKey = []byte("compliance-audit-dev-signing-key!")
+
+var (
+	// ErrUnknownKey is returned when an entry names a key the keyring does
+	// not hold.
+	ErrUnknownKey = errors.New("unknown audit signing key")
+	// ErrNoSigningKey is returned when signing with a keyring that has no
+	// active key.
+	ErrNoSigningKey = errors.New("no audit signing key is configured")
+)
+
+// Keyring holds the audit signing keys by ID. New entries are signed with
+// the active key; retired keys are kept to verify older entries.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+// NewKeyring returns an empty keyring. It verifies legacy entries but
+// cannot sign.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string][]byte{}}
+}
+
+// DevKeyring returns a keyring signing with the fixed development key. It
+// must not be used outside development.
+func DevKeyring() *Keyring {
+	return &Keyring{keys: map[string][]byte{DevKeyID: devKey}, active: DevKeyID}
+}
+
+// ParseKeyring reads comma-separated "id:hexkey" pairs. The key named by
+// active signs new entries; when active is empty the last pair does. An
+// empty spec gives a keyring that cannot sign.
+func ParseKeyring(spec, active string) (*Keyring, error) {
+	kr := NewKeyring()
+	last := ""
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, keyHex, ok := strings.Cut(pair, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || id == LegacyKeyID || id == DevKeyID {
+			return nil, fmt.Errorf("invalid audit key %q: want id:hexkey", id)
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(keyHex))
+		if err != nil {
+			return nil, fmt.Errorf("audit key %s: %w", id, err)
+		}
+		if len(key) < 32 {
+			return nil, fmt.Errorf("audit key %s must be at least 32 bytes", id)
+		}
+		kr.keys[id] = key
+		last = id
+	}
+	if active == "" {
+		active = last
+	}
+	if active != "" {
+		if _, ok := kr.keys[active]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, active)
+		}
+		kr.active = active
+	}
+	return kr, nil
+}
+
+// ActiveID returns the ID of the key that signs new entries, or "" when
+// the keyring cannot sign.
+func (k *Keyring) ActiveID() string {
+	return k.active
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
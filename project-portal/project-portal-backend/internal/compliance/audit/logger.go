@@ -6,6 +6,8 @@ import (
 
 // AuditLogEntry represents data needed to create an audit log (sub-package local type).
 type AuditLogEntry struct {
+	Sequence         int64
+	KeyID            string
 	EventTime        time.Time
 	EventType        string
 	EventAction      string
@@ -27,35 +29,51 @@ type AuditLogEntry struct {
 	Signature        string
 }
 
-// LogCreator defines the interface for persisting audit logs.
-type LogCreator interface {
-	InsertAuditLog(entry AuditLogEntry) error
-	GetLastHash() (string, error)
-}
-
 // Logger handles audit log creation with hash chain integrity.
 type Logger struct {
 	immutable *ImmutableLog
 }
 
-// NewLogger creates a new audit logger.
+// NewLogger creates a new audit logger. It cannot sign entries until
+// SetKeyring is given a keyring with an active key.
 func NewLogger() *Logger {
 	return &Logger{
-		immutable: NewImmutableLog(),
+		immutable: NewImmutableLog(NewKeyring()),
 	}
 }
 
-// BuildEntry prepares an audit log entry with hash chain linkage and signature.
-func (l *Logger) BuildEntry(entry AuditLogEntry, previousHash string) AuditLogEntry {
+// SetKeyring replaces the keys entries are signed and verified with.
+func (l *Logger) SetKeyring(keys *Keyring) {
+	l.immutable = NewImmutableLog(keys)
+}
+
+// Immutable returns the hash and signature scheme used for entries.
+func (l *Logger) Immutable() *ImmutableLog {
+	return l.immutable
+}
+
+// BuildEntry prepares the entry at sequence with hash chain linkage to
+// previousHash and a signature from the active key. The event time is cut
+// to the microsecond precision the database stores.
+func (l *Logger) BuildEntry(entry AuditLogEntry, sequence int64, previousHash string) (AuditLogEntry, error) {
 	if entry.EventTime.IsZero() {
 		entry.EventTime = time.Now()
 	}
+	entry.EventTime = entry.EventTime.Truncate(time.Microsecond)
 	if entry.SensitivityLevel == "" {
 		entry.SensitivityLevel = "normal"
 	}
+	entry.Sequence = sequence
+	entry.KeyID = l.immutable.keys.ActiveID()
+	if entry.KeyID == "" {
+		return entry, ErrNoSigningKey
+	}
 
 	entry.HashChain = l.immutable.ComputeHash(entry, previousHash)
-	entry.Signature = l.immutable.Sign(entry)
-
-	return entry
+	sig, err := l.immutable.Sign(entry)
+	if err != nil {
+		return entry, err
+	}
+	entry.Signature = sig
+	return entry, nil
 }
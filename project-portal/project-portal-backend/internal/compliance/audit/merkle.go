@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MerkleRoot returns the root of a SHA-256 Merkle tree over hex-encoded
+// entry hashes, in order. Leaves and inner nodes are hashed with distinct
+// prefixes so one cannot pass for the other; an odd node is carried up
+// unchanged. The root of an empty list is empty.
+func MerkleRoot(hashes []string) (string, error) {
+	if len(hashes) == 0 {
+		return "", nil
+	}
+	level := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		raw, err := hex.DecodeString(h)
+		if err != nil {
+			return "", fmt.Errorf("leaf %d: %w", i, err)
+		}
+		sum := sha256.Sum256(append([]byte{0x00}, raw...))
+		level[i] = sum[:]
+	}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			node := make([]byte, 0, 1+2*sha256.Size)
+			node = append(node, 0x01)
+			node = append(node, level[i]...)
+			node = append(node, level[i+1]...)
+			sum := sha256.Sum256(node)
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	return hex.EncodeToString(level[0]), nil
+}
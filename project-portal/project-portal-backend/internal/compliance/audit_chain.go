@@ -0,0 +1,424 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	auditpkg "carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNoNewAuditEntries is returned when every audit entry is already
+	// covered by a checkpoint.
+	ErrNoNewAuditEntries = errors.New("no audit entries since the last checkpoint")
+	// ErrAuditChainBroken is returned when a checkpoint would cover entries
+	// that fail verification.
+	ErrAuditChainBroken = errors.New("audit chain failed verification")
+	// ErrCheckpointNotFound is returned for an unknown checkpoint.
+	ErrCheckpointNotFound = errors.New("audit checkpoint not found")
+)
+
+const (
+	// verifyBatch is the number of entries read at a time when walking the
+	// chain.
+	verifyBatch = 1000
+	// maxChainProblems caps the problems a verification reports.
+	maxChainProblems = 100
+	// maxCheckpointEntries caps the entries one checkpoint covers, so the
+	// first checkpoint over a long chain stays bounded.
+	maxCheckpointEntries = 10000
+)
+
+// ChainProblem is one entry that failed verification.
+type ChainProblem struct {
+	Sequence int64  `json:"sequence"`
+	LogID    int64  `json:"log_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// ChainVerification is the result of walking a range of the audit chain.
+type ChainVerification struct {
+	FromSequence int64          `json:"from_sequence"`
+	ToSequence   int64          `json:"to_sequence"`
+	Checked      int64          `json:"checked"`
+	Valid        bool           `json:"valid"`
+	FirstInvalid *int64         `json:"first_invalid,omitempty"`
+	Problems     []ChainProblem `json:"problems,omitempty"`
+	Truncated    bool           `json:"truncated,omitempty"`
+	VerifiedAt   time.Time      `json:"verified_at"`
+}
+
+// CheckpointVerification compares a checkpoint with the entries it covers.
+type CheckpointVerification struct {
+	Checkpoint     *AuditCheckpoint `json:"checkpoint"`
+	MerkleRoot     string           `json:"merkle_root"`
+	RootMatches    bool             `json:"root_matches"`
+	SignatureValid bool             `json:"signature_valid"`
+	AnchorMatches  *bool            `json:"anchor_matches,omitempty"`
+	Valid          bool             `json:"valid"`
+	VerifiedAt     time.Time        `json:"verified_at"`
+}
+
+// CheckpointLeaf is one entry hash in an exported checkpoint.
+type CheckpointLeaf struct {
+	Sequence int64  `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// CheckpointExport is a checkpoint with the entry hashes its Merkle root is
+// built from, so the root can be recomputed outside the system.
+type CheckpointExport struct {
+	Checkpoint *AuditCheckpoint `json:"checkpoint"`
+	Algorithm  string           `json:"algorithm"`
+	Leaves     []CheckpointLeaf `json:"leaves"`
+	ExportedAt time.Time        `json:"exported_at"`
+}
+
+// SetAuditKeys sets the keys audit entries and checkpoints are signed and
+// verified with.
+func (s *Service) SetAuditKeys(keys *auditpkg.Keyring) {
+	s.auditLogger.SetKeyring(keys)
+}
+
+// SetAuditAnchor sets where checkpoint roots are published. Checkpoints are
+// only stored locally until it is called.
+func (s *Service) SetAuditAnchor(anchor auditpkg.Anchor) {
+	s.anchor = anchor
+}
+
+// VerifyAuditChain walks the entries with sequence numbers in [from, to]
+// and checks that they are contiguous, that each hash covers the entry and
+// its predecessor, and that each signature matches. A to of zero means the
+// head of the chain.
+func (s *Service) VerifyAuditChain(ctx context.Context, from, to int64) (*ChainVerification, error) {
+	if from < 1 {
+		from = 1
+	}
+	if to <= 0 {
+		head, err := s.repo.GetLastAuditLog(ctx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ChainVerification{FromSequence: from, Valid: true, VerifiedAt: time.Now()}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading chain head: %w", err)
+		}
+		to = head.Sequence
+	}
+	v := &ChainVerification{FromSequence: from, ToSequence: to, Valid: true}
+	_, err := s.walkChain(ctx, from, to, func(p ChainProblem) {
+		if v.Valid {
+			first := p.Sequence
+			v.FirstInvalid = &first
+		}
+		v.Valid = false
+		if len(v.Problems) < maxChainProblems {
+			v.Problems = append(v.Problems, p)
+		} else {
+			v.Truncated = true
+		}
+	}, func(*AuditLog) { v.Checked++ })
+	if err != nil {
+		return nil, err
+	}
+	v.VerifiedAt = time.Now()
+	return v, nil
+}
+
+// CreateAuditCheckpoint verifies the entries after the latest checkpoint,
+// up to a bounded number, and records their Merkle root, signed with the
+// active key. When an anchor is set the root is published there too.
+func (s *Service) CreateAuditCheckpoint(ctx context.Context) (*AuditCheckpoint, error) {
+	var from int64 = 1
+	latest, err := s.repo.GetLatestAuditCheckpoint(ctx)
+	switch {
+	case err == nil:
+		from = latest.ToSequence + 1
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("reading latest checkpoint: %w", err)
+	}
+	head, err := s.repo.GetLastAuditLog(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoNewAuditEntries
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading chain head: %w", err)
+	}
+	if head.Sequence < from {
+		return nil, ErrNoNewAuditEntries
+	}
+	to := min(head.Sequence, from+maxCheckpointEntries-1)
+
+	var hashes []string
+	var problem *ChainProblem
+	last, err := s.walkChain(ctx, from, to, func(p ChainProblem) {
+		if problem == nil {
+			problem = &p
+		}
+	}, func(l *AuditLog) { hashes = append(hashes, l.HashChain) })
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		log.Printf("WARNING: audit chain broken at sequence %d: %s", problem.Sequence, problem.Reason)
+		return nil, fmt.Errorf("%w at sequence %d: %s", ErrAuditChainBroken, problem.Sequence, problem.Reason)
+	}
+
+	root, err := auditpkg.MerkleRoot(hashes)
+	if err != nil {
+		return nil, fmt.Errorf("computing merkle root: %w", err)
+	}
+	keyID, sig, err := s.auditLogger.Immutable().SignCheckpoint(from, to, root, last)
+	if err != nil {
+		return nil, fmt.Errorf("signing checkpoint: %w", err)
+	}
+	cp := &AuditCheckpoint{
+		FromSequence: from,
+		ToSequence:   to,
+		EntryCount:   int64(len(hashes)),
+		MerkleRoot:   root,
+		LastHash:     last,
+		KeyID:        keyID,
+		Signature:    sig,
+	}
+	if err := s.repo.CreateAuditCheckpoint(ctx, cp); err != nil {
+		return nil, fmt.Errorf("storing checkpoint: %w", err)
+	}
+
+	if s.anchor != nil {
+		receipt, err := s.anchor.Anchor(ctx, root)
+		if err != nil {
+			log.Printf("WARNING: anchoring audit checkpoint %s: %v", cp.ID, err)
+			return cp, nil
+		}
+		cp.AnchorNetwork = receipt.Network
+		cp.AnchorTxID = receipt.TxID
+		cp.AnchorMemo = receipt.Memo
+		cp.AnchoredAt = &receipt.AnchoredAt
+		if err := s.repo.UpdateAuditCheckpoint(ctx, cp); err != nil {
+			return nil, fmt.Errorf("recording checkpoint anchor: %w", err)
+		}
+	}
+	return cp, nil
+}
+
+// ListAuditCheckpoints returns checkpoints, newest first.
+func (s *Service) ListAuditCheckpoints(ctx context.Context, limit, offset int) ([]AuditCheckpoint, int64, error) {
+	return s.repo.ListAuditCheckpoints(ctx, limit, offset)
+}
+
+// VerifyAuditCheckpoint recomputes a checkpoint's Merkle root from the
+// entries it covers and checks its signature and anchor memo.
+func (s *Service) VerifyAuditCheckpoint(ctx context.Context, id string) (*CheckpointVerification, error) {
+	export, err := s.ExportAuditCheckpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cp := export.Checkpoint
+	hashes := make([]string, len(export.Leaves))
+	for i, leaf := range export.Leaves {
+		hashes[i] = leaf.Hash
+	}
+	root, err := auditpkg.MerkleRoot(hashes)
+	if err != nil {
+		return nil, fmt.Errorf("computing merkle root: %w", err)
+	}
+	last := ""
+	if n := len(hashes); n > 0 {
+		last = hashes[n-1]
+	}
+
+	v := &CheckpointVerification{
+		Checkpoint:     cp,
+		MerkleRoot:     root,
+		RootMatches:    root == cp.MerkleRoot && last == cp.LastHash && int64(len(hashes)) == cp.EntryCount,
+		SignatureValid: s.auditLogger.Immutable().VerifyCheckpoint(cp.KeyID, cp.FromSequence, cp.ToSequence, cp.MerkleRoot, cp.LastHash, cp.Signature) == nil,
+		VerifiedAt:     time.Now(),
+	}
+	v.Valid = v.RootMatches && v.SignatureValid
+	if cp.AnchorMemo != "" {
+		matches := cp.AnchorMemo == root
+		v.AnchorMatches = &matches
+		v.Valid = v.Valid && matches
+	}
+	return v, nil
+}
+
+// ExportAuditCheckpoint returns a checkpoint with the entry hashes it
+// covers.
+func (s *Service) ExportAuditCheckpoint(ctx context.Context, id string) (*CheckpointExport, error) {
+	cp, err := s.repo.GetAuditCheckpoint(ctx, id)
+	if err != nil {
+		return nil, ErrCheckpointNotFound
+	}
+	export := &CheckpointExport{
+		Checkpoint: cp,
+		Algorithm:  "sha256-merkle: leaf = H(0x00 || hash), node = H(0x01 || left || right), odd node carried up",
+		Leaves:     make([]CheckpointLeaf, 0, cp.EntryCount),
+	}
+	for next := cp.FromSequence; next <= cp.ToSequence; {
+		logs, err := s.repo.ListAuditLogsBySequence(ctx, next, cp.ToSequence, verifyBatch)
+		if err != nil {
+			return nil, fmt.Errorf("reading audit entries: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+		for _, l := range logs {
+			export.Leaves = append(export.Leaves, CheckpointLeaf{Sequence: l.Sequence, Hash: l.HashChain})
+		}
+		next = logs[len(logs)-1].Sequence + 1
+	}
+	export.ExportedAt = time.Now()
+	return export, nil
+}
+
+// StartCheckpointSweep checkpoints new audit entries every interval until
+// ctx is cancelled.
+func (s *Service) StartCheckpointSweep(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for ctx.Err() == nil {
+					cp, err := s.CreateAuditCheckpoint(ctx)
+					if errors.Is(err, ErrNoNewAuditEntries) {
+						break
+					}
+					if err != nil {
+						log.Printf("WARNING: audit checkpoint failed: %v", err)
+						break
+					}
+					log.Printf("🔗 Audit checkpoint %d-%d root %s", cp.FromSequence, cp.ToSequence, cp.MerkleRoot)
+				}
+			}
+		}
+	}()
+}
+
+// walkChain verifies the entries in [from, to] in batches, reporting each
+// problem and passing each entry to visit. It returns the hash of the last
+// entry read.
+func (s *Service) walkChain(ctx context.Context, from, to int64, report func(ChainProblem), visit func(*AuditLog)) (string, error) {
+	prevHash := ""
+	if from > 1 {
+		prev, err := s.repo.GetAuditLogBySequence(ctx, from-1)
+		switch {
+		case err == nil:
+			prevHash = prev.HashChain
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			report(ChainProblem{Sequence: from - 1, Reason: "preceding entry is missing"})
+		default:
+			return "", fmt.Errorf("reading audit entry %d: %w", from-1, err)
+		}
+	}
+
+	legacyUntil, err := s.repo.GetAuditLegacyBoundary(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading audit legacy boundary: %w", err)
+	}
+	immutable := s.auditLogger.Immutable()
+	expected := from
+	for expected <= to {
+		logs, err := s.repo.ListAuditLogsBySequence(ctx, expected, to, verifyBatch)
+		if err != nil {
+			return "", fmt.Errorf("reading audit entries: %w", err)
+		}
+		if len(logs) == 0 {
+			report(ChainProblem{Sequence: expected, Reason: fmt.Sprintf("entries %d-%d are missing", expected, to)})
+			break
+		}
+		for i := range logs {
+			l := &logs[i]
+			if l.Sequence != expected {
+				report(ChainProblem{Sequence: expected, Reason: fmt.Sprintf("entries %d-%d are missing", expected, l.Sequence-1)})
+			}
+			if err := immutable.VerifyEntry(auditEntryFromLog(l), prevHash, legacyUntil); err != nil {
+				report(ChainProblem{Sequence: l.Sequence, LogID: l.LogID, Reason: err.Error()})
+			}
+			visit(l)
+			prevHash = l.HashChain
+			expected = l.Sequence + 1
+		}
+	}
+	return prevHash, nil
+}
+
+// auditLogFromEntry is the stored form of a built entry.
+func auditLogFromEntry(built auditpkg.AuditLogEntry) *AuditLog {
+	log := &AuditLog{
+		Sequence:         built.Sequence,
+		KeyID:            built.KeyID,
+		EventTime:        built.EventTime,
+		EventType:        built.EventType,
+		EventAction:      built.EventAction,
+		ActorType:        built.ActorType,
+		ActorIP:          built.ActorIP,
+		TargetType:       built.TargetType,
+		DataCategory:     built.DataCategory,
+		SensitivityLevel: built.SensitivityLevel,
+		ServiceName:      built.ServiceName,
+		Endpoint:         built.Endpoint,
+		HTTPMethod:       built.HTTPMethod,
+		OldValues:        built.OldValues,
+		NewValues:        built.NewValues,
+		PermissionUsed:   built.PermissionUsed,
+		HashChain:        built.HashChain,
+		Signature:        built.Signature,
+	}
+	if built.ActorID != "" {
+		log.ActorID = &built.ActorID
+	}
+	if built.TargetID != "" {
+		log.TargetID = &built.TargetID
+	}
+	if built.TargetOwnerID != "" {
+		log.TargetOwnerID = &built.TargetOwnerID
+	}
+	return log
+}
+
+// auditEntryFromLog is the entry a stored log was built from.
+func auditEntryFromLog(l *AuditLog) auditpkg.AuditLogEntry {
+	entry := auditpkg.AuditLogEntry{
+		Sequence:         l.Sequence,
+		KeyID:            l.KeyID,
+		EventTime:        l.EventTime,
+		EventType:        l.EventType,
+		EventAction:      l.EventAction,
+		ActorType:        l.ActorType,
+		ActorIP:          l.ActorIP,
+		TargetType:       l.TargetType,
+		DataCategory:     l.DataCategory,
+		SensitivityLevel: l.SensitivityLevel,
+		ServiceName:      l.ServiceName,
+		Endpoint:         l.Endpoint,
+		HTTPMethod:       l.HTTPMethod,
+		OldValues:        l.OldValues,
+		NewValues:        l.NewValues,
+		PermissionUsed:   l.PermissionUsed,
+		HashChain:        l.HashChain,
+		Signature:        l.Signature,
+	}
+	if l.ActorID != nil {
+		entry.ActorID = *l.ActorID
+	}
+	if l.TargetID != nil {
+		entry.TargetID = *l.TargetID
+	}
+	if l.TargetOwnerID != nil {
+		entry.TargetOwnerID = *l.TargetOwnerID
+	}
+	return entry
+}
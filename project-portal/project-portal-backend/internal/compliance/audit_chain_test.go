@@ -0,0 +1,32 @@
+package compliance
+
+import (
+	"testing"
+
+	auditpkg "carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+)
+
+func TestStoredAuditEntriesVerify(t *testing.T) {
+	logger := auditpkg.NewLogger()
+	logger.SetKeyring(auditpkg.DevKeyring())
+	built, err := logger.BuildEntry(auditpkg.AuditLogEntry{
+		EventType:     "data_retention",
+		EventAction:   "delete",
+		ActorID:       "5f0c2a4e-8d1b-4c57-9a1e-2b7f3d6c8e90",
+		TargetType:    "retention_policy",
+		TargetID:      "0b9d8c7e-6f5a-4b3c-8d2e-1f0a9b8c7d6e",
+		ServiceName:   "compliance",
+		NewValues:     map[string]any{"records_affected": int64(42)},
+		TargetOwnerID: "",
+	}, 7, "prev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := auditLogFromEntry(built)
+	if stored.Sequence != 7 || stored.KeyID != auditpkg.DevKeyID || stored.TargetOwnerID != nil {
+		t.Fatalf("stored entry = %+v", stored)
+	}
+	if err := logger.Immutable().VerifyEntry(auditEntryFromLog(stored), "prev", 0); err != nil {
+		t.Errorf("stored entry does not verify: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests/datasource"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRequestNotProcessable = errors.New("privacy request cannot be processed in its current state")
+	ErrCertificateNotFound   = errors.New("deletion certificate not found")
+)
+
+// defaultCertificateKey signs deletion certificates until SetCertificateKey
+// is called.
+const defaultCertificateKey = "compliance-certificate-signing-key"
+
+// NewPrivacySource returns the data source for the compliance module's own
+// records of a user. Privacy preferences are deleted; consent records are
+// kept as evidence of consent.
+func NewPrivacySource(db *gorm.DB) *datasource.Source {
+	return datasource.New("compliance", db,
+		datasource.Table{Name: "privacy_preferences", Category: DataCategoryUserProfile, Match: "user_id = ?"},
+		datasource.Table{Name: "consent_records", Category: DataCategoryConsentRecords, Match: "user_id = ?"},
+	)
+}
+
+// RetentionTargets returns the compliance tables retention policies act on.
+// Consent records lose their network details when anonymized. Audit logs
+// are write-once and never registered.
+func RetentionTargets() []retention.Target {
+	return []retention.Target{
+		{Table: "consent_records", Category: DataCategoryConsentRecords, UserColumn: "user_id",
+			Anonymize:  map[string]any{"ip_address": "", "user_agent": "", "geolocation": ""},
+			Anonymized: "COALESCE(ip_address, '') = '' AND COALESCE(user_agent, '') = '' AND COALESCE(geolocation, '') = ''"},
+	}
+}
+
+// RegisterDataSource adds a module's data source to privacy request
+// processing.
+func (s *Service) RegisterDataSource(adapter requests.Adapter) {
+	s.processor.RegisterDataSource(adapter)
+}
+
+// SetCertificateKey sets the HMAC key that signs deletion certificates.
+func (s *Service) SetCertificateKey(key []byte) {
+	s.certKey = key
+}
+
+// ListDataSources returns the registered data sources.
+func (s *Service) ListDataSources() []requests.DataSource {
+	return s.processor.ListDataSources()
+}
+
+// DiscoverUserData returns where the user's data is held, with record
+// counts, optionally limited to categories.
+func (s *Service) DiscoverUserData(ctx context.Context, userID string, categories []string) ([]requests.DataLocation, error) {
+	return s.processor.Discover(ctx, userID, categories)
+}
+
+// ProcessDeletionRequest erases the subject's data for a received (or
+// previously failed) deletion request and issues a signed certificate.
+// Retained categories and data under legal hold are kept and listed in the
+// certificate. The request fails when any location could not be erased.
+func (s *Service) ProcessDeletionRequest(ctx context.Context, requestID, actorID string) (*DeletionCertificate, error) {
+	req, err := s.repo.GetPrivacyRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching privacy request: %w", err)
+	}
+	if req.RequestType != RequestTypeDeletion || (req.Status != RequestStatusReceived && req.Status != RequestStatusFailed) {
+		return nil, ErrRequestNotProcessable
+	}
+
+	s.verifyByOfficer(ctx, req, actorID)
+	req.Status = RequestStatusProcessing
+	req.ErrorMessage = ""
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		return nil, fmt.Errorf("updating privacy request: %w", err)
+	}
+
+	holds, err := s.repo.ListActiveLegalHolds(ctx)
+	if err != nil {
+		return nil, s.failRequest(ctx, req, fmt.Errorf("listing legal holds: %w", err))
+	}
+	result, err := s.processor.ProcessDeletionRequest(ctx, req.UserID, req.DataCategories, subjectHolds(holds, req.UserID, time.Now()))
+	if err != nil {
+		return nil, s.failRequest(ctx, req, err)
+	}
+
+	issued := requests.IssueCertificate(req.ID, result, s.certKey)
+	cert := &DeletionCertificate{
+		RequestID: issued.RequestID,
+		UserID:    issued.UserID,
+		IssuedAt:  issued.IssuedAt,
+		Verified:  issued.Verified,
+		Entries:   issued.Entries,
+		Digest:    issued.Digest,
+		Signature: issued.Signature,
+	}
+	if err := s.repo.CreateDeletionCertificate(ctx, cert); err != nil {
+		return nil, s.failRequest(ctx, req, fmt.Errorf("storing deletion certificate: %w", err))
+	}
+
+	req.DeletionSummary = toMap(result)
+	req.DeletionSummary["certificate_id"] = cert.ID
+	if len(result.FailedCategories) > 0 {
+		req.Status = RequestStatusFailed
+		req.ErrorMessage = "erasure failed for: " + strings.Join(result.FailedCategories, ", ")
+	} else {
+		now := time.Now()
+		req.Status = RequestStatusCompleted
+		req.CompletedAt = &now
+	}
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		return nil, fmt.Errorf("updating privacy request: %w", err)
+	}
+
+	if err := s.LogAuditEvent(ctx, AuditEntry{
+		EventType:        "data_deletion",
+		EventAction:      "erase",
+		ActorID:          actorID,
+		ActorType:        actorType(actorID),
+		TargetType:       "privacy_request",
+		TargetID:         req.ID,
+		TargetOwnerID:    req.UserID,
+		SensitivityLevel: SensitivityHighly,
+		ServiceName:      "compliance",
+		NewValues: map[string]any{
+			"certificate_id":      cert.ID,
+			"digest":              cert.Digest,
+			"verified":            cert.Verified,
+			"deleted_categories":  result.DeletedCategories,
+			"retained_categories": result.RetainedCategories,
+			"failed_categories":   result.FailedCategories,
+		},
+	}); err != nil {
+		log.Printf("WARNING: audit log for deletion request %s: %v", req.ID, err)
+	}
+	return cert, nil
+}
+
+// GetDeletionCertificate returns the latest certificate for a request.
+func (s *Service) GetDeletionCertificate(ctx context.Context, requestID string) (*DeletionCertificate, error) {
+	cert, err := s.repo.GetDeletionCertificate(ctx, requestID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCertificateNotFound
+	}
+	return cert, err
+}
+
+// VerifyDeletionCertificate re-checks the stored certificate's digest and
+// signature, detecting any change to it since it was issued.
+func (s *Service) VerifyDeletionCertificate(ctx context.Context, requestID string) (*CertificateVerification, error) {
+	cert, err := s.GetDeletionCertificate(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	v := &CertificateVerification{CertificateID: cert.ID, RequestID: cert.RequestID, Digest: cert.Digest, Valid: true}
+	if err := cert.certificate().Verify(s.certKey); err != nil {
+		v.Valid, v.Error = false, err.Error()
+	}
+	return v, nil
+}
+
+func (s *Service) failRequest(ctx context.Context, req *PrivacyRequest, cause error) error {
+	req.Status = RequestStatusFailed
+	req.ErrorMessage = cause.Error()
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		log.Printf("WARNING: marking privacy request %s failed: %v", req.ID, err)
+	}
+	s.logRequestEvent(ctx, req, "fail", map[string]any{"error": req.ErrorMessage})
+	return cause
+}
+
+// subjectHolds collects the active, unexpired legal holds that apply to a
+// user's deletion. A hold naming the user covers its categories, or all of
+// the user's data when it lists none; a hold naming no users covers its
+// categories for everyone. Projects named by any hold are kept.
+func subjectHolds(holds []LegalHold, userID string, now time.Time) requests.Holds {
+	out := requests.Holds{Categories: make(map[string]string)}
+	for _, h := range holds {
+		if h.Status != LegalHoldActive || (h.ExpiresAt != nil && !h.ExpiresAt.After(now)) {
+			continue
+		}
+		out.ProjectIDs = append(out.ProjectIDs, h.ProjectIDs...)
+
+		named := slices.Contains(h.AffectedUserIDs, userID)
+		switch {
+		case named && len(h.DataCategories) == 0:
+			if out.Subject == "" {
+				out.Subject = h.Name
+			}
+		case named || len(h.AffectedUserIDs) == 0:
+			for _, cat := range h.DataCategories {
+				if _, ok := out.Categories[cat]; !ok {
+					out.Categories[cat] = h.Name
+				}
+			}
+		}
+	}
+	return out
+}
+
+// toMap converts a result struct into the JSON object stored on a request.
+func toMap(v any) map[string]any {
+	data, _ := json.Marshal(v)
+	out := make(map[string]any)
+	_ = json.Unmarshal(data, &out)
+	return out
+}
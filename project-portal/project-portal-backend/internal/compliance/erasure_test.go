@@ -0,0 +1,34 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubjectHolds(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	holds := []LegalHold{
+		{Name: "user-only", Status: LegalHoldActive, AffectedUserIDs: []string{"u1"}, DataCategories: []string{DataCategoryProjectData}},
+		{Name: "everyone", Status: LegalHoldActive, DataCategories: []string{DataCategorySystemLogs}},
+		{Name: "other-user", Status: LegalHoldActive, AffectedUserIDs: []string{"u2"}},
+		{Name: "projects", Status: LegalHoldActive, ProjectIDs: []string{"p1"}},
+		{Name: "expired", Status: LegalHoldActive, AffectedUserIDs: []string{"u1"}, ExpiresAt: &past, ProjectIDs: []string{"p2"}},
+		{Name: "released", Status: LegalHoldReleased, AffectedUserIDs: []string{"u1"}},
+	}
+
+	got := subjectHolds(holds, "u1", now)
+	if got.Subject != "" {
+		t.Errorf("unexpected subject hold %q", got.Subject)
+	}
+	if got.Categories[DataCategoryProjectData] != "user-only" || got.Categories[DataCategorySystemLogs] != "everyone" || len(got.Categories) != 2 {
+		t.Errorf("categories: %v", got.Categories)
+	}
+	if len(got.ProjectIDs) != 1 || got.ProjectIDs[0] != "p1" {
+		t.Errorf("projects: %v", got.ProjectIDs)
+	}
+
+	if got := subjectHolds(holds, "u2", now); got.Subject != "other-user" {
+		t.Errorf("expected u2 to be fully held, got %+v", got)
+	}
+}
@@ -0,0 +1,287 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+)
+
+var (
+	// ErrExportStorageUnavailable is returned when no export storage has
+	// been configured.
+	ErrExportStorageUnavailable = errors.New("export storage is not configured")
+	// ErrExportNotFound is returned when a request has no export the caller
+	// may download.
+	ErrExportNotFound = errors.New("export not found")
+	// ErrExportExpired is returned once an export package has expired.
+	ErrExportExpired = errors.New("export has expired")
+	// ErrExportToken is returned for a missing or wrong download token.
+	ErrExportToken = errors.New("export download token is missing or incorrect")
+	// ErrExportIntegrity is returned when a stored package no longer matches
+	// the hash recorded on the request.
+	ErrExportIntegrity = errors.New("export package failed its integrity check")
+)
+
+// exportTTL is how long a completed export package can be downloaded.
+const exportTTL = 7 * 24 * time.Hour
+
+// ExportToken is a download token for a completed export. Only its hash is
+// stored, so the token is shown once.
+type ExportToken struct {
+	RequestID   string    `json:"request_id"`
+	Token       string    `json:"token"`
+	DownloadURL string    `json:"download_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ExportDownload is a decrypted export package.
+type ExportDownload struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// SetExportStorage sets where encrypted export packages are stored and the
+// vault that encrypts them. Exports cannot be processed until it is called.
+func (s *Service) SetExportStorage(store storage.ObjectStore, vault *encryption.Vault) {
+	s.exportStore = store
+	s.exportVault = vault
+}
+
+// ExportPath is the API path an export package is downloaded from.
+func ExportPath(requestID string) string {
+	return "/api/v1/compliance/requests/" + requestID + "/export"
+}
+
+// ProcessExportRequest collects the subject's records for a received (or
+// previously failed) export request, renders them in the requested format
+// and stores the package encrypted. The request is completed with the
+// package's hash and an expiry; the subject then issues a download token.
+func (s *Service) ProcessExportRequest(ctx context.Context, requestID, actorID string) (*PrivacyRequest, error) {
+	if s.exportStore == nil || s.exportVault == nil {
+		return nil, ErrExportStorageUnavailable
+	}
+	req, err := s.repo.GetPrivacyRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching privacy request: %w", err)
+	}
+	if req.RequestType != RequestTypeExport || (req.Status != RequestStatusReceived && req.Status != RequestStatusFailed) {
+		return nil, ErrRequestNotProcessable
+	}
+	if req.ExportFormat == "" {
+		req.ExportFormat = requests.FormatJSON
+	}
+
+	s.verifyByOfficer(ctx, req, actorID)
+	req.Status = RequestStatusProcessing
+	req.ErrorMessage = ""
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		return nil, fmt.Errorf("updating privacy request: %w", err)
+	}
+
+	result, err := s.processor.ProcessExportRequest(ctx, req.UserID, req.DataCategories, req.DateRangeStart, req.DateRangeEnd, req.ExportFormat)
+	if err != nil {
+		return nil, s.failRequest(ctx, req, err)
+	}
+	sealed, err := s.exportVault.Encrypt(result.Data)
+	if err != nil {
+		return nil, s.failRequest(ctx, req, fmt.Errorf("encrypting export: %w", err))
+	}
+	key := fmt.Sprintf("privacy-exports/%s/%s.%s.enc", req.UserID, req.ID, requests.FileExtension(req.ExportFormat))
+	if _, err := s.exportStore.Upload(ctx, key, bytes.NewReader(sealed), "application/octet-stream"); err != nil {
+		return nil, s.failRequest(ctx, req, fmt.Errorf("storing export: %w", err))
+	}
+
+	now := time.Now()
+	expires := now.Add(exportTTL)
+	req.Status = RequestStatusCompleted
+	req.CompletedAt = &now
+	req.ExportFileURL = ExportPath(req.ID)
+	req.ExportFileHash = result.FileHash
+	req.ExportObjectKey = key
+	req.ExportSizeBytes = result.SizeBytes
+	req.ExportTokenHash = ""
+	req.ExportExpiresAt = &expires
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		return nil, fmt.Errorf("updating privacy request: %w", err)
+	}
+
+	s.logExportEvent(ctx, req, "generate", actorID, map[string]any{
+		"format":       req.ExportFormat,
+		"file_hash":    result.FileHash,
+		"size_bytes":   result.SizeBytes,
+		"record_count": result.RecordCount,
+		"expires_at":   expires,
+	})
+	return req, nil
+}
+
+// IssueExportToken gives the subject of a completed export a new download
+// token, replacing any earlier one.
+func (s *Service) IssueExportToken(ctx context.Context, requestID, userID string) (*ExportToken, error) {
+	req, err := s.downloadableExport(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("generating token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	req.ExportTokenHash = hashExportToken(token)
+	if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+		return nil, fmt.Errorf("updating privacy request: %w", err)
+	}
+	return &ExportToken{
+		RequestID:   req.ID,
+		Token:       token,
+		DownloadURL: req.ExportFileURL + "?token=" + token,
+		ExpiresAt:   *req.ExportExpiresAt,
+	}, nil
+}
+
+// OpenExport checks the subject's download token and returns the decrypted
+// package after confirming it still matches the recorded hash.
+func (s *Service) OpenExport(ctx context.Context, requestID, userID, token string) (*ExportDownload, error) {
+	req, err := s.downloadableExport(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" || req.ExportTokenHash == "" ||
+		subtle.ConstantTimeCompare([]byte(hashExportToken(token)), []byte(req.ExportTokenHash)) != 1 {
+		return nil, ErrExportToken
+	}
+
+	body, _, err := s.exportStore.DownloadStream(ctx, req.ExportObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading export: %w", err)
+	}
+	defer body.Close()
+	sealed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading export: %w", err)
+	}
+	data, err := s.exportVault.Decrypt(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExportIntegrity, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != req.ExportFileHash {
+		return nil, ErrExportIntegrity
+	}
+
+	s.logExportEvent(ctx, req, "download", userID, map[string]any{"file_hash": req.ExportFileHash})
+	return &ExportDownload{
+		Filename:    fmt.Sprintf("data-export-%s.%s", req.ID, requests.FileExtension(req.ExportFormat)),
+		ContentType: requests.ContentType(req.ExportFormat),
+		Data:        data,
+	}, nil
+}
+
+// ExpireExports deletes up to batch stored packages past their expiry and
+// clears their download details. The hash stays on the request as a record
+// of what was delivered.
+func (s *Service) ExpireExports(ctx context.Context, batch int) (int, error) {
+	if s.exportStore == nil {
+		return 0, nil
+	}
+	expired, err := s.repo.ListExpiredExports(ctx, time.Now(), batch)
+	if err != nil {
+		return 0, fmt.Errorf("listing expired exports: %w", err)
+	}
+	n := 0
+	for i := range expired {
+		req := &expired[i]
+		if err := s.exportStore.Delete(ctx, req.ExportObjectKey); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			log.Printf("WARNING: deleting export for request %s: %v", req.ID, err)
+			continue
+		}
+		req.ExportObjectKey = ""
+		req.ExportTokenHash = ""
+		req.ExportFileURL = ""
+		if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+			log.Printf("WARNING: clearing export for request %s: %v", req.ID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// StartExportSweep runs ExpireExports every interval until ctx is cancelled.
+func (s *Service) StartExportSweep(ctx context.Context, interval time.Duration, batch int) {
+	if interval <= 0 || batch <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.ExpireExports(ctx, batch)
+				if err != nil {
+					log.Printf("WARNING: export sweep failed: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("🔒 Export sweep removed %d expired package(s)", n)
+				}
+			}
+		}
+	}()
+}
+
+// downloadableExport returns the user's completed export request while its
+// package is still stored. Requests of other users are reported as not
+// found.
+func (s *Service) downloadableExport(ctx context.Context, requestID, userID string) (*PrivacyRequest, error) {
+	if s.exportStore == nil || s.exportVault == nil {
+		return nil, ErrExportStorageUnavailable
+	}
+	req, err := s.repo.GetPrivacyRequest(ctx, requestID)
+	if err != nil || req.UserID != userID || req.RequestType != RequestTypeExport || req.Status != RequestStatusCompleted {
+		return nil, ErrExportNotFound
+	}
+	if req.ExportObjectKey == "" || req.ExportExpiresAt == nil || !req.ExportExpiresAt.After(time.Now()) {
+		return nil, ErrExportExpired
+	}
+	return req, nil
+}
+
+func (s *Service) logExportEvent(ctx context.Context, req *PrivacyRequest, action, actorID string, values map[string]any) {
+	if err := s.LogAuditEvent(ctx, AuditEntry{
+		EventType:        "data_export",
+		EventAction:      action,
+		ActorID:          actorID,
+		ActorType:        actorType(actorID),
+		TargetType:       "privacy_request",
+		TargetID:         req.ID,
+		TargetOwnerID:    req.UserID,
+		SensitivityLevel: SensitivityHighly,
+		ServiceName:      "compliance",
+		NewValues:        values,
+	}); err != nil {
+		log.Printf("WARNING: audit log for export request %s: %v", req.ID, err)
+	}
+}
+
+func hashExportToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
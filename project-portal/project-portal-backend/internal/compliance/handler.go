@@ -1,10 +1,17 @@
 package compliance
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Handler exposes compliance endpoints via Gin.
@@ -18,18 +25,35 @@ func NewHandler(service *Service) *Handler {
 }
 
 // RegisterRoutes registers all compliance routes under /api/v1/compliance.
+// The group must already require authentication. Privacy requests,
+// preferences and consents act on the caller's own data; audit, retention,
+// legal hold and stats endpoints are restricted to compliance officers, as
+// are erasing a deletion request and building an export package. A deletion
+// certificate can be read and verified by the requester or an officer; an
+// export package can only be downloaded by the requester, with a token.
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	compliance := router.Group("/compliance")
+	officer := middleware.RequirePlatformRole(middleware.PlatformRoleComplianceOfficer)
 	{
 		// Privacy requests
-		requests := compliance.Group("/requests")
+		privacy := compliance.Group("/requests")
 		{
-			requests.POST("/export", h.CreateExportRequest)
-			requests.POST("/delete", h.CreateDeleteRequest)
-			requests.GET("/:id", h.GetRequestStatus)
-			requests.GET("", h.ListRequests)
+			privacy.POST("/export", h.CreateExportRequest)
+			privacy.POST("/delete", h.CreateDeleteRequest)
+			privacy.GET("/discover", h.DiscoverUserData)
+			privacy.GET("/:id", h.GetRequestStatus)
+			privacy.POST("/:id/erase", officer, h.ProcessDeletionRequest)
+			privacy.POST("/:id/export", officer, h.ProcessExportRequest)
+			privacy.POST("/:id/export/token", h.IssueExportToken)
+			privacy.GET("/:id/export", h.DownloadExport)
+			privacy.GET("/:id/certificate", h.GetDeletionCertificate)
+			privacy.GET("/:id/certificate/verify", h.VerifyDeletionCertificate)
+			privacy.GET("", h.ListRequests)
 		}
 
+		// Registered personal data sources
+		compliance.GET("/data-sources", officer, h.ListDataSources)
+
 		// Privacy preferences
 		preferences := compliance.Group("/preferences")
 		{
@@ -46,23 +70,29 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		}
 
 		// Audit logs
-		audit := compliance.Group("/audit")
+		audit := compliance.Group("/audit", officer)
 		{
 			audit.GET("/logs", h.QueryAuditLogs)
+			audit.GET("/verify", h.VerifyAuditChain)
+			audit.GET("/checkpoints", h.ListAuditCheckpoints)
+			audit.POST("/checkpoints", h.CreateAuditCheckpoint)
+			audit.GET("/checkpoints/:id/export", h.ExportAuditCheckpoint)
+			audit.GET("/checkpoints/:id/verify", h.VerifyAuditCheckpoint)
 		}
 
 		// Retention policies
-		retention := compliance.Group("/retention")
+		retention := compliance.Group("/retention", officer)
 		{
 			retention.POST("/policies", h.CreateRetentionPolicy)
 			retention.GET("/policies", h.ListRetentionPolicies)
 			retention.GET("/policies/:id", h.GetRetentionPolicy)
 			retention.PUT("/policies/:id", h.UpdateRetentionPolicy)
+			retention.POST("/policies/:id/run", h.RunRetentionPolicy)
 			retention.GET("/schedule", h.ListRetentionSchedules)
 		}
 
 		// Legal holds
-		holds := compliance.Group("/legal-holds")
+		holds := compliance.Group("/legal-holds", officer)
 		{
 			holds.POST("", h.CreateLegalHold)
 			holds.GET("", h.ListLegalHolds)
@@ -70,14 +100,14 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		}
 
 		// Stats
-		compliance.GET("/stats", h.GetStats)
+		compliance.GET("/stats", officer, h.GetStats)
 	}
 }
 
 // --- Privacy Request Handlers ---
 
 func (h *Handler) CreateExportRequest(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -90,6 +120,10 @@ func (h *Handler) CreateExportRequest(c *gin.Context) {
 	}
 
 	result, err := h.service.CreateExportRequest(c.Request.Context(), userID, req)
+	if errors.Is(err, requests.ErrUnsupportedFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -98,7 +132,7 @@ func (h *Handler) CreateExportRequest(c *gin.Context) {
 }
 
 func (h *Handler) CreateDeleteRequest(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -121,7 +155,7 @@ func (h *Handler) CreateDeleteRequest(c *gin.Context) {
 func (h *Handler) GetRequestStatus(c *gin.Context) {
 	id := c.Param("id")
 	result, err := h.service.GetRequestStatus(c.Request.Context(), id)
-	if err != nil {
+	if err != nil || (result.UserID != currentUserID(c) && !isComplianceOfficer(c)) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 		return
 	}
@@ -129,7 +163,11 @@ func (h *Handler) GetRequestStatus(c *gin.Context) {
 }
 
 func (h *Handler) ListRequests(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	// Officers may list every request or filter by user; others see their own.
+	userID := currentUserID(c)
+	if isComplianceOfficer(c) {
+		userID = c.Query("user_id")
+	}
 	status := c.Query("status")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -147,10 +185,101 @@ func (h *Handler) ListRequests(c *gin.Context) {
 	})
 }
 
+// DiscoverUserData lists where the caller's data is held, with record
+// counts: GET /requests/discover?categories=a,b. Officers may pass user_id.
+func (h *Handler) DiscoverUserData(c *gin.Context) {
+	userID := currentUserID(c)
+	if isComplianceOfficer(c) && c.Query("user_id") != "" {
+		userID = c.Query("user_id")
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
+		return
+	}
+	var categories []string
+	if raw := c.Query("categories"); raw != "" {
+		categories = strings.Split(raw, ",")
+	}
+
+	locations, err := h.service.DiscoverUserData(c.Request.Context(), userID, categories)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "locations": locations})
+}
+
+// ProcessDeletionRequest erases the data for a deletion request now and
+// returns the signed deletion certificate.
+func (h *Handler) ProcessDeletionRequest(c *gin.Context) {
+	cert, err := h.service.ProcessDeletionRequest(c.Request.Context(), c.Param("id"), currentUserID(c))
+	if err != nil {
+		requestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+func (h *Handler) GetDeletionCertificate(c *gin.Context) {
+	cert, err := h.service.GetDeletionCertificate(c.Request.Context(), c.Param("id"))
+	if err != nil || (cert.UserID != currentUserID(c) && !isComplianceOfficer(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrCertificateNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cert)
+}
+
+func (h *Handler) VerifyDeletionCertificate(c *gin.Context) {
+	cert, err := h.service.GetDeletionCertificate(c.Request.Context(), c.Param("id"))
+	if err != nil || (cert.UserID != currentUserID(c) && !isComplianceOfficer(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrCertificateNotFound.Error()})
+		return
+	}
+	result, err := h.service.VerifyDeletionCertificate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		requestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) ProcessExportRequest(c *gin.Context) {
+	req, err := h.service.ProcessExportRequest(c.Request.Context(), c.Param("id"), currentUserID(c))
+	if err != nil {
+		requestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+func (h *Handler) IssueExportToken(c *gin.Context) {
+	token, err := h.service.IssueExportToken(c.Request.Context(), c.Param("id"), currentUserID(c))
+	if err != nil {
+		requestError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *Handler) DownloadExport(c *gin.Context) {
+	pkg, err := h.service.OpenExport(c.Request.Context(), c.Param("id"), currentUserID(c), c.Query("token"))
+	if err != nil {
+		requestError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+pkg.Filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, pkg.ContentType, pkg.Data)
+}
+
+func (h *Handler) ListDataSources(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListDataSources())
+}
+
 // --- Privacy Preference Handlers ---
 
 func (h *Handler) GetPreferences(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -165,7 +294,7 @@ func (h *Handler) GetPreferences(c *gin.Context) {
 }
 
 func (h *Handler) UpdatePreferences(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -188,7 +317,7 @@ func (h *Handler) UpdatePreferences(c *gin.Context) {
 // --- Consent Handlers ---
 
 func (h *Handler) RecordConsent(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -212,7 +341,7 @@ func (h *Handler) RecordConsent(c *gin.Context) {
 }
 
 func (h *Handler) ListConsents(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -227,7 +356,7 @@ func (h *Handler) ListConsents(c *gin.Context) {
 }
 
 func (h *Handler) WithdrawConsent(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -267,6 +396,68 @@ func (h *Handler) QueryAuditLogs(c *gin.Context) {
 	})
 }
 
+// VerifyAuditChain walks the audit chain between the from and to sequence
+// numbers, defaulting to the whole chain.
+func (h *Handler) VerifyAuditChain(c *gin.Context) {
+	from, err1 := strconv.ParseInt(c.DefaultQuery("from", "1"), 10, 64)
+	to, err2 := strconv.ParseInt(c.DefaultQuery("to", "0"), 10, 64)
+	if err1 != nil || err2 != nil || (to > 0 && to < from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be sequence numbers with from <= to"})
+		return
+	}
+	result, err := h.service.VerifyAuditChain(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) ListAuditCheckpoints(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	checkpoints, total, err := h.service.ListAuditCheckpoints(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, PaginatedResponse{Data: checkpoints, Total: total, Limit: limit, Offset: offset})
+}
+
+// CreateAuditCheckpoint checkpoints the entries written since the last
+// checkpoint.
+func (h *Handler) CreateAuditCheckpoint(c *gin.Context) {
+	cp, err := h.service.CreateAuditCheckpoint(c.Request.Context())
+	if err != nil {
+		checkpointError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, cp)
+}
+
+// ExportAuditCheckpoint downloads a checkpoint with its entry hashes.
+func (h *Handler) ExportAuditCheckpoint(c *gin.Context) {
+	export, err := h.service.ExportAuditCheckpoint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		checkpointError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=audit-checkpoint-"+export.Checkpoint.ID+".json")
+	c.JSON(http.StatusOK, export)
+}
+
+func (h *Handler) VerifyAuditCheckpoint(c *gin.Context) {
+	result, err := h.service.VerifyAuditCheckpoint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		checkpointError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // --- Retention Policy Handlers ---
 
 func (h *Handler) CreateRetentionPolicy(c *gin.Context) {
@@ -323,6 +514,26 @@ func (h *Handler) UpdateRetentionPolicy(c *gin.Context) {
 	c.JSON(http.StatusOK, policy)
 }
 
+// RunRetentionPolicy enforces a policy now. With dry_run=true it returns
+// the rows that would be affected, per table, without changing them.
+func (h *Handler) RunRetentionPolicy(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+	userID, _ := middleware.CurrentUserID(c)
+	result, err := h.service.RunRetentionPolicy(c.Request.Context(), c.Param("id"), dryRun, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "policy not found"})
+		case errors.Is(err, ErrRetentionUnavailable), errors.Is(err, retention.ErrArchiveUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *Handler) ListRetentionSchedules(c *gin.Context) {
 	policyID := c.Query("policy_id")
 	schedules, err := h.service.ListRetentionSchedules(c.Request.Context(), policyID)
@@ -336,7 +547,7 @@ func (h *Handler) ListRetentionSchedules(c *gin.Context) {
 // --- Legal Hold Handlers ---
 
 func (h *Handler) CreateLegalHold(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -367,7 +578,7 @@ func (h *Handler) ListLegalHolds(c *gin.Context) {
 
 func (h *Handler) ReleaseLegalHold(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.GetHeader("X-User-ID")
+	userID := currentUserID(c)
 	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID required"})
 		return
@@ -391,3 +602,49 @@ func (h *Handler) GetStats(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, stats)
 }
+
+// --- helpers ---
+
+// currentUserID returns the caller's ID from the verified access token.
+func currentUserID(c *gin.Context) string {
+	id, _ := middleware.CurrentUserID(c)
+	return id
+}
+
+// requestError maps privacy request processing errors to HTTP responses.
+func requestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+	case errors.Is(err, ErrCertificateNotFound), errors.Is(err, ErrExportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrExportExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrExportToken):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrExportStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRequestNotProcessable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func checkpointError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrCheckpointNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNoNewAuditEntries):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAuditChainBroken):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func isComplianceOfficer(c *gin.Context) bool {
+	role := middleware.CurrentRole(c)
+	return role == middleware.PlatformRoleComplianceOfficer || role == middleware.PlatformRoleAdmin
+}
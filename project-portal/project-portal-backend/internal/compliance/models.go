@@ -4,15 +4,19 @@ import (
 	"net"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+
 	"github.com/lib/pq"
 )
 
 // Data category constants
 const (
-	DataCategoryUserProfile   = "user_profile"
-	DataCategoryProjectData   = "project_data"
-	DataCategoryFinancialRecs = "financial_records"
-	DataCategorySystemLogs    = "system_logs"
+	DataCategoryUserProfile    = requests.CategoryUserProfile
+	DataCategoryProjectData    = requests.CategoryProjectData
+	DataCategoryFinancialRecs  = requests.CategoryFinancialRecs
+	DataCategorySystemLogs     = requests.CategorySystemLogs
+	DataCategoryAuditLogs      = requests.CategoryAuditLogs
+	DataCategoryConsentRecords = requests.CategoryConsentRecords
 )
 
 // Deletion method constants
@@ -109,9 +113,18 @@ type PrivacyRequest struct {
 	VerifiedAt          *time.Time     `json:"verified_at,omitempty"`
 	ExportFileURL       string         `json:"export_file_url,omitempty"`
 	ExportFileHash      string         `json:"export_file_hash,omitempty"`
+	ExportFormat        string         `json:"export_format,omitempty"`
+	ExportObjectKey     string         `json:"-"`
+	ExportSizeBytes     int64          `json:"export_size_bytes,omitempty"`
+	ExportTokenHash     string         `json:"-"`
+	ExportExpiresAt     *time.Time     `json:"export_expires_at,omitempty"`
 	DeletionSummary     map[string]any `gorm:"serializer:json" json:"deletion_summary,omitempty"`
 	ErrorMessage        string         `json:"error_message,omitempty"`
 	LegalBasis          string         `json:"legal_basis,omitempty"`
+	Jurisdiction        string         `json:"jurisdiction,omitempty"`
+	RequestedBy         *string        `json:"requested_by,omitempty"`
+	DueAt               *time.Time     `gorm:"index" json:"due_at,omitempty"`
+	EscalatedAt         *time.Time     `json:"escalated_at,omitempty"`
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 }
@@ -152,9 +165,13 @@ type ConsentRecord struct {
 	CreatedAt      time.Time  `json:"created_at"`
 }
 
-// AuditLog is an immutable record of data access or modification.
+// AuditLog is an immutable record of data access or modification. Entries
+// form a hash chain ordered by Sequence; KeyID names the key that signed
+// the entry and is empty for entries written before sequencing.
 type AuditLog struct {
 	LogID            int64          `gorm:"primaryKey;autoIncrement" json:"log_id"`
+	Sequence         int64          `gorm:"index:idx_audit_logs_sequence,unique" json:"sequence"`
+	KeyID            string         `gorm:"size:64" json:"key_id,omitempty"`
 	EventTime        time.Time      `gorm:"not null;index;default:CURRENT_TIMESTAMP" json:"event_time"`
 	EventType        string         `gorm:"not null" json:"event_type"`
 	EventAction      string         `gorm:"not null" json:"event_action"`
@@ -177,6 +194,34 @@ type AuditLog struct {
 	CreatedAt        time.Time      `json:"created_at"`
 }
 
+// AuditChainState holds the single row recording the last audit entry
+// written in the legacy format, before entries were signed with configured
+// keys.
+type AuditChainState struct {
+	ID          int16 `gorm:"primaryKey" json:"-"`
+	LegacyUntil int64 `gorm:"not null" json:"legacy_until"`
+}
+
+func (AuditChainState) TableName() string { return "audit_chain_state" }
+
+// AuditCheckpoint records the Merkle root of a contiguous range of audit
+// log entries, signed and optionally anchored outside the database.
+type AuditCheckpoint struct {
+	ID            string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	FromSequence  int64      `gorm:"not null" json:"from_sequence"`
+	ToSequence    int64      `gorm:"not null;uniqueIndex" json:"to_sequence"`
+	EntryCount    int64      `gorm:"not null" json:"entry_count"`
+	MerkleRoot    string     `gorm:"size:64;not null" json:"merkle_root"`
+	LastHash      string     `gorm:"size:64;not null" json:"last_hash"`
+	KeyID         string     `gorm:"size:64;not null" json:"key_id"`
+	Signature     string     `gorm:"not null" json:"signature"`
+	AnchorNetwork string     `json:"anchor_network,omitempty"`
+	AnchorTxID    string     `json:"anchor_tx_id,omitempty"`
+	AnchorMemo    string     `json:"anchor_memo,omitempty"`
+	AnchoredAt    *time.Time `json:"anchored_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
 // RetentionSchedule tracks when retention actions should occur.
 type RetentionSchedule struct {
 	ID                  string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
@@ -189,6 +234,9 @@ type RetentionSchedule struct {
 	LastActionDate      *time.Time      `gorm:"type:date" json:"last_action_date,omitempty"`
 	LastActionType      string          `json:"last_action_type,omitempty"`
 	LastActionResult    string          `json:"last_action_result,omitempty"`
+	LastRecordsAffected int64           `json:"last_records_affected"`
+	LastRecordsHeld     int64           `json:"last_records_held"`
+	LastRunDetails      map[string]any  `gorm:"serializer:json" json:"last_run_details,omitempty"`
 	RecordCountEstimate *int64          `json:"record_count_estimate,omitempty"`
 	CreatedAt           time.Time       `json:"created_at"`
 	UpdatedAt           time.Time       `json:"updated_at"`
@@ -203,6 +251,7 @@ type LegalHold struct {
 	Status          string         `gorm:"default:'active';index" json:"status"`
 	DataCategories  pq.StringArray `gorm:"type:text[]" json:"data_categories,omitempty"`
 	AffectedUserIDs pq.StringArray `gorm:"type:text[]" json:"affected_user_ids,omitempty"`
+	ProjectIDs      pq.StringArray `gorm:"type:text[]" json:"project_ids,omitempty"` // projects that must not be purged
 	InitiatedBy     string         `gorm:"not null" json:"initiated_by"`
 	ReleasedBy      *string        `json:"released_by,omitempty"`
 	InitiatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"initiated_at"`
@@ -212,6 +261,35 @@ type LegalHold struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
+// DeletionCertificate is the signed record of what a deletion request
+// erased and kept. Digest and Signature cover the request, user, issue
+// time, verification flag and entries; see requests.Certificate.
+type DeletionCertificate struct {
+	ID        string                      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestID string                      `gorm:"not null;index" json:"request_id"`
+	UserID    string                      `gorm:"not null;index" json:"user_id"`
+	IssuedAt  time.Time                   `gorm:"not null" json:"issued_at"`
+	Verified  bool                        `gorm:"not null" json:"verified"`
+	Entries   []requests.CertificateEntry `gorm:"serializer:json" json:"entries"`
+	Digest    string                      `gorm:"not null" json:"digest"`
+	Signature string                      `gorm:"not null" json:"signature"`
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+// certificate returns the signed fields in the form requests.Certificate
+// verifies.
+func (c *DeletionCertificate) certificate() *requests.Certificate {
+	return &requests.Certificate{
+		RequestID: c.RequestID,
+		UserID:    c.UserID,
+		IssuedAt:  c.IssuedAt,
+		Verified:  c.Verified,
+		Entries:   c.Entries,
+		Digest:    c.Digest,
+		Signature: c.Signature,
+	}
+}
+
 // --- Request / Response DTOs ---
 
 type CreateRetentionPolicyRequest struct {
@@ -280,6 +358,7 @@ type CreateLegalHoldRequest struct {
 	Reason          string     `json:"reason" binding:"required"`
 	DataCategories  []string   `json:"data_categories"`
 	AffectedUserIDs []string   `json:"affected_user_ids"`
+	ProjectIDs      []string   `json:"project_ids"`
 	ExpiresAt       *time.Time `json:"expires_at"`
 }
 
@@ -319,3 +398,13 @@ type ComplianceStats struct {
 	ConsentRates      map[string]float64 `json:"consent_rates"`
 	AuditLogCount     int64              `json:"audit_log_count"`
 }
+
+// CertificateVerification is the result of re-checking a stored deletion
+// certificate against its signature.
+type CertificateVerification struct {
+	CertificateID string `json:"certificate_id"`
+	RequestID     string `json:"request_id"`
+	Digest        string `json:"digest"`
+	Valid         bool   `json:"valid"`
+	Error         string `json:"error,omitempty"`
+}
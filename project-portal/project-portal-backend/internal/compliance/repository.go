@@ -2,6 +2,7 @@ package compliance
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -23,6 +24,8 @@ type Repository interface {
 	ListPrivacyRequests(ctx context.Context, userID string, status string, limit, offset int) ([]PrivacyRequest, int64, error)
 	UpdatePrivacyRequest(ctx context.Context, req *PrivacyRequest) error
 	GetPendingRequests(ctx context.Context, requestType string, limit int) ([]PrivacyRequest, error)
+	ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]PrivacyRequest, error)
+	ListRequestsDueBefore(ctx context.Context, before time.Time, limit int) ([]PrivacyRequest, error)
 
 	// Privacy Preferences
 	GetPrivacyPreference(ctx context.Context, userID string) (*PrivacyPreference, error)
@@ -38,6 +41,17 @@ type Repository interface {
 	CreateAuditLog(ctx context.Context, log *AuditLog) error
 	QueryAuditLogs(ctx context.Context, query AuditLogQuery) ([]AuditLog, int64, error)
 	GetLastAuditLog(ctx context.Context) (*AuditLog, error)
+	AppendAuditLog(ctx context.Context, build func(prev *AuditLog) (*AuditLog, error)) error
+	ListAuditLogsBySequence(ctx context.Context, from, to int64, limit int) ([]AuditLog, error)
+	GetAuditLogBySequence(ctx context.Context, sequence int64) (*AuditLog, error)
+	GetAuditLegacyBoundary(ctx context.Context) (int64, error)
+
+	// Audit Checkpoints
+	CreateAuditCheckpoint(ctx context.Context, cp *AuditCheckpoint) error
+	GetAuditCheckpoint(ctx context.Context, id string) (*AuditCheckpoint, error)
+	GetLatestAuditCheckpoint(ctx context.Context) (*AuditCheckpoint, error)
+	ListAuditCheckpoints(ctx context.Context, limit, offset int) ([]AuditCheckpoint, int64, error)
+	UpdateAuditCheckpoint(ctx context.Context, cp *AuditCheckpoint) error
 
 	// Retention Schedules
 	CreateRetentionSchedule(ctx context.Context, schedule *RetentionSchedule) error
@@ -51,6 +65,11 @@ type Repository interface {
 	ListActiveLegalHolds(ctx context.Context) ([]LegalHold, error)
 	UpdateLegalHold(ctx context.Context, hold *LegalHold) error
 	IsDataUnderLegalHold(ctx context.Context, userID, dataCategory string) (bool, error)
+	IsProjectUnderLegalHold(ctx context.Context, projectID string) (bool, error)
+
+	// Deletion Certificates
+	CreateDeletionCertificate(ctx context.Context, cert *DeletionCertificate) error
+	GetDeletionCertificate(ctx context.Context, requestID string) (*DeletionCertificate, error)
 
 	// Statistics
 	GetComplianceStats(ctx context.Context) (*ComplianceStats, error)
@@ -163,6 +182,37 @@ func (r *repository) GetPendingRequests(ctx context.Context, requestType string,
 	return requests, nil
 }
 
+// ListExpiredExports returns requests whose stored export package expired
+// before the given time.
+func (r *repository) ListExpiredExports(ctx context.Context, before time.Time, limit int) ([]PrivacyRequest, error) {
+	var requests []PrivacyRequest
+	if limit <= 0 {
+		limit = 50
+	}
+	if err := r.db.WithContext(ctx).
+		Where("export_object_key <> '' AND export_expires_at < ?", before).
+		Order("export_expires_at ASC").Limit(limit).Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ListRequestsDueBefore returns open, not yet escalated requests whose
+// response deadline falls before the given time, earliest first.
+func (r *repository) ListRequestsDueBefore(ctx context.Context, before time.Time, limit int) ([]PrivacyRequest, error) {
+	var requests []PrivacyRequest
+	if limit <= 0 {
+		limit = 50
+	}
+	if err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{RequestStatusReceived, RequestStatusProcessing, RequestStatusFailed}).
+		Where("escalated_at IS NULL AND due_at < ?", before).
+		Order("due_at ASC").Limit(limit).Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
 // --- Privacy Preferences ---
 
 func (r *repository) GetPrivacyPreference(ctx context.Context, userID string) (*PrivacyPreference, error) {
@@ -281,14 +331,122 @@ func (r *repository) QueryAuditLogs(ctx context.Context, query AuditLogQuery) ([
 	return logs, total, nil
 }
 
+// GetLastAuditLog returns the entry at the head of the hash chain.
 func (r *repository) GetLastAuditLog(ctx context.Context) (*AuditLog, error) {
 	var log AuditLog
-	if err := r.db.WithContext(ctx).Order("log_id DESC").First(&log).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("sequence IS NOT NULL").Order("sequence DESC").First(&log).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// auditChainLock is the advisory lock key that serializes chain appends.
+const auditChainLock = 0x6175646974636861 // "auditcha"
+
+// AppendAuditLog adds an entry to the head of the hash chain. A
+// transaction-scoped advisory lock serializes appends, so build sees the
+// current head (nil for the first entry) and no concurrent append can fork
+// the chain.
+func (r *repository) AppendAuditLog(ctx context.Context, build func(prev *AuditLog) (*AuditLog, error)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+			return fmt.Errorf("locking audit chain: %w", err)
+		}
+		var prev *AuditLog
+		var head AuditLog
+		err := tx.Where("sequence IS NOT NULL").Order("sequence DESC").First(&head).Error
+		switch {
+		case err == nil:
+			prev = &head
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		log, err := build(prev)
+		if err != nil {
+			return err
+		}
+		return tx.Create(log).Error
+	})
+}
+
+// ListAuditLogsBySequence returns up to limit entries with sequence numbers
+// in [from, to], in chain order.
+func (r *repository) ListAuditLogsBySequence(ctx context.Context, from, to int64, limit int) ([]AuditLog, error) {
+	var logs []AuditLog
+	if err := r.db.WithContext(ctx).
+		Where("sequence BETWEEN ? AND ?", from, to).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (r *repository) GetAuditLogBySequence(ctx context.Context, sequence int64) (*AuditLog, error) {
+	var log AuditLog
+	if err := r.db.WithContext(ctx).Where("sequence = ?", sequence).First(&log).Error; err != nil {
 		return nil, err
 	}
 	return &log, nil
 }
 
+// --- Audit Checkpoints ---
+
+// GetAuditLegacyBoundary returns the last sequence that may hold a
+// legacy-format entry. Without a recorded boundary no legacy entries are
+// accepted.
+func (r *repository) GetAuditLegacyBoundary(ctx context.Context) (int64, error) {
+	var state AuditChainState
+	err := r.db.WithContext(ctx).Where("id = ?", 1).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return state.LegacyUntil, nil
+}
+
+func (r *repository) CreateAuditCheckpoint(ctx context.Context, cp *AuditCheckpoint) error {
+	return r.db.WithContext(ctx).Create(cp).Error
+}
+
+func (r *repository) GetAuditCheckpoint(ctx context.Context, id string) (*AuditCheckpoint, error) {
+	var cp AuditCheckpoint
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&cp).Error; err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// GetLatestAuditCheckpoint returns the checkpoint reaching furthest along
+// the chain.
+func (r *repository) GetLatestAuditCheckpoint(ctx context.Context) (*AuditCheckpoint, error) {
+	var cp AuditCheckpoint
+	if err := r.db.WithContext(ctx).Order("to_sequence DESC").First(&cp).Error; err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (r *repository) ListAuditCheckpoints(ctx context.Context, limit, offset int) ([]AuditCheckpoint, int64, error) {
+	var cps []AuditCheckpoint
+	var total int64
+	q := r.db.WithContext(ctx).Model(&AuditCheckpoint{})
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("to_sequence DESC").Limit(limit).Offset(offset).Find(&cps).Error; err != nil {
+		return nil, 0, err
+	}
+	return cps, total, nil
+}
+
+func (r *repository) UpdateAuditCheckpoint(ctx context.Context, cp *AuditCheckpoint) error {
+	return r.db.WithContext(ctx).Save(cp).Error
+}
+
 // --- Retention Schedules ---
 
 func (r *repository) CreateRetentionSchedule(ctx context.Context, schedule *RetentionSchedule) error {
@@ -352,21 +510,52 @@ func (r *repository) UpdateLegalHold(ctx context.Context, hold *LegalHold) error
 	return r.db.WithContext(ctx).Save(hold).Error
 }
 
+// IsDataUnderLegalHold reports whether an active, unexpired hold covers the
+// user's data in a category. A hold naming users covers those users' data in
+// its categories, or all of it when it lists none; a hold naming only
+// categories covers every user's data in them.
 func (r *repository) IsDataUnderLegalHold(ctx context.Context, userID, dataCategory string) (bool, error) {
 	var count int64
-	q := r.db.WithContext(ctx).Model(&LegalHold{}).Where("status = ?", LegalHoldActive)
-
-	// Check if user is in affected list or data category is held
-	// Using raw SQL for array containment since GORM doesn't natively support @>
-	if err := q.Where(
-		"(? = ANY(affected_user_ids) OR ? = ANY(data_categories))",
-		userID, dataCategory,
-	).Count(&count).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&LegalHold{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", LegalHoldActive, time.Now()).
+		Where(`(? = ANY(affected_user_ids) AND COALESCE(cardinality(data_categories), 0) = 0)
+			OR ((? = ANY(affected_user_ids) OR COALESCE(cardinality(affected_user_ids), 0) = 0) AND ? = ANY(data_categories))`,
+			userID, userID, dataCategory).
+		Count(&count).Error; err != nil {
 		return false, fmt.Errorf("checking legal hold: %w", err)
 	}
 	return count > 0, nil
 }
 
+// IsProjectUnderLegalHold reports whether an active, unexpired hold names the
+// project or covers all project data.
+func (r *repository) IsProjectUnderLegalHold(ctx context.Context, projectID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&LegalHold{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", LegalHoldActive, time.Now()).
+		Where("(? = ANY(project_ids) OR ? = ANY(data_categories))", projectID, DataCategoryProjectData).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("checking project legal hold: %w", err)
+	}
+	return count > 0, nil
+}
+
+// --- Deletion Certificates ---
+
+func (r *repository) CreateDeletionCertificate(ctx context.Context, cert *DeletionCertificate) error {
+	return r.db.WithContext(ctx).Create(cert).Error
+}
+
+// GetDeletionCertificate returns the latest certificate issued for a request.
+func (r *repository) GetDeletionCertificate(ctx context.Context, requestID string) (*DeletionCertificate, error) {
+	var cert DeletionCertificate
+	if err := r.db.WithContext(ctx).Where("request_id = ?", requestID).
+		Order("issued_at DESC").First(&cert).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
 // --- Statistics ---
 
 func (r *repository) GetComplianceStats(ctx context.Context) (*ComplianceStats, error) {
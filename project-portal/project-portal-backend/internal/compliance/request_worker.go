@@ -0,0 +1,223 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+)
+
+// escalationWindow is how long before its deadline an open request is
+// escalated to compliance officers.
+const escalationWindow = 5 * 24 * time.Hour
+
+// SetAccountChecker makes identity verification of privacy requests
+// require an active account with a verified email address.
+func (s *Service) SetAccountChecker(accounts requests.AccountChecker) {
+	s.processor.SetAccountChecker(accounts)
+}
+
+// setDeadline records the subject's jurisdiction on a new request and the
+// response deadline it sets.
+func (s *Service) setDeadline(ctx context.Context, req *PrivacyRequest) {
+	pref, _ := s.GetPreferences(ctx, req.UserID)
+	req.Jurisdiction = pref.Jurisdiction
+	due := responseDeadline(req.SubmittedAt, s.jurisdictions.GetMaxResponseDays(req.Jurisdiction))
+	req.DueAt = &due
+}
+
+// ProcessPendingRequests verifies and executes up to batch received export
+// and deletion requests each, oldest first. Exports are skipped while no
+// export storage is configured. It returns the number of requests
+// completed.
+func (s *Service) ProcessPendingRequests(ctx context.Context, batch int) (int, error) {
+	types := []string{RequestTypeDeletion}
+	if s.exportStore != nil && s.exportVault != nil {
+		types = append(types, RequestTypeExport)
+	}
+
+	completed := 0
+	for _, requestType := range types {
+		pending, err := s.repo.GetPendingRequests(ctx, requestType, batch)
+		if err != nil {
+			return completed, fmt.Errorf("listing pending %s requests: %w", requestType, err)
+		}
+		for i := range pending {
+			if ctx.Err() != nil {
+				return completed, ctx.Err()
+			}
+			ok, err := s.processPending(ctx, &pending[i])
+			if err != nil {
+				log.Printf("WARNING: privacy request %s: %v", pending[i].ID, err)
+			}
+			if ok {
+				completed++
+			}
+		}
+	}
+	return completed, nil
+}
+
+// processPending verifies the requester of one received request and then
+// runs it. Requests whose requester cannot be verified fail.
+func (s *Service) processPending(ctx context.Context, req *PrivacyRequest) (bool, error) {
+	if req.DueAt == nil {
+		s.setDeadline(ctx, req)
+		if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+			return false, fmt.Errorf("updating privacy request: %w", err)
+		}
+	}
+
+	if req.VerifiedAt == nil {
+		requestedBy := req.UserID
+		if req.RequestedBy != nil {
+			requestedBy = *req.RequestedBy
+		}
+		result, err := s.processor.VerifyRequester(ctx, req.UserID, requestedBy)
+		if errors.Is(err, requests.ErrIdentityNotVerified) {
+			s.logRequestEvent(ctx, req, "verify", map[string]any{"verified": false, "reason": result.Reason})
+			return false, s.failRequest(ctx, req, err)
+		}
+		if err != nil {
+			return false, err
+		}
+		req.VerificationMethod = result.Method
+		req.VerifiedBy = &result.VerifiedBy
+		req.VerifiedAt = &result.VerifiedAt
+		if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+			return false, fmt.Errorf("updating privacy request: %w", err)
+		}
+		s.logRequestEvent(ctx, req, "verify", map[string]any{"verified": true, "method": result.Method})
+	}
+
+	switch req.RequestType {
+	case RequestTypeExport:
+		done, err := s.ProcessExportRequest(ctx, req.ID, "")
+		if err != nil {
+			return false, err
+		}
+		return done.Status == RequestStatusCompleted, nil
+	case RequestTypeDeletion:
+		if _, err := s.ProcessDeletionRequest(ctx, req.ID, ""); err != nil {
+			return false, err
+		}
+		done, err := s.repo.GetPrivacyRequest(ctx, req.ID)
+		if err != nil {
+			return false, err
+		}
+		return done.Status == RequestStatusCompleted, nil
+	}
+	return false, nil
+}
+
+// EscalateDueRequests flags up to batch open requests whose deadline is
+// within the escalation window, or already passed, so officers can act
+// before the jurisdiction's response time runs out. Each request is
+// escalated once.
+func (s *Service) EscalateDueRequests(ctx context.Context, batch int) (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListRequestsDueBefore(ctx, now.Add(escalationWindow), batch)
+	if err != nil {
+		return 0, fmt.Errorf("listing due requests: %w", err)
+	}
+	n := 0
+	for i := range due {
+		req := &due[i]
+		req.EscalatedAt = &now
+		if err := s.repo.UpdatePrivacyRequest(ctx, req); err != nil {
+			log.Printf("WARNING: escalating privacy request %s: %v", req.ID, err)
+			continue
+		}
+		overdue := req.DueAt.Before(now)
+		log.Printf("WARNING: privacy request %s (%s, %s) is due %s (overdue: %t)",
+			req.ID, req.RequestType, req.Jurisdiction, req.DueAt.Format(time.RFC3339), overdue)
+		s.logRequestEvent(ctx, req, "escalate", map[string]any{
+			"status":       req.Status,
+			"jurisdiction": req.Jurisdiction,
+			"due_at":       req.DueAt,
+			"overdue":      overdue,
+		})
+		n++
+	}
+	return n, nil
+}
+
+// StartRequestWorker processes pending privacy requests and escalates
+// those nearing their deadline every interval until ctx is cancelled.
+func (s *Service) StartRequestWorker(ctx context.Context, interval time.Duration, batch int) {
+	if interval <= 0 || batch <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				completed, err := s.ProcessPendingRequests(ctx, batch)
+				if err != nil {
+					log.Printf("WARNING: privacy request worker failed: %v", err)
+				}
+				escalated, err := s.EscalateDueRequests(ctx, batch)
+				if err != nil {
+					log.Printf("WARNING: privacy request escalation failed: %v", err)
+				}
+				if completed+escalated > 0 {
+					log.Printf("🛡️ Privacy request worker completed %d request(s), escalated %d", completed, escalated)
+				}
+			}
+		}
+	}()
+}
+
+// verifyByOfficer records an officer who processes an unverified request
+// as having verified the requester.
+func (s *Service) verifyByOfficer(ctx context.Context, req *PrivacyRequest, actorID string) {
+	if req.VerifiedAt != nil || actorID == "" {
+		return
+	}
+	result, err := s.processor.VerifyByAdmin(actorID)
+	if err != nil {
+		return
+	}
+	req.VerificationMethod = result.Method
+	req.VerifiedBy = &result.VerifiedBy
+	req.VerifiedAt = &result.VerifiedAt
+	s.logRequestEvent(ctx, req, "verify", map[string]any{"verified": true, "method": result.Method, "verified_by": actorID})
+}
+
+func (s *Service) logRequestEvent(ctx context.Context, req *PrivacyRequest, action string, values map[string]any) {
+	if err := s.LogAuditEvent(ctx, AuditEntry{
+		EventType:        "privacy_request",
+		EventAction:      action,
+		ActorType:        ActorTypeSystem,
+		TargetType:       "privacy_request",
+		TargetID:         req.ID,
+		TargetOwnerID:    req.UserID,
+		SensitivityLevel: SensitivitySensitive,
+		ServiceName:      "compliance",
+		NewValues:        values,
+	}); err != nil {
+		log.Printf("WARNING: audit log for privacy request %s: %v", req.ID, err)
+	}
+}
+
+// responseDeadline is the end of a jurisdiction's response period for a
+// request submitted at the given time.
+func responseDeadline(submitted time.Time, maxDays int) time.Time {
+	return submitted.AddDate(0, 0, maxDays)
+}
+
+// actorType is the audit actor type for an action taken by actorID, or by
+// the system when it is empty.
+func actorType(actorID string) string {
+	if actorID == "" {
+		return ActorTypeSystem
+	}
+	return ActorTypeUser
+}
@@ -0,0 +1,24 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/privacy"
+)
+
+func TestResponseDeadlines(t *testing.T) {
+	jm := privacy.NewJurisdictionManager()
+	submitted := time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC)
+	for jurisdiction, want := range map[string]string{
+		"GDPR":    "2026-02-19",
+		"LGPD":    "2026-02-04",
+		"CCPA":    "2026-03-06",
+		"unknown": "2026-02-19",
+	} {
+		got := responseDeadline(submitted, jm.GetMaxResponseDays(jurisdiction))
+		if got.Format("2006-01-02") != want || got.Hour() != 9 {
+			t.Errorf("%s: got %s, want %s", jurisdiction, got, want)
+		}
+	}
+}
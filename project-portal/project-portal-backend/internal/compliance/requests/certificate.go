@@ -0,0 +1,105 @@
+package requests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCertificateInvalid is returned when a deletion certificate's digest or
+// signature does not match its contents.
+var ErrCertificateInvalid = errors.New("deletion certificate does not match its signature")
+
+// Certificate attests what a deletion request erased and kept. Digest is
+// the SHA-256 of the canonical JSON of the other fields; Signature is an
+// HMAC-SHA256 of the digest.
+type Certificate struct {
+	RequestID string             `json:"request_id"`
+	UserID    string             `json:"user_id"`
+	IssuedAt  time.Time          `json:"issued_at"`
+	Verified  bool               `json:"verified"`
+	Entries   []CertificateEntry `json:"entries"`
+	Digest    string             `json:"digest"`
+	Signature string             `json:"signature"`
+}
+
+// CertificateEntry is the outcome for one source and category.
+type CertificateEntry struct {
+	Source            string `json:"source"`
+	Category          string `json:"category"`
+	Status            string `json:"status"`
+	RecordsFound      int64  `json:"records_found"`
+	RecordsDeleted    int64  `json:"records_deleted"`
+	RecordsAnonymized int64  `json:"records_anonymized"`
+	RecordsRetained   int64  `json:"records_retained"`
+	RecordsRemaining  int64  `json:"records_remaining"`
+	Reason            string `json:"reason,omitempty"`
+}
+
+// IssueCertificate builds and signs the certificate for a deletion result.
+func IssueCertificate(requestID string, result *DeletionResult, key []byte) *Certificate {
+	cert := &Certificate{
+		RequestID: requestID,
+		UserID:    result.UserID,
+		IssuedAt:  time.Now().UTC().Truncate(time.Second),
+		Verified:  result.Verified,
+	}
+	for loc, r := range result.Summary {
+		source, category, _ := strings.Cut(loc, "/")
+		cert.Entries = append(cert.Entries, CertificateEntry{
+			Source:            source,
+			Category:          category,
+			Status:            r.Status,
+			RecordsFound:      r.RecordsFound,
+			RecordsDeleted:    r.RecordsDeleted,
+			RecordsAnonymized: r.RecordsAnonymized,
+			RecordsRetained:   r.RecordsRetained,
+			RecordsRemaining:  r.RecordsRemaining,
+			Reason:            r.Reason,
+		})
+	}
+	sort.Slice(cert.Entries, func(i, j int) bool {
+		if cert.Entries[i].Source != cert.Entries[j].Source {
+			return cert.Entries[i].Source < cert.Entries[j].Source
+		}
+		return cert.Entries[i].Category < cert.Entries[j].Category
+	})
+	cert.Digest = cert.digest()
+	cert.Signature = signDigest(cert.Digest, key)
+	return cert
+}
+
+// Verify recomputes the digest and checks the signature.
+func (c *Certificate) Verify(key []byte) error {
+	if c.digest() != c.Digest || !hmac.Equal([]byte(signDigest(c.Digest, key)), []byte(c.Signature)) {
+		return ErrCertificateInvalid
+	}
+	return nil
+}
+
+func (c *Certificate) digest() string {
+	entries := c.Entries
+	if entries == nil {
+		entries = []CertificateEntry{}
+	}
+	payload, _ := json.Marshal(struct {
+		RequestID string             `json:"request_id"`
+		UserID    string             `json:"user_id"`
+		IssuedAt  string             `json:"issued_at"`
+		Verified  bool               `json:"verified"`
+		Entries   []CertificateEntry `json:"entries"`
+	}{c.RequestID, c.UserID, c.IssuedAt.UTC().Format(time.RFC3339), c.Verified, entries})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func signDigest(digest string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(digest))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,156 @@
+// Package datasource implements requests.Adapter over database tables, so
+// modules only describe where a data subject's rows live.
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+
+	"gorm.io/gorm"
+)
+
+// Table describes the rows of one table that belong to a data subject.
+type Table struct {
+	Name     string // table name
+	Dataset  string // export name; defaults to Name
+	Category string
+	// Match selects the subject's rows, with one placeholder for the user ID.
+	Match string
+	// Project is the column or expression giving a row's project. Rows in
+	// projects under legal hold are never erased. Empty when rows do not
+	// belong to a project.
+	Project string
+	// Anonymize returns the columns to overwrite when erasing. When nil the
+	// rows are deleted.
+	Anonymize func(userID string) map[string]any
+	// TimeColumn is the row creation time used for export date ranges;
+	// defaults to created_at.
+	TimeColumn string
+	// Omit lists columns left out of exports, such as secrets and hashes.
+	Omit []string
+	// NoExport leaves the table out of exports. It is used for rows that
+	// only reference the subject in someone else's record.
+	NoExport bool
+}
+
+// Source is a requests.Adapter over a set of tables.
+type Source struct {
+	name   string
+	db     *gorm.DB
+	tables []Table
+}
+
+// New creates a data source named after the module that owns the tables.
+func New(name string, db *gorm.DB, tables ...Table) *Source {
+	return &Source{name: name, db: db, tables: tables}
+}
+
+// Source describes the data source and its categories.
+func (s *Source) Source() requests.DataSource {
+	var cats []string
+	for _, t := range s.tables {
+		if !slices.Contains(cats, t.Category) {
+			cats = append(cats, t.Category)
+		}
+	}
+	return requests.DataSource{Name: s.name, Type: "database", Categories: cats}
+}
+
+// Count returns the subject's rows per category.
+func (s *Source) Count(ctx context.Context, userID string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, t := range s.tables {
+		var n int64
+		if err := s.db.WithContext(ctx).Table(t.Name).Where(t.Match, userID).Count(&n).Error; err != nil {
+			return nil, fmt.Errorf("counting %s: %w", t.Name, err)
+		}
+		counts[t.Category] += n
+	}
+	return counts, nil
+}
+
+// Export returns the subject's rows in the scope's categories and time
+// range.
+func (s *Source) Export(ctx context.Context, scope requests.ExportScope) ([]requests.Dataset, error) {
+	var sets []requests.Dataset
+	for _, t := range s.tables {
+		if t.NoExport || (len(scope.Categories) > 0 && !slices.Contains(scope.Categories, t.Category)) {
+			continue
+		}
+		timeCol := t.TimeColumn
+		if timeCol == "" {
+			timeCol = "created_at"
+		}
+		q := s.db.WithContext(ctx).Table(t.Name).Where(t.Match, scope.UserID)
+		if scope.From != nil {
+			q = q.Where(timeCol+" >= ?", *scope.From)
+		}
+		if scope.To != nil {
+			q = q.Where(timeCol+" < ?", *scope.To)
+		}
+		var rows []map[string]any
+		if err := q.Order(timeCol).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("exporting %s: %w", t.Name, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		for _, row := range rows {
+			for _, col := range t.Omit {
+				delete(row, col)
+			}
+		}
+		name := t.Dataset
+		if name == "" {
+			name = t.Name
+		}
+		sets = append(sets, requests.Dataset{Source: s.name, Category: t.Category, Name: name, Records: rows})
+	}
+	return sets, nil
+}
+
+// Erase deletes or anonymizes the subject's rows in one category in a
+// single transaction. Rows in held projects are counted as retained.
+func (s *Source) Erase(ctx context.Context, scope requests.ErasureScope) (requests.CategoryResult, error) {
+	var res requests.CategoryResult
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, t := range s.tables {
+			if t.Category != scope.Category {
+				continue
+			}
+			q := tx.Table(t.Name).Where(t.Match, scope.UserID)
+			if t.Project != "" && len(scope.HeldProjectIDs) > 0 {
+				var held int64
+				if err := tx.Table(t.Name).Where(t.Match, scope.UserID).
+					Where(t.Project+"::text IN ?", scope.HeldProjectIDs).
+					Count(&held).Error; err != nil {
+					return fmt.Errorf("counting held rows in %s: %w", t.Name, err)
+				}
+				res.RecordsRetained += held
+				q = q.Where("("+t.Project+" IS NULL OR "+t.Project+"::text NOT IN ?)", scope.HeldProjectIDs)
+			}
+
+			if t.Anonymize == nil {
+				r := q.Delete(nil)
+				if r.Error != nil {
+					return fmt.Errorf("deleting from %s: %w", t.Name, r.Error)
+				}
+				res.RecordsDeleted += r.RowsAffected
+				continue
+			}
+			r := q.Updates(t.Anonymize(scope.UserID))
+			if r.Error != nil {
+				return fmt.Errorf("anonymizing %s: %w", t.Name, r.Error)
+			}
+			res.RecordsAnonymized += r.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return requests.CategoryResult{}, err
+	}
+	return res, nil
+}
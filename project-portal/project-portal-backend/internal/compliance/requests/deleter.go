@@ -2,10 +2,46 @@ package requests
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
 	"time"
 )
 
+// Category result statuses.
+const (
+	StatusDeleted  = "deleted"
+	StatusRetained = "retained"
+	StatusHeld     = "held"
+	StatusFailed   = "failed"
+)
+
+// retainedCategories are kept despite a deletion request.
+var retainedCategories = map[string]string{
+	CategoryFinancialRecs:  "Required for tax/legal compliance",
+	CategoryAuditLogs:      "Required for regulatory compliance",
+	CategoryConsentRecords: "Evidence of consent (GDPR Art. 7(1))",
+}
+
+// Holds are the legal holds that apply to one data subject.
+type Holds struct {
+	// Subject names the hold covering all of the subject's data, if any.
+	Subject string
+	// Categories maps each held category to the name of its hold.
+	Categories map[string]string
+	// ProjectIDs lists projects under hold; their rows are kept.
+	ProjectIDs []string
+}
+
+// category returns the name of the hold covering a category.
+func (h Holds) category(cat string) (string, bool) {
+	if h.Subject != "" {
+		return h.Subject, true
+	}
+	name, ok := h.Categories[cat]
+	return name, ok
+}
+
 // Deleter handles secure data deletion across all stores.
 type Deleter struct {
 	discoverer *Discoverer
@@ -16,8 +52,10 @@ func NewDeleter(discoverer *Discoverer) *Deleter {
 	return &Deleter{discoverer: discoverer}
 }
 
-// Delete removes user data from all discovered locations.
-func (d *Deleter) Delete(ctx context.Context, userID string, locations []DataLocation) (*DeletionResult, error) {
+// Delete erases user data from all discovered locations, skipping retained
+// and held categories, then re-counts every source to record what is left.
+// Summary is keyed by "source/category".
+func (d *Deleter) Delete(ctx context.Context, userID string, locations []DataLocation, holds Holds) (*DeletionResult, error) {
 	log.Printf("deleting data for user %s across %d locations", userID, len(locations))
 
 	result := &DeletionResult{
@@ -26,39 +64,109 @@ func (d *Deleter) Delete(ctx context.Context, userID string, locations []DataLoc
 	}
 
 	for _, loc := range locations {
-		catResult := d.deleteFromLocation(ctx, userID, loc)
-		result.Summary[loc.Category] = catResult
+		result.Summary[loc.Source+"/"+loc.Category] = d.deleteFromLocation(ctx, userID, loc, holds)
+	}
+	d.verify(ctx, userID, locations, result)
 
-		if catResult.Status == "deleted" {
-			result.DeletedCategories = append(result.DeletedCategories, loc.Category)
-		} else {
-			result.RetainedCategories = append(result.RetainedCategories, loc.Category)
+	deleted, retained, failed := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, loc := range locations {
+		r := result.Summary[loc.Source+"/"+loc.Category]
+		switch {
+		case r.Status == StatusFailed:
+			failed[loc.Category] = true
+		case r.Status != StatusDeleted || r.RecordsRetained > 0:
+			retained[loc.Category] = true
+		default:
+			deleted[loc.Category] = true
+		}
+	}
+	for cat := range deleted {
+		if !retained[cat] && !failed[cat] {
+			result.DeletedCategories = append(result.DeletedCategories, cat)
+		}
+	}
+	for cat := range retained {
+		if !failed[cat] {
+			result.RetainedCategories = append(result.RetainedCategories, cat)
 		}
 	}
+	for cat := range failed {
+		result.FailedCategories = append(result.FailedCategories, cat)
+	}
+	sort.Strings(result.DeletedCategories)
+	sort.Strings(result.RetainedCategories)
+	sort.Strings(result.FailedCategories)
 
+	result.Verified = len(failed) == 0
 	result.CompletedAt = time.Now()
 	return result, nil
 }
 
-func (d *Deleter) deleteFromLocation(ctx context.Context, userID string, loc DataLocation) CategoryResult {
-	// Financial records and legal compliance data are typically retained
-	retainedCategories := map[string]string{
-		"financial_records": "Required for tax/legal compliance",
-		"audit_logs":        "Required for regulatory compliance",
-	}
-
+func (d *Deleter) deleteFromLocation(ctx context.Context, userID string, loc DataLocation, holds Holds) CategoryResult {
 	if reason, retained := retainedCategories[loc.Category]; retained {
 		return CategoryResult{
-			RecordsDeleted: 0,
-			Status:         "retained",
-			Reason:         reason,
+			RecordsFound:    loc.RecordCount,
+			RecordsRetained: loc.RecordCount,
+			Status:          StatusRetained,
+			Reason:          reason,
+		}
+	}
+	if hold, held := holds.category(loc.Category); held {
+		return CategoryResult{
+			RecordsFound:    loc.RecordCount,
+			RecordsRetained: loc.RecordCount,
+			Status:          StatusHeld,
+			Reason:          "Under legal hold: " + hold,
 		}
 	}
 
+	adapter := d.discoverer.adapter(loc.Source)
+	if adapter == nil {
+		return CategoryResult{RecordsFound: loc.RecordCount, Status: StatusFailed, Reason: "no data source registered"}
+	}
+
 	log.Printf("deleting %s data for user %s from %s", loc.Category, userID, loc.Source)
+	res, err := adapter.Erase(ctx, ErasureScope{UserID: userID, Category: loc.Category, HeldProjectIDs: holds.ProjectIDs})
+	if err != nil {
+		log.Printf("WARNING: erasing %s data for user %s from %s: %v", loc.Category, userID, loc.Source, err)
+		return CategoryResult{RecordsFound: loc.RecordCount, Status: StatusFailed, Reason: err.Error()}
+	}
+	res.RecordsFound = loc.RecordCount
+	res.Status = StatusDeleted
+	if res.RecordsRetained > 0 {
+		res.Reason = fmt.Sprintf("%d records kept in projects under legal hold", res.RecordsRetained)
+	}
+	return res
+}
 
-	return CategoryResult{
-		RecordsDeleted: loc.RecordCount,
-		Status:         "deleted",
+// verify re-counts each source after erasure. A location whose remaining
+// records exceed what was deliberately kept is marked failed.
+func (d *Deleter) verify(ctx context.Context, userID string, locations []DataLocation, result *DeletionResult) {
+	counts := make(map[string]map[string]int64)
+	for _, loc := range locations {
+		key := loc.Source + "/" + loc.Category
+		r := result.Summary[key]
+		if r.Status != StatusDeleted {
+			r.RecordsRemaining = r.RecordsRetained
+			result.Summary[key] = r
+			continue
+		}
+
+		c, ok := counts[loc.Source]
+		if !ok {
+			var err error
+			if c, err = d.discoverer.adapter(loc.Source).Count(ctx, userID); err != nil {
+				r.Status, r.Reason = StatusFailed, "verification failed: "+err.Error()
+				result.Summary[key] = r
+				continue
+			}
+			counts[loc.Source] = c
+		}
+		r.RecordsRemaining = c[loc.Category]
+		if r.RecordsRemaining > r.RecordsRetained {
+			r.Status = StatusFailed
+			r.Reason = fmt.Sprintf("%d records still present after erasure", r.RecordsRemaining-r.RecordsRetained)
+		}
+		result.Summary[key] = r
 	}
 }
@@ -0,0 +1,159 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeAdapter holds per-category record counts. Erase removes all records
+// except those in held projects and, to simulate a faulty store, leftover.
+type fakeAdapter struct {
+	name     string
+	counts   map[string]int64
+	inHeld   map[string]int64 // records per category that sit in held projects
+	leftover map[string]int64
+	erased   []string
+}
+
+func (f *fakeAdapter) Source() DataSource {
+	var cats []string
+	for c := range f.counts {
+		cats = append(cats, c)
+	}
+	return DataSource{Name: f.name, Type: "database", Categories: cats}
+}
+
+func (f *fakeAdapter) Count(ctx context.Context, userID string) (map[string]int64, error) {
+	out := make(map[string]int64)
+	for c, n := range f.counts {
+		out[c] = n
+	}
+	return out, nil
+}
+
+func (f *fakeAdapter) Export(ctx context.Context, scope ExportScope) ([]Dataset, error) {
+	var sets []Dataset
+	for _, c := range f.Source().Categories {
+		if len(scope.Categories) > 0 && !slices.Contains(scope.Categories, c) {
+			continue
+		}
+		var records []map[string]any
+		for i := int64(0); i < f.counts[c]; i++ {
+			records = append(records, map[string]any{"id": i, "category": c})
+		}
+		sets = append(sets, Dataset{Source: f.name, Category: c, Name: c, Records: records})
+	}
+	return sets, nil
+}
+
+func (f *fakeAdapter) Erase(ctx context.Context, scope ErasureScope) (CategoryResult, error) {
+	f.erased = append(f.erased, scope.Category)
+	var kept int64
+	if len(scope.HeldProjectIDs) > 0 {
+		kept = f.inHeld[scope.Category]
+	}
+	erased := f.counts[scope.Category] - kept
+	f.counts[scope.Category] = kept + f.leftover[scope.Category]
+	return CategoryResult{RecordsDeleted: erased - f.leftover[scope.Category], RecordsRetained: kept}, nil
+}
+
+func TestDeleterRespectsRetentionAndHolds(t *testing.T) {
+	ctx := context.Background()
+	settings := &fakeAdapter{name: "settings", counts: map[string]int64{
+		CategoryUserProfile:   3,
+		CategoryFinancialRecs: 2,
+	}}
+	collab := &fakeAdapter{name: "collaboration",
+		counts: map[string]int64{CategoryProjectData: 5, CategorySystemLogs: 4},
+		inHeld: map[string]int64{CategoryProjectData: 2},
+	}
+	p := NewProcessor(settings, collab)
+
+	holds := Holds{
+		Categories: map[string]string{CategorySystemLogs: "Audit 2026"},
+		ProjectIDs: []string{"p1"},
+	}
+	res, err := p.ProcessDeletionRequest(ctx, "u1", nil, holds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := res.Summary["settings/"+CategoryFinancialRecs]; got.Status != StatusRetained || got.RecordsRetained != 2 {
+		t.Errorf("financial records: %+v", got)
+	}
+	if got := res.Summary["collaboration/"+CategorySystemLogs]; got.Status != StatusHeld || got.RecordsRemaining != 4 {
+		t.Errorf("held logs: %+v", got)
+	}
+	if got := res.Summary["settings/"+CategoryUserProfile]; got.Status != StatusDeleted || got.RecordsDeleted != 3 || got.RecordsRemaining != 0 {
+		t.Errorf("profile: %+v", got)
+	}
+	if got := res.Summary["collaboration/"+CategoryProjectData]; got.Status != StatusDeleted || got.RecordsRetained != 2 || got.RecordsRemaining != 2 {
+		t.Errorf("project data: %+v", got)
+	}
+	if len(collab.erased) != 1 || collab.erased[0] != CategoryProjectData {
+		t.Errorf("held category was erased: %v", collab.erased)
+	}
+	if !res.Verified || len(res.FailedCategories) != 0 {
+		t.Errorf("expected a verified result, got %+v", res)
+	}
+	if len(res.DeletedCategories) != 1 || res.DeletedCategories[0] != CategoryUserProfile {
+		t.Errorf("deleted categories: %v", res.DeletedCategories)
+	}
+}
+
+func TestDeleterSubjectHoldKeepsEverything(t *testing.T) {
+	a := &fakeAdapter{name: "settings", counts: map[string]int64{CategoryUserProfile: 1}}
+	res, err := NewProcessor(a).ProcessDeletionRequest(context.Background(), "u1", nil, Holds{Subject: "Litigation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Summary["settings/"+CategoryUserProfile]; got.Status != StatusHeld || len(a.erased) != 0 {
+		t.Errorf("expected the profile to be held, got %+v (erased %v)", got, a.erased)
+	}
+}
+
+func TestDeleterFailsWhenRecordsRemain(t *testing.T) {
+	a := &fakeAdapter{name: "settings",
+		counts:   map[string]int64{CategoryUserProfile: 3},
+		leftover: map[string]int64{CategoryUserProfile: 1},
+	}
+	res, err := NewProcessor(a).ProcessDeletionRequest(context.Background(), "u1", nil, Holds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Summary["settings/"+CategoryUserProfile]; got.Status != StatusFailed || got.RecordsRemaining != 1 {
+		t.Errorf("expected failed verification, got %+v", got)
+	}
+	if res.Verified || len(res.FailedCategories) != 1 {
+		t.Errorf("expected an unverified result, got %+v", res)
+	}
+}
+
+func TestCertificateVerify(t *testing.T) {
+	a := &fakeAdapter{name: "settings", counts: map[string]int64{CategoryUserProfile: 2, CategoryFinancialRecs: 1}}
+	res, err := NewProcessor(a).ProcessDeletionRequest(context.Background(), "u1", nil, Holds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("test-key")
+	cert := IssueCertificate("req-1", res, key)
+	if len(cert.Entries) != 2 || cert.Entries[0].Category != CategoryFinancialRecs {
+		t.Fatalf("entries not sorted: %+v", cert.Entries)
+	}
+	if err := cert.Verify(key); err != nil {
+		t.Fatalf("fresh certificate: %v", err)
+	}
+	if err := cert.Verify([]byte("other-key")); !errors.Is(err, ErrCertificateInvalid) {
+		t.Errorf("wrong key: %v", err)
+	}
+
+	tampered := *cert
+	tampered.Entries = append([]CertificateEntry(nil), cert.Entries...)
+	tampered.Entries[1].RecordsRemaining = 0
+	tampered.Entries[1].RecordsDeleted = 5
+	if err := tampered.Verify(key); !errors.Is(err, ErrCertificateInvalid) {
+		t.Errorf("tampered entries: %v", err)
+	}
+}
@@ -2,19 +2,76 @@ package requests
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
+	"time"
 )
 
-// Discoverer locates all user data across distributed systems.
+// Data categories used by data sources. The compliance package re-exports
+// these as DataCategory* constants.
+const (
+	CategoryUserProfile    = "user_profile"
+	CategoryProjectData    = "project_data"
+	CategoryFinancialRecs  = "financial_records"
+	CategorySystemLogs     = "system_logs"
+	CategoryAuditLogs      = "audit_logs"
+	CategoryConsentRecords = "consent_records"
+)
+
+// ErasedUserID replaces a data subject's ID in columns that cannot be NULL
+// once their rows have been anonymized.
+const ErasedUserID = "00000000-0000-0000-0000-000000000000"
+
+// Adapter gives privacy requests access to the personal data one module
+// stores. Each module registers its own adapter with the Discoverer.
+type Adapter interface {
+	// Source describes the adapter and the categories it can hold.
+	Source() DataSource
+	// Count returns the number of the subject's records per category.
+	Count(ctx context.Context, userID string) (map[string]int64, error)
+	// Export returns the subject's records selected by scope.
+	Export(ctx context.Context, scope ExportScope) ([]Dataset, error)
+	// Erase deletes or anonymizes the subject's records in one category,
+	// leaving rows that belong to held projects untouched.
+	Erase(ctx context.Context, scope ErasureScope) (CategoryResult, error)
+}
+
+// ErasureScope selects the records an Adapter erases.
+type ErasureScope struct {
+	UserID   string
+	Category string
+	// HeldProjectIDs lists projects under legal hold; their rows are kept.
+	HeldProjectIDs []string
+}
+
+// ExportScope selects the records an Adapter exports.
+type ExportScope struct {
+	UserID string
+	// Categories limits the export; empty exports every category.
+	Categories []string
+	// From and To optionally limit records by creation time.
+	From, To *time.Time
+}
+
+// Dataset is one table's worth of a subject's records in an export.
+type Dataset struct {
+	Source   string           `json:"source"`
+	Category string           `json:"category"`
+	Name     string           `json:"name"`
+	Records  []map[string]any `json:"records"`
+}
+
+// Discoverer locates all user data across the registered data sources.
 type Discoverer struct {
-	dataSources []DataSource
+	adapters []Adapter
 }
 
 // DataSource represents a system that may contain user data.
 type DataSource struct {
-	Name       string
-	Type       string // "database", "file_storage", "cache", "log"
-	Categories []string
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // "database", "file_storage", "cache", "log"
+	Categories []string `json:"categories"`
 }
 
 // DataLocation describes where user data was found.
@@ -25,25 +82,14 @@ type DataLocation struct {
 	Description string `json:"description"`
 }
 
-// NewDiscoverer creates a new data discoverer with default data source registry.
-func NewDiscoverer() *Discoverer {
-	return &Discoverer{
-		dataSources: defaultDataSources(),
-	}
-}
-
-func defaultDataSources() []DataSource {
-	return []DataSource{
-		{Name: "users_db", Type: "database", Categories: []string{"user_profile"}},
-		{Name: "projects_db", Type: "database", Categories: []string{"project_data"}},
-		{Name: "financial_db", Type: "database", Categories: []string{"financial_records"}},
-		{Name: "activity_logs", Type: "database", Categories: []string{"system_logs"}},
-		{Name: "file_storage", Type: "file_storage", Categories: []string{"user_profile", "project_data"}},
-		{Name: "cache", Type: "cache", Categories: []string{"user_profile"}},
-	}
+// NewDiscoverer creates a data discoverer over the given adapters.
+func NewDiscoverer(adapters ...Adapter) *Discoverer {
+	return &Discoverer{adapters: adapters}
 }
 
-// DiscoverUserData finds all data for a user, optionally filtered by categories.
+// DiscoverUserData counts the user's records in every registered source,
+// optionally filtered by categories. Only locations holding records are
+// returned, ordered by source and category.
 func (d *Discoverer) DiscoverUserData(ctx context.Context, userID string, categories []string) ([]DataLocation, error) {
 	log.Printf("discovering data for user %s (categories: %v)", userID, categories)
 
@@ -53,29 +99,54 @@ func (d *Discoverer) DiscoverUserData(ctx context.Context, userID string, catego
 	}
 
 	var locations []DataLocation
-	for _, source := range d.dataSources {
-		for _, cat := range source.Categories {
-			if len(categoryFilter) > 0 && !categoryFilter[cat] {
+	for _, adapter := range d.adapters {
+		source := adapter.Source()
+		counts, err := adapter.Count(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("counting records in %s: %w", source.Name, err)
+		}
+		for cat, n := range counts {
+			if n == 0 || (len(categoryFilter) > 0 && !categoryFilter[cat]) {
 				continue
 			}
 			locations = append(locations, DataLocation{
 				Source:      source.Name,
 				Category:    cat,
-				RecordCount: 0,
+				RecordCount: n,
 				Description: source.Type + " records in " + source.Name,
 			})
 		}
 	}
 
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Source != locations[j].Source {
+			return locations[i].Source < locations[j].Source
+		}
+		return locations[i].Category < locations[j].Category
+	})
 	return locations, nil
 }
 
-// RegisterDataSource adds a new data source to the discoverer.
-func (d *Discoverer) RegisterDataSource(source DataSource) {
-	d.dataSources = append(d.dataSources, source)
+// RegisterDataSource adds a module's adapter to the discoverer.
+func (d *Discoverer) RegisterDataSource(adapter Adapter) {
+	d.adapters = append(d.adapters, adapter)
 }
 
 // ListDataSources returns all registered data sources.
 func (d *Discoverer) ListDataSources() []DataSource {
-	return d.dataSources
+	sources := make([]DataSource, 0, len(d.adapters))
+	for _, a := range d.adapters {
+		sources = append(sources, a.Source())
+	}
+	return sources
+}
+
+// adapter returns the adapter registered under a source name.
+func (d *Discoverer) adapter(name string) Adapter {
+	for _, a := range d.adapters {
+		if a.Source().Name == name {
+			return a
+		}
+	}
+	return nil
 }
@@ -3,7 +3,6 @@ package requests
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -19,45 +18,69 @@ func NewExporter(discoverer *Discoverer) *Exporter {
 	return &Exporter{discoverer: discoverer}
 }
 
-// Export builds a data export for the specified user and data locations.
-func (e *Exporter) Export(ctx context.Context, userID string, locations []DataLocation, format string) (*ExportResult, error) {
-	log.Printf("exporting data for user %s in format %s (%d locations)", userID, format, len(locations))
-
-	exportData := make(map[string]interface{})
-	exportData["user_id"] = userID
-	exportData["export_date"] = time.Now().Format(time.RFC3339)
-	exportData["data_categories"] = make(map[string]interface{})
+// Export collects the subject's records from each discovered location and
+// renders the export package. The scope's categories are ignored in favour
+// of the locations; its date range is passed to each data source.
+func (e *Exporter) Export(ctx context.Context, scope ExportScope, locations []DataLocation, format string) (*ExportResult, error) {
+	if !ValidFormat(format) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	log.Printf("exporting data for user %s in format %s (%d locations)", scope.UserID, format, len(locations))
 
+	// One Export call per source, covering all of its discovered categories.
+	var order []string
+	bySource := make(map[string][]string)
 	for _, loc := range locations {
-		categoryData, ok := exportData["data_categories"].(map[string]interface{})
-		if !ok {
-			continue
+		if _, ok := bySource[loc.Source]; !ok {
+			order = append(order, loc.Source)
 		}
-		categoryData[loc.Category] = map[string]interface{}{
-			"source":       loc.Source,
-			"record_count": loc.RecordCount,
-			"description":  loc.Description,
+		bySource[loc.Source] = append(bySource[loc.Source], loc.Category)
+	}
+
+	var datasets []Dataset
+	var records int64
+	for _, name := range order {
+		adapter := e.discoverer.adapter(name)
+		if adapter == nil {
+			return nil, fmt.Errorf("no data source registered as %s", name)
 		}
+		sourceScope := scope
+		sourceScope.Categories = bySource[name]
+		sets, err := adapter.Export(ctx, sourceScope)
+		if err != nil {
+			return nil, fmt.Errorf("exporting from %s: %w", name, err)
+		}
+		for _, set := range sets {
+			records += int64(len(set.Records))
+		}
+		datasets = append(datasets, sets...)
 	}
 
-	data, err := json.MarshalIndent(exportData, "", "  ")
+	now := time.Now().UTC().Truncate(time.Second)
+	data, err := Render(&Package{
+		UserID:      scope.UserID,
+		GeneratedAt: now,
+		Locations:   locations,
+		Datasets:    datasets,
+	}, format)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling export data: %w", err)
+		return nil, fmt.Errorf("rendering %s export: %w", format, err)
 	}
 
 	hash := sha256.Sum256(data)
-
 	return &ExportResult{
-		UserID:      userID,
-		FileURL:     fmt.Sprintf("/exports/%s/%d.json", userID, time.Now().Unix()),
+		UserID:      scope.UserID,
 		FileHash:    fmt.Sprintf("%x", hash),
 		Format:      format,
+		ContentType: ContentType(format),
 		SizeBytes:   int64(len(data)),
-		CompletedAt: time.Now(),
+		RecordCount: records,
+		Data:        data,
+		CompletedAt: now,
 	}, nil
 }
 
 // SupportedFormats returns the list of supported export formats.
 func (e *Exporter) SupportedFormats() []string {
-	return []string{"json", "xml", "csv"}
+	return []string{FormatJSON, FormatXML, FormatCSV}
 }
@@ -0,0 +1,256 @@
+package requests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXML  = "xml"
+)
+
+// ErrUnsupportedFormat is returned for an export format that cannot be
+// rendered.
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Package is the content of a data-subject export.
+type Package struct {
+	UserID      string         `json:"user_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Locations   []DataLocation `json:"locations"`
+	Datasets    []Dataset      `json:"datasets"`
+}
+
+// ValidFormat reports whether format can be rendered.
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatCSV || format == FormatXML
+}
+
+// FileExtension returns the file extension of a rendered package.
+func FileExtension(format string) string {
+	if format == FormatCSV {
+		return "zip"
+	}
+	return format
+}
+
+// ContentType returns the MIME type of a rendered package.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "application/zip"
+	case FormatXML:
+		return "application/xml"
+	default:
+		return "application/json"
+	}
+}
+
+// Render writes the package in the given format. CSV packages are a zip
+// holding one file per category and a JSON manifest of the locations.
+func Render(pkg *Package, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(pkg, "", "  ")
+	case FormatCSV:
+		return renderCSV(pkg)
+	case FormatXML:
+		return renderXML(pkg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func renderCSV(pkg *Package) ([]byte, error) {
+	var order []string
+	byCategory := make(map[string][]Dataset)
+	for _, set := range pkg.Datasets {
+		if _, ok := byCategory[set.Category]; !ok {
+			order = append(order, set.Category)
+		}
+		byCategory[set.Category] = append(byCategory[set.Category], set)
+	}
+	sort.Strings(order)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, category := range order {
+		sets := byCategory[category]
+		f, err := zw.Create(category + ".csv")
+		if err != nil {
+			return nil, err
+		}
+		w := csv.NewWriter(f)
+		columns := recordColumns(sets)
+		if err := w.Write(append([]string{"source", "dataset"}, columns...)); err != nil {
+			return nil, err
+		}
+		for _, set := range sets {
+			for _, rec := range set.Records {
+				row := []string{csvSafe(set.Source), csvSafe(set.Name)}
+				for _, col := range columns {
+					v, ok := rec[col]
+					if !ok {
+						row = append(row, "")
+						continue
+					}
+					row = append(row, csvSafe(formatValue(v)))
+				}
+				if err := w.Write(row); err != nil {
+					return nil, err
+				}
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(map[string]any{
+		"user_id":      pkg.UserID,
+		"generated_at": pkg.GeneratedAt,
+		"locations":    pkg.Locations,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// recordColumns returns the sorted union of the record keys in sets.
+func recordColumns(sets []Dataset) []string {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, set := range sets {
+		for _, rec := range set.Records {
+			for k := range rec {
+				if !seen[k] {
+					seen[k] = true
+					cols = append(cols, k)
+				}
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// csvSafe stops spreadsheet applications from evaluating cell values as
+// formulas.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type xmlExport struct {
+	XMLName     xml.Name      `xml:"export"`
+	UserID      string        `xml:"user_id,attr"`
+	GeneratedAt string        `xml:"generated_at,attr"`
+	Locations   []xmlLocation `xml:"locations>location"`
+	Datasets    []xmlDataset  `xml:"dataset"`
+}
+
+type xmlLocation struct {
+	Source      string `xml:"source,attr"`
+	Category    string `xml:"category,attr"`
+	RecordCount int64  `xml:"record_count,attr"`
+}
+
+type xmlDataset struct {
+	Source   string      `xml:"source,attr"`
+	Category string      `xml:"category,attr"`
+	Name     string      `xml:"name,attr"`
+	Records  []xmlRecord `xml:"record"`
+}
+
+type xmlRecord struct {
+	Fields []xmlField `xml:"field"`
+}
+
+type xmlField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+func renderXML(pkg *Package) ([]byte, error) {
+	doc := xmlExport{
+		UserID:      pkg.UserID,
+		GeneratedAt: pkg.GeneratedAt.UTC().Format(time.RFC3339),
+	}
+	for _, loc := range pkg.Locations {
+		doc.Locations = append(doc.Locations, xmlLocation{Source: loc.Source, Category: loc.Category, RecordCount: loc.RecordCount})
+	}
+	for _, set := range pkg.Datasets {
+		ds := xmlDataset{Source: set.Source, Category: set.Category, Name: set.Name}
+		for _, rec := range set.Records {
+			keys := make([]string, 0, len(rec))
+			for k := range rec {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var r xmlRecord
+			for _, k := range keys {
+				r.Fields = append(r.Fields, xmlField{Name: k, Value: formatValue(rec[k])})
+			}
+			ds.Records = append(ds.Records, r)
+		}
+		doc.Datasets = append(doc.Datasets, ds)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// formatValue renders a database value as text for CSV and XML packages.
+func formatValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	case map[string]any, []any:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,124 @@
+package requests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPackage() *Package {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &Package{
+		UserID:      "u1",
+		GeneratedAt: created,
+		Locations:   []DataLocation{{Source: "settings", Category: CategoryUserProfile, RecordCount: 1}},
+		Datasets: []Dataset{
+			{Source: "settings", Category: CategoryUserProfile, Name: "user_profiles", Records: []map[string]any{
+				{"id": 1, "display_name": "=HYPERLINK(\"x\")", "created_at": created},
+			}},
+			{Source: "collaboration", Category: CategoryProjectData, Name: "comments", Records: []map[string]any{
+				{"id": 7, "content": "hello", "location": nil},
+			}},
+			{Source: "documents", Category: CategoryProjectData, Name: "documents", Records: []map[string]any{
+				{"id": 9, "name": "plan.pdf"},
+			}},
+		},
+	}
+}
+
+func TestRenderCSVZipsOneFilePerCategory(t *testing.T) {
+	data, err := Render(testPackage(), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][][]string)
+	for _, f := range zr.File {
+		if f.Name == "manifest.json" {
+			continue
+		}
+		rc, _ := f.Open()
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		files[f.Name] = rows
+	}
+	if len(zr.File) != 3 || len(files) != 2 {
+		t.Fatalf("expected two category files and a manifest, got %d files", len(zr.File))
+	}
+
+	project := files["project_data.csv"]
+	if got := strings.Join(project[0], ","); got != "source,dataset,content,id,location,name" {
+		t.Errorf("project header: %s", got)
+	}
+	if len(project) != 3 || project[2][1] != "documents" || project[2][5] != "plan.pdf" {
+		t.Errorf("project rows: %v", project)
+	}
+
+	profile := files["user_profile.csv"]
+	if profile[1][3] != `'=HYPERLINK("x")` {
+		t.Errorf("formula not escaped: %q", profile[1][3])
+	}
+	if profile[1][2] != "2026-03-01T12:00:00Z" {
+		t.Errorf("time not formatted: %q", profile[1][2])
+	}
+}
+
+func TestRenderXML(t *testing.T) {
+	data, err := Render(testPackage(), FormatXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc xmlExport
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.UserID != "u1" || len(doc.Locations) != 1 || len(doc.Datasets) != 3 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	fields := doc.Datasets[1].Records[0].Fields
+	if len(fields) != 3 || fields[0].Name != "content" || fields[0].Value != "hello" || fields[1].Value != "7" {
+		t.Errorf("fields: %+v", fields)
+	}
+}
+
+func TestRenderRejectsUnknownFormat(t *testing.T) {
+	if _, err := Render(testPackage(), "pdf"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestExportRendersDiscoveredRecords(t *testing.T) {
+	a := &fakeAdapter{name: "settings", counts: map[string]int64{CategoryUserProfile: 2, CategoryFinancialRecs: 1}}
+	res, err := NewProcessor(a).ProcessExportRequest(context.Background(), "u1", []string{CategoryUserProfile}, nil, nil, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RecordCount != 2 || res.ContentType != "application/json" || res.SizeBytes != int64(len(res.Data)) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if sum := sha256.Sum256(res.Data); res.FileHash != fmt.Sprintf("%x", sum) {
+		t.Error("hash does not match the package")
+	}
+	var pkg Package
+	if err := json.Unmarshal(res.Data, &pkg); err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.Datasets) != 1 || pkg.Datasets[0].Category != CategoryUserProfile || len(pkg.Datasets[0].Records) != 2 {
+		t.Errorf("datasets: %+v", pkg.Datasets)
+	}
+}
@@ -7,26 +7,19 @@ import (
 	"time"
 )
 
-// RequestRepository defines the data access interface needed by the request processor.
-type RequestRepository interface {
-	GetPendingRequests(ctx context.Context, requestType string, limit int) (interface{}, error)
-	UpdatePrivacyRequest(ctx context.Context, req interface{}) error
-}
-
 // Processor handles the lifecycle of privacy data subject requests.
 type Processor struct {
-	repo       interface{}
 	exporter   *Exporter
 	deleter    *Deleter
 	discoverer *Discoverer
 	verifier   *Verifier
 }
 
-// NewProcessor creates a new request processor with all sub-components.
-func NewProcessor(repo interface{}) *Processor {
-	discoverer := NewDiscoverer()
+// NewProcessor creates a new request processor over the given data source
+// adapters. More can be added with RegisterDataSource.
+func NewProcessor(adapters ...Adapter) *Processor {
+	discoverer := NewDiscoverer(adapters...)
 	return &Processor{
-		repo:       repo,
 		exporter:   NewExporter(discoverer),
 		deleter:    NewDeleter(discoverer),
 		discoverer: discoverer,
@@ -34,8 +27,41 @@ func NewProcessor(repo interface{}) *Processor {
 	}
 }
 
-// ProcessExportRequest handles a data export request end-to-end.
-func (p *Processor) ProcessExportRequest(ctx context.Context, userID string, categories []string, startDate, endDate *time.Time) (*ExportResult, error) {
+// RegisterDataSource adds a module's data source adapter.
+func (p *Processor) RegisterDataSource(adapter Adapter) {
+	p.discoverer.RegisterDataSource(adapter)
+}
+
+// SetAccountChecker sets how requesters' accounts are checked during
+// identity verification.
+func (p *Processor) SetAccountChecker(accounts AccountChecker) {
+	p.verifier.SetAccountChecker(accounts)
+}
+
+// VerifyRequester confirms the identity of a request's submitter.
+func (p *Processor) VerifyRequester(ctx context.Context, userID, requestedBy string) (*VerificationResult, error) {
+	return p.verifier.VerifyRequester(ctx, userID, requestedBy)
+}
+
+// VerifyByAdmin records an administrator's verification of a request.
+func (p *Processor) VerifyByAdmin(adminUserID string) (*VerificationResult, error) {
+	return p.verifier.VerifyByAdmin(adminUserID)
+}
+
+// ListDataSources returns all registered data sources.
+func (p *Processor) ListDataSources() []DataSource {
+	return p.discoverer.ListDataSources()
+}
+
+// Discover returns where the user's data is held and how many records each
+// location has.
+func (p *Processor) Discover(ctx context.Context, userID string, categories []string) ([]DataLocation, error) {
+	return p.discoverer.DiscoverUserData(ctx, userID, categories)
+}
+
+// ProcessExportRequest handles a data export request end-to-end, rendering
+// the subject's records created between startDate and endDate in format.
+func (p *Processor) ProcessExportRequest(ctx context.Context, userID string, categories []string, startDate, endDate *time.Time, format string) (*ExportResult, error) {
 	log.Printf("processing export request for user %s", userID)
 
 	locations, err := p.discoverer.DiscoverUserData(ctx, userID, categories)
@@ -43,7 +69,8 @@ func (p *Processor) ProcessExportRequest(ctx context.Context, userID string, cat
 		return nil, fmt.Errorf("data discovery failed: %w", err)
 	}
 
-	result, err := p.exporter.Export(ctx, userID, locations, "json")
+	scope := ExportScope{UserID: userID, From: startDate, To: endDate}
+	result, err := p.exporter.Export(ctx, scope, locations, format)
 	if err != nil {
 		return nil, fmt.Errorf("export failed: %w", err)
 	}
@@ -51,8 +78,9 @@ func (p *Processor) ProcessExportRequest(ctx context.Context, userID string, cat
 	return result, nil
 }
 
-// ProcessDeletionRequest handles a data deletion request end-to-end.
-func (p *Processor) ProcessDeletionRequest(ctx context.Context, userID string, categories []string) (*DeletionResult, error) {
+// ProcessDeletionRequest handles a data deletion request end-to-end. Data
+// covered by holds is kept.
+func (p *Processor) ProcessDeletionRequest(ctx context.Context, userID string, categories []string, holds Holds) (*DeletionResult, error) {
 	log.Printf("processing deletion request for user %s", userID)
 
 	locations, err := p.discoverer.DiscoverUserData(ctx, userID, categories)
@@ -60,7 +88,7 @@ func (p *Processor) ProcessDeletionRequest(ctx context.Context, userID string, c
 		return nil, fmt.Errorf("data discovery failed: %w", err)
 	}
 
-	result, err := p.deleter.Delete(ctx, userID, locations)
+	result, err := p.deleter.Delete(ctx, userID, locations, holds)
 	if err != nil {
 		return nil, fmt.Errorf("deletion failed: %w", err)
 	}
@@ -68,28 +96,41 @@ func (p *Processor) ProcessDeletionRequest(ctx context.Context, userID string, c
 	return result, nil
 }
 
-// ExportResult captures the outcome of a data export operation.
+// ExportResult captures the outcome of a data export operation. Data is
+// the rendered package and FileHash its SHA-256.
 type ExportResult struct {
 	UserID      string    `json:"user_id"`
-	FileURL     string    `json:"file_url"`
 	FileHash    string    `json:"file_hash"`
 	Format      string    `json:"format"`
+	ContentType string    `json:"content_type"`
 	SizeBytes   int64     `json:"size_bytes"`
+	RecordCount int64     `json:"record_count"`
+	Data        []byte    `json:"-"`
 	CompletedAt time.Time `json:"completed_at"`
 }
 
 // DeletionResult captures the outcome of a data deletion operation.
+// Verified is false when any location failed to erase or still holds
+// records after erasure.
 type DeletionResult struct {
 	UserID             string                    `json:"user_id"`
 	DeletedCategories  []string                  `json:"deleted_categories"`
 	RetainedCategories []string                  `json:"retained_categories"`
+	FailedCategories   []string                  `json:"failed_categories,omitempty"`
 	Summary            map[string]CategoryResult `json:"summary"`
+	Verified           bool                      `json:"verified"`
 	CompletedAt        time.Time                 `json:"completed_at"`
 }
 
 // CategoryResult holds the result for a single data category deletion.
+// RecordsRetained counts records deliberately kept; RecordsRemaining is
+// the count found when re-checking after erasure.
 type CategoryResult struct {
-	RecordsDeleted int64  `json:"records_deleted"`
-	Status         string `json:"status"`
-	Reason         string `json:"reason,omitempty"`
+	RecordsFound      int64  `json:"records_found"`
+	RecordsDeleted    int64  `json:"records_deleted"`
+	RecordsAnonymized int64  `json:"records_anonymized"`
+	RecordsRetained   int64  `json:"records_retained"`
+	RecordsRemaining  int64  `json:"records_remaining"`
+	Status            string `json:"status"`
+	Reason            string `json:"reason,omitempty"`
 }
@@ -1,18 +1,63 @@
 package requests
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrIdentityNotVerified is returned when a requester's identity cannot be
+// confirmed.
+var ErrIdentityNotVerified = errors.New("requester identity could not be verified")
+
+// AccountChecker reports whether a user's account is active and its email
+// address has been verified.
+type AccountChecker interface {
+	AccountVerified(ctx context.Context, userID string) (bool, error)
+}
+
 // Verifier handles identity verification for privacy requests.
-type Verifier struct{}
+type Verifier struct {
+	accounts AccountChecker
+}
 
 // NewVerifier creates a new request verifier.
 func NewVerifier() *Verifier {
 	return &Verifier{}
 }
 
+// SetAccountChecker makes VerifyRequester require an active account with
+// a verified email address.
+func (v *Verifier) SetAccountChecker(accounts AccountChecker) {
+	v.accounts = accounts
+}
+
+// VerifyRequester verifies a request submitted by requestedBy for the
+// subject requestUserID. The submitter must be the subject, authenticated
+// with an account whose email address has been verified.
+func (v *Verifier) VerifyRequester(ctx context.Context, requestUserID, requestedBy string) (*VerificationResult, error) {
+	res, err := v.VerifyByEmail(requestUserID, requestedBy)
+	if err != nil {
+		return res, fmt.Errorf("%w: %v", ErrIdentityNotVerified, err)
+	}
+	if v.accounts == nil {
+		return res, nil
+	}
+	ok, err := v.accounts.AccountVerified(ctx, requestUserID)
+	if err != nil {
+		return nil, fmt.Errorf("checking account: %w", err)
+	}
+	if !ok {
+		return &VerificationResult{
+			Verified: false,
+			Method:   "email",
+			Reason:   "account is inactive or its email address is not verified",
+		}, ErrIdentityNotVerified
+	}
+	return res, nil
+}
+
 // VerificationResult captures the outcome of an identity verification.
 type VerificationResult struct {
 	Verified   bool      `json:"verified"`
@@ -0,0 +1,31 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeAccounts map[string]bool
+
+func (f fakeAccounts) AccountVerified(ctx context.Context, userID string) (bool, error) {
+	return f[userID], nil
+}
+
+func TestVerifyRequester(t *testing.T) {
+	ctx := context.Background()
+	v := NewVerifier()
+	v.SetAccountChecker(fakeAccounts{"u1": true, "u2": false})
+
+	res, err := v.VerifyRequester(ctx, "u1", "u1")
+	if err != nil || !res.Verified || res.VerifiedBy != "u1" {
+		t.Errorf("verified account: %+v, %v", res, err)
+	}
+	if _, err := v.VerifyRequester(ctx, "u1", "u2"); !errors.Is(err, ErrIdentityNotVerified) {
+		t.Errorf("other submitter: %v", err)
+	}
+	res, err = v.VerifyRequester(ctx, "u2", "u2")
+	if !errors.Is(err, ErrIdentityNotVerified) || res.Verified || res.Reason == "" {
+		t.Errorf("unverified email: %+v, %v", res, err)
+	}
+}
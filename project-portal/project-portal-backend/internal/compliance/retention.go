@@ -0,0 +1,281 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+)
+
+// ErrRetentionUnavailable is returned when no retention enforcer has been
+// configured.
+var ErrRetentionUnavailable = errors.New("retention enforcement is not configured")
+
+// SetRetentionEnforcer sets the enforcer that applies retention policies to
+// module tables. Policies are not enforced until it is called.
+func (s *Service) SetRetentionEnforcer(enforcer *retention.Enforcer) {
+	s.enforcer = enforcer
+}
+
+// RunRetentionPolicy enforces one policy now. A dry run only reports the
+// rows that would be affected; the schedule's record estimate is refreshed
+// but nothing else is recorded.
+func (s *Service) RunRetentionPolicy(ctx context.Context, policyID string, dryRun bool, actorID string) (*retention.EnforcementResult, error) {
+	if s.enforcer == nil {
+		return nil, ErrRetentionUnavailable
+	}
+	policy, err := s.repo.GetRetentionPolicy(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching policy: %w", err)
+	}
+	schedule, err := s.policySchedule(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.runSchedule(ctx, schedule, dryRun, actorID)
+}
+
+// RunDueRetention enforces every active policy whose next action date has
+// arrived. It returns the number of schedules run.
+func (s *Service) RunDueRetention(ctx context.Context) (int, error) {
+	if s.enforcer == nil {
+		return 0, nil
+	}
+	due, err := s.repo.GetDueSchedules(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("listing due schedules: %w", err)
+	}
+	n := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		schedule := &due[i]
+		if !schedule.Policy.IsActive {
+			continue
+		}
+		if _, err := s.runSchedule(ctx, schedule, false, ""); err != nil {
+			log.Printf("WARNING: retention policy %s: %v", schedule.PolicyID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// StartRetentionSweep runs RunDueRetention every interval until ctx is
+// cancelled.
+func (s *Service) StartRetentionSweep(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.RunDueRetention(ctx)
+				if err != nil {
+					log.Printf("WARNING: retention sweep failed: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("🗄️ Retention sweep enforced %d polic(ies)", n)
+				}
+			}
+		}
+	}()
+}
+
+// runSchedule enforces a schedule's policy, skipping rows under legal hold,
+// and records the outcome on the schedule.
+func (s *Service) runSchedule(ctx context.Context, schedule *RetentionSchedule, dryRun bool, actorID string) (*retention.EnforcementResult, error) {
+	policy := &schedule.Policy
+	holds, err := s.repo.ListActiveLegalHolds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing legal holds: %w", err)
+	}
+
+	now := time.Now()
+	action := policyAction(policy)
+	cutoff := now
+	if policy.RetentionPeriodDays >= 0 {
+		cutoff = now.AddDate(0, 0, -policy.RetentionPeriodDays)
+	}
+	result, err := s.enforcer.Enforce(ctx, retention.Run{
+		Category:      policy.DataCategory,
+		Action:        action,
+		OlderThan:     cutoff,
+		Holds:         retentionHolds(holds, policy.DataCategory, now),
+		DryRun:        dryRun,
+		ArchivePrefix: fmt.Sprintf("retention/%s/%s/%s", policy.DataCategory, policy.ID, now.UTC().Format("20060102T150405Z")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := result.RecordsMatched
+	schedule.RecordCountEstimate = &estimate
+	if !dryRun {
+		tomorrow := now.AddDate(0, 0, 1)
+		schedule.ActionType = action
+		schedule.LastActionDate = &now
+		schedule.LastActionType = action
+		schedule.LastActionResult = result.Status
+		schedule.LastRecordsAffected = result.RecordsAffected
+		schedule.LastRecordsHeld = result.RecordsHeld
+		schedule.LastRunDetails = runDetails(result)
+		schedule.NextActionDate = &tomorrow
+		if !schedule.NextReviewDate.After(now) {
+			schedule.NextReviewDate = now.AddDate(0, 0, reviewDays(policy))
+		}
+	}
+	if err := s.repo.UpdateRetentionSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("updating retention schedule: %w", err)
+	}
+
+	if !dryRun {
+		if err := s.LogAuditEvent(ctx, AuditEntry{
+			EventType:        "data_retention",
+			EventAction:      action,
+			ActorID:          actorID,
+			ActorType:        actorType(actorID),
+			TargetType:       "retention_policy",
+			TargetID:         policy.ID,
+			DataCategory:     policy.DataCategory,
+			SensitivityLevel: SensitivitySensitive,
+			ServiceName:      "compliance",
+			NewValues: map[string]any{
+				"status":           result.Status,
+				"older_than":       cutoff,
+				"records_affected": result.RecordsAffected,
+				"records_held":     result.RecordsHeld,
+				"archive_keys":     result.ArchiveKeys,
+			},
+		}); err != nil {
+			log.Printf("WARNING: audit log for retention policy %s: %v", policy.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// policySchedule returns the policy's schedule, creating it when the policy
+// has none yet.
+func (s *Service) policySchedule(ctx context.Context, policy *RetentionPolicy) (*RetentionSchedule, error) {
+	schedules, err := s.repo.ListRetentionSchedules(ctx, policy.ID)
+	if err != nil {
+		return nil, fmt.Errorf("listing retention schedules: %w", err)
+	}
+	if len(schedules) > 0 {
+		schedule := &schedules[0]
+		schedule.Policy = *policy
+		return schedule, nil
+	}
+	entry := retention.BuildSchedule(policy.ID, policy.DataCategory, policyAction(policy), 0, reviewDays(policy))
+	schedule := &RetentionSchedule{
+		PolicyID:       policy.ID,
+		DataType:       entry.DataType,
+		ActionType:     entry.ActionType,
+		NextActionDate: &entry.NextActionDate,
+		NextReviewDate: entry.ReviewDate,
+	}
+	if err := s.repo.CreateRetentionSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("creating retention schedule: %w", err)
+	}
+	schedule.Policy = *policy
+	return schedule, nil
+}
+
+// schedulePolicy keeps a policy's schedule in step with the policy after it
+// is created or changed. Changed policies run at the next sweep.
+func (s *Service) schedulePolicy(ctx context.Context, policy *RetentionPolicy) error {
+	schedule, err := s.policySchedule(ctx, policy)
+	if err != nil {
+		return err
+	}
+	today := time.Now()
+	schedule.DataType = policy.DataCategory
+	schedule.ActionType = policyAction(policy)
+	schedule.NextActionDate = &today
+	if err := s.repo.UpdateRetentionSchedule(ctx, schedule); err != nil {
+		return fmt.Errorf("updating retention schedule: %w", err)
+	}
+	return nil
+}
+
+// policyAction is the retention action a policy takes on expired data.
+// Indefinite retention only reviews; anonymizing methods anonymize; a
+// policy with an archival period archives; everything else is deleted.
+func policyAction(policy *RetentionPolicy) string {
+	switch {
+	case policy.RetentionPeriodDays < 0:
+		return retention.ActionReview
+	case policy.DeletionMethod == DeletionMethodAnonymize, policy.DeletionMethod == DeletionMethodPseudonymize:
+		return retention.ActionAnonymize
+	case policy.ArchivalPeriodDays != nil:
+		return retention.ActionArchive
+	default:
+		return retention.ActionDelete
+	}
+}
+
+func reviewDays(policy *RetentionPolicy) int {
+	if policy.ReviewPeriodDays <= 0 {
+		return 365
+	}
+	return policy.ReviewPeriodDays
+}
+
+// retentionHolds collects the active, unexpired legal holds that apply to a
+// category. A hold naming neither users nor projects holds the whole
+// category when it covers it; held users are kept in the categories their
+// hold covers, or all of them when it lists none; held projects are always
+// kept.
+func retentionHolds(holds []LegalHold, category string, now time.Time) retention.Holds {
+	var out retention.Holds
+	for _, h := range holds {
+		if h.Status != LegalHoldActive || (h.ExpiresAt != nil && !h.ExpiresAt.After(now)) {
+			continue
+		}
+		covers := len(h.DataCategories) == 0 || slices.Contains(h.DataCategories, category)
+		out.ProjectIDs = append(out.ProjectIDs, h.ProjectIDs...)
+		switch {
+		case !covers:
+		case len(h.AffectedUserIDs) > 0:
+			out.UserIDs = append(out.UserIDs, h.AffectedUserIDs...)
+		case len(h.ProjectIDs) == 0 && out.Category == "":
+			out.Category = h.Name
+		}
+	}
+	return out
+}
+
+// runDetails is the per-table summary stored on a schedule.
+func runDetails(result *retention.EnforcementResult) map[string]any {
+	tables := make([]map[string]any, 0, len(result.Tables))
+	for _, t := range result.Tables {
+		entry := map[string]any{"table": t.Table, "matched": t.Matched, "affected": t.Affected, "held": t.Held}
+		if t.Skipped != "" {
+			entry["skipped"] = t.Skipped
+		}
+		if t.Error != "" {
+			entry["error"] = t.Error
+		}
+		tables = append(tables, entry)
+	}
+	details := map[string]any{"status": result.Status, "tables": tables}
+	if len(result.ArchiveKeys) > 0 {
+		details["archive_keys"] = result.ArchiveKeys
+	}
+	if result.ErrorMessage != "" {
+		details["error"] = result.ErrorMessage
+	}
+	return details
+}
@@ -0,0 +1,92 @@
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+)
+
+// Archive formats.
+const (
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// Archiver writes archived rows to cold storage as gzip-compressed JSON
+// lines or as Parquet files with gzip-compressed column chunks.
+type Archiver struct {
+	store  storage.ObjectStore
+	format string
+}
+
+// NewArchiver creates an archiver writing to store in the given format.
+func NewArchiver(store storage.ObjectStore, format string) (*Archiver, error) {
+	if format != FormatJSONL && format != FormatParquet {
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+	return &Archiver{store: store, format: format}, nil
+}
+
+// Archive encodes rows and stores them under key plus the format's file
+// extension, returning the full object key.
+func (a *Archiver) Archive(ctx context.Context, key string, rows []map[string]any) (string, error) {
+	var (
+		data        []byte
+		err         error
+		contentType string
+	)
+	switch a.format {
+	case FormatParquet:
+		key += ".parquet"
+		contentType = "application/vnd.apache.parquet"
+		data, err = EncodeParquet(rows)
+	default:
+		key += ".jsonl.gz"
+		contentType = "application/gzip"
+		data, err = EncodeJSONL(rows)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encoding archive: %w", err)
+	}
+	if _, err := a.store.Upload(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return "", fmt.Errorf("storing archive %s: %w", key, err)
+	}
+	return key, nil
+}
+
+// EncodeJSONL writes one JSON object per row, gzip-compressed.
+func EncodeJSONL(rows []map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columnNames returns the sorted union of the rows' keys.
+func columnNames(rows []map[string]any) []string {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				cols = append(cols, k)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
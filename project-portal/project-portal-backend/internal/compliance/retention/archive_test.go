@@ -0,0 +1,98 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+)
+
+func archiveRows() []map[string]any {
+	at := time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC)
+	return []map[string]any{
+		{"id": int64(1), "user_id": "u1", "amount": 12.5, "created_at": at},
+		{"id": int64(2), "user_id": nil, "amount": int64(3), "created_at": at.Add(time.Hour)},
+	}
+}
+
+func TestEncodeParquet(t *testing.T) {
+	data, err := EncodeParquet(archiveRows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("missing Parquet magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		t.Fatalf("footer length %d out of range", footerLen)
+	}
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, col := range []string{"amount", "created_at", "id", "user_id"} {
+		if !bytes.Contains(footer, []byte(col)) {
+			t.Errorf("footer does not name column %s", col)
+		}
+	}
+}
+
+func TestColumnKind(t *testing.T) {
+	rows := archiveRows()
+	for col, want := range map[string]parquetKind{
+		"id":         kindInt64,
+		"amount":     kindDouble,
+		"created_at": kindTimestamp,
+		"user_id":    kindString,
+	} {
+		if got := columnKind(rows, col); got != want {
+			t.Errorf("%s: got %+v, want %+v", col, got, want)
+		}
+	}
+}
+
+func TestArchiveJSONL(t *testing.T) {
+	store := storage.NewMemoryStore(storage.NewURLSigner([]byte("secret"), "http://localhost"))
+	archiver, err := NewArchiver(store, FormatJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := archiver.Archive(context.Background(), "retention/system_logs/run/activity_logs-0001", archiveRows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(key, ".jsonl.gz") {
+		t.Fatalf("key = %s", key)
+	}
+
+	body, _, err := store.DownloadStream(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []map[string]any
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, row)
+	}
+	if len(lines) != 2 || lines[0]["user_id"] != "u1" || lines[1]["user_id"] != nil {
+		t.Errorf("archived rows = %v", lines)
+	}
+
+	if _, err := NewArchiver(store, "csv"); err == nil {
+		t.Error("csv archive format accepted")
+	}
+}
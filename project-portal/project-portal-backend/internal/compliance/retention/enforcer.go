@@ -2,87 +2,317 @@ package retention
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Retention actions.
+const (
+	ActionDelete    = "delete"
+	ActionAnonymize = "anonymize"
+	ActionArchive   = "archive"
+	ActionReview    = "review"
 )
 
-// Enforcer executes retention actions (archive, anonymize, delete) on data.
+// Enforcement statuses.
+const (
+	StatusCompleted     = "completed"
+	StatusPartial       = "partial"
+	StatusHeld          = "held"
+	StatusDryRun        = "dry_run"
+	StatusPendingReview = "pending_review"
+	StatusFailed        = "failed"
+)
+
+// ErrArchiveUnavailable is returned for archive runs when no archiver has
+// been configured.
+var ErrArchiveUnavailable = errors.New("retention archive storage is not configured")
+
+// archiveBatch is the number of rows written to each archive object.
+const archiveBatch = 5000
+
+// sampleSize is the number of rows a dry run returns per table.
+const sampleSize = 5
+
+// Target maps a data category to the rows of one table that retention
+// policies for that category act on.
+type Target struct {
+	Table    string
+	Category string
+	// TimeColumn is the row's age; rows older than the policy's cutoff are
+	// due. Defaults to created_at.
+	TimeColumn string
+	// Key is the primary key column used when archiving; defaults to id.
+	Key string
+	// Where optionally narrows the rows retention applies to.
+	Where string
+	// UserColumn names the data subject of each row, so rows of users under
+	// legal hold can be kept. Empty when rows have no subject.
+	UserColumn string
+	// Project is the column or expression giving a row's project. Rows in
+	// projects under legal hold are kept. Empty when rows have no project.
+	Project string
+	// Anonymize lists the columns overwritten by anonymize runs; nil when
+	// the table cannot be anonymized.
+	Anonymize map[string]any
+	// Anonymized matches rows that an earlier run already anonymized, so
+	// they are not counted again.
+	Anonymized string
+}
+
+// Holds describes the legal holds that apply to one category.
+type Holds struct {
+	// Category names a hold covering the whole category; nothing is
+	// touched while it is set.
+	Category string
+	// UserIDs and ProjectIDs list held subjects and projects whose rows are
+	// kept.
+	UserIDs    []string
+	ProjectIDs []string
+}
+
+// Run describes one enforcement of a policy.
+type Run struct {
+	Category  string
+	Action    string
+	OlderThan time.Time
+	Holds     Holds
+	DryRun    bool
+	// ArchivePrefix is the object key prefix archive runs write under.
+	ArchivePrefix string
+}
+
+// Enforcer executes retention actions (archive, anonymize, delete) on the
+// tables registered for each data category.
 type Enforcer struct {
-	anonymizer *Anonymizer
+	db       *gorm.DB
+	archiver *Archiver
+	targets  []Target
 }
 
-// NewEnforcer creates a new retention enforcer.
-func NewEnforcer(anonymizer *Anonymizer) *Enforcer {
-	return &Enforcer{anonymizer: anonymizer}
+// NewEnforcer creates a retention enforcer over db. Archive runs need an
+// archiver; it may be nil when archiving is not configured.
+func NewEnforcer(db *gorm.DB, archiver *Archiver) *Enforcer {
+	return &Enforcer{db: db, archiver: archiver}
 }
 
-// EnforceAction executes the specified retention action on a data set.
-func (e *Enforcer) EnforceAction(ctx context.Context, action, dataType string, olderThan time.Time) (*EnforcementResult, error) {
-	switch action {
-	case "delete":
-		return e.executeDelete(ctx, dataType, olderThan)
-	case "anonymize":
-		return e.executeAnonymize(ctx, dataType, olderThan)
-	case "archive":
-		return e.executeArchive(ctx, dataType, olderThan)
-	case "review":
-		return e.executeReview(ctx, dataType, olderThan)
-	default:
-		return nil, fmt.Errorf("unknown action: %s", action)
-	}
+// Register adds the tables a module keeps for retention policies.
+func (e *Enforcer) Register(targets ...Target) {
+	e.targets = append(e.targets, targets...)
 }
 
-func (e *Enforcer) executeDelete(ctx context.Context, dataType string, olderThan time.Time) (*EnforcementResult, error) {
-	log.Printf("enforcing deletion for %s older than %v", dataType, olderThan)
-	return &EnforcementResult{
-		Action:          "delete",
-		DataType:        dataType,
-		RecordsAffected: 0,
-		CompletedAt:     time.Now(),
-		Status:          "completed",
-	}, nil
+// Targets returns the registered tables for a category.
+func (e *Enforcer) Targets(category string) []Target {
+	var out []Target
+	for _, t := range e.targets {
+		if t.Category == category {
+			out = append(out, t)
+		}
+	}
+	return out
 }
 
-func (e *Enforcer) executeAnonymize(ctx context.Context, dataType string, olderThan time.Time) (*EnforcementResult, error) {
-	log.Printf("enforcing anonymization for %s older than %v", dataType, olderThan)
-	return &EnforcementResult{
-		Action:          "anonymize",
-		DataType:        dataType,
-		RecordsAffected: 0,
-		CompletedAt:     time.Now(),
-		Status:          "completed",
-	}, nil
+// Enforce runs a retention action over every table registered for the
+// run's category. Rows under legal hold are kept and counted as held. A
+// dry run only counts the rows that would be affected and returns a
+// sample of them.
+func (e *Enforcer) Enforce(ctx context.Context, run Run) (*EnforcementResult, error) {
+	switch run.Action {
+	case ActionDelete, ActionAnonymize, ActionReview:
+	case ActionArchive:
+		if e.archiver == nil && !run.DryRun {
+			return nil, ErrArchiveUnavailable
+		}
+	default:
+		return nil, fmt.Errorf("unknown action: %s", run.Action)
+	}
+
+	res := &EnforcementResult{Action: run.Action, DataType: run.Category, DryRun: run.DryRun}
+	if run.Holds.Category != "" {
+		res.Status = StatusHeld
+		res.ErrorMessage = "category is under legal hold: " + run.Holds.Category
+		res.CompletedAt = time.Now()
+		return res, nil
+	}
+
+	var failed []string
+	for _, t := range e.Targets(run.Category) {
+		tr, err := e.enforceTable(ctx, t, run)
+		if err != nil {
+			log.Printf("WARNING: retention %s on %s: %v", run.Action, t.Table, err)
+			tr.Error = err.Error()
+			failed = append(failed, t.Table)
+		}
+		res.Tables = append(res.Tables, tr)
+		res.RecordsMatched += tr.Matched
+		res.RecordsAffected += tr.Affected
+		res.RecordsHeld += tr.Held
+		res.ArchiveKeys = append(res.ArchiveKeys, tr.ArchiveKeys...)
+	}
+
+	switch {
+	case len(failed) > 0 && len(failed) == len(res.Tables):
+		res.Status = StatusFailed
+	case len(failed) > 0:
+		res.Status = StatusPartial
+	case run.DryRun:
+		res.Status = StatusDryRun
+	case run.Action == ActionReview:
+		res.Status = StatusPendingReview
+	default:
+		res.Status = StatusCompleted
+	}
+	if len(failed) > 0 {
+		res.ErrorMessage = fmt.Sprintf("failed on %v", failed)
+	}
+	res.CompletedAt = time.Now()
+	return res, nil
 }
 
-func (e *Enforcer) executeArchive(ctx context.Context, dataType string, olderThan time.Time) (*EnforcementResult, error) {
-	log.Printf("enforcing archival for %s older than %v", dataType, olderThan)
-	return &EnforcementResult{
-		Action:          "archive",
-		DataType:        dataType,
-		RecordsAffected: 0,
-		CompletedAt:     time.Now(),
-		Status:          "completed",
-	}, nil
+func (e *Enforcer) enforceTable(ctx context.Context, t Target, run Run) (TableResult, error) {
+	tr := TableResult{Table: t.Table}
+	timeCol := t.TimeColumn
+	if timeCol == "" {
+		timeCol = "created_at"
+	}
+	due := func() *gorm.DB {
+		q := e.db.WithContext(ctx).Table(t.Table).Where(timeCol+" < ?", run.OlderThan)
+		if t.Where != "" {
+			q = q.Where(t.Where)
+		}
+		if run.Action == ActionAnonymize && t.Anonymized != "" {
+			q = q.Where("NOT (" + t.Anonymized + ")")
+		}
+		return q
+	}
+
+	var total int64
+	if err := due().Count(&total).Error; err != nil {
+		return tr, fmt.Errorf("counting due rows: %w", err)
+	}
+	if len(run.Holds.UserIDs) > 0 && t.UserColumn == "" {
+		// Rows cannot be told apart by subject, so all of them are kept.
+		tr.Held, tr.Skipped = total, "held subjects cannot be identified in this table"
+		return tr, nil
+	}
+	if run.Action == ActionAnonymize && t.Anonymize == nil {
+		tr.Matched, tr.Skipped = total, "table cannot be anonymized"
+		return tr, nil
+	}
+
+	eligible := func() *gorm.DB {
+		q := due()
+		if len(run.Holds.UserIDs) > 0 {
+			q = q.Where("("+t.UserColumn+" IS NULL OR "+t.UserColumn+"::text NOT IN ?)", run.Holds.UserIDs)
+		}
+		if len(run.Holds.ProjectIDs) > 0 && t.Project != "" {
+			q = q.Where("("+t.Project+" IS NULL OR "+t.Project+"::text NOT IN ?)", run.Holds.ProjectIDs)
+		}
+		return q
+	}
+	if err := eligible().Count(&tr.Matched).Error; err != nil {
+		return tr, fmt.Errorf("counting eligible rows: %w", err)
+	}
+	tr.Held = total - tr.Matched
+
+	if run.DryRun {
+		if tr.Matched > 0 {
+			if err := eligible().Order(timeCol).Limit(sampleSize).Find(&tr.Sample).Error; err != nil {
+				return tr, fmt.Errorf("sampling rows: %w", err)
+			}
+		}
+		return tr, nil
+	}
+	if tr.Matched == 0 {
+		return tr, nil
+	}
+
+	switch run.Action {
+	case ActionDelete:
+		r := eligible().Delete(nil)
+		if r.Error != nil {
+			return tr, fmt.Errorf("deleting rows: %w", r.Error)
+		}
+		tr.Affected = r.RowsAffected
+	case ActionAnonymize:
+		r := eligible().Updates(t.Anonymize)
+		if r.Error != nil {
+			return tr, fmt.Errorf("anonymizing rows: %w", r.Error)
+		}
+		tr.Affected = r.RowsAffected
+	case ActionArchive:
+		return e.archiveTable(ctx, t, run, eligible, tr)
+	}
+	return tr, nil
 }
 
-func (e *Enforcer) executeReview(ctx context.Context, dataType string, olderThan time.Time) (*EnforcementResult, error) {
-	log.Printf("flagging %s for review (older than %v)", dataType, olderThan)
-	return &EnforcementResult{
-		Action:          "review",
-		DataType:        dataType,
-		RecordsAffected: 0,
-		CompletedAt:     time.Now(),
-		Status:          "pending_review",
-	}, nil
+// archiveTable writes the eligible rows to cold storage in batches and
+// deletes each batch once its archive object has been stored.
+func (e *Enforcer) archiveTable(ctx context.Context, t Target, run Run, eligible func() *gorm.DB, tr TableResult) (TableResult, error) {
+	key := t.Key
+	if key == "" {
+		key = "id"
+	}
+	for part := 1; ; part++ {
+		var rows []map[string]any
+		if err := eligible().Order(key).Limit(archiveBatch).Find(&rows).Error; err != nil {
+			return tr, fmt.Errorf("reading rows to archive: %w", err)
+		}
+		if len(rows) == 0 {
+			return tr, nil
+		}
+		objectKey := fmt.Sprintf("%s/%s-%04d", run.ArchivePrefix, t.Table, part)
+		stored, err := e.archiver.Archive(ctx, objectKey, rows)
+		if err != nil {
+			return tr, err
+		}
+		tr.ArchiveKeys = append(tr.ArchiveKeys, stored)
+
+		ids := make([]any, len(rows))
+		for i, row := range rows {
+			ids[i] = row[key]
+		}
+		r := e.db.WithContext(ctx).Table(t.Table).Where(key+" IN ?", ids).Delete(nil)
+		if r.Error != nil {
+			return tr, fmt.Errorf("deleting archived rows: %w", r.Error)
+		}
+		tr.Affected += r.RowsAffected
+		if r.RowsAffected == 0 || len(rows) < archiveBatch {
+			return tr, nil
+		}
+	}
 }
 
 // EnforcementResult captures the outcome of a retention enforcement action.
+// RecordsMatched counts the due rows not under hold; RecordsAffected those
+// actually deleted, anonymized or archived.
 type EnforcementResult struct {
-	Action          string    `json:"action"`
-	DataType        string    `json:"data_type"`
-	RecordsAffected int64     `json:"records_affected"`
-	CompletedAt     time.Time `json:"completed_at"`
-	Status          string    `json:"status"`
-	ErrorMessage    string    `json:"error_message,omitempty"`
+	Action          string        `json:"action"`
+	DataType        string        `json:"data_type"`
+	DryRun          bool          `json:"dry_run"`
+	RecordsMatched  int64         `json:"records_matched"`
+	RecordsAffected int64         `json:"records_affected"`
+	RecordsHeld     int64         `json:"records_held"`
+	Tables          []TableResult `json:"tables"`
+	ArchiveKeys     []string      `json:"archive_keys,omitempty"`
+	CompletedAt     time.Time     `json:"completed_at"`
+	Status          string        `json:"status"`
+	ErrorMessage    string        `json:"error_message,omitempty"`
+}
+
+// TableResult is the outcome of an enforcement on one table. Sample holds
+// a few of the matched rows on dry runs.
+type TableResult struct {
+	Table       string           `json:"table"`
+	Matched     int64            `json:"matched"`
+	Affected    int64            `json:"affected"`
+	Held        int64            `json:"held"`
+	Skipped     string           `json:"skipped,omitempty"`
+	ArchiveKeys []string         `json:"archive_keys,omitempty"`
+	Sample      []map[string]any `json:"sample,omitempty"`
+	Error       string           `json:"error,omitempty"`
 }
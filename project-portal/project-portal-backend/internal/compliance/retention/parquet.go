@@ -0,0 +1,380 @@
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// EncodeParquet writes rows as a Parquet file with a single row group.
+// Every column is optional. Integer columns are stored as INT64, floating
+// point columns as DOUBLE, time columns as INT64 microsecond timestamps and
+// everything else as UTF-8 strings. Pages are PLAIN encoded and
+// gzip-compressed.
+func EncodeParquet(rows []map[string]any) ([]byte, error) {
+	cols := columnNames(rows)
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+
+	var chunks []parquetChunk
+	var totalSize int64
+	for _, name := range cols {
+		kind := columnKind(rows, name)
+		values, defs, err := encodeColumn(rows, name, kind)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+
+		var page bytes.Buffer
+		levels := rleLevels(defs)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+		page.Write(values)
+
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		var header thriftWriter
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(compressed.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(len(rows)))
+		header.i32(2, 0) // PLAIN
+		header.i32(3, 3) // RLE definition levels
+		header.i32(4, 3) // RLE repetition levels
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{
+			name:             name,
+			kind:             kind,
+			offset:           int64(out.Len()),
+			compressedSize:   int64(header.buf.Len() + compressed.Len()),
+			uncompressedSize: int64(header.buf.Len() + page.Len()),
+		}
+		out.Write(header.buf.Bytes())
+		out.Write(compressed.Bytes())
+		chunks = append(chunks, chunk)
+		totalSize += chunk.uncompressedSize
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStruct, len(cols)+1)
+	meta.elemBegin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(cols)))
+	meta.elemEnd()
+	for _, c := range chunks {
+		meta.elemBegin()
+		meta.i32(1, c.kind.physical)
+		meta.i32(3, 1) // OPTIONAL
+		meta.str(4, c.name)
+		if c.kind.converted >= 0 {
+			meta.i32(6, c.kind.converted)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin()
+	meta.listBegin(1, thriftStruct, len(chunks))
+	for _, c := range chunks {
+		meta.elemBegin()
+		meta.i64(2, c.offset)
+		meta.beginStruct(3)
+		meta.i32(1, c.kind.physical)
+		meta.listBegin(2, thriftI32, 2)
+		meta.varint(zigzag(0)) // PLAIN
+		meta.varint(zigzag(3)) // RLE
+		meta.listBegin(3, thriftBinary, 1)
+		meta.binary(c.name)
+		meta.i32(4, 2) // GZIP
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, c.uncompressedSize)
+		meta.i64(7, c.compressedSize)
+		meta.i64(9, c.offset)
+		meta.endStruct()
+		meta.elemEnd()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(len(rows)))
+	meta.elemEnd()
+	meta.str(6, "carbon-scribe retention archiver")
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	_ = binary.Write(&out, binary.LittleEndian, uint32(meta.buf.Len()))
+	out.WriteString("PAR1")
+	return out.Bytes(), nil
+}
+
+type parquetChunk struct {
+	name             string
+	kind             parquetKind
+	offset           int64
+	compressedSize   int64
+	uncompressedSize int64
+}
+
+// parquetKind is a column's physical type and converted type (-1 for
+// none).
+type parquetKind struct {
+	physical  int32
+	converted int32
+}
+
+var (
+	kindInt64     = parquetKind{physical: 2, converted: -1}
+	kindDouble    = parquetKind{physical: 5, converted: -1}
+	kindString    = parquetKind{physical: 6, converted: 0}  // BYTE_ARRAY, UTF8
+	kindTimestamp = parquetKind{physical: 2, converted: 10} // INT64, TIMESTAMP_MICROS
+)
+
+// columnKind picks the narrowest type that holds every non-null value.
+func columnKind(rows []map[string]any, name string) parquetKind {
+	kind := parquetKind{physical: -1}
+	for _, row := range rows {
+		var k parquetKind
+		switch v := row[name].(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint8, uint16, uint32:
+			k = kindInt64
+		case float32, float64:
+			k = kindDouble
+		case time.Time:
+			k = kindTimestamp
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			k = kindTimestamp
+		default:
+			return kindString
+		}
+		switch {
+		case kind.physical == -1:
+			kind = k
+		case kind == kindInt64 && k == kindDouble, kind == kindDouble && k == kindInt64:
+			kind = kindDouble
+		case kind != k:
+			return kindString
+		}
+	}
+	if kind.physical == -1 {
+		return kindString
+	}
+	return kind
+}
+
+// encodeColumn PLAIN-encodes a column's non-null values and returns its
+// definition levels.
+func encodeColumn(rows []map[string]any, name string, kind parquetKind) ([]byte, []bool, error) {
+	var buf bytes.Buffer
+	defs := make([]bool, len(rows))
+	for i, row := range rows {
+		v := row[name]
+		if t, ok := v.(*time.Time); ok {
+			if t == nil {
+				v = nil
+			} else {
+				v = *t
+			}
+		}
+		if v == nil {
+			continue
+		}
+		defs[i] = true
+		switch kind {
+		case kindInt64:
+			_ = binary.Write(&buf, binary.LittleEndian, toInt64(v))
+		case kindDouble:
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float64bits(toFloat64(v)))
+		case kindTimestamp:
+			_ = binary.Write(&buf, binary.LittleEndian, v.(time.Time).UnixMicro())
+		default:
+			s, err := stringValue(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(len(s)))
+			buf.WriteString(s)
+		}
+	}
+	return buf.Bytes(), defs, nil
+}
+
+// rleLevels encodes definition levels of bit width 1 as RLE runs.
+func rleLevels(defs []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(defs); {
+		j := i
+		for j < len(defs) && defs[j] == defs[i] {
+			j++
+		}
+		var tmp [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(tmp[:], uint64(j-i)<<1)
+		buf.Write(tmp[:n])
+		if defs[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	}
+	return 0
+}
+
+func toFloat64(v any) float64 {
+	switch n := v.(type) {
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return float64(toInt64(v))
+}
+
+func stringValue(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case bool, uint, uint64:
+		return fmt.Sprint(t), nil
+	case fmt.Stringer:
+		return t.String(), nil
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// Thrift compact protocol types used by the Parquet footer.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes the subset of the Thrift compact protocol needed for
+// Parquet page headers and file metadata.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last int16
+	// stack holds the last field ID of each enclosing struct.
+	stack []int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag(int64(id)))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *thriftWriter) binary(s string) {
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.binary(s)
+}
+
+// beginStruct starts a struct-valued field; endStruct closes it.
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.elemBegin()
+}
+
+func (w *thriftWriter) endStruct() { w.elemEnd() }
+
+// listBegin starts a list field of n elements. Struct elements are each
+// wrapped in elemBegin and elemEnd.
+func (w *thriftWriter) listBegin(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elemType)
+		return
+	}
+	w.buf.WriteByte(0xF0 | elemType)
+	w.varint(uint64(n))
+}
+
+func (w *thriftWriter) elemBegin() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+func (w *thriftWriter) elemEnd() {
+	w.stop()
+	w.last = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *thriftWriter) stop() { w.buf.WriteByte(0) }
+
+func zigzag(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
@@ -0,0 +1,58 @@
+package compliance
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestRetentionHolds(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	holds := []LegalHold{
+		{Name: "users", Status: LegalHoldActive, AffectedUserIDs: pq.StringArray{"u1"}},
+		{Name: "logs-of-u2", Status: LegalHoldActive, AffectedUserIDs: pq.StringArray{"u2"}, DataCategories: pq.StringArray{DataCategorySystemLogs}},
+		{Name: "projects", Status: LegalHoldActive, ProjectIDs: pq.StringArray{"p1"}, DataCategories: pq.StringArray{DataCategoryProjectData}},
+		{Name: "finance", Status: LegalHoldActive, DataCategories: pq.StringArray{DataCategoryFinancialRecs}},
+		{Name: "expired", Status: LegalHoldActive, DataCategories: pq.StringArray{DataCategorySystemLogs}, ExpiresAt: &past},
+		{Name: "released", Status: LegalHoldReleased, AffectedUserIDs: pq.StringArray{"u3"}},
+	}
+
+	logs := retentionHolds(holds, DataCategorySystemLogs, now)
+	if logs.Category != "" {
+		t.Errorf("system logs held as a category by %q", logs.Category)
+	}
+	if !slices.Equal(logs.UserIDs, []string{"u1", "u2"}) {
+		t.Errorf("system log users = %v", logs.UserIDs)
+	}
+	if !slices.Equal(logs.ProjectIDs, []string{"p1"}) {
+		t.Errorf("system log projects = %v", logs.ProjectIDs)
+	}
+
+	finance := retentionHolds(holds, DataCategoryFinancialRecs, now)
+	if finance.Category != "finance" {
+		t.Errorf("financial records category hold = %q", finance.Category)
+	}
+	if !slices.Equal(finance.UserIDs, []string{"u1"}) {
+		t.Errorf("financial record users = %v", finance.UserIDs)
+	}
+}
+
+func TestPolicyAction(t *testing.T) {
+	days := 30
+	for _, tc := range []struct {
+		policy RetentionPolicy
+		want   string
+	}{
+		{RetentionPolicy{RetentionPeriodDays: -1, DeletionMethod: DeletionMethodHardDelete}, "review"},
+		{RetentionPolicy{RetentionPeriodDays: 90, DeletionMethod: DeletionMethodPseudonymize}, "anonymize"},
+		{RetentionPolicy{RetentionPeriodDays: 90, ArchivalPeriodDays: &days, DeletionMethod: DeletionMethodSoftDelete}, "archive"},
+		{RetentionPolicy{RetentionPeriodDays: 90, DeletionMethod: DeletionMethodHardDelete}, "delete"},
+	} {
+		if got := policyAction(&tc.policy); got != tc.want {
+			t.Errorf("policyAction(%+v) = %s, want %s", tc.policy, got, tc.want)
+		}
+	}
+}
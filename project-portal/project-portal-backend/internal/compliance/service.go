@@ -3,22 +3,40 @@ package compliance
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	auditpkg "carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/privacy"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
 )
 
 // Service orchestrates all compliance operations.
 type Service struct {
-	repo        Repository
-	auditLogger *auditpkg.Logger
+	repo          Repository
+	auditLogger   *auditpkg.Logger
+	processor     *requests.Processor
+	jurisdictions *privacy.JurisdictionManager
+	certKey       []byte
+	exportStore   storage.ObjectStore
+	exportVault   *encryption.Vault
+	enforcer      *retention.Enforcer
+	anchor        auditpkg.Anchor
 }
 
 // NewService creates a new compliance service with all sub-components.
+// Modules add their data sources with RegisterDataSource.
 func NewService(repo Repository) *Service {
 	return &Service{
-		repo:        repo,
-		auditLogger: auditpkg.NewLogger(),
+		repo:          repo,
+		auditLogger:   auditpkg.NewLogger(),
+		processor:     requests.NewProcessor(),
+		jurisdictions: privacy.NewJurisdictionManager(),
+		certKey:       []byte(defaultCertificateKey),
 	}
 }
 
@@ -53,6 +71,9 @@ func (s *Service) CreateRetentionPolicy(ctx context.Context, req CreateRetention
 	if err := s.repo.CreateRetentionPolicy(ctx, policy); err != nil {
 		return nil, fmt.Errorf("creating retention policy: %w", err)
 	}
+	if err := s.schedulePolicy(ctx, policy); err != nil {
+		log.Printf("WARNING: scheduling retention policy %s: %v", policy.ID, err)
+	}
 	return policy, nil
 }
 
@@ -89,12 +110,23 @@ func (s *Service) UpdateRetentionPolicy(ctx context.Context, id string, req Crea
 	if err := s.repo.UpdateRetentionPolicy(ctx, policy); err != nil {
 		return nil, fmt.Errorf("updating retention policy: %w", err)
 	}
+	if err := s.schedulePolicy(ctx, policy); err != nil {
+		log.Printf("WARNING: scheduling retention policy %s: %v", policy.ID, err)
+	}
 	return policy, nil
 }
 
 // --- Privacy Request Operations ---
 
 func (s *Service) CreateExportRequest(ctx context.Context, userID string, req ExportRequest) (*PrivacyRequest, error) {
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = requests.FormatJSON
+	}
+	if !requests.ValidFormat(format) {
+		return nil, fmt.Errorf("%w: %q", requests.ErrUnsupportedFormat, req.Format)
+	}
+
 	estimated := time.Now().Add(72 * time.Hour)
 	privReq := &PrivacyRequest{
 		UserID:              userID,
@@ -106,6 +138,7 @@ func (s *Service) CreateExportRequest(ctx context.Context, userID string, req Ex
 		DataCategories:      req.DataCategories,
 		DateRangeStart:      req.DateRangeStart,
 		DateRangeEnd:        req.DateRangeEnd,
+		ExportFormat:        format,
 		LegalBasis:          "consent",
 	}
 
@@ -113,6 +146,9 @@ func (s *Service) CreateExportRequest(ctx context.Context, userID string, req Ex
 		privReq.RequestSubtype = "partial_export"
 	}
 
+	s.setDeadline(ctx, privReq)
+	privReq.RequestedBy = &userID
+
 	if err := s.repo.CreatePrivacyRequest(ctx, privReq); err != nil {
 		return nil, fmt.Errorf("creating export request: %w", err)
 	}
@@ -146,6 +182,9 @@ func (s *Service) CreateDeleteRequest(ctx context.Context, userID string, req De
 		privReq.RequestSubtype = "partial_deletion"
 	}
 
+	s.setDeadline(ctx, privReq)
+	privReq.RequestedBy = &userID
+
 	if err := s.repo.CreatePrivacyRequest(ctx, privReq); err != nil {
 		return nil, fmt.Errorf("creating deletion request: %w", err)
 	}
@@ -271,12 +310,6 @@ func (s *Service) QueryAuditLogs(ctx context.Context, query AuditLogQuery) ([]Au
 }
 
 func (s *Service) LogAuditEvent(ctx context.Context, entry AuditEntry) error {
-	prevHash := ""
-	lastLog, err := s.repo.GetLastAuditLog(ctx)
-	if err == nil && lastLog != nil {
-		prevHash = lastLog.HashChain
-	}
-
 	auditEntry := auditpkg.AuditLogEntry{
 		EventTime:        time.Now(),
 		EventType:        entry.EventType,
@@ -299,38 +332,21 @@ func (s *Service) LogAuditEvent(ctx context.Context, entry AuditEntry) error {
 		auditEntry.ActorIP = entry.ActorIP.String()
 	}
 
-	built := s.auditLogger.BuildEntry(auditEntry, prevHash)
-
-	log := &AuditLog{
-		EventTime:        built.EventTime,
-		EventType:        built.EventType,
-		EventAction:      built.EventAction,
-		ActorType:        built.ActorType,
-		ActorIP:          built.ActorIP,
-		TargetType:       built.TargetType,
-		DataCategory:     built.DataCategory,
-		SensitivityLevel: built.SensitivityLevel,
-		ServiceName:      built.ServiceName,
-		Endpoint:         built.Endpoint,
-		HTTPMethod:       built.HTTPMethod,
-		OldValues:        built.OldValues,
-		NewValues:        built.NewValues,
-		PermissionUsed:   built.PermissionUsed,
-		HashChain:        built.HashChain,
-		Signature:        built.Signature,
-	}
-
-	if built.ActorID != "" {
-		log.ActorID = &built.ActorID
-	}
-	if built.TargetID != "" {
-		log.TargetID = &built.TargetID
-	}
-	if built.TargetOwnerID != "" {
-		log.TargetOwnerID = &built.TargetOwnerID
-	}
-
-	return s.repo.CreateAuditLog(ctx, log)
+	// The chain head is read and extended under the repository's lock, so
+	// concurrent events are sequenced rather than forking the chain.
+	return s.repo.AppendAuditLog(ctx, func(prev *AuditLog) (*AuditLog, error) {
+		var sequence int64 = 1
+		prevHash := ""
+		if prev != nil {
+			sequence = prev.Sequence + 1
+			prevHash = prev.HashChain
+		}
+		built, err := s.auditLogger.BuildEntry(auditEntry, sequence, prevHash)
+		if err != nil {
+			return nil, fmt.Errorf("signing audit entry: %w", err)
+		}
+		return auditLogFromEntry(built), nil
+	})
 }
 
 // --- Retention Schedules ---
@@ -349,6 +365,7 @@ func (s *Service) CreateLegalHold(ctx context.Context, req CreateLegalHoldReques
 		Status:          LegalHoldActive,
 		DataCategories:  req.DataCategories,
 		AffectedUserIDs: req.AffectedUserIDs,
+		ProjectIDs:      req.ProjectIDs,
 		InitiatedBy:     initiatedBy,
 		InitiatedAt:     time.Now(),
 		ExpiresAt:       req.ExpiresAt,
@@ -377,6 +394,28 @@ func (s *Service) ReleaseLegalHold(ctx context.Context, id, releasedBy string) (
 	return hold, nil
 }
 
+// IsProjectUnderLegalHold implements project.PurgeCompliance.
+func (s *Service) IsProjectUnderLegalHold(ctx context.Context, projectID string) (bool, error) {
+	return s.repo.IsProjectUnderLegalHold(ctx, projectID)
+}
+
+// RecordProjectPurge writes the audit entry for a permanently deleted
+// project. It implements project.PurgeCompliance.
+func (s *Service) RecordProjectPurge(ctx context.Context, projectID, userID string, project map[string]any) error {
+	return s.LogAuditEvent(ctx, AuditEntry{
+		EventType:        "data_deletion",
+		EventAction:      "purge",
+		ActorID:          userID,
+		ActorType:        ActorTypeUser,
+		TargetType:       "project",
+		TargetID:         projectID,
+		DataCategory:     DataCategoryProjectData,
+		SensitivityLevel: SensitivitySensitive,
+		ServiceName:      "projects",
+		OldValues:        project,
+	})
+}
+
 func (s *Service) ListActiveLegalHolds(ctx context.Context) ([]LegalHold, error) {
 	return s.repo.ListActiveLegalHolds(ctx)
 }
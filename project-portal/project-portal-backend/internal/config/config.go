@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds application configuration
@@ -17,6 +18,11 @@ type Config struct {
 	Storage       StorageConfig
 	Geospatial    GeospatialConfig
 	Settings      SettingsConfig
+	Auth          AuthConfig
+	Mail          MailConfig
+	Signing       SigningConfig
+	Compliance    ComplianceConfig
+	Integration   IntegrationConfig
 }
 
 // ElasticsearchConfig holds configuration for Elasticsearch
@@ -36,8 +42,14 @@ type AWSConfig struct {
 	Endpoint        string // optional: LocalStack / MinIO override
 }
 
-// StorageConfig holds document storage settings.
+// StorageConfig holds document storage settings. Driver is "s3", "local" or
+// "memory". The local and memory drivers serve downloads through the API
+// with URLs signed by SigningSecret under PublicBaseURL.
 type StorageConfig struct {
+	Driver          string
+	LocalDir        string
+	SigningSecret   string
+	PublicBaseURL   string
 	S3BucketName    string
 	MaxUploadSizeMB int64
 	IPFSEnabled     bool
@@ -50,6 +62,80 @@ type SettingsConfig struct {
 	ProfileCDNBase   string
 }
 
+// AuthConfig holds token signing settings. HS256 uses JWTSecret; RS256 and
+// EdDSA load a PEM private key from JWTPrivateKeyFile. Previous keys are
+// accepted for verification only, for RotationGrace after startup. Keys
+// generated by rotation are stored in the database, encrypted with
+// KeyVaultHex, which defaults to the settings encryption key.
+type AuthConfig struct {
+	JWTAlgorithm      string
+	JWTKeyID          string
+	JWTSecret         string
+	JWTPrivateKeyFile string
+	// JWTPreviousKeys maps kid to a retired HS256 secret or PEM key file path.
+	JWTPreviousKeys  map[string]string
+	Issuer           string
+	Audience         string
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	RotationInterval time.Duration // 0 disables automatic rotation
+	RotationGrace    time.Duration
+	KeyVaultHex      string
+}
+
+// MailConfig selects the outgoing mail driver. "file" writes messages to
+// FileDir for local development; "smtp" delivers through SMTPHost.
+// AppBaseURL is the frontend origin used to build links in emails.
+type MailConfig struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+	AppBaseURL   string
+}
+
+// SigningConfig holds PAdES document signing settings. Signing keys are
+// encrypted with VaultKeyHex, which defaults to the settings encryption key.
+// When TSAURL is set every signature gets an RFC 3161 timestamp.
+// RevocationCheck is off, soft or hard; see documents.SetRevocationChecking.
+type SigningConfig struct {
+	VaultKeyHex     string
+	TSAURL          string
+	TSAUsername     string
+	TSAPassword     string
+	RevocationCheck string
+}
+
+// ComplianceConfig holds the HMAC key that signs deletion certificates,
+// the AES key that encrypts privacy export packages, and where retention
+// archives go. ArchiveDriver is "s3" or "local"; ArchiveFormat is
+// "parquet" or "jsonl". AuditKeys lists the audit signing keys as
+// comma-separated id:hex pairs and AuditKeyID picks the one that signs new
+// entries; AuditAnchor is "stellar-local" or "none".
+type ComplianceConfig struct {
+	CertificateKeyHex string
+	ExportKeyHex      string
+	ArchiveDriver     string
+	ArchiveDir        string
+	ArchiveBucket     string
+	ArchiveFormat     string
+	AuditKeys         string
+	AuditKeyID        string
+	AuditAnchor       string
+}
+
+// IntegrationConfig holds the shared secret external systems sign incoming
+// webhooks with and the key that signs OAuth2 state. Without WebhookSecret
+// incoming webhooks are rejected; without OAuthStateSecret OAuth2 flows are
+// unavailable outside debug mode.
+type IntegrationConfig struct {
+	WebhookSecret    string
+	OAuthStateSecret string
+}
+
 type GeospatialConfig struct {
 	DefaultProvider   string
 	MapboxAccessToken string
@@ -76,6 +162,28 @@ func Load() (*Config, error) {
 		esAddresses = "http://localhost:9200"
 	}
 
+	accessTTL, err := getDurationOrDefault("JWT_ACCESS_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := getDurationOrDefault("JWT_REFRESH_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	rotationInterval, err := getDurationOrDefault("JWT_ROTATION_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+	rotationGrace, err := getDurationOrDefault("JWT_ROTATION_GRACE", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if rotationGrace < accessTTL {
+		return nil, fmt.Errorf("JWT_ROTATION_GRACE (%s) must not be shorter than JWT_ACCESS_TTL (%s)", rotationGrace, accessTTL)
+	}
+
+	smtpPort, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+
 	maxUpload, _ := strconv.ParseInt(os.Getenv("MAX_UPLOAD_SIZE_MB"), 10, 64)
 	if maxUpload <= 0 {
 		maxUpload = 100
@@ -99,6 +207,10 @@ func Load() (*Config, error) {
 			Endpoint:        os.Getenv("AWS_ENDPOINT_URL"), // for LocalStack
 		},
 		Storage: StorageConfig{
+			Driver:          getEnvOrDefault("STORAGE_DRIVER", "s3"),
+			LocalDir:        getEnvOrDefault("STORAGE_LOCAL_DIR", "data/documents"),
+			SigningSecret:   os.Getenv("STORAGE_SIGNING_SECRET"),
+			PublicBaseURL:   getEnvOrDefault("PUBLIC_BASE_URL", "http://localhost:"+port),
 			S3BucketName:    getEnvOrDefault("S3_BUCKET_NAME", "carbon-scribe-documents"),
 			MaxUploadSizeMB: maxUpload,
 			IPFSEnabled:     os.Getenv("IPFS_ENABLED") == "true",
@@ -115,6 +227,52 @@ func Load() (*Config, error) {
 			APIKeyPrefix:     getEnvOrDefault("SETTINGS_API_KEY_PREFIX", "ppk_live"),
 			ProfileCDNBase:   getEnvOrDefault("SETTINGS_PROFILE_CDN_BASE", "https://cdn.carbonscribe.local"),
 		},
+		Auth: AuthConfig{
+			JWTAlgorithm:      getEnvOrDefault("JWT_ALGORITHM", "HS256"),
+			JWTKeyID:          getEnvOrDefault("JWT_KEY_ID", "default"),
+			JWTSecret:         os.Getenv("JWT_SECRET"),
+			JWTPrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+			JWTPreviousKeys:   parseKeyValueList(os.Getenv("JWT_PREVIOUS_KEYS")),
+			Issuer:            getEnvOrDefault("JWT_ISSUER", "carbon-scribe-project-portal"),
+			Audience:          getEnvOrDefault("JWT_AUDIENCE", "carbon-scribe"),
+			AccessTokenTTL:    accessTTL,
+			RefreshTokenTTL:   refreshTTL,
+			RotationInterval:  rotationInterval,
+			RotationGrace:     rotationGrace,
+			KeyVaultHex:       getEnvOrDefault("JWT_KEY_VAULT_KEY_HEX", os.Getenv("SETTINGS_ENCRYPTION_KEY_HEX")),
+		},
+		Mail: MailConfig{
+			Driver:       getEnvOrDefault("MAIL_DRIVER", "file"),
+			From:         getEnvOrDefault("MAIL_FROM", "CarbonScribe <no-reply@carbonscribe.local>"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     smtpPort,
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			FileDir:      getEnvOrDefault("MAIL_FILE_DIR", "tmp/mail"),
+			AppBaseURL:   getEnvOrDefault("APP_BASE_URL", "http://localhost:3000"),
+		},
+		Signing: SigningConfig{
+			VaultKeyHex:     getEnvOrDefault("SIGNING_VAULT_KEY_HEX", os.Getenv("SETTINGS_ENCRYPTION_KEY_HEX")),
+			TSAURL:          os.Getenv("SIGNING_TSA_URL"),
+			TSAUsername:     os.Getenv("SIGNING_TSA_USERNAME"),
+			TSAPassword:     os.Getenv("SIGNING_TSA_PASSWORD"),
+			RevocationCheck: getEnvOrDefault("SIGNING_REVOCATION_CHECK", "soft"),
+		},
+		Compliance: ComplianceConfig{
+			CertificateKeyHex: os.Getenv("COMPLIANCE_CERTIFICATE_KEY_HEX"),
+			ExportKeyHex:      getEnvOrDefault("COMPLIANCE_EXPORT_KEY_HEX", os.Getenv("SETTINGS_ENCRYPTION_KEY_HEX")),
+			ArchiveDriver:     getEnvOrDefault("RETENTION_ARCHIVE_DRIVER", "local"),
+			ArchiveDir:        getEnvOrDefault("RETENTION_ARCHIVE_DIR", "data/archive"),
+			ArchiveBucket:     getEnvOrDefault("RETENTION_ARCHIVE_BUCKET", "carbon-scribe-archive"),
+			ArchiveFormat:     getEnvOrDefault("RETENTION_ARCHIVE_FORMAT", "parquet"),
+			AuditKeys:         os.Getenv("COMPLIANCE_AUDIT_KEYS"),
+			AuditKeyID:        os.Getenv("COMPLIANCE_AUDIT_KEY_ID"),
+			AuditAnchor:       getEnvOrDefault("COMPLIANCE_AUDIT_ANCHOR", "stellar-local"),
+		},
+		Integration: IntegrationConfig{
+			WebhookSecret:    os.Getenv("INTEGRATION_WEBHOOK_SECRET"),
+			OAuthStateSecret: os.Getenv("INTEGRATION_OAUTH_STATE_SECRET"),
+		},
 	}, nil
 }
 
@@ -124,3 +282,28 @@ func getEnvOrDefault(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+func getDurationOrDefault(key string, defaultVal time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// parseKeyValueList parses "a=1,b=2" into a map, skipping malformed entries.
+func parseKeyValueList(raw string) map[string]string {
+	out := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
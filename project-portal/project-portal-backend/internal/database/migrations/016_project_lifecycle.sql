@@ -0,0 +1,37 @@
+-- Migration: 016_project_lifecycle
+-- Description: Project lifecycle stages, methodology assignment and append-only status history
+-- Date: 2026-10-17
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS methodology_code VARCHAR(100);
+ALTER TABLE projects ALTER COLUMN status SET DEFAULT 'draft';
+
+-- Map the legacy free-form statuses onto lifecycle stages
+UPDATE projects SET status = 'draft' WHERE status IS NULL OR status IN ('', 'pending');
+UPDATE projects SET status = 'monitoring' WHERE status = 'active';
+UPDATE projects SET status = 'closed' WHERE status = 'completed';
+
+CREATE INDEX IF NOT EXISTS idx_projects_status ON projects (status);
+
+-- Every lifecycle transition; rows are never updated
+CREATE TABLE IF NOT EXISTS project_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    performed_by VARCHAR(255),
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_status_history_project ON project_status_history (project_id, created_at);
+
+CREATE OR REPLACE FUNCTION project_status_history_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'project_status_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_project_status_history_immutable ON project_status_history;
+CREATE TRIGGER trg_project_status_history_immutable
+    BEFORE UPDATE ON project_status_history
+    FOR EACH ROW EXECUTE FUNCTION project_status_history_immutable();
@@ -0,0 +1,21 @@
+-- Migration: 017_credit_calculations
+-- Description: Stored, reproducible credit estimates produced by the methodology engine
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS credit_calculations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    methodology_code VARCHAR(100) NOT NULL,
+    methodology_version VARCHAR(50) NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    net_credits DOUBLE PRECISION NOT NULL DEFAULT 0,
+    issuable_credits BIGINT NOT NULL DEFAULT 0,
+    input_hash CHAR(64) NOT NULL,
+    result JSONB NOT NULL,
+    calculated_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_calculations_project ON credit_calculations (project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_credit_calculations_mode ON credit_calculations (mode);
+CREATE INDEX IF NOT EXISTS idx_credit_calculations_input_hash ON credit_calculations (input_hash);
@@ -0,0 +1,14 @@
+-- Migration: 018_project_list_indexes
+-- Description: Indexes for project list filters, keyset pagination and full-text search
+-- Date: 2026-10-17
+
+CREATE INDEX IF NOT EXISTS idx_projects_created_at_id ON projects (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_projects_type ON projects (type);
+CREATE INDEX IF NOT EXISTS idx_projects_start_date ON projects (start_date);
+CREATE INDEX IF NOT EXISTS idx_projects_area ON projects (area);
+CREATE INDEX IF NOT EXISTS idx_projects_carbon_credits ON projects (carbon_credits);
+
+-- Must match the expression used by project.Filter for the q parameter.
+CREATE INDEX IF NOT EXISTS idx_projects_search ON projects USING GIN (
+    to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(type, '') || ' ' || coalesce(location, ''))
+);
@@ -0,0 +1,15 @@
+-- Migration: 019_project_archive
+-- Description: Archive/restore for projects, project-scoped legal holds, and no cascading boundary deletes
+-- Date: 2026-10-17
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_by TEXT;
+CREATE INDEX IF NOT EXISTS idx_projects_archived_at ON projects (archived_at);
+
+-- Projects named here cannot be purged while the hold is active.
+ALTER TABLE legal_holds ADD COLUMN IF NOT EXISTS project_ids TEXT[];
+
+-- Boundaries are removed only by an explicit purge, never by cascade.
+ALTER TABLE project_geometries DROP CONSTRAINT IF EXISTS project_geometries_project_id_fkey;
+ALTER TABLE project_geometries ADD CONSTRAINT project_geometries_project_id_fkey
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE RESTRICT;
@@ -0,0 +1,15 @@
+-- Migration: 020_invitation_lifecycle
+-- Description: Invitation accept/decline/revoke/expiry tracking and hashed tokens
+-- Date: 2026-10-17
+
+ALTER TABLE project_invitations ADD COLUMN IF NOT EXISTS invited_by TEXT;
+ALTER TABLE project_invitations ADD COLUMN IF NOT EXISTS responded_at TIMESTAMPTZ;
+
+-- Used by the expiry sweep.
+CREATE INDEX IF NOT EXISTS idx_invitations_status_expiry ON project_invitations (status, expires_at);
+
+-- Tokens are now stored as SHA-256 hex. Older plain tokens can no longer be
+-- redeemed, so close those invitations; they can be resent.
+UPDATE project_invitations
+SET status = 'expired', updated_at = NOW()
+WHERE status = 'pending' AND length(token) <> 64;
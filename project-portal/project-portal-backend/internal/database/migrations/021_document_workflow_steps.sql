@@ -0,0 +1,52 @@
+-- Migration: 021_document_workflow_steps
+-- Description: Template-driven document workflows: per-document progress, approval history and auto-assignment
+-- Date: 2026-10-17
+
+ALTER TABLE document_workflows ADD COLUMN IF NOT EXISTS auto_assign BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Steps used to double as a shared audit trail. Keep the old contents for
+-- reference and leave only named step definitions; unnamed entries made the
+-- template fall back to the built-in transitions anyway.
+ALTER TABLE document_workflows ADD COLUMN IF NOT EXISTS legacy_steps JSONB;
+UPDATE document_workflows
+SET legacy_steps = steps,
+    steps = COALESCE((SELECT jsonb_agg(e) FROM jsonb_array_elements(steps) e WHERE e ? 'name'), '[]'::jsonb)
+WHERE legacy_steps IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_document_workflows_auto_assign
+    ON document_workflows (document_type, created_at DESC) WHERE auto_assign;
+
+CREATE TABLE IF NOT EXISTS document_workflow_runs (
+    document_id UUID PRIMARY KEY REFERENCES documents(id) ON DELETE CASCADE,
+    workflow_id UUID NOT NULL REFERENCES document_workflows(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL DEFAULT 1,
+    step_index INTEGER NOT NULL DEFAULT 0,
+    step_started_at TIMESTAMPTZ NOT NULL,
+    due_at TIMESTAMPTZ,
+    escalated_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Used by the SLA escalation sweep.
+CREATE INDEX IF NOT EXISTS idx_document_workflow_runs_due
+    ON document_workflow_runs (due_at) WHERE escalated_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS document_approvals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    workflow_id UUID NOT NULL REFERENCES document_workflows(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    step_index INTEGER NOT NULL,
+    step_name VARCHAR(255) NOT NULL,
+    user_id UUID,
+    role VARCHAR(50),
+    decision VARCHAR(20) NOT NULL, -- 'approve', 'reject', 'send_back', 'escalated'
+    comment TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_approvals_document ON document_approvals (document_id, round, step_index);
+
+-- An approver counts once towards a step's quorum.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_approvals_once
+    ON document_approvals (document_id, round, step_index, user_id) WHERE decision = 'approve';
@@ -0,0 +1,19 @@
+-- Migration: 022_document_integrity
+-- Description: SHA-256 content digests per document and version, duplicate flags and integrity check status
+-- Date: 2026-10-17
+
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS duplicate_of UUID REFERENCES documents(id) ON DELETE SET NULL;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS integrity_status VARCHAR(20); -- 'verified', 'mismatch', 'missing', 'baseline'
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS integrity_checked_at TIMESTAMPTZ;
+
+ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS duplicate_of UUID REFERENCES documents(id) ON DELETE SET NULL;
+
+-- Duplicate detection looks up content within a project.
+CREATE INDEX IF NOT EXISTS idx_documents_project_hash ON documents (project_id, content_hash);
+CREATE INDEX IF NOT EXISTS idx_document_versions_hash ON document_versions (content_hash);
+
+-- The integrity sweep takes the least recently checked documents first.
+CREATE INDEX IF NOT EXISTS idx_documents_integrity_checked ON documents (integrity_checked_at NULLS FIRST)
+    WHERE deleted_at IS NULL;
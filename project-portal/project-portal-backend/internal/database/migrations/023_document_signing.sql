@@ -0,0 +1,26 @@
+-- Migration: 023_document_signing
+-- Description: Organization and personal PAdES signing certificates with vault-encrypted private keys
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS document_signing_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    user_id UUID, -- NULL for the project's organization certificate
+    name VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    serial_number VARCHAR(128) NOT NULL,
+    fingerprint_sha256 VARCHAR(64) NOT NULL,
+    not_before TIMESTAMPTZ NOT NULL,
+    not_after TIMESTAMPTZ NOT NULL,
+    certificate_pem TEXT NOT NULL, -- signer certificate first, then its chain
+    encrypted_key TEXT NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+-- Signing looks up the newest active certificate for a user or project.
+CREATE INDEX IF NOT EXISTS idx_document_signing_certificates_project
+    ON document_signing_certificates (project_id, user_id, created_at DESC)
+    WHERE revoked_at IS NULL;
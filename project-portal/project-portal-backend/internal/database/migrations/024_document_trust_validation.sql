@@ -0,0 +1,32 @@
+-- Migration: 024_document_trust_validation
+-- Description: Per-project trust anchor bundles, cached OCSP/CRL responses and PAdES validation results
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS document_trust_anchors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    certificates_pem TEXT NOT NULL,
+    certificates JSONB DEFAULT '[]', -- subject, issuer, fingerprint and expiry of each certificate
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_trust_anchors_project
+    ON document_trust_anchors (project_id, created_at DESC);
+
+-- Shared across instances; rows are replaced on refresh and swept once expired.
+CREATE TABLE IF NOT EXISTS document_revocation_responses (
+    cache_key VARCHAR(512) PRIMARY KEY, -- ocsp:<issuer key hash>:<serial> or crl:<url>
+    response BYTEA NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_revocation_responses_expires
+    ON document_revocation_responses (expires_at);
+
+ALTER TABLE document_signatures
+    ADD COLUMN IF NOT EXISTS indication VARCHAR(20), -- TOTAL-PASSED, TOTAL-FAILED or INDETERMINATE
+    ADD COLUMN IF NOT EXISTS level VARCHAR(20);      -- PAdES-B-B, -B-T, -B-LT or -B-LTA
@@ -0,0 +1,14 @@
+-- Migration: 025_document_text_extraction
+-- Description: Track asynchronous full-text extraction and search indexing of document files
+-- Date: 2026-10-17
+
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS text_status VARCHAR(20), -- pending, indexed, failed or unsupported; NULL until first queued
+    ADD COLUMN IF NOT EXISTS text_version INTEGER,
+    ADD COLUMN IF NOT EXISTS text_extracted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS text_error TEXT;
+
+-- The extraction worker polls for documents still waiting for indexing.
+CREATE INDEX IF NOT EXISTS idx_documents_text_pending
+    ON documents (uploaded_at)
+    WHERE deleted_at IS NULL AND (text_status IS NULL OR text_status = 'pending');
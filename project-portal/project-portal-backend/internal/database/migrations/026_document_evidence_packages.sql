@@ -0,0 +1,20 @@
+-- Migration: 026_document_evidence_packages
+-- Description: Record of verification evidence packages issued per project and reporting period
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS document_evidence_packages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    file_count INTEGER NOT NULL,
+    total_bytes BIGINT NOT NULL,
+    manifest_sha256 VARCHAR(64) NOT NULL, -- SHA-256 of manifest.json as signed
+    signing_certificate_id UUID NOT NULL,
+    signer_subject VARCHAR(500),
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_evidence_packages_project
+    ON document_evidence_packages (project_id, created_at DESC);
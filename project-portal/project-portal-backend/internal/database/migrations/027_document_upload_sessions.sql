@@ -0,0 +1,31 @@
+-- Migration: 027_document_upload_sessions
+-- Description: Resumable chunked uploads for large documents and imagery
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS document_upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL,
+    document_id UUID, -- target document when the upload is a new version
+    name VARCHAR(500),
+    description TEXT,
+    document_type VARCHAR(100),
+    change_summary TEXT,
+    filename VARCHAR(500) NOT NULL,
+    content_type VARCHAR(255),
+    file_type VARCHAR(50) NOT NULL,
+    total_size BIGINT NOT NULL,
+    expected_sha256 VARCHAR(64),
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    parts JSONB NOT NULL DEFAULT '[]', -- stored chunks in order: offset, size, sha256, key
+    status VARCHAR(20) NOT NULL,       -- active, assembling, completed, failed, aborted, expired
+    error TEXT,
+    result_id UUID,                    -- reserved ID of the document or version the upload records
+    result_version INTEGER,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_upload_sessions_project ON document_upload_sessions (project_id);
+CREATE INDEX IF NOT EXISTS idx_document_upload_sessions_status ON document_upload_sessions (status, expires_at);
@@ -0,0 +1,29 @@
+-- Migration: 028_document_share_links
+-- Description: Expiring external share links for documents, and the link used for each logged access
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS document_share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL,
+    version_number INTEGER,                -- NULL shares the current version
+    label VARCHAR(255),
+    token_hash VARCHAR(64) NOT NULL,       -- SHA-256 of the URL token
+    password_hash VARCHAR(100),            -- bcrypt, NULL when no password is set
+    expires_at TIMESTAMPTZ NOT NULL,
+    max_downloads INTEGER NOT NULL DEFAULT 0, -- 0 means unlimited
+    download_count INTEGER NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_share_links_token ON document_share_links (token_hash);
+CREATE INDEX IF NOT EXISTS idx_document_share_links_document ON document_share_links (document_id, created_at DESC);
+
+ALTER TABLE document_access_logs ADD COLUMN IF NOT EXISTS share_link_id UUID;
+CREATE INDEX IF NOT EXISTS idx_document_access_logs_share_link
+    ON document_access_logs (share_link_id) WHERE share_link_id IS NOT NULL;
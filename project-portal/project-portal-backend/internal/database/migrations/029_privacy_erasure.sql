@@ -0,0 +1,30 @@
+-- Migration: 029_privacy_erasure
+-- Description: Signed deletion certificates for privacy requests, and stored search analytics events
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS deletion_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES privacy_requests(id),
+    user_id UUID NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    verified BOOLEAN NOT NULL,            -- every erased location re-counted clean
+    entries JSONB NOT NULL DEFAULT '[]',  -- per source and category outcome
+    digest VARCHAR(64) NOT NULL,          -- SHA-256 of the canonical certificate body
+    signature VARCHAR(64) NOT NULL,       -- HMAC-SHA256 of the digest
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_deletion_certificates_request ON deletion_certificates (request_id, issued_at DESC);
+CREATE INDEX IF NOT EXISTS idx_deletion_certificates_user ON deletion_certificates (user_id);
+
+CREATE TABLE IF NOT EXISTS search_analytics_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID,                         -- NULL for anonymous searches
+    query TEXT,
+    results_count BIGINT NOT NULL DEFAULT 0,
+    took_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_analytics_events_user ON search_analytics_events (user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_search_analytics_events_created ON search_analytics_events (created_at);
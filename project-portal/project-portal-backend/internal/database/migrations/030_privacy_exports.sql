@@ -0,0 +1,13 @@
+-- Migration: 030_privacy_exports
+-- Description: Encrypted data-subject export packages with download tokens and expiry
+-- Date: 2026-10-17
+
+ALTER TABLE privacy_requests
+    ADD COLUMN IF NOT EXISTS export_format VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS export_object_key TEXT NOT NULL DEFAULT '',  -- encrypted package in object storage
+    ADD COLUMN IF NOT EXISTS export_size_bytes BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS export_token_hash VARCHAR(64) NOT NULL DEFAULT '',  -- SHA-256 of the download token
+    ADD COLUMN IF NOT EXISTS export_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_export_expiry
+    ON privacy_requests (export_expires_at) WHERE export_object_key <> '';
@@ -0,0 +1,16 @@
+-- Migration: 031_privacy_request_deadlines
+-- Description: Jurisdiction response deadlines, requester and escalation tracking for privacy requests
+-- Date: 2026-10-17
+
+ALTER TABLE privacy_requests
+    ADD COLUMN IF NOT EXISTS jurisdiction VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS requested_by UUID,   -- authenticated user who submitted the request
+    ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ,  -- submitted_at plus the jurisdiction's maximum response days
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMPTZ;
+
+-- Existing requests get the GDPR deadline, the default jurisdiction.
+UPDATE privacy_requests SET jurisdiction = 'GDPR', due_at = submitted_at + INTERVAL '30 days'
+WHERE due_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_due_at ON privacy_requests (due_at)
+    WHERE escalated_at IS NULL;
@@ -0,0 +1,11 @@
+-- Migration: 032_retention_runs
+-- Description: Record the outcome of each retention enforcement run on its schedule
+-- Date: 2026-10-17
+
+ALTER TABLE retention_schedules
+    ADD COLUMN IF NOT EXISTS last_records_affected BIGINT NOT NULL DEFAULT 0,  -- rows deleted, anonymized or archived
+    ADD COLUMN IF NOT EXISTS last_records_held BIGINT NOT NULL DEFAULT 0,      -- due rows kept under legal hold
+    ADD COLUMN IF NOT EXISTS last_run_details JSONB;                           -- per-table counts and archive keys
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_schedules_policy_type
+    ON retention_schedules (policy_id, data_type);
@@ -0,0 +1,44 @@
+-- Migration: 033_audit_chain_sequence
+-- Description: Sequence the audit hash chain, record signing keys and add Merkle checkpoints
+-- Date: 2026-10-17
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS sequence BIGINT,      -- position in the hash chain, assigned under an advisory lock
+    ADD COLUMN IF NOT EXISTS key_id VARCHAR(64);   -- signing key; NULL for entries written before sequencing
+
+-- Existing entries were chained in log_id order.
+UPDATE audit_logs a
+SET sequence = o.n
+FROM (SELECT log_id, row_number() OVER (ORDER BY log_id) AS n FROM audit_logs) o
+WHERE a.log_id = o.log_id AND a.sequence IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_sequence ON audit_logs(sequence);
+
+-- Entries up to legacy_until were signed with the old, public key and are
+-- only accepted in that format up to here.
+CREATE TABLE IF NOT EXISTS audit_chain_state (
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    legacy_until BIGINT NOT NULL
+);
+
+INSERT INTO audit_chain_state (id, legacy_until)
+SELECT 1, COALESCE(MAX(sequence), 0) FROM audit_logs
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_sequence BIGINT NOT NULL,
+    to_sequence BIGINT NOT NULL,
+    entry_count BIGINT NOT NULL,
+    merkle_root VARCHAR(64) NOT NULL,   -- SHA-256 Merkle root of the entries' hashes
+    last_hash VARCHAR(64) NOT NULL,     -- hash of the entry at to_sequence
+    key_id VARCHAR(64) NOT NULL,
+    signature TEXT NOT NULL,
+    anchor_network VARCHAR(50),
+    anchor_tx_id VARCHAR(128),
+    anchor_memo VARCHAR(128),
+    anchored_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_checkpoints_to_sequence ON audit_checkpoints(to_sequence);
@@ -0,0 +1,14 @@
+-- Migration: 034_jwt_signing_keys
+-- Description: Share rotated JWT signing keys between instances
+-- Date: 2026-10-17
+
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL,   -- HS256, RS256 or EdDSA
+    key_material BYTEA NOT NULL,      -- secret or PKCS#8 private key, encrypted with the key vault
+    not_before TIMESTAMPTZ NOT NULL,  -- when the key starts signing; instances load it earlier to verify
+    retired_at TIMESTAMPTZ,           -- when the next key took over
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_not_before ON jwt_signing_keys (not_before);
@@ -0,0 +1,385 @@
+package documents
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+
+	"github.com/google/uuid"
+)
+
+// ─── Access History & Anomalies ───────────────────────────────────────────────
+
+const (
+	// MaxAccessExportRows caps one CSV export of access logs.
+	MaxAccessExportRows = 100000
+	// maxAnomalyEvents caps the events analysed in one anomaly report.
+	maxAnomalyEvents = 50000
+	// defaultStatsWindow and defaultAnomalyWindow apply without from/to.
+	defaultStatsWindow   = 30 * 24 * time.Hour
+	defaultAnomalyWindow = 24 * time.Hour
+	// knownIPLookback is the history that decides whether an address is new.
+	knownIPLookback = 90 * 24 * time.Hour
+	// volumeBaseline is the history a user's download rate is compared to.
+	volumeBaseline = 30 * 24 * time.Hour
+)
+
+// ErrInvalidAccessQuery is returned for malformed access log filters.
+var ErrInvalidAccessQuery = errors.New("invalid access log query")
+
+// downloadActions are the access log actions counted as downloads.
+var downloadActions = []AccessAction{ActionDownload, ActionShareDownload}
+
+// accessAnomalyThresholds tune the shared detector for document access:
+// more than 50 downloads in an hour is a bulk download.
+var accessAnomalyThresholds = audit.AnomalyThresholds{
+	MaxAccessPerHour:       50,
+	UnusualHoursStart:      22,
+	UnusualHoursEnd:        6,
+	AccessVolumeMultiplier: 3.0,
+}
+
+// AccessLogFilter selects access logs. From and To accept RFC 3339 times
+// or dates; To is exclusive.
+type AccessLogFilter struct {
+	ProjectID   string `form:"project_id"`
+	DocumentID  string `form:"document_id"`
+	UserID      string `form:"user_id"`
+	ShareLinkID string `form:"share_link_id"`
+	Action      string `form:"action"`
+	IPAddress   string `form:"ip_address"`
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Page        int    `form:"page,default=1"`
+	PageSize    int    `form:"page_size,default=50"`
+}
+
+// AccessLogEntry is an access log with the document it concerns.
+type AccessLogEntry struct {
+	DocumentAccessLog
+	DocumentName string    `json:"document_name"`
+	ProjectID    uuid.UUID `json:"project_id"`
+}
+
+// AccessLogPage is a page of access history.
+type AccessLogPage struct {
+	Data       []AccessLogEntry `json:"data"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+// AccessStats aggregates access history over a period.
+type AccessStats struct {
+	From              time.Time              `json:"from"`
+	To                time.Time              `json:"to"`
+	Total             int64                  `json:"total"`
+	UniqueUsers       int64                  `json:"unique_users"`
+	UniqueIPs         int64                  `json:"unique_ips"`
+	ShareLinkAccesses int64                  `json:"share_link_accesses"`
+	ByAction          map[AccessAction]int64 `json:"by_action"`
+	DownloadsByDay    []DailyDownloads       `json:"downloads_by_day"`
+	TopDocuments      []DocumentDownloads    `json:"top_documents"`
+	TopUsers          []UserDownloads        `json:"top_users"`
+}
+
+// DailyDownloads counts downloads on one UTC day.
+type DailyDownloads struct {
+	Day       time.Time `json:"day"`
+	Downloads int64     `json:"downloads"`
+}
+
+// DocumentDownloads counts downloads of one document.
+type DocumentDownloads struct {
+	DocumentID   uuid.UUID `json:"document_id"`
+	DocumentName string    `json:"document_name"`
+	Downloads    int64     `json:"downloads"`
+	Downloaders  int64     `json:"downloaders"` // users and share links
+}
+
+// UserDownloads counts one user's downloads.
+type UserDownloads struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Downloads int64     `json:"downloads"`
+	Documents int64     `json:"documents"`
+}
+
+// AccessAnomalyReport lists unusual access patterns in a period.
+type AccessAnomalyReport struct {
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	EventsAnalyzed int             `json:"events_analyzed"`
+	Truncated      bool            `json:"truncated,omitempty"` // more events than were analysed
+	Anomalies      []audit.Anomaly `json:"anomalies"`
+}
+
+// QueryAccessLogs returns a page of access history, newest first.
+func (s *Service) QueryAccessLogs(ctx context.Context, f AccessLogFilter) (*AccessLogPage, error) {
+	from, to, err := parseAccessFilter(&f, 0)
+	if err != nil {
+		return nil, err
+	}
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 || f.PageSize > 200 {
+		f.PageSize = 50
+	}
+	entries, total, err := s.repo.FindAccessLogs(ctx, &f, from, to, f.Page, f.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []AccessLogEntry{}
+	}
+	return &AccessLogPage{
+		Data:       entries,
+		Total:      total,
+		Page:       f.Page,
+		PageSize:   f.PageSize,
+		TotalPages: int((total + int64(f.PageSize) - 1) / int64(f.PageSize)),
+	}, nil
+}
+
+// AccessStatistics aggregates access history, by default over 30 days.
+func (s *Service) AccessStatistics(ctx context.Context, f AccessLogFilter) (*AccessStats, error) {
+	from, to, err := parseAccessFilter(&f, defaultStatsWindow)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.AccessStats(ctx, &f, from, to, downloadActions, 10)
+}
+
+// DetectAccessAnomalies looks for bulk downloads, access from new
+// addresses, downloads at unusual hours and download rates well above a
+// user's baseline, by default over the last 24 hours.
+func (s *Service) DetectAccessAnomalies(ctx context.Context, f AccessLogFilter) (*AccessAnomalyReport, error) {
+	from, to, err := parseAccessFilter(&f, defaultAnomalyWindow)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.repo.FindAccessEvents(ctx, &f, from, to, maxAnomalyEvents+1)
+	if err != nil {
+		return nil, err
+	}
+	report := &AccessAnomalyReport{From: from, To: to, Anomalies: []audit.Anomaly{}}
+	if len(logs) > maxAnomalyEvents {
+		logs, report.Truncated = logs[:maxAnomalyEvents], true
+	}
+	report.EventsAnalyzed = len(logs)
+
+	events, downloads := make([]audit.AccessEvent, 0, len(logs)), []audit.AccessEvent{}
+	userSet := map[uuid.UUID]bool{}
+	for _, l := range logs {
+		ev := audit.AccessEvent{
+			ActorID:  accessActor(&l),
+			TargetID: l.DocumentID.String(),
+			Action:   string(l.Action),
+			IP:       l.IPAddress,
+			At:       l.PerformedAt,
+		}
+		events = append(events, ev)
+		if isDownload(l.Action) {
+			downloads = append(downloads, ev)
+		}
+		if l.UserID != nil {
+			userSet[*l.UserID] = true
+		}
+	}
+	users := make([]uuid.UUID, 0, len(userSet))
+	for id := range userSet {
+		users = append(users, id)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].String() < users[j].String() })
+
+	detector := audit.NewAnomalyDetectorWithThresholds(accessAnomalyThresholds)
+	report.Anomalies = append(report.Anomalies, detector.AnalyzeBulkAccess(downloads)...)
+
+	knownIPs, err := s.repo.KnownIPs(ctx, users, from.Add(-knownIPLookback), from)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]map[string]bool, len(knownIPs))
+	for id, ips := range knownIPs {
+		set := make(map[string]bool, len(ips))
+		for _, ip := range ips {
+			set[ip] = true
+		}
+		known[id.String()] = set
+	}
+	report.Anomalies = append(report.Anomalies, detector.AnalyzeNewIPs(events, known)...)
+	report.Anomalies = append(report.Anomalies, detector.AnalyzeHistoryUnusualHours(downloads)...)
+
+	baseline, err := s.repo.CountAccessByUser(ctx, &f, from.Add(-volumeBaseline), from, downloadActions)
+	if err != nil {
+		return nil, err
+	}
+	current := map[uuid.UUID]int64{}
+	for _, l := range logs {
+		if l.UserID != nil && isDownload(l.Action) {
+			current[*l.UserID]++
+		}
+	}
+	days := to.Sub(from).Hours() / 24
+	for _, id := range users {
+		if current[id] == 0 {
+			continue
+		}
+		a := detector.AnalyzeVolumeSpike(id.String(), float64(current[id])/days, float64(baseline[id])/(volumeBaseline.Hours()/24))
+		if a != nil {
+			a.DetectedAt = to
+			report.Anomalies = append(report.Anomalies, *a)
+		}
+	}
+
+	sort.SliceStable(report.Anomalies, func(i, j int) bool {
+		return report.Anomalies[i].DetectedAt.Before(report.Anomalies[j].DetectedAt)
+	})
+	return report, nil
+}
+
+// ExportAccessLogsCSV writes access history matching f to w as CSV, oldest
+// first. It returns the number of rows written and whether the export was
+// cut at MaxAccessExportRows.
+func (s *Service) ExportAccessLogsCSV(ctx context.Context, f AccessLogFilter, w io.Writer) (int, bool, error) {
+	from, to, err := parseAccessFilter(&f, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"performed_at", "project_id", "document_id", "document_name", "action", "user_id", "share_link_id", "ip_address", "user_agent", "details"})
+	rows := 0
+	err = s.repo.EachAccessLog(ctx, &f, from, to, MaxAccessExportRows+1, func(e *AccessLogEntry) error {
+		rows++
+		if rows > MaxAccessExportRows {
+			return nil
+		}
+		return cw.Write([]string{
+			e.PerformedAt.UTC().Format(time.RFC3339),
+			e.ProjectID.String(),
+			e.DocumentID.String(),
+			csvSafe(e.DocumentName),
+			string(e.Action),
+			uuidString(e.UserID),
+			uuidString(e.ShareLinkID),
+			e.IPAddress,
+			csvSafe(e.UserAgent),
+			csvSafe(string(e.Details)),
+		})
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	cw.Flush()
+	truncated := rows > MaxAccessExportRows
+	if truncated {
+		rows = MaxAccessExportRows
+	}
+	return rows, truncated, cw.Error()
+}
+
+// WriteAnomaliesCSV writes an anomaly report to w as CSV.
+func WriteAnomaliesCSV(report *AccessAnomalyReport, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"detected_at", "type", "severity", "actor", "description", "details"})
+	for _, a := range report.Anomalies {
+		details, _ := json.Marshal(a.Details)
+		_ = cw.Write([]string{
+			a.DetectedAt.UTC().Format(time.RFC3339),
+			a.Type,
+			a.Severity,
+			a.ActorID,
+			csvSafe(a.Description),
+			csvSafe(string(details)),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseAccessFilter validates f and resolves its period. Without From, the
+// period starts window before To; a zero window leaves it open.
+func parseAccessFilter(f *AccessLogFilter, window time.Duration) (from, to time.Time, err error) {
+	for name, v := range map[string]string{"project_id": f.ProjectID, "document_id": f.DocumentID, "user_id": f.UserID, "share_link_id": f.ShareLinkID} {
+		if v != "" {
+			if _, err := uuid.Parse(v); err != nil {
+				return from, to, fmt.Errorf("%w: invalid %s", ErrInvalidAccessQuery, name)
+			}
+		}
+	}
+	if f.IPAddress != "" && net.ParseIP(f.IPAddress) == nil {
+		return from, to, fmt.Errorf("%w: invalid ip_address", ErrInvalidAccessQuery)
+	}
+	if from, err = parseAccessTime(f.From); err != nil {
+		return from, to, fmt.Errorf("%w: invalid from: %v", ErrInvalidAccessQuery, err)
+	}
+	if to, err = parseAccessTime(f.To); err != nil {
+		return from, to, fmt.Errorf("%w: invalid to: %v", ErrInvalidAccessQuery, err)
+	}
+	if window > 0 {
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		if from.IsZero() {
+			from = to.Add(-window)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("%w: from must be before to", ErrInvalidAccessQuery)
+	}
+	return from, to, nil
+}
+
+func parseAccessTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// accessActor names who performed an access: a user, or a share link.
+func accessActor(l *DocumentAccessLog) string {
+	switch {
+	case l.UserID != nil:
+		return l.UserID.String()
+	case l.ShareLinkID != nil:
+		return "share_link:" + l.ShareLinkID.String()
+	}
+	return "anonymous"
+}
+
+func isDownload(a AccessAction) bool {
+	for _, d := range downloadActions {
+		if a == d {
+			return true
+		}
+	}
+	return false
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// csvSafe neutralises values a spreadsheet would run as formulas.
+func csvSafe(v string) string {
+	if v != "" && (v[0] == '=' || v[0] == '+' || v[0] == '-' || v[0] == '@') {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/audit"
+)
+
+func TestParseAccessFilter(t *testing.T) {
+	f := AccessLogFilter{From: "2026-10-01", To: "2026-10-02T12:00:00Z"}
+	from, to, err := parseAccessFilter(&f, 0)
+	if err != nil || !from.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("got %v %v %v", from, to, err)
+	}
+
+	from, to, err = parseAccessFilter(&AccessLogFilter{}, 24*time.Hour)
+	if err != nil || to.Sub(from) != 24*time.Hour || time.Since(to) > time.Minute {
+		t.Errorf("default window: %v %v %v", from, to, err)
+	}
+	if from, to, _ := parseAccessFilter(&AccessLogFilter{}, 0); !from.IsZero() || !to.IsZero() {
+		t.Errorf("open filter got a period: %v %v", from, to)
+	}
+
+	for _, bad := range []AccessLogFilter{
+		{ProjectID: "nope"},
+		{UserID: "nope"},
+		{IPAddress: "not-an-ip"},
+		{From: "yesterday"},
+		{From: "2026-10-02", To: "2026-10-01"},
+	} {
+		if _, _, err := parseAccessFilter(&bad, 0); !errors.Is(err, ErrInvalidAccessQuery) {
+			t.Errorf("%+v: expected ErrInvalidAccessQuery, got %v", bad, err)
+		}
+	}
+}
+
+func TestWriteAnomaliesCSV(t *testing.T) {
+	report := &AccessAnomalyReport{Anomalies: []audit.Anomaly{{
+		Type: "bulk_access", Severity: "high", ActorID: "u1",
+		Description: "=HYPERLINK(\"x\")", DetectedAt: time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC),
+		Details: map[string]interface{}{"access_count": 60},
+	}}}
+	var buf bytes.Buffer
+	if err := WriteAnomaliesCSV(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("rows %v, %v", rows, err)
+	}
+	if got := rows[1]; got[0] != "2026-10-01T01:00:00Z" || got[1] != "bulk_access" || got[4] != "'=HYPERLINK(\"x\")" || got[5] != `{"access_count":60}` {
+		t.Errorf("row %q", got)
+	}
+}
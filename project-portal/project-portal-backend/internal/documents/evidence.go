@@ -0,0 +1,485 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+
+	"github.com/google/uuid"
+)
+
+// ─── Verification Evidence Packages ───────────────────────────────────────────
+
+// EvidenceFormat identifies the manifest layout.
+const EvidenceFormat = "carbon-scribe-evidence/1"
+
+// MaxEvidenceBytes caps the files packed into one evidence package.
+const MaxEvidenceBytes = 512 << 20
+
+// Files in every package besides the evidence itself.
+const (
+	evidenceManifest  = "manifest.json"
+	evidenceSignature = "manifest.json.p7s"
+	evidenceChain     = "signer-chain.pem"
+	evidenceReadme    = "README.txt"
+)
+
+// ErrInvalidEvidenceRequest is returned for unusable package selections.
+var ErrInvalidEvidenceRequest = errors.New("invalid evidence package request")
+
+// BoundarySource supplies project boundaries; geospatial.Service
+// implements it.
+type BoundarySource interface {
+	ProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error)
+}
+
+// SetBoundarySource makes evidence packages include the project boundary.
+func (s *Service) SetBoundarySource(b BoundarySource) {
+	s.boundaries = b
+}
+
+// EvidencePackageRequest is the JSON body for
+// POST /api/v1/documents/evidence-packages. Without Documents, every
+// document of the project (optionally limited to DocumentTypes) is included
+// at the latest version uploaded by PeriodEnd.
+type EvidencePackageRequest struct {
+	ProjectID       string                `json:"project_id" binding:"required"`
+	PeriodStart     time.Time             `json:"period_start" binding:"required"`
+	PeriodEnd       time.Time             `json:"period_end" binding:"required"`
+	DocumentTypes   []string              `json:"document_types"`
+	Documents       []EvidenceDocumentRef `json:"documents"`
+	ExcludeBoundary bool                  `json:"exclude_boundary"`
+}
+
+// EvidenceDocumentRef selects one document version. Version 0 means the
+// latest version uploaded by the end of the period.
+type EvidenceDocumentRef struct {
+	DocumentID uuid.UUID `json:"document_id" binding:"required"`
+	Version    int       `json:"version"`
+}
+
+// EvidenceManifest is manifest.json. It lists every file in the package so
+// a recipient can check the package is complete and unmodified; the
+// detached signature in manifest.json.p7s covers it byte for byte.
+type EvidenceManifest struct {
+	Format      string             `json:"format"`
+	PackageID   uuid.UUID          `json:"package_id"`
+	ProjectID   uuid.UUID          `json:"project_id"`
+	PeriodStart time.Time          `json:"period_start"`
+	PeriodEnd   time.Time          `json:"period_end"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	GeneratedBy *uuid.UUID         `json:"generated_by,omitempty"`
+	Files       []EvidenceFile     `json:"files"`
+	Omitted     []EvidenceOmission `json:"omitted,omitempty"`
+}
+
+// EvidenceFile describes one packed file.
+type EvidenceFile struct {
+	Path         string              `json:"path"`
+	Kind         string              `json:"kind"` // document or boundary
+	SHA256       string              `json:"sha256"`
+	Size         int64               `json:"size"`
+	DocumentID   *uuid.UUID          `json:"document_id,omitempty"`
+	Name         string              `json:"name,omitempty"`
+	DocumentType string              `json:"document_type,omitempty"`
+	Status       DocumentStatus      `json:"status,omitempty"`
+	Version      int                 `json:"version,omitempty"`
+	UploadedAt   *time.Time          `json:"uploaded_at,omitempty"`
+	IPFSCID      string              `json:"ipfs_cid,omitempty"`
+	Signatures   *EvidenceSignatures `json:"signatures,omitempty"`
+}
+
+// EvidenceSignatures is the verification of a PDF's signatures at export.
+type EvidenceSignatures struct {
+	SignedCount int                      `json:"signed_count"`
+	AllValid    bool                     `json:"all_valid"`
+	Results     []security.SignatureInfo `json:"results,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+}
+
+// EvidenceOmission records requested evidence that could not be packed.
+type EvidenceOmission struct {
+	DocumentID *uuid.UUID `json:"document_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Reason     string     `json:"reason"`
+}
+
+// EvidencePackageResult is returned after a package was written.
+type EvidencePackageResult struct {
+	Package   *EvidencePackage            `json:"package"`
+	Manifest  *EvidenceManifest           `json:"manifest"`
+	Signature *security.DetachedSignature `json:"signature"`
+}
+
+// evidenceSource is one stored version of a document.
+type evidenceSource struct {
+	version    int
+	key        string
+	hash       string
+	cid        string
+	size       int64
+	uploadedAt time.Time
+	tracked    bool // false for a replaced original whose object was not kept
+}
+
+// ExportEvidencePackage writes a ZIP of the selected document versions and
+// the project boundary to w, with a manifest signed by the caller's (or
+// else the project's) signing certificate. Every document is checked
+// against its recorded SHA-256 first; a mismatch aborts the export.
+func (s *Service) ExportEvidencePackage(ctx context.Context, req *EvidencePackageRequest, userID *uuid.UUID, w io.Writer) (*EvidencePackageResult, error) {
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid project_id", ErrInvalidEvidenceRequest)
+	}
+	if !req.PeriodEnd.After(req.PeriodStart) {
+		return nil, fmt.Errorf("%w: period_end must be after period_start", ErrInvalidEvidenceRequest)
+	}
+	signer, sc, err := s.loadSigner(ctx, s.repo, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := s.repo.FindProjectDocuments(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	manifest := &EvidenceManifest{
+		Format:      EvidenceFormat,
+		PackageID:   uuid.New(),
+		ProjectID:   projectID,
+		PeriodStart: req.PeriodStart.UTC(),
+		PeriodEnd:   req.PeriodEnd.UTC(),
+		GeneratedAt: now,
+		GeneratedBy: userID,
+		Files:       []EvidenceFile{},
+	}
+	selected, err := selectEvidence(docs, req, manifest)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, sel := range selected {
+		total += sel.src.size
+	}
+	if total > MaxEvidenceBytes {
+		return nil, fmt.Errorf("%w: the selected files exceed %d MB, narrow the selection", ErrInvalidEvidenceRequest, MaxEvidenceBytes>>20)
+	}
+
+	zw := zip.NewWriter(w)
+	add := func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	used := map[string]bool{}
+	for _, sel := range selected {
+		file, data, err := s.packDocument(ctx, sel.doc, sel.src, used)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(file.Path, data); err != nil {
+			return nil, fmt.Errorf("failed to write evidence package: %w", err)
+		}
+		manifest.Files = append(manifest.Files, *file)
+	}
+	if !req.ExcludeBoundary {
+		file, data, err := s.packBoundary(ctx, projectID, manifest)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			if err := add(file.Path, data); err != nil {
+				return nil, fmt.Errorf("failed to write evidence package: %w", err)
+			}
+			manifest.Files = append(manifest.Files, *file)
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	signature, sigInfo, err := security.SignDetached(ctx, manifestJSON, signer, s.tsa)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{evidenceManifest, manifestJSON},
+		{evidenceSignature, signature},
+		{evidenceChain, signer.CertificatesPEM()},
+		{evidenceReadme, evidenceReadmeText(manifest)},
+	} {
+		if err := add(f.name, f.data); err != nil {
+			return nil, fmt.Errorf("failed to write evidence package: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write evidence package: %w", err)
+	}
+
+	sum := sha256.Sum256(manifestJSON)
+	pkg := &EvidencePackage{
+		ID:                   manifest.PackageID,
+		ProjectID:            projectID,
+		PeriodStart:          manifest.PeriodStart,
+		PeriodEnd:            manifest.PeriodEnd,
+		FileCount:            len(manifest.Files),
+		TotalBytes:           total,
+		ManifestSHA256:       hex.EncodeToString(sum[:]),
+		SigningCertificateID: sc.ID,
+		SignerSubject:        sigInfo.CertSubject,
+		CreatedBy:            userID,
+		CreatedAt:            now,
+	}
+	if err := s.repo.CreateEvidencePackage(ctx, pkg); err != nil {
+		return nil, err
+	}
+	for _, sel := range selected {
+		_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
+			DocumentID:  sel.doc.ID,
+			UserID:      userID,
+			Action:      ActionEvidenceExport,
+			PerformedAt: now,
+		})
+	}
+	return &EvidencePackageResult{Package: pkg, Manifest: manifest, Signature: sigInfo}, nil
+}
+
+// ListEvidencePackages returns the packages issued for a project.
+func (s *Service) ListEvidencePackages(ctx context.Context, projectID uuid.UUID) ([]EvidencePackage, error) {
+	return s.repo.ListEvidencePackages(ctx, projectID)
+}
+
+type evidenceSelection struct {
+	doc *Document
+	src evidenceSource
+}
+
+// selectEvidence resolves the requested document versions. Documents that
+// cannot be included are recorded in manifest.Omitted.
+func selectEvidence(docs []Document, req *EvidencePackageRequest, manifest *EvidenceManifest) ([]evidenceSelection, error) {
+	omit := func(doc *Document, reason string) {
+		id := doc.ID
+		manifest.Omitted = append(manifest.Omitted, EvidenceOmission{DocumentID: &id, Name: doc.Name, Reason: reason})
+	}
+	pick := func(doc *Document, version int) (evidenceSelection, bool) {
+		src, ok := evidenceVersion(doc, version, manifest.PeriodEnd)
+		switch {
+		case !ok && version > 0:
+			omit(doc, fmt.Sprintf("version %d does not exist", version))
+		case !ok:
+			return evidenceSelection{}, false // not uploaded by the end of the period
+		case !src.tracked:
+			omit(doc, fmt.Sprintf("version %d was replaced before version history was kept", src.version))
+		default:
+			return evidenceSelection{doc: doc, src: src}, true
+		}
+		return evidenceSelection{}, false
+	}
+
+	var out []evidenceSelection
+	if len(req.Documents) > 0 {
+		byID := make(map[uuid.UUID]*Document, len(docs))
+		for i := range docs {
+			byID[docs[i].ID] = &docs[i]
+		}
+		seen := map[uuid.UUID]bool{}
+		for _, ref := range req.Documents {
+			doc, ok := byID[ref.DocumentID]
+			if !ok {
+				return nil, fmt.Errorf("%w: document %s is not in the project", ErrInvalidEvidenceRequest, ref.DocumentID)
+			}
+			if seen[ref.DocumentID] {
+				return nil, fmt.Errorf("%w: document %s is listed twice", ErrInvalidEvidenceRequest, ref.DocumentID)
+			}
+			seen[ref.DocumentID] = true
+			if sel, ok := pick(doc, ref.Version); ok {
+				out = append(out, sel)
+			} else if ref.Version == 0 {
+				omit(doc, "no version was uploaded by the end of the period")
+			}
+		}
+		return out, nil
+	}
+
+	types := map[string]bool{}
+	for _, t := range req.DocumentTypes {
+		types[strings.ToUpper(t)] = true
+	}
+	for i := range docs {
+		if len(types) > 0 && !types[string(docs[i].DocumentType)] {
+			continue
+		}
+		if sel, ok := pick(&docs[i], 0); ok {
+			out = append(out, sel)
+		}
+	}
+	return out, nil
+}
+
+// evidenceVersion returns the requested version of doc, or with version 0
+// the latest one uploaded by asOf. doc.Versions must be loaded.
+func evidenceVersion(doc *Document, version int, asOf time.Time) (evidenceSource, bool) {
+	sources := map[int]evidenceSource{}
+	for _, v := range doc.Versions {
+		sources[v.VersionNumber] = evidenceSource{
+			version: v.VersionNumber, key: v.S3Key, hash: v.ContentHash, cid: v.IPFSCID,
+			size: v.FileSize, uploadedAt: v.UploadedAt, tracked: true,
+		}
+	}
+	// The first upload has no version row; its object is only known while
+	// it is still the current file.
+	if _, ok := sources[1]; !ok {
+		sources[1] = evidenceSource{version: 1, uploadedAt: doc.UploadedAt}
+	}
+	if cur, ok := sources[doc.CurrentVersion]; ok {
+		cur.key, cur.hash, cur.cid, cur.size, cur.tracked = doc.S3Key, doc.ContentHash, doc.IPFSCID, doc.FileSize, true
+		sources[doc.CurrentVersion] = cur
+	}
+
+	if version > 0 {
+		src, ok := sources[version]
+		return src, ok
+	}
+	best := evidenceSource{}
+	for _, src := range sources {
+		if !src.uploadedAt.After(asOf) && src.version > best.version {
+			best = src
+		}
+	}
+	return best, best.version > 0
+}
+
+// packDocument downloads and checks one document version and verifies the
+// signatures of PDFs.
+func (s *Service) packDocument(ctx context.Context, doc *Document, src evidenceSource, used map[string]bool) (*EvidenceFile, []byte, error) {
+	data, err := s.storage.DownloadBytes(ctx, src.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download %q version %d: %w", doc.Name, src.version, err)
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	if src.hash != "" && digest != src.hash {
+		return nil, nil, fmt.Errorf("%w: %q version %d does not match its recorded SHA-256", ErrIntegrity, doc.Name, src.version)
+	}
+
+	id, uploadedAt := doc.ID, src.uploadedAt
+	file := &EvidenceFile{
+		Path:         evidencePath(doc, src.version, used),
+		Kind:         "document",
+		SHA256:       digest,
+		Size:         int64(len(data)),
+		DocumentID:   &id,
+		Name:         doc.Name,
+		DocumentType: string(doc.DocumentType),
+		Status:       doc.Status,
+		Version:      src.version,
+		UploadedAt:   &uploadedAt,
+		IPFSCID:      src.cid,
+	}
+	if doc.FileType == FileTypePDF {
+		file.Signatures = &EvidenceSignatures{}
+		vr, err := security.VerifyPDFSignaturesWithOptions(ctx, data, s.verifyOptions(ctx, doc.ProjectID))
+		if err != nil {
+			file.Signatures.Error = err.Error()
+		} else {
+			file.Signatures.SignedCount = vr.SignedCount
+			file.Signatures.AllValid = vr.AllValid
+			file.Signatures.Results = vr.Signatures
+		}
+	}
+	return file, data, nil
+}
+
+// packBoundary returns the project boundary as a GeoJSON Feature, or nil
+// when there is none.
+func (s *Service) packBoundary(ctx context.Context, projectID uuid.UUID, manifest *EvidenceManifest) (*EvidenceFile, []byte, error) {
+	if s.boundaries == nil {
+		manifest.Omitted = append(manifest.Omitted, EvidenceOmission{Name: "project boundary", Reason: "geospatial data is not available"})
+		return nil, nil, nil
+	}
+	boundaries, err := s.boundaries.ProjectBoundaries(ctx, []uuid.UUID{projectID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load project boundary: %w", err)
+	}
+	geometry, ok := boundaries[projectID]
+	if !ok {
+		manifest.Omitted = append(manifest.Omitted, EvidenceOmission{Name: "project boundary", Reason: "the project has no boundary"})
+		return nil, nil, nil
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   geometry,
+		"properties": map[string]interface{}{"project_id": projectID},
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &EvidenceFile{
+		Path:   "boundary/project_boundary.geojson",
+		Kind:   "boundary",
+		SHA256: hex.EncodeToString(sum[:]),
+		Size:   int64(len(data)),
+	}, data, nil
+}
+
+// evidencePath names a document inside the package:
+// documents/<type>/<name>_v<version>.<ext>, made unique within it.
+func evidencePath(doc *Document, version int, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(doc.Name))
+	name = strings.Trim(name, "._")
+	if name == "" {
+		name = doc.ID.String()
+	}
+	ext := "." + strings.ToLower(string(doc.FileType))
+	name = strings.TrimSuffix(name, ext)
+	dir := path.Join("documents", strings.ToLower(string(doc.DocumentType)))
+	p := path.Join(dir, fmt.Sprintf("%s_v%d%s", name, version, ext))
+	if used[p] {
+		p = path.Join(dir, fmt.Sprintf("%s_%s_v%d%s", name, doc.ID.String()[:8], version, ext))
+	}
+	used[p] = true
+	return p
+}
+
+func evidenceReadmeText(m *EvidenceManifest) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Verification evidence package %s\n", m.PackageID)
+	fmt.Fprintf(&b, "Project %s, reporting period %s to %s\n", m.ProjectID, m.PeriodStart.Format("2006-01-02"), m.PeriodEnd.Format("2006-01-02"))
+	fmt.Fprintf(&b, "Generated %s\n\n", m.GeneratedAt.Format(time.RFC3339))
+	b.WriteString("manifest.json lists every file in this package with its SHA-256.\n")
+	b.WriteString("manifest.json.p7s is a detached CMS signature over manifest.json;\n")
+	b.WriteString("signer-chain.pem holds the signing certificate and its chain.\n\n")
+	b.WriteString("To check the package offline:\n")
+	b.WriteString("  1. openssl cms -verify -binary -inform DER -in manifest.json.p7s \\\n")
+	b.WriteString("       -content manifest.json -CAfile <issuing CA> -purpose any -out /dev/null\n")
+	b.WriteString("  2. sha256sum each file listed in manifest.json and compare.\n")
+	b.WriteString("  3. Check that the package holds no files besides those listed and\n")
+	fmt.Fprintf(&b, "     %s, %s, %s and %s.\n", evidenceManifest, evidenceSignature, evidenceChain, evidenceReadme)
+	return b.Bytes()
+}
@@ -0,0 +1,121 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+func TestEvidenceVersion(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	doc := &Document{
+		ID: uuid.New(), CurrentVersion: 3, S3Key: "v3", UploadedAt: day(1),
+		Versions: []DocumentVersion{
+			{VersionNumber: 2, S3Key: "v2", UploadedAt: day(10)},
+			{VersionNumber: 3, S3Key: "v3", UploadedAt: day(20)},
+		},
+	}
+	tests := []struct {
+		name    string
+		version int
+		asOf    time.Time
+		want    int
+		tracked bool
+		ok      bool
+	}{
+		{"latest by period end", 0, day(15), 2, true, true},
+		{"current", 0, day(25), 3, true, true},
+		{"replaced original", 0, day(5), 1, false, true},
+		{"before first upload", 0, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), 0, false, false},
+		{"explicit version", 2, day(1), 2, true, true},
+		{"unknown version", 7, day(25), 0, false, false},
+	}
+	for _, tt := range tests {
+		src, ok := evidenceVersion(doc, tt.version, tt.asOf)
+		if ok != tt.ok || src.version != tt.want || (ok && src.tracked != tt.tracked) {
+			t.Errorf("%s: got %+v ok=%v", tt.name, src, ok)
+		}
+	}
+}
+
+func TestSelectEvidence(t *testing.T) {
+	end := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	pdd := Document{ID: uuid.New(), Name: "PDD", DocumentType: DocumentTypePDD, CurrentVersion: 1, S3Key: "pdd", UploadedAt: end.AddDate(0, -6, 0)}
+	late := Document{ID: uuid.New(), Name: "Later report", DocumentType: DocumentTypeMonitoringReport, CurrentVersion: 1, S3Key: "mr", UploadedAt: end.AddDate(0, 1, 0)}
+	docs := []Document{pdd, late}
+
+	m := &EvidenceManifest{PeriodEnd: end}
+	sel, err := selectEvidence(docs, &EvidencePackageRequest{}, m)
+	if err != nil || len(sel) != 1 || sel[0].doc.ID != pdd.ID || len(m.Omitted) != 0 {
+		t.Errorf("period selection: %+v %+v %v", sel, m.Omitted, err)
+	}
+
+	m = &EvidenceManifest{PeriodEnd: end}
+	sel, err = selectEvidence(docs, &EvidencePackageRequest{Documents: []EvidenceDocumentRef{{DocumentID: late.ID}}}, m)
+	if err != nil || len(sel) != 0 || len(m.Omitted) != 1 {
+		t.Errorf("explicit selection: %+v %+v %v", sel, m.Omitted, err)
+	}
+
+	_, err = selectEvidence(docs, &EvidencePackageRequest{Documents: []EvidenceDocumentRef{{DocumentID: uuid.New()}}}, &EvidenceManifest{PeriodEnd: end})
+	if !errors.Is(err, ErrInvalidEvidenceRequest) {
+		t.Errorf("foreign document: %v", err)
+	}
+}
+
+type fakeBoundaries map[uuid.UUID]json.RawMessage
+
+func (f fakeBoundaries) ProjectBoundaries(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]json.RawMessage, error) {
+	return f, nil
+}
+
+func TestPackEvidence(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(storage.NewURLSigner([]byte("k"), "http://localhost/objects"))
+	svc := &Service{storage: NewStorageService(store)}
+
+	content := []byte("monitoring data")
+	sum := sha256.Sum256(content)
+	if _, err := store.Upload(ctx, "mr.xlsx", bytes.NewReader(content), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	doc := &Document{ID: uuid.New(), Name: "Monitoring report 2026.xlsx", DocumentType: DocumentTypeMonitoringReport, FileType: FileTypeXLSX}
+	src := evidenceSource{version: 2, key: "mr.xlsx", hash: hex.EncodeToString(sum[:]), cid: "bafy", tracked: true}
+
+	used := map[string]bool{}
+	file, data, err := svc.packDocument(ctx, doc, src, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != "documents/monitoring_report/Monitoring_report_2026_v2.xlsx" || file.SHA256 != src.hash ||
+		file.IPFSCID != "bafy" || !bytes.Equal(data, content) || file.Signatures != nil {
+		t.Errorf("packed %+v", file)
+	}
+	if again, _, _ := svc.packDocument(ctx, doc, src, used); again.Path == file.Path {
+		t.Errorf("duplicate path %s", again.Path)
+	}
+
+	src.hash = hex.EncodeToString(make([]byte, 32))
+	if _, _, err := svc.packDocument(ctx, doc, src, used); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("tampered object: %v", err)
+	}
+
+	pid := uuid.New()
+	svc.boundaries = fakeBoundaries{pid: json.RawMessage(`{"type":"Point","coordinates":[1,2]}`)}
+	m := &EvidenceManifest{}
+	file, data, err = svc.packBoundary(ctx, pid, m)
+	if err != nil || file == nil || file.Kind != "boundary" || !json.Valid(data) {
+		t.Fatalf("boundary %+v %v", file, err)
+	}
+	if file, _, _ := svc.packBoundary(ctx, uuid.New(), m); file != nil || len(m.Omitted) != 1 {
+		t.Errorf("missing boundary: %+v %+v", file, m.Omitted)
+	}
+}
@@ -1,9 +1,20 @@
 package documents
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/textextract"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,12 +22,14 @@ import (
 
 // Handler holds the document service for use in HTTP handlers.
 type Handler struct {
-	svc *Service
+	svc   *Service
+	authz middleware.Authorizer
 }
 
-// NewHandler creates a new document Handler.
-func NewHandler(svc *Service) *Handler {
-	return &Handler{svc: svc}
+// NewHandler creates a new document Handler. authz resolves the caller's
+// project role for every document route.
+func NewHandler(svc *Service, authz middleware.Authorizer) *Handler {
+	return &Handler{svc: svc, authz: authz}
 }
 
 // Transition handles POST /api/v1/documents/:id/transition
@@ -30,11 +43,18 @@ func (h *Handler) Transition(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result, err := h.svc.AdvanceWorkflow(c.Request.Context(), id, &req, extractUserID(c))
+	access, _ := middleware.CurrentProjectAccess(c)
+	result, err := h.svc.AdvanceWorkflow(c.Request.Context(), id, &req, extractUserID(c), access)
 	if err != nil {
 		status := http.StatusBadRequest
 		if containsAny(err.Error(), "not found") {
 			status = http.StatusNotFound
+		} else if errors.Is(err, ErrForbidden) {
+			status = http.StatusForbidden
+		} else if errors.Is(err, ErrAlreadyDecided) {
+			status = http.StatusConflict
+		} else if errors.Is(err, ErrSigningDisabled) || errors.Is(err, ErrNoSigningCertificate) {
+			status = signingErrorStatus(err)
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
@@ -65,7 +85,11 @@ func (h *Handler) CreateWorkflowTemplate(c *gin.Context) {
 	}
 	wf, err := h.svc.CreateWorkflowTemplate(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidWorkflow) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, wf)
@@ -102,6 +126,226 @@ func (h *Handler) VerifySignature(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// SignDocument handles POST /api/v1/documents/:id/sign
+func (h *Handler) SignDocument(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	var req SignRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	result, err := h.svc.SignDocument(c.Request.Context(), id, &req, extractUserID(c), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(signingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// AddSigningCertificate handles POST /api/v1/documents/signing-certificates
+// Personal certificates need documents:approve, the project's own
+// certificate needs project:write.
+func (h *Handler) AddSigningCertificate(c *gin.Context) {
+	var req AddSigningCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	perm := middleware.PermProjectWrite
+	if req.Personal {
+		perm = middleware.PermDocumentsApprove
+	}
+	if !h.authorize(c, req.ProjectID, perm) {
+		return
+	}
+	sc, err := h.svc.AddSigningCertificate(c.Request.Context(), &req, extractUserID(c))
+	if err != nil {
+		c.JSON(signingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sc)
+}
+
+// ListSigningCertificates handles GET /api/v1/documents/signing-certificates?project_id=
+func (h *Handler) ListSigningCertificates(c *gin.Context) {
+	projectID := c.Query("project_id")
+	if !h.authorize(c, projectID, middleware.PermDocumentsRead) {
+		return
+	}
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project_id"})
+		return
+	}
+	certs, err := h.svc.ListSigningCertificates(c.Request.Context(), pid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"certificates": certs, "total": len(certs)})
+}
+
+// RevokeSigningCertificate handles DELETE /api/v1/documents/signing-certificates/:certId
+func (h *Handler) RevokeSigningCertificate(c *gin.Context) {
+	id, err := parseUUID(c, "certId")
+	if err != nil {
+		return
+	}
+	ctx := c.Request.Context()
+	sc, err := h.svc.GetSigningCertificate(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.authorize(c, sc.ProjectID.String(), middleware.PermDocumentsRead) {
+		return
+	}
+	access, _ := middleware.CurrentProjectAccess(c)
+	sc, err = h.svc.RevokeSigningCertificate(ctx, id, access)
+	if err != nil {
+		c.JSON(signingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sc)
+}
+
+// AddTrustAnchors handles POST /api/v1/documents/trust-anchors
+func (h *Handler) AddTrustAnchors(c *gin.Context) {
+	var req AddTrustAnchorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.authorize(c, req.ProjectID, middleware.PermProjectWrite) {
+		return
+	}
+	b, err := h.svc.AddTrustAnchorBundle(c.Request.Context(), &req, extractUserID(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidTrustAnchors) || containsAny(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, b)
+}
+
+// ExportEvidencePackage handles POST /api/v1/documents/evidence-packages.
+// The package is signed on behalf of the project, so approvers build it.
+func (h *Handler) ExportEvidencePackage(c *gin.Context) {
+	var req EvidencePackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.authorize(c, req.ProjectID, middleware.PermDocumentsApprove) {
+		return
+	}
+	var buf bytes.Buffer
+	res, err := h.svc.ExportEvidencePackage(c.Request.Context(), &req, extractUserID(c), &buf)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidEvidenceRequest), errors.Is(err, ErrNoSigningCertificate):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrSigningDisabled):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, ErrIntegrity):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="evidence_%s_%s.zip"`,
+		res.Manifest.PeriodEnd.Format("2006-01-02"), res.Package.ID))
+	c.Header("X-Evidence-Package-ID", res.Package.ID.String())
+	c.Header("X-Manifest-SHA256", res.Package.ManifestSHA256)
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// ListEvidencePackages handles GET /api/v1/documents/evidence-packages?project_id=
+func (h *Handler) ListEvidencePackages(c *gin.Context) {
+	projectID := c.Query("project_id")
+	if !h.authorize(c, projectID, middleware.PermDocumentsRead) {
+		return
+	}
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project_id"})
+		return
+	}
+	pkgs, err := h.svc.ListEvidencePackages(c.Request.Context(), pid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"evidence_packages": pkgs, "total": len(pkgs)})
+}
+
+// ListTrustAnchors handles GET /api/v1/documents/trust-anchors?project_id=
+func (h *Handler) ListTrustAnchors(c *gin.Context) {
+	projectID := c.Query("project_id")
+	if !h.authorize(c, projectID, middleware.PermDocumentsRead) {
+		return
+	}
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project_id"})
+		return
+	}
+	bundles, err := h.svc.ListTrustAnchorBundles(c.Request.Context(), pid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trust_anchors": bundles, "total": len(bundles)})
+}
+
+// DeleteTrustAnchors handles DELETE /api/v1/documents/trust-anchors/:bundleId
+func (h *Handler) DeleteTrustAnchors(c *gin.Context) {
+	id, err := parseUUID(c, "bundleId")
+	if err != nil {
+		return
+	}
+	ctx := c.Request.Context()
+	b, err := h.svc.GetTrustAnchorBundle(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.authorize(c, b.ProjectID.String(), middleware.PermProjectWrite) {
+		return
+	}
+	if err := h.svc.DeleteTrustAnchorBundle(ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "trust anchor bundle deleted"})
+}
+
+// signingErrorStatus maps signing and certificate errors to HTTP statuses.
+func signingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrSigningDisabled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrNoSigningCertificate):
+		return http.StatusConflict
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, security.ErrSignerCertificate), errors.Is(err, security.ErrUnsupportedPDF), errors.Is(err, ErrNotSignable):
+		return http.StatusBadRequest
+	case containsAny(err.Error(), "not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
 // GeneratePDF handles POST /api/v1/documents/generate-pdf
 func (h *Handler) GeneratePDF(c *gin.Context) {
 	var req GeneratePDFRequest
@@ -109,6 +353,9 @@ func (h *Handler) GeneratePDF(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.authorize(c, req.ProjectID, middleware.PermDocumentsWrite) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	userID := extractUserID(c)
@@ -136,6 +383,9 @@ func (h *Handler) Upload(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.authorize(c, req.ProjectID, middleware.PermDocumentsWrite) {
+		return
+	}
 
 	fh, err := c.FormFile("file")
 	if err != nil {
@@ -165,6 +415,12 @@ func (h *Handler) List(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Only platform admins may list across projects.
+	if filter.ProjectID != "" || !middleware.IsPlatformAdmin(c) {
+		if !h.authorize(c, filter.ProjectID, middleware.PermDocumentsRead) {
+			return
+		}
+	}
 
 	result, err := h.svc.List(c.Request.Context(), filter)
 	if err != nil {
@@ -175,7 +431,8 @@ func (h *Handler) List(c *gin.Context) {
 }
 
 // Download handles GET /api/v1/documents/:id
-// Returns a redirect to a presigned S3 URL so the client streams directly from S3.
+// Returns a redirect to a signed, expiring URL: an S3 presigned URL, or the
+// API's own object route for the local and in-memory backends.
 func (h *Handler) Download(c *gin.Context) {
 	id, err := parseUUID(c, "id")
 	if err != nil {
@@ -189,13 +446,88 @@ func (h *Handler) Download(c *gin.Context) {
 
 	url, _, err := h.svc.GenerateDownloadURL(ctx, id, userID, ipAddr, ua)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		status := http.StatusNotFound
+		if errors.Is(err, ErrIntegrity) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
+// ServeObject handles GET /api/v1/storage/objects/*key, the target of signed
+// download URLs from the local and in-memory backends. The signature is the
+// credential, so the route sits outside token authentication like an S3
+// presigned URL.
+func (h *Handler) ServeObject(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	rc, size, err := h.svc.storage.OpenSigned(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrURLExpired), errors.Is(err, storage.ErrBadSignature):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, storage.ErrObjectNotFound), errors.Is(err, storage.ErrInvalidKey), errors.Is(err, ErrNotServed):
+			c.JSON(http.StatusNotFound, gin.H{"error": "object not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", path.Base(key)),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
+// CheckIntegrity handles GET /api/v1/documents/:id/integrity
+func (h *Handler) CheckIntegrity(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	report, err := h.svc.CheckIntegrity(c.Request.Context(), id, extractUserID(c), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if containsAny(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ReindexText handles POST /api/v1/documents/:id/reindex
+func (h *Handler) ReindexText(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	doc, err := h.svc.ReindexText(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrTextIndexingDisabled):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, textextract.ErrUnsupportedFormat):
+			status = http.StatusBadRequest
+		case containsAny(err.Error(), "not found"):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"document_id": doc.ID, "text_status": doc.TextStatus})
+}
+
 // GetMetadata handles GET /api/v1/documents/:id/metadata
 func (h *Handler) GetMetadata(c *gin.Context) {
 	id, err := parseUUID(c, "id")
@@ -263,6 +595,388 @@ func (h *Handler) UploadVersion(c *gin.Context) {
 	})
 }
 
+// tusVersion is the resumable upload protocol version the upload routes speak.
+const tusVersion = "1.0.0"
+
+// CreateUpload handles POST /api/v1/documents/uploads. It opens a resumable
+// upload session for a new document, or for a new version when
+// document_id is set. Chunks are then sent with PATCH to the Location.
+func (h *Handler) CreateUpload(c *gin.Context) {
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DocumentID != "" {
+		docID, err := uuid.Parse(req.DocumentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
+			return
+		}
+		projectID, err := h.svc.ProjectIDOf(c.Request.Context(), docID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		req.ProjectID = projectID.String()
+	}
+	if !h.authorize(c, req.ProjectID, middleware.PermDocumentsWrite) {
+		return
+	}
+	u, err := h.svc.CreateUpload(c.Request.Context(), &req, extractUserID(c))
+	if err != nil {
+		h.uploadError(c, nil, err)
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Location", "/api/v1/documents/uploads/"+u.ID.String())
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, gin.H{"upload": u})
+}
+
+// GetUpload handles HEAD and GET /api/v1/documents/uploads/:uploadId. The
+// Upload-Offset header tells an interrupted client where to resume.
+func (h *Handler) GetUpload(c *gin.Context) {
+	u, ok := h.loadUpload(c, middleware.PermDocumentsWrite)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, u)
+	c.Header("Cache-Control", "no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload": u})
+}
+
+// WriteChunk handles PATCH /api/v1/documents/uploads/:uploadId. The body is
+// the chunk starting at Upload-Offset, checked against Upload-Checksum
+// ("sha256 <base64 digest>").
+func (h *Handler) WriteChunk(c *gin.Context) {
+	if mt, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mt != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+	u, ok := h.loadUpload(c, middleware.PermDocumentsWrite)
+	if !ok {
+		return
+	}
+
+	// A chunk may take longer than the server-wide timeouts on a slow link.
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(chunkDeadline)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxChunkSize)
+
+	u, err = h.svc.WriteChunk(c.Request.Context(), u.ID, extractUserID(c), offset, c.GetHeader("Upload-Checksum"), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("chunks are limited to %d MB", MaxChunkSize>>20)})
+			return
+		}
+		h.uploadError(c, u, err)
+		return
+	}
+	setUploadHeaders(c, u)
+	c.Status(http.StatusNoContent)
+}
+
+// AbortUpload handles DELETE /api/v1/documents/uploads/:uploadId
+func (h *Handler) AbortUpload(c *gin.Context) {
+	u, ok := h.loadUpload(c, middleware.PermDocumentsWrite)
+	if !ok {
+		return
+	}
+	u, err := h.svc.AbortUpload(c.Request.Context(), u.ID, extractUserID(c))
+	if err != nil {
+		h.uploadError(c, u, err)
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Status(http.StatusNoContent)
+}
+
+// loadUpload fetches the caller's :uploadId session and checks perm on its
+// project, writing the error response on failure.
+func (h *Handler) loadUpload(c *gin.Context, perm string) (*UploadSession, bool) {
+	id, err := parseUUID(c, "uploadId")
+	if err != nil {
+		return nil, false
+	}
+	u, err := h.svc.GetUpload(c.Request.Context(), id, extractUserID(c))
+	if err != nil {
+		h.uploadError(c, nil, err)
+		return nil, false
+	}
+	if !h.authorize(c, u.ProjectID.String(), perm) {
+		return nil, false
+	}
+	return u, true
+}
+
+// uploadError maps upload session errors to responses. u, when known,
+// supplies the offset the client should resume from.
+func (h *Handler) uploadError(c *gin.Context, u *UploadSession, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrInvalidUpload):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrUploadOffset):
+		status = http.StatusConflict
+	case errors.Is(err, ErrChunkChecksum):
+		status = 460 // tus "Checksum Mismatch"
+	case errors.Is(err, ErrUploadClosed):
+		status = http.StatusGone
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	case containsAny(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	if u != nil {
+		setUploadHeaders(c, u)
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+func setUploadHeaders(c *gin.Context, u *UploadSession) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.TotalSize, 10))
+	c.Header("Upload-Status", string(u.Status))
+}
+
+// CreateShareLink handles POST /api/v1/documents/:id/share-links. The
+// token is only returned in this response.
+func (h *Handler) CreateShareLink(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	var req CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.svc.CreateShareLink(c.Request.Context(), id, &req, extractUserID(c), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidShareLink):
+			status = http.StatusBadRequest
+		case containsAny(err.Error(), "not found"):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListShareLinks handles GET /api/v1/documents/:id/share-links?all=true
+func (h *Handler) ListShareLinks(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	links, err := h.svc.ListShareLinks(c.Request.Context(), id, c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"document_id": id, "share_links": links, "total": len(links)})
+}
+
+// RevokeShareLink handles DELETE /api/v1/documents/:id/share-links/:linkId
+func (h *Handler) RevokeShareLink(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	linkID, err := parseUUID(c, "linkId")
+	if err != nil {
+		return
+	}
+	if err := h.svc.RevokeShareLink(c.Request.Context(), id, linkID, extractUserID(c), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrShareLinkNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "share link revoked"})
+}
+
+// ViewShared handles GET /api/v1/share/:token. A link's password is sent
+// in the X-Share-Password header, never in the URL.
+func (h *Handler) ViewShared(c *gin.Context) {
+	view, err := h.svc.ViewSharedDocument(c.Request.Context(), c.Param("token"), c.GetHeader("X-Share-Password"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, view)
+}
+
+// DownloadShared handles GET and POST /api/v1/share/:token/download. It
+// redirects to a short-lived URL for the file. The password comes from the
+// X-Share-Password header, or the "password" field of a posted form.
+func (h *Handler) DownloadShared(c *gin.Context) {
+	password := c.GetHeader("X-Share-Password")
+	if password == "" && c.Request.Method == http.MethodPost {
+		password = c.PostForm("password")
+	}
+	url, _, err := h.svc.DownloadShared(c.Request.Context(), c.Param("token"), password, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(shareErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusSeeOther, url)
+}
+
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrShareLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrShareLinkUnavailable):
+		return http.StatusGone
+	case errors.Is(err, ErrSharePassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrIntegrity):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ListAccessLogs handles GET /api/v1/documents/access-logs, the access
+// history of a project filtered by document, user, share link, action,
+// address and period. Only platform admins may omit project_id.
+func (h *Handler) ListAccessLogs(c *gin.Context) {
+	f, ok := h.accessFilter(c)
+	if !ok {
+		return
+	}
+	page, err := h.svc.QueryAccessLogs(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(accessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// DocumentAccessLogs handles GET /api/v1/documents/:id/access-logs
+func (h *Handler) DocumentAccessLogs(c *gin.Context) {
+	id, err := parseUUID(c, "id")
+	if err != nil {
+		return
+	}
+	var f AccessLogFilter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	f.ProjectID, f.DocumentID = "", id.String()
+	page, err := h.svc.QueryAccessLogs(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(accessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ExportAccessLogs handles GET /api/v1/documents/access-logs/export, the
+// filtered access history as CSV for client audits.
+func (h *Handler) ExportAccessLogs(c *gin.Context) {
+	f, ok := h.accessFilter(c)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	rows, truncated, err := h.svc.ExportAccessLogsCSV(c.Request.Context(), f, &buf)
+	if err != nil {
+		c.JSON(accessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="document_access_%s.csv"`, time.Now().UTC().Format("20060102")))
+	c.Header("X-Total-Rows", strconv.Itoa(rows))
+	if truncated {
+		c.Header("X-Truncated", "true")
+	}
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// AccessStats handles GET /api/v1/documents/access-logs/stats
+func (h *Handler) AccessStats(c *gin.Context) {
+	f, ok := h.accessFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.svc.AccessStatistics(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(accessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// AccessAnomalies handles GET /api/v1/documents/access-logs/anomalies.
+// With format=csv the report is returned as a CSV file.
+func (h *Handler) AccessAnomalies(c *gin.Context) {
+	f, ok := h.accessFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.DetectAccessAnomalies(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(accessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	var buf bytes.Buffer
+	if err := WriteAnomaliesCSV(report, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="document_access_anomalies_%s.csv"`, report.To.Format("20060102")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// accessFilter binds an access log filter and authorizes it: access
+// history is visible to those who approve the project's documents.
+func (h *Handler) accessFilter(c *gin.Context) (AccessLogFilter, bool) {
+	var f AccessLogFilter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return f, false
+	}
+	if f.ProjectID != "" || !middleware.IsPlatformAdmin(c) {
+		if !h.authorize(c, f.ProjectID, middleware.PermDocumentsApprove) {
+			return f, false
+		}
+	}
+	return f, true
+}
+
+func accessErrorStatus(err error) int {
+	if errors.Is(err, ErrInvalidAccessQuery) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // ListVersions handles GET /api/v1/documents/:id/versions
 func (h *Handler) ListVersions(c *gin.Context) {
 	id, err := parseUUID(c, "id")
@@ -314,18 +1028,40 @@ func parseUUID(c *gin.Context, param string) (uuid.UUID, error) {
 	return id, nil
 }
 
-// extractUserID reads the X-User-ID header set by auth middleware.
-// Returns nil if the header is absent (unauthenticated request).
+// extractUserID returns the caller's ID from the verified access token.
+// Returns nil if the request is unauthenticated.
 func extractUserID(c *gin.Context) *uuid.UUID {
-	raw := c.GetHeader("X-User-ID")
-	if raw == "" {
-		return nil
+	return middleware.CurrentUserUUID(c)
+}
+
+// authorize checks perm on a project named in the request and writes the
+// error response when it is missing.
+func (h *Handler) authorize(c *gin.Context, projectID, perm string) bool {
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_id is required"})
+		return false
+	}
+	access, status, err := middleware.Authorize(c, h.authz, projectID, perm)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return false
+	}
+	c.Set(middleware.ContextProjectAccess, access)
+	return true
+}
+
+// documentProject resolves the project owning the :id document so
+// document-scoped routes can be authorized by project role.
+func (h *Handler) documentProject(c *gin.Context) (string, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return "", errors.New("invalid id format")
 	}
-	id, err := uuid.Parse(raw)
+	projectID, err := h.svc.ProjectIDOf(c.Request.Context(), id)
 	if err != nil {
-		return nil
+		return "", errors.New("document not found")
 	}
-	return &id
+	return projectID.String(), nil
 }
 
 // parseIntParam extracts an integer path parameter, writing a 400 error on failure.
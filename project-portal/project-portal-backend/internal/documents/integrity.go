@@ -0,0 +1,265 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ─── Content Integrity ────────────────────────────────────────────────────────
+
+// ErrIntegrity is returned when a stored object is missing or no longer
+// matches the SHA-256 recorded at upload.
+var ErrIntegrity = errors.New("document content failed integrity verification")
+
+// ObjectIntegrity is the check result for one stored object.
+type ObjectIntegrity struct {
+	Version      int             `json:"version"`
+	Key          string          `json:"key"`
+	StoredSHA256 string          `json:"stored_sha256,omitempty"`
+	ObjectSHA256 string          `json:"object_sha256,omitempty"`
+	StoredSize   int64           `json:"stored_size"`
+	ObjectSize   int64           `json:"object_size"`
+	Status       IntegrityStatus `json:"status,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// IntegrityReport is returned from GET /api/v1/documents/:id/integrity. It
+// covers the current file, every stored version and, when the document is
+// pinned, the CID of the current file compared with the recorded one.
+type IntegrityReport struct {
+	DocumentID uuid.UUID         `json:"document_id"`
+	Status     IntegrityStatus   `json:"status,omitempty"`
+	Current    ObjectIntegrity   `json:"current"`
+	Versions   []ObjectIntegrity `json:"versions,omitempty"`
+	IPFSCID    string            `json:"ipfs_cid,omitempty"`
+	ObjectCID  string            `json:"object_cid,omitempty"`
+	IPFSMatch  *bool             `json:"ipfs_match,omitempty"`
+	IPFSError  string            `json:"ipfs_error,omitempty"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+// CheckIntegrity re-hashes the document's objects, compares them with the
+// stored digests and the IPFS CID, and records the outcome. Objects
+// uploaded before hashing existed adopt their current digest as a baseline.
+func (s *Service) CheckIntegrity(ctx context.Context, docID uuid.UUID, userID *uuid.UUID, ipAddr, ua string) (*IntegrityReport, error) {
+	doc, err := s.repo.FindByID(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	report, err := s.checkDocument(ctx, doc, true)
+	if err != nil {
+		return nil, err
+	}
+	s.logIntegrity(ctx, doc.ID, userID, ipAddr, ua, report)
+	return report, nil
+}
+
+// checkDocument runs the checks behind CheckIntegrity and the sweep.
+func (s *Service) checkDocument(ctx context.Context, doc *Document, withIPFS bool) (*IntegrityReport, error) {
+	versions, err := s.repo.FindVersionsByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	report := &IntegrityReport{DocumentID: doc.ID, IPFSCID: doc.IPFSCID, CheckedAt: now}
+
+	// The latest version shares its key with the document; hash each key once.
+	checked := map[string]ObjectIntegrity{}
+	check := func(version int, key, stored string, size int64) ObjectIntegrity {
+		res, ok := checked[key]
+		if !ok || res.StoredSHA256 != stored {
+			res = s.checkObject(ctx, key, stored, size)
+			checked[key] = res
+		}
+		res.Version = version
+		return res
+	}
+
+	report.Current = check(doc.CurrentVersion, doc.S3Key, doc.ContentHash, doc.FileSize)
+	if report.Current.Status == IntegrityBaseline {
+		if err := s.repo.SetContentHash(ctx, doc.ID, report.Current.ObjectSHA256); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range versions {
+		res := check(v.VersionNumber, v.S3Key, v.ContentHash, v.FileSize)
+		if res.Status == IntegrityBaseline {
+			if err := s.repo.SetVersionContentHash(ctx, v.ID, res.ObjectSHA256); err != nil {
+				return nil, err
+			}
+		}
+		report.Versions = append(report.Versions, res)
+	}
+
+	report.Status = report.Current.Status
+	for _, v := range report.Versions {
+		report.Status = worseIntegrity(report.Status, v.Status)
+	}
+
+	if withIPFS && doc.IPFSCID != "" && s.ipfs != nil && report.Current.Status != IntegrityMissing {
+		s.compareCID(ctx, doc, report)
+	}
+
+	if report.Status != "" {
+		if err := s.repo.SetIntegrityStatus(ctx, doc.ID, report.Status, now); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// checkObject hashes one object and compares it with the stored digest.
+// Read failures other than a missing object are reported without a status
+// so a flaky backend is not mistaken for tampering.
+func (s *Service) checkObject(ctx context.Context, key, stored string, size int64) ObjectIntegrity {
+	res := ObjectIntegrity{Key: key, StoredSHA256: stored, StoredSize: size}
+	sum, n, err := s.storage.HashObject(ctx, key)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound):
+		res.Status = IntegrityMissing
+	case err != nil:
+		res.Error = err.Error()
+	case stored == "":
+		res.ObjectSHA256, res.ObjectSize, res.Status = sum, n, IntegrityBaseline
+	case sum == stored:
+		res.ObjectSHA256, res.ObjectSize, res.Status = sum, n, IntegrityVerified
+	default:
+		res.ObjectSHA256, res.ObjectSize, res.Status = sum, n, IntegrityMismatch
+	}
+	return res
+}
+
+// compareCID asks the IPFS node for the CID of the current object and
+// compares it with the CID recorded when the document was pinned.
+func (s *Service) compareCID(ctx context.Context, doc *Document, report *IntegrityReport) {
+	rc, _, err := s.storage.DownloadStream(ctx, doc.S3Key)
+	if err != nil {
+		report.IPFSError = err.Error()
+		return
+	}
+	defer rc.Close()
+	cid, err := s.ipfs.ComputeCID(ctx, doc.ID.String(), rc)
+	if err != nil {
+		report.IPFSError = err.Error()
+		return
+	}
+	match := cid == doc.IPFSCID
+	report.ObjectCID, report.IPFSMatch = cid, &match
+	if !match {
+		report.Status = worseIntegrity(report.Status, IntegrityMismatch)
+	}
+}
+
+// verifyForDownload re-hashes the current object before a download URL is
+// issued. Documents without a recorded digest are served unchecked; the
+// sweep records their baseline.
+func (s *Service) verifyForDownload(ctx context.Context, doc *Document, userID *uuid.UUID, ipAddr, ua string) error {
+	if doc.ContentHash == "" {
+		return nil
+	}
+	res := s.checkObject(ctx, doc.S3Key, doc.ContentHash, doc.FileSize)
+	if res.Error != "" {
+		return fmt.Errorf("failed to verify document content: %s", res.Error)
+	}
+	if res.Status == IntegrityVerified {
+		return nil
+	}
+	now := time.Now().UTC()
+	if err := s.repo.SetIntegrityStatus(ctx, doc.ID, res.Status, now); err != nil {
+		log.Printf("WARNING: failed to record integrity status for document %s: %v", doc.ID, err)
+	}
+	s.logIntegrity(ctx, doc.ID, userID, ipAddr, ua, &IntegrityReport{DocumentID: doc.ID, Status: res.Status, Current: res, CheckedAt: now})
+	return fmt.Errorf("%w: %s", ErrIntegrity, res.Status)
+}
+
+// VerifyIntegrityBatch checks up to limit documents, least recently checked
+// first, skipping those checked within minAge. It returns how many were
+// checked and how many failed.
+func (s *Service) VerifyIntegrityBatch(ctx context.Context, limit int, minAge time.Duration) (checked, failed int, err error) {
+	docs, err := s.repo.FindForIntegrityCheck(ctx, limit, time.Now().Add(-minAge))
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := range docs {
+		report, err := s.checkDocument(ctx, &docs[i], false)
+		if err != nil {
+			log.Printf("WARNING: integrity check failed for document %s: %v", docs[i].ID, err)
+			continue
+		}
+		checked++
+		if report.Status == IntegrityMismatch || report.Status == IntegrityMissing {
+			failed++
+			log.Printf("🚨 Document %s failed integrity verification: %s", docs[i].ID, report.Status)
+			s.logIntegrity(ctx, docs[i].ID, nil, "", "", report)
+		}
+	}
+	return checked, failed, nil
+}
+
+// StartIntegritySweep verifies a batch of documents every interval until
+// ctx is done. Each document is re-checked at most once a day.
+func (s *Service) StartIntegritySweep(ctx context.Context, interval time.Duration, batch int) {
+	if interval <= 0 || batch <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checked, failed, err := s.VerifyIntegrityBatch(ctx, batch, 24*time.Hour)
+				if err != nil {
+					log.Printf("WARNING: document integrity sweep failed: %v", err)
+					continue
+				}
+				if checked > 0 {
+					log.Printf("🔒 Integrity sweep checked %d document(s), %d failed", checked, failed)
+				}
+			}
+		}
+	}()
+}
+
+// logIntegrity records a check in the access log, as INTEGRITY_FAILED when
+// any object is missing or altered.
+func (s *Service) logIntegrity(ctx context.Context, docID uuid.UUID, userID *uuid.UUID, ipAddr, ua string, report *IntegrityReport) {
+	action := ActionIntegrityCheck
+	if report.Status == IntegrityMismatch || report.Status == IntegrityMissing {
+		action = ActionIntegrityFailed
+	}
+	details := datatypes.JSON("{}")
+	if raw, err := json.Marshal(map[string]any{"status": report.Status, "current": report.Current, "versions": report.Versions, "ipfs_match": report.IPFSMatch}); err == nil {
+		details = raw
+	}
+	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
+		DocumentID:  docID,
+		UserID:      userID,
+		Action:      action,
+		IPAddress:   ipAddr,
+		UserAgent:   ua,
+		Details:     details,
+		PerformedAt: time.Now().UTC(),
+	})
+}
+
+// worseIntegrity returns the more serious of two statuses. An empty status
+// (check could not run) never hides a result.
+func worseIntegrity(a, b IntegrityStatus) IntegrityStatus {
+	rank := map[IntegrityStatus]int{"": 0, IntegrityVerified: 1, IntegrityBaseline: 2, IntegrityMissing: 3, IntegrityMismatch: 4}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,52 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+)
+
+func TestCheckObject(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(storage.NewURLSigner([]byte("k"), "http://localhost/objects"))
+	svc := &Service{storage: NewStorageService(store)}
+
+	content := []byte("verification certificate")
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	if _, err := store.Upload(ctx, "cert.pdf", bytes.NewReader(content), "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := svc.checkObject(ctx, "cert.pdf", digest, int64(len(content))); res.Status != IntegrityVerified || res.ObjectSize != int64(len(content)) {
+		t.Errorf("unchanged object: %+v", res)
+	}
+	if res := svc.checkObject(ctx, "cert.pdf", "", 0); res.Status != IntegrityBaseline || res.ObjectSHA256 != digest {
+		t.Errorf("object without digest: %+v", res)
+	}
+	if res := svc.checkObject(ctx, "missing.pdf", digest, 0); res.Status != IntegrityMissing {
+		t.Errorf("missing object: %+v", res)
+	}
+
+	// Swap the object in the bucket.
+	if _, err := store.Upload(ctx, "cert.pdf", strings.NewReader("forged certificate"), "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if res := svc.checkObject(ctx, "cert.pdf", digest, int64(len(content))); res.Status != IntegrityMismatch {
+		t.Errorf("swapped object: %+v", res)
+	}
+}
+
+func TestWorseIntegrity(t *testing.T) {
+	if got := worseIntegrity(IntegrityVerified, IntegrityMismatch); got != IntegrityMismatch {
+		t.Errorf("got %q", got)
+	}
+	if got := worseIntegrity(IntegrityMissing, ""); got != IntegrityMissing {
+		t.Errorf("unknown result hid a failure: %q", got)
+	}
+}
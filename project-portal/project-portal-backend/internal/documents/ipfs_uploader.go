@@ -3,6 +3,7 @@ package documents
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
 )
@@ -30,3 +31,16 @@ func (u *IPFSUploader) PinDocument(ctx context.Context, docID string, data []byt
 	}
 	return result.Hash, nil
 }
+
+// ComputeCID returns the CID content would be pinned under, without pinning
+// it.
+func (u *IPFSUploader) ComputeCID(ctx context.Context, docID string, r io.Reader) (string, error) {
+	if u == nil || u.client == nil {
+		return "", nil // IPFS disabled — no-op
+	}
+	cid, err := u.client.ComputeCID(ctx, docID+".pdf", r)
+	if err != nil {
+		return "", fmt.Errorf("ipfs hash failed: %w", err)
+	}
+	return cid, nil
+}
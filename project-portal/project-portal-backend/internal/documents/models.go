@@ -52,21 +52,99 @@ const (
 	ActionDelete          AccessAction = "DELETE"
 	ActionVersionUpload   AccessAction = "VERSION_UPLOAD"
 	ActionVerifySignature AccessAction = "VERIFY_SIGNATURE"
+	ActionIntegrityCheck  AccessAction = "INTEGRITY_CHECK"
+	ActionIntegrityFailed AccessAction = "INTEGRITY_FAILED"
+	ActionSign            AccessAction = "SIGN"
+	ActionEvidenceExport  AccessAction = "EVIDENCE_EXPORT"
+	ActionShareCreate     AccessAction = "SHARE_LINK_CREATE"
+	ActionShareRevoke     AccessAction = "SHARE_LINK_REVOKE"
+	ActionShareView       AccessAction = "SHARE_LINK_VIEW"
+	ActionShareDownload   AccessAction = "SHARE_LINK_DOWNLOAD"
+	ActionShareDenied     AccessAction = "SHARE_LINK_DENIED"
 )
 
-// DocumentWorkflow defines an approval flow template.
+// IntegrityStatus is the outcome of comparing stored objects with their
+// recorded SHA-256 digests.
+type IntegrityStatus string
+
+const (
+	IntegrityVerified IntegrityStatus = "verified"
+	IntegrityMismatch IntegrityStatus = "mismatch"
+	IntegrityMissing  IntegrityStatus = "missing"
+	IntegrityBaseline IntegrityStatus = "baseline" // no digest was recorded; the current one was adopted
+)
+
+// TextStatus tracks full-text extraction of a document's current file.
+type TextStatus string
+
+const (
+	TextPending     TextStatus = "pending"
+	TextIndexed     TextStatus = "indexed"
+	TextFailed      TextStatus = "failed"
+	TextUnsupported TextStatus = "unsupported" // file type without an extractor
+)
+
+// DocumentWorkflow defines an approval flow template. Steps holds the
+// template's []WorkflowStep; a template without steps uses the built-in
+// review/approve transitions. AutoAssign templates are attached to new
+// uploads of their DocumentType.
 type DocumentWorkflow struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	Name         string         `gorm:"size:255;not null" json:"name"`
 	Description  string         `gorm:"type:text" json:"description"`
 	DocumentType DocumentType   `gorm:"size:100;not null" json:"document_type"`
 	Steps        datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"steps"`
+	AutoAssign   bool           `gorm:"not null;default:false" json:"auto_assign"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 }
 
 func (DocumentWorkflow) TableName() string { return "document_workflows" }
 
+// DocumentWorkflowRun tracks a document's progress through its workflow
+// template. Round increases on every submission so approvals from an earlier
+// submission no longer count.
+type DocumentWorkflowRun struct {
+	DocumentID    uuid.UUID  `gorm:"type:uuid;primaryKey" json:"document_id"`
+	WorkflowID    uuid.UUID  `gorm:"type:uuid;not null" json:"workflow_id"`
+	Round         int        `gorm:"not null;default:1" json:"round"`
+	StepIndex     int        `gorm:"not null;default:0" json:"step_index"`
+	StepStartedAt time.Time  `gorm:"not null" json:"step_started_at"`
+	DueAt         *time.Time `gorm:"index" json:"due_at,omitempty"`
+	EscalatedAt   *time.Time `json:"escalated_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (DocumentWorkflowRun) TableName() string { return "document_workflow_runs" }
+
+// ApprovalDecision is what an approver decided on a workflow step.
+type ApprovalDecision string
+
+const (
+	DecisionApprove   ApprovalDecision = "approve"
+	DecisionReject    ApprovalDecision = "reject"
+	DecisionSendBack  ApprovalDecision = "send_back"
+	DecisionEscalated ApprovalDecision = "escalated" // recorded by the SLA sweep
+)
+
+// DocumentApproval is one decision on a workflow step. Together they form
+// the document's approval history.
+type DocumentApproval struct {
+	ID         uuid.UUID        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DocumentID uuid.UUID        `gorm:"type:uuid;not null;index" json:"document_id"`
+	WorkflowID uuid.UUID        `gorm:"type:uuid;not null" json:"workflow_id"`
+	Round      int              `gorm:"not null" json:"round"`
+	StepIndex  int              `gorm:"not null" json:"step_index"`
+	StepName   string           `gorm:"size:255;not null" json:"step_name"`
+	UserID     *uuid.UUID       `gorm:"type:uuid" json:"user_id,omitempty"`
+	Role       string           `gorm:"size:50" json:"role,omitempty"`
+	Decision   ApprovalDecision `gorm:"size:20;not null" json:"decision"`
+	Comment    string           `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+func (DocumentApproval) TableName() string { return "document_approvals" }
+
 // Document is the core document record.
 type Document struct {
 	ID             uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
@@ -79,6 +157,8 @@ type Document struct {
 	S3Key          string         `gorm:"size:1000;not null" json:"s3_key"`
 	S3Bucket       string         `gorm:"size:255;not null" json:"s3_bucket"`
 	IPFSCID        string         `gorm:"size:100" json:"ipfs_cid,omitempty"`
+	ContentHash    string         `gorm:"size:64;index" json:"content_hash,omitempty"` // SHA-256 hex of the current file
+	DuplicateOf    *uuid.UUID     `gorm:"type:uuid" json:"duplicate_of,omitempty"`     // earlier document in the project with identical content
 	CurrentVersion int            `gorm:"default:1" json:"current_version"`
 	Status         DocumentStatus `gorm:"size:50;default:'draft';index" json:"status"`
 	WorkflowID     *uuid.UUID     `gorm:"type:uuid" json:"workflow_id,omitempty"`
@@ -87,6 +167,17 @@ type Document struct {
 	DeletedAt      *time.Time     `gorm:"index" json:"deleted_at,omitempty"`
 	Metadata       datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"metadata"`
 
+	// Result of the last content check against the stored hashes.
+	IntegrityStatus    IntegrityStatus `gorm:"size:20" json:"integrity_status,omitempty"`
+	IntegrityCheckedAt *time.Time      `json:"integrity_checked_at,omitempty"`
+
+	// Full-text extraction of the current file. The text itself is kept in
+	// the search index, one entry per page or sheet.
+	TextStatus      TextStatus `gorm:"size:20;index" json:"text_status,omitempty"`
+	TextVersion     int        `json:"text_version,omitempty"` // version the indexed text came from
+	TextExtractedAt *time.Time `json:"text_extracted_at,omitempty"`
+	TextError       string     `gorm:"type:text" json:"text_error,omitempty"`
+
 	// Associations (loaded on demand)
 	Workflow *DocumentWorkflow `gorm:"foreignKey:WorkflowID" json:"workflow,omitempty"`
 	Versions []DocumentVersion `gorm:"foreignKey:DocumentID" json:"versions,omitempty"`
@@ -103,6 +194,8 @@ type DocumentVersion struct {
 	S3Bucket      string     `gorm:"size:255;not null" json:"s3_bucket"`
 	FileSize      int64      `gorm:"not null;default:0" json:"file_size"`
 	IPFSCID       string     `gorm:"size:100" json:"ipfs_cid,omitempty"`
+	ContentHash   string     `gorm:"size:64;index" json:"content_hash,omitempty"`
+	DuplicateOf   *uuid.UUID `gorm:"type:uuid" json:"duplicate_of,omitempty"`
 	ChangeSummary string     `gorm:"type:text" json:"change_summary,omitempty"`
 	UploadedBy    *uuid.UUID `gorm:"type:uuid" json:"uploaded_by,omitempty"`
 	UploadedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"uploaded_at"`
@@ -121,12 +214,134 @@ type DocumentSignature struct {
 	CertificateSubject  string         `gorm:"size:255" json:"certificate_subject,omitempty"`
 	SigningTime         time.Time      `gorm:"not null" json:"signing_time"`
 	IsValid             bool           `gorm:"not null;default:false" json:"is_valid"`
+	Indication          string         `gorm:"size:20" json:"indication,omitempty"` // TOTAL-PASSED, TOTAL-FAILED or INDETERMINATE
+	Level               string         `gorm:"size:20" json:"level,omitempty"`      // PAdES baseline level
 	VerificationDetails datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"verification_details"`
 	VerifiedAt          time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"verified_at"`
 }
 
 func (DocumentSignature) TableName() string { return "document_signatures" }
 
+// SigningCertificate is a key and certificate used to apply PAdES
+// signatures. The private key is held encrypted by the vault. A certificate
+// with a UserID is that member's personal certificate; one without is the
+// project's organization certificate, used when the approver has none.
+type SigningCertificate struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProjectID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	UserID            *uuid.UUID `gorm:"type:uuid" json:"user_id,omitempty"`
+	Name              string     `gorm:"size:255;not null" json:"name"`
+	Subject           string     `gorm:"size:500;not null" json:"subject"`
+	Issuer            string     `gorm:"size:500;not null" json:"issuer"`
+	SerialNumber      string     `gorm:"size:100;not null" json:"serial_number"`
+	FingerprintSHA256 string     `gorm:"size:64;not null" json:"fingerprint_sha256"`
+	NotBefore         time.Time  `gorm:"not null" json:"not_before"`
+	NotAfter          time.Time  `gorm:"not null" json:"not_after"`
+	CertificatePEM    string     `gorm:"type:text;not null" json:"certificate_pem"` // signer certificate followed by its chain
+	EncryptedKey      string     `gorm:"type:text;not null" json:"-"`
+	CreatedBy         *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (SigningCertificate) TableName() string { return "document_signing_certificates" }
+
+// TrustAnchorBundle is a set of CA certificates a project trusts when
+// verifying signatures, such as a registry's or validator's own CA.
+// Certificates summarises the bundle for listing.
+type TrustAnchorBundle struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProjectID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"project_id"`
+	Name            string         `gorm:"size:255;not null" json:"name"`
+	Description     string         `gorm:"type:text" json:"description,omitempty"`
+	CertificatesPEM string         `gorm:"type:text;not null" json:"certificates_pem"`
+	Certificates    datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"certificates"`
+	CreatedBy       *uuid.UUID     `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+func (TrustAnchorBundle) TableName() string { return "document_trust_anchors" }
+
+// EvidencePackage records a verification evidence package issued for a
+// project. The package itself is not stored; ManifestSHA256 identifies the
+// signed manifest it was issued with.
+type EvidencePackage struct {
+	ID                   uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProjectID            uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	PeriodStart          time.Time  `gorm:"not null" json:"period_start"`
+	PeriodEnd            time.Time  `gorm:"not null" json:"period_end"`
+	FileCount            int        `gorm:"not null" json:"file_count"`
+	TotalBytes           int64      `gorm:"not null" json:"total_bytes"`
+	ManifestSHA256       string     `gorm:"size:64;not null" json:"manifest_sha256"`
+	SigningCertificateID uuid.UUID  `gorm:"type:uuid;not null" json:"signing_certificate_id"`
+	SignerSubject        string     `gorm:"size:500" json:"signer_subject"`
+	CreatedBy            *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+func (EvidencePackage) TableName() string { return "document_evidence_packages" }
+
+// UploadStatus is the state of a resumable upload session.
+type UploadStatus string
+
+const (
+	UploadActive     UploadStatus = "active"     // accepting chunks
+	UploadAssembling UploadStatus = "assembling" // all bytes received; building the file
+	UploadCompleted  UploadStatus = "completed"
+	UploadFailed     UploadStatus = "failed"
+	UploadAborted    UploadStatus = "aborted"
+	UploadExpired    UploadStatus = "expired"
+)
+
+// UploadSession is a resumable upload. Chunks are stored as separate
+// objects and assembled into a document, or a new version of DocumentID,
+// once TotalSize bytes have arrived.
+type UploadSession struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProjectID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"project_id"`
+	DocumentID     *uuid.UUID     `gorm:"type:uuid" json:"document_id,omitempty"` // set for a new version
+	Name           string         `gorm:"size:500" json:"name,omitempty"`
+	Description    string         `gorm:"type:text" json:"description,omitempty"`
+	DocumentType   DocumentType   `gorm:"size:100" json:"document_type,omitempty"`
+	ChangeSummary  string         `gorm:"type:text" json:"change_summary,omitempty"`
+	Filename       string         `gorm:"size:500;not null" json:"filename"`
+	ContentType    string         `gorm:"size:255" json:"content_type,omitempty"`
+	FileType       FileType       `gorm:"size:50;not null" json:"file_type"`
+	TotalSize      int64          `gorm:"not null" json:"total_size"`
+	ExpectedSHA256 string         `gorm:"column:expected_sha256;size:64" json:"sha256,omitempty"`
+	Offset         int64          `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Parts          datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"-"`
+	Status         UploadStatus   `gorm:"size:20;not null;index" json:"status"`
+	Error          string         `gorm:"type:text" json:"error,omitempty"`
+	ResultID       *uuid.UUID     `gorm:"type:uuid" json:"-"` // reserved ID of the document or version the upload records
+	ResultVersion  int            `json:"result_version,omitempty"`
+	CreatedBy      *uuid.UUID     `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	ExpiresAt      time.Time      `gorm:"not null" json:"expires_at"`
+}
+
+func (UploadSession) TableName() string { return "document_upload_sessions" }
+
+// UploadPart is a stored chunk of an upload session.
+type UploadPart struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Key    string `json:"key"`
+}
+
+// RevocationResponse caches an OCSP response or CRL fetched during
+// signature verification until it expires.
+type RevocationResponse struct {
+	CacheKey  string    `gorm:"primaryKey;size:512"`
+	Response  []byte    `gorm:"type:bytea;not null"`
+	FetchedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (RevocationResponse) TableName() string { return "document_revocation_responses" }
+
 // DocumentAccessLog records every action performed on a document.
 type DocumentAccessLog struct {
 	ID          uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
@@ -135,12 +350,50 @@ type DocumentAccessLog struct {
 	Action      AccessAction   `gorm:"size:50;not null" json:"action"`
 	IPAddress   string         `gorm:"type:inet" json:"ip_address,omitempty"`
 	UserAgent   string         `gorm:"type:text" json:"user_agent,omitempty"`
+	ShareLinkID *uuid.UUID     `gorm:"type:uuid;index" json:"share_link_id,omitempty"` // set for access through a share link
 	Details     datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"details"`
 	PerformedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP;index" json:"performed_at"`
 }
 
 func (DocumentAccessLog) TableName() string { return "document_access_logs" }
 
+// ShareLinkStatus is the state of a share link at a point in time.
+type ShareLinkStatus string
+
+const (
+	ShareLinkActive    ShareLinkStatus = "active"
+	ShareLinkExpired   ShareLinkStatus = "expired"
+	ShareLinkRevoked   ShareLinkStatus = "revoked"
+	ShareLinkExhausted ShareLinkStatus = "exhausted" // download limit reached
+	ShareLinkLocked    ShareLinkStatus = "locked"    // too many wrong passwords
+)
+
+// DocumentShareLink gives someone outside the project access to one
+// document through a secret URL. Only a hash of the token is stored.
+type DocumentShareLink struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DocumentID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"document_id"`
+	ProjectID      uuid.UUID  `gorm:"type:uuid;not null" json:"project_id"`
+	VersionNumber  *int       `json:"version_number,omitempty"` // nil shares whatever version is current
+	Label          string     `gorm:"size:255" json:"label,omitempty"`
+	TokenHash      string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	PasswordHash   string     `gorm:"size:100" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	MaxDownloads   int        `gorm:"not null;default:0" json:"max_downloads"` // 0 means unlimited
+	DownloadCount  int        `gorm:"not null;default:0" json:"download_count"`
+	FailedAttempts int        `gorm:"not null;default:0" json:"failed_attempts"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy      *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	CreatedBy      *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	PasswordProtected bool            `gorm:"-" json:"password_protected"`
+	Status            ShareLinkStatus `gorm:"-" json:"status"`
+}
+
+func (DocumentShareLink) TableName() string { return "document_share_links" }
+
 // UploadRequest is the incoming multipart form data structure.
 type UploadRequest struct {
 	ProjectID    string `form:"project_id" binding:"required"`
@@ -3,6 +3,7 @@ package documents
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"path"
 	"strings"
@@ -62,15 +63,19 @@ func (s *Service) GeneratePDF(ctx context.Context, req *GeneratePDFRequest, user
 		FileSize:     int64(len(pdfBytes)),
 		S3Key:        result.Key,
 		S3Bucket:     result.Bucket,
+		ContentHash:  fmt.Sprintf("%x", sha256.Sum256(pdfBytes)),
 		Status:       DocumentStatusDraft,
 		UploadedBy:   userID,
 		UploadedAt:   time.Now().UTC(),
 	}
 
+	s.assignWorkflow(ctx, doc)
+	s.queueText(doc)
 	if err := s.repo.Create(ctx, doc); err != nil {
 		_ = s.storage.Delete(ctx, s3Key)
 		return nil, err
 	}
+	s.wakeTextExtraction()
 
 	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
 		DocumentID:  doc.ID,
@@ -0,0 +1,55 @@
+package documents
+
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests/datasource"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+
+	"gorm.io/gorm"
+)
+
+// NewPrivacySource returns the data source privacy requests use to find,
+// export and erase what documents record about a user. Documents belong to
+// their project, so erasure clears the uploader rather than deleting files.
+// Access logs lose the user, IP address and user agent. Approval decisions
+// are workflow audit records and are kept.
+func NewPrivacySource(db *gorm.DB) *datasource.Source {
+	nullify := func(cols ...string) func(string) map[string]any {
+		return func(string) map[string]any {
+			set := make(map[string]any, len(cols))
+			for _, col := range cols {
+				set[col] = nil
+			}
+			return set
+		}
+	}
+	return datasource.New("documents", db,
+		datasource.Table{Name: Document{}.TableName(), Category: requests.CategoryProjectData, Match: "uploaded_by = ?", Project: "project_id", TimeColumn: "uploaded_at",
+			Anonymize: nullify("uploaded_by")},
+		datasource.Table{Name: DocumentVersion{}.TableName(), Category: requests.CategoryProjectData, Match: "uploaded_by = ?", TimeColumn: "uploaded_at",
+			Project:   "(SELECT d.project_id FROM documents d WHERE d.id = document_versions.document_id)",
+			Anonymize: nullify("uploaded_by")},
+		datasource.Table{Name: DocumentShareLink{}.TableName(), Category: requests.CategoryProjectData, Match: "created_by = ?", Project: "project_id",
+			Omit: []string{"token_hash", "password_hash"}, Anonymize: nullify("created_by")},
+		datasource.Table{Name: DocumentShareLink{}.TableName(), Dataset: "revoked_share_links", Category: requests.CategoryProjectData, Match: "revoked_by = ?", Project: "project_id",
+			NoExport: true, Anonymize: nullify("revoked_by")},
+		datasource.Table{Name: DocumentAccessLog{}.TableName(), Category: requests.CategorySystemLogs, Match: "user_id = ?", TimeColumn: "performed_at",
+			Project:   "(SELECT d.project_id FROM documents d WHERE d.id = document_access_logs.document_id)",
+			Anonymize: nullify("user_id", "ip_address", "user_agent")},
+		datasource.Table{Name: DocumentApproval{}.TableName(), Category: requests.CategoryAuditLogs, Match: "user_id = ?",
+			Project:   "(SELECT d.project_id FROM documents d WHERE d.id = document_approvals.document_id)",
+			Anonymize: nullify("user_id")},
+	)
+}
+
+// RetentionTargets returns the documents tables retention policies act on.
+// Access logs age out by when the access happened; documents themselves are
+// governed by the project lifecycle.
+func RetentionTargets() []retention.Target {
+	return []retention.Target{
+		{Table: DocumentAccessLog{}.TableName(), Category: requests.CategorySystemLogs, TimeColumn: "performed_at", UserColumn: "user_id",
+			Project:    "(SELECT d.project_id FROM documents d WHERE d.id = document_access_logs.document_id)",
+			Anonymize:  map[string]any{"user_id": nil, "ip_address": nil, "user_agent": nil},
+			Anonymized: "user_id IS NULL AND ip_address IS NULL"},
+	}
+}
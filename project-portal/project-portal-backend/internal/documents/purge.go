@@ -0,0 +1,110 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Purger permanently removes a project's documents. It implements
+// project.DataPurger. Stored files are deleted after the purge commits;
+// without storage they are logged as orphaned instead.
+type Purger struct {
+	storage *StorageService
+	text    TextIndexer // optional; extracted text is removed from search
+}
+
+// NewPurger creates a document purger. storage may be nil.
+func NewPurger(storage *StorageService) *Purger {
+	return &Purger{storage: storage}
+}
+
+// SetTextIndexer makes purges also remove the documents' extracted text
+// from the search index.
+func (p *Purger) SetTextIndexer(idx TextIndexer) {
+	p.text = idx
+}
+
+// PurgeProjectData deletes documents, including soft-deleted ones, with
+// their versions, signatures, share links, workflow progress and access
+// logs, and the project's signing certificates and upload sessions inside
+// the caller's transaction.
+func (p *Purger) PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error) {
+	tx = tx.WithContext(ctx)
+	docIDs := tx.Model(&Document{}).Select("id").Where("project_id = ?", projectID)
+
+	var keys []string
+	if err := tx.Model(&Document{}).Where("project_id = ?", projectID).Pluck("s3_key", &keys).Error; err != nil {
+		return nil, err
+	}
+	var versionKeys []string
+	if err := tx.Model(&DocumentVersion{}).Where("document_id IN (?)", docIDs).Pluck("s3_key", &versionKeys).Error; err != nil {
+		return nil, err
+	}
+	keys = append(keys, versionKeys...)
+	var partLists []datatypes.JSON
+	if err := tx.Model(&UploadSession{}).Where("project_id = ?", projectID).Pluck("parts", &partLists).Error; err != nil {
+		return nil, err
+	}
+	for _, raw := range partLists {
+		var parts []UploadPart
+		_ = json.Unmarshal(raw, &parts)
+		for _, part := range parts {
+			keys = append(keys, part.Key)
+		}
+	}
+	var ids []uuid.UUID
+	if p.text != nil {
+		if err := tx.Model(&Document{}).Where("project_id = ?", projectID).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for _, model := range []any{&DocumentAccessLog{}, &DocumentShareLink{}, &DocumentSignature{}, &DocumentApproval{}, &DocumentWorkflowRun{}, &DocumentVersion{}} {
+		if err := tx.Where("document_id IN (?)", docIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Where("project_id = ?", projectID).Delete(&Document{}).Error; err != nil {
+		return nil, err
+	}
+	for _, model := range []any{&SigningCertificate{}, &TrustAnchorBundle{}, &EvidencePackage{}, &UploadSession{}} {
+		if err := tx.Where("project_id = ?", projectID).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return func(ctx context.Context) {
+		p.deleteObjects(ctx, projectID, keys)
+		p.deleteText(ctx, projectID, ids)
+	}, nil
+}
+
+func (p *Purger) deleteText(ctx context.Context, projectID uuid.UUID, ids []uuid.UUID) {
+	for _, id := range ids {
+		if err := p.text.DeleteDocumentText(ctx, id.String()); err != nil {
+			log.Printf("documents: purge of project %s could not remove document %s from search: %v", projectID, id, err)
+		}
+	}
+}
+
+func (p *Purger) deleteObjects(ctx context.Context, projectID uuid.UUID, keys []string) {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if p.storage == nil {
+			log.Printf("documents: purge of project %s left orphaned object %s (storage unavailable)", projectID, key)
+			continue
+		}
+		if err := p.storage.Delete(ctx, key); err != nil {
+			log.Printf("documents: purge of project %s could not delete object %s: %v", projectID, key, err)
+		}
+	}
+}
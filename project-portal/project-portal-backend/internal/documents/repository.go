@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository handles all database operations for documents.
@@ -39,6 +41,19 @@ func (r *Repository) FindByID(ctx context.Context, id uuid.UUID) (*Document, err
 	return &doc, nil
 }
 
+// HasProjectDocument reports whether the project has a live document of the
+// given type in the given workflow status.
+func (r *Repository) HasProjectDocument(ctx context.Context, projectID uuid.UUID, docType, status string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Document{}).
+		Where("project_id = ? AND document_type = ? AND status = ? AND deleted_at IS NULL", projectID, docType, status).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check project documents: %w", err)
+	}
+	return count > 0, nil
+}
+
 // FindAll retrieves documents matching the given filter (paginated).
 func (r *Repository) FindAll(ctx context.Context, filter ListFilter) (*ListResponse, error) {
 	query := r.db.WithContext(ctx).Model(&Document{}).Where("deleted_at IS NULL")
@@ -131,6 +146,21 @@ func (r *Repository) CreateVersion(ctx context.Context, v *DocumentVersion) erro
 	return nil
 }
 
+// FindProjectDocuments returns a project's live documents with their
+// version history, ordered by type and name.
+func (r *Repository) FindProjectDocuments(ctx context.Context, projectID uuid.UUID) ([]Document, error) {
+	var docs []Document
+	err := r.db.WithContext(ctx).
+		Preload("Versions", func(db *gorm.DB) *gorm.DB { return db.Order("version_number ASC") }).
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Order("document_type, name, id").
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project documents: %w", err)
+	}
+	return docs, nil
+}
+
 // FindVersionsByDocumentID retrieves all versions for a document ordered ASC.
 func (r *Repository) FindVersionsByDocumentID(ctx context.Context, docID uuid.UUID) ([]DocumentVersion, error) {
 	var versions []DocumentVersion
@@ -156,6 +186,15 @@ func (r *Repository) FindVersionByNumber(ctx context.Context, docID uuid.UUID, v
 	return &v, nil
 }
 
+// FindVersionByID retrieves a version by its ID.
+func (r *Repository) FindVersionByID(ctx context.Context, id uuid.UUID) (*DocumentVersion, error) {
+	var v DocumentVersion
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&v).Error; err != nil {
+		return nil, fmt.Errorf("version %s not found: %w", id, err)
+	}
+	return &v, nil
+}
+
 // CreateSignature persists a digital signature verification result.
 func (r *Repository) CreateSignature(ctx context.Context, sig *DocumentSignature) error {
 	if err := r.db.WithContext(ctx).Create(sig).Error; err != nil {
@@ -177,6 +216,126 @@ func (r *Repository) FindSignaturesByDocumentID(ctx context.Context, docID uuid.
 	return sigs, nil
 }
 
+// ─── Integrity Methods ────────────────────────────────────────────────────────
+
+// FindDuplicate returns the earliest live document in the project whose
+// current file or any version has the given SHA-256, or nil.
+func (r *Repository) FindDuplicate(ctx context.Context, projectID uuid.UUID, hash string) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&Document{}).
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Where("content_hash = ? OR id IN (?)", hash,
+			r.db.Model(&DocumentVersion{}).Select("document_id").Where("content_hash = ?", hash)).
+		Order("uploaded_at ASC").
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for duplicate documents: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+// SetContentHash records a document's current SHA-256.
+func (r *Repository) SetContentHash(ctx context.Context, id uuid.UUID, hash string) error {
+	err := r.db.WithContext(ctx).Model(&Document{}).Where("id = ?", id).Update("content_hash", hash).Error
+	if err != nil {
+		return fmt.Errorf("failed to record content hash: %w", err)
+	}
+	return nil
+}
+
+// SetVersionContentHash records a version's SHA-256.
+func (r *Repository) SetVersionContentHash(ctx context.Context, id uuid.UUID, hash string) error {
+	err := r.db.WithContext(ctx).Model(&DocumentVersion{}).Where("id = ?", id).Update("content_hash", hash).Error
+	if err != nil {
+		return fmt.Errorf("failed to record version content hash: %w", err)
+	}
+	return nil
+}
+
+// SetVersionIPFSCID records the CID a version was pinned under.
+func (r *Repository) SetVersionIPFSCID(ctx context.Context, id uuid.UUID, cid string) error {
+	err := r.db.WithContext(ctx).Model(&DocumentVersion{}).Where("id = ?", id).Update("ipfs_cid", cid).Error
+	if err != nil {
+		return fmt.Errorf("failed to record version IPFS CID: %w", err)
+	}
+	return nil
+}
+
+// SetIntegrityStatus records the outcome of an integrity check.
+func (r *Repository) SetIntegrityStatus(ctx context.Context, id uuid.UUID, status IntegrityStatus, checkedAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&Document{}).Where("id = ?", id).
+		Updates(map[string]any{"integrity_status": status, "integrity_checked_at": checkedAt}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record integrity status: %w", err)
+	}
+	return nil
+}
+
+// FindForIntegrityCheck returns up to limit live documents not checked since
+// before, never-checked documents first.
+func (r *Repository) FindForIntegrityCheck(ctx context.Context, limit int, before time.Time) ([]Document, error) {
+	var docs []Document
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL AND (integrity_checked_at IS NULL OR integrity_checked_at < ?)", before).
+		Order("integrity_checked_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents for integrity check: %w", err)
+	}
+	return docs, nil
+}
+
+// FindPendingTextExtraction returns up to limit live documents whose text
+// is waiting to be extracted, oldest first. Documents never queued, such as
+// those uploaded before indexing was enabled, are included when their file
+// type has an extractor.
+func (r *Repository) FindPendingTextExtraction(ctx context.Context, limit int, fileTypes []FileType) ([]Document, error) {
+	var docs []Document
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL AND (text_status = ? OR ((text_status IS NULL OR text_status = '') AND file_type IN ?))", TextPending, fileTypes).
+		Order("uploaded_at ASC").
+		Limit(limit).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents for text extraction: %w", err)
+	}
+	return docs, nil
+}
+
+// QueueTextExtraction marks a document's text as waiting for extraction.
+func (r *Repository) QueueTextExtraction(ctx context.Context, id uuid.UUID) error {
+	err := r.db.WithContext(ctx).Model(&Document{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]any{"text_status": TextPending, "text_error": ""}).Error
+	if err != nil {
+		return fmt.Errorf("failed to queue text extraction: %w", err)
+	}
+	return nil
+}
+
+// RecordTextExtraction stores the outcome of extracting version of a
+// document. It reports false, and changes nothing, if a newer version was
+// uploaded meanwhile; that version is already queued.
+func (r *Repository) RecordTextExtraction(ctx context.Context, id uuid.UUID, version int, status TextStatus, errMsg string, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&Document{}).
+		Where("id = ? AND current_version = ?", id, version).
+		Updates(map[string]any{
+			"text_status":       status,
+			"text_version":      version,
+			"text_extracted_at": at,
+			"text_error":        errMsg,
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to record text extraction: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
 // ─── Workflow Template Methods ─────────────────────────────────────────────────
 
 // CreateWorkflow inserts a new workflow template.
@@ -205,29 +364,631 @@ func (r *Repository) FindAllWorkflows(ctx context.Context) ([]DocumentWorkflow,
 	return wfs, nil
 }
 
-// AppendWorkflowStep appends a step entry to the steps JSONB array of a workflow.
-// Non-fatal: logs warning on failure rather than returning an error.
-func (r *Repository) AppendWorkflowStep(ctx context.Context, workflowID uuid.UUID, step interface{}) error {
-	stepJSON, err := marshalStep(step)
+// FindAutoAssignWorkflow returns the newest auto-assign template for the
+// document type, preferring an exact type over AnyDocumentType. It returns
+// nil when there is none.
+func (r *Repository) FindAutoAssignWorkflow(ctx context.Context, docType DocumentType) (*DocumentWorkflow, error) {
+	var wfs []DocumentWorkflow
+	err := r.db.WithContext(ctx).
+		Where("auto_assign AND document_type IN ?", []DocumentType{docType, AnyDocumentType}).
+		Order(clause.Expr{SQL: "document_type = ? DESC, created_at DESC", Vars: []any{docType}}).
+		Limit(1).
+		Find(&wfs).Error
 	if err != nil {
-		fmt.Printf("WARNING: failed to marshal workflow step: %v\n", err)
-		return nil
+		return nil, fmt.Errorf("failed to find workflow template: %w", err)
 	}
-	result := r.db.WithContext(ctx).Exec(
-		`UPDATE document_workflows SET steps = steps || ?::jsonb WHERE id = ?`,
-		fmt.Sprintf("[%s]", stepJSON), workflowID,
-	)
-	if result.Error != nil {
-		fmt.Printf("WARNING: failed to append workflow step: %v\n", result.Error)
+	if len(wfs) == 0 {
+		return nil, nil
+	}
+	return &wfs[0], nil
+}
+
+// FindWorkflowRun returns a document's workflow progress, or nil if it has
+// never been submitted through a template.
+func (r *Repository) FindWorkflowRun(ctx context.Context, docID uuid.UUID) (*DocumentWorkflowRun, error) {
+	var runs []DocumentWorkflowRun
+	if err := r.db.WithContext(ctx).Where("document_id = ?", docID).Limit(1).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load workflow run: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// SaveWorkflowRun inserts or updates a document's workflow progress.
+func (r *Repository) SaveWorkflowRun(ctx context.Context, run *DocumentWorkflowRun) error {
+	if err := r.db.WithContext(ctx).Save(run).Error; err != nil {
+		return fmt.Errorf("failed to save workflow run: %w", err)
+	}
+	return nil
+}
+
+// CreateApproval records a decision on a workflow step.
+func (r *Repository) CreateApproval(ctx context.Context, a *DocumentApproval) error {
+	if err := r.db.WithContext(ctx).Create(a).Error; err != nil {
+		return fmt.Errorf("failed to record approval: %w", err)
+	}
+	return nil
+}
+
+// ListApprovals returns a document's approval history, oldest first.
+func (r *Repository) ListApprovals(ctx context.Context, docID uuid.UUID) ([]DocumentApproval, error) {
+	var out []DocumentApproval
+	err := r.db.WithContext(ctx).
+		Where("document_id = ?", docID).
+		Order("created_at ASC").
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	return out, nil
+}
+
+// StepApprovers returns the users who approved a step in the given round,
+// in the order they approved.
+func (r *Repository) StepApprovers(ctx context.Context, docID uuid.UUID, round, step int) ([]string, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&DocumentApproval{}).
+		Where("document_id = ? AND round = ? AND step_index = ? AND decision = ?", docID, round, step, DecisionApprove).
+		Order("created_at ASC").
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load step approvals: %w", err)
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out, nil
+}
+
+// FindOverdueRuns returns in-progress runs whose step deadline has passed
+// and that have not been escalated yet.
+func (r *Repository) FindOverdueRuns(ctx context.Context, now time.Time) ([]DocumentWorkflowRun, error) {
+	var runs []DocumentWorkflowRun
+	err := r.db.WithContext(ctx).
+		Joins("JOIN documents d ON d.id = document_workflow_runs.document_id").
+		Where("document_workflow_runs.due_at < ? AND document_workflow_runs.escalated_at IS NULL", now).
+		Where("d.deleted_at IS NULL AND d.status IN ?", []DocumentStatus{DocumentStatusSubmitted, DocumentStatusUnderReview}).
+		Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue workflow runs: %w", err)
+	}
+	return runs, nil
+}
+
+// ─── Signing Certificate Methods ──────────────────────────────────────────────
+
+// CreateSigningCertificate stores a signing certificate.
+func (r *Repository) CreateSigningCertificate(ctx context.Context, sc *SigningCertificate) error {
+	if err := r.db.WithContext(ctx).Create(sc).Error; err != nil {
+		return fmt.Errorf("failed to save signing certificate: %w", err)
+	}
+	return nil
+}
+
+// FindSigningCertificate retrieves a signing certificate by ID.
+func (r *Repository) FindSigningCertificate(ctx context.Context, id uuid.UUID) (*SigningCertificate, error) {
+	var sc SigningCertificate
+	if err := r.db.WithContext(ctx).First(&sc, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("signing certificate not found: %w", err)
+	}
+	return &sc, nil
+}
+
+// ListSigningCertificates returns a project's signing certificates, newest first.
+func (r *Repository) ListSigningCertificates(ctx context.Context, projectID uuid.UUID) ([]SigningCertificate, error) {
+	var out []SigningCertificate
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing certificates: %w", err)
+	}
+	return out, nil
+}
+
+// FindActiveSigningCertificate returns the newest unrevoked certificate
+// valid at now, preferring the user's personal certificate over the
+// project's. It returns nil when there is none.
+func (r *Repository) FindActiveSigningCertificate(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, now time.Time) (*SigningCertificate, error) {
+	q := r.db.WithContext(ctx).
+		Where("project_id = ? AND revoked_at IS NULL AND not_before <= ? AND not_after > ?", projectID, now, now)
+	if userID != nil {
+		q = q.Where("user_id IS NULL OR user_id = ?", *userID).
+			Order(clause.Expr{SQL: "user_id IS NULL, created_at DESC"})
+	} else {
+		q = q.Where("user_id IS NULL").Order("created_at DESC")
+	}
+	var out []SigningCertificate
+	if err := q.Limit(1).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to find signing certificate: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return &out[0], nil
+}
+
+// RevokeSigningCertificate marks a certificate as revoked.
+func (r *Repository) RevokeSigningCertificate(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&SigningCertificate{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke signing certificate: %w", err)
+	}
+	return nil
+}
+
+// ─── Trust Anchor Methods ─────────────────────────────────────────────────────
+
+// CreateTrustAnchorBundle stores a trust anchor bundle.
+func (r *Repository) CreateTrustAnchorBundle(ctx context.Context, b *TrustAnchorBundle) error {
+	if err := r.db.WithContext(ctx).Create(b).Error; err != nil {
+		return fmt.Errorf("failed to save trust anchor bundle: %w", err)
+	}
+	return nil
+}
+
+// FindTrustAnchorBundle retrieves a trust anchor bundle by ID.
+func (r *Repository) FindTrustAnchorBundle(ctx context.Context, id uuid.UUID) (*TrustAnchorBundle, error) {
+	var b TrustAnchorBundle
+	if err := r.db.WithContext(ctx).First(&b, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("trust anchor bundle not found: %w", err)
+	}
+	return &b, nil
+}
+
+// ListTrustAnchorBundles returns a project's trust anchor bundles, newest first.
+func (r *Repository) ListTrustAnchorBundles(ctx context.Context, projectID uuid.UUID) ([]TrustAnchorBundle, error) {
+	var out []TrustAnchorBundle
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trust anchor bundles: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteTrustAnchorBundle removes a trust anchor bundle.
+func (r *Repository) DeleteTrustAnchorBundle(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&TrustAnchorBundle{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete trust anchor bundle: %w", err)
 	}
 	return nil
 }
 
-// marshalStep JSON-encodes a workflow step for DB insertion.
-func marshalStep(v interface{}) (string, error) {
-	b, err := json.Marshal(v)
+// GetRevocationResponse returns a cached OCSP response or CRL that has not
+// expired.
+func (r *Repository) GetRevocationResponse(ctx context.Context, key string, now time.Time) (*RevocationResponse, error) {
+	var out []RevocationResponse
+	err := r.db.WithContext(ctx).
+		Where("cache_key = ? AND expires_at > ?", key, now).
+		Limit(1).
+		Find(&out).Error
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to read revocation cache: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return &out[0], nil
+}
+
+// SaveRevocationResponse stores or replaces a cached response and drops
+// expired ones.
+func (r *Repository) SaveRevocationResponse(ctx context.Context, resp *RevocationResponse) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cache_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"response", "fetched_at", "expires_at"}),
+		}).Create(resp).Error
+		if err != nil {
+			return fmt.Errorf("failed to save revocation response: %w", err)
+		}
+		return tx.Where("expires_at <= ?", resp.FetchedAt).Delete(&RevocationResponse{}).Error
+	})
+}
+
+// WithDocumentLock runs fn in a transaction holding a row lock on the
+// document, so concurrent decisions on the same workflow step are applied
+// one at a time. fn receives a repository bound to the transaction and the
+// freshly loaded document.
+func (r *Repository) WithDocumentLock(ctx context.Context, docID uuid.UUID, fn func(tx *Repository, doc *Document) error) error {
+	return r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var doc Document
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", docID).
+			First(&doc).Error
+		if err != nil {
+			return fmt.Errorf("document not found: %w", err)
+		}
+		return fn(&Repository{db: db}, &doc)
+	})
+}
+
+// ─── Evidence Package Methods ─────────────────────────────────────────────────
+
+// CreateEvidencePackage records an issued evidence package.
+func (r *Repository) CreateEvidencePackage(ctx context.Context, p *EvidencePackage) error {
+	if err := r.db.WithContext(ctx).Create(p).Error; err != nil {
+		return fmt.Errorf("failed to record evidence package: %w", err)
+	}
+	return nil
+}
+
+// ListEvidencePackages returns the packages issued for a project, newest first.
+func (r *Repository) ListEvidencePackages(ctx context.Context, projectID uuid.UUID) ([]EvidencePackage, error) {
+	var out []EvidencePackage
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence packages: %w", err)
+	}
+	return out, nil
+}
+
+// ─── Upload Session Methods ───────────────────────────────────────────────────
+
+// CreateUploadSession inserts a resumable upload session.
+func (r *Repository) CreateUploadSession(ctx context.Context, u *UploadSession) error {
+	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return nil
+}
+
+// FindUploadSession retrieves an upload session by ID.
+func (r *Repository) FindUploadSession(ctx context.Context, id uuid.UUID) (*UploadSession, error) {
+	var u UploadSession
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&u).Error; err != nil {
+		return nil, fmt.Errorf("upload session not found: %w", err)
+	}
+	return &u, nil
+}
+
+// AppendUploadPart records a stored chunk if the session is still active at
+// part.Offset. It reports false when another chunk got there first.
+func (r *Repository) AppendUploadPart(ctx context.Context, id uuid.UUID, part UploadPart, expiresAt time.Time) (bool, error) {
+	raw, err := json.Marshal([]UploadPart{part})
+	if err != nil {
+		return false, err
+	}
+	res := r.db.WithContext(ctx).Model(&UploadSession{}).
+		Where("id = ? AND status = ? AND upload_offset = ?", id, UploadActive, part.Offset).
+		Updates(map[string]interface{}{
+			"parts":         gorm.Expr("parts || ?::jsonb", string(raw)),
+			"upload_offset": part.Offset + part.Size,
+			"expires_at":    expiresAt,
+			"updated_at":    time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to record upload chunk: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// TransitionUploadSession applies updates to a session in status from. It
+// reports false when the session has moved on.
+func (r *Repository) TransitionUploadSession(ctx context.Context, id uuid.UUID, from UploadStatus, updates map[string]interface{}) (bool, error) {
+	updates["updated_at"] = time.Now().UTC()
+	res := r.db.WithContext(ctx).Model(&UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to update upload session: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// FindExpiredUploads returns active sessions past their expiry.
+func (r *Repository) FindExpiredUploads(ctx context.Context, now time.Time, limit int) ([]UploadSession, error) {
+	var out []UploadSession
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", UploadActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired uploads: %w", err)
+	}
+	return out, nil
+}
+
+// FindStalledAssemblies returns sessions whose assembly has not finished
+// since before, e.g. because the instance running it stopped.
+func (r *Repository) FindStalledAssemblies(ctx context.Context, before time.Time, limit int) ([]UploadSession, error) {
+	var out []UploadSession
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", UploadAssembling, before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stalled uploads: %w", err)
+	}
+	return out, nil
+}
+
+// ClaimUploadAssembly takes over a stalled assembly. It reports false when
+// another instance claimed or finished it first.
+func (r *Repository) ClaimUploadAssembly(ctx context.Context, id uuid.UUID, staleBefore time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&UploadSession{}).
+		Where("id = ? AND status = ? AND updated_at < ?", id, UploadAssembling, staleBefore).
+		Update("updated_at", time.Now().UTC())
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to claim upload assembly: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ─── Share Links ──────────────────────────────────────────────────────────────
+
+// CreateShareLink inserts a share link.
+func (r *Repository) CreateShareLink(ctx context.Context, link *DocumentShareLink) error {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+	return nil
+}
+
+// FindShareLinkByTokenHash looks a link up by the hash of its URL token.
+func (r *Repository) FindShareLinkByTokenHash(ctx context.Context, hash string) (*DocumentShareLink, error) {
+	var link DocumentShareLink
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&link).Error; err != nil {
+		return nil, fmt.Errorf("share link not found: %w", err)
+	}
+	return &link, nil
+}
+
+// ListShareLinks returns a document's links, newest first. Unless all is
+// set, only links that can still be used at now are returned.
+func (r *Repository) ListShareLinks(ctx context.Context, docID uuid.UUID, all bool, now time.Time, maxFailures int) ([]DocumentShareLink, error) {
+	q := r.db.WithContext(ctx).Where("document_id = ?", docID)
+	if !all {
+		q = q.Where("revoked_at IS NULL AND expires_at > ? AND failed_attempts < ?", now, maxFailures).
+			Where("max_downloads = 0 OR download_count < max_downloads")
+	}
+	var out []DocumentShareLink
+	if err := q.Order("created_at DESC").Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	return out, nil
+}
+
+// RevokeShareLink revokes a document's link. It reports false when the link
+// does not exist or was already revoked.
+func (r *Repository) RevokeShareLink(ctx context.Context, docID, linkID uuid.UUID, userID *uuid.UUID, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&DocumentShareLink{}).
+		Where("id = ? AND document_id = ? AND revoked_at IS NULL", linkID, docID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_by": userID})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to revoke share link: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ClaimShareDownload counts one download against a link if it is still
+// usable at now. It reports false when the link expired, was revoked or
+// ran out of downloads in the meantime.
+func (r *Repository) ClaimShareDownload(ctx context.Context, id uuid.UUID, now time.Time, maxFailures int) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&DocumentShareLink{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ? AND failed_attempts < ?", id, now, maxFailures).
+		Where("max_downloads = 0 OR download_count < max_downloads").
+		Updates(map[string]interface{}{
+			"download_count":   gorm.Expr("download_count + 1"),
+			"last_accessed_at": now,
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to record share download: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RecordShareAccess updates a link's last access time, and counts a failed
+// password attempt when failed is set.
+func (r *Repository) RecordShareAccess(ctx context.Context, id uuid.UUID, now time.Time, failed bool) error {
+	updates := map[string]interface{}{"last_accessed_at": now}
+	if failed {
+		updates["failed_attempts"] = gorm.Expr("failed_attempts + 1")
+	}
+	return r.db.WithContext(ctx).Model(&DocumentShareLink{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ─── Access Log Queries ───────────────────────────────────────────────────────
+
+// accessLogQuery selects access logs matching f within [from, to), joined
+// to their documents as d. Deleted documents keep their history.
+func (r *Repository) accessLogQuery(ctx context.Context, f *AccessLogFilter, from, to time.Time) *gorm.DB {
+	q := r.db.WithContext(ctx).Table("document_access_logs AS l").
+		Joins("JOIN documents d ON d.id = l.document_id")
+	if f.ProjectID != "" {
+		q = q.Where("d.project_id = ?", f.ProjectID)
+	}
+	if f.DocumentID != "" {
+		q = q.Where("l.document_id = ?", f.DocumentID)
+	}
+	if f.UserID != "" {
+		q = q.Where("l.user_id = ?", f.UserID)
+	}
+	if f.ShareLinkID != "" {
+		q = q.Where("l.share_link_id = ?", f.ShareLinkID)
+	}
+	if f.Action != "" {
+		q = q.Where("l.action = ?", f.Action)
+	}
+	if f.IPAddress != "" {
+		q = q.Where("l.ip_address = ?::inet", f.IPAddress)
+	}
+	if !from.IsZero() {
+		q = q.Where("l.performed_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("l.performed_at < ?", to)
+	}
+	return q
+}
+
+// FindAccessLogs returns a page of access logs matching f, newest first.
+func (r *Repository) FindAccessLogs(ctx context.Context, f *AccessLogFilter, from, to time.Time, page, pageSize int) ([]AccessLogEntry, int64, error) {
+	var total int64
+	if err := r.accessLogQuery(ctx, f, from, to).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count access logs: %w", err)
+	}
+	var out []AccessLogEntry
+	err := r.accessLogQuery(ctx, f, from, to).
+		Select("l.*, d.name AS document_name, d.project_id").
+		Order("l.performed_at DESC, l.id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&out).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list access logs: %w", err)
+	}
+	return out, total, nil
+}
+
+// EachAccessLog calls fn for up to limit access logs matching f, oldest
+// first, without loading them all at once.
+func (r *Repository) EachAccessLog(ctx context.Context, f *AccessLogFilter, from, to time.Time, limit int, fn func(*AccessLogEntry) error) error {
+	q := r.accessLogQuery(ctx, f, from, to).
+		Select("l.*, d.name AS document_name, d.project_id").
+		Order("l.performed_at ASC, l.id").
+		Limit(limit)
+	rows, err := q.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to read access logs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry AccessLogEntry
+		if err := r.db.ScanRows(rows, &entry); err != nil {
+			return fmt.Errorf("failed to read access log: %w", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// AccessStats aggregates access logs matching f. downloads are the actions
+// counted as downloads; top caps the top document and user lists.
+func (r *Repository) AccessStats(ctx context.Context, f *AccessLogFilter, from, to time.Time, downloads []AccessAction, top int) (*AccessStats, error) {
+	stats := &AccessStats{From: from, To: to, ByAction: map[AccessAction]int64{}}
+
+	var totals struct {
+		Total         int64
+		Users         int64
+		IPs           int64
+		ShareAccesses int64
+	}
+	err := r.accessLogQuery(ctx, f, from, to).
+		Select("COUNT(*) AS total, COUNT(DISTINCT l.user_id) AS users, COUNT(DISTINCT l.ip_address) AS ips, COUNT(l.share_link_id) AS share_accesses").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count access logs: %w", err)
+	}
+	stats.Total, stats.UniqueUsers, stats.UniqueIPs, stats.ShareLinkAccesses = totals.Total, totals.Users, totals.IPs, totals.ShareAccesses
+
+	var byAction []struct {
+		Action AccessAction
+		Count  int64
+	}
+	if err := r.accessLogQuery(ctx, f, from, to).Select("l.action, COUNT(*) AS count").Group("l.action").Scan(&byAction).Error; err != nil {
+		return nil, fmt.Errorf("failed to count access logs by action: %w", err)
+	}
+	for _, row := range byAction {
+		stats.ByAction[row.Action] = row.Count
+	}
+
+	err = r.accessLogQuery(ctx, f, from, to).Where("l.action IN ?", downloads).
+		Select("date_trunc('day', l.performed_at AT TIME ZONE 'UTC') AS day, COUNT(*) AS downloads").
+		Group("day").Order("day").
+		Scan(&stats.DownloadsByDay).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads by day: %w", err)
+	}
+	err = r.accessLogQuery(ctx, f, from, to).Where("l.action IN ?", downloads).
+		Select("l.document_id, d.name AS document_name, COUNT(*) AS downloads, COUNT(DISTINCT COALESCE(l.user_id, l.share_link_id)) AS downloaders").
+		Group("l.document_id, d.name").Order("downloads DESC, l.document_id").Limit(top).
+		Scan(&stats.TopDocuments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank documents by downloads: %w", err)
+	}
+	err = r.accessLogQuery(ctx, f, from, to).Where("l.action IN ? AND l.user_id IS NOT NULL", downloads).
+		Select("l.user_id, COUNT(*) AS downloads, COUNT(DISTINCT l.document_id) AS documents").
+		Group("l.user_id").Order("downloads DESC, l.user_id").Limit(top).
+		Scan(&stats.TopUsers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank users by downloads: %w", err)
+	}
+	return stats, nil
+}
+
+// FindAccessEvents returns up to limit access logs matching f, oldest first.
+func (r *Repository) FindAccessEvents(ctx context.Context, f *AccessLogFilter, from, to time.Time, limit int) ([]DocumentAccessLog, error) {
+	var out []DocumentAccessLog
+	err := r.accessLogQuery(ctx, f, from, to).
+		Select("l.id, l.document_id, l.user_id, l.share_link_id, l.action, host(l.ip_address) AS ip_address, l.performed_at").
+		Order("l.performed_at ASC, l.id").
+		Limit(limit).
+		Scan(&out).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load access events: %w", err)
+	}
+	return out, nil
+}
+
+// KnownIPs returns the addresses each user accessed any document from
+// within [from, to).
+func (r *Repository) KnownIPs(ctx context.Context, userIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID][]string, error) {
+	out := map[uuid.UUID][]string{}
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		UserID    uuid.UUID
+		IPAddress string
+	}
+	err := r.db.WithContext(ctx).Model(&DocumentAccessLog{}).
+		Distinct("user_id", "host(ip_address) AS ip_address").
+		Where("user_id IN ? AND ip_address IS NOT NULL AND performed_at >= ? AND performed_at < ?", userIDs, from, to).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known addresses: %w", err)
+	}
+	for _, row := range rows {
+		out[row.UserID] = append(out[row.UserID], row.IPAddress)
+	}
+	return out, nil
+}
+
+// CountAccessByUser counts access logs matching f per user within
+// [from, to), limited to the given actions.
+func (r *Repository) CountAccessByUser(ctx context.Context, f *AccessLogFilter, from, to time.Time, actions []AccessAction) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		UserID uuid.UUID
+		Count  int64
+	}
+	err := r.accessLogQuery(ctx, f, from, to).
+		Where("l.action IN ? AND l.user_id IS NOT NULL", actions).
+		Select("l.user_id, COUNT(*) AS count").
+		Group("l.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count access by user: %w", err)
+	}
+	out := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		out[row.UserID] = row.Count
 	}
-	return string(b), nil
+	return out, nil
 }
@@ -0,0 +1,502 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ─── Resumable Uploads ────────────────────────────────────────────────────────
+
+const (
+	// MaxResumableUploadSize caps the size of a resumable upload.
+	MaxResumableUploadSize = 20 << 30
+	// MaxChunkSize caps one chunk; clients on poor links should send less.
+	MaxChunkSize = 64 << 20
+	// uploadSessionTTL is how long a session waits for its next chunk.
+	uploadSessionTTL = 24 * time.Hour
+	// assemblyStallAfter is when an unfinished assembly is retried.
+	assemblyStallAfter = 15 * time.Minute
+	// assemblyTimeout bounds one assembly attempt.
+	assemblyTimeout = 2 * time.Hour
+	// chunkDeadline replaces the server's read and write timeouts for one
+	// chunk request, so a full chunk can arrive over a slow link.
+	chunkDeadline = 15 * time.Minute
+)
+
+var (
+	// ErrInvalidUpload is returned for unusable upload session requests.
+	ErrInvalidUpload = errors.New("invalid upload")
+	// ErrUploadOffset is returned when a chunk does not start where the
+	// session left off; the client should resume from the session offset.
+	ErrUploadOffset = errors.New("chunk offset does not match the upload offset")
+	// ErrChunkChecksum is returned when a chunk does not match its checksum.
+	ErrChunkChecksum = errors.New("chunk checksum mismatch")
+	// ErrUploadClosed is returned for chunks sent to a finished session.
+	ErrUploadClosed = errors.New("upload session is not accepting chunks")
+)
+
+// errPartCorrupt marks stored chunks that no longer match their digest.
+var errPartCorrupt = errors.New("stored chunk does not match its checksum")
+
+var sha256HexRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// CreateUploadRequest is the JSON body for POST /api/v1/documents/uploads.
+// With DocumentID the upload becomes a new version of that document;
+// otherwise Name and DocumentType describe a new document.
+type CreateUploadRequest struct {
+	ProjectID     string `json:"project_id"`
+	DocumentID    string `json:"document_id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	DocumentType  string `json:"document_type"`
+	ChangeSummary string `json:"change_summary"`
+	Filename      string `json:"filename" binding:"required"`
+	ContentType   string `json:"content_type"`
+	Size          int64  `json:"size" binding:"required"`
+	SHA256        string `json:"sha256"` // optional hex digest of the whole file
+}
+
+// CreateUpload opens a resumable upload session.
+func (s *Service) CreateUpload(ctx context.Context, req *CreateUploadRequest, userID *uuid.UUID) (*UploadSession, error) {
+	pid, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid project_id", ErrInvalidUpload)
+	}
+	if req.Size <= 0 || req.Size > MaxResumableUploadSize {
+		return nil, fmt.Errorf("%w: size must be between 1 byte and %d GB", ErrInvalidUpload, MaxResumableUploadSize>>30)
+	}
+	digest := strings.ToLower(strings.TrimSpace(req.SHA256))
+	if digest != "" && !sha256HexRe.MatchString(digest) {
+		return nil, fmt.Errorf("%w: sha256 must be 64 hex characters", ErrInvalidUpload)
+	}
+	ft, err := detectFileType(req.ContentType, req.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	now := time.Now().UTC()
+	u := &UploadSession{
+		ID:             uuid.New(),
+		ProjectID:      pid,
+		Name:           req.Name,
+		Description:    req.Description,
+		DocumentType:   DocumentType(req.DocumentType),
+		ChangeSummary:  req.ChangeSummary,
+		Filename:       sanitizeFilename(req.Filename),
+		ContentType:    req.ContentType,
+		FileType:       ft,
+		TotalSize:      req.Size,
+		ExpectedSHA256: digest,
+		Parts:          datatypes.JSON("[]"),
+		Status:         UploadActive,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      now.Add(uploadSessionTTL),
+	}
+	if req.DocumentID != "" {
+		docID, err := uuid.Parse(req.DocumentID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid document_id", ErrInvalidUpload)
+		}
+		doc, err := s.repo.FindByID(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+		if doc.ProjectID != pid {
+			return nil, fmt.Errorf("%w: document belongs to another project", ErrInvalidUpload)
+		}
+		u.DocumentID, u.DocumentType = &doc.ID, doc.DocumentType
+	} else if req.Name == "" || req.DocumentType == "" {
+		return nil, fmt.Errorf("%w: name and document_type are required for a new document", ErrInvalidUpload)
+	}
+	if err := s.repo.CreateUploadSession(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetUpload returns an upload session started by userID.
+func (s *Service) GetUpload(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (*UploadSession, error) {
+	u, err := s.repo.FindUploadSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if userID == nil || u.CreatedBy == nil || *u.CreatedBy != *userID {
+		return nil, ErrForbidden
+	}
+	return u, nil
+}
+
+// WriteChunk stores the chunk read from body at offset. checksum is an
+// Upload-Checksum header value, "sha256 <base64 digest>". When the last
+// byte arrives the file is assembled in the background; poll the session
+// until it is completed.
+func (s *Service) WriteChunk(ctx context.Context, id uuid.UUID, userID *uuid.UUID, offset int64, checksum string, body io.Reader) (*UploadSession, error) {
+	want, err := parseChunkChecksum(checksum)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.GetUpload(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status != UploadActive || time.Now().After(u.ExpiresAt) {
+		return u, ErrUploadClosed
+	}
+	if offset != u.Offset {
+		return u, fmt.Errorf("%w: expected offset %d", ErrUploadOffset, u.Offset)
+	}
+
+	remaining := u.TotalSize - u.Offset
+	key := fmt.Sprintf("uploads/%s/%016d-%s", u.ID, offset, uuid.NewString()[:8])
+	h := sha256.New()
+	counter := &countingReader{r: io.TeeReader(io.LimitReader(body, remaining+1), h)}
+	if _, err := s.storage.UploadReader(ctx, key, counter, "application/octet-stream"); err != nil {
+		_ = s.storage.Delete(ctx, key)
+		return u, fmt.Errorf("failed to store chunk: %w", err)
+	}
+	discard := func(err error) (*UploadSession, error) {
+		_ = s.storage.Delete(ctx, key)
+		return u, err
+	}
+	switch {
+	case counter.n == 0:
+		return discard(fmt.Errorf("%w: empty chunk", ErrInvalidUpload))
+	case counter.n > remaining:
+		return discard(fmt.Errorf("%w: chunk runs past the declared size of %d bytes", ErrInvalidUpload, u.TotalSize))
+	}
+	sum := h.Sum(nil)
+	if string(sum) != string(want) {
+		return discard(ErrChunkChecksum)
+	}
+
+	part := UploadPart{Offset: offset, Size: counter.n, SHA256: hex.EncodeToString(sum), Key: key}
+	ok, err := s.repo.AppendUploadPart(ctx, u.ID, part, time.Now().UTC().Add(uploadSessionTTL))
+	if err != nil {
+		return discard(err)
+	}
+	if !ok {
+		// A concurrent request stored this range first.
+		current, _ := s.repo.FindUploadSession(ctx, u.ID)
+		if current != nil {
+			u = current
+		}
+		return discard(fmt.Errorf("%w: expected offset %d", ErrUploadOffset, u.Offset))
+	}
+	u.Offset += part.Size
+
+	if u.Offset == u.TotalSize {
+		ok, err := s.repo.TransitionUploadSession(ctx, u.ID, UploadActive, map[string]interface{}{
+			"status":     UploadAssembling,
+			"expires_at": time.Now().UTC().Add(uploadSessionTTL),
+		})
+		if err != nil {
+			return u, err
+		}
+		if ok {
+			u.Status = UploadAssembling
+			go s.runAssembly(u.ID)
+		}
+	}
+	return u, nil
+}
+
+// AbortUpload cancels a session and removes its stored chunks.
+func (s *Service) AbortUpload(ctx context.Context, id uuid.UUID, userID *uuid.UUID) (*UploadSession, error) {
+	u, err := s.GetUpload(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status != UploadActive && u.Status != UploadFailed {
+		return u, ErrUploadClosed
+	}
+	ok, err := s.repo.TransitionUploadSession(ctx, u.ID, u.Status, map[string]interface{}{"status": UploadAborted})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return u, ErrUploadClosed
+	}
+	s.deleteParts(ctx, u)
+	u.Status = UploadAborted
+	return u, nil
+}
+
+// runAssembly assembles a session outside the request that completed it.
+func (s *Service) runAssembly(id uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), assemblyTimeout)
+	defer cancel()
+	u, err := s.repo.FindUploadSession(ctx, id)
+	if err != nil {
+		log.Printf("WARNING: upload %s: %v", id, err)
+		return
+	}
+	s.assembleUpload(ctx, u)
+}
+
+// assembleUpload concatenates the stored chunks into a document file,
+// checks it against the declared size and digest, and records it through
+// the same path as a single-request upload. Storage and database errors
+// leave the session assembling so the sweep retries it. The document or
+// version is recorded under an ID reserved on the session first, so a retry
+// after it was recorded only completes the session.
+func (s *Service) assembleUpload(ctx context.Context, u *UploadSession) {
+	if u.ResultID != nil {
+		docID, version, err := s.uploadResult(ctx, u)
+		if err == nil {
+			s.completeUpload(ctx, u, docID, version)
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("WARNING: upload %s: checking for a recorded result, will retry: %v", u.ID, err)
+			return
+		}
+	} else {
+		id := uuid.New()
+		ok, err := s.repo.TransitionUploadSession(ctx, u.ID, UploadAssembling, map[string]interface{}{"result_id": id})
+		if err != nil || !ok {
+			log.Printf("WARNING: upload %s: could not reserve a result ID, will retry: %v", u.ID, err)
+			return
+		}
+		u.ResultID = &id
+	}
+
+	var parts []UploadPart
+	if err := json.Unmarshal(u.Parts, &parts); err != nil {
+		s.failUpload(ctx, u, fmt.Sprintf("unreadable chunk list: %v", err))
+		return
+	}
+	stored, err := s.storage.StoreStream(ctx, u.ProjectID.String(), u.DocumentType, u.Filename, u.ContentType, u.FileType,
+		&partsReader{ctx: ctx, storage: s.storage, parts: parts})
+	if errors.Is(err, errPartCorrupt) {
+		s.failUpload(ctx, u, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("WARNING: upload %s: assembly failed, will retry: %v", u.ID, err)
+		return
+	}
+	if stored.Size != u.TotalSize || (u.ExpectedSHA256 != "" && stored.SHA256 != u.ExpectedSHA256) {
+		_ = s.storage.Delete(ctx, stored.Key)
+		s.failUpload(ctx, u, fmt.Sprintf("assembled file (%d bytes, sha256 %s) does not match the declared size or sha256", stored.Size, stored.SHA256))
+		return
+	}
+
+	var docID uuid.UUID
+	var version int
+	if u.DocumentID == nil {
+		doc, err := s.createDocument(ctx, *u.ResultID, &UploadRequest{
+			ProjectID:    u.ProjectID.String(),
+			Name:         u.Name,
+			Description:  u.Description,
+			DocumentType: string(u.DocumentType),
+		}, stored, u.CreatedBy)
+		if err != nil {
+			log.Printf("WARNING: upload %s: failed to record document, will retry: %v", u.ID, err)
+			return
+		}
+		docID, version = doc.ID, doc.CurrentVersion
+	} else {
+		doc, err := s.repo.FindByID(ctx, *u.DocumentID)
+		if err != nil {
+			_ = s.storage.Delete(ctx, stored.Key)
+			s.failUpload(ctx, u, "the document was deleted during the upload")
+			return
+		}
+		v, err := s.addVersion(ctx, *u.ResultID, doc, &VersionUploadRequest{ChangeSummary: u.ChangeSummary}, stored, u.CreatedBy)
+		if err != nil {
+			log.Printf("WARNING: upload %s: failed to record version, will retry: %v", u.ID, err)
+			return
+		}
+		docID, version = doc.ID, v.VersionNumber
+	}
+	s.completeUpload(ctx, u, docID, version)
+}
+
+// uploadResult returns the document and version recorded under the
+// session's reserved ID, or gorm.ErrRecordNotFound when none was.
+func (s *Service) uploadResult(ctx context.Context, u *UploadSession) (uuid.UUID, int, error) {
+	if u.DocumentID == nil {
+		doc, err := s.repo.FindByID(ctx, *u.ResultID)
+		if err != nil {
+			return uuid.Nil, 0, err
+		}
+		return doc.ID, 1, nil
+	}
+	v, err := s.repo.FindVersionByID(ctx, *u.ResultID)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	return v.DocumentID, v.VersionNumber, nil
+}
+
+func (s *Service) completeUpload(ctx context.Context, u *UploadSession, docID uuid.UUID, version int) {
+	if _, err := s.repo.TransitionUploadSession(ctx, u.ID, UploadAssembling, map[string]interface{}{
+		"status":         UploadCompleted,
+		"document_id":    docID,
+		"result_version": version,
+	}); err != nil {
+		log.Printf("WARNING: upload %s: document %s recorded but session not updated, will retry: %v", u.ID, docID, err)
+		return
+	}
+	s.deleteParts(ctx, u)
+}
+
+func (s *Service) failUpload(ctx context.Context, u *UploadSession, reason string) {
+	log.Printf("WARNING: upload %s failed: %s", u.ID, reason)
+	if _, err := s.repo.TransitionUploadSession(ctx, u.ID, u.Status, map[string]interface{}{
+		"status": UploadFailed,
+		"error":  reason,
+	}); err != nil {
+		log.Printf("WARNING: upload %s: %v", u.ID, err)
+	}
+	s.deleteParts(ctx, u)
+}
+
+func (s *Service) deleteParts(ctx context.Context, u *UploadSession) {
+	var parts []UploadPart
+	_ = json.Unmarshal(u.Parts, &parts)
+	for _, p := range parts {
+		if err := s.storage.Delete(ctx, p.Key); err != nil {
+			log.Printf("WARNING: upload %s: failed to delete chunk %s: %v", u.ID, p.Key, err)
+		}
+	}
+}
+
+// ExpireUploads closes abandoned sessions and retries stalled assemblies,
+// up to limit of each.
+func (s *Service) ExpireUploads(ctx context.Context, limit int) (expired, retried int, err error) {
+	now := time.Now().UTC()
+	stale, err := s.repo.FindExpiredUploads(ctx, now, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := range stale {
+		ok, err := s.repo.TransitionUploadSession(ctx, stale[i].ID, UploadActive, map[string]interface{}{"status": UploadExpired})
+		if err != nil {
+			return expired, retried, err
+		}
+		if ok {
+			s.deleteParts(ctx, &stale[i])
+			expired++
+		}
+	}
+
+	stalled, err := s.repo.FindStalledAssemblies(ctx, now.Add(-assemblyStallAfter), limit)
+	if err != nil {
+		return expired, retried, err
+	}
+	for i := range stalled {
+		u := &stalled[i]
+		if now.After(u.ExpiresAt) {
+			s.failUpload(ctx, u, "assembly did not complete before the session expired")
+			continue
+		}
+		ok, err := s.repo.ClaimUploadAssembly(ctx, u.ID, now.Add(-assemblyStallAfter))
+		if err != nil {
+			return expired, retried, err
+		}
+		if ok {
+			s.assembleUpload(ctx, u)
+			retried++
+		}
+	}
+	return expired, retried, nil
+}
+
+// StartUploadSweep runs ExpireUploads every interval until ctx is cancelled.
+func (s *Service) StartUploadSweep(ctx context.Context, interval time.Duration, batch int) {
+	if interval <= 0 || batch <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, retried, err := s.ExpireUploads(ctx, batch)
+				if err != nil {
+					log.Printf("WARNING: upload sweep failed: %v", err)
+					continue
+				}
+				if expired+retried > 0 {
+					log.Printf("📦 Upload sweep expired %d session(s), retried %d assembly(ies)", expired, retried)
+				}
+			}
+		}
+	}()
+}
+
+// parseChunkChecksum decodes an Upload-Checksum header, "sha256 <base64>".
+func parseChunkChecksum(header string) ([]byte, error) {
+	alg, value, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(alg, "sha256") {
+		return nil, fmt.Errorf("%w: Upload-Checksum must be \"sha256 <base64 digest>\"", ErrInvalidUpload)
+	}
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("%w: Upload-Checksum is not a base64 SHA-256 digest", ErrInvalidUpload)
+	}
+	return sum, nil
+}
+
+// partsReader reads stored chunks in order, opening each one as it is
+// reached and checking it against its recorded size and digest.
+type partsReader struct {
+	ctx     context.Context
+	storage *StorageService
+	parts   []UploadPart
+	cur     io.ReadCloser
+	h       hash.Hash
+	n       int64
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			rc, _, err := r.storage.DownloadStream(r.ctx, r.parts[0].Key)
+			if err != nil {
+				return 0, fmt.Errorf("failed to open chunk at offset %d: %w", r.parts[0].Offset, err)
+			}
+			r.cur, r.h, r.n = rc, sha256.New(), 0
+		}
+		n, err := r.cur.Read(p)
+		r.h.Write(p[:n])
+		r.n += int64(n)
+		if err == io.EOF {
+			part := r.parts[0]
+			r.cur.Close()
+			r.cur, r.parts = nil, r.parts[1:]
+			if r.n != part.Size || hex.EncodeToString(r.h.Sum(nil)) != part.SHA256 {
+				return n, fmt.Errorf("%w: chunk at offset %d", errPartCorrupt, part.Offset)
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,67 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/storage"
+)
+
+func TestParseChunkChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("chunk"))
+	got, err := parseChunkChecksum("sha256 " + base64.StdEncoding.EncodeToString(sum[:]))
+	if err != nil || !bytes.Equal(got, sum[:]) {
+		t.Fatalf("got %x, %v", got, err)
+	}
+	for _, bad := range []string{"", "md5 AAAA", "sha256", "sha256 not-base64!", "sha256 " + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := parseChunkChecksum(bad); !errors.Is(err, ErrInvalidUpload) {
+			t.Errorf("%q: expected ErrInvalidUpload, got %v", bad, err)
+		}
+	}
+}
+
+func TestPartsReader(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(storage.NewURLSigner([]byte("k"), "http://localhost/objects"))
+	svc := NewStorageService(store)
+
+	chunks := [][]byte{[]byte("first chunk "), []byte("second "), []byte("third")}
+	var parts []UploadPart
+	var offset int64
+	for i, c := range chunks {
+		key := "uploads/test/" + string(rune('a'+i))
+		if _, err := store.Upload(ctx, key, bytes.NewReader(c), "application/octet-stream"); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(c)
+		parts = append(parts, UploadPart{Offset: offset, Size: int64(len(c)), SHA256: hex.EncodeToString(sum[:]), Key: key})
+		offset += int64(len(c))
+	}
+
+	got, err := io.ReadAll(&partsReader{ctx: ctx, storage: svc, parts: parts})
+	if err != nil || string(got) != "first chunk second third" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	// A chunk changed in storage after it was accepted.
+	if _, err := store.Upload(ctx, parts[1].Key, bytes.NewReader([]byte("SECOND ")), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(&partsReader{ctx: ctx, storage: svc, parts: parts}); !errors.Is(err, errPartCorrupt) {
+		t.Errorf("expected errPartCorrupt, got %v", err)
+	}
+
+	// A chunk missing from storage fails the read instead of truncating it.
+	if err := store.Delete(ctx, parts[0].Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(&partsReader{ctx: ctx, storage: svc, parts: parts}); err == nil || errors.Is(err, errPartCorrupt) {
+		t.Errorf("expected a storage error, got %v", err)
+	}
+}
@@ -1,34 +1,95 @@
 package documents
 
-import "github.com/gin-gonic/gin"
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
 
 // RegisterRoutes wires all document endpoints under the given router group.
-// Expected base: /api/v1 (caller's group).
+// Expected base: /api/v1 (caller's group), which must require authentication.
+// Routes on an existing document are authorized by the caller's role in the
+// document's project; upload, upload sessions, list, access history, PDF
+// generation, signing certificates and evidence packages authorize in the
+// handler.
 func RegisterRoutes(v1 *gin.RouterGroup, h *Handler) {
+	can := func(perm string) gin.HandlerFunc {
+		return middleware.RequireProjectPermission(h.authz, perm, h.documentProject)
+	}
+
 	docs := v1.Group("/documents")
 	{
 		// Core CRUD
 		docs.POST("/upload", h.Upload)
 		docs.GET("", h.List)
-		docs.GET("/:id", h.Download)
-		docs.GET("/:id/metadata", h.GetMetadata)
-		docs.DELETE("/:id", h.Delete)
+		docs.GET("/:id", can(middleware.PermDocumentsRead), h.Download)
+		docs.GET("/:id/metadata", can(middleware.PermDocumentsRead), h.GetMetadata)
+		docs.DELETE("/:id", can(middleware.PermDocumentsDelete), h.Delete)
+		docs.GET("/:id/integrity", can(middleware.PermDocumentsRead), h.CheckIntegrity)
+		docs.POST("/:id/reindex", can(middleware.PermDocumentsWrite), h.ReindexText)
 
 		// Versioning
-		docs.POST("/:id/versions", h.UploadVersion)
-		docs.GET("/:id/versions", h.ListVersions)
-		docs.GET("/:id/versions/:version", h.GetVersion)
+		docs.POST("/:id/versions", can(middleware.PermDocumentsWrite), h.UploadVersion)
+		docs.GET("/:id/versions", can(middleware.PermDocumentsRead), h.ListVersions)
+		docs.GET("/:id/versions/:version", can(middleware.PermDocumentsRead), h.GetVersion)
+
+		// Access history
+		docs.GET("/access-logs", h.ListAccessLogs)
+		docs.GET("/access-logs/export", h.ExportAccessLogs)
+		docs.GET("/access-logs/stats", h.AccessStats)
+		docs.GET("/access-logs/anomalies", h.AccessAnomalies)
+		docs.GET("/:id/access-logs", can(middleware.PermDocumentsApprove), h.DocumentAccessLogs)
+
+		// External share links
+		docs.POST("/:id/share-links", can(middleware.PermDocumentsWrite), h.CreateShareLink)
+		docs.GET("/:id/share-links", can(middleware.PermDocumentsWrite), h.ListShareLinks)
+		docs.DELETE("/:id/share-links/:linkId", can(middleware.PermDocumentsWrite), h.RevokeShareLink)
+
+		// Resumable uploads. Sessions are authorized by their project in
+		// the handler and are only visible to the user who opened them.
+		docs.POST("/uploads", h.CreateUpload)
+		docs.HEAD("/uploads/:uploadId", h.GetUpload)
+		docs.GET("/uploads/:uploadId", h.GetUpload)
+		docs.PATCH("/uploads/:uploadId", h.WriteChunk)
+		docs.DELETE("/uploads/:uploadId", h.AbortUpload)
 
 		// PDF Generation
 		docs.POST("/generate-pdf", h.GeneratePDF)
 
-		// Digital Signature Verification
-		docs.POST("/:id/verify-signature", h.VerifySignature)
+		// Digital Signatures
+		docs.POST("/:id/verify-signature", can(middleware.PermDocumentsRead), h.VerifySignature)
+		docs.POST("/:id/sign", can(middleware.PermDocumentsApprove), h.SignDocument)
+		docs.POST("/signing-certificates", h.AddSigningCertificate)
+		docs.GET("/signing-certificates", h.ListSigningCertificates)
+		docs.DELETE("/signing-certificates/:certId", h.RevokeSigningCertificate)
+		docs.POST("/trust-anchors", h.AddTrustAnchors)
+		docs.GET("/trust-anchors", h.ListTrustAnchors)
+		docs.DELETE("/trust-anchors/:bundleId", h.DeleteTrustAnchors)
 
-		// Compliance Workflow Engine
-		docs.POST("/:id/transition", h.Transition)
-		docs.GET("/:id/workflow", h.GetWorkflowState)
-		docs.POST("/workflows", h.CreateWorkflowTemplate)
+		// Verification evidence packages
+		docs.POST("/evidence-packages", h.ExportEvidencePackage)
+		docs.GET("/evidence-packages", h.ListEvidencePackages)
+
+		// Compliance Workflow Engine. The permission for the specific
+		// transition is checked by the workflow state machine.
+		docs.POST("/:id/transition", can(middleware.PermDocumentsRead), h.Transition)
+		docs.GET("/:id/workflow", can(middleware.PermDocumentsRead), h.GetWorkflowState)
+		docs.POST("/workflows", middleware.RequirePlatformRole(), h.CreateWorkflowTemplate)
 		docs.GET("/workflows", h.ListWorkflowTemplates)
 	}
 }
+
+// RegisterObjectRoutes serves signed download URLs for backends without
+// their own HTTP endpoint. r must not require a bearer token; the URL
+// signature authorizes the request.
+func RegisterObjectRoutes(r *gin.Engine, h *Handler) {
+	r.GET("/api/v1/storage/objects/*key", h.ServeObject)
+}
+
+// RegisterShareRoutes serves external share links. r must not require a
+// bearer token; the link token authorizes the request.
+func RegisterShareRoutes(r *gin.Engine, h *Handler) {
+	r.GET("/api/v1/share/:token", h.ViewShared)
+	r.GET("/api/v1/share/:token/download", h.DownloadShared)
+	r.POST("/api/v1/share/:token/download", h.DownloadShared)
+}
@@ -6,6 +6,9 @@ import (
 	"mime/multipart"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+
 	"github.com/google/uuid"
 )
 
@@ -16,7 +19,17 @@ type multipartFile = *multipart.FileHeader
 type Service struct {
 	repo    *Repository
 	storage *StorageService
-	ipfs    *IPFSUploader // optional; nil when IPFS_ENABLED=false
+	ipfs    *IPFSUploader            // optional; nil when IPFS_ENABLED=false
+	vault   encryption.SecureStorage // encrypts signing keys; nil disables signing
+	tsa     *security.TSAClient      // optional signature timestamps
+
+	revocation        *security.RevocationChecker // online OCSP/CRL checks; nil uses embedded data only
+	requireRevocation bool                        // missing revocation status makes a signature INDETERMINATE
+
+	textIndexer TextIndexer   // full-text search index; nil disables extraction
+	textWake    chan struct{} // starts an extraction run early
+
+	boundaries BoundarySource // project boundaries for evidence packages; optional
 }
 
 // NewService creates a new document Service.
@@ -34,11 +47,19 @@ func NewServiceWithIPFS(repo *Repository, storage *StorageService, ipfs *IPFSUpl
 func (s *Service) UploadFile(ctx context.Context, req *UploadRequest, fh *multipart.FileHeader, userID *uuid.UUID) (*Document, error) {
 	docType := DocumentType(req.DocumentType)
 
-	// Stream to S3.
-	key, bucket, fileType, size, err := s.storage.UploadFile(ctx, req.ProjectID, docType, fh)
+	// Stream to S3, hashing on the way.
+	stored, err := s.storage.UploadFile(ctx, req.ProjectID, docType, fh)
 	if err != nil {
 		return nil, fmt.Errorf("storage upload failed: %w", err)
 	}
+	return s.createDocument(ctx, uuid.New(), req, stored, userID)
+}
+
+// createDocument records a stored file as a new document. It is shared by
+// single-request and resumable uploads; on failure the object is removed.
+func (s *Service) createDocument(ctx context.Context, id uuid.UUID, req *UploadRequest, stored *StoredFile, userID *uuid.UUID) (*Document, error) {
+	docType := DocumentType(req.DocumentType)
+	key := stored.Key
 
 	pid, err := uuid.Parse(req.ProjectID)
 	if err != nil {
@@ -47,24 +68,29 @@ func (s *Service) UploadFile(ctx context.Context, req *UploadRequest, fh *multip
 	}
 
 	doc := &Document{
-		ID:           uuid.New(),
+		ID:           id,
 		ProjectID:    pid,
 		Name:         req.Name,
 		Description:  req.Description,
 		DocumentType: docType,
-		FileType:     fileType,
-		FileSize:     size,
+		FileType:     stored.FileType,
+		FileSize:     stored.Size,
 		S3Key:        key,
-		S3Bucket:     bucket,
+		S3Bucket:     stored.Bucket,
+		ContentHash:  stored.SHA256,
 		Status:       DocumentStatusDraft,
 		UploadedBy:   userID,
 		UploadedAt:   time.Now().UTC(),
 	}
+	doc.DuplicateOf = s.findDuplicate(ctx, pid, stored.SHA256)
 
+	s.assignWorkflow(ctx, doc)
+	s.queueText(doc)
 	if err := s.repo.Create(ctx, doc); err != nil {
 		_ = s.storage.Delete(ctx, key)
 		return nil, err
 	}
+	s.wakeTextExtraction()
 
 	// Optionally pin to IPFS (best-effort: failure is logged, not fatal).
 	s.pinToIPFS(ctx, doc, key)
@@ -78,6 +104,16 @@ func (s *Service) UploadFile(ctx context.Context, req *UploadRequest, fh *multip
 	return doc, nil
 }
 
+// ProjectIDOf returns the project a document belongs to. It is used to
+// authorize document-scoped routes before the handler runs.
+func (s *Service) ProjectIDOf(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	doc, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return doc.ProjectID, nil
+}
+
 // List returns a paginated list of documents matching the filter.
 func (s *Service) List(ctx context.Context, filter ListFilter) (*ListResponse, error) {
 	return s.repo.FindAll(ctx, filter)
@@ -100,12 +136,17 @@ func (s *Service) GetMetadata(ctx context.Context, id uuid.UUID, userID *uuid.UU
 	return doc, nil
 }
 
-// GenerateDownloadURL returns a presigned S3 URL (15-minute TTL) and logs DOWNLOAD.
+// GenerateDownloadURL re-checks the file against its recorded SHA-256, then
+// returns a presigned S3 URL (15-minute TTL) and logs DOWNLOAD. A file that
+// fails the check is not served; ErrIntegrity is returned instead.
 func (s *Service) GenerateDownloadURL(ctx context.Context, id uuid.UUID, userID *uuid.UUID, ipAddr, ua string) (string, *Document, error) {
 	doc, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return "", nil, err
 	}
+	if err := s.verifyForDownload(ctx, doc, userID, ipAddr, ua); err != nil {
+		return "", nil, err
+	}
 	url, err := s.storage.GeneratePresignedURL(ctx, doc.S3Key)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to generate download URL: %w", err)
@@ -134,20 +175,30 @@ func (s *Service) UploadVersion(ctx context.Context, docID uuid.UUID, req *Versi
 		return nil, fmt.Errorf("document not found: %w", err)
 	}
 
-	key, bucket, _, size, err := s.storage.UploadFile(ctx, doc.ProjectID.String(), doc.DocumentType, fh)
+	stored, err := s.storage.UploadFile(ctx, doc.ProjectID.String(), doc.DocumentType, fh)
 	if err != nil {
 		return nil, fmt.Errorf("storage upload failed: %w", err)
 	}
+	return s.addVersion(ctx, uuid.New(), doc, req, stored, userID)
+}
+
+// addVersion records a stored file as the next version of doc. It is shared
+// by single-request and resumable uploads; on failure the object is removed.
+func (s *Service) addVersion(ctx context.Context, id uuid.UUID, doc *Document, req *VersionUploadRequest, stored *StoredFile, userID *uuid.UUID) (*DocumentVersion, error) {
+	docID := doc.ID
+	key := stored.Key
 
 	newVersion := doc.CurrentVersion + 1
 
 	version := &DocumentVersion{
-		ID:            uuid.New(),
+		ID:            id,
 		DocumentID:    docID,
 		VersionNumber: newVersion,
 		S3Key:         key,
-		S3Bucket:      bucket,
-		FileSize:      size,
+		S3Bucket:      stored.Bucket,
+		FileSize:      stored.Size,
+		ContentHash:   stored.SHA256,
+		DuplicateOf:   s.findDuplicate(ctx, doc.ProjectID, stored.SHA256),
 		ChangeSummary: req.ChangeSummary,
 		UploadedBy:    userID,
 		UploadedAt:    time.Now().UTC(),
@@ -161,11 +212,23 @@ func (s *Service) UploadVersion(ctx context.Context, docID uuid.UUID, req *Versi
 	// Update parent document to track the latest version.
 	doc.CurrentVersion = newVersion
 	doc.S3Key = key
-	doc.S3Bucket = bucket
-	doc.FileSize = size
+	doc.S3Bucket = stored.Bucket
+	doc.FileSize = stored.Size
+	doc.ContentHash = stored.SHA256
+	// The old CID names the previous version's content; it is replaced
+	// when the new version is pinned.
+	doc.IPFSCID = ""
+	s.queueText(doc)
 	if err := s.repo.Update(ctx, doc); err != nil {
 		fmt.Printf("WARNING: failed to update document current_version to %d: %v\n", newVersion, err)
 	}
+	s.wakeTextExtraction()
+	if cid := s.pinToIPFS(ctx, doc, key); cid != "" {
+		version.IPFSCID = cid
+		if err := s.repo.SetVersionIPFSCID(ctx, version.ID, cid); err != nil {
+			fmt.Printf("WARNING: %v\n", err)
+		}
+	}
 
 	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
 		DocumentID:  docID,
@@ -204,6 +267,7 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID, userID *uuid.UUID, i
 	if err := s.storage.Delete(ctx, doc.S3Key); err != nil {
 		fmt.Printf("WARNING: S3 delete failed for key %q: %v\n", doc.S3Key, err)
 	}
+	s.removeText(ctx, id)
 	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
 		DocumentID:  id,
 		UserID:      userID,
@@ -215,31 +279,43 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID, userID *uuid.UUID, i
 	return nil
 }
 
+// findDuplicate flags content already stored in the project. Lookup failures
+// are logged; the upload is not blocked.
+func (s *Service) findDuplicate(ctx context.Context, projectID uuid.UUID, hash string) *uuid.UUID {
+	dup, err := s.repo.FindDuplicate(ctx, projectID, hash)
+	if err != nil {
+		fmt.Printf("WARNING: duplicate check skipped: %v\n", err)
+		return nil
+	}
+	return dup
+}
+
 // ─── IPFS helpers ─────────────────────────────────────────────────────────────
 
 // pinToIPFS downloads the document from S3 and pins it to IPFS.
-// It updates the document's IPFS_CID field in the DB on success.
+// It updates the document's IPFS_CID field in the DB on success and returns
+// the CID, or "" when nothing was pinned.
 // All errors are non-fatal — they are logged to stderr as warnings.
-func (s *Service) pinToIPFS(ctx context.Context, doc *Document, s3Key string) {
+func (s *Service) pinToIPFS(ctx context.Context, doc *Document, s3Key string) string {
 	if s.ipfs == nil {
-		return // IPFS disabled
+		return "" // IPFS disabled
 	}
 
 	// Download bytes from S3.
 	data, err := s.storage.DownloadBytes(ctx, s3Key)
 	if err != nil {
 		fmt.Printf("WARNING: IPFS pin skipped — failed to download %q from S3: %v\n", s3Key, err)
-		return
+		return ""
 	}
 
 	// Pin to IPFS.
 	cid, err := s.ipfs.PinDocument(ctx, doc.ID.String(), data)
 	if err != nil {
 		fmt.Printf("WARNING: IPFS pin failed for document %s: %v\n", doc.ID, err)
-		return
+		return ""
 	}
 	if cid == "" {
-		return
+		return ""
 	}
 
 	// Persist the CID in the document record.
@@ -247,4 +323,5 @@ func (s *Service) pinToIPFS(ctx context.Context, doc *Document, s3Key string) {
 	if err := s.repo.Update(ctx, doc); err != nil {
 		fmt.Printf("WARNING: failed to save IPFS CID for document %s: %v\n", doc.ID, err)
 	}
+	return cid
 }
@@ -0,0 +1,352 @@
+package documents
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// ─── External Share Links ─────────────────────────────────────────────────────
+
+const (
+	// defaultShareTTL applies when a link is created without an expiry.
+	defaultShareTTL = 7 * 24 * time.Hour
+	// maxShareTTL caps how long a link may stay valid.
+	maxShareTTL = 90 * 24 * time.Hour
+	// maxShareFailures locks a link after this many wrong passwords.
+	maxShareFailures = 10
+	// shareURLTTL is how long a download URL issued through a link lasts.
+	// Each URL counts as one download, so it is kept short.
+	shareURLTTL = 2 * time.Minute
+	// minSharePasswordLen is the shortest password accepted for a link.
+	minSharePasswordLen = 8
+)
+
+var (
+	// ErrInvalidShareLink is returned for unusable share link requests.
+	ErrInvalidShareLink = errors.New("invalid share link request")
+	// ErrShareLinkNotFound is returned for unknown share tokens.
+	ErrShareLinkNotFound = errors.New("share link not found")
+	// ErrShareLinkUnavailable is returned for links that expired, were
+	// revoked, ran out of downloads or were locked.
+	ErrShareLinkUnavailable = errors.New("share link is no longer available")
+	// ErrSharePassword is returned when a link's password is missing or wrong.
+	ErrSharePassword = errors.New("share link password is missing or incorrect")
+)
+
+// CreateShareLinkRequest is the JSON body for
+// POST /api/v1/documents/:id/share-links.
+type CreateShareLinkRequest struct {
+	Version      *int       `json:"version"`    // share this version only; default follows the current version
+	ExpiresAt    *time.Time `json:"expires_at"` // default 7 days, at most 90
+	Password     string     `json:"password"`
+	MaxDownloads int        `json:"max_downloads"` // 0 means unlimited
+	Label        string     `json:"label"`         // e.g. who the link was sent to
+}
+
+// ShareLinkCreated is returned once when a link is created; the token is
+// not stored and cannot be shown again.
+type ShareLinkCreated struct {
+	Link  *DocumentShareLink `json:"share_link"`
+	Token string             `json:"token"`
+	Path  string             `json:"path"`
+}
+
+// SharedDocument is what a link's recipient sees before downloading. File
+// details are withheld until the password is given.
+type SharedDocument struct {
+	RequiresPassword   bool       `json:"requires_password"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+	DownloadsRemaining *int       `json:"downloads_remaining,omitempty"`
+	Name               string     `json:"name,omitempty"`
+	FileType           FileType   `json:"file_type,omitempty"`
+	FileSize           int64      `json:"file_size,omitempty"`
+	Version            int        `json:"version,omitempty"`
+	UploadedAt         *time.Time `json:"uploaded_at,omitempty"`
+}
+
+// SharePath is the public path of a share link.
+func SharePath(token string) string { return "/api/v1/share/" + token }
+
+// CreateShareLink creates an expiring link to a document, or to one of its
+// versions, for someone outside the project.
+func (s *Service) CreateShareLink(ctx context.Context, docID uuid.UUID, req *CreateShareLinkRequest, userID *uuid.UUID, ipAddr, ua string) (*ShareLinkCreated, error) {
+	doc, err := s.repo.FindByID(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	now := time.Now().UTC()
+	expiresAt := now.Add(defaultShareTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.UTC()
+	}
+	switch {
+	case !expiresAt.After(now):
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidShareLink)
+	case expiresAt.Sub(now) > maxShareTTL:
+		return nil, fmt.Errorf("%w: links may be valid for at most %d days", ErrInvalidShareLink, int(maxShareTTL.Hours()/24))
+	case req.MaxDownloads < 0:
+		return nil, fmt.Errorf("%w: max_downloads cannot be negative", ErrInvalidShareLink)
+	case req.Password != "" && len(req.Password) < minSharePasswordLen:
+		return nil, fmt.Errorf("%w: password must be at least %d characters", ErrInvalidShareLink, minSharePasswordLen)
+	}
+	if req.Version != nil {
+		if _, err := s.shareSource(ctx, doc, *req.Version); err != nil {
+			return nil, fmt.Errorf("%w: version %d is not available", ErrInvalidShareLink, *req.Version)
+		}
+	}
+
+	token, hash := newShareToken()
+	link := &DocumentShareLink{
+		ID:            uuid.New(),
+		DocumentID:    doc.ID,
+		ProjectID:     doc.ProjectID,
+		VersionNumber: req.Version,
+		Label:         req.Label,
+		TokenHash:     hash,
+		ExpiresAt:     expiresAt,
+		MaxDownloads:  req.MaxDownloads,
+		CreatedBy:     userID,
+		CreatedAt:     now,
+	}
+	if req.Password != "" {
+		if link.PasswordHash, err = utils.HashPassword(req.Password); err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+	}
+	if err := s.repo.CreateShareLink(ctx, link); err != nil {
+		return nil, err
+	}
+	s.logShare(ctx, link, userID, ActionShareCreate, ipAddr, ua, map[string]any{
+		"expires_at": expiresAt, "max_downloads": req.MaxDownloads, "version": req.Version,
+	})
+	link.describe(now)
+	return &ShareLinkCreated{Link: link, Token: token, Path: SharePath(token)}, nil
+}
+
+// ListShareLinks returns a document's usable links, or all of them with all.
+func (s *Service) ListShareLinks(ctx context.Context, docID uuid.UUID, all bool) ([]DocumentShareLink, error) {
+	now := time.Now().UTC()
+	links, err := s.repo.ListShareLinks(ctx, docID, all, now, maxShareFailures)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		links[i].describe(now)
+	}
+	return links, nil
+}
+
+// RevokeShareLink disables a link immediately.
+func (s *Service) RevokeShareLink(ctx context.Context, docID, linkID uuid.UUID, userID *uuid.UUID, ipAddr, ua string) error {
+	ok, err := s.repo.RevokeShareLink(ctx, docID, linkID, userID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: no active link %s on this document", ErrShareLinkNotFound, linkID)
+	}
+	s.logShare(ctx, &DocumentShareLink{ID: linkID, DocumentID: docID}, userID, ActionShareRevoke, ipAddr, ua, nil)
+	return nil
+}
+
+// ViewSharedDocument describes the document behind a link. File details
+// are only included once the password, if any, is given.
+func (s *Service) ViewSharedDocument(ctx context.Context, token, password, ipAddr, ua string) (*SharedDocument, error) {
+	link, doc, err := s.openShareLink(ctx, token, ipAddr, ua)
+	if err != nil {
+		return nil, err
+	}
+	view := &SharedDocument{RequiresPassword: link.PasswordHash != "", ExpiresAt: link.ExpiresAt}
+	if link.MaxDownloads > 0 {
+		remaining := link.MaxDownloads - link.DownloadCount
+		view.DownloadsRemaining = &remaining
+	}
+	if link.PasswordHash != "" && password == "" {
+		s.logShare(ctx, link, nil, ActionShareView, ipAddr, ua, map[string]any{"details_withheld": true})
+		return view, nil
+	}
+	if err := s.checkSharePassword(ctx, link, password, ipAddr, ua); err != nil {
+		return nil, err
+	}
+	src, err := s.shareSource(ctx, doc, link.version(doc))
+	if err != nil {
+		s.logShare(ctx, link, nil, ActionShareDenied, ipAddr, ua, map[string]any{"reason": "version unavailable"})
+		return nil, ErrShareLinkUnavailable
+	}
+	view.Name, view.FileType, view.FileSize = doc.Name, doc.FileType, src.size
+	view.Version, view.UploadedAt = src.version, &src.uploadedAt
+	_ = s.repo.RecordShareAccess(ctx, link.ID, time.Now().UTC(), false)
+	s.logShare(ctx, link, nil, ActionShareView, ipAddr, ua, map[string]any{"version": src.version})
+	return view, nil
+}
+
+// DownloadShared verifies the shared file and returns a short-lived
+// download URL for it. Each call counts against the link's download limit.
+func (s *Service) DownloadShared(ctx context.Context, token, password, ipAddr, ua string) (string, *SharedDocument, error) {
+	link, doc, err := s.openShareLink(ctx, token, ipAddr, ua)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.checkSharePassword(ctx, link, password, ipAddr, ua); err != nil {
+		return "", nil, err
+	}
+	src, err := s.shareSource(ctx, doc, link.version(doc))
+	if err != nil {
+		s.logShare(ctx, link, nil, ActionShareDenied, ipAddr, ua, map[string]any{"reason": "version unavailable"})
+		return "", nil, ErrShareLinkUnavailable
+	}
+	if src.hash != "" {
+		res := s.checkObject(ctx, src.key, src.hash, src.size)
+		if res.Error != "" {
+			return "", nil, fmt.Errorf("failed to verify document content: %s", res.Error)
+		}
+		if res.Status != IntegrityVerified {
+			s.logShare(ctx, link, nil, ActionIntegrityFailed, ipAddr, ua, map[string]any{"version": src.version, "status": res.Status})
+			return "", nil, fmt.Errorf("%w: %s", ErrIntegrity, res.Status)
+		}
+	}
+
+	now := time.Now().UTC()
+	ok, err := s.repo.ClaimShareDownload(ctx, link.ID, now, maxShareFailures)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		s.logShare(ctx, link, nil, ActionShareDenied, ipAddr, ua, map[string]any{"reason": "no longer available"})
+		return "", nil, ErrShareLinkUnavailable
+	}
+	url, err := s.storage.GeneratePresignedURLFor(ctx, src.key, shareURLTTL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate download URL: %w", err)
+	}
+	s.logShare(ctx, link, nil, ActionShareDownload, ipAddr, ua, map[string]any{
+		"version": src.version, "content_hash": src.hash, "download": link.DownloadCount + 1,
+	})
+	return url, &SharedDocument{
+		ExpiresAt: link.ExpiresAt, Name: doc.Name, FileType: doc.FileType,
+		FileSize: src.size, Version: src.version, UploadedAt: &src.uploadedAt,
+	}, nil
+}
+
+// openShareLink resolves a token to a usable link and its document.
+// Refusals of known links are logged against the link.
+func (s *Service) openShareLink(ctx context.Context, token, ipAddr, ua string) (*DocumentShareLink, *Document, error) {
+	if token == "" {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	link, err := s.repo.FindShareLinkByTokenHash(ctx, hashShareToken(token))
+	if err != nil {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	if status := link.status(time.Now().UTC()); status != ShareLinkActive {
+		s.logShare(ctx, link, nil, ActionShareDenied, ipAddr, ua, map[string]any{"reason": status})
+		return nil, nil, fmt.Errorf("%w: %s", ErrShareLinkUnavailable, status)
+	}
+	doc, err := s.repo.FindByID(ctx, link.DocumentID)
+	if err != nil {
+		// The document was deleted after the link was made.
+		return nil, nil, ErrShareLinkUnavailable
+	}
+	return link, doc, nil
+}
+
+func (s *Service) checkSharePassword(ctx context.Context, link *DocumentShareLink, password, ipAddr, ua string) error {
+	if link.PasswordHash == "" {
+		return nil
+	}
+	if password != "" && utils.CheckPassword(password, link.PasswordHash) == nil {
+		return nil
+	}
+	if err := s.repo.RecordShareAccess(ctx, link.ID, time.Now().UTC(), true); err != nil {
+		return err
+	}
+	s.logShare(ctx, link, nil, ActionShareDenied, ipAddr, ua, map[string]any{"reason": "wrong password", "attempt": link.FailedAttempts + 1})
+	return ErrSharePassword
+}
+
+// shareSource resolves the object behind version of doc.
+func (s *Service) shareSource(ctx context.Context, doc *Document, version int) (evidenceSource, error) {
+	if version == doc.CurrentVersion {
+		return evidenceSource{
+			version: doc.CurrentVersion, key: doc.S3Key, hash: doc.ContentHash,
+			size: doc.FileSize, uploadedAt: doc.UploadedAt, tracked: true,
+		}, nil
+	}
+	// The first upload has no version row once it has been replaced.
+	v, err := s.repo.FindVersionByNumber(ctx, doc.ID, version)
+	if err != nil {
+		return evidenceSource{}, err
+	}
+	return evidenceSource{
+		version: v.VersionNumber, key: v.S3Key, hash: v.ContentHash,
+		size: v.FileSize, uploadedAt: v.UploadedAt, tracked: true,
+	}, nil
+}
+
+func (s *Service) logShare(ctx context.Context, link *DocumentShareLink, userID *uuid.UUID, action AccessAction, ipAddr, ua string, details map[string]any) {
+	entry := &DocumentAccessLog{
+		DocumentID:  link.DocumentID,
+		UserID:      userID,
+		Action:      action,
+		IPAddress:   ipAddr,
+		UserAgent:   ua,
+		ShareLinkID: &link.ID,
+		PerformedAt: time.Now().UTC(),
+	}
+	if details != nil {
+		entry.Details, _ = json.Marshal(details)
+	}
+	_ = s.repo.LogAccess(ctx, entry)
+}
+
+// version is the version a link serves for doc.
+func (l *DocumentShareLink) version(doc *Document) int {
+	if l.VersionNumber != nil {
+		return *l.VersionNumber
+	}
+	return doc.CurrentVersion
+}
+
+func (l *DocumentShareLink) status(now time.Time) ShareLinkStatus {
+	switch {
+	case l.RevokedAt != nil:
+		return ShareLinkRevoked
+	case !now.Before(l.ExpiresAt):
+		return ShareLinkExpired
+	case l.FailedAttempts >= maxShareFailures:
+		return ShareLinkLocked
+	case l.MaxDownloads > 0 && l.DownloadCount >= l.MaxDownloads:
+		return ShareLinkExhausted
+	}
+	return ShareLinkActive
+}
+
+// describe fills the computed fields shown to link owners.
+func (l *DocumentShareLink) describe(now time.Time) {
+	l.Status = l.status(now)
+	l.PasswordProtected = l.PasswordHash != ""
+}
+
+// newShareToken returns a random URL token and the hash that is stored.
+func newShareToken() (token, hash string) {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashShareToken(token)
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
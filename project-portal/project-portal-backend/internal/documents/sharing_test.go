@@ -0,0 +1,50 @@
+package documents
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareLinkStatus(t *testing.T) {
+	now := time.Now().UTC()
+	revoked := now.Add(-time.Minute)
+	cases := []struct {
+		name string
+		link DocumentShareLink
+		want ShareLinkStatus
+	}{
+		{"active", DocumentShareLink{ExpiresAt: now.Add(time.Hour)}, ShareLinkActive},
+		{"downloads left", DocumentShareLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 3, DownloadCount: 2}, ShareLinkActive},
+		{"expired", DocumentShareLink{ExpiresAt: now}, ShareLinkExpired},
+		{"revoked", DocumentShareLink{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, ShareLinkRevoked},
+		{"exhausted", DocumentShareLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 2, DownloadCount: 2}, ShareLinkExhausted},
+		{"locked", DocumentShareLink{ExpiresAt: now.Add(time.Hour), FailedAttempts: maxShareFailures}, ShareLinkLocked},
+	}
+	for _, tc := range cases {
+		if got := tc.link.status(now); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestShareToken(t *testing.T) {
+	token, hash := newShareToken()
+	other, _ := newShareToken()
+	if token == other {
+		t.Fatal("tokens repeat")
+	}
+	if len(token) < 40 || hash != hashShareToken(token) || hash == token {
+		t.Errorf("token %q hash %q", token, hash)
+	}
+}
+
+func TestShareLinkVersion(t *testing.T) {
+	doc := &Document{CurrentVersion: 4}
+	if v := (&DocumentShareLink{}).version(doc); v != 4 {
+		t.Errorf("link without version served v%d", v)
+	}
+	pinned := 2
+	if v := (&DocumentShareLink{VersionNumber: &pinned}).version(doc); v != 2 {
+		t.Errorf("pinned link served v%d", v)
+	}
+}
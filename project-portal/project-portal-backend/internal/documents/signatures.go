@@ -17,6 +17,7 @@ type VerifySignatureResult struct {
 	Signatures  []security.SignatureInfo `json:"signatures"`
 	AllValid    bool                     `json:"all_valid"`
 	SignedCount int                      `json:"signed_count"`
+	HasDSS      bool                     `json:"has_dss"`
 	VerifiedAt  time.Time                `json:"verified_at"`
 }
 
@@ -38,8 +39,9 @@ func (s *Service) VerifySignature(ctx context.Context, docID uuid.UUID, userID *
 		return nil, fmt.Errorf("failed to download document for verification: %w", err)
 	}
 
-	// 3. Run cryptographic verification.
-	vResult, err := security.VerifyPDFSignatures(pdfBytes)
+	// 3. Run cryptographic verification against the project's trust anchors,
+	// at the time proven by each signature's timestamp.
+	vResult, err := security.VerifyPDFSignaturesWithOptions(ctx, pdfBytes, s.verifyOptions(ctx, doc.ProjectID))
 	if err != nil {
 		return nil, fmt.Errorf("signature verification failed: %w", err)
 	}
@@ -57,6 +59,8 @@ func (s *Service) VerifySignature(ctx context.Context, docID uuid.UUID, userID *
 			CertificateSubject:  sig.CertificateSubject,
 			SigningTime:         sig.SigningTime,
 			IsValid:             sig.IsValid,
+			Indication:          sig.Indication,
+			Level:               sig.Level,
 			VerificationDetails: details,
 			VerifiedAt:          time.Now().UTC(),
 		}
@@ -80,6 +84,7 @@ func (s *Service) VerifySignature(ctx context.Context, docID uuid.UUID, userID *
 		Signatures:  vResult.Signatures,
 		AllValid:    vResult.AllValid,
 		SignedCount: vResult.SignedCount,
+		HasDSS:      vResult.HasDSS,
 		VerifiedAt:  vResult.VerifiedAt,
 	}, nil
 }
@@ -87,10 +92,23 @@ func (s *Service) VerifySignature(ctx context.Context, docID uuid.UUID, userID *
 // buildVerificationDetails encodes a SignatureInfo into JSONB for storage.
 func buildVerificationDetails(sig security.SignatureInfo) datatypes.JSON {
 	detail := map[string]interface{}{
-		"is_valid":            sig.IsValid,
-		"failure_reason":      sig.FailureReason,
-		"certificate_issuer":  sig.CertificateIssuer,
-		"certificate_subject": sig.CertificateSubject,
+		"type":                  sig.Type,
+		"is_valid":              sig.IsValid,
+		"indication":            sig.Indication,
+		"failure_reason":        sig.FailureReason,
+		"certificate_issuer":    sig.CertificateIssuer,
+		"certificate_subject":   sig.CertificateSubject,
+		"sub_filter":            sig.SubFilter,
+		"level":                 sig.Level,
+		"covers_whole_document": sig.CoversWholeDocument,
+		"certificate_chain":     sig.CertificateChain,
+		"validation_time":       sig.ValidationTime,
+		"checks":                sig.Checks,
+		"revocation":            sig.Revocation,
+	}
+	if sig.TimestampTime != nil {
+		detail["timestamp_time"] = sig.TimestampTime
+		detail["timestamp_authority"] = sig.TimestampAuthority
 	}
 	raw, err := json.Marshal(detail)
 	if err != nil {
@@ -0,0 +1,320 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/encryption"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ─── PAdES Signing ────────────────────────────────────────────────────────────
+
+var (
+	// ErrSigningDisabled is returned when no vault is configured for signing keys.
+	ErrSigningDisabled = errors.New("document signing is not configured")
+	// ErrNoSigningCertificate is returned when neither the signer nor the
+	// project has an active signing certificate.
+	ErrNoSigningCertificate = errors.New("no active signing certificate")
+	// ErrNotSignable is returned for documents that are not PDFs.
+	ErrNotSignable = errors.New("only PDF documents can be signed")
+)
+
+// SetSigning enables PAdES signing. Private keys of signing certificates are
+// encrypted with vault; tsa, when set, timestamps every signature
+// (PAdES-B-T).
+func (s *Service) SetSigning(vault encryption.SecureStorage, tsa *security.TSAClient) {
+	s.vault, s.tsa = vault, tsa
+}
+
+// AddSigningCertificateRequest is the JSON body for
+// POST /api/v1/documents/signing-certificates. The key and certificates are
+// given either as PEM or as a base64 PKCS#12 bundle with its password.
+// Personal certificates sign the caller's own approvals; the others are the
+// project's organization certificate.
+type AddSigningCertificateRequest struct {
+	ProjectID string `json:"project_id" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Personal  bool   `json:"personal"`
+	PEM       string `json:"pem"`
+	PKCS12    string `json:"pkcs12"`
+	Password  string `json:"password"`
+}
+
+// AddSigningCertificate validates a key and certificate pair and stores it
+// with the key encrypted by the vault.
+func (s *Service) AddSigningCertificate(ctx context.Context, req *AddSigningCertificateRequest, userID *uuid.UUID) (*SigningCertificate, error) {
+	if s.vault == nil {
+		return nil, ErrSigningDisabled
+	}
+	pid, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project_id: %w", err)
+	}
+	var signer *security.Signer
+	switch {
+	case req.PEM != "" && req.PKCS12 == "":
+		signer, err = security.ParseSignerPEM([]byte(req.PEM))
+	case req.PKCS12 != "" && req.PEM == "":
+		raw, decodeErr := base64.StdEncoding.DecodeString(req.PKCS12)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("%w: pkcs12 is not valid base64", security.ErrSignerCertificate)
+		}
+		signer, err = security.ParseSignerPKCS12(raw, req.Password)
+	default:
+		return nil, fmt.Errorf("%w: provide exactly one of pem or pkcs12", security.ErrSignerCertificate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cert := signer.Certificate
+	if time.Now().After(cert.NotAfter) {
+		return nil, fmt.Errorf("%w: certificate expired on %s", security.ErrSignerCertificate, cert.NotAfter.Format(time.RFC3339))
+	}
+	if userID == nil && req.Personal {
+		return nil, fmt.Errorf("%w: personal certificates need an authenticated user", ErrForbidden)
+	}
+
+	keyPEM, err := signer.KeyPEM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	encrypted, err := s.vault.EncryptString(string(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+	fingerprint := sha256.Sum256(cert.Raw)
+	sc := &SigningCertificate{
+		ID:                uuid.New(),
+		ProjectID:         pid,
+		Name:              req.Name,
+		Subject:           cert.Subject.String(),
+		Issuer:            cert.Issuer.String(),
+		SerialNumber:      cert.SerialNumber.Text(16),
+		FingerprintSHA256: hex.EncodeToString(fingerprint[:]),
+		NotBefore:         cert.NotBefore.UTC(),
+		NotAfter:          cert.NotAfter.UTC(),
+		CertificatePEM:    string(signer.CertificatesPEM()),
+		EncryptedKey:      encrypted,
+		CreatedBy:         userID,
+		CreatedAt:         time.Now().UTC(),
+	}
+	if req.Personal {
+		sc.UserID = userID
+	}
+	if err := s.repo.CreateSigningCertificate(ctx, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// ListSigningCertificates returns a project's certificates, newest first.
+func (s *Service) ListSigningCertificates(ctx context.Context, projectID uuid.UUID) ([]SigningCertificate, error) {
+	return s.repo.ListSigningCertificates(ctx, projectID)
+}
+
+// GetSigningCertificate returns one certificate; handlers use it to find the
+// project to authorize against.
+func (s *Service) GetSigningCertificate(ctx context.Context, id uuid.UUID) (*SigningCertificate, error) {
+	return s.repo.FindSigningCertificate(ctx, id)
+}
+
+// RevokeSigningCertificate stops a certificate from being used for new
+// signatures. Members may revoke their own personal certificates; other
+// certificates need project:write.
+func (s *Service) RevokeSigningCertificate(ctx context.Context, id uuid.UUID, access *middleware.ProjectAccess) (*SigningCertificate, error) {
+	sc, err := s.repo.FindSigningCertificate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	own := sc.UserID != nil && access != nil && sc.UserID.String() == access.UserID
+	if !own && !access.Can(middleware.PermProjectWrite) {
+		return nil, fmt.Errorf("%w: only the certificate holder or a project manager may revoke it", ErrForbidden)
+	}
+	if sc.RevokedAt == nil {
+		now := time.Now().UTC()
+		if err := s.repo.RevokeSigningCertificate(ctx, id, now); err != nil {
+			return nil, err
+		}
+		sc.RevokedAt = &now
+	}
+	return sc, nil
+}
+
+// SignRequest is the JSON body for POST /api/v1/documents/:id/sign.
+type SignRequest struct {
+	Reason   string `json:"reason"`
+	Location string `json:"location"`
+}
+
+// SignResponse describes a signed document version.
+type SignResponse struct {
+	DocumentID    uuid.UUID            `json:"document_id"`
+	Version       *DocumentVersion     `json:"version"`
+	CertificateID uuid.UUID            `json:"certificate_id"`
+	Signature     *security.SignResult `json:"signature"`
+}
+
+// SignDocument applies the caller's signature to the current PDF outside a
+// workflow step and stores the result as a new version.
+func (s *Service) SignDocument(ctx context.Context, docID uuid.UUID, req *SignRequest, userID *uuid.UUID, ipAddr, ua string) (*SignResponse, error) {
+	var resp *SignResponse
+	err := s.repo.WithDocumentLock(ctx, docID, func(tx *Repository, doc *Document) error {
+		var err error
+		resp, err = s.signCurrentFile(ctx, tx, doc, userID, security.SignOptions{Reason: req.Reason, Location: req.Location})
+		if err != nil {
+			return err
+		}
+		return tx.Update(ctx, doc)
+	})
+	if err != nil {
+		if resp != nil {
+			_ = s.storage.Delete(ctx, resp.Version.S3Key)
+		}
+		return nil, err
+	}
+	s.logSigning(ctx, resp, userID, ipAddr, ua)
+	s.wakeTextExtraction()
+	return resp, nil
+}
+
+// signCurrentFile signs doc's current PDF with the signer's personal
+// certificate, or the project certificate if they have none, uploads it and
+// records a new version. doc is updated to point at the signed file but not
+// saved. If an error is returned after the upload, the response is still
+// returned so the caller can remove the object.
+func (s *Service) signCurrentFile(ctx context.Context, tx *Repository, doc *Document, userID *uuid.UUID, opts security.SignOptions) (*SignResponse, error) {
+	if s.vault == nil {
+		return nil, ErrSigningDisabled
+	}
+	if doc.FileType != FileTypePDF {
+		return nil, fmt.Errorf("%w (got %s)", ErrNotSignable, doc.FileType)
+	}
+	signer, sc, err := s.loadSigner(ctx, tx, doc.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := s.storage.DownloadBytes(ctx, doc.S3Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download document for signing: %w", err)
+	}
+	opts.TSA = s.tsa
+	signed, result, err := security.SignPDF(ctx, original, signer, opts)
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
+	}
+	if s.tsa != nil && s.revocation != nil {
+		signed, result = s.addLongTermValidation(ctx, doc.ProjectID, signed, result)
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("projects/%s/documents/%s/%s_v%d_signed.pdf",
+		doc.ProjectID, strings.ToLower(string(doc.DocumentType)), now.Format("20060102T150405"), doc.CurrentVersion+1)
+	stored, err := s.storage.UploadReader(ctx, key, bytes.NewReader(signed), "application/pdf")
+	if err != nil {
+		return nil, fmt.Errorf("storage upload failed: %w", err)
+	}
+	sum := sha256.Sum256(signed)
+	version := &DocumentVersion{
+		ID:            uuid.New(),
+		DocumentID:    doc.ID,
+		VersionNumber: doc.CurrentVersion + 1,
+		S3Key:         stored.Key,
+		S3Bucket:      stored.Bucket,
+		FileSize:      int64(len(signed)),
+		ContentHash:   hex.EncodeToString(sum[:]),
+		ChangeSummary: fmt.Sprintf("Signed by %s (%s)", result.SignerName, result.Level),
+		UploadedBy:    userID,
+		UploadedAt:    now,
+	}
+	resp := &SignResponse{DocumentID: doc.ID, Version: version, CertificateID: sc.ID, Signature: result}
+	if err := tx.CreateVersion(ctx, version); err != nil {
+		return resp, err
+	}
+	doc.CurrentVersion = version.VersionNumber
+	doc.S3Key, doc.S3Bucket = version.S3Key, version.S3Bucket
+	doc.FileSize, doc.ContentHash = version.FileSize, version.ContentHash
+	s.queueText(doc)
+	return resp, nil
+}
+
+// loadSigner decrypts the signing certificate userID signs with in the
+// project: their personal certificate, or else the project's.
+func (s *Service) loadSigner(ctx context.Context, repo *Repository, projectID uuid.UUID, userID *uuid.UUID) (*security.Signer, *SigningCertificate, error) {
+	if s.vault == nil {
+		return nil, nil, ErrSigningDisabled
+	}
+	sc, err := repo.FindActiveSigningCertificate(ctx, projectID, userID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if sc == nil {
+		return nil, nil, fmt.Errorf("%w: upload a personal or project certificate to sign documents", ErrNoSigningCertificate)
+	}
+	keyPEM, err := s.vault.DecryptString(sc.EncryptedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+	}
+	signer, err := security.ParseSignerPEM([]byte(sc.CertificatePEM + keyPEM))
+	if err != nil {
+		return nil, nil, err
+	}
+	return signer, sc, nil
+}
+
+// addLongTermValidation embeds the chains and revocation status of every
+// signature in signed and seals them with a document timestamp
+// (PAdES-B-LTA). If the validation data cannot be gathered the signature is
+// kept at PAdES-B-T; it can still be validated while the responders are up.
+func (s *Service) addLongTermValidation(ctx context.Context, projectID uuid.UUID, signed []byte, result *security.SignResult) ([]byte, *security.SignResult) {
+	opts := s.verifyOptions(ctx, projectID)
+	lta, err := security.AddLongTermValidation(ctx, signed, opts, s.tsa)
+	if err != nil {
+		fmt.Printf("WARNING: long-term validation data not embedded for %s: %v\n", result.SignerName, err)
+		return signed, result
+	}
+	if vr, err := security.VerifyPDFSignaturesWithOptions(ctx, lta, opts); err == nil {
+		// The new signature is the last one before the document timestamp.
+		for _, sig := range vr.Signatures {
+			if sig.Type == security.SignatureTypeSignature && sig.Level != "" {
+				result.Level = sig.Level
+			}
+		}
+	}
+	return lta, result
+}
+
+// logSigning records a SIGN access-log entry.
+func (s *Service) logSigning(ctx context.Context, resp *SignResponse, userID *uuid.UUID, ipAddr, ua string) {
+	details := datatypes.JSON("{}")
+	if raw, err := json.Marshal(map[string]any{
+		"version":        resp.Version.VersionNumber,
+		"certificate_id": resp.CertificateID,
+		"level":          resp.Signature.Level,
+		"timestamp_time": resp.Signature.TimestampTime,
+	}); err == nil {
+		details = raw
+	}
+	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
+		DocumentID:  resp.DocumentID,
+		UserID:      userID,
+		Action:      ActionSign,
+		IPAddress:   ipAddr,
+		UserAgent:   ua,
+		Details:     details,
+		PerformedAt: time.Now().UTC(),
+	})
+}
@@ -3,6 +3,9 @@ package documents
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -24,6 +27,7 @@ var allowedMIMETypes = map[string]FileType{
 	"image/png":                    FileTypeImage,
 	"image/gif":                    FileTypeImage,
 	"image/webp":                   FileTypeImage,
+	"image/tiff":                   FileTypeImage, // GeoTIFF orthomosaics
 	"application/zip":              FileTypeZIP,
 	"application/x-zip-compressed": FileTypeZIP,
 }
@@ -31,106 +35,175 @@ var allowedMIMETypes = map[string]FileType{
 // maxUploadSize is 100 MB.
 const maxUploadSize = 100 * 1024 * 1024
 
-// StorageService handles all file-level S3 operations.
+// ErrNotServed is returned when a signed object URL is presented but the
+// configured backend serves its own downloads (S3).
+var ErrNotServed = errors.New("objects are not served by this backend")
+
+// StorageService handles all file-level operations against the configured
+// object store (S3, local disk or memory).
 type StorageService struct {
-	s3 *storage.S3Client
+	store storage.ObjectStore
+}
+
+// NewStorageService creates a StorageService backed by the given store.
+func NewStorageService(store storage.ObjectStore) *StorageService {
+	return &StorageService{store: store}
 }
 
-// NewStorageService creates a StorageService backed by the given S3 client.
-func NewStorageService(s3Client *storage.S3Client) *StorageService {
-	return &StorageService{s3: s3Client}
+// StoredFile describes an object written by UploadFile.
+type StoredFile struct {
+	Key      string
+	Bucket   string
+	FileType FileType
+	Size     int64
+	SHA256   string // hex digest of the bytes as uploaded
 }
 
-// UploadFile validates, streams, and stores a multipart file to S3.
-// Returns the S3 key, bucket, detected FileType, and actual file size.
+// UploadFile validates, streams, and stores a multipart file, hashing it
+// with SHA-256 on the way through.
 func (s *StorageService) UploadFile(
 	ctx context.Context,
 	projectID string,
 	docType DocumentType,
 	fileHeader *multipart.FileHeader,
-) (key string, bucket string, fileType FileType, size int64, err error) {
+) (*StoredFile, error) {
 	// 1. Size guard
 	if fileHeader.Size > maxUploadSize {
-		return "", "", "", 0, fmt.Errorf("file exceeds maximum allowed size of 100 MB")
+		return nil, fmt.Errorf("file exceeds maximum allowed size of 100 MB")
 	}
 
 	// 2. Detect content type from header
 	contentType := fileHeader.Header.Get("Content-Type")
-	ft, ok := allowedMIMETypes[contentType]
-	if !ok {
-		// Fallback: infer from extension
-		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		ft, ok = extensionToFileType(ext)
-		if !ok {
-			return "", "", "", 0, fmt.Errorf("unsupported file type: %s", contentType)
-		}
+	ft, err := detectFileType(contentType, fileHeader.Filename)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Build S3 key: projects/{project_id}/documents/{doc_type}/{timestamp}_{filename}
-	safeFilename := sanitizeFilename(fileHeader.Filename)
-	timestamp := time.Now().UTC().Format("20060102T150405")
-	key = fmt.Sprintf(
-		"projects/%s/documents/%s/%s_%s",
-		projectID, strings.ToLower(string(docType)), timestamp, safeFilename,
-	)
-
-	// 4. Open and stream to S3
+	// 3. Open and stream to the store
 	file, err := fileHeader.Open()
 	if err != nil {
-		return "", "", "", 0, fmt.Errorf("failed to open uploaded file: %w", err)
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
 	}
 	defer file.Close()
+	return s.StoreStream(ctx, projectID, docType, fileHeader.Filename, contentType, ft, file)
+}
+
+// StoreStream stores a document file read from r under
+// projects/{project_id}/documents/{doc_type}/{timestamp}_{filename},
+// hashing it with SHA-256 on the way through.
+func (s *StorageService) StoreStream(ctx context.Context, projectID string, docType DocumentType, filename, contentType string, ft FileType, r io.Reader) (*StoredFile, error) {
+	safeFilename := sanitizeFilename(filename)
+	timestamp := time.Now().UTC().Format("20060102T150405")
+	key := fmt.Sprintf(
+		"projects/%s/documents/%s/%s_%s",
+		projectID, strings.ToLower(string(docType)), timestamp, safeFilename,
+	)
 
-	result, err := s.s3.Upload(ctx, key, file, contentType)
+	h := sha256.New()
+	counter := &countingReader{r: io.TeeReader(r, h)}
+	result, err := s.store.Upload(ctx, key, counter, contentType)
 	if err != nil {
-		return "", "", "", 0, err
+		return nil, err
 	}
 
-	return result.Key, result.Bucket, ft, fileHeader.Size, nil
+	return &StoredFile{
+		Key:      result.Key,
+		Bucket:   result.Bucket,
+		FileType: ft,
+		Size:     counter.n,
+		SHA256:   hex.EncodeToString(h.Sum(nil)),
+	}, nil
 }
 
 // UploadReader uploads from a plain io.Reader (used for generated PDFs etc).
 func (s *StorageService) UploadReader(ctx context.Context, key string, r io.Reader, contentType string) (*storage.UploadResult, error) {
-	return s.s3.Upload(ctx, key, r, contentType)
+	return s.store.Upload(ctx, key, r, contentType)
 }
 
 // GeneratePresignedURL returns a short-lived download URL.
 func (s *StorageService) GeneratePresignedURL(ctx context.Context, key string) (string, error) {
-	return s.s3.GeneratePresignedURL(ctx, key, 15*time.Minute)
+	return s.store.GeneratePresignedURL(ctx, key, 15*time.Minute)
+}
+
+// GeneratePresignedURLFor returns a download URL valid for ttl.
+func (s *StorageService) GeneratePresignedURLFor(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return s.store.GeneratePresignedURL(ctx, key, ttl)
 }
 
-// DownloadStream opens an S3 object for streaming.
+// OpenSigned checks a signed URL issued by GeneratePresignedURL and opens
+// the object. Only backends whose URLs point back at the API support it.
+func (s *StorageService) OpenSigned(ctx context.Context, key, expires, signature string) (io.ReadCloser, int64, error) {
+	verifier, ok := s.store.(storage.SignedURLVerifier)
+	if !ok {
+		return nil, 0, ErrNotServed
+	}
+	if err := verifier.VerifySignedURL(key, expires, signature); err != nil {
+		return nil, 0, err
+	}
+	return s.store.DownloadStream(ctx, key)
+}
+
+// DownloadStream opens an object for streaming.
 func (s *StorageService) DownloadStream(ctx context.Context, key string) (io.ReadCloser, int64, error) {
-	return s.s3.DownloadStream(ctx, key)
+	return s.store.DownloadStream(ctx, key)
 }
 
-// DownloadBytes downloads an S3 object fully into memory and returns the bytes.
+// DownloadBytes downloads an object fully into memory and returns the bytes.
 // Use only for small/moderate files (e.g. PDFs to be analysed in-process).
 func (s *StorageService) DownloadBytes(ctx context.Context, key string) ([]byte, error) {
-	rc, _, err := s.s3.DownloadStream(ctx, key)
+	rc, _, err := s.store.DownloadStream(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("s3 download failed: %w", err)
+		return nil, fmt.Errorf("storage download failed: %w", err)
 	}
 	defer rc.Close()
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, rc); err != nil {
-		return nil, fmt.Errorf("failed to read s3 stream: %w", err)
+		return nil, fmt.Errorf("failed to read storage stream: %w", err)
 	}
 	return buf.Bytes(), nil
 }
 
-// Delete removes a file from S3.
+// HashObject streams an object through SHA-256 and returns the hex digest
+// and the number of bytes read.
+func (s *StorageService) HashObject(ctx context.Context, key string) (string, int64, error) {
+	rc, _, err := s.store.DownloadStream(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read storage stream: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Delete removes a file from the store.
 func (s *StorageService) Delete(ctx context.Context, key string) error {
-	return s.s3.Delete(ctx, key)
+	return s.store.Delete(ctx, key)
 }
 
 // BucketName returns the configured bucket.
 func (s *StorageService) BucketName() string {
-	return s.s3.BucketName()
+	return s.store.BucketName()
 }
 
 // --- helpers ---
 
+// detectFileType maps a declared content type, or else the filename
+// extension, to a FileType.
+func detectFileType(contentType, filename string) (FileType, error) {
+	if ft, ok := allowedMIMETypes[contentType]; ok {
+		return ft, nil
+	}
+	ft, ok := extensionToFileType(strings.ToLower(filepath.Ext(filename)))
+	if !ok {
+		return "", fmt.Errorf("unsupported file type: %s", contentType)
+	}
+	return ft, nil
+}
+
 func extensionToFileType(ext string) (FileType, bool) {
 	m := map[string]FileType{
 		".pdf":  FileTypePDF,
@@ -143,12 +216,26 @@ func extensionToFileType(ext string) (FileType, bool) {
 		".png":  FileTypeImage,
 		".gif":  FileTypeImage,
 		".webp": FileTypeImage,
+		".tif":  FileTypeImage,
+		".tiff": FileTypeImage,
 		".zip":  FileTypeZIP,
 	}
 	ft, ok := m[ext]
 	return ft, ok
 }
 
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func sanitizeFilename(name string) string {
 	// Strip any path components and replace spaces with underscores.
 	base := filepath.Base(name)
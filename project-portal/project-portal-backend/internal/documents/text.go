@@ -0,0 +1,202 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/search"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/textextract"
+
+	"github.com/google/uuid"
+)
+
+// ─── Full-text Extraction ─────────────────────────────────────────────────────
+
+// ErrTextIndexingDisabled is returned when no search index is configured.
+var ErrTextIndexingDisabled = errors.New("full-text indexing is not configured")
+
+// TextIndexer stores the text extracted from documents for full-text
+// search. search.Service implements it.
+type TextIndexer interface {
+	IndexDocumentText(ctx context.Context, documentID string, segments []*search.DocumentDocument) error
+	DeleteDocumentText(ctx context.Context, documentID string) error
+}
+
+// extractableTypes are the file types textextract can read.
+var extractableTypes = []FileType{FileTypePDF, FileTypeDOCX, FileTypeXLSX}
+
+// maxExtractFileSize caps the files read into memory for extraction; larger
+// ones, which only arrive through resumable uploads, are marked failed.
+const maxExtractFileSize = 512 << 20
+
+// SetTextIndexer enables full-text indexing. New uploads and versions are
+// queued for extraction; StartTextExtraction processes the queue.
+func (s *Service) SetTextIndexer(idx TextIndexer) {
+	s.textIndexer = idx
+	s.textWake = make(chan struct{}, 1)
+}
+
+// queueText marks doc's current file for extraction. doc is not saved.
+func (s *Service) queueText(doc *Document) {
+	if s.textIndexer == nil {
+		return
+	}
+	doc.TextStatus, doc.TextError = TextPending, ""
+	if !textextract.Supported(string(doc.FileType)) {
+		doc.TextStatus = TextUnsupported
+	}
+}
+
+// wakeTextExtraction starts an extraction run without waiting for the next
+// tick. It never blocks.
+func (s *Service) wakeTextExtraction() {
+	if s.textWake == nil {
+		return
+	}
+	select {
+	case s.textWake <- struct{}{}:
+	default:
+	}
+}
+
+// ReindexText queues a document's current file for extraction again, e.g.
+// after the extractor improved or the search index was rebuilt.
+func (s *Service) ReindexText(ctx context.Context, docID uuid.UUID) (*Document, error) {
+	if s.textIndexer == nil {
+		return nil, ErrTextIndexingDisabled
+	}
+	doc, err := s.repo.FindByID(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("document not found: %w", err)
+	}
+	if !textextract.Supported(string(doc.FileType)) {
+		return nil, fmt.Errorf("%w: %s", textextract.ErrUnsupportedFormat, doc.FileType)
+	}
+	if err := s.repo.QueueTextExtraction(ctx, docID); err != nil {
+		return nil, err
+	}
+	doc.TextStatus, doc.TextError = TextPending, ""
+	s.wakeTextExtraction()
+	return doc, nil
+}
+
+// ExtractPendingText extracts and indexes up to limit queued documents.
+// Documents that cannot be read are marked failed; indexing errors leave
+// them queued for the next run.
+func (s *Service) ExtractPendingText(ctx context.Context, limit int) (indexed, failed int, err error) {
+	if s.textIndexer == nil {
+		return 0, 0, ErrTextIndexingDisabled
+	}
+	docs, err := s.repo.FindPendingTextExtraction(ctx, limit, extractableTypes)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := range docs {
+		if err := s.extractText(ctx, &docs[i]); err != nil {
+			log.Printf("WARNING: text extraction for document %s failed: %v", docs[i].ID, err)
+			failed++
+			continue
+		}
+		indexed++
+	}
+	return indexed, failed, nil
+}
+
+// extractText reads doc's current file, indexes its pages or sheets and
+// records the outcome.
+func (s *Service) extractText(ctx context.Context, doc *Document) error {
+	record := func(status TextStatus, errMsg string) error {
+		_, err := s.repo.RecordTextExtraction(ctx, doc.ID, doc.CurrentVersion, status, errMsg, time.Now().UTC())
+		return err
+	}
+	if doc.FileSize > maxExtractFileSize {
+		return record(TextFailed, fmt.Sprintf("file is larger than %d MB", maxExtractFileSize>>20))
+	}
+	data, err := s.storage.DownloadBytes(ctx, doc.S3Key)
+	if err != nil {
+		// Storage errors may be transient; the document stays queued.
+		return fmt.Errorf("download failed: %w", err)
+	}
+	res, err := textextract.Extract(data, string(doc.FileType))
+	if errors.Is(err, textextract.ErrUnsupportedFormat) {
+		return record(TextUnsupported, "")
+	}
+	if err != nil {
+		if recErr := record(TextFailed, err.Error()); recErr != nil {
+			return recErr
+		}
+		return err
+	}
+
+	now := time.Now().UTC()
+	segments := make([]*search.DocumentDocument, len(res.Segments))
+	for i, seg := range res.Segments {
+		segments[i] = &search.DocumentDocument{
+			EntityID:     doc.ID.String(),
+			EntityType:   "document",
+			Title:        doc.Name,
+			Content:      seg.Text,
+			ProjectID:    doc.ProjectID.String(),
+			DocumentType: string(doc.DocumentType),
+			FileFormat:   string(doc.FileType),
+			Version:      doc.CurrentVersion,
+			Page:         seg.Page,
+			Sheet:        seg.Sheet,
+			Metadata:     res.Metadata,
+			CreatedAt:    doc.UploadedAt,
+			UpdatedAt:    now,
+		}
+	}
+	if err := s.textIndexer.IndexDocumentText(ctx, doc.ID.String(), segments); err != nil {
+		return fmt.Errorf("indexing failed: %w", err)
+	}
+	errMsg := ""
+	if res.Truncated {
+		errMsg = fmt.Sprintf("text truncated to %d bytes", textextract.MaxTextBytes)
+	}
+	return record(TextIndexed, errMsg)
+}
+
+// StartTextExtraction processes queued documents every interval, and as
+// soon as an upload is queued, until ctx is cancelled.
+func (s *Service) StartTextExtraction(ctx context.Context, interval time.Duration, batch int) {
+	if s.textIndexer == nil || interval <= 0 || batch <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.textWake:
+			}
+			indexed, failed, err := s.ExtractPendingText(ctx, batch)
+			if err != nil {
+				log.Printf("WARNING: text extraction run failed: %v", err)
+				continue
+			}
+			if indexed+failed > 0 {
+				log.Printf("🔎 Text extraction indexed %d document(s), %d failed", indexed, failed)
+			}
+			if indexed+failed == batch {
+				s.wakeTextExtraction() // more may be waiting
+			}
+		}
+	}()
+}
+
+// removeText drops a document from the search index. Failures are logged.
+func (s *Service) removeText(ctx context.Context, docID uuid.UUID) {
+	if s.textIndexer == nil {
+		return
+	}
+	if err := s.textIndexer.DeleteDocumentText(ctx, docID.String()); err != nil {
+		log.Printf("WARNING: failed to remove document %s from the search index: %v", docID, err)
+	}
+}
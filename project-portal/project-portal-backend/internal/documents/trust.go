@@ -0,0 +1,225 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+
+	"github.com/google/uuid"
+)
+
+// ─── Trust Anchors & Revocation ───────────────────────────────────────────────
+
+// ErrInvalidTrustAnchors is returned when a trust anchor bundle holds no
+// usable certificate.
+var ErrInvalidTrustAnchors = errors.New("invalid trust anchor bundle")
+
+// Revocation checking modes for SetRevocationChecking.
+const (
+	RevocationCheckOff  = "off"  // embedded revocation data only; missing status is a warning
+	RevocationCheckSoft = "soft" // fetch OCSP/CRL; missing status is a warning
+	RevocationCheckHard = "hard" // fetch OCSP/CRL; missing status makes the signature INDETERMINATE
+)
+
+// SetRevocationChecking configures how signature verification treats
+// certificate revocation. In soft and hard mode OCSP responses and CRLs are
+// fetched online and cached in the database until they expire; hard mode
+// also requires a status for every certificate in the chain. Signing with a
+// TSA configured embeds the fetched data for long-term validation.
+func (s *Service) SetRevocationChecking(mode string) error {
+	switch mode {
+	case RevocationCheckOff:
+		s.revocation, s.requireRevocation = nil, false
+	case RevocationCheckSoft, RevocationCheckHard:
+		s.revocation = &security.RevocationChecker{Cache: &revocationCache{repo: s.repo}}
+		s.requireRevocation = mode == RevocationCheckHard
+	default:
+		return fmt.Errorf("unknown revocation check mode %q (want off, soft or hard)", mode)
+	}
+	return nil
+}
+
+// verifyOptions returns the verification options for a project's documents.
+func (s *Service) verifyOptions(ctx context.Context, projectID uuid.UUID) security.VerifyOptions {
+	return security.VerifyOptions{
+		Roots:             s.projectTrustAnchors(ctx, projectID),
+		Revocation:        s.revocation,
+		RequireRevocation: s.requireRevocation,
+	}
+}
+
+// projectTrustAnchors returns the project's configured trust anchors and the
+// certificates registered for signing in it, including revoked ones, which
+// still vouch for the signatures they made before revocation. Signatures the
+// portal applies chain to these, so VerifySignature trusts them alongside
+// the system store.
+func (s *Service) projectTrustAnchors(ctx context.Context, projectID uuid.UUID) []*x509.Certificate {
+	var out []*x509.Certificate
+	bundles, err := s.repo.ListTrustAnchorBundles(ctx, projectID)
+	if err != nil {
+		fmt.Printf("WARNING: failed to load trust anchors for project %s: %v\n", projectID, err)
+	}
+	for _, b := range bundles {
+		certs, _ := parseCertificatesPEM(b.CertificatesPEM)
+		out = append(out, certs...)
+	}
+
+	signing, err := s.repo.ListSigningCertificates(ctx, projectID)
+	if err != nil {
+		fmt.Printf("WARNING: failed to load signing certificates for project %s: %v\n", projectID, err)
+		return out
+	}
+	for _, sc := range signing {
+		// The first certificate in the bundle is the signer's own.
+		if block, _ := pem.Decode([]byte(sc.CertificatePEM)); block != nil {
+			if c, err := x509.ParseCertificate(block.Bytes); err == nil {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// AddTrustAnchorsRequest is the JSON body for
+// POST /api/v1/documents/trust-anchors.
+type AddTrustAnchorsRequest struct {
+	ProjectID       string `json:"project_id" binding:"required"`
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	CertificatesPEM string `json:"certificates_pem" binding:"required"`
+}
+
+// TrustAnchorSummary describes one certificate of a bundle.
+type TrustAnchorSummary struct {
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	NotAfter          time.Time `json:"not_after"`
+	IsCA              bool      `json:"is_ca"`
+}
+
+// AddTrustAnchorBundle stores a PEM bundle of certificates that signatures
+// on the project's documents may chain to, such as a registry's or
+// verifier's root CA.
+func (s *Service) AddTrustAnchorBundle(ctx context.Context, req *AddTrustAnchorsRequest, userID *uuid.UUID) (*TrustAnchorBundle, error) {
+	pid, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project_id: %w", err)
+	}
+	certs, err := parseCertificatesPEM(req.CertificatesPEM)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]TrustAnchorSummary, len(certs))
+	var normalized strings.Builder
+	for i, c := range certs {
+		sum := sha256.Sum256(c.Raw)
+		summaries[i] = TrustAnchorSummary{
+			Subject:           c.Subject.String(),
+			Issuer:            c.Issuer.String(),
+			FingerprintSHA256: hex.EncodeToString(sum[:]),
+			NotAfter:          c.NotAfter.UTC(),
+			IsCA:              c.IsCA,
+		}
+		_ = pem.Encode(&normalized, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	raw, err := json.Marshal(summaries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate summary: %w", err)
+	}
+	b := &TrustAnchorBundle{
+		ID:              uuid.New(),
+		ProjectID:       pid,
+		Name:            req.Name,
+		Description:     req.Description,
+		CertificatesPEM: normalized.String(),
+		Certificates:    raw,
+		CreatedBy:       userID,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if err := s.repo.CreateTrustAnchorBundle(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ListTrustAnchorBundles returns a project's trust anchor bundles, newest first.
+func (s *Service) ListTrustAnchorBundles(ctx context.Context, projectID uuid.UUID) ([]TrustAnchorBundle, error) {
+	return s.repo.ListTrustAnchorBundles(ctx, projectID)
+}
+
+// GetTrustAnchorBundle returns one bundle; handlers use it to find the
+// project to authorize against.
+func (s *Service) GetTrustAnchorBundle(ctx context.Context, id uuid.UUID) (*TrustAnchorBundle, error) {
+	return s.repo.FindTrustAnchorBundle(ctx, id)
+}
+
+// DeleteTrustAnchorBundle removes a bundle. Signatures that only chained to
+// it fail to validate from the next verification on.
+func (s *Service) DeleteTrustAnchorBundle(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteTrustAnchorBundle(ctx, id)
+}
+
+// parseCertificatesPEM parses every CERTIFICATE block in data.
+func parseCertificatesPEM(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTrustAnchors, err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%w: no PEM certificates found", ErrInvalidTrustAnchors)
+	}
+	return certs, nil
+}
+
+// revocationCache keeps fetched OCSP responses and CRLs in the database so
+// they are shared between instances and survive restarts.
+type revocationCache struct {
+	repo *Repository
+}
+
+func (c *revocationCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	resp, err := c.repo.GetRevocationResponse(ctx, key, time.Now())
+	if err != nil {
+		fmt.Printf("WARNING: %v\n", err)
+		return nil, false
+	}
+	if resp == nil {
+		return nil, false
+	}
+	return resp.Response, true
+}
+
+func (c *revocationCache) Put(ctx context.Context, key string, der []byte, expires time.Time) {
+	err := c.repo.SaveRevocationResponse(ctx, &RevocationResponse{
+		CacheKey:  key,
+		Response:  der,
+		FetchedAt: time.Now().UTC(),
+		ExpiresAt: expires.UTC(),
+	})
+	if err != nil {
+		fmt.Printf("WARNING: %v\n", err)
+	}
+}
@@ -0,0 +1,188 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// ─── Workflow Template Steps ───────────────────────────────────────────────────
+
+// AnyDocumentType is the template document type that matches every document
+// when a workflow is auto-assigned on upload.
+const AnyDocumentType DocumentType = "*"
+
+// Approval modes for a step with named approvers.
+const (
+	StepModeParallel   = "parallel"
+	StepModeSequential = "sequential"
+)
+
+var (
+	// ErrInvalidWorkflow is returned when a template's steps are malformed.
+	ErrInvalidWorkflow = errors.New("invalid workflow template")
+	// ErrAlreadyDecided is returned when an approver votes twice on a step.
+	ErrAlreadyDecided = errors.New("you have already approved this step")
+)
+
+// WorkflowStep is one stage of a template's approval chain. A step is
+// approved by members holding at least Role, or by the named Users. With
+// named users, Mode decides whether they may approve in any order
+// (parallel) or only in the listed order (sequential), and Quorum is how
+// many of them must approve; role-based steps need Quorum distinct
+// approvers. When SLAHours passes without a decision the step escalates and
+// members holding EscalateTo may decide it alone. On a Sign step every
+// approval applies the approver's PAdES signature to the document.
+type WorkflowStep struct {
+	Name       string         `json:"name"`
+	Role       string         `json:"role,omitempty"`
+	Users      []string       `json:"users,omitempty"`
+	Mode       string         `json:"mode,omitempty"`
+	Quorum     int            `json:"quorum,omitempty"`
+	SLAHours   int            `json:"sla_hours,omitempty"`
+	EscalateTo string         `json:"escalate_to,omitempty"`
+	When       *StepCondition `json:"when,omitempty"`
+	Sign       bool           `json:"sign,omitempty"`
+}
+
+// StepCondition limits a step to some document types. A step without a
+// condition applies to every document using the template.
+type StepCondition struct {
+	DocumentTypes []DocumentType `json:"document_types"`
+}
+
+var knownDocumentTypes = []DocumentType{
+	DocumentTypePDD,
+	DocumentTypeMonitoringReport,
+	DocumentTypeVerificationCertificate,
+	DocumentTypeCompliance,
+	DocumentTypeOther,
+}
+
+// normalizeWorkflowSteps validates steps and fills in defaults: parallel
+// mode, a quorum of every named user (or one for role-based steps) and
+// Owner as the escalation role for steps with an SLA.
+func normalizeWorkflowSteps(steps []WorkflowStep) error {
+	names := make(map[string]bool, len(steps))
+	for i := range steps {
+		st := &steps[i]
+		if st.Name == "" {
+			return fmt.Errorf("%w: step %d has no name", ErrInvalidWorkflow, i+1)
+		}
+		if names[st.Name] {
+			return fmt.Errorf("%w: duplicate step name %q", ErrInvalidWorkflow, st.Name)
+		}
+		names[st.Name] = true
+
+		if st.Role == "" && len(st.Users) == 0 {
+			return fmt.Errorf("%w: step %q needs a role or named users", ErrInvalidWorkflow, st.Name)
+		}
+		if st.Role != "" && !middleware.IsValidRole(st.Role) {
+			return fmt.Errorf("%w: step %q has unknown role %q", ErrInvalidWorkflow, st.Name, st.Role)
+		}
+		seen := make(map[string]bool, len(st.Users))
+		for _, u := range st.Users {
+			if _, err := uuid.Parse(u); err != nil {
+				return fmt.Errorf("%w: step %q has invalid user id %q", ErrInvalidWorkflow, st.Name, u)
+			}
+			if seen[u] {
+				return fmt.Errorf("%w: step %q lists user %s twice", ErrInvalidWorkflow, st.Name, u)
+			}
+			seen[u] = true
+		}
+
+		switch st.Mode {
+		case "":
+			st.Mode = StepModeParallel
+		case StepModeParallel:
+		case StepModeSequential:
+			if len(st.Users) == 0 || st.Role != "" {
+				return fmt.Errorf("%w: sequential step %q needs named users and no role", ErrInvalidWorkflow, st.Name)
+			}
+		default:
+			return fmt.Errorf("%w: step %q has unknown mode %q", ErrInvalidWorkflow, st.Name, st.Mode)
+		}
+
+		if st.Quorum < 0 {
+			return fmt.Errorf("%w: step %q has a negative quorum", ErrInvalidWorkflow, st.Name)
+		}
+		if st.Quorum == 0 {
+			st.Quorum = max(len(st.Users), 1)
+		}
+		if st.Role == "" && st.Quorum > len(st.Users) {
+			return fmt.Errorf("%w: step %q needs %d approvals but names %d users", ErrInvalidWorkflow, st.Name, st.Quorum, len(st.Users))
+		}
+		if st.Mode == StepModeSequential && st.Quorum != len(st.Users) {
+			return fmt.Errorf("%w: sequential step %q must be approved by every named user", ErrInvalidWorkflow, st.Name)
+		}
+
+		if st.SLAHours < 0 {
+			return fmt.Errorf("%w: step %q has a negative SLA", ErrInvalidWorkflow, st.Name)
+		}
+		if st.EscalateTo != "" {
+			if st.SLAHours == 0 {
+				return fmt.Errorf("%w: step %q escalates but has no SLA", ErrInvalidWorkflow, st.Name)
+			}
+			if !middleware.IsValidRole(st.EscalateTo) {
+				return fmt.Errorf("%w: step %q escalates to unknown role %q", ErrInvalidWorkflow, st.Name, st.EscalateTo)
+			}
+		} else if st.SLAHours > 0 {
+			st.EscalateTo = middleware.RoleOwner
+		}
+
+		if st.When != nil {
+			if len(st.When.DocumentTypes) == 0 {
+				return fmt.Errorf("%w: step %q has an empty condition", ErrInvalidWorkflow, st.Name)
+			}
+			for _, t := range st.When.DocumentTypes {
+				if !slices.Contains(knownDocumentTypes, t) {
+					return fmt.Errorf("%w: step %q has unknown document type %q", ErrInvalidWorkflow, st.Name, t)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// stepsFor returns the steps that apply to documents of the given type.
+func stepsFor(steps []WorkflowStep, docType DocumentType) []WorkflowStep {
+	var out []WorkflowStep
+	for _, st := range steps {
+		if st.When == nil || slices.Contains(st.When.DocumentTypes, docType) {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// canDecide reports whether the caller is an approver for the step. approved
+// holds the users who already approved it in this round, in order.
+func (st *WorkflowStep) canDecide(access *middleware.ProjectAccess, approved []string) bool {
+	if slices.Contains(st.Users, access.UserID) {
+		if st.Mode != StepModeSequential {
+			return true
+		}
+		// Sequential: only the next user in line may act.
+		return len(approved) < len(st.Users) && st.Users[len(approved)] == access.UserID
+	}
+	return st.Role != "" && middleware.RoleRank(access.Role) >= middleware.RoleRank(st.Role)
+}
+
+// canEscalate reports whether the caller may decide an escalated step alone.
+func (st *WorkflowStep) canEscalate(access *middleware.ProjectAccess) bool {
+	return st.EscalateTo != "" && middleware.RoleRank(access.Role) >= middleware.RoleRank(st.EscalateTo)
+}
+
+// dueAt returns the SLA deadline for a step started at start, if it has one.
+func (st *WorkflowStep) dueAt(start time.Time) *time.Time {
+	if st.SLAHours <= 0 {
+		return nil
+	}
+	due := start.Add(time.Duration(st.SLAHours) * time.Hour)
+	return &due
+}
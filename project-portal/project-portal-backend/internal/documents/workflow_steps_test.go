@@ -0,0 +1,106 @@
+package documents
+
+import (
+	"errors"
+	"testing"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+)
+
+const (
+	alice = "11111111-1111-1111-1111-111111111111"
+	bob   = "22222222-2222-2222-2222-222222222222"
+	carol = "33333333-3333-3333-3333-333333333333"
+)
+
+func TestNormalizeWorkflowSteps(t *testing.T) {
+	cases := []struct {
+		name string
+		step WorkflowStep
+		want error
+	}{
+		{"role", WorkflowStep{Name: "review", Role: middleware.RoleManager}, nil},
+		{"users with quorum", WorkflowStep{Name: "sign", Users: []string{alice, bob, carol}, Quorum: 2}, nil},
+		{"sequential", WorkflowStep{Name: "sign", Users: []string{alice, bob}, Mode: StepModeSequential}, nil},
+		{"no approvers", WorkflowStep{Name: "sign"}, ErrInvalidWorkflow},
+		{"unknown role", WorkflowStep{Name: "sign", Role: "Admin"}, ErrInvalidWorkflow},
+		{"bad user id", WorkflowStep{Name: "sign", Users: []string{"alice"}}, ErrInvalidWorkflow},
+		{"quorum above users", WorkflowStep{Name: "sign", Users: []string{alice}, Quorum: 2}, ErrInvalidWorkflow},
+		{"sequential partial quorum", WorkflowStep{Name: "sign", Users: []string{alice, bob}, Mode: StepModeSequential, Quorum: 1}, ErrInvalidWorkflow},
+		{"escalation without sla", WorkflowStep{Name: "sign", Role: middleware.RoleViewer, EscalateTo: middleware.RoleOwner}, ErrInvalidWorkflow},
+		{"unknown document type", WorkflowStep{Name: "sign", Role: middleware.RoleViewer, When: &StepCondition{DocumentTypes: []DocumentType{"MEMO"}}}, ErrInvalidWorkflow},
+	}
+	for _, tc := range cases {
+		steps := []WorkflowStep{tc.step}
+		if err := normalizeWorkflowSteps(steps); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	steps := []WorkflowStep{
+		{Name: "review", Role: middleware.RoleManager, SLAHours: 24},
+		{Name: "sign", Users: []string{alice, bob}},
+	}
+	if err := normalizeWorkflowSteps(steps); err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Quorum != 1 || steps[0].EscalateTo != middleware.RoleOwner || steps[0].Mode != StepModeParallel {
+		t.Errorf("role step defaults: %+v", steps[0])
+	}
+	if steps[1].Quorum != 2 {
+		t.Errorf("user step quorum = %d, want every named user", steps[1].Quorum)
+	}
+
+	dup := []WorkflowStep{{Name: "a", Role: middleware.RoleViewer}, {Name: "a", Role: middleware.RoleViewer}}
+	if err := normalizeWorkflowSteps(dup); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Errorf("duplicate names: got %v", err)
+	}
+}
+
+func TestStepsFor(t *testing.T) {
+	steps := []WorkflowStep{
+		{Name: "review"},
+		{Name: "verifier", When: &StepCondition{DocumentTypes: []DocumentType{DocumentTypeMonitoringReport}}},
+	}
+	if got := stepsFor(steps, DocumentTypePDD); len(got) != 1 || got[0].Name != "review" {
+		t.Errorf("PDD steps = %+v", got)
+	}
+	if got := stepsFor(steps, DocumentTypeMonitoringReport); len(got) != 2 {
+		t.Errorf("monitoring report steps = %+v", got)
+	}
+}
+
+func TestCanDecide(t *testing.T) {
+	as := func(user, role string) *middleware.ProjectAccess {
+		return middleware.NewProjectAccess("p", user, role, nil)
+	}
+
+	role := WorkflowStep{Name: "review", Role: middleware.RoleManager}
+	if !role.canDecide(as(alice, middleware.RoleOwner), nil) {
+		t.Error("owner should satisfy a Manager step")
+	}
+	if role.canDecide(as(alice, middleware.RoleContributor), nil) {
+		t.Error("contributor should not satisfy a Manager step")
+	}
+
+	parallel := WorkflowStep{Name: "sign", Users: []string{alice, bob}, Mode: StepModeParallel}
+	if !parallel.canDecide(as(bob, middleware.RoleViewer), nil) {
+		t.Error("named user should decide a parallel step in any order")
+	}
+	if parallel.canDecide(as(carol, middleware.RoleOwner), nil) {
+		t.Error("unnamed user should not decide a user-only step")
+	}
+
+	seq := WorkflowStep{Name: "sign", Users: []string{alice, bob}, Mode: StepModeSequential}
+	if seq.canDecide(as(bob, middleware.RoleViewer), nil) {
+		t.Error("second user should wait for the first")
+	}
+	if !seq.canDecide(as(bob, middleware.RoleViewer), []string{alice}) {
+		t.Error("second user should act after the first")
+	}
+
+	escalating := WorkflowStep{Name: "sign", Users: []string{alice}, SLAHours: 1, EscalateTo: middleware.RoleManager}
+	if !escalating.canEscalate(as(carol, middleware.RoleManager)) || escalating.canEscalate(as(carol, middleware.RoleContributor)) {
+		t.Error("escalation should follow the escalation role")
+	}
+}
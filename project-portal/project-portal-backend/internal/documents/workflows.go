@@ -3,94 +3,148 @@ package documents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/pkg/security"
+
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
 )
 
 // ─── Workflow State Machine ────────────────────────────────────────────────────
 
-// validTransitions defines the allowed Status changes and the role required to
-// perform each transition. An empty required role means any authenticated user may.
+// ErrForbidden is returned when the caller's project access does not allow an action.
+var ErrForbidden = errors.New("forbidden")
+
+// validTransitions defines the allowed Status changes and the project
+// permission required to perform each transition. It applies to documents
+// without a workflow template, or whose template has no steps.
 var validTransitions = map[DocumentStatus][]WorkflowTransition{
 	DocumentStatusDraft: {
-		{To: DocumentStatusSubmitted, RequiredRole: ""},
+		{To: DocumentStatusSubmitted, RequiredPermission: middleware.PermDocumentsWrite},
 	},
 	DocumentStatusSubmitted: {
-		{To: DocumentStatusUnderReview, RequiredRole: "reviewer"},
-		{To: DocumentStatusDraft, RequiredRole: "reviewer"}, // send back
+		{To: DocumentStatusUnderReview, RequiredPermission: middleware.PermDocumentsReview},
+		{To: DocumentStatusDraft, RequiredPermission: middleware.PermDocumentsReview}, // send back
 	},
 	DocumentStatusUnderReview: {
-		{To: DocumentStatusApproved, RequiredRole: "approver"},
-		{To: DocumentStatusRejected, RequiredRole: "approver"},
-		{To: DocumentStatusDraft, RequiredRole: "approver"}, // send back for rework
+		{To: DocumentStatusApproved, RequiredPermission: middleware.PermDocumentsApprove},
+		{To: DocumentStatusRejected, RequiredPermission: middleware.PermDocumentsApprove},
+		{To: DocumentStatusDraft, RequiredPermission: middleware.PermDocumentsApprove}, // send back for rework
+	},
+}
+
+// stepTransitions are the status changes for documents driven by template
+// steps. Submitting needs documents:write; every other transition is a
+// decision on the current step, allowed to that step's approvers. Approving
+// moves to the next step, or to approved once the last step has its quorum.
+var stepTransitions = map[DocumentStatus][]WorkflowTransition{
+	DocumentStatusDraft: {
+		{To: DocumentStatusSubmitted, RequiredPermission: middleware.PermDocumentsWrite},
+	},
+	DocumentStatusSubmitted: {
+		{To: DocumentStatusUnderReview},
+		{To: DocumentStatusApproved},
+		{To: DocumentStatusRejected},
+		{To: DocumentStatusDraft},
+	},
+	DocumentStatusUnderReview: {
+		{To: DocumentStatusApproved},
+		{To: DocumentStatusRejected},
+		{To: DocumentStatusDraft},
 	},
 }
 
 // WorkflowTransition describes a single allowed status change.
 type WorkflowTransition struct {
-	To           DocumentStatus `json:"to"`
-	RequiredRole string         `json:"required_role"`
+	To                 DocumentStatus `json:"to"`
+	RequiredPermission string         `json:"required_permission,omitempty"`
 }
 
 // TransitionRequest is the JSON body for POST /api/v1/documents/:id/transition.
+// The performer's role is taken from their project membership, never the body.
 type TransitionRequest struct {
-	To            DocumentStatus `json:"to" binding:"required"`
-	Comment       string         `json:"comment"`
-	PerformerRole string         `json:"performer_role"` // role of the requesting user
+	To      DocumentStatus `json:"to" binding:"required"`
+	Comment string         `json:"comment"`
 }
 
-// TransitionResponse is returned after a successful state transition.
+// TransitionResponse is returned after a successful state transition. For
+// template workflows, ToStatus is the resulting status, which stays
+// under_review while a step is waiting for more approvals; Step names the
+// step that was decided and SignedVersion the version created when the
+// approval signed the document.
 type TransitionResponse struct {
 	DocumentID     uuid.UUID      `json:"document_id"`
 	FromStatus     DocumentStatus `json:"from_status"`
 	ToStatus       DocumentStatus `json:"to_status"`
+	Step           string         `json:"step,omitempty"`
+	Approvals      int            `json:"approvals,omitempty"`
+	Quorum         int            `json:"quorum,omitempty"`
 	Comment        string         `json:"comment,omitempty"`
+	SignedVersion  int            `json:"signed_version,omitempty"`
 	TransitionedAt time.Time      `json:"transitioned_at"`
 }
 
-// AdvanceWorkflow validates the requested status transition and applies it.
-func (s *Service) AdvanceWorkflow(ctx context.Context, docID uuid.UUID, req *TransitionRequest, userID *uuid.UUID) (*TransitionResponse, error) {
-	doc, err := s.repo.FindByID(ctx, docID)
+// AdvanceWorkflow validates the requested status transition against the
+// caller's project access and applies it. Documents whose template has steps
+// follow those steps; others use the built-in transitions.
+func (s *Service) AdvanceWorkflow(ctx context.Context, docID uuid.UUID, req *TransitionRequest, userID *uuid.UUID, access *middleware.ProjectAccess) (*TransitionResponse, error) {
+	var resp *TransitionResponse
+	var signed *SignResponse
+	err := s.repo.WithDocumentLock(ctx, docID, func(tx *Repository, doc *Document) error {
+		steps, wf, err := loadSteps(ctx, tx, doc)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			resp, err = applyTransition(ctx, tx, doc, req, access)
+			return err
+		}
+		sign := func(step *WorkflowStep) error {
+			var err error
+			signed, err = s.signCurrentFile(ctx, tx, doc, userID, security.SignOptions{Reason: "Approved: " + step.Name})
+			return err
+		}
+		resp, err = applyStepTransition(ctx, tx, doc, wf.ID, steps, req, userID, access, sign)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("document not found: %w", err)
-	}
-
-	// Check that the target status is a valid next state from the current one.
-	if err := validateTransition(doc.Status, req.To, req.PerformerRole); err != nil {
+		if signed != nil {
+			_ = s.storage.Delete(ctx, signed.Version.S3Key)
+		}
 		return nil, err
 	}
-
-	fromStatus := doc.Status
-	doc.Status = req.To
-
-	if err := s.repo.Update(ctx, doc); err != nil {
-		return nil, fmt.Errorf("failed to update document status: %w", err)
-	}
-
-	// Record the transition in the workflow steps (stored in the document_workflows table
-	// if a workflow is attached) and in the access log.
-	if doc.WorkflowID != nil {
-		_ = s.repo.AppendWorkflowStep(ctx, *doc.WorkflowID, WorkflowStepEntry{
-			Step:          fmt.Sprintf("%s → %s", fromStatus, req.To),
-			PerformedBy:   userIDString(userID),
-			PerformerRole: req.PerformerRole,
-			Comment:       req.Comment,
-			PerformedAt:   time.Now().UTC().Format(time.RFC3339),
-		})
+	if signed != nil {
+		resp.SignedVersion = signed.Version.VersionNumber
+		s.logSigning(ctx, signed, userID, "", "")
+		s.wakeTextExtraction()
 	}
 
 	_ = s.repo.LogAccess(ctx, &DocumentAccessLog{
 		DocumentID:  docID,
 		UserID:      userID,
-		Action:      AccessAction(fmt.Sprintf("STATUS_%s", req.To)),
+		Action:      AccessAction(fmt.Sprintf("STATUS_%s", resp.ToStatus)),
 		PerformedAt: time.Now().UTC(),
 	})
+	return resp, nil
+}
 
+// applyTransition performs a built-in transition.
+func applyTransition(ctx context.Context, tx *Repository, doc *Document, req *TransitionRequest, access *middleware.ProjectAccess) (*TransitionResponse, error) {
+	if err := validateTransition(validTransitions, doc.Status, req.To, access); err != nil {
+		return nil, err
+	}
+	fromStatus := doc.Status
+	doc.Status = req.To
+	if err := tx.Update(ctx, doc); err != nil {
+		return nil, fmt.Errorf("failed to update document status: %w", err)
+	}
 	return &TransitionResponse{
-		DocumentID:     docID,
+		DocumentID:     doc.ID,
 		FromStatus:     fromStatus,
 		ToStatus:       req.To,
 		Comment:        req.Comment,
@@ -98,30 +152,177 @@ func (s *Service) AdvanceWorkflow(ctx context.Context, docID uuid.UUID, req *Tra
 	}, nil
 }
 
-// GetWorkflowState returns the document's current status, available next states,
-// and the full audit trail of workflow steps if a workflow is attached.
+// applyStepTransition submits the document into its template's first step or
+// records the caller's decision on the current step. sign is called for
+// approvals of Sign steps before the decision is recorded.
+func applyStepTransition(ctx context.Context, tx *Repository, doc *Document, workflowID uuid.UUID, steps []WorkflowStep, req *TransitionRequest, userID *uuid.UUID, access *middleware.ProjectAccess, sign func(*WorkflowStep) error) (*TransitionResponse, error) {
+	if err := validateTransition(stepTransitions, doc.Status, req.To, access); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	resp := &TransitionResponse{
+		DocumentID:     doc.ID,
+		FromStatus:     doc.Status,
+		Comment:        req.Comment,
+		TransitionedAt: now,
+	}
+	run, err := tx.FindWorkflowRun(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.To == DocumentStatusSubmitted {
+		round := 1
+		if run != nil {
+			round = run.Round + 1
+		}
+		run = &DocumentWorkflowRun{
+			DocumentID:    doc.ID,
+			WorkflowID:    workflowID,
+			Round:         round,
+			StepStartedAt: now,
+			DueAt:         steps[0].dueAt(now),
+			UpdatedAt:     now,
+		}
+		doc.Status = DocumentStatusSubmitted
+		if err := saveStepProgress(ctx, tx, doc, run); err != nil {
+			return nil, err
+		}
+		resp.ToStatus, resp.Step, resp.Quorum = doc.Status, steps[0].Name, steps[0].Quorum
+		return resp, nil
+	}
+
+	if run == nil || run.WorkflowID != workflowID || run.StepIndex >= len(steps) {
+		return nil, fmt.Errorf("%w: document has no active step; send it back to draft and resubmit", ErrInvalidWorkflow)
+	}
+	step := &steps[run.StepIndex]
+	approved, err := tx.StepApprovers(ctx, doc.ID, run.Round, run.StepIndex)
+	if err != nil {
+		return nil, err
+	}
+	override := run.EscalatedAt != nil && step.canEscalate(access)
+	if !override && !step.canDecide(access, approved) {
+		return nil, fmt.Errorf("%w: you are not an approver for step %q", ErrForbidden, step.Name)
+	}
+	resp.Step, resp.Quorum = step.Name, step.Quorum
+
+	decision := &DocumentApproval{
+		ID:         uuid.New(),
+		DocumentID: doc.ID,
+		WorkflowID: workflowID,
+		Round:      run.Round,
+		StepIndex:  run.StepIndex,
+		StepName:   step.Name,
+		UserID:     userID,
+		Role:       access.Role,
+		Comment:    req.Comment,
+		CreatedAt:  now,
+	}
+	switch req.To {
+	case DocumentStatusUnderReview:
+		decision = nil
+		doc.Status = DocumentStatusUnderReview
+	case DocumentStatusApproved:
+		if slices.Contains(approved, access.UserID) {
+			return nil, ErrAlreadyDecided
+		}
+		if step.Sign && sign != nil {
+			if err := sign(step); err != nil {
+				return nil, err
+			}
+		}
+		decision.Decision = DecisionApprove
+		approved = append(approved, access.UserID)
+		doc.Status = DocumentStatusUnderReview
+		if override || len(approved) >= step.Quorum {
+			if run.StepIndex == len(steps)-1 {
+				doc.Status = DocumentStatusApproved
+				run.DueAt = nil
+			} else {
+				run.StepIndex++
+				run.StepStartedAt = now
+				run.DueAt = steps[run.StepIndex].dueAt(now)
+				run.EscalatedAt = nil
+			}
+		}
+	case DocumentStatusRejected:
+		decision.Decision = DecisionReject
+		doc.Status = DocumentStatusRejected
+		run.DueAt = nil
+	case DocumentStatusDraft:
+		decision.Decision = DecisionSendBack
+		doc.Status = DocumentStatusDraft
+		run.DueAt = nil
+	}
+
+	if decision != nil {
+		if err := tx.CreateApproval(ctx, decision); err != nil {
+			return nil, err
+		}
+	}
+	run.UpdatedAt = now
+	if err := saveStepProgress(ctx, tx, doc, run); err != nil {
+		return nil, err
+	}
+	resp.ToStatus, resp.Approvals = doc.Status, len(approved)
+	return resp, nil
+}
+
+func saveStepProgress(ctx context.Context, tx *Repository, doc *Document, run *DocumentWorkflowRun) error {
+	if err := tx.SaveWorkflowRun(ctx, run); err != nil {
+		return err
+	}
+	if err := tx.Update(ctx, doc); err != nil {
+		return fmt.Errorf("failed to update document status: %w", err)
+	}
+	return nil
+}
+
+// GetWorkflowState returns the document's current status, available next
+// states and, for template workflows, the steps that apply to it, the
+// current step with its deadline and the approval history.
 func (s *Service) GetWorkflowState(ctx context.Context, docID uuid.UUID) (*WorkflowStateResponse, error) {
 	doc, err := s.repo.FindByID(ctx, docID)
 	if err != nil {
 		return nil, fmt.Errorf("document not found: %w", err)
 	}
 
-	available := validTransitions[doc.Status]
-
 	resp := &WorkflowStateResponse{
-		DocumentID:           docID,
-		CurrentStatus:        doc.Status,
-		AvailableTransitions: available,
+		DocumentID:    docID,
+		CurrentStatus: doc.Status,
+	}
+	steps, wf, err := loadSteps(ctx, s.repo, doc)
+	if err != nil {
+		return nil, err
+	}
+	if wf != nil {
+		resp.WorkflowName = wf.Name
+	}
+	if len(steps) == 0 {
+		resp.AvailableTransitions = validTransitions[doc.Status]
+		return resp, nil
 	}
 
-	if doc.WorkflowID != nil {
-		wf, err := s.repo.FindWorkflowByID(ctx, *doc.WorkflowID)
-		if err == nil {
-			resp.WorkflowName = wf.Name
-			resp.WorkflowSteps = wf.Steps
+	resp.AvailableTransitions = stepTransitions[doc.Status]
+	resp.WorkflowSteps = steps
+	run, err := s.repo.FindWorkflowRun(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	inReview := doc.Status == DocumentStatusSubmitted || doc.Status == DocumentStatusUnderReview
+	if run != nil && inReview && run.StepIndex < len(steps) {
+		resp.CurrentStep = steps[run.StepIndex].Name
+		resp.StepDueAt = run.DueAt
+		resp.EscalatedAt = run.EscalatedAt
+		approved, err := s.repo.StepApprovers(ctx, docID, run.Round, run.StepIndex)
+		if err != nil {
+			return nil, err
 		}
+		resp.StepApprovals = approved
+	}
+	if resp.History, err = s.repo.ListApprovals(ctx, docID); err != nil {
+		return nil, err
 	}
-
 	return resp, nil
 }
 
@@ -131,12 +332,29 @@ type WorkflowStateResponse struct {
 	CurrentStatus        DocumentStatus       `json:"current_status"`
 	AvailableTransitions []WorkflowTransition `json:"available_transitions"`
 	WorkflowName         string               `json:"workflow_name,omitempty"`
-	WorkflowSteps        datatypes.JSON       `json:"workflow_steps,omitempty"`
+	WorkflowSteps        []WorkflowStep       `json:"workflow_steps,omitempty"`
+	CurrentStep          string               `json:"current_step,omitempty"`
+	StepApprovals        []string             `json:"step_approvals,omitempty"`
+	StepDueAt            *time.Time           `json:"step_due_at,omitempty"`
+	EscalatedAt          *time.Time           `json:"escalated_at,omitempty"`
+	History              []DocumentApproval   `json:"history,omitempty"`
 }
 
-// CreateWorkflowTemplate creates a new reusable workflow template.
+// CreateWorkflowTemplate validates the steps and creates a reusable workflow
+// template.
 func (s *Service) CreateWorkflowTemplate(ctx context.Context, req *CreateWorkflowTemplateRequest) (*DocumentWorkflow, error) {
-	stepsJSON, err := json.Marshal(req.Steps)
+	docType := DocumentType(req.DocumentType)
+	if docType != AnyDocumentType && !slices.Contains(knownDocumentTypes, docType) {
+		return nil, fmt.Errorf("%w: unknown document type %q", ErrInvalidWorkflow, req.DocumentType)
+	}
+	steps := slices.Clone(req.Steps)
+	if err := normalizeWorkflowSteps(steps); err != nil {
+		return nil, err
+	}
+	if steps == nil {
+		steps = []WorkflowStep{}
+	}
+	stepsJSON, err := json.Marshal(steps)
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialise steps: %w", err)
 	}
@@ -145,8 +363,9 @@ func (s *Service) CreateWorkflowTemplate(ctx context.Context, req *CreateWorkflo
 		ID:           uuid.New(),
 		Name:         req.Name,
 		Description:  req.Description,
-		DocumentType: DocumentType(req.DocumentType),
-		Steps:        datatypes.JSON(stepsJSON),
+		DocumentType: docType,
+		Steps:        stepsJSON,
+		AutoAssign:   req.AutoAssign,
 	}
 
 	if err := s.repo.CreateWorkflow(ctx, wf); err != nil {
@@ -161,43 +380,142 @@ func (s *Service) ListWorkflowTemplates(ctx context.Context) ([]DocumentWorkflow
 }
 
 // CreateWorkflowTemplateRequest is the JSON body for POST /api/v1/documents/workflows.
+// DocumentType may be "*" for a template that serves every type; steps can
+// then branch with a "when" condition.
 type CreateWorkflowTemplateRequest struct {
-	Name         string                   `json:"name" binding:"required"`
-	Description  string                   `json:"description"`
-	DocumentType string                   `json:"document_type" binding:"required"`
-	Steps        []map[string]interface{} `json:"steps"`
+	Name         string         `json:"name" binding:"required"`
+	Description  string         `json:"description"`
+	DocumentType string         `json:"document_type" binding:"required"`
+	Steps        []WorkflowStep `json:"steps"`
+	AutoAssign   bool           `json:"auto_assign"`
 }
 
-// WorkflowStepEntry is a single step recorded in the workflow audit trail.
-type WorkflowStepEntry struct {
-	Step          string `json:"step"`
-	PerformedBy   string `json:"performed_by"`
-	PerformerRole string `json:"performer_role"`
-	Comment       string `json:"comment,omitempty"`
-	PerformedAt   string `json:"performed_at"`
+// assignWorkflow attaches the auto-assign template for the document's type,
+// if any. Failure is logged; the document simply has no workflow.
+func (s *Service) assignWorkflow(ctx context.Context, doc *Document) {
+	wf, err := s.repo.FindAutoAssignWorkflow(ctx, doc.DocumentType)
+	if err != nil {
+		fmt.Printf("WARNING: workflow auto-assignment skipped for document %s: %v\n", doc.ID, err)
+		return
+	}
+	if wf != nil {
+		doc.WorkflowID = &wf.ID
+	}
+}
+
+// ─── SLA escalation ───────────────────────────────────────────────────────────
+
+// EscalateOverdueSteps marks steps past their SLA as escalated, which lets
+// members holding the step's escalation role decide it alone, and records
+// the escalation in the approval history. It returns how many steps were
+// escalated.
+func (s *Service) EscalateOverdueSteps(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	runs, err := s.repo.FindOverdueRuns(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	escalated := 0
+	for _, r := range runs {
+		err := s.repo.WithDocumentLock(ctx, r.DocumentID, func(tx *Repository, doc *Document) error {
+			run, err := tx.FindWorkflowRun(ctx, doc.ID)
+			if err != nil || run == nil || run.EscalatedAt != nil || run.DueAt == nil || run.DueAt.After(now) {
+				return err // decided or escalated since the query
+			}
+			steps, _, err := loadSteps(ctx, tx, doc)
+			if err != nil {
+				return err
+			}
+			if run.StepIndex >= len(steps) {
+				return nil
+			}
+			step := steps[run.StepIndex]
+			run.EscalatedAt, run.UpdatedAt = &now, now
+			if err := tx.SaveWorkflowRun(ctx, run); err != nil {
+				return err
+			}
+			escalated++
+			return tx.CreateApproval(ctx, &DocumentApproval{
+				ID:         uuid.New(),
+				DocumentID: doc.ID,
+				WorkflowID: run.WorkflowID,
+				Round:      run.Round,
+				StepIndex:  run.StepIndex,
+				StepName:   step.Name,
+				Role:       step.EscalateTo,
+				Decision:   DecisionEscalated,
+				Comment:    fmt.Sprintf("SLA of %dh exceeded; escalated to %s", step.SLAHours, step.EscalateTo),
+				CreatedAt:  now,
+			})
+		})
+		if err != nil {
+			log.Printf("WARNING: workflow escalation failed for document %s: %v", r.DocumentID, err)
+		}
+	}
+	return escalated, nil
+}
+
+// StartEscalationSweep escalates overdue workflow steps every interval until
+// ctx is done.
+func (s *Service) StartEscalationSweep(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.EscalateOverdueSteps(ctx)
+				if err != nil {
+					log.Printf("WARNING: workflow escalation sweep failed: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("⏰ Escalated %d overdue workflow step(s)", n)
+				}
+			}
+		}
+	}()
 }
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
 
-func validateTransition(current, target DocumentStatus, callerRole string) error {
-	allowed, ok := validTransitions[current]
+// loadSteps returns the document's workflow template and the template steps
+// that apply to the document's type. Both are nil without a template.
+func loadSteps(ctx context.Context, repo *Repository, doc *Document) ([]WorkflowStep, *DocumentWorkflow, error) {
+	if doc.WorkflowID == nil {
+		return nil, nil, nil
+	}
+	wf, err := repo.FindWorkflowByID(ctx, *doc.WorkflowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var steps []WorkflowStep
+	if err := json.Unmarshal(wf.Steps, &steps); err != nil {
+		return nil, nil, fmt.Errorf("%w: template %s: %v", ErrInvalidWorkflow, wf.ID, err)
+	}
+	if err := normalizeWorkflowSteps(steps); err != nil {
+		return nil, nil, fmt.Errorf("template %s: %w", wf.ID, err)
+	}
+	return stepsFor(steps, doc.DocumentType), wf, nil
+}
+
+func validateTransition(transitions map[DocumentStatus][]WorkflowTransition, current, target DocumentStatus, access *middleware.ProjectAccess) error {
+	allowed, ok := transitions[current]
 	if !ok {
 		return fmt.Errorf("no transitions defined for status %q", current)
 	}
 	for _, t := range allowed {
 		if t.To == target {
-			if t.RequiredRole != "" && callerRole != t.RequiredRole {
-				return fmt.Errorf("transition to %q requires role %q (got %q)", target, t.RequiredRole, callerRole)
+			if t.RequiredPermission != "" && !access.Can(t.RequiredPermission) {
+				return fmt.Errorf("%w: transition to %q requires permission %q", ErrForbidden, target, t.RequiredPermission)
 			}
 			return nil
 		}
 	}
 	return fmt.Errorf("transition from %q to %q is not allowed", current, target)
 }
-
-func userIDString(id *uuid.UUID) string {
-	if id == nil {
-		return "anonymous"
-	}
-	return id.String()
-}
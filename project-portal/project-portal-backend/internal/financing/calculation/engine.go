@@ -1,7 +1,231 @@
-//go:build future
-// +build future
+package calculation
 
-package financing
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
 
-// This file won't be compiled in normal builds
-// Implementation pending
+// Estimate modes.
+const (
+	// ModeExAnte estimates credits before removals happen, from default
+	// growth rates and planned activity.
+	ModeExAnte = "ex_ante"
+	// ModeExPost computes credits from monitoring data for a completed period.
+	ModeExPost = "ex_post"
+)
+
+// Input is everything a calculation depends on. The same Input always yields
+// the same Result, and Result.InputHash identifies it.
+type Input struct {
+	MethodologyCode string             `json:"methodology_code"`
+	Mode            string             `json:"mode"`
+	AreaHectares    float64            `json:"area_hectares"`
+	Years           float64            `json:"years"`
+	Parameters      map[string]float64 `json:"parameters"`
+}
+
+// Step is one line of the calculation trail.
+type Step struct {
+	Name    string  `json:"name"`
+	Formula string  `json:"formula"`
+	Value   float64 `json:"value"`
+	Unit    string  `json:"unit"`
+}
+
+// Result is an auditable credit estimate. All quantities are tCO2e.
+type Result struct {
+	MethodologyCode    string             `json:"methodology_code"`
+	MethodologyVersion string             `json:"methodology_version"`
+	Mode               string             `json:"mode"`
+	AreaHectares       float64            `json:"area_hectares"`
+	Years              float64            `json:"years"`
+	Parameters         map[string]float64 `json:"parameters"` // resolved, including defaults
+	GrossRemovals      float64            `json:"gross_removals"`
+	BaselineRemovals   float64            `json:"baseline_removals"`
+	ProjectEmissions   float64            `json:"project_emissions"`
+	LeakageDeduction   float64            `json:"leakage_deduction"`
+	BufferDeduction    float64            `json:"buffer_deduction"`
+	NetCredits         float64            `json:"net_credits"`
+	IssuableCredits    int64              `json:"issuable_credits"` // whole tonnes
+	Steps              []Step             `json:"steps"`
+	InputHash          string             `json:"input_hash"`
+	CalculatedAt       time.Time          `json:"calculated_at"`
+}
+
+// quantities is what a methodology formula produces before the engine applies
+// the common leakage and buffer deductions.
+type quantities struct {
+	gross     float64
+	baseline  float64
+	emissions float64
+	steps     []Step
+}
+
+type calculator func(in Input, p map[string]float64) quantities
+
+// calculators implements the formulas for each catalog methodology.
+var calculators = map[string]calculator{
+	"VM0047":     calculateARR,
+	"AR-AMS0007": calculateAgroforestry,
+	"VM0042":     calculateSoilCarbon,
+	"GS-TPDDTEC": calculateCookstoves,
+}
+
+// Engine computes credit estimates from the methodology catalog.
+type Engine struct {
+	now func() time.Time
+}
+
+// NewEngine creates a calculation engine.
+func NewEngine() *Engine {
+	return &Engine{now: time.Now}
+}
+
+// Calculate validates the input, resolves defaults and applies the
+// methodology formula followed by leakage and buffer-pool deductions.
+func (e *Engine) Calculate(in Input) (*Result, error) {
+	m, err := Get(in.MethodologyCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	calc, ok := calculators[m.Code]
+	if !ok {
+		return nil, fmt.Errorf("no calculator registered for %s", m.Code)
+	}
+	if err := Validate(m, in); err != nil {
+		return nil, err
+	}
+
+	params := resolveParameters(m, in)
+	q := calc(in, params)
+
+	net := q.gross - q.baseline - q.emissions
+	steps := append(q.steps, Step{Name: "net_removals", Formula: "gross - baseline - project_emissions", Value: round(net), Unit: "tCO2e"})
+
+	leakage := math.Max(net, 0) * m.LeakagePercent / 100
+	steps = append(steps, Step{Name: "leakage", Formula: fmt.Sprintf("net_removals × %g%%", m.LeakagePercent), Value: round(leakage), Unit: "tCO2e"})
+
+	buffer := math.Max(net-leakage, 0) * m.BufferPercent / 100
+	steps = append(steps, Step{Name: "buffer_pool", Formula: fmt.Sprintf("(net_removals - leakage) × %g%%", m.BufferPercent), Value: round(buffer), Unit: "tCO2e"})
+
+	credits := math.Max(net-leakage-buffer, 0)
+	steps = append(steps, Step{Name: "net_credits", Formula: "net_removals - leakage - buffer_pool", Value: round(credits), Unit: "tCO2e"})
+
+	result := &Result{
+		MethodologyCode:    m.Code,
+		MethodologyVersion: m.Version,
+		Mode:               in.Mode,
+		AreaHectares:       in.AreaHectares,
+		Years:              in.Years,
+		Parameters:         params,
+		GrossRemovals:      round(q.gross),
+		BaselineRemovals:   round(q.baseline),
+		ProjectEmissions:   round(q.emissions),
+		LeakageDeduction:   round(leakage),
+		BufferDeduction:    round(buffer),
+		NetCredits:         round(credits),
+		IssuableCredits:    int64(math.Floor(credits)),
+		Steps:              steps,
+		CalculatedAt:       e.now().UTC(),
+	}
+	result.InputHash = hashInput(m, in, params)
+	return result, nil
+}
+
+// resolveParameters fills in parameter defaults and methodology default
+// factors for anything the caller did not supply.
+func resolveParameters(m *Methodology, in Input) map[string]float64 {
+	out := make(map[string]float64, len(m.Parameters))
+	for _, spec := range m.Parameters {
+		if !spec.usedIn(in.Mode) {
+			continue
+		}
+		if v, ok := in.Parameters[spec.Name]; ok {
+			out[spec.Name] = v
+		} else if spec.Default != nil {
+			out[spec.Name] = *spec.Default
+		} else if v, ok := m.DefaultFactors[spec.Name]; ok {
+			out[spec.Name] = v
+		}
+	}
+	return out
+}
+
+// hashInput fingerprints the methodology version and resolved inputs so a
+// stored result can be reproduced and checked later.
+func hashInput(m *Methodology, in Input, params map[string]float64) string {
+	payload, _ := json.Marshal(struct {
+		Code       string             `json:"code"`
+		Version    string             `json:"version"`
+		Mode       string             `json:"mode"`
+		Area       float64            `json:"area"`
+		Years      float64            `json:"years"`
+		Parameters map[string]float64 `json:"parameters"`
+		Buffer     float64            `json:"buffer"`
+		Leakage    float64            `json:"leakage"`
+	}{m.Code, m.Version, in.Mode, in.AreaHectares, in.Years, params, m.BufferPercent, m.LeakagePercent})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// --- Methodology formulas ---
+
+func calculateARR(in Input, p map[string]float64) quantities {
+	var q quantities
+	if in.Mode == ModeExAnte {
+		q.gross = in.AreaHectares * p["annual_removal_rate"] * in.Years
+		q.steps = append(q.steps, Step{Name: "gross_removals", Formula: "area × annual_removal_rate × years", Value: round(q.gross), Unit: "tCO2e"})
+	} else {
+		biomass := (p["agb_end"] - p["agb_start"]) * (1 + p["root_shoot_ratio"])
+		q.steps = append(q.steps, Step{Name: "biomass_change", Formula: "(agb_end - agb_start) × (1 + root_shoot_ratio)", Value: round(biomass), Unit: "t d.m./ha"})
+		q.gross = biomass * p["carbon_fraction"] * CO2PerC * in.AreaHectares
+		q.steps = append(q.steps, Step{Name: "gross_removals", Formula: "biomass_change × carbon_fraction × 44/12 × area", Value: round(q.gross), Unit: "tCO2e"})
+	}
+	q.baseline = p["baseline_removals"]
+	return q
+}
+
+func calculateAgroforestry(in Input, p map[string]float64) quantities {
+	var q quantities
+	var biomass float64
+	if in.Mode == ModeExAnte {
+		biomass = p["trees_per_hectare"] * p["survival_rate"] * p["annual_growth_per_tree"] / 1000 * in.Years
+		q.steps = append(q.steps, Step{Name: "biomass_change", Formula: "trees_per_hectare × survival_rate × annual_growth_per_tree / 1000 × years", Value: round(biomass), Unit: "t d.m./ha"})
+	} else {
+		biomass = p["measured_biomass_change"]
+		q.steps = append(q.steps, Step{Name: "biomass_change", Formula: "measured_biomass_change", Value: round(biomass), Unit: "t d.m./ha"})
+	}
+	q.gross = biomass * (1 + p["root_shoot_ratio"]) * p["carbon_fraction"] * CO2PerC * in.AreaHectares
+	q.steps = append(q.steps, Step{Name: "gross_removals", Formula: "biomass_change × (1 + root_shoot_ratio) × carbon_fraction × 44/12 × area", Value: round(q.gross), Unit: "tCO2e"})
+	return q
+}
+
+func calculateSoilCarbon(in Input, p map[string]float64) quantities {
+	var q quantities
+	if in.Mode == ModeExAnte {
+		q.gross = in.AreaHectares * p["soc_sequestration_rate"] * CO2PerC * in.Years
+		q.steps = append(q.steps, Step{Name: "gross_removals", Formula: "area × soc_sequestration_rate × 44/12 × years", Value: round(q.gross), Unit: "tCO2e"})
+	} else {
+		stock := (p["soc_project"] - p["soc_baseline"]) * CO2PerC * in.AreaHectares
+		q.steps = append(q.steps, Step{Name: "soc_stock_change", Formula: "(soc_project - soc_baseline) × 44/12 × area", Value: round(stock), Unit: "tCO2e"})
+		q.gross = stock * (1 - p["uncertainty_deduction"])
+		q.steps = append(q.steps, Step{Name: "gross_removals", Formula: "soc_stock_change × (1 - uncertainty_deduction)", Value: round(q.gross), Unit: "tCO2e"})
+	}
+	q.emissions = p["project_emissions"]
+	return q
+}
+
+func calculateCookstoves(in Input, p map[string]float64) quantities {
+	var q quantities
+	q.gross = p["stoves_deployed"] * p["usage_rate"] * p["fuel_savings_per_stove"] * p["fnrb"] * p["emission_factor"] * in.Years
+	q.steps = append(q.steps, Step{Name: "emission_reductions", Formula: "stoves_deployed × usage_rate × fuel_savings_per_stove × fnrb × emission_factor × years", Value: round(q.gross), Unit: "tCO2e"})
+	return q
+}
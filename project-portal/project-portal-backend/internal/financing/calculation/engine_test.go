@@ -0,0 +1,79 @@
+package calculation
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCalculateARRExAnte(t *testing.T) {
+	res, err := NewEngine().Calculate(Input{MethodologyCode: "VM0047", Mode: ModeExAnte, AreaHectares: 100, Years: 10})
+	if err != nil {
+		t.Fatalf("Calculate error: %v", err)
+	}
+	// 100 ha × 8 t/ha/yr × 10 yr = 8000 gross; 5% leakage = 400; 20% buffer of 7600 = 1520.
+	if res.GrossRemovals != 8000 || res.LeakageDeduction != 400 || res.BufferDeduction != 1520 {
+		t.Fatalf("unexpected deductions: %+v", res)
+	}
+	if res.NetCredits != 6080 || res.IssuableCredits != 6080 {
+		t.Fatalf("unexpected net credits: %v / %d", res.NetCredits, res.IssuableCredits)
+	}
+	if res.Parameters["annual_removal_rate"] != 8 {
+		t.Fatalf("default parameter not resolved: %v", res.Parameters)
+	}
+}
+
+func TestCalculateSoilCarbonExPost(t *testing.T) {
+	res, err := NewEngine().Calculate(Input{
+		MethodologyCode: "VM0042",
+		Mode:            ModeExPost,
+		AreaHectares:    10,
+		Years:           5,
+		Parameters:      map[string]float64{"soc_baseline": 40, "soc_project": 43},
+	})
+	if err != nil {
+		t.Fatalf("Calculate error: %v", err)
+	}
+	// 3 t C/ha × 44/12 × 10 ha × (1 - 0.15)
+	want := 3 * CO2PerC * 10 * 0.85
+	if math.Abs(res.GrossRemovals-want) > 0.001 {
+		t.Fatalf("gross = %v, want %v", res.GrossRemovals, want)
+	}
+}
+
+func TestCalculateIsReproducible(t *testing.T) {
+	in := Input{MethodologyCode: "GS-TPDDTEC", Mode: ModeExAnte, Years: 1, Parameters: map[string]float64{"stoves_deployed": 1000}}
+	a, err := NewEngine().Calculate(in)
+	if err != nil {
+		t.Fatalf("Calculate error: %v", err)
+	}
+	b, _ := NewEngine().Calculate(in)
+	if a.InputHash != b.InputHash || a.NetCredits != b.NetCredits {
+		t.Fatal("identical inputs must produce identical results")
+	}
+}
+
+func TestValidateRejectsMissingMonitoringData(t *testing.T) {
+	_, err := NewEngine().Calculate(Input{MethodologyCode: "VM0047", Mode: ModeExPost, AreaHectares: 10, Years: 1})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	_, err = NewEngine().Calculate(Input{MethodologyCode: "VM0047", Mode: ModeExAnte, AreaHectares: 10, Years: 31})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected crediting period violation, got %v", err)
+	}
+}
+
+func TestValidateReportsProblemsInStableOrder(t *testing.T) {
+	in := Input{MethodologyCode: "VM0047", Mode: ModeExAnte, AreaHectares: 10, Years: 1,
+		Parameters: map[string]float64{"zeta": 1, "alpha": 1, "mu": 1, "beta": 1}}
+	_, first := NewEngine().Calculate(in)
+	if first == nil {
+		t.Fatal("expected unknown parameters to be rejected")
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := NewEngine().Calculate(in); err.Error() != first.Error() {
+			t.Fatalf("problems reordered: %q vs %q", err, first)
+		}
+	}
+}
@@ -1,7 +1,167 @@
-//go:build future
-// +build future
+package calculation
 
-package financing
+import (
+	"fmt"
+	"sort"
+)
 
-// This file won't be compiled in normal builds
-// Implementation pending
+// Methodology categories supported by the catalog.
+const (
+	CategoryARR          = "arr"
+	CategoryAgroforestry = "agroforestry"
+	CategorySoilCarbon   = "soil_carbon"
+	CategoryCookstoves   = "cookstoves"
+)
+
+// Unit conversion constants shared by the methodologies.
+const (
+	// CO2PerC converts tonnes of carbon to tonnes of CO2 (44/12).
+	CO2PerC = 44.0 / 12.0
+)
+
+// ParameterSpec describes one input a methodology accepts. Parameters with a
+// Default may be omitted; Required parameters without a default must be
+// supplied by the caller.
+type ParameterSpec struct {
+	Name        string   `json:"name"`
+	Unit        string   `json:"unit"`
+	Description string   `json:"description"`
+	ExAnte      bool     `json:"ex_ante"` // used for ex-ante estimates
+	ExPost      bool     `json:"ex_post"` // used for ex-post (monitored) estimates
+	Required    bool     `json:"required"`
+	Default     *float64 `json:"default,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+}
+
+// Methodology is a crediting methodology with its parameters, default factors
+// and deductions. Formulas are implemented by the calculator registered for
+// the methodology code in the engine.
+type Methodology struct {
+	Code                 string             `json:"code"`
+	Name                 string             `json:"name"`
+	Standard             string             `json:"standard"`
+	Version              string             `json:"version"`
+	Category             string             `json:"category"`
+	AreaBased            bool               `json:"area_based"`
+	Parameters           []ParameterSpec    `json:"parameters"`
+	DefaultFactors       map[string]float64 `json:"default_factors"`
+	BufferPercent        float64            `json:"buffer_percent"`
+	LeakagePercent       float64            `json:"leakage_percent"`
+	CreditingPeriodYears int                `json:"crediting_period_years"`
+}
+
+// Parameter returns the spec for name.
+func (m *Methodology) Parameter(name string) (ParameterSpec, bool) {
+	for _, p := range m.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ParameterSpec{}, false
+}
+
+func f(v float64) *float64 { return &v }
+
+// catalog holds the built-in methodologies keyed by code.
+var catalog = map[string]*Methodology{
+	"VM0047": {
+		Code:      "VM0047",
+		Name:      "Afforestation, Reforestation and Revegetation",
+		Standard:  "Verra VCS",
+		Version:   "1.0",
+		Category:  CategoryARR,
+		AreaBased: true,
+		Parameters: []ParameterSpec{
+			{Name: "annual_removal_rate", Unit: "tCO2e/ha/yr", Description: "Expected net removals per hectare per year", ExAnte: true, Default: f(8.0), Min: f(0), Max: f(50)},
+			{Name: "agb_start", Unit: "t d.m./ha", Description: "Above-ground biomass at the start of the monitoring period", ExPost: true, Required: true, Min: f(0)},
+			{Name: "agb_end", Unit: "t d.m./ha", Description: "Above-ground biomass at the end of the monitoring period", ExPost: true, Required: true, Min: f(0)},
+			{Name: "root_shoot_ratio", Unit: "ratio", Description: "Below-ground to above-ground biomass ratio", ExPost: true, Min: f(0), Max: f(1)},
+			{Name: "carbon_fraction", Unit: "t C/t d.m.", Description: "Carbon fraction of dry matter", ExPost: true, Min: f(0), Max: f(1)},
+			{Name: "baseline_removals", Unit: "tCO2e", Description: "Removals that would have occurred without the project", ExAnte: true, ExPost: true, Default: f(0), Min: f(0)},
+		},
+		DefaultFactors:       map[string]float64{"root_shoot_ratio": 0.24, "carbon_fraction": 0.47},
+		BufferPercent:        20,
+		LeakagePercent:       5,
+		CreditingPeriodYears: 30,
+	},
+	"AR-AMS0007": {
+		Code:      "AR-AMS0007",
+		Name:      "Small-scale A/R on lands other than wetlands (agroforestry)",
+		Standard:  "CDM",
+		Version:   "3.1",
+		Category:  CategoryAgroforestry,
+		AreaBased: true,
+		Parameters: []ParameterSpec{
+			{Name: "trees_per_hectare", Unit: "trees/ha", Description: "Planting density of woody perennials", ExAnte: true, ExPost: true, Default: f(400), Min: f(0)},
+			{Name: "annual_growth_per_tree", Unit: "kg d.m./tree/yr", Description: "Expected dry-matter increment per tree", ExAnte: true, Default: f(10), Min: f(0)},
+			{Name: "survival_rate", Unit: "fraction", Description: "Share of planted trees surviving", ExAnte: true, ExPost: true, Default: f(0.8), Min: f(0), Max: f(1)},
+			{Name: "measured_biomass_change", Unit: "t d.m./ha", Description: "Measured change in tree biomass over the monitoring period", ExPost: true, Required: true},
+			{Name: "root_shoot_ratio", Unit: "ratio", Description: "Below-ground to above-ground biomass ratio", ExAnte: true, ExPost: true, Min: f(0), Max: f(1)},
+			{Name: "carbon_fraction", Unit: "t C/t d.m.", Description: "Carbon fraction of dry matter", ExAnte: true, ExPost: true, Min: f(0), Max: f(1)},
+		},
+		DefaultFactors:       map[string]float64{"root_shoot_ratio": 0.26, "carbon_fraction": 0.47},
+		BufferPercent:        15,
+		LeakagePercent:       0,
+		CreditingPeriodYears: 20,
+	},
+	"VM0042": {
+		Code:      "VM0042",
+		Name:      "Improved Agricultural Land Management (soil carbon)",
+		Standard:  "Verra VCS",
+		Version:   "2.0",
+		Category:  CategorySoilCarbon,
+		AreaBased: true,
+		Parameters: []ParameterSpec{
+			{Name: "soc_sequestration_rate", Unit: "t C/ha/yr", Description: "Expected soil organic carbon gain per hectare per year", ExAnte: true, Default: f(0.3), Min: f(0), Max: f(5)},
+			{Name: "soc_baseline", Unit: "t C/ha", Description: "Measured SOC stock in the baseline", ExPost: true, Required: true, Min: f(0)},
+			{Name: "soc_project", Unit: "t C/ha", Description: "Measured SOC stock at the end of the monitoring period", ExPost: true, Required: true, Min: f(0)},
+			{Name: "project_emissions", Unit: "tCO2e", Description: "N2O and fuel emissions caused by the practice change", ExAnte: true, ExPost: true, Default: f(0), Min: f(0)},
+			{Name: "uncertainty_deduction", Unit: "fraction", Description: "Share deducted for measurement uncertainty", ExPost: true, Min: f(0), Max: f(1)},
+		},
+		DefaultFactors:       map[string]float64{"uncertainty_deduction": 0.15},
+		BufferPercent:        10,
+		LeakagePercent:       5,
+		CreditingPeriodYears: 10,
+	},
+	"GS-TPDDTEC": {
+		Code:      "GS-TPDDTEC",
+		Name:      "Technologies and Practices to Displace Decentralized Thermal Energy Consumption (cookstoves)",
+		Standard:  "Gold Standard",
+		Version:   "3.1",
+		Category:  CategoryCookstoves,
+		AreaBased: false,
+		Parameters: []ParameterSpec{
+			{Name: "stoves_deployed", Unit: "stoves", Description: "Number of improved stoves in use", ExAnte: true, ExPost: true, Required: true, Min: f(0)},
+			{Name: "fuel_savings_per_stove", Unit: "t wood/stove/yr", Description: "Woody biomass saved per stove per year", ExAnte: true, ExPost: true, Default: f(1.2), Min: f(0)},
+			{Name: "usage_rate", Unit: "fraction", Description: "Share of deployed stoves in regular use", ExAnte: true, ExPost: true, Default: f(0.9), Min: f(0), Max: f(1)},
+			{Name: "fnrb", Unit: "fraction", Description: "Fraction of non-renewable biomass", ExAnte: true, ExPost: true, Min: f(0), Max: f(1)},
+			{Name: "emission_factor", Unit: "tCO2/t wood", Description: "Emission factor of displaced wood fuel (NCV × EF)", ExAnte: true, ExPost: true, Min: f(0)},
+		},
+		DefaultFactors:       map[string]float64{"fnrb": 0.3, "emission_factor": 1.747},
+		BufferPercent:        0,
+		LeakagePercent:       5,
+		CreditingPeriodYears: 7,
+	},
+}
+
+// Get returns the methodology registered under code.
+func Get(code string) (*Methodology, error) {
+	m, ok := catalog[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown methodology %q", code)
+	}
+	return m, nil
+}
+
+// List returns every methodology, optionally filtered by category, sorted by code.
+func List(category string) []*Methodology {
+	out := make([]*Methodology, 0, len(catalog))
+	for _, m := range catalog {
+		if category == "" || m.Category == category {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
@@ -1,7 +1,67 @@
-//go:build future
-// +build future
+package calculation
 
-package financing
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
 
-// This file won't be compiled in normal builds
-// Implementation pending
+// ErrInvalidInput wraps every validation failure so callers can map it to a
+// client error.
+var ErrInvalidInput = errors.New("invalid calculation input")
+
+// Validate checks the input against the methodology's parameter specs for the
+// requested mode. All problems are reported together, in a stable order.
+func Validate(m *Methodology, in Input) error {
+	var problems []string
+
+	if in.Mode != ModeExAnte && in.Mode != ModeExPost {
+		problems = append(problems, fmt.Sprintf("mode must be %q or %q", ModeExAnte, ModeExPost))
+	}
+	if m.AreaBased && in.AreaHectares <= 0 {
+		problems = append(problems, "area_hectares must be greater than zero")
+	}
+	if in.Years <= 0 {
+		problems = append(problems, "years must be greater than zero")
+	} else if m.CreditingPeriodYears > 0 && in.Years > float64(m.CreditingPeriodYears) {
+		problems = append(problems, fmt.Sprintf("years exceeds the %d-year crediting period", m.CreditingPeriodYears))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(in.Parameters)) {
+		v := in.Parameters[name]
+		spec, ok := m.Parameter(name)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown parameter %q", name))
+			continue
+		}
+		if spec.Min != nil && v < *spec.Min {
+			problems = append(problems, fmt.Sprintf("%s must be at least %g", name, *spec.Min))
+		}
+		if spec.Max != nil && v > *spec.Max {
+			problems = append(problems, fmt.Sprintf("%s must be at most %g", name, *spec.Max))
+		}
+	}
+
+	for _, spec := range m.Parameters {
+		if !spec.Required || !spec.usedIn(in.Mode) {
+			continue
+		}
+		if _, ok := in.Parameters[spec.Name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is required for %s estimates", spec.Name, in.Mode))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (p ParameterSpec) usedIn(mode string) bool {
+	if mode == ModeExAnte {
+		return p.ExAnte
+	}
+	return p.ExPost
+}
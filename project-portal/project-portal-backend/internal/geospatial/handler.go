@@ -1,34 +1,53 @@
 package geospatial
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// ProjectMembers lists the projects a user belongs to. Spatial queries
+// across projects are limited to them.
+type ProjectMembers interface {
+	ListUserProjectIDs(ctx context.Context, userID string) ([]string, error)
+}
+
 type Handler struct {
 	service Service
+	authz   middleware.Authorizer
+	members ProjectMembers
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, authz middleware.Authorizer, members ProjectMembers) *Handler {
+	return &Handler{service: service, authz: authz, members: members}
 }
 
+// RegisterRoutes mounts the geospatial API. The group must already require
+// authentication; per-project routes also check the caller's project role,
+// cross-project queries only return the caller's projects and platform-wide
+// geofences may only be created by admins.
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	byID := middleware.ProjectFromParam("id")
+	canRead := middleware.RequireProjectPermission(h.authz, middleware.PermProjectRead, byID)
+	canWrite := middleware.RequireProjectPermission(h.authz, middleware.PermProjectWrite, byID)
+
 	g := rg.Group("/geospatial")
 	{
-		g.POST("/projects/:id/geometry", h.UploadProjectGeometry)
-		g.GET("/projects/:id/geometry", h.GetProjectGeometry)
-		g.GET("/projects/:id/boundary", h.GetProjectBoundary)
+		g.POST("/projects/:id/geometry", canWrite, h.UploadProjectGeometry)
+		g.GET("/projects/:id/geometry", canRead, h.GetProjectGeometry)
+		g.GET("/projects/:id/boundary", canRead, h.GetProjectBoundary)
 		g.GET("/projects/nearby", h.GetNearbyProjects)
 		g.GET("/projects/within", h.GetProjectsWithin)
 		g.POST("/analysis/intersect", h.AnalyzeIntersection)
 		g.GET("/maps/static", h.GetStaticMap)
 		g.GET("/maps/tile/:z/:x/:y", h.GetMapTile)
-		g.POST("/geofences", h.CreateGeofence)
-		g.GET("/geofences/project/:id", h.CheckProjectGeofences)
+		g.POST("/geofences", middleware.RequirePlatformRole(), h.CreateGeofence)
+		g.GET("/geofences/project/:id", canRead, h.CheckProjectGeofences)
 		g.GET("/boundaries/:level", h.GetBoundaries)
 	}
 }
@@ -93,6 +112,11 @@ func (h *Handler) GetNearbyProjects(c *gin.Context) {
 		return
 	}
 
+	var ok bool
+	if q.ProjectIDs, ok = h.callerProjects(c); !ok {
+		return
+	}
+
 	data, err := h.service.FindNearby(c.Request.Context(), q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -108,6 +132,11 @@ func (h *Handler) GetProjectsWithin(c *gin.Context) {
 		return
 	}
 
+	var ok bool
+	if q.ProjectIDs, ok = h.callerProjects(c); !ok {
+		return
+	}
+
 	data, err := h.service.FindWithin(c.Request.Context(), q)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -123,6 +152,11 @@ func (h *Handler) AnalyzeIntersection(c *gin.Context) {
 		return
 	}
 
+	var ok bool
+	if req.ProjectIDs, ok = h.callerProjects(c); !ok {
+		return
+	}
+
 	results, err := h.service.Intersect(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -233,3 +267,23 @@ func (h *Handler) GetBoundaries(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"boundaries": items, "count": len(items)})
 }
+
+// callerProjects returns the projects the caller may query, or nil for
+// platform admins, who may query all of them. It writes the error response
+// and returns false on failure.
+func (h *Handler) callerProjects(c *gin.Context) ([]string, bool) {
+	if middleware.IsPlatformAdmin(c) {
+		return nil, true
+	}
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return nil, false
+	}
+	ids, err := h.members.ListUserProjectIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return append([]string{}, ids...), true
+}
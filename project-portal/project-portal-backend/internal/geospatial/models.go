@@ -59,6 +59,8 @@ type NearbyQuery struct {
 	Lon          float64 `form:"lon" binding:"required"`
 	RadiusMeters float64 `form:"radius_meters"`
 	Limit        int     `form:"limit"`
+	// ProjectIDs limits results to these projects; nil means all.
+	ProjectIDs []string `form:"-"`
 }
 
 type WithinQuery struct {
@@ -68,10 +70,14 @@ type WithinQuery struct {
 	MaxLon    *float64 `form:"max_lon"`
 	GeoJSON   string   `form:"geojson"`
 	Limit     int      `form:"limit"`
+	// ProjectIDs limits results to these projects; nil means all.
+	ProjectIDs []string `form:"-"`
 }
 
 type IntersectRequest struct {
 	GeoJSON json.RawMessage `json:"geojson" binding:"required"`
+	// ProjectIDs limits results to these projects; nil means all.
+	ProjectIDs []string `json:"-"`
 }
 
 type IntersectResult struct {
@@ -0,0 +1,22 @@
+package geospatial
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PurgeProjectData permanently removes the project's geometry and geofence
+// events inside the caller's transaction. It implements project.DataPurger.
+func (s *service) PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error) {
+	tx = tx.WithContext(ctx)
+	if err := tx.Exec("DELETE FROM geofence_events WHERE project_id = ?", projectID).Error; err != nil {
+		return nil, fmt.Errorf("purge geofence events: %w", err)
+	}
+	if err := tx.Exec("DELETE FROM project_geometries WHERE project_id = ?", projectID).Error; err != nil {
+		return nil, fmt.Errorf("purge project geometry: %w", err)
+	}
+	return nil, nil
+}
@@ -1,6 +1,15 @@
 package queries
 
-const IntersectionSQL = `
+// IntersectionSQL measures each project's overlap with a GeoJSON geometry.
+// When scoped, the final placeholder takes the allowed project IDs.
+func IntersectionSQL(scoped bool) string {
+	if !scoped {
+		return intersectionSQL
+	}
+	return intersectionSQL + "WHERE pg.project_id::text IN ?\n"
+}
+
+const intersectionSQL = `
 SELECT pg.project_id,
        ST_Intersects(pg.geometry::geometry, ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)) AS intersects,
        CASE
@@ -2,7 +2,9 @@ package queries
 
 import "fmt"
 
-func NearbyProjectsSQL(limit int) string {
+// NearbyProjectsSQL finds projects whose centroid lies within a radius of a
+// point. When scoped, the final placeholder takes the allowed project IDs.
+func NearbyProjectsSQL(limit int, scoped bool) string {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -13,13 +15,15 @@ SELECT p.id AS project_id,
        ST_AsGeoJSON(pg.centroid::geometry) AS centroid_geojson
 FROM project_geometries pg
 JOIN projects p ON p.id = pg.project_id
-WHERE ST_DWithin(pg.centroid::geometry::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geometry::geography, ?)
+WHERE ST_DWithin(pg.centroid::geometry::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geometry::geography, ?)%s
 ORDER BY distance_meters ASC
 LIMIT %d
-`, limit)
+`, projectScope(scoped), limit)
 }
 
-func WithinBBoxSQL(limit int) string {
+// WithinBBoxSQL finds projects intersecting a bounding box. When scoped,
+// the final placeholder takes the allowed project IDs.
+func WithinBBoxSQL(limit int, scoped bool) string {
 	if limit <= 0 {
 		limit = 100
 	}
@@ -32,12 +36,14 @@ JOIN projects p ON p.id = pg.project_id
 WHERE ST_Intersects(
   pg.geometry::geometry,
   ST_MakeEnvelope(?, ?, ?, ?, 4326)
-)
+)%s
 LIMIT %d
-`, limit)
+`, projectScope(scoped), limit)
 }
 
-func WithinPolygonSQL(limit int) string {
+// WithinPolygonSQL finds projects intersecting a GeoJSON polygon. When
+// scoped, the final placeholder takes the allowed project IDs.
+func WithinPolygonSQL(limit int, scoped bool) string {
 	if limit <= 0 {
 		limit = 100
 	}
@@ -50,7 +56,15 @@ JOIN projects p ON p.id = pg.project_id
 WHERE ST_Intersects(
   pg.geometry::geometry,
   ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)
-)
+)%s
 LIMIT %d
-`, limit)
+`, projectScope(scoped), limit)
+}
+
+// projectScope is the condition restricting a query to a list of projects.
+func projectScope(scoped bool) string {
+	if !scoped {
+		return ""
+	}
+	return "\n  AND pg.project_id::text IN ?"
 }
@@ -17,10 +17,12 @@ import (
 type Repository interface {
 	UpsertProjectGeometry(ctx context.Context, projectID uuid.UUID, req UploadGeometryRequest) (*ProjectGeometry, error)
 	GetProjectGeometry(ctx context.Context, projectID uuid.UUID) (*ProjectGeometry, error)
+	HasProjectGeometry(ctx context.Context, projectID uuid.UUID) (bool, error)
 	GetProjectBoundary(ctx context.Context, projectID uuid.UUID, format string) (*BoundaryResponse, error)
+	ListProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error)
 	FindNearby(ctx context.Context, q NearbyQuery) ([]NearbyProject, error)
 	FindWithin(ctx context.Context, q WithinQuery) ([]NearbyProject, error)
-	Intersect(ctx context.Context, geometry json.RawMessage) ([]IntersectResult, error)
+	Intersect(ctx context.Context, geometry json.RawMessage, projectIDs []string) ([]IntersectResult, error)
 
 	CreateGeofence(ctx context.Context, req CreateGeofenceRequest) (*Geofence, error)
 	CheckProjectGeofences(ctx context.Context, projectID uuid.UUID) ([]GeofenceCheckResult, error)
@@ -135,6 +137,14 @@ DO UPDATE SET
 	return r.GetProjectGeometry(ctx, projectID)
 }
 
+func (r *repository) HasProjectGeometry(ctx context.Context, projectID uuid.UUID) (bool, error) {
+	var exists bool
+	if err := r.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM project_geometries WHERE project_id = ?)", projectID).Scan(&exists).Error; err != nil {
+		return false, fmt.Errorf("check project geometry: %w", err)
+	}
+	return exists, nil
+}
+
 func (r *repository) GetProjectGeometry(ctx context.Context, projectID uuid.UUID) (*ProjectGeometry, error) {
 	row := r.db.WithContext(ctx).Raw(queries.GeometryByProjectSQL(), projectID).Row()
 	var out ProjectGeometry
@@ -241,8 +251,16 @@ func (r *repository) FindNearby(ctx context.Context, q NearbyQuery) ([]NearbyPro
 		q.Limit = 20
 	}
 
-	sqlStmt := queries.NearbyProjectsSQL(q.Limit)
-	rows, err := r.db.WithContext(ctx).Raw(sqlStmt, q.Lon, q.Lat, q.Lon, q.Lat, q.RadiusMeters).Rows()
+	if q.ProjectIDs != nil && len(q.ProjectIDs) == 0 {
+		return []NearbyProject{}, nil
+	}
+
+	args := []any{q.Lon, q.Lat, q.Lon, q.Lat, q.RadiusMeters}
+	if q.ProjectIDs != nil {
+		args = append(args, q.ProjectIDs)
+	}
+	sqlStmt := queries.NearbyProjectsSQL(q.Limit, q.ProjectIDs != nil)
+	rows, err := r.db.WithContext(ctx).Raw(sqlStmt, args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -264,17 +282,27 @@ func (r *repository) FindWithin(ctx context.Context, q WithinQuery) ([]NearbyPro
 		q.Limit = 100
 	}
 
+	if q.ProjectIDs != nil && len(q.ProjectIDs) == 0 {
+		return []NearbyProject{}, nil
+	}
+
 	var (
 		rows *sql.Rows
 		err  error
 	)
+	scoped := q.ProjectIDs != nil
 	if q.GeoJSON != "" {
-		rows, err = r.db.WithContext(ctx).Raw(queries.WithinPolygonSQL(q.Limit), q.GeoJSON).Rows()
+		args := []any{q.GeoJSON}
+		if scoped {
+			args = append(args, q.ProjectIDs)
+		}
+		rows, err = r.db.WithContext(ctx).Raw(queries.WithinPolygonSQL(q.Limit, scoped), args...).Rows()
 	} else {
-		rows, err = r.db.WithContext(ctx).Raw(
-			queries.WithinBBoxSQL(q.Limit),
-			*q.MinLon, *q.MinLat, *q.MaxLon, *q.MaxLat,
-		).Rows()
+		args := []any{*q.MinLon, *q.MinLat, *q.MaxLon, *q.MaxLat}
+		if scoped {
+			args = append(args, q.ProjectIDs)
+		}
+		rows, err = r.db.WithContext(ctx).Raw(queries.WithinBBoxSQL(q.Limit, scoped), args...).Rows()
 	}
 	if err != nil {
 		return nil, err
@@ -294,8 +322,16 @@ func (r *repository) FindWithin(ctx context.Context, q WithinQuery) ([]NearbyPro
 	return out, nil
 }
 
-func (r *repository) Intersect(ctx context.Context, geometry json.RawMessage) ([]IntersectResult, error) {
-	rows, err := r.db.WithContext(ctx).Raw(queries.IntersectionSQL, string(geometry), string(geometry), string(geometry)).Rows()
+func (r *repository) Intersect(ctx context.Context, geometry json.RawMessage, projectIDs []string) ([]IntersectResult, error) {
+	if projectIDs != nil && len(projectIDs) == 0 {
+		return []IntersectResult{}, nil
+	}
+
+	args := []any{string(geometry), string(geometry), string(geometry)}
+	if projectIDs != nil {
+		args = append(args, projectIDs)
+	}
+	rows, err := r.db.WithContext(ctx).Raw(queries.IntersectionSQL(projectIDs != nil), args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -13,11 +13,13 @@ import (
 	pkggeojson "carbon-scribe/project-portal/project-portal-backend/pkg/geojson"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Service interface {
 	UploadProjectGeometry(ctx context.Context, projectID uuid.UUID, req UploadGeometryRequest) (*ProjectGeometry, error)
 	GetProjectGeometry(ctx context.Context, projectID uuid.UUID) (*ProjectGeometry, error)
+	HasBoundary(ctx context.Context, projectID uuid.UUID) (bool, error)
 	GetProjectBoundary(ctx context.Context, projectID uuid.UUID, format string) (*BoundaryResponse, error)
 	FindNearby(ctx context.Context, q NearbyQuery) ([]NearbyProject, error)
 	FindWithin(ctx context.Context, q WithinQuery) ([]NearbyProject, error)
@@ -27,6 +29,10 @@ type Service interface {
 	CreateGeofence(ctx context.Context, req CreateGeofenceRequest) (*Geofence, error)
 	CheckProjectGeofences(ctx context.Context, projectID uuid.UUID) ([]GeofenceCheckResult, error)
 	GetAdministrativeBoundaries(ctx context.Context, level int, countryCode string) ([]AdministrativeBoundary, error)
+
+	ProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error)
+	ImportProjectBoundary(ctx context.Context, tx *gorm.DB, projectID uuid.UUID, geojson json.RawMessage, sourceFile string) error
+	PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error)
 }
 
 type service struct {
@@ -56,6 +62,11 @@ func (s *service) GetProjectGeometry(ctx context.Context, projectID uuid.UUID) (
 	return s.repo.GetProjectGeometry(ctx, projectID)
 }
 
+// HasBoundary reports whether a boundary geometry has been uploaded for the project.
+func (s *service) HasBoundary(ctx context.Context, projectID uuid.UUID) (bool, error) {
+	return s.repo.HasProjectGeometry(ctx, projectID)
+}
+
 func (s *service) GetProjectBoundary(ctx context.Context, projectID uuid.UUID, format string) (*BoundaryResponse, error) {
 	format = strings.ToLower(strings.TrimSpace(format))
 	if format == "" {
@@ -96,7 +107,7 @@ func (s *service) Intersect(ctx context.Context, req IntersectRequest) ([]Inters
 	if err := geometry.ValidateGeoJSON(geometry.ExtractGeometry(req.GeoJSON)); err != nil {
 		return nil, err
 	}
-	return s.repo.Intersect(ctx, geometry.ExtractGeometry(req.GeoJSON))
+	return s.repo.Intersect(ctx, geometry.ExtractGeometry(req.GeoJSON), req.ProjectIDs)
 }
 
 func (s *service) BuildStaticMapURL(ctx context.Context, req StaticMapRequest) (string, error) {
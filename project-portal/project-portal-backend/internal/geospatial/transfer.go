@@ -0,0 +1,50 @@
+package geospatial
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProjectBoundaries returns the GeoJSON geometry of each listed project that
+// has a boundary. It implements project.BoundaryStore for bulk export.
+func (s *service) ProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error) {
+	return s.repo.ListProjectBoundaries(ctx, projectIDs)
+}
+
+// ImportProjectBoundary stores a boundary validated by the bulk importer
+// inside the caller's transaction. It implements project.BoundaryStore.
+func (s *service) ImportProjectBoundary(ctx context.Context, tx *gorm.DB, projectID uuid.UUID, geojson json.RawMessage, sourceFile string) error {
+	if _, err := NewRepository(tx).UpsertProjectGeometry(ctx, projectID, UploadGeometryRequest{
+		GeoJSON:    geojson,
+		SourceType: "import",
+		SourceFile: sourceFile,
+	}); err != nil {
+		return fmt.Errorf("import boundary: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error) {
+	out := make(map[uuid.UUID]json.RawMessage, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ProjectID uuid.UUID
+		Geometry  string
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+SELECT project_id, ST_AsGeoJSON(geometry::geometry) AS geometry
+FROM project_geometries WHERE project_id IN ?
+`, projectIDs).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list project boundaries: %w", err)
+	}
+	for _, row := range rows {
+		out[row.ProjectID] = json.RawMessage(row.Geometry)
+	}
+	return out, nil
+}
@@ -1,11 +1,18 @@
 package integration
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
+// maxWebhookBody caps the size of an incoming webhook payload.
+const maxWebhookBody = 1 << 20
+
 type Handler struct {
 	service *Service
 }
@@ -46,9 +53,18 @@ func (h *Handler) ConfigureWebhook(c *gin.Context) {
 	c.JSON(http.StatusCreated, webhook)
 }
 
-// IncomingWebhook
+// IncomingWebhook accepts events from external systems. The route is public,
+// so the body must carry a valid signature.
 func (h *Handler) IncomingWebhook(c *gin.Context) {
-	// Verify signature logic would go here
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody+1))
+	if err != nil || len(body) > maxWebhookBody {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable or oversized body"})
+		return
+	}
+	if err := h.service.VerifyIncomingWebhook(body, c.GetHeader(WebhookSignatureHeader)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "received"})
 }
 
@@ -76,8 +92,17 @@ func (h *Handler) GetHealth(c *gin.Context) {
 
 // OAuth2 Authorize
 func (h *Handler) OAuth2Authorize(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
 	provider := c.Param("provider")
-	url, err := h.service.InitiateOAuth2(c.Request.Context(), provider)
+	url, err := h.service.InitiateOAuth2(c.Request.Context(), provider, userID)
+	if errors.Is(err, ErrNotConfigured) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,8 +114,9 @@ func (h *Handler) OAuth2Authorize(c *gin.Context) {
 func (h *Handler) OAuth2Callback(c *gin.Context) {
 	provider := c.Param("provider")
 	code := c.Query("code")
+	state := c.Query("state")
 
-	if err := h.service.HandleOAuth2Callback(c.Request.Context(), provider, code); err != nil {
+	if err := h.service.HandleOAuth2Callback(c.Request.Context(), provider, code, state); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
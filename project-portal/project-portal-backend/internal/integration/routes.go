@@ -2,15 +2,24 @@ package integration
 
 import "github.com/gin-gonic/gin"
 
-func RegisterRoutes(r *gin.Engine, h *Handler) {
-	v1 := r.Group("/api/v1/integrations")
+// RegisterRoutes mounts the integration API. Management routes require a
+// valid access token. Incoming webhooks and OAuth2 callbacks come from
+// external systems and are public; they are checked by body signature and
+// OAuth2 state instead.
+func RegisterRoutes(r *gin.Engine, h *Handler, authRequired gin.HandlerFunc) {
+	public := r.Group("/api/v1/integrations")
+	{
+		public.POST("/webhooks/incoming", h.IncomingWebhook)
+		public.POST("/oauth2/callback/:provider", h.OAuth2Callback)
+	}
+
+	v1 := r.Group("/api/v1/integrations", authRequired)
 	{
 		// Connection Management
 		v1.POST("/connections", h.RegisterConnection)
 
 		// Webhooks
 		v1.POST("/webhooks", h.ConfigureWebhook)
-		v1.POST("/webhooks/incoming", h.IncomingWebhook)
 
 		// Subscriptions
 		v1.POST("/subscriptions", h.SubscribeToEvent)
@@ -20,6 +29,5 @@ func RegisterRoutes(r *gin.Engine, h *Handler) {
 
 		// OAuth2
 		v1.GET("/oauth2/authorize/:provider", h.OAuth2Authorize)
-		v1.POST("/oauth2/callback/:provider", h.OAuth2Callback)
 	}
 }
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/auth"
+	"carbon-scribe/project-portal/project-portal-backend/internal/config"
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	tokens, err := auth.NewTokenService(config.AuthConfig{
+		JWTAlgorithm:   auth.AlgHS256,
+		JWTKeyID:       "test",
+		JWTSecret:      strings.Repeat("s", 32),
+		AccessTokenTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewTokenService error: %v", err)
+	}
+	svc := NewService(nil)
+	svc.SetSecrets([]byte("webhook-secret"), []byte("state-key"))
+	r := gin.New()
+	RegisterRoutes(r, NewHandler(svc), middleware.AuthRequired(tokens))
+	return r
+}
+
+func TestManagementRoutesRequireToken(t *testing.T) {
+	r := newTestRouter(t)
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/integrations/connections"},
+		{http.MethodPost, "/api/v1/integrations/webhooks"},
+		{http.MethodPost, "/api/v1/integrations/subscriptions"},
+		{http.MethodGet, "/api/v1/integrations/health"},
+		{http.MethodGet, "/api/v1/integrations/oauth2/authorize/stripe"},
+	}
+	for _, rt := range routes {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(rt.method, rt.path, strings.NewReader("{}")))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without token: got %d, want 401", rt.method, rt.path, w.Code)
+		}
+	}
+}
+
+func TestIncomingWebhookRequiresSignature(t *testing.T) {
+	r := newTestRouter(t)
+	body := `{"event":"ping"}`
+
+	cases := []struct {
+		signature string
+		want      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"sha256=" + signWebhook([]byte("other"), []byte(body)), http.StatusUnauthorized},
+		{"sha256=" + signWebhook([]byte("webhook-secret"), []byte(body)), http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/integrations/webhooks/incoming", strings.NewReader(body))
+		if tc.signature != "" {
+			req.Header.Set(WebhookSignatureHeader, tc.signature)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("signature %q: got %d, want %d", tc.signature, w.Code, tc.want)
+		}
+	}
+}
+
+func TestOAuth2CallbackRequiresState(t *testing.T) {
+	r := newTestRouter(t)
+	now := time.Now()
+	valid := newOAuthState([]byte("state-key"), "u-1", "stripe", now)
+
+	cases := []struct {
+		name, state string
+		want        int
+	}{
+		{"missing", "", http.StatusBadRequest},
+		{"forged", newOAuthState([]byte("other"), "u-1", "stripe", now), http.StatusBadRequest},
+		{"other provider", newOAuthState([]byte("state-key"), "u-1", "github", now), http.StatusBadRequest},
+		{"expired", newOAuthState([]byte("state-key"), "u-1", "stripe", now.Add(-time.Hour)), http.StatusBadRequest},
+		{"valid", valid, http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost,
+			"/api/v1/integrations/oauth2/callback/stripe?code=abc&state="+tc.state, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s state: got %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
@@ -3,19 +3,35 @@ package integration
 import (
 	"context"
 	"errors"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo Repository
+	repo          Repository
+	webhookSecret []byte
+	stateKey      []byte
 }
 
 func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetSecrets sets the shared secret that incoming webhooks are signed with
+// and the key that signs OAuth2 state. Without them incoming webhooks and
+// OAuth2 callbacks are rejected.
+func (s *Service) SetSecrets(webhookSecret, stateKey []byte) {
+	s.webhookSecret = webhookSecret
+	s.stateKey = stateKey
+}
+
+// VerifyIncomingWebhook checks the signature an external system sent with body.
+func (s *Service) VerifyIncomingWebhook(body []byte, signature string) error {
+	return verifyWebhook(s.webhookSecret, body, signature)
+}
+
 // RegisterConnection creates a new integration connection
 func (s *Service) RegisterConnection(ctx context.Context, conn *IntegrationConnection) error {
 	conn.CreatedAt = time.Now()
@@ -100,12 +116,23 @@ func (s *Service) TriggerWebhook(ctx context.Context, eventType string, payload
 
 // OAuth2 Flow Placeholders
 
-func (s *Service) InitiateOAuth2(ctx context.Context, provider string) (string, error) {
+// InitiateOAuth2 returns the provider's authorization URL with a state
+// bound to userID, which the callback must return.
+func (s *Service) InitiateOAuth2(ctx context.Context, provider, userID string) (string, error) {
+	if len(s.stateKey) == 0 {
+		return "", ErrNotConfigured
+	}
+	state := newOAuthState(s.stateKey, userID, provider, time.Now())
 	// Return authorization URL
-	return "https://" + provider + ".com/oauth/authorize?client_id=...", nil
+	return "https://" + provider + ".com/oauth/authorize?client_id=...&state=" + url.QueryEscape(state), nil
 }
 
-func (s *Service) HandleOAuth2Callback(ctx context.Context, provider, code string) error {
+// HandleOAuth2Callback checks the state issued by InitiateOAuth2 before
+// accepting the code.
+func (s *Service) HandleOAuth2Callback(ctx context.Context, provider, code, state string) error {
+	if _, err := verifyOAuthState(s.stateKey, state, provider, time.Now()); err != nil {
+		return err
+	}
 	// Exchange code for token and save
 	if code == "" {
 		return errors.New("invalid code")
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of an incoming webhook
+// body, optionally prefixed with "sha256=".
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// oauthStateTTL bounds how long an authorization may take to come back.
+const oauthStateTTL = 10 * time.Minute
+
+var (
+	ErrNotConfigured    = errors.New("integration secret not configured")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidState     = errors.New("invalid or expired oauth state")
+)
+
+// signWebhook returns the hex HMAC-SHA256 of body under secret.
+func signWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhook checks signature against body.
+func verifyWebhook(secret, body []byte, signature string) error {
+	if len(secret) == 0 {
+		return ErrNotConfigured
+	}
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(signWebhook(secret, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// newOAuthState binds an authorization to the user who started it and the
+// provider it was sent to. The state is "<payload>.<mac>" where payload is
+// the base64url of "userID|provider|expires".
+func newOAuthState(key []byte, userID, provider string, now time.Time) string {
+	raw := userID + "|" + provider + "|" + strconv.FormatInt(now.Add(oauthStateTTL).Unix(), 10)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(raw))
+	return payload + "." + stateMAC(key, payload)
+}
+
+// verifyOAuthState returns the user who started the authorization.
+func verifyOAuthState(key []byte, state, provider string, now time.Time) (string, error) {
+	if len(key) == 0 {
+		return "", ErrNotConfigured
+	}
+	payload, mac, ok := strings.Cut(state, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(stateMAC(key, payload))) {
+		return "", ErrInvalidState
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidState
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] == "" || parts[1] != provider {
+		return "", ErrInvalidState
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", ErrInvalidState
+	}
+	return parts[0], nil
+}
+
+func stateMAC(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("oauth-state\x00"))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
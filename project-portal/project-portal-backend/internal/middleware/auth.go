@@ -1,34 +1,14 @@
 package middleware
 
 import (
-	"net/http"
-	"strings"
-
-	"carbon-scribe/project-portal/project-portal-backend/pkg/utils"
+	"carbon-scribe/project-portal/project-portal-backend/internal/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRequired() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing auth header"})
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid auth header"})
-			return
-		}
-
-		token, err := utils.ParseToken(parts[1])
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			return
-		}
-
-		c.Next()
-	}
+// AuthRequired rejects requests without a valid bearer token. Tokens are
+// verified by the shared auth.TokenService so every route group accepts the
+// same credentials.
+func AuthRequired(tokens *auth.TokenService) gin.HandlerFunc {
+	return auth.AuthMiddleware(tokens)
 }
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Context keys populated by AuthRequired from the verified token claims.
+const (
+	ContextUserID = "user_id"
+	ContextEmail  = "email"
+	ContextRole   = "role"
+
+	// ContextProjectAccess holds the *ProjectAccess resolved by
+	// RequireProjectPermission for the current request.
+	ContextProjectAccess = "project_access"
+)
+
+// Platform roles carried in the token's role claim.
+const (
+	PlatformRoleUser              = "user"
+	PlatformRoleAdmin             = "admin"
+	PlatformRoleComplianceOfficer = "compliance_officer"
+)
+
+// CurrentUserID returns the authenticated caller's ID.
+func CurrentUserID(c *gin.Context) (string, bool) {
+	id := c.GetString(ContextUserID)
+	return id, id != ""
+}
+
+// CurrentEmail returns the authenticated caller's email from the token.
+func CurrentEmail(c *gin.Context) string {
+	return c.GetString(ContextEmail)
+}
+
+// CurrentUserUUID returns the authenticated caller's ID as a UUID, or nil when
+// the request is unauthenticated or the ID is malformed.
+func CurrentUserUUID(c *gin.Context) *uuid.UUID {
+	id, err := uuid.Parse(c.GetString(ContextUserID))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// CurrentRole returns the caller's platform role from the token.
+func CurrentRole(c *gin.Context) string {
+	return c.GetString(ContextRole)
+}
+
+// IsPlatformAdmin reports whether the caller holds the platform admin role.
+// Admins bypass project membership checks.
+func IsPlatformAdmin(c *gin.Context) bool {
+	return CurrentRole(c) == PlatformRoleAdmin
+}
+
+// CurrentProjectAccess returns the access resolved by RequireProjectPermission.
+func CurrentProjectAccess(c *gin.Context) (*ProjectAccess, bool) {
+	v, ok := c.Get(ContextProjectAccess)
+	if !ok {
+		return nil, false
+	}
+	access, ok := v.(*ProjectAccess)
+	return access, ok
+}
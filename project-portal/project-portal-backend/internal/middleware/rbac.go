@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Project roles, ordered from most to least privileged.
+const (
+	RoleOwner       = "Owner"
+	RoleManager     = "Manager"
+	RoleContributor = "Contributor"
+	RoleViewer      = "Viewer"
+)
+
+// Project-scoped permissions checked by RequireProjectPermission.
+const (
+	PermProjectRead   = "project:read"
+	PermProjectWrite  = "project:write"
+	PermProjectDelete = "project:delete"
+
+	PermMembersRead   = "members:read"
+	PermMembersInvite = "members:invite"
+	PermMembersManage = "members:manage"
+
+	PermDocumentsRead    = "documents:read"
+	PermDocumentsWrite   = "documents:write"
+	PermDocumentsReview  = "documents:review"
+	PermDocumentsApprove = "documents:approve"
+	PermDocumentsDelete  = "documents:delete"
+
+	PermCollaborate = "collaboration:write"
+)
+
+// ErrNotMember is returned by an Authorizer when the user has no membership
+// in the project.
+var ErrNotMember = errors.New("user is not a member of this project")
+
+var viewerPermissions = []string{
+	PermProjectRead,
+	PermMembersRead,
+	PermDocumentsRead,
+}
+
+var contributorPermissions = append(append([]string{}, viewerPermissions...),
+	PermDocumentsWrite,
+	PermCollaborate,
+)
+
+var managerPermissions = append(append([]string{}, contributorPermissions...),
+	PermProjectWrite,
+	PermMembersInvite,
+	PermMembersManage,
+	PermDocumentsReview,
+	PermDocumentsApprove,
+	PermDocumentsDelete,
+)
+
+var ownerPermissions = append(append([]string{}, managerPermissions...),
+	PermProjectDelete,
+)
+
+// RolePermissions lists the permissions each project role grants by default.
+var RolePermissions = map[string][]string{
+	RoleOwner:       ownerPermissions,
+	RoleManager:     managerPermissions,
+	RoleContributor: contributorPermissions,
+	RoleViewer:      viewerPermissions,
+}
+
+// roleRank orders roles so callers can compare privilege levels.
+var roleRank = map[string]int{
+	RoleViewer:      1,
+	RoleContributor: 2,
+	RoleManager:     3,
+	RoleOwner:       4,
+}
+
+// RoleRank returns the privilege level of a project role; unknown roles rank 0.
+func RoleRank(role string) int {
+	return roleRank[role]
+}
+
+// IsValidRole reports whether role is a known project role.
+func IsValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// IsValidPermission reports whether perm is a known project permission.
+func IsValidPermission(perm string) bool {
+	for _, p := range ownerPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectAccess is a user's effective access to a single project.
+type ProjectAccess struct {
+	ProjectID   string   `json:"project_id"`
+	UserID      string   `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// NewProjectAccess combines the role's default permissions with any extra
+// grants stored on the membership.
+func NewProjectAccess(projectID, userID, role string, extra []string) *ProjectAccess {
+	perms := append(append([]string{}, RolePermissions[role]...), extra...)
+	return &ProjectAccess{ProjectID: projectID, UserID: userID, Role: role, Permissions: perms}
+}
+
+// Can reports whether the access grants perm.
+func (a *ProjectAccess) Can(perm string) bool {
+	if a == nil {
+		return false
+	}
+	for _, p := range a.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer resolves a user's access to a project. It returns ErrNotMember
+// when the user has no membership.
+type Authorizer interface {
+	ProjectAccess(ctx context.Context, projectID, userID string) (*ProjectAccess, error)
+}
+
+// ProjectResolver extracts the project ID a request targets.
+type ProjectResolver func(c *gin.Context) (string, error)
+
+// ProjectFromParam resolves the project from a path parameter.
+func ProjectFromParam(name string) ProjectResolver {
+	return func(c *gin.Context) (string, error) {
+		return c.Param(name), nil
+	}
+}
+
+// ProjectFromQuery resolves the project from a query parameter.
+func ProjectFromQuery(name string) ProjectResolver {
+	return func(c *gin.Context) (string, error) {
+		return c.Query(name), nil
+	}
+}
+
+// RequireProjectPermission rejects the request unless the authenticated user
+// holds perm on the project returned by resolve. Platform admins are allowed
+// through with Owner-level access. The resolved access is stored in the
+// context for handlers to inspect.
+func RequireProjectPermission(authz Authorizer, perm string, resolve ProjectResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, err := resolve(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if projectID == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "project_id is required"})
+			return
+		}
+
+		access, status, err := Authorize(c, authz, projectID, perm)
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(ContextProjectAccess, access)
+		c.Next()
+	}
+}
+
+// Authorize checks perm for the current user on projectID. It returns the
+// resolved access, or an HTTP status and error suitable for the response.
+// Handlers use it directly when the project is only known after binding the
+// request body.
+func Authorize(c *gin.Context, authz Authorizer, projectID, perm string) (*ProjectAccess, int, error) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		return nil, http.StatusUnauthorized, errors.New("authentication required")
+	}
+	if IsPlatformAdmin(c) {
+		return NewProjectAccess(projectID, userID, RoleOwner, nil), http.StatusOK, nil
+	}
+
+	access, err := authz.ProjectAccess(c.Request.Context(), projectID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			return nil, http.StatusForbidden, ErrNotMember
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if !access.Can(perm) {
+		return nil, http.StatusForbidden, errors.New("missing permission: " + perm)
+	}
+	return access, http.StatusOK, nil
+}
+
+// RequirePlatformRole rejects the request unless the token carries one of the
+// given platform roles. Admins always pass.
+func RequirePlatformRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := CurrentRole(c)
+		if role == PlatformRoleAdmin {
+			c.Next()
+			return
+		}
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
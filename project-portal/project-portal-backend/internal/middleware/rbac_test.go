@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAuthorizer map[string]string // userID -> role
+
+func (f fakeAuthorizer) ProjectAccess(_ context.Context, projectID, userID string) (*ProjectAccess, error) {
+	role, ok := f[userID]
+	if !ok {
+		return nil, ErrNotMember
+	}
+	return NewProjectAccess(projectID, userID, role, nil), nil
+}
+
+func TestRolePermissionsAreCumulative(t *testing.T) {
+	viewer := NewProjectAccess("p", "u", RoleViewer, nil)
+	if !viewer.Can(PermDocumentsRead) || viewer.Can(PermDocumentsWrite) {
+		t.Fatalf("viewer should be read-only: %v", viewer.Permissions)
+	}
+	manager := NewProjectAccess("p", "u", RoleManager, nil)
+	if !manager.Can(PermDocumentsApprove) || manager.Can(PermProjectDelete) {
+		t.Fatalf("unexpected manager permissions: %v", manager.Permissions)
+	}
+	if !NewProjectAccess("p", "u", RoleOwner, nil).Can(PermProjectDelete) {
+		t.Fatal("owner should be able to delete the project")
+	}
+	extra := NewProjectAccess("p", "u", RoleViewer, []string{PermDocumentsReview})
+	if !extra.Can(PermDocumentsReview) {
+		t.Fatal("extra membership permissions should be granted")
+	}
+}
+
+func TestRequireProjectPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authz := fakeAuthorizer{"viewer": RoleViewer, "manager": RoleManager}
+
+	cases := []struct {
+		user, role string
+		want       int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"stranger", PlatformRoleUser, http.StatusForbidden},
+		{"viewer", PlatformRoleUser, http.StatusForbidden},
+		{"manager", PlatformRoleUser, http.StatusOK},
+		{"stranger", PlatformRoleAdmin, http.StatusOK},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if tc.user != "" {
+				c.Set(ContextUserID, tc.user)
+				c.Set(ContextRole, tc.role)
+			}
+		})
+		r.PUT("/projects/:id", RequireProjectPermission(authz, PermProjectWrite, ProjectFromParam("id")), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/projects/p-1", nil))
+		if w.Code != tc.want {
+			t.Errorf("user %q (%s): got %d, want %d", tc.user, tc.role, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrProjectArchived is returned when changing an archived project.
+	// Restore it first.
+	ErrProjectArchived = errors.New("project is archived")
+	// ErrNotArchived is returned when restoring or purging a project that
+	// has not been archived.
+	ErrNotArchived = errors.New("project is not archived")
+	// ErrLegalHold blocks purging a project covered by an active legal hold.
+	ErrLegalHold = errors.New("project is under legal hold")
+)
+
+// DataPurger permanently deletes one module's rows for a project inside the
+// purge transaction. Work outside the database, such as removing stored
+// files, belongs in the returned func, which runs only after the transaction
+// commits; it may be nil.
+type DataPurger interface {
+	PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error)
+}
+
+// PurgeCompliance checks legal holds before a purge and records the purge
+// in the audit log. It is implemented by the compliance service.
+type PurgeCompliance interface {
+	IsProjectUnderLegalHold(ctx context.Context, projectID string) (bool, error)
+	RecordProjectPurge(ctx context.Context, projectID, userID string, project map[string]any) error
+}
+
+// ArchiveProject hides the project from lists without removing any data.
+// Archived projects are read-only until restored.
+func (s *service) ArchiveProject(ctx context.Context, id uuid.UUID, userID string) (*Project, error) {
+	if err := s.repo.Archive(ctx, id, userID, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	s.recordActivity(ctx, id, userID, "project_archived", nil)
+	return s.repo.GetByID(ctx, id)
+}
+
+// RestoreProject makes an archived project visible and editable again.
+func (s *service) RestoreProject(ctx context.Context, id uuid.UUID, userID string) (*Project, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	s.recordActivity(ctx, id, userID, "project_restored", nil)
+	return s.repo.GetByID(ctx, id)
+}
+
+// PurgeProject permanently deletes an archived project and every module's
+// data for it in one transaction. It refuses while a legal hold covers the
+// project, and fails closed when holds cannot be checked.
+func (s *service) PurgeProject(ctx context.Context, id uuid.UUID, userID string) error {
+	project, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if project.ArchivedAt == nil {
+		return fmt.Errorf("%w: archive the project before purging it", ErrNotArchived)
+	}
+	if s.compliance == nil {
+		return errors.New("purge unavailable: legal holds cannot be checked")
+	}
+	held, err := s.compliance.IsProjectUnderLegalHold(ctx, id.String())
+	if err != nil {
+		return fmt.Errorf("checking legal holds: %w", err)
+	}
+	if held {
+		return ErrLegalHold
+	}
+
+	cleanups, err := s.repo.Purge(ctx, id, s.purgers)
+	if err != nil {
+		return fmt.Errorf("purging project: %w", err)
+	}
+	for _, cleanup := range cleanups {
+		cleanup(ctx)
+	}
+
+	// The project's own activity feed is gone; the audit log keeps the record.
+	return s.compliance.RecordProjectPurge(ctx, id.String(), userID, map[string]any{
+		"name":        project.Name,
+		"type":        project.Type,
+		"location":    project.Location,
+		"status":      project.Status,
+		"archived_at": project.ArchivedAt,
+		"archived_by": project.ArchivedBy,
+	})
+}
+
+// ensureActive rejects changes to archived projects.
+func ensureActive(p *Project) error {
+	if p.ArchivedAt != nil {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+func (s *service) recordActivity(ctx context.Context, id uuid.UUID, userID, action string, metadata map[string]any) {
+	if s.activity != nil {
+		_ = s.activity.RecordActivity(ctx, id.String(), userID, action, metadata)
+	}
+}
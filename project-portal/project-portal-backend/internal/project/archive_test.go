@@ -0,0 +1,108 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type stubCompliance struct {
+	held   bool
+	purged []string
+}
+
+func (c *stubCompliance) IsProjectUnderLegalHold(context.Context, string) (bool, error) {
+	return c.held, nil
+}
+func (c *stubCompliance) RecordProjectPurge(_ context.Context, projectID, _ string, _ map[string]any) error {
+	c.purged = append(c.purged, projectID)
+	return nil
+}
+
+type stubPurger struct{ purged, cleaned int }
+
+func (p *stubPurger) PurgeProjectData(context.Context, *gorm.DB, uuid.UUID) (func(context.Context), error) {
+	p.purged++
+	return func(context.Context) { p.cleaned++ }, nil
+}
+
+func TestArchivedProjectIsReadOnlyUntilRestored(t *testing.T) {
+	svc, _, checks, id := newLifecycleFixture(StatusDraft)
+	ctx := context.Background()
+
+	if _, err := svc.ArchiveProject(ctx, id, "u-1"); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if _, err := svc.ArchiveProject(ctx, id, "u-1"); !errors.Is(err, ErrProjectArchived) {
+		t.Fatalf("second archive should conflict, got %v", err)
+	}
+	if _, err := svc.TransitionProject(ctx, id, &TransitionRequest{To: StatusOnboarding}, "u-1"); !errors.Is(err, ErrProjectArchived) {
+		t.Fatalf("archived project should reject transitions, got %v", err)
+	}
+
+	p, err := svc.RestoreProject(ctx, id, "u-1")
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if p.ArchivedAt != nil {
+		t.Fatal("restored project still archived")
+	}
+	if _, err := svc.TransitionProject(ctx, id, &TransitionRequest{To: StatusOnboarding}, "u-1"); err != nil {
+		t.Fatalf("restored project should accept transitions: %v", err)
+	}
+	if len(checks.events) < 2 || checks.events[0] != "project_archived" || checks.events[1] != "project_restored" {
+		t.Fatalf("unexpected activity events: %v", checks.events)
+	}
+}
+
+func TestPurgeRequiresArchiveAndRespectsLegalHold(t *testing.T) {
+	svc, repo, _, id := newLifecycleFixture(StatusDraft)
+	comp := &stubCompliance{held: true}
+	purger := &stubPurger{}
+	svc.compliance, svc.purgers = comp, []DataPurger{purger}
+	ctx := context.Background()
+
+	if err := svc.PurgeProject(ctx, id, "admin"); !errors.Is(err, ErrNotArchived) {
+		t.Fatalf("purging an active project should fail, got %v", err)
+	}
+	if _, err := svc.ArchiveProject(ctx, id, "u-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.PurgeProject(ctx, id, "admin"); !errors.Is(err, ErrLegalHold) {
+		t.Fatalf("legal hold should block purge, got %v", err)
+	}
+	if purger.purged != 0 {
+		t.Fatal("no module should be purged while a hold is active")
+	}
+
+	comp.held = false
+	if err := svc.PurgeProject(ctx, id, "admin"); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if _, ok := repo.projects[id]; ok {
+		t.Fatal("project row was not removed")
+	}
+	if purger.purged != 1 || purger.cleaned != 1 || len(comp.purged) != 1 {
+		t.Fatalf("purge did not walk modules and audit: purger=%+v audit=%v", purger, comp.purged)
+	}
+}
+
+func TestUpdateAfterArchiveKeepsProjectArchived(t *testing.T) {
+	svc, repo, _, id := newLifecycleFixture(StatusDraft)
+	ctx := context.Background()
+
+	if _, err := svc.ArchiveProject(ctx, id, "u-1"); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	name := "Renamed"
+	if _, err := svc.UpdateProject(ctx, id, &ProjectUpdateRequest{Name: &name}); !errors.Is(err, ErrProjectArchived) {
+		t.Fatalf("updating an archived project should fail, got %v", err)
+	}
+	p := repo.projects[id]
+	if p.ArchivedAt == nil || p.ArchivedBy != "u-1" || p.Name == name {
+		t.Fatalf("project should be unchanged and still archived: %+v", p)
+	}
+}
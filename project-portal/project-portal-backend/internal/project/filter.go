@@ -0,0 +1,293 @@
+package project
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFilter is returned for malformed list filters, sort keys or
+// cursors so handlers can answer with 400.
+var ErrInvalidFilter = errors.New("invalid project filter")
+
+// Sort orders.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// sortColumns maps the sort keys accepted by the API to project columns.
+var sortColumns = map[string]string{
+	"name":           "name",
+	"type":           "type",
+	"location":       "location",
+	"area":           "area",
+	"start_date":     "start_date",
+	"farmers":        "farmers",
+	"carbon_credits": "carbon_credits",
+	"progress":       "progress",
+	"status":         "status",
+	"methodology":    "methodology_code",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+}
+
+// timeColumns are decoded back into time.Time when read from a cursor.
+var timeColumns = map[string]bool{"start_date": true, "created_at": true, "updated_at": true}
+
+// Filter selects, orders and pages projects. It is bound from query
+// parameters by the project list endpoint and shared with reports (via
+// Where) and search (which translates it into Elasticsearch clauses), so all
+// three agree on what a filtered project set is.
+type Filter struct {
+	Statuses   []string   `form:"status" json:"status,omitempty"`
+	Types      []string   `form:"type" json:"type,omitempty"`
+	Location   string     `form:"location" json:"location,omitempty"` // case-insensitive substring
+	OwnerID    string     `form:"owner_id" json:"owner_id,omitempty"`
+	MemberID   string     `form:"member_id" json:"member_id,omitempty"`
+	AreaMin    *float64   `form:"area_min" json:"area_min,omitempty"`
+	AreaMax    *float64   `form:"area_max" json:"area_max,omitempty"`
+	StartFrom  *time.Time `form:"start_from" time_format:"2006-01-02" json:"start_from,omitempty"`
+	StartTo    *time.Time `form:"start_to" time_format:"2006-01-02" json:"start_to,omitempty"`
+	CreditsMin *int       `form:"credits_min" json:"credits_min,omitempty"`
+	CreditsMax *int       `form:"credits_max" json:"credits_max,omitempty"`
+	Query      string     `form:"q" json:"q,omitempty"`               // full-text over name, type and location
+	Archived   bool       `form:"archived" json:"archived,omitempty"` // list archived projects instead of active ones
+
+	SortBy    string `form:"sort_by" json:"sort_by,omitempty"`
+	SortOrder string `form:"sort_order" json:"sort_order,omitempty"`
+	Limit     int    `form:"limit" json:"limit,omitempty"`
+	Cursor    string `form:"cursor" json:"cursor,omitempty"`
+
+	// ProjectIDs restricts results to these projects. It is set by the
+	// service to scope non-admin callers to their memberships and is never
+	// bound from the request. A non-nil empty slice matches nothing.
+	ProjectIDs []string `form:"-" json:"-"`
+}
+
+// ListResult is one page of projects.
+type ListResult struct {
+	Projects   []Project `json:"projects"`
+	Total      int64     `json:"total"`                 // matches across all pages
+	NextCursor string    `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// cursor is the keyset position after the last row of a page. It carries
+// the sort key so a cursor cannot be replayed against a different order.
+type cursor struct {
+	SortBy string          `json:"s"`
+	Order  string          `json:"o"`
+	Value  json.RawMessage `json:"v"`
+	ID     string          `json:"id"`
+}
+
+// Normalize applies defaults and validates the filter. It must be called
+// before Where or the repository list query.
+func (f *Filter) Normalize() error {
+	if f.SortBy == "" {
+		f.SortBy = "created_at"
+	}
+	if _, ok := sortColumns[f.SortBy]; !ok {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, f.SortBy)
+	}
+	switch strings.ToLower(f.SortOrder) {
+	case "":
+		f.SortOrder = SortDesc
+	case SortAsc, SortDesc:
+		f.SortOrder = strings.ToLower(f.SortOrder)
+	default:
+		return fmt.Errorf("%w: sort_order must be asc or desc", ErrInvalidFilter)
+	}
+	f.Limit = clampLimit(f.Limit)
+	f.Statuses = splitValues(f.Statuses)
+	f.Types = splitValues(f.Types)
+
+	for _, s := range f.Statuses {
+		if !IsValidStatus(s) {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, s)
+		}
+	}
+	if f.AreaMin != nil && f.AreaMax != nil && *f.AreaMin > *f.AreaMax {
+		return fmt.Errorf("%w: area_min is greater than area_max", ErrInvalidFilter)
+	}
+	if f.StartFrom != nil && f.StartTo != nil && f.StartFrom.After(*f.StartTo) {
+		return fmt.Errorf("%w: start_from is after start_to", ErrInvalidFilter)
+	}
+	if f.CreditsMin != nil && f.CreditsMax != nil && *f.CreditsMin > *f.CreditsMax {
+		return fmt.Errorf("%w: credits_min is greater than credits_max", ErrInvalidFilter)
+	}
+	if f.Cursor != "" {
+		if _, err := f.decodeCursor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Where applies the selection criteria, but not ordering or paging, to a
+// query over the projects table. Reports use it to aggregate over the same
+// project set the list endpoint returns.
+func (f *Filter) Where(db *gorm.DB) *gorm.DB {
+	if f.Archived {
+		db = db.Where("projects.archived_at IS NOT NULL")
+	} else {
+		db = db.Where("projects.archived_at IS NULL")
+	}
+	if f.ProjectIDs != nil {
+		if len(f.ProjectIDs) == 0 {
+			return db.Where("1 = 0")
+		}
+		db = db.Where("projects.id IN ?", f.ProjectIDs)
+	}
+	if len(f.Statuses) > 0 {
+		db = db.Where("projects.status IN ?", f.Statuses)
+	}
+	if len(f.Types) > 0 {
+		db = db.Where("projects.type IN ?", f.Types)
+	}
+	if f.Location != "" {
+		db = db.Where("projects.location ILIKE ?", "%"+escapeLike(f.Location)+"%")
+	}
+	if f.OwnerID != "" {
+		db = db.Where("projects.id::text IN (SELECT project_id FROM project_members WHERE user_id = ? AND role = ? AND deleted_at IS NULL)", f.OwnerID, middleware.RoleOwner)
+	}
+	if f.MemberID != "" {
+		db = db.Where("projects.id::text IN (SELECT project_id FROM project_members WHERE user_id = ? AND deleted_at IS NULL)", f.MemberID)
+	}
+	if f.AreaMin != nil {
+		db = db.Where("projects.area >= ?", *f.AreaMin)
+	}
+	if f.AreaMax != nil {
+		db = db.Where("projects.area <= ?", *f.AreaMax)
+	}
+	if f.StartFrom != nil {
+		db = db.Where("projects.start_date >= ?", *f.StartFrom)
+	}
+	if f.StartTo != nil {
+		db = db.Where("projects.start_date <= ?", *f.StartTo)
+	}
+	if f.CreditsMin != nil {
+		db = db.Where("projects.carbon_credits >= ?", *f.CreditsMin)
+	}
+	if f.CreditsMax != nil {
+		db = db.Where("projects.carbon_credits <= ?", *f.CreditsMax)
+	}
+	if q := strings.TrimSpace(f.Query); q != "" {
+		db = db.Where(projectSearchVector+" @@ plainto_tsquery('simple', ?)", q)
+	}
+	return db
+}
+
+// projectSearchVector must match the expression index created by
+// runProjectListDDL so full-text filters can use it.
+const projectSearchVector = "to_tsvector('simple', coalesce(projects.name, '') || ' ' || coalesce(projects.type, '') || ' ' || coalesce(projects.location, ''))"
+
+// page applies keyset ordering and the cursor position. Ties on the sort
+// column are broken by id, so pages stay stable while rows are inserted.
+func (f *Filter) page(db *gorm.DB) (*gorm.DB, error) {
+	col := "projects." + sortColumns[f.SortBy]
+	cmp := "<"
+	if f.SortOrder == SortAsc {
+		cmp = ">"
+	}
+	if f.Cursor != "" {
+		value, err := f.decodeCursor()
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s, projects.id) %s (?, ?)", col, cmp), value.value, value.id)
+	}
+	return db.Order(fmt.Sprintf("%s %s, projects.id %s", col, f.SortOrder, f.SortOrder)).Limit(f.Limit + 1), nil
+}
+
+type cursorPosition struct {
+	value any
+	id    string
+}
+
+func (f *Filter) decodeCursor() (*cursorPosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	if c.SortBy != f.SortBy || c.Order != f.SortOrder {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidFilter)
+	}
+
+	pos := &cursorPosition{id: c.ID}
+	switch {
+	case timeColumns[c.SortBy]:
+		var t time.Time
+		err = json.Unmarshal(c.Value, &t)
+		pos.value = t
+	default:
+		err = json.Unmarshal(c.Value, &pos.value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	return pos, nil
+}
+
+// nextCursor encodes the position after p for the current sort.
+func (f *Filter) nextCursor(p *Project) string {
+	var value any
+	switch f.SortBy {
+	case "name":
+		value = p.Name
+	case "type":
+		value = p.Type
+	case "location":
+		value = p.Location
+	case "area":
+		value = p.Area
+	case "start_date":
+		value = p.StartDate
+	case "farmers":
+		value = p.Farmers
+	case "carbon_credits":
+		value = p.CarbonCredits
+	case "progress":
+		value = p.Progress
+	case "status":
+		value = p.Status
+	case "methodology":
+		value = p.MethodologyCode
+	case "updated_at":
+		value = p.UpdatedAt
+	default:
+		value = p.CreatedAt
+	}
+	v, _ := json.Marshal(value)
+	raw, _ := json.Marshal(cursor{SortBy: f.SortBy, Order: f.SortOrder, Value: v, ID: p.ID.String()})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// splitValues accepts both repeated parameters (status=a&status=b) and
+// comma-separated lists (status=a,b).
+func splitValues(in []string) []string {
+	var out []string
+	for _, v := range in {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,59 @@
+package project
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFilterNormalizeDefaultsAndValidation(t *testing.T) {
+	f := &Filter{Statuses: []string{"draft,monitoring"}}
+	if err := f.Normalize(); err != nil {
+		t.Fatalf("Normalize error: %v", err)
+	}
+	if f.SortBy != "created_at" || f.SortOrder != SortDesc || f.Limit != 10 {
+		t.Fatalf("unexpected defaults: %+v", f)
+	}
+	if len(f.Statuses) != 2 {
+		t.Fatalf("comma-separated statuses not split: %v", f.Statuses)
+	}
+
+	bad := []*Filter{
+		{SortBy: "password"},
+		{SortOrder: "sideways"},
+		{Statuses: []string{"pending"}},
+		{AreaMin: ptr(10.0), AreaMax: ptr(1.0)},
+		{Cursor: "not-a-cursor"},
+	}
+	for _, f := range bad {
+		if err := f.Normalize(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("expected ErrInvalidFilter for %+v, got %v", f, err)
+		}
+	}
+}
+
+func TestFilterCursorRoundTrip(t *testing.T) {
+	f := &Filter{SortBy: "start_date", SortOrder: "asc"}
+	if err := f.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	p := &Project{ID: uuid.New(), StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	f.Cursor = f.nextCursor(p)
+
+	pos, err := f.decodeCursor()
+	if err != nil {
+		t.Fatalf("decodeCursor error: %v", err)
+	}
+	if pos.id != p.ID.String() || !pos.value.(time.Time).Equal(p.StartDate) {
+		t.Fatalf("cursor did not round-trip: %+v", pos)
+	}
+
+	other := &Filter{SortBy: "name", Cursor: f.Cursor}
+	if err := other.Normalize(); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("cursor reused with a different sort should be rejected, got %v", err)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
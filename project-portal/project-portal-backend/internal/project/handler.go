@@ -1,19 +1,27 @@
 package project
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/financing/calculation"
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
 	service Service
+	authz   middleware.Authorizer
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, authz middleware.Authorizer) *Handler {
+	return &Handler{service: service, authz: authz}
 }
 
 func (h *Handler) CreateProject(c *gin.Context) {
@@ -23,7 +31,8 @@ func (h *Handler) CreateProject(c *gin.Context) {
 		return
 	}
 
-	project, err := h.service.CreateProject(c.Request.Context(), &req)
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := h.service.CreateProject(c.Request.Context(), &req, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -49,17 +58,108 @@ func (h *Handler) GetProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
+// ListProjects lists projects matching the query filters. Pass the returned
+// next_cursor back as cursor to fetch the following page.
 func (h *Handler) ListProjects(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	var filter Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	projects, err := h.service.ListProjects(c.Request.Context(), limit, offset)
+	var result *ListResult
+	var err error
+	if middleware.IsPlatformAdmin(c) {
+		result, err = h.service.ListProjects(c.Request.Context(), &filter)
+	} else {
+		userID, _ := middleware.CurrentUserID(c)
+		result, err = h.service.ListProjectsForUser(c.Request.Context(), userID, &filter)
+	}
+	if errors.Is(err, ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"projects": projects})
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportProjects handles POST /projects/import. The multipart form carries
+// the rows as file (CSV or XLSX), optional boundaries (a GeoJSON
+// FeatureCollection) and dry_run. The caller becomes Owner of every project.
+func (h *Handler) ImportProjects(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	format := FormatFromFilename(file.Filename)
+	if f := c.PostForm("format"); f != "" {
+		format = f
+	}
+	rows, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	req := &ImportRequest{Format: format, File: rows, DryRun: c.PostForm("dry_run") == "true"}
+	if bf, err := c.FormFile("boundaries"); err == nil {
+		boundaries, err := bf.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer boundaries.Close()
+		req.Boundaries, req.BoundaryName = boundaries, bf.Filename
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	report, err := h.service.ImportProjects(c.Request.Context(), req, userID)
+	switch {
+	case errors.Is(err, ErrImportInvalid) && report != nil:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case errors.Is(err, ErrImportInvalid), errors.Is(err, ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case report.Committed:
+		c.JSON(http.StatusCreated, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ExportProjects handles GET /projects/export?format=csv|xlsx|geojson. It
+// accepts the list filters; non-admins only export their own projects.
+func (h *Handler) ExportProjects(c *gin.Context) {
+	var filter Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.IsPlatformAdmin(c) {
+		filter.MemberID, _ = middleware.CurrentUserID(c)
+	}
+	format := c.DefaultQuery("format", FormatCSV)
+
+	var buf bytes.Buffer
+	err := h.service.ExportProjects(c.Request.Context(), &filter, format, &buf)
+	switch {
+	case errors.Is(err, ErrInvalidFilter), errors.Is(err, ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="projects.%s"`, format))
+	c.Data(http.StatusOK, ContentType(format), buf.Bytes())
 }
 
 func (h *Handler) UpdateProject(c *gin.Context) {
@@ -78,38 +178,254 @@ func (h *Handler) UpdateProject(c *gin.Context) {
 
 	project, err := h.service.UpdateProject(c.Request.Context(), id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		case errors.Is(err, calculation.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrProjectArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, project)
 }
 
-func (h *Handler) DeleteProject(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+// ArchiveProject handles DELETE /projects/:id and POST /projects/:id/archive.
+// Deleting a project archives it; use the purge endpoint to remove it.
+func (h *Handler) ArchiveProject(c *gin.Context) {
+	h.changeArchive(c, h.service.ArchiveProject)
+}
+
+// RestoreProject handles POST /projects/:id/restore
+func (h *Handler) RestoreProject(c *gin.Context) {
+	h.changeArchive(c, h.service.RestoreProject)
+}
+
+func (h *Handler) changeArchive(c *gin.Context, change func(context.Context, uuid.UUID, string) (*Project, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := change(c.Request.Context(), id, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		case errors.Is(err, ErrProjectArchived), errors.Is(err, ErrNotArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// PurgeProject handles DELETE /projects/:id/purge. It permanently removes an
+// archived project and its data from every module.
+func (h *Handler) PurgeProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if err := h.service.PurgeProject(c.Request.Context(), id, userID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		case errors.Is(err, ErrNotArchived), errors.Is(err, ErrLegalHold):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "project purged"})
+}
+
+// TransitionProject handles POST /projects/:id/transitions
+func (h *Handler) TransitionProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !IsValidStatus(req.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown lifecycle stage"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	project, err := h.service.TransitionProject(c.Request.Context(), id, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrGuardFailed), errors.Is(err, ErrStatusConflict), errors.Is(err, ErrProjectArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// GetLifecycle handles GET /projects/:id/lifecycle
+func (h *Handler) GetLifecycle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
 		return
 	}
 
-	err = h.service.DeleteProject(c.Request.Context(), id)
+	state, err := h.service.GetLifecycle(c.Request.Context(), id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "project deleted"})
+	c.JSON(http.StatusOK, state)
+}
+
+// GetStatusHistory handles GET /projects/:id/history
+func (h *Handler) GetStatusHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// ListMethodologies handles GET /methodologies
+func (h *Handler) ListMethodologies(c *gin.Context) {
+	methodologies := calculation.List(c.Query("category"))
+	c.JSON(http.StatusOK, gin.H{"methodologies": methodologies, "total": len(methodologies)})
+}
+
+// GetMethodology handles GET /methodologies/:code
+func (h *Handler) GetMethodology(c *gin.Context) {
+	m, err := calculation.Get(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, m)
 }
 
-// RegisterRoutes registers all project routes with the Gin router
+// CalculateCredits handles POST /projects/:id/credits/calculate
+func (h *Handler) CalculateCredits(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	var req CalculateCreditsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	calc, err := h.service.CalculateCredits(c.Request.Context(), id, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		case errors.Is(err, calculation.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrProjectArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, calc)
+}
+
+// ListCreditCalculations handles GET /projects/:id/credits/calculations
+func (h *Handler) ListCreditCalculations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	calcs, err := h.service.ListCreditCalculations(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calculations": calcs, "total": len(calcs)})
+}
+
+// RegisterRoutes registers all project routes with the Gin router. The group
+// must already require authentication.
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	byID := middleware.ProjectFromParam("id")
 	projects := router.Group("/projects")
 	{
 		projects.POST("", h.CreateProject)
 		projects.GET("", h.ListProjects)
-		projects.GET("/:id", h.GetProject)
-		projects.PUT("/:id", h.UpdateProject)
-		projects.DELETE("/:id", h.DeleteProject)
+		projects.POST("/import", h.ImportProjects)
+		projects.GET("/export", h.ExportProjects)
+		projects.GET("/:id", middleware.RequireProjectPermission(h.authz, middleware.PermProjectRead, byID), h.GetProject)
+		projects.PUT("/:id", middleware.RequireProjectPermission(h.authz, middleware.PermProjectWrite, byID), h.UpdateProject)
+		projects.DELETE("/:id", middleware.RequireProjectPermission(h.authz, middleware.PermProjectDelete, byID), h.ArchiveProject)
+
+		// Archive and purge. Purging is irreversible and reserved for
+		// platform admins.
+		projects.POST("/:id/archive", middleware.RequireProjectPermission(h.authz, middleware.PermProjectDelete, byID), h.ArchiveProject)
+		projects.POST("/:id/restore", middleware.RequireProjectPermission(h.authz, middleware.PermProjectDelete, byID), h.RestoreProject)
+		projects.DELETE("/:id/purge", middleware.RequirePlatformRole(), h.PurgeProject)
+
+		// Lifecycle
+		projects.POST("/:id/transitions", middleware.RequireProjectPermission(h.authz, middleware.PermProjectWrite, byID), h.TransitionProject)
+		projects.GET("/:id/lifecycle", middleware.RequireProjectPermission(h.authz, middleware.PermProjectRead, byID), h.GetLifecycle)
+		projects.GET("/:id/history", middleware.RequireProjectPermission(h.authz, middleware.PermProjectRead, byID), h.GetStatusHistory)
+
+		// Credit calculations
+		projects.POST("/:id/credits/calculate", middleware.RequireProjectPermission(h.authz, middleware.PermProjectWrite, byID), h.CalculateCredits)
+		projects.GET("/:id/credits/calculations", middleware.RequireProjectPermission(h.authz, middleware.PermProjectRead, byID), h.ListCreditCalculations)
+	}
+
+	methodologies := router.Group("/methodologies")
+	{
+		methodologies.GET("", h.ListMethodologies)
+		methodologies.GET("/:code", h.GetMethodology)
 	}
 }
@@ -0,0 +1,311 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle stages a project moves through, mirroring carbon registry stages.
+const (
+	StatusDraft        = "draft"
+	StatusOnboarding   = "onboarding"
+	StatusPDDSubmitted = "pdd_submitted"
+	StatusValidation   = "validation"
+	StatusRegistered   = "registered"
+	StatusMonitoring   = "monitoring"
+	StatusVerification = "verification"
+	StatusIssuance     = "issuance"
+	StatusClosed       = "closed"
+)
+
+// Document types and statuses checked by lifecycle guards. They match the
+// values stored by the documents module.
+const (
+	documentTypePDD                     = "PDD"
+	documentTypeMonitoringReport        = "MONITORING_REPORT"
+	documentTypeVerificationCertificate = "VERIFICATION_CERTIFICATE"
+	documentStatusApproved              = "approved"
+)
+
+var (
+	// ErrInvalidTransition is returned when the target stage is not reachable
+	// from the project's current stage.
+	ErrInvalidTransition = errors.New("invalid lifecycle transition")
+	// ErrGuardFailed is returned when a transition's preconditions are not met.
+	ErrGuardFailed = errors.New("lifecycle guard failed")
+	// ErrStatusConflict is returned when the project changed stage concurrently.
+	ErrStatusConflict = errors.New("project status changed concurrently")
+)
+
+// Guard is a named precondition that must hold before a transition applies.
+type Guard string
+
+const (
+	GuardMethodologyAssigned      Guard = "methodology_assigned"
+	GuardBoundaryUploaded         Guard = "boundary_uploaded"
+	GuardPDDApproved              Guard = "pdd_approved"
+	GuardMonitoringReportApproved Guard = "monitoring_report_approved"
+	GuardVerificationApproved     Guard = "verification_certificate_approved"
+)
+
+// Transition is a single allowed stage change and the guards it requires.
+type Transition struct {
+	To     string  `json:"to"`
+	Guards []Guard `json:"guards,omitempty"`
+}
+
+// lifecycleTransitions defines the allowed stage changes. Send-back edges let
+// reviewers return a project for rework; issuance loops back to monitoring
+// for the next crediting period.
+var lifecycleTransitions = map[string][]Transition{
+	StatusDraft: {
+		{To: StatusOnboarding},
+		{To: StatusClosed},
+	},
+	StatusOnboarding: {
+		{To: StatusPDDSubmitted, Guards: []Guard{GuardMethodologyAssigned, GuardBoundaryUploaded}},
+		{To: StatusClosed},
+	},
+	StatusPDDSubmitted: {
+		{To: StatusValidation, Guards: []Guard{GuardPDDApproved}},
+		{To: StatusOnboarding},
+	},
+	StatusValidation: {
+		{To: StatusRegistered, Guards: []Guard{GuardPDDApproved, GuardMethodologyAssigned, GuardBoundaryUploaded}},
+		{To: StatusPDDSubmitted},
+	},
+	StatusRegistered: {
+		{To: StatusMonitoring},
+		{To: StatusClosed},
+	},
+	StatusMonitoring: {
+		{To: StatusVerification, Guards: []Guard{GuardMonitoringReportApproved}},
+		{To: StatusClosed},
+	},
+	StatusVerification: {
+		{To: StatusIssuance, Guards: []Guard{GuardVerificationApproved}},
+		{To: StatusMonitoring},
+	},
+	StatusIssuance: {
+		{To: StatusMonitoring},
+		{To: StatusClosed},
+	},
+}
+
+// IsValidStatus reports whether s is a known lifecycle stage.
+func IsValidStatus(s string) bool {
+	if s == StatusClosed {
+		return true
+	}
+	_, ok := lifecycleTransitions[s]
+	return ok
+}
+
+// StatusChange is an append-only record of a lifecycle transition.
+type StatusChange struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index"`
+	FromStatus  string    `json:"from_status" gorm:"not null"`
+	ToStatus    string    `json:"to_status" gorm:"not null"`
+	PerformedBy string    `json:"performed_by"`
+	Comment     string    `json:"comment,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName keeps the history table name explicit; it is created by raw DDL
+// with a trigger that rejects updates. Rows are only removed when the project
+// itself is deleted.
+func (StatusChange) TableName() string {
+	return "project_status_history"
+}
+
+// TransitionRequest is the body for POST /projects/:id/transitions.
+type TransitionRequest struct {
+	To      string `json:"to" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// GuardResult reports whether a single guard currently holds.
+type GuardResult struct {
+	Guard  Guard  `json:"guard"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// AvailableTransition is a reachable stage with its guard evaluation.
+type AvailableTransition struct {
+	To      string        `json:"to"`
+	Allowed bool          `json:"allowed"`
+	Guards  []GuardResult `json:"guards,omitempty"`
+}
+
+// LifecycleState is returned from GET /projects/:id/lifecycle.
+type LifecycleState struct {
+	ProjectID   uuid.UUID             `json:"project_id"`
+	Status      string                `json:"status"`
+	Transitions []AvailableTransition `json:"transitions"`
+}
+
+// --- Guard dependencies ---
+
+// DocumentChecker looks up project documents. It is implemented by the
+// documents repository.
+type DocumentChecker interface {
+	HasProjectDocument(ctx context.Context, projectID uuid.UUID, docType, status string) (bool, error)
+}
+
+// BoundaryChecker looks up project geometries. It is implemented by the
+// geospatial service.
+type BoundaryChecker interface {
+	HasBoundary(ctx context.Context, projectID uuid.UUID) (bool, error)
+}
+
+// ActivityFeed receives lifecycle events. It is implemented by the
+// collaboration service.
+type ActivityFeed interface {
+	RecordActivity(ctx context.Context, projectID, userID, action string, metadata map[string]any) error
+}
+
+// --- Service methods ---
+
+// TransitionProject moves a project to the requested stage after checking
+// that the edge exists and all of its guards pass. The status update and the
+// history entry are written atomically.
+func (s *service) TransitionProject(ctx context.Context, id uuid.UUID, req *TransitionRequest, userID string) (*Project, error) {
+	project, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureActive(project); err != nil {
+		return nil, err
+	}
+
+	transition, ok := findTransition(project.Status, req.To)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s → %s", ErrInvalidTransition, project.Status, req.To)
+	}
+
+	results, err := s.evaluateGuards(ctx, project, transition.Guards)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if !r.Passed {
+			return nil, fmt.Errorf("%w: %s", ErrGuardFailed, r.Reason)
+		}
+	}
+
+	change := &StatusChange{
+		ProjectID:   project.ID,
+		FromStatus:  project.Status,
+		ToStatus:    req.To,
+		PerformedBy: userID,
+		Comment:     req.Comment,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.repo.ApplyStatusChange(ctx, change); err != nil {
+		return nil, err
+	}
+	project.Status = req.To
+	project.UpdatedAt = change.CreatedAt
+
+	if s.activity != nil {
+		_ = s.activity.RecordActivity(ctx, project.ID.String(), userID, "project_status_changed", map[string]any{
+			"from":    change.FromStatus,
+			"to":      change.ToStatus,
+			"comment": change.Comment,
+		})
+	}
+	return project, nil
+}
+
+// GetLifecycle returns the project's stage and each reachable stage with the
+// current result of its guards.
+func (s *service) GetLifecycle(ctx context.Context, id uuid.UUID) (*LifecycleState, error) {
+	project, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &LifecycleState{ProjectID: project.ID, Status: project.Status, Transitions: []AvailableTransition{}}
+	for _, t := range lifecycleTransitions[project.Status] {
+		results, err := s.evaluateGuards(ctx, project, t.Guards)
+		if err != nil {
+			return nil, err
+		}
+		allowed := true
+		for _, r := range results {
+			allowed = allowed && r.Passed
+		}
+		state.Transitions = append(state.Transitions, AvailableTransition{To: t.To, Allowed: allowed, Guards: results})
+	}
+	return state, nil
+}
+
+// GetStatusHistory returns the project's lifecycle history, oldest first.
+func (s *service) GetStatusHistory(ctx context.Context, id uuid.UUID) ([]StatusChange, error) {
+	return s.repo.ListStatusChanges(ctx, id)
+}
+
+func (s *service) evaluateGuards(ctx context.Context, project *Project, guards []Guard) ([]GuardResult, error) {
+	results := make([]GuardResult, 0, len(guards))
+	for _, g := range guards {
+		passed, reason, err := s.checkGuard(ctx, project, g)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating guard %s: %w", g, err)
+		}
+		results = append(results, GuardResult{Guard: g, Passed: passed, Reason: reason})
+	}
+	return results, nil
+}
+
+func (s *service) checkGuard(ctx context.Context, project *Project, g Guard) (bool, string, error) {
+	switch g {
+	case GuardMethodologyAssigned:
+		if project.MethodologyCode == "" {
+			return false, "a methodology must be assigned", nil
+		}
+		return true, "", nil
+	case GuardBoundaryUploaded:
+		if s.boundaries == nil {
+			return false, "geospatial service unavailable", nil
+		}
+		ok, err := s.boundaries.HasBoundary(ctx, project.ID)
+		if err != nil || ok {
+			return ok, "", err
+		}
+		return false, "a project boundary must be uploaded", nil
+	case GuardPDDApproved:
+		return s.checkDocument(ctx, project.ID, documentTypePDD, "an approved PDD is required")
+	case GuardMonitoringReportApproved:
+		return s.checkDocument(ctx, project.ID, documentTypeMonitoringReport, "an approved monitoring report is required")
+	case GuardVerificationApproved:
+		return s.checkDocument(ctx, project.ID, documentTypeVerificationCertificate, "an approved verification certificate is required")
+	default:
+		return false, "", fmt.Errorf("unknown guard %q", g)
+	}
+}
+
+func (s *service) checkDocument(ctx context.Context, projectID uuid.UUID, docType, reason string) (bool, string, error) {
+	if s.documents == nil {
+		return false, "document service unavailable", nil
+	}
+	ok, err := s.documents.HasProjectDocument(ctx, projectID, docType, documentStatusApproved)
+	if err != nil || ok {
+		return ok, "", err
+	}
+	return false, reason, nil
+}
+
+func findTransition(from, to string) (Transition, bool) {
+	for _, t := range lifecycleTransitions[from] {
+		if t.To == to {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
@@ -0,0 +1,193 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memRepo struct {
+	projects map[uuid.UUID]*Project
+	history  []StatusChange
+	updates  []map[string]any
+}
+
+func (r *memRepo) Create(_ context.Context, p *Project) error { r.projects[p.ID] = p; return nil }
+func (r *memRepo) CreateMany(_ context.Context, ps []*Project, after func(*gorm.DB, int) error) error {
+	for i, p := range ps {
+		r.projects[p.ID] = p
+		if err := after(nil, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (r *memRepo) GetByID(_ context.Context, id uuid.UUID) (*Project, error) {
+	p, ok := r.projects[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	cp := *p
+	return &cp, nil
+}
+func (r *memRepo) List(context.Context, *Filter) (*ListResult, error) { return &ListResult{}, nil }
+func (r *memRepo) Delete(_ context.Context, id uuid.UUID) error       { delete(r.projects, id); return nil }
+func (r *memRepo) Update(_ context.Context, id uuid.UUID, changes map[string]any) error {
+	p, ok := r.projects[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	if p.ArchivedAt != nil {
+		return ErrProjectArchived
+	}
+	r.updates = append(r.updates, changes)
+	if name, ok := changes["name"].(string); ok {
+		p.Name = name
+	}
+	return nil
+}
+func (r *memRepo) Archive(_ context.Context, id uuid.UUID, userID string, at time.Time) error {
+	p := r.projects[id]
+	if p.ArchivedAt != nil {
+		return ErrProjectArchived
+	}
+	p.ArchivedAt, p.ArchivedBy = &at, userID
+	return nil
+}
+func (r *memRepo) Restore(_ context.Context, id uuid.UUID) error {
+	p := r.projects[id]
+	if p.ArchivedAt == nil {
+		return ErrNotArchived
+	}
+	p.ArchivedAt, p.ArchivedBy = nil, ""
+	return nil
+}
+func (r *memRepo) Purge(ctx context.Context, id uuid.UUID, purgers []DataPurger) ([]func(context.Context), error) {
+	var cleanups []func(context.Context)
+	for _, p := range purgers {
+		cleanup, err := p.PurgeProjectData(ctx, nil, id)
+		if err != nil {
+			return nil, err
+		}
+		if cleanup != nil {
+			cleanups = append(cleanups, cleanup)
+		}
+	}
+	delete(r.projects, id)
+	return cleanups, nil
+}
+func (r *memRepo) ApplyStatusChange(_ context.Context, c *StatusChange) error {
+	p := r.projects[c.ProjectID]
+	if p.Status != c.FromStatus {
+		return ErrStatusConflict
+	}
+	p.Status = c.ToStatus
+	r.history = append(r.history, *c)
+	return nil
+}
+func (r *memRepo) ListStatusChanges(context.Context, uuid.UUID) ([]StatusChange, error) {
+	return r.history, nil
+}
+
+func (r *memRepo) CreateCreditCalculation(context.Context, *CreditCalculation) error { return nil }
+func (r *memRepo) ListCreditCalculations(context.Context, uuid.UUID) ([]CreditCalculation, error) {
+	return nil, nil
+}
+
+type stubChecks struct {
+	boundary bool
+	docs     map[string]bool
+	events   []string
+}
+
+func (s *stubChecks) HasBoundary(context.Context, uuid.UUID) (bool, error) { return s.boundary, nil }
+func (s *stubChecks) HasProjectDocument(_ context.Context, _ uuid.UUID, docType, _ string) (bool, error) {
+	return s.docs[docType], nil
+}
+func (s *stubChecks) RecordActivity(_ context.Context, _, _, action string, _ map[string]any) error {
+	s.events = append(s.events, action)
+	return nil
+}
+
+func newLifecycleFixture(status string) (*service, *memRepo, *stubChecks, uuid.UUID) {
+	id := uuid.New()
+	repo := &memRepo{projects: map[uuid.UUID]*Project{id: {ID: id, Status: status}}}
+	checks := &stubChecks{docs: map[string]bool{}}
+	svc := NewService(repo, Dependencies{Documents: checks, Boundaries: checks, Activity: checks}).(*service)
+	return svc, repo, checks, id
+}
+
+func TestTransitionRejectsUnknownEdge(t *testing.T) {
+	svc, _, _, id := newLifecycleFixture(StatusDraft)
+	_, err := svc.TransitionProject(context.Background(), id, &TransitionRequest{To: StatusRegistered}, "u-1")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestTransitionEnforcesGuards(t *testing.T) {
+	svc, repo, checks, id := newLifecycleFixture(StatusOnboarding)
+	ctx := context.Background()
+	req := &TransitionRequest{To: StatusPDDSubmitted}
+
+	if _, err := svc.TransitionProject(ctx, id, req, "u-1"); !errors.Is(err, ErrGuardFailed) {
+		t.Fatalf("expected guard failure without methodology and boundary, got %v", err)
+	}
+
+	repo.projects[id].MethodologyCode = "VM0047"
+	checks.boundary = true
+	p, err := svc.TransitionProject(ctx, id, req, "u-1")
+	if err != nil {
+		t.Fatalf("transition should pass once guards hold: %v", err)
+	}
+	if p.Status != StatusPDDSubmitted {
+		t.Fatalf("unexpected status %q", p.Status)
+	}
+	if len(repo.history) != 1 || repo.history[0].FromStatus != StatusOnboarding || repo.history[0].PerformedBy != "u-1" {
+		t.Fatalf("unexpected history: %+v", repo.history)
+	}
+	if len(checks.events) != 1 || checks.events[0] != "project_status_changed" {
+		t.Fatalf("expected an activity event, got %v", checks.events)
+	}
+}
+
+func TestGetLifecycleReportsGuardResults(t *testing.T) {
+	svc, _, checks, id := newLifecycleFixture(StatusPDDSubmitted)
+	checks.docs[documentTypePDD] = true
+
+	state, err := svc.GetLifecycle(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetLifecycle error: %v", err)
+	}
+	for _, tr := range state.Transitions {
+		if !tr.Allowed {
+			t.Fatalf("transition to %s should be allowed: %+v", tr.To, tr.Guards)
+		}
+	}
+}
+
+func TestUpdateProjectLeavesStatusAndCreditsAlone(t *testing.T) {
+	svc, repo, _, id := newLifecycleFixture(StatusDraft)
+	ctx := context.Background()
+
+	if _, err := svc.TransitionProject(ctx, id, &TransitionRequest{To: StatusOnboarding}, "u-1"); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+	name, progress := "Renamed", 40
+	p, err := svc.UpdateProject(ctx, id, &ProjectUpdateRequest{Name: &name, Progress: &progress})
+	if err != nil {
+		t.Fatalf("UpdateProject error: %v", err)
+	}
+	if p.Status != StatusOnboarding || p.Name != name {
+		t.Fatalf("unexpected project after update: %+v", p)
+	}
+	for _, col := range []string{"status", "carbon_credits", "archived_at", "archived_by"} {
+		if _, ok := repo.updates[0][col]; ok {
+			t.Fatalf("update should not write %s: %v", col, repo.updates[0])
+		}
+	}
+}
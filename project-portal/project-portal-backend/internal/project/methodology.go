@@ -1,7 +1,105 @@
-//go:build future
-// +build future
-
 package project
 
-// This file won't be compiled in normal builds
-// Implementation pending
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/financing/calculation"
+
+	"github.com/google/uuid"
+)
+
+// CreditCalculation is a stored, reproducible credit estimate for a project.
+// Result carries the full calculation trail; InputHash lets auditors confirm
+// that re-running the same inputs yields the same numbers.
+type CreditCalculation struct {
+	ID                 uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID          uuid.UUID          `json:"project_id" gorm:"type:uuid;not null;index"`
+	MethodologyCode    string             `json:"methodology_code" gorm:"not null"`
+	MethodologyVersion string             `json:"methodology_version" gorm:"not null"`
+	Mode               string             `json:"mode" gorm:"not null;index"`
+	NetCredits         float64            `json:"net_credits"`
+	IssuableCredits    int64              `json:"issuable_credits"`
+	InputHash          string             `json:"input_hash" gorm:"size:64;index"`
+	Result             calculation.Result `json:"result" gorm:"serializer:json"`
+	CalculatedBy       string             `json:"calculated_by"`
+	CreatedAt          time.Time          `json:"created_at"`
+}
+
+// CalculateCreditsRequest is the body for POST /projects/:id/credits/calculate.
+// The project's area and assigned methodology are always used so results
+// cannot drift from the project record.
+type CalculateCreditsRequest struct {
+	Mode       string             `json:"mode" binding:"required,oneof=ex_ante ex_post"`
+	Years      float64            `json:"years" binding:"required,gt=0"`
+	Parameters map[string]float64 `json:"parameters"`
+}
+
+// CalculateCredits runs the credit engine for the project's methodology and
+// stores the result. Ex-post results update Project.CarbonCredits; ex-ante
+// results only do so until the first ex-post result exists.
+func (s *service) CalculateCredits(ctx context.Context, id uuid.UUID, req *CalculateCreditsRequest, userID string) (*CreditCalculation, error) {
+	project, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureActive(project); err != nil {
+		return nil, err
+	}
+	if project.MethodologyCode == "" {
+		return nil, fmt.Errorf("%w: project has no methodology assigned", calculation.ErrInvalidInput)
+	}
+
+	result, err := s.engine.Calculate(calculation.Input{
+		MethodologyCode: project.MethodologyCode,
+		Mode:            req.Mode,
+		AreaHectares:    project.Area,
+		Years:           req.Years,
+		Parameters:      req.Parameters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	calc := &CreditCalculation{
+		ProjectID:          project.ID,
+		MethodologyCode:    result.MethodologyCode,
+		MethodologyVersion: result.MethodologyVersion,
+		Mode:               result.Mode,
+		NetCredits:         result.NetCredits,
+		IssuableCredits:    result.IssuableCredits,
+		InputHash:          result.InputHash,
+		Result:             *result,
+		CalculatedBy:       userID,
+		CreatedAt:          result.CalculatedAt,
+	}
+	if err := s.repo.CreateCreditCalculation(ctx, calc); err != nil {
+		return nil, err
+	}
+
+	if s.activity != nil {
+		_ = s.activity.RecordActivity(ctx, project.ID.String(), userID, "credits_calculated", map[string]any{
+			"methodology": calc.MethodologyCode,
+			"mode":        calc.Mode,
+			"net_credits": calc.NetCredits,
+		})
+	}
+	return calc, nil
+}
+
+// ListCreditCalculations returns the project's stored calculations, newest first.
+func (s *service) ListCreditCalculations(ctx context.Context, id uuid.UUID) ([]CreditCalculation, error) {
+	return s.repo.ListCreditCalculations(ctx, id)
+}
+
+// validateMethodology checks that code exists in the catalog.
+func validateMethodology(code string) error {
+	if code == "" {
+		return nil
+	}
+	if _, err := calculation.Get(code); err != nil {
+		return fmt.Errorf("%w: %v", calculation.ErrInvalidInput, err)
+	}
+	return nil
+}
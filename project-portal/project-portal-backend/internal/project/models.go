@@ -9,19 +9,24 @@ import (
 
 // Project represents a carbon project
 type Project struct {
-	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name          string    `json:"name" gorm:"not null"`
-	Type          string    `json:"type" gorm:"not null"` // e.g., Reforestation, Agroforestry
-	Location      string    `json:"location" gorm:"not null"`
-	Area          float64   `json:"area" gorm:"not null"` // in hectares
-	StartDate     time.Time `json:"start_date"`
-	Farmers       int       `json:"farmers"`
-	CarbonCredits int       `json:"carbon_credits"`
-	Progress      int       `json:"progress"` // percentage
-	Icon          string    `json:"icon"`
-	Status        string    `json:"status" gorm:"default:'pending'"` // active, pending, completed
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name            string    `json:"name" gorm:"not null"`
+	Type            string    `json:"type" gorm:"not null"` // e.g., Reforestation, Agroforestry
+	Location        string    `json:"location" gorm:"not null"`
+	Area            float64   `json:"area" gorm:"not null"` // in hectares
+	StartDate       time.Time `json:"start_date"`
+	Farmers         int       `json:"farmers"`
+	CarbonCredits   int       `json:"carbon_credits"`
+	Progress        int       `json:"progress"` // percentage
+	Icon            string    `json:"icon"`
+	Status          string    `json:"status" gorm:"not null;default:'draft';index"` // lifecycle stage, see lifecycle.go
+	MethodologyCode string    `json:"methodology_code"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	// ArchivedAt hides the project from lists and freezes it until it is
+	// restored. Archiving never removes data; see PurgeProject.
+	ArchivedAt *time.Time `json:"archived_at,omitempty" gorm:"index"`
+	ArchivedBy string     `json:"archived_by,omitempty"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID.
@@ -34,28 +39,27 @@ func (p *Project) BeforeCreate(tx *gorm.DB) (err error) {
 
 // ProjectCreateRequest represents the request to create a project
 type ProjectCreateRequest struct {
-	Name          string  `json:"name" binding:"required"`
-	Type          string  `json:"type" binding:"required"`
-	Location      string  `json:"location" binding:"required"`
-	Area          float64 `json:"area" binding:"required,min=0"`
-	StartDate     string  `json:"start_date"` // ISO date string
-	Farmers       int     `json:"farmers" binding:"min=0"`
-	CarbonCredits int     `json:"carbon_credits" binding:"min=0"`
-	Progress      int     `json:"progress" binding:"min=0,max=100"`
-	Icon          string  `json:"icon"`
-	Status        string  `json:"status"`
+	Name      string  `json:"name" binding:"required"`
+	Type      string  `json:"type" binding:"required"`
+	Location  string  `json:"location" binding:"required"`
+	Area      float64 `json:"area" binding:"required,min=0"`
+	StartDate string  `json:"start_date"` // ISO date string
+	Farmers   int     `json:"farmers" binding:"min=0"`
+	Progress  int     `json:"progress" binding:"min=0,max=100"`
+	Icon      string  `json:"icon"`
 }
 
 // ProjectUpdateRequest represents the request to update a project
 type ProjectUpdateRequest struct {
-	Name          *string  `json:"name,omitempty"`
-	Type          *string  `json:"type,omitempty"`
-	Location      *string  `json:"location,omitempty"`
-	Area          *float64 `json:"area,omitempty"`
-	StartDate     *string  `json:"start_date,omitempty"`
-	Farmers       *int     `json:"farmers,omitempty"`
-	CarbonCredits *int     `json:"carbon_credits,omitempty"`
-	Progress      *int     `json:"progress,omitempty"`
-	Icon          *string  `json:"icon,omitempty"`
-	Status        *string  `json:"status,omitempty"`
+	Name      *string  `json:"name,omitempty"`
+	Type      *string  `json:"type,omitempty"`
+	Location  *string  `json:"location,omitempty"`
+	Area      *float64 `json:"area,omitempty"`
+	StartDate *string  `json:"start_date,omitempty"`
+	Farmers   *int     `json:"farmers,omitempty"`
+	Progress  *int     `json:"progress,omitempty"`
+	Icon      *string  `json:"icon,omitempty"`
+	// MethodologyCode assigns the crediting methodology. Status is not
+	// editable here; use the lifecycle transition endpoint.
+	MethodologyCode *string `json:"methodology_code,omitempty"`
 }
@@ -2,17 +2,32 @@ package project
 
 import (
 	"context"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/financing/calculation"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, project *Project) error
+	CreateMany(ctx context.Context, projects []*Project, after func(tx *gorm.DB, i int) error) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Project, error)
-	List(ctx context.Context, limit, offset int) ([]Project, error)
-	Update(ctx context.Context, project *Project) error
+	List(ctx context.Context, filter *Filter) (*ListResult, error)
+	Update(ctx context.Context, id uuid.UUID, changes map[string]any) error
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	Archive(ctx context.Context, id uuid.UUID, userID string, at time.Time) error
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, id uuid.UUID, purgers []DataPurger) ([]func(context.Context), error)
+
+	ApplyStatusChange(ctx context.Context, change *StatusChange) error
+	ListStatusChanges(ctx context.Context, projectID uuid.UUID) ([]StatusChange, error)
+
+	CreateCreditCalculation(ctx context.Context, calc *CreditCalculation) error
+	ListCreditCalculations(ctx context.Context, projectID uuid.UUID) ([]CreditCalculation, error)
 }
 
 type repository struct {
@@ -27,6 +42,24 @@ func (r *repository) Create(ctx context.Context, project *Project) error {
 	return r.db.WithContext(ctx).Create(project).Error
 }
 
+// CreateMany inserts projects in one transaction, calling after for each one
+// once it exists so related rows commit or roll back with it.
+func (r *repository) CreateMany(ctx context.Context, projects []*Project, after func(tx *gorm.DB, i int) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, p := range projects {
+			if err := tx.Create(p).Error; err != nil {
+				return err
+			}
+			if after != nil {
+				if err := after(tx, i); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
 func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Project, error) {
 	var project Project
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(&project).Error
@@ -36,16 +69,174 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Project, error
 	return &project, nil
 }
 
-func (r *repository) List(ctx context.Context, limit, offset int) ([]Project, error) {
-	var projects []Project
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&projects).Error
-	return projects, err
+// List returns one page of projects matching a normalized filter, the total
+// number of matches and the cursor for the next page.
+func (r *repository) List(ctx context.Context, filter *Filter) (*ListResult, error) {
+	result := &ListResult{Projects: []Project{}}
+	base := filter.Where(r.db.WithContext(ctx).Model(&Project{}))
+	if err := base.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	query, err := filter.page(base.Session(&gorm.Session{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Find(&result.Projects).Error; err != nil {
+		return nil, err
+	}
+	// page fetches one extra row to learn whether another page exists.
+	if len(result.Projects) > filter.Limit {
+		result.Projects = result.Projects[:filter.Limit]
+		result.NextCursor = filter.nextCursor(&result.Projects[filter.Limit-1])
+	}
+	return result, nil
 }
 
-func (r *repository) Update(ctx context.Context, project *Project) error {
-	return r.db.WithContext(ctx).Save(project).Error
+// Update writes changes, keyed by column, to an active project. It returns
+// ErrProjectArchived if the project is archived.
+func (r *repository) Update(ctx context.Context, id uuid.UUID, changes map[string]any) error {
+	res := r.db.WithContext(ctx).Model(&Project{}).
+		Where("id = ? AND archived_at IS NULL", id).
+		Updates(changes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missingOr(ctx, id, ErrProjectArchived)
+	}
+	return nil
 }
 
 func (r *repository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&Project{}, "id = ?", id).Error
 }
+
+// Archive marks an active project archived. It returns ErrProjectArchived if
+// the project is already archived.
+func (r *repository) Archive(ctx context.Context, id uuid.UUID, userID string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&Project{}).
+		Where("id = ? AND archived_at IS NULL", id).
+		Updates(map[string]any{"archived_at": at, "archived_by": userID, "updated_at": at})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missingOr(ctx, id, ErrProjectArchived)
+	}
+	return nil
+}
+
+// Restore clears the archive marker. It returns ErrNotArchived if the
+// project is active.
+func (r *repository) Restore(ctx context.Context, id uuid.UUID) error {
+	res := r.db.WithContext(ctx).Model(&Project{}).
+		Where("id = ? AND archived_at IS NOT NULL", id).
+		Updates(map[string]any{"archived_at": nil, "archived_by": "", "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missingOr(ctx, id, ErrNotArchived)
+	}
+	return nil
+}
+
+// missingOr distinguishes a conditional update that matched nothing because
+// the project does not exist from one that failed its condition.
+func (r *repository) missingOr(ctx context.Context, id uuid.UUID, err error) error {
+	if _, getErr := r.GetByID(ctx, id); getErr != nil {
+		return getErr
+	}
+	return err
+}
+
+// Purge deletes the project, its history and credit calculations, and every
+// module's data via purgers, all in one transaction. It returns the purgers'
+// post-commit cleanups. Only archived projects are purged.
+func (r *repository) Purge(ctx context.Context, id uuid.UUID, purgers []DataPurger) ([]func(context.Context), error) {
+	var cleanups []func(context.Context)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the row so a concurrent restore cannot interleave.
+		var project Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND archived_at IS NOT NULL", id).First(&project).Error; err != nil {
+			return err
+		}
+		for _, p := range purgers {
+			cleanup, err := p.PurgeProjectData(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if cleanup != nil {
+				cleanups = append(cleanups, cleanup)
+			}
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&CreditCalculation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&StatusChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Project{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cleanups, nil
+}
+
+// ApplyStatusChange moves the project from change.FromStatus to
+// change.ToStatus and appends the history entry in one transaction. The
+// update is conditional on the current status so concurrent transitions
+// cannot both succeed.
+func (r *repository) ApplyStatusChange(ctx context.Context, change *StatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Project{}).
+			Where("id = ? AND status = ?", change.ProjectID, change.FromStatus).
+			Updates(map[string]any{"status": change.ToStatus, "updated_at": change.CreatedAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStatusConflict
+		}
+		return tx.Create(change).Error
+	})
+}
+
+func (r *repository) ListStatusChanges(ctx context.Context, projectID uuid.UUID) ([]StatusChange, error) {
+	var changes []StatusChange
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at ASC").Find(&changes).Error
+	return changes, err
+}
+
+// CreateCreditCalculation stores the calculation and refreshes the project's
+// carbon_credits. An ex-ante result never overwrites credits derived from
+// monitoring data.
+func (r *repository) CreateCreditCalculation(ctx context.Context, calc *CreditCalculation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if calc.Mode != calculation.ModeExPost {
+			var expost int64
+			if err := tx.Model(&CreditCalculation{}).
+				Where("project_id = ? AND mode = ?", calc.ProjectID, calculation.ModeExPost).
+				Count(&expost).Error; err != nil {
+				return err
+			}
+			if expost > 0 {
+				return tx.Create(calc).Error
+			}
+		}
+		if err := tx.Create(calc).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Project{}).Where("id = ?", calc.ProjectID).
+			Updates(map[string]any{"carbon_credits": calc.IssuableCredits, "updated_at": calc.CreatedAt}).Error
+	})
+}
+
+func (r *repository) ListCreditCalculations(ctx context.Context, projectID uuid.UUID) ([]CreditCalculation, error) {
+	var calcs []CreditCalculation
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at DESC").Find(&calcs).Error
+	return calcs, err
+}
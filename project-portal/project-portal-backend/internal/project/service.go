@@ -3,45 +3,95 @@ package project
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/financing/calculation"
+
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Service interface {
-	CreateProject(ctx context.Context, req *ProjectCreateRequest) (*Project, error)
+	CreateProject(ctx context.Context, req *ProjectCreateRequest, ownerID string) (*Project, error)
 	GetProject(ctx context.Context, id uuid.UUID) (*Project, error)
-	ListProjects(ctx context.Context, limit, offset int) ([]Project, error)
+	ListProjects(ctx context.Context, filter *Filter) (*ListResult, error)
+	ListProjectsForUser(ctx context.Context, userID string, filter *Filter) (*ListResult, error)
 	UpdateProject(ctx context.Context, id uuid.UUID, req *ProjectUpdateRequest) (*Project, error)
-	DeleteProject(ctx context.Context, id uuid.UUID) error
+
+	// Bulk import and export
+	ImportProjects(ctx context.Context, req *ImportRequest, ownerID string) (*ImportReport, error)
+	ExportProjects(ctx context.Context, filter *Filter, format string, w io.Writer) error
+
+	// Archive and purge
+	ArchiveProject(ctx context.Context, id uuid.UUID, userID string) (*Project, error)
+	RestoreProject(ctx context.Context, id uuid.UUID, userID string) (*Project, error)
+	PurgeProject(ctx context.Context, id uuid.UUID, userID string) error
+
+	// Lifecycle
+	TransitionProject(ctx context.Context, id uuid.UUID, req *TransitionRequest, userID string) (*Project, error)
+	GetLifecycle(ctx context.Context, id uuid.UUID) (*LifecycleState, error)
+	GetStatusHistory(ctx context.Context, id uuid.UUID) ([]StatusChange, error)
+
+	// Credits
+	CalculateCredits(ctx context.Context, id uuid.UUID, req *CalculateCreditsRequest, userID string) (*CreditCalculation, error)
+	ListCreditCalculations(ctx context.Context, id uuid.UUID) ([]CreditCalculation, error)
 }
 
-type service struct {
-	repo Repository
+// Membership records who belongs to a project. It is implemented by the
+// collaboration service.
+type Membership interface {
+	AddOwner(ctx context.Context, projectID, userID string) error
+	AddOwnerTx(ctx context.Context, tx *gorm.DB, projectID, userID string) error
+	ListUserProjectIDs(ctx context.Context, userID string) ([]string, error)
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// Dependencies are the other modules the project service relies on. Members
+// is required; the lifecycle guards treat a nil Documents or Boundaries as
+// unavailable, and a nil Activity disables activity feed events. Purge
+// refuses to run without Compliance, and Purgers must cover every module
+// that stores rows keyed by project_id. Importing boundaries and exporting
+// GeoJSON need BoundaryStore.
+type Dependencies struct {
+	Members       Membership
+	Documents     DocumentChecker
+	Boundaries    BoundaryChecker
+	Activity      ActivityFeed
+	Compliance    PurgeCompliance
+	Purgers       []DataPurger
+	BoundaryStore BoundaryStore
 }
 
-func (s *service) CreateProject(ctx context.Context, req *ProjectCreateRequest) (*Project, error) {
-	project := &Project{
-		Name:          req.Name,
-		Type:          req.Type,
-		Location:      req.Location,
-		Area:          req.Area,
-		Farmers:       req.Farmers,
-		CarbonCredits: req.CarbonCredits,
-		Progress:      req.Progress,
-		Icon:          req.Icon,
-		Status:        req.Status,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
+type service struct {
+	repo          Repository
+	members       Membership
+	documents     DocumentChecker
+	boundaries    BoundaryChecker
+	activity      ActivityFeed
+	compliance    PurgeCompliance
+	purgers       []DataPurger
+	boundaryStore BoundaryStore
+	engine        *calculation.Engine
+}
 
-	if req.Status == "" {
-		project.Status = "pending"
+func NewService(repo Repository, deps Dependencies) Service {
+	return &service{
+		repo:          repo,
+		members:       deps.Members,
+		documents:     deps.Documents,
+		boundaries:    deps.Boundaries,
+		activity:      deps.Activity,
+		compliance:    deps.Compliance,
+		purgers:       deps.Purgers,
+		boundaryStore: deps.BoundaryStore,
+		engine:        calculation.NewEngine(),
 	}
+}
+
+// CreateProject stores the project and makes ownerID its first Owner.
+func (s *service) CreateProject(ctx context.Context, req *ProjectCreateRequest, ownerID string) (*Project, error) {
+	project := newProject(req, time.Now())
 
 	if req.StartDate != "" {
 		startDate, err := time.Parse("2006-01-02", req.StartDate)
@@ -56,74 +106,110 @@ func (s *service) CreateProject(ctx context.Context, req *ProjectCreateRequest)
 		return nil, err
 	}
 
+	if err := s.members.AddOwner(ctx, project.ID.String(), ownerID); err != nil {
+		// A project nobody can access is useless; roll it back.
+		_ = s.repo.Delete(ctx, project.ID)
+		return nil, fmt.Errorf("assigning project owner: %w", err)
+	}
+
 	return project, nil
 }
 
+// newProject builds a draft project from a create request. StartDate is
+// parsed by the caller.
+func newProject(req *ProjectCreateRequest, now time.Time) *Project {
+	return &Project{
+		Name:      req.Name,
+		Type:      req.Type,
+		Location:  req.Location,
+		Area:      req.Area,
+		Farmers:   req.Farmers,
+		Progress:  req.Progress,
+		Icon:      req.Icon,
+		Status:    StatusDraft,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
 func (s *service) GetProject(ctx context.Context, id uuid.UUID) (*Project, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *service) ListProjects(ctx context.Context, limit, offset int) ([]Project, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-	if limit > 100 {
-		limit = 100
+// ListProjects lists every project matching the filter.
+func (s *service) ListProjects(ctx context.Context, filter *Filter) (*ListResult, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
 	}
-	return s.repo.List(ctx, limit, offset)
+	return s.repo.List(ctx, filter)
 }
 
-func (s *service) UpdateProject(ctx context.Context, id uuid.UUID, req *ProjectUpdateRequest) (*Project, error) {
-	project, err := s.repo.GetByID(ctx, id)
+// ListProjectsForUser lists only matching projects the user is a member of.
+func (s *service) ListProjectsForUser(ctx context.Context, userID string, filter *Filter) (*ListResult, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+	ids, err := s.members.ListUserProjectIDs(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	filter.ProjectIDs = append([]string{}, ids...)
+	return s.repo.List(ctx, filter)
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return 10
+	}
+	if limit > 100 {
+		return 100
+	}
+	return limit
+}
 
+// UpdateProject writes only the fields set in req. Lifecycle status, credits
+// and the archive marker are owned by their own operations and are never
+// written here, so a concurrent transition, calculation or archive is kept.
+func (s *service) UpdateProject(ctx context.Context, id uuid.UUID, req *ProjectUpdateRequest) (*Project, error) {
+	changes := map[string]any{}
 	if req.Name != nil {
-		project.Name = *req.Name
+		changes["name"] = *req.Name
 	}
 	if req.Type != nil {
-		project.Type = *req.Type
+		changes["type"] = *req.Type
 	}
 	if req.Location != nil {
-		project.Location = *req.Location
+		changes["location"] = *req.Location
 	}
 	if req.Area != nil {
-		project.Area = *req.Area
+		changes["area"] = *req.Area
 	}
 	if req.Farmers != nil {
-		project.Farmers = *req.Farmers
-	}
-	if req.CarbonCredits != nil {
-		project.CarbonCredits = *req.CarbonCredits
+		changes["farmers"] = *req.Farmers
 	}
 	if req.Progress != nil {
-		project.Progress = *req.Progress
+		changes["progress"] = *req.Progress
 	}
 	if req.Icon != nil {
-		project.Icon = *req.Icon
+		changes["icon"] = *req.Icon
 	}
-	if req.Status != nil {
-		project.Status = *req.Status
+	if req.MethodologyCode != nil {
+		if err := validateMethodology(*req.MethodologyCode); err != nil {
+			return nil, err
+		}
+		changes["methodology_code"] = *req.MethodologyCode
 	}
 	if req.StartDate != nil {
 		startDate, err := time.Parse("2006-01-02", *req.StartDate)
 		if err != nil {
 			return nil, errors.New("invalid start_date format, use YYYY-MM-DD")
 		}
-		project.StartDate = startDate
+		changes["start_date"] = startDate
 	}
+	changes["updated_at"] = time.Now()
 
-	project.UpdatedAt = time.Now()
-
-	err = s.repo.Update(ctx, project)
-	if err != nil {
+	if err := s.repo.Update(ctx, id, changes); err != nil {
 		return nil, err
 	}
-
-	return project, nil
-}
-
-func (s *service) DeleteProject(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	return s.repo.GetByID(ctx, id)
 }
@@ -0,0 +1,529 @@
+package project
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/geospatial/geometry"
+	pkggeojson "carbon-scribe/project-portal/project-portal-backend/pkg/geojson"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Bulk transfer formats.
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatGeoJSON = "geojson"
+)
+
+const (
+	// MaxImportRows caps a single import so it fits in one transaction.
+	MaxImportRows = 1000
+	// MaxExportRows caps a single export; narrow the filter for more.
+	MaxExportRows = 10000
+
+	xlsxSheet = "Projects"
+)
+
+var (
+	// ErrImportInvalid is returned with a report when any row or boundary
+	// failed validation. Nothing is written.
+	ErrImportInvalid = errors.New("import contains invalid rows")
+	// ErrUnsupportedFormat is returned for formats other than csv, xlsx and
+	// geojson (export only).
+	ErrUnsupportedFormat = errors.New("unsupported format")
+)
+
+// importColumns are read by ImportProjects and written first by
+// ExportProjects, in this order. ref links a row to its boundary feature.
+var importColumns = []string{"ref", "name", "type", "location", "area", "start_date", "farmers", "progress", "icon", "methodology_code"}
+
+// exportOnlyColumns are written by ExportProjects and ignored on import.
+var exportOnlyColumns = []string{"status", "carbon_credits", "created_at"}
+
+// BoundaryStore reads and writes project boundaries for bulk import and
+// export. It is implemented by the geospatial service.
+type BoundaryStore interface {
+	ProjectBoundaries(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID]json.RawMessage, error)
+	ImportProjectBoundary(ctx context.Context, tx *gorm.DB, projectID uuid.UUID, geojson json.RawMessage, sourceFile string) error
+}
+
+// ImportRequest is a parsed POST /projects/import upload.
+type ImportRequest struct {
+	Format       string    // csv or xlsx
+	File         io.Reader // project rows with a header row
+	Boundaries   io.Reader // optional GeoJSON FeatureCollection
+	BoundaryName string    // file name recorded as the boundary source
+	DryRun       bool
+}
+
+// ImportReport describes the outcome of an import, row by row.
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Valid     int               `json:"valid"`
+	Invalid   int               `json:"invalid"`
+	Rows      []ImportRowResult `json:"rows"`
+	Errors    []string          `json:"errors,omitempty"` // file-level problems
+}
+
+// ImportRowResult is the validation result for one row. Row is the line or
+// sheet row number, counting the header as row 1.
+type ImportRowResult struct {
+	Row       int        `json:"row"`
+	Ref       string     `json:"ref,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Boundary  bool       `json:"boundary"`
+	Errors    []string   `json:"errors,omitempty"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+}
+
+type importRow struct {
+	result   *ImportRowResult
+	req      ProjectCreateRequest
+	method   string
+	boundary json.RawMessage
+}
+
+// ImportProjects validates every row against the ProjectCreateRequest rules
+// and every boundary against the geometry validator. Unless DryRun is set and
+// all rows are valid, it creates the projects, their owner memberships and
+// boundaries in one transaction. Invalid input returns the report together
+// with ErrImportInvalid.
+func (s *service) ImportProjects(ctx context.Context, req *ImportRequest, ownerID string) (*ImportReport, error) {
+	records, err := readRecords(req.Format, req.File)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: the file has no data rows", ErrImportInvalid)
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrImportInvalid, MaxImportRows)
+	}
+
+	report := &ImportReport{DryRun: req.DryRun}
+	header := indexHeader(records[0])
+	for _, col := range []string{"name", "type", "location", "area"} {
+		if _, ok := header[col]; !ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("missing required column %q", col))
+		}
+	}
+	if len(report.Errors) > 0 {
+		return report, ErrImportInvalid
+	}
+
+	rows := make([]*importRow, 0, len(records)-1)
+	refs := map[string]*importRow{}
+	for i, rec := range records[1:] {
+		row := parseImportRow(header, rec, i+2)
+		key := row.key()
+		if prev, dup := refs[key]; dup && key != "" {
+			row.fail("duplicate ref %q (also row %d)", key, prev.result.Row)
+		} else {
+			refs[key] = row
+		}
+		rows = append(rows, row)
+	}
+
+	if req.Boundaries != nil {
+		report.Errors = append(report.Errors, attachBoundaries(req.Boundaries, refs)...)
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row.result)
+		if len(row.result.Errors) > 0 {
+			report.Invalid++
+		} else {
+			report.Valid++
+		}
+	}
+	report.Total = len(rows)
+	if report.Invalid > 0 || len(report.Errors) > 0 {
+		return report, ErrImportInvalid
+	}
+	if req.DryRun {
+		return report, nil
+	}
+	if s.boundaryStore == nil && req.Boundaries != nil {
+		return nil, errors.New("boundary import unavailable")
+	}
+
+	now := time.Now()
+	projects := make([]*Project, len(rows))
+	for i, row := range rows {
+		projects[i] = newProject(&row.req, now)
+		projects[i].ID = uuid.New()
+		projects[i].MethodologyCode = row.method
+		if row.req.StartDate != "" {
+			projects[i].StartDate, _ = time.Parse("2006-01-02", row.req.StartDate)
+		}
+	}
+	err = s.repo.CreateMany(ctx, projects, func(tx *gorm.DB, i int) error {
+		p := projects[i]
+		if err := s.members.AddOwnerTx(ctx, tx, p.ID.String(), ownerID); err != nil {
+			return fmt.Errorf("row %d: assigning owner: %w", rows[i].result.Row, err)
+		}
+		if rows[i].boundary != nil {
+			if err := s.boundaryStore.ImportProjectBoundary(ctx, tx, p.ID, rows[i].boundary, req.BoundaryName); err != nil {
+				return fmt.Errorf("row %d: storing boundary: %w", rows[i].result.Row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range report.Rows {
+		id := projects[i].ID
+		report.Rows[i].ProjectID = &id
+	}
+	report.Committed = true
+	return report, nil
+}
+
+func (r *importRow) key() string {
+	if r.result.Ref != "" {
+		return r.result.Ref
+	}
+	return r.result.Name
+}
+
+func (r *importRow) fail(format string, args ...any) {
+	r.result.Errors = append(r.result.Errors, fmt.Sprintf(format, args...))
+}
+
+// parseImportRow converts one record and applies the create-request rules.
+func parseImportRow(header map[string]int, rec []string, line int) *importRow {
+	get := func(col string) string {
+		if i, ok := header[col]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	row := &importRow{result: &ImportRowResult{Row: line, Ref: get("ref"), Name: get("name")}}
+	row.req = ProjectCreateRequest{
+		Name:      get("name"),
+		Type:      get("type"),
+		Location:  get("location"),
+		StartDate: get("start_date"),
+		Icon:      get("icon"),
+	}
+	if v := get("area"); v != "" {
+		area, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			row.fail("area: %q is not a number", v)
+		}
+		row.req.Area = area
+	}
+	for col, dst := range map[string]*int{"farmers": &row.req.Farmers, "progress": &row.req.Progress} {
+		if v := get(col); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				row.fail("%s: %q is not a whole number", col, v)
+			}
+			*dst = n
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(&row.req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, fe := range verrs {
+				row.fail("%s: failed %q rule", columnName(fe.Field()), fe.Tag())
+			}
+		} else {
+			row.fail("%v", err)
+		}
+	}
+	if row.req.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", row.req.StartDate); err != nil {
+			row.fail("start_date: use YYYY-MM-DD")
+		}
+	}
+	row.method = get("methodology_code")
+	if err := validateMethodology(row.method); err != nil {
+		row.fail("methodology_code: %v", err)
+	}
+	return row
+}
+
+// attachBoundaries matches FeatureCollection features to rows by
+// properties.ref, falling back to properties.name, and validates each
+// geometry. It returns file-level errors.
+func attachBoundaries(r io.Reader, rows map[string]*importRow) []string {
+	var fc struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return []string{fmt.Sprintf("boundaries: invalid JSON: %v", err)}
+	}
+	if fc.Type != "FeatureCollection" {
+		return []string{"boundaries: expected a GeoJSON FeatureCollection"}
+	}
+
+	var problems []string
+	for i, raw := range fc.Features {
+		var f struct {
+			Properties map[string]any `json:"properties"`
+		}
+		_ = json.Unmarshal(raw, &f)
+		key, _ := f.Properties["ref"].(string)
+		if key == "" {
+			key, _ = f.Properties["name"].(string)
+		}
+		row, ok := rows[key]
+		if key == "" || !ok {
+			problems = append(problems, fmt.Sprintf("boundaries: feature %d has no matching row (set properties.ref or properties.name)", i))
+			continue
+		}
+		if row.boundary != nil {
+			row.fail("more than one boundary feature")
+			continue
+		}
+		if err := pkggeojson.ValidateRFC7946(raw); err != nil {
+			row.fail("boundary: %v", err)
+			continue
+		}
+		if err := geometry.ValidateGeoJSON(raw); err != nil {
+			row.fail("boundary: %v", err)
+			continue
+		}
+		row.boundary = geometry.ExtractGeometry(raw)
+		row.result.Boundary = true
+	}
+	return problems
+}
+
+// ExportProjects writes every project matching the filter in the given
+// format. CSV and XLSX use the import columns followed by read-only ones;
+// GeoJSON writes a FeatureCollection of boundaries keyed by properties.ref,
+// so exports can be imported again.
+func (s *service) ExportProjects(ctx context.Context, filter *Filter, format string, w io.Writer) error {
+	if format != FormatCSV && format != FormatXLSX && format != FormatGeoJSON {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	filter.Cursor = ""
+	if err := filter.Normalize(); err != nil {
+		return err
+	}
+	filter.Limit = 100
+
+	var projects []Project
+	for {
+		page, err := s.repo.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if page.Total > MaxExportRows {
+			return fmt.Errorf("%w: export is limited to %d projects, narrow the filter", ErrInvalidFilter, MaxExportRows)
+		}
+		projects = append(projects, page.Projects...)
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, projects)
+	case FormatXLSX:
+		return writeXLSX(w, projects)
+	default:
+		return s.writeGeoJSON(ctx, w, projects)
+	}
+}
+
+func exportHeader() []string {
+	return append(append([]string{}, importColumns...), exportOnlyColumns...)
+}
+
+func exportRecord(p *Project) []string {
+	start := ""
+	if !p.StartDate.IsZero() {
+		start = p.StartDate.Format("2006-01-02")
+	}
+	return []string{
+		p.ID.String(),
+		p.Name,
+		p.Type,
+		p.Location,
+		strconv.FormatFloat(p.Area, 'f', -1, 64),
+		start,
+		strconv.Itoa(p.Farmers),
+		strconv.Itoa(p.Progress),
+		p.Icon,
+		p.MethodologyCode,
+		p.Status,
+		strconv.Itoa(p.CarbonCredits),
+		p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func writeCSV(w io.Writer, projects []Project) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportHeader()); err != nil {
+		return err
+	}
+	for i := range projects {
+		if err := cw.Write(exportRecord(&projects[i])); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeXLSX(w io.Writer, projects []Project) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSheet); err != nil {
+		return err
+	}
+	write := func(row int, values []string) error {
+		cells := make([]any, len(values))
+		for i, v := range values {
+			cells[i] = v
+		}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		return f.SetSheetRow(xlsxSheet, cell, &cells)
+	}
+	if err := write(1, exportHeader()); err != nil {
+		return err
+	}
+	for i := range projects {
+		if err := write(i+2, exportRecord(&projects[i])); err != nil {
+			return err
+		}
+	}
+	_, err := f.WriteTo(w)
+	return err
+}
+
+func (s *service) writeGeoJSON(ctx context.Context, w io.Writer, projects []Project) error {
+	if s.boundaryStore == nil {
+		return errors.New("boundary export unavailable")
+	}
+	ids := make([]uuid.UUID, len(projects))
+	for i := range projects {
+		ids[i] = projects[i].ID
+	}
+	boundaries, err := s.boundaryStore.ProjectBoundaries(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	type feature struct {
+		Type       string            `json:"type"`
+		Geometry   json.RawMessage   `json:"geometry"`
+		Properties map[string]string `json:"properties"`
+	}
+	out := struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: []feature{}}
+
+	header := exportHeader()
+	for i := range projects {
+		g, ok := boundaries[projects[i].ID]
+		if !ok {
+			continue
+		}
+		props := make(map[string]string, len(header))
+		for j, v := range exportRecord(&projects[i]) {
+			props[header[j]] = v
+		}
+		out.Features = append(out.Features, feature{Type: "Feature", Geometry: g, Properties: props})
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// readRecords reads all rows, header included, from a CSV or XLSX file. For
+// XLSX the first sheet is used.
+func readRecords(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		records, err := cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportInvalid, err)
+		}
+		if len(records) > 0 && len(records[0]) > 0 {
+			records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+		}
+		return records, nil
+	case FormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportInvalid, err)
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func indexHeader(row []string) map[string]int {
+	idx := make(map[string]int, len(row))
+	for i, col := range row {
+		idx[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	return idx
+}
+
+// columnName maps a ProjectCreateRequest field to its column.
+func columnName(field string) string {
+	switch field {
+	case "StartDate":
+		return "start_date"
+	default:
+		return strings.ToLower(field)
+	}
+}
+
+// FormatFromFilename picks the import format from a file extension.
+func FormatFromFilename(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".xlsx"):
+		return FormatXLSX
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV
+	default:
+		return ""
+	}
+}
+
+// ContentType returns the MIME type for an export format.
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	default:
+		return "text/csv"
+	}
+}
@@ -0,0 +1,94 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type stubMembers struct{ owners []string }
+
+func (m *stubMembers) AddOwner(context.Context, string, string) error { return nil }
+func (m *stubMembers) AddOwnerTx(_ context.Context, _ *gorm.DB, projectID, _ string) error {
+	m.owners = append(m.owners, projectID)
+	return nil
+}
+func (m *stubMembers) ListUserProjectIDs(context.Context, string) ([]string, error) { return nil, nil }
+
+const importCSV = `ref,name,type,location,area,start_date,farmers,progress,methodology_code
+a,Mangrove North,blue_carbon,Kenya,120.5,2024-01-15,40,10,
+b,,forestry,Peru,abc,15/01/2024,,,NOT-A-CODE
+a,Duplicate,forestry,Peru,10,,,,
+`
+
+func newImportFixture() (*service, *memRepo, *stubMembers) {
+	repo := &memRepo{projects: map[uuid.UUID]*Project{}}
+	members := &stubMembers{}
+	return NewService(repo, Dependencies{Members: members}).(*service), repo, members
+}
+
+func TestImportReportsRowErrors(t *testing.T) {
+	svc, repo, _ := newImportFixture()
+	report, err := svc.ImportProjects(context.Background(), &ImportRequest{Format: FormatCSV, File: strings.NewReader(importCSV)}, "user-1")
+	if !errors.Is(err, ErrImportInvalid) {
+		t.Fatalf("expected ErrImportInvalid, got %v", err)
+	}
+	if report.Total != 3 || report.Valid != 1 || report.Invalid != 2 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	// name, area, start_date and methodology on row 3.
+	if got := len(report.Rows[1].Errors); got < 4 {
+		t.Fatalf("row 3 errors = %v", report.Rows[1].Errors)
+	}
+	if !strings.Contains(strings.Join(report.Rows[2].Errors, ";"), "duplicate ref") {
+		t.Fatalf("row 4 errors = %v", report.Rows[2].Errors)
+	}
+	if len(repo.projects) != 0 {
+		t.Fatal("nothing should be written when a row is invalid")
+	}
+}
+
+func TestImportDryRunThenCommit(t *testing.T) {
+	svc, repo, members := newImportFixture()
+	valid := strings.Join(strings.Split(importCSV, "\n")[:2], "\n")
+
+	report, err := svc.ImportProjects(context.Background(), &ImportRequest{Format: FormatCSV, File: strings.NewReader(valid), DryRun: true}, "user-1")
+	if err != nil || report.Committed || len(repo.projects) != 0 {
+		t.Fatalf("dry run wrote data or failed: %v %+v", err, report)
+	}
+
+	report, err = svc.ImportProjects(context.Background(), &ImportRequest{Format: FormatCSV, File: strings.NewReader(valid)}, "user-1")
+	if err != nil || !report.Committed {
+		t.Fatalf("commit failed: %v %+v", err, report)
+	}
+	id := *report.Rows[0].ProjectID
+	p := repo.projects[id]
+	if p == nil || p.Status != StatusDraft || p.Area != 120.5 || p.StartDate.Format("2006-01-02") != "2024-01-15" {
+		t.Fatalf("unexpected project %+v", p)
+	}
+	if len(members.owners) != 1 || members.owners[0] != id.String() {
+		t.Fatalf("owner not assigned: %v", members.owners)
+	}
+}
+
+func TestExportRoundTrips(t *testing.T) {
+	p := Project{ID: uuid.New(), Name: "Mangrove North", Type: "blue_carbon", Location: "Kenya", Area: 120.5, Status: StatusDraft}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []Project{p}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, _, _ := newImportFixture()
+	report, err := svc.ImportProjects(context.Background(), &ImportRequest{Format: FormatCSV, File: &buf, DryRun: true}, "user-1")
+	if err != nil {
+		t.Fatalf("exported CSV failed validation: %v %+v", err, report)
+	}
+	if report.Rows[0].Ref != p.ID.String() || report.Rows[0].Name != p.Name {
+		t.Fatalf("unexpected row %+v", report.Rows[0])
+	}
+}
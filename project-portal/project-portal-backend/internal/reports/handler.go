@@ -5,6 +5,9 @@ import (
 	"strconv"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -70,24 +73,12 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
-// getUserID extracts the user ID from the request context
-// In production, this would come from JWT middleware
+// getUserID extracts the user ID from the verified access token set by the
+// auth middleware. Returns a nil UUID for unauthenticated requests.
 func getUserID(c *gin.Context) uuid.UUID {
-	// Try to get from context (set by auth middleware)
-	if userID, exists := c.Get("user_id"); exists {
-		if uid, ok := userID.(uuid.UUID); ok {
-			return uid
-		}
+	if uid := middleware.CurrentUserUUID(c); uid != nil {
+		return *uid
 	}
-
-	// Fallback: try to get from header
-	if userIDStr := c.GetHeader("X-User-ID"); userIDStr != "" {
-		if uid, err := uuid.Parse(userIDStr); err == nil {
-			return uid
-		}
-	}
-
-	// Default to a nil UUID (should handle this as unauthorized in production)
 	return uuid.Nil
 }
 
@@ -483,6 +474,9 @@ func (h *Handler) GetDatasets(c *gin.Context) {
 // @Description Get pre-aggregated summary metrics for dashboard widgets
 // @Tags reports
 // @Produce json
+// @Param status query string false "Project statuses (comma-separated)"
+// @Param type query string false "Project types (comma-separated)"
+// @Param location query string false "Project location contains"
 // @Success 200 {object} DashboardSummary
 // @Router /api/v1/reports/dashboard/summary [get]
 func (h *Handler) GetDashboardSummary(c *gin.Context) {
@@ -492,7 +486,22 @@ func (h *Handler) GetDashboardSummary(c *gin.Context) {
 		userIDPtr = &userID
 	}
 
-	summary, err := h.service.GetDashboardSummary(c.Request.Context(), userIDPtr)
+	// Project figures accept the same filters as GET /projects and are
+	// limited to the caller's own projects unless they are an admin.
+	var filter project.Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := filter.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.IsPlatformAdmin(c) {
+		filter.MemberID = userID.String()
+	}
+
+	summary, err := h.service.GetDashboardSummary(c.Request.Context(), userIDPtr, &filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,33 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PurgeProjectData removes dashboard widgets and report definitions pinned
+// to a single project by an equality filter on project_id, along with the
+// definitions' schedules. Reports spanning several projects are kept. It
+// implements project.DataPurger.
+func (s *service) PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error) {
+	tx = tx.WithContext(ctx)
+	pinned, err := json.Marshal([]FilterConfig{{Field: "project_id", Operator: "eq", Value: projectID.String()}})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("config->'filters' @> ?::jsonb", string(pinned)).Delete(&DashboardWidget{}).Error; err != nil {
+		return nil, err
+	}
+	defs := tx.Model(&ReportDefinition{}).Select("id").Where("config->'filters' @> ?::jsonb", string(pinned))
+	if err := tx.Where("report_definition_id IN (?)", defs).Delete(&ReportSchedule{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("config->'filters' @> ?::jsonb", string(pinned)).Delete(&ReportDefinition{}).Error; err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
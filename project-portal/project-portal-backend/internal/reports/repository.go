@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/datatypes"
@@ -56,7 +58,7 @@ type Repository interface {
 	UpdateWidgetPositions(ctx context.Context, userID uuid.UUID, positions map[uuid.UUID]int) error
 
 	// Dashboard Data
-	GetDashboardSummary(ctx context.Context, userID *uuid.UUID) (*DashboardSummary, error)
+	GetDashboardSummary(ctx context.Context, userID *uuid.UUID, filter *project.Filter) (*DashboardSummary, error)
 	GetTimeSeriesData(ctx context.Context, metric string, startTime, endTime time.Time, interval string) ([]TimeSeriesPoint, error)
 
 	// Dynamic Query Execution
@@ -485,16 +487,21 @@ func (r *repository) UpdateWidgetPositions(ctx context.Context, userID uuid.UUID
 
 // ========== Dashboard Data ==========
 
-func (r *repository) GetDashboardSummary(ctx context.Context, userID *uuid.UUID) (*DashboardSummary, error) {
+func (r *repository) GetDashboardSummary(ctx context.Context, userID *uuid.UUID, filter *project.Filter) (*DashboardSummary, error) {
 	summary := &DashboardSummary{
 		PerformanceMetrics: make(map[string]MetricSummary),
 		TimeSeriesData:     make(map[string][]TimeSeriesPoint),
 	}
 
-	// Get total projects count
-	var projectCount int64
-	r.db.WithContext(ctx).Table("projects").Count(&projectCount)
-	summary.TotalProjects = int(projectCount)
+	// Get project count and credits for the filtered project set
+	var projects struct {
+		Count   int64
+		Credits float64
+	}
+	filter.Where(r.db.WithContext(ctx).Table("projects")).
+		Select("COUNT(*) AS count, COALESCE(SUM(projects.carbon_credits), 0) AS credits").
+		Scan(&projects)
+	summary.TotalProjects = int(projects.Count)
 
 	// Get total credits (assuming a carbon_credits table exists)
 	var totalCredits struct {
@@ -530,6 +537,12 @@ func (r *repository) GetDashboardSummary(ctx context.Context, userID *uuid.UUID)
 		Trend:         "stable",
 	}
 
+	summary.PerformanceMetrics["project_credits"] = MetricSummary{
+		Value:  projects.Credits,
+		Period: "all",
+		Trend:  "stable",
+	}
+
 	summary.PerformanceMetrics["revenue"] = MetricSummary{
 		Value:         summary.TotalRevenue,
 		Change:        0,
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"time"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
+
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Service defines the interface for reporting business logic
@@ -42,7 +45,7 @@ type Service interface {
 	UpdateBenchmark(ctx context.Context, datasetID uuid.UUID, dataset *BenchmarkDataset) (*BenchmarkDataset, error)
 
 	// Dashboard
-	GetDashboardSummary(ctx context.Context, userID *uuid.UUID) (*DashboardSummary, error)
+	GetDashboardSummary(ctx context.Context, userID *uuid.UUID, filter *project.Filter) (*DashboardSummary, error)
 	GetTimeSeriesData(ctx context.Context, metric string, startTime, endTime time.Time, interval string) ([]TimeSeriesPoint, error)
 	GetWidgets(ctx context.Context, userID uuid.UUID, section string) ([]DashboardWidget, error)
 	SaveWidget(ctx context.Context, widget *DashboardWidget) (*DashboardWidget, error)
@@ -50,6 +53,9 @@ type Service interface {
 
 	// Datasets
 	GetAvailableDatasets(ctx context.Context) ([]DatasetMetadata, error)
+
+	// Project purge
+	PurgeProjectData(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) (func(context.Context), error)
 }
 
 // service implements the Service interface
@@ -624,8 +630,8 @@ func (s *service) UpdateBenchmark(ctx context.Context, datasetID uuid.UUID, data
 
 // ========== Dashboard ==========
 
-func (s *service) GetDashboardSummary(ctx context.Context, userID *uuid.UUID) (*DashboardSummary, error) {
-	return s.repo.GetDashboardSummary(ctx, userID)
+func (s *service) GetDashboardSummary(ctx context.Context, userID *uuid.UUID, filter *project.Filter) (*DashboardSummary, error) {
+	return s.repo.GetDashboardSummary(ctx, userID, filter)
 }
 
 func (s *service) GetTimeSeriesData(ctx context.Context, metric string, startTime, endTime time.Time, interval string) ([]TimeSeriesPoint, error) {
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests/datasource"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+
+	"gorm.io/gorm"
+)
+
+// Event is a stored search query. UserID is empty for anonymous searches.
+type Event struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       *string   `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Query        string    `gorm:"type:text" json:"query"`
+	ResultsCount int64     `json:"results_count"`
+	TookMs       int64     `json:"took_ms"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+func (Event) TableName() string { return "search_analytics_events" }
+
+type userKey struct{}
+
+// WithUser attaches the searching user to ctx so trackers can attribute
+// the query.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// DBTracker stores search events in the database.
+type DBTracker struct {
+	db *gorm.DB
+}
+
+// NewDBTracker creates a tracker that stores events in db.
+func NewDBTracker(db *gorm.DB) *DBTracker {
+	return &DBTracker{db: db}
+}
+
+// TrackSearch stores the search event. Failures are logged, never returned
+// to the search.
+func (t *DBTracker) TrackSearch(ctx context.Context, query string, resultsCount int64, took int64) {
+	event := &Event{Query: query, ResultsCount: resultsCount, TookMs: took}
+	if id, ok := ctx.Value(userKey{}).(string); ok && id != "" {
+		event.UserID = &id
+	}
+	if err := t.db.WithContext(context.WithoutCancel(ctx)).Create(event).Error; err != nil {
+		log.Printf("WARNING: storing search analytics event: %v", err)
+	}
+}
+
+// NewPrivacySource returns the data source privacy requests use to find,
+// export and erase a user's search history. Erasure deletes the events.
+func NewPrivacySource(db *gorm.DB) *datasource.Source {
+	return datasource.New("search_analytics", db,
+		datasource.Table{Name: Event{}.TableName(), Category: requests.CategorySystemLogs, Match: "user_id = ?"},
+	)
+}
+
+// RetentionTargets returns the search history tables retention policies act
+// on.
+func RetentionTargets() []retention.Target {
+	return []retention.Target{
+		{Table: Event{}.TableName(), Category: requests.CategorySystemLogs, UserColumn: "user_id",
+			Anonymize: map[string]any{"user_id": nil}, Anonymized: "user_id IS NULL"},
+	}
+}
@@ -1,21 +1,37 @@
 package search
 
 import (
+	"context"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
+	"carbon-scribe/project-portal/project-portal-backend/internal/search/analytics"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ProjectMembers lists the projects a user belongs to or owns. Searches are
+// limited to the caller's projects.
+type ProjectMembers interface {
+	ListUserProjectIDs(ctx context.Context, userID string) ([]string, error)
+	ListOwnedProjectIDs(ctx context.Context, userID string) ([]string, error)
+}
+
 // Handler handles HTTP requests for search
 type Handler struct {
 	service Service
+	members ProjectMembers
 }
 
 // NewHandler creates a new search handler
-func NewHandler(service Service) *Handler {
+func NewHandler(service Service, members ProjectMembers) *Handler {
 	return &Handler{
 		service: service,
+		members: members,
 	}
 }
 
@@ -25,7 +41,8 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	{
 		search.GET("", h.Search)
 		search.GET("/nearby", h.SearchNearby)
-		search.POST("/index/sync", h.SyncIndex)
+		search.GET("/documents", h.SearchDocuments)
+		search.POST("/index/sync", middleware.RequirePlatformRole(), h.SyncIndex)
 	}
 }
 
@@ -56,13 +73,17 @@ func (h *Handler) SearchNearby(c *gin.Context) {
 	req.Query = c.Query("q")
 	req.Filters = make(map[string]interface{})
 	// (Add common filters parsing if needed, sharing logic with Search would be good)
+	req.Project = &project.Filter{}
+	if !h.scopeToCaller(c, req.Project) {
+		return
+	}
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	req.Page = page
 	req.PageSize = pageSize
 
-	resp, err := h.service.SearchNearby(c.Request.Context(), req, lat, lon, dist)
+	resp, err := h.service.SearchNearby(searchContext(c), req, lat, lon, dist)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -90,11 +111,26 @@ func (h *Handler) Search(c *gin.Context) {
 	if projectType := c.Query("project_type"); projectType != "" {
 		filters["project_type"] = projectType
 	}
-	if status := c.Query("status"); status != "" {
-		filters["status"] = status
-	}
 	req.Filters = filters
 
+	// Status, type, area, credit and date filters use the same parameters
+	// as GET /projects.
+	var projectFilter project.Filter
+	if err := c.ShouldBindQuery(&projectFilter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Sorting and paging stay with the search request.
+	projectFilter.SortBy, projectFilter.SortOrder, projectFilter.Cursor = "", "", ""
+	if err := projectFilter.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.scopeToCaller(c, &projectFilter) {
+		return
+	}
+	req.Project = &projectFilter
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
@@ -112,7 +148,69 @@ func (h *Handler) Search(c *gin.Context) {
 	req.PageSize = pageSize
 
 	// Execute search
-	resp, err := h.service.SearchProjects(c.Request.Context(), req)
+	resp, err := h.service.SearchProjects(searchContext(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SearchDocuments handles full-text search over document contents:
+// GET /search/documents?q=&project_id=&document_type=&file_format=. Results
+// are limited to the caller's projects, or to project_id when given.
+func (h *Handler) SearchDocuments(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q parameter is required"})
+		return
+	}
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var projectIDs []string
+	if middleware.IsPlatformAdmin(c) && c.Query("project_id") != "" {
+		projectIDs = []string{c.Query("project_id")}
+	} else {
+		ids, err := h.members.ListUserProjectIDs(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		projectIDs = ids
+		if pid := c.Query("project_id"); pid != "" {
+			if !slices.Contains(ids, pid) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "not a member of this project"})
+				return
+			}
+			projectIDs = []string{pid}
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	resp, err := h.service.SearchDocuments(searchContext(c), DocumentSearchRequest{
+		Query:        q,
+		ProjectIDs:   projectIDs,
+		DocumentType: c.Query("document_type"),
+		FileFormat:   strings.ToUpper(c.Query("file_format")),
+		Page:         page,
+		PageSize:     pageSize,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -130,3 +228,48 @@ func (h *Handler) SyncIndex(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "index sync triggered"})
 }
+
+// scopeToCaller sets f.ProjectIDs to the projects the caller may see,
+// narrowed to those owned by f.OwnerID and those f.MemberID belongs to, the
+// way GET /projects filters. Platform admins see every project. It writes
+// the error response and returns false on failure.
+func (h *Handler) scopeToCaller(c *gin.Context, f *project.Filter) bool {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return false
+	}
+	ctx := c.Request.Context()
+	var scope []string
+	restrict := func(ids []string, err error) bool {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		if scope == nil {
+			scope = append([]string{}, ids...)
+		} else {
+			scope = slices.DeleteFunc(scope, func(id string) bool { return !slices.Contains(ids, id) })
+		}
+		return true
+	}
+	if !middleware.IsPlatformAdmin(c) && !restrict(h.members.ListUserProjectIDs(ctx, userID)) {
+		return false
+	}
+	if f.MemberID != "" && !restrict(h.members.ListUserProjectIDs(ctx, f.MemberID)) {
+		return false
+	}
+	if f.OwnerID != "" && !restrict(h.members.ListOwnedProjectIDs(ctx, f.OwnerID)) {
+		return false
+	}
+	f.ProjectIDs = scope
+	return true
+}
+
+// searchContext attributes the search to the caller for analytics.
+func searchContext(c *gin.Context) context.Context {
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		return analytics.WithUser(c.Request.Context(), userID)
+	}
+	return c.Request.Context()
+}
@@ -33,3 +33,15 @@ func (i *Indexer) BulkIndexProjects(ctx context.Context, projects []*ProjectDocu
 	}
 	return nil
 }
+
+// IndexDocumentText replaces the indexed text of a document with segments,
+// one per page or sheet.
+func (i *Indexer) IndexDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error {
+	log.Printf("Indexing %d text segment(s) of document: %s", len(segments), documentID)
+	return i.repo.ReplaceDocumentText(ctx, documentID, segments)
+}
+
+// DeleteDocumentText removes the indexed text of a document.
+func (i *Indexer) DeleteDocumentText(ctx context.Context, documentID string) error {
+	return i.repo.DeleteDocumentText(ctx, documentID)
+}
@@ -10,6 +10,12 @@ type mockRepo struct{}
 func (m *mockRepo) IndexProject(ctx context.Context, project *ProjectDocument) error {
 	return nil
 }
+func (m *mockRepo) ReplaceDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error {
+	return nil
+}
+func (m *mockRepo) DeleteDocumentText(ctx context.Context, documentID string) error {
+	return nil
+}
 func (m *mockRepo) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error) {
 	return nil, nil
 }
@@ -2,6 +2,8 @@ package search
 
 import (
 	"time"
+
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
 )
 
 // SearchRequest represents the search parameters
@@ -12,6 +14,8 @@ type SearchRequest struct {
 	SortOrder string                 `json:"sort_order" form:"sort_order"` // asc or desc
 	Page      int                    `json:"page" form:"page"`
 	PageSize  int                    `json:"page_size" form:"page_size"`
+	// Project applies the shared project list filter on top of Filters.
+	Project *project.Filter `json:"project,omitempty" form:"-"`
 }
 
 // SearchResponse represents the search results
@@ -60,17 +64,58 @@ type ProjectDocument struct {
 	VerificationScore float64     `json:"verification_score"`
 }
 
-// DocumentDocument represents a file/document for indexing
+// DocumentDocument represents a file/document for indexing. Each page of a
+// PDF or DOCX and each sheet of an XLSX is indexed separately so a hit
+// points at where the match occurred.
 type DocumentDocument struct {
-	EntityID     string    `json:"entity_id"`
-	EntityType   string    `json:"entity_type"` // "document"
-	Title        string    `json:"title"`
-	Content      string    `json:"content"` // Extracted text content
-	ProjectID    string    `json:"project_id"`
-	DocumentType string    `json:"document_type"`
-	FileFormat   string    `json:"file_format"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	EntityID     string            `json:"entity_id"`
+	EntityType   string            `json:"entity_type"` // "document"
+	Title        string            `json:"title"`
+	Content      string            `json:"content"` // Extracted text content
+	ProjectID    string            `json:"project_id"`
+	DocumentType string            `json:"document_type"`
+	FileFormat   string            `json:"file_format"`
+	Version      int               `json:"version"`
+	Page         int               `json:"page,omitempty"`
+	Sheet        string            `json:"sheet,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"` // title, author, subject, keywords from the file
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// DocumentSearchRequest is a full-text search over document contents.
+// ProjectIDs restricts results to the projects the caller may read.
+type DocumentSearchRequest struct {
+	Query        string   `json:"query"`
+	ProjectIDs   []string `json:"project_ids"`
+	DocumentType string   `json:"document_type,omitempty"`
+	FileFormat   string   `json:"file_format,omitempty"`
+	Page         int      `json:"page"`
+	PageSize     int      `json:"page_size"`
+}
+
+// DocumentHit is a page or sheet matching a document search.
+type DocumentHit struct {
+	DocumentID   string   `json:"document_id"`
+	ProjectID    string   `json:"project_id"`
+	Title        string   `json:"title"`
+	DocumentType string   `json:"document_type"`
+	FileFormat   string   `json:"file_format"`
+	Version      int      `json:"version"`
+	Page         int      `json:"page,omitempty"`
+	Sheet        string   `json:"sheet,omitempty"`
+	Score        float64  `json:"score"`
+	Highlights   []string `json:"highlights,omitempty"`
+	URL          string   `json:"url"` // document metadata endpoint
+}
+
+// DocumentSearchResponse lists matching pages and sheets.
+type DocumentSearchResponse struct {
+	Hits     []DocumentHit `json:"hits"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Took     int64         `json:"took_ms"`
 }
 
 // GeoPoint represents a geographic coordinate
@@ -116,3 +161,31 @@ const ProjectIndexMapping = `
 	}
 }
 `
+
+// DocumentIndexName holds the extracted text of uploaded documents.
+const DocumentIndexName = "documents"
+const DocumentIndexMapping = `
+{
+	"mappings": {
+		"properties": {
+			"entity_id": { "type": "keyword" },
+			"entity_type": { "type": "keyword" },
+			"title": {
+				"type": "text",
+				"analyzer": "english",
+				"fields": { "keyword": { "type": "keyword" } }
+			},
+			"content": { "type": "text", "analyzer": "english" },
+			"project_id": { "type": "keyword" },
+			"document_type": { "type": "keyword" },
+			"file_format": { "type": "keyword" },
+			"version": { "type": "integer" },
+			"page": { "type": "integer" },
+			"sheet": { "type": "keyword" },
+			"metadata": { "type": "object", "dynamic": true },
+			"created_at": { "type": "date" },
+			"updated_at": { "type": "date" }
+		}
+	}
+}
+`
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/project"
+)
+
+// ProjectFilterClauses translates a project list filter into Elasticsearch
+// filter clauses over ProjectDocument fields, so search results match the
+// project set GET /projects returns for the same parameters.
+//
+// The index does not store memberships or archive state: OwnerID, MemberID
+// and Archived are ignored, and callers must resolve owners and members into
+// ProjectIDs. Full-text is driven by SearchRequest.Query, and start dates are
+// compared by year.
+func ProjectFilterClauses(f *project.Filter) []map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	var clauses []map[string]interface{}
+
+	if f.ProjectIDs != nil {
+		clauses = append(clauses, terms("project_id", f.ProjectIDs))
+	}
+	if len(f.Statuses) > 0 {
+		clauses = append(clauses, terms("status", f.Statuses))
+	}
+	if len(f.Types) > 0 {
+		clauses = append(clauses, terms("project_type", f.Types))
+	}
+	if f.Location != "" {
+		clauses = append(clauses, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  f.Location,
+				"fields": []string{"region", "country_code"},
+			},
+		})
+	}
+	if f.AreaMin != nil || f.AreaMax != nil {
+		clauses = append(clauses, rangeClause("area_hectares", f.AreaMin, f.AreaMax))
+	}
+	if f.CreditsMin != nil || f.CreditsMax != nil {
+		clauses = append(clauses, rangeClause("carbon_credits", f.CreditsMin, f.CreditsMax))
+	}
+	if f.StartFrom != nil || f.StartTo != nil {
+		var from, to *int
+		if f.StartFrom != nil {
+			y := f.StartFrom.Year()
+			from = &y
+		}
+		if f.StartTo != nil {
+			y := f.StartTo.Year()
+			to = &y
+		}
+		clauses = append(clauses, rangeClause("start_year", from, to))
+	}
+	return clauses
+}
+
+func terms(field string, values []string) map[string]interface{} {
+	return map[string]interface{}{"terms": map[string]interface{}{field: values}}
+}
+
+// rangeClause builds a range query from optional bounds.
+func rangeClause[T int | float64](field string, gte, lte *T) map[string]interface{} {
+	bounds := map[string]interface{}{}
+	if gte != nil {
+		bounds["gte"] = *gte
+	}
+	if lte != nil {
+		bounds["lte"] = *lte
+	}
+	return map[string]interface{}{"range": map[string]interface{}{field: bounds}}
+}
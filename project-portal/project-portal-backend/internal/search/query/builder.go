@@ -112,6 +112,19 @@ func (b *Builder) WithQuery(query map[string]interface{}) *Builder {
 	return b
 }
 
+// Highlight requests highlighted fragments of the given fields
+func (b *Builder) Highlight(fragmentSize, fragments int, fields ...string) *Builder {
+	f := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		f[field] = map[string]interface{}{
+			"fragment_size":       fragmentSize,
+			"number_of_fragments": fragments,
+		}
+	}
+	b.query["highlight"] = map[string]interface{}{"fields": f}
+	return b
+}
+
 // Aggregate adds an aggregation
 func (b *Builder) Aggregate(name, field string) *Builder {
 	if _, ok := b.query["aggs"]; !ok {
@@ -11,6 +11,8 @@ import (
 // Repository defines the interface for search operations
 type Repository interface {
 	IndexProject(ctx context.Context, project *ProjectDocument) error
+	ReplaceDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error
+	DeleteDocumentText(ctx context.Context, documentID string) error
 	Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error)
 	SetupIndexes(ctx context.Context) error
 }
@@ -32,6 +34,26 @@ func (r *ElasticRepository) IndexProject(ctx context.Context, project *ProjectDo
 	return r.client.IndexDocument(ctx, ProjectIndexName, project.ProjectID, project)
 }
 
+// ReplaceDocumentText removes the indexed text of a document and indexes
+// segments in its place.
+func (r *ElasticRepository) ReplaceDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error {
+	if err := r.DeleteDocumentText(ctx, documentID); err != nil {
+		return err
+	}
+	docs := make(map[string]interface{}, len(segments))
+	for i, seg := range segments {
+		docs[fmt.Sprintf("%s:%d", documentID, i+1)] = seg
+	}
+	return r.client.BulkIndex(ctx, DocumentIndexName, docs)
+}
+
+// DeleteDocumentText removes the indexed text of a document.
+func (r *ElasticRepository) DeleteDocumentText(ctx context.Context, documentID string) error {
+	return r.client.DeleteByQuery(ctx, DocumentIndexName, map[string]interface{}{
+		"term": map[string]interface{}{"entity_id": documentID},
+	})
+}
+
 // Search performs a search query
 func (r *ElasticRepository) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error) {
 	resp, err := r.client.Search(ctx, index, query)
@@ -44,22 +66,30 @@ func (r *ElasticRepository) Search(ctx context.Context, index string, query map[
 
 // SetupIndexes creates necessary indices if they don't exist
 func (r *ElasticRepository) SetupIndexes(ctx context.Context) error {
-	exists, err := r.client.IndexExists(ctx, ProjectIndexName)
-	if err != nil {
-		return fmt.Errorf("failed to check index existence: %w", err)
-	}
+	for name, mappingJSON := range map[string]string{
+		ProjectIndexName:  ProjectIndexMapping,
+		DocumentIndexName: DocumentIndexMapping,
+	} {
+		exists, err := r.client.IndexExists(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check index existence: %w", err)
+		}
 
-	if exists {
-		return nil
-	}
+		if exists {
+			continue
+		}
 
-	// Parse mapping string to map
-	var mapping map[string]interface{}
-	if err := json.Unmarshal([]byte(ProjectIndexMapping), &mapping); err != nil {
-		return fmt.Errorf("invalid mapping json: %w", err)
-	}
+		// Parse mapping string to map
+		var mapping map[string]interface{}
+		if err := json.Unmarshal([]byte(mappingJSON), &mapping); err != nil {
+			return fmt.Errorf("invalid mapping json: %w", err)
+		}
 
-	return r.client.CreateIndex(ctx, ProjectIndexName, mapping)
+		if err := r.client.CreateIndex(ctx, name, mapping); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func parseSearchResponse(raw map[string]interface{}) (*SearchResponse, error) {
@@ -93,11 +123,25 @@ func parseSearchResponse(raw map[string]interface{}) (*SearchResponse, error) {
 		score, _ := hitMap["_score"].(float64)
 		source, _ := hitMap["_source"].(map[string]interface{})
 
+		var highlights map[string][]string
+		if hl, ok := hitMap["highlight"].(map[string]interface{}); ok {
+			highlights = make(map[string][]string, len(hl))
+			for field, frags := range hl {
+				list, _ := frags.([]interface{})
+				for _, f := range list {
+					if s, ok := f.(string); ok {
+						highlights[field] = append(highlights[field], s)
+					}
+				}
+			}
+		}
+
 		results = append(results, SearchHit{
-			ID:     id,
-			Index:  idx,
-			Score:  score,
-			Source: source,
+			ID:         id,
+			Index:      idx,
+			Score:      score,
+			Source:     source,
+			Highlights: highlights,
 		})
 	}
 
@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"carbon-scribe/project-portal/project-portal-backend/internal/search/analytics"
@@ -13,6 +14,9 @@ type Service interface {
 	SearchProjects(ctx context.Context, req SearchRequest) (*SearchResponse, error)
 	SearchNearby(ctx context.Context, req SearchRequest, lat, lon float64, dist string) (*SearchResponse, error)
 	IndexProject(ctx context.Context, project ProjectDocument) error
+	SearchDocuments(ctx context.Context, req DocumentSearchRequest) (*DocumentSearchResponse, error)
+	IndexDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error
+	DeleteDocumentText(ctx context.Context, documentID string) error
 	SyncIndex(ctx context.Context) error
 }
 
@@ -32,6 +36,12 @@ func NewService(repo Repository) *ServiceImpl {
 	}
 }
 
+// SetTracker replaces the analytics tracker, e.g. with one that stores
+// events so they can be included in privacy requests.
+func (s *ServiceImpl) SetTracker(tracker analytics.Tracker) {
+	s.tracker = tracker
+}
+
 // SearchProjects performs a search for projects
 func (s *ServiceImpl) SearchProjects(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
 	startTime := time.Now()
@@ -99,6 +109,10 @@ func (s *ServiceImpl) SearchProjects(ctx context.Context, req SearchRequest) (*S
 		})
 	}
 
+	for _, clause := range ProjectFilterClauses(req.Project) {
+		boolQuery.Filter(clause)
+	}
+
 	qb.WithQuery(boolQuery.Build())
 
 	// Execute search
@@ -133,6 +147,9 @@ func (s *ServiceImpl) SearchNearby(ctx context.Context, req SearchRequest, lat,
 			"term": map[string]interface{}{k: v},
 		})
 	}
+	for _, clause := range ProjectFilterClauses(req.Project) {
+		boolQuery.Filter(clause)
+	}
 
 	// Match all if no text query, otherwise match text
 	if req.Query != "" {
@@ -175,6 +192,78 @@ func (s *ServiceImpl) IndexProject(ctx context.Context, project ProjectDocument)
 	return s.indexer.IndexProject(ctx, &project)
 }
 
+// SearchDocuments performs a full-text search over extracted document text,
+// limited to req.ProjectIDs. Each hit is a page or sheet with highlighted
+// fragments of the matching text.
+func (s *ServiceImpl) SearchDocuments(ctx context.Context, req DocumentSearchRequest) (*DocumentSearchResponse, error) {
+	startTime := time.Now()
+	resp := &DocumentSearchResponse{Hits: []DocumentHit{}, Page: req.Page, PageSize: req.PageSize}
+	if len(req.ProjectIDs) == 0 {
+		return resp, nil
+	}
+
+	qb := query.NewBuilder()
+	qb.From((req.Page-1)*req.PageSize).Size(req.PageSize).Highlight(160, 3, "content")
+
+	boolQuery := query.NewBoolBuilder()
+	boolQuery.Must(map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"query":  req.Query,
+			"fields": []string{"content", "title^2", "metadata.*"},
+		},
+	})
+	boolQuery.Filter(terms("project_id", req.ProjectIDs))
+	if req.DocumentType != "" {
+		boolQuery.Filter(map[string]interface{}{"term": map[string]interface{}{"document_type": req.DocumentType}})
+	}
+	if req.FileFormat != "" {
+		boolQuery.Filter(map[string]interface{}{"term": map[string]interface{}{"file_format": req.FileFormat}})
+	}
+	qb.WithQuery(boolQuery.Build())
+
+	raw, err := s.repo.Search(ctx, DocumentIndexName, qb.Build())
+
+	took := time.Since(startTime).Milliseconds()
+	hits := int64(0)
+	if raw != nil {
+		hits = raw.Total
+	}
+	s.tracker.TrackSearch(ctx, "documents:"+req.Query, hits, took)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Total, resp.Took = raw.Total, raw.Took
+	for _, h := range raw.Hits {
+		hit := DocumentHit{Score: h.Score, Highlights: h.Highlights["content"]}
+		hit.DocumentID, _ = h.Source["entity_id"].(string)
+		hit.ProjectID, _ = h.Source["project_id"].(string)
+		hit.Title, _ = h.Source["title"].(string)
+		hit.DocumentType, _ = h.Source["document_type"].(string)
+		hit.FileFormat, _ = h.Source["file_format"].(string)
+		hit.Sheet, _ = h.Source["sheet"].(string)
+		if v, ok := h.Source["version"].(float64); ok {
+			hit.Version = int(v)
+		}
+		if v, ok := h.Source["page"].(float64); ok {
+			hit.Page = int(v)
+		}
+		hit.URL = fmt.Sprintf("/api/v1/documents/%s", hit.DocumentID)
+		resp.Hits = append(resp.Hits, hit)
+	}
+	return resp, nil
+}
+
+// IndexDocumentText replaces the indexed text of a document
+func (s *ServiceImpl) IndexDocumentText(ctx context.Context, documentID string, segments []*DocumentDocument) error {
+	return s.indexer.IndexDocumentText(ctx, documentID, segments)
+}
+
+// DeleteDocumentText removes a document from the text index
+func (s *ServiceImpl) DeleteDocumentText(ctx context.Context, documentID string) error {
+	return s.indexer.DeleteDocumentText(ctx, documentID)
+}
+
 // SyncIndex triggers a full re-index
 func (s *ServiceImpl) SyncIndex(ctx context.Context) error {
 	return s.repo.SetupIndexes(ctx)
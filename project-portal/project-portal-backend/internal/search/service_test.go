@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// searchRepo records the last query and returns canned hits.
+type searchRepo struct {
+	mockRepo
+	index string
+	query map[string]interface{}
+	resp  *SearchResponse
+}
+
+func (r *searchRepo) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error) {
+	r.index, r.query = index, query
+	return r.resp, nil
+}
+
+func TestSearchDocuments_ScopesAndLinksHits(t *testing.T) {
+	repo := &searchRepo{resp: &SearchResponse{Total: 1, Hits: []SearchHit{{
+		Score: 2.5,
+		Source: map[string]interface{}{
+			"entity_id": "doc-1", "project_id": "p-1", "title": "Monitoring Report",
+			"file_format": "PDF", "version": float64(3), "page": float64(4),
+		},
+		Highlights: map[string][]string{"content": {"baseline <em>emissions</em>"}},
+	}}}}
+	svc := NewService(repo)
+
+	resp, err := svc.SearchDocuments(context.Background(), DocumentSearchRequest{
+		Query: "emissions", ProjectIDs: []string{"p-1", "p-2"}, Page: 2, PageSize: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.index != DocumentIndexName {
+		t.Errorf("searched index %q", repo.index)
+	}
+	q, _ := json.Marshal(repo.query)
+	if !strings.Contains(string(q), `{"terms":{"project_id":["p-1","p-2"]}}`) || !strings.Contains(string(q), `"from":10`) {
+		t.Errorf("query not scoped or paged: %s", q)
+	}
+	if len(resp.Hits) != 1 {
+		t.Fatalf("hits %+v", resp.Hits)
+	}
+	hit := resp.Hits[0]
+	if hit.DocumentID != "doc-1" || hit.Page != 4 || hit.Version != 3 || hit.URL != "/api/v1/documents/doc-1" || len(hit.Highlights) != 1 {
+		t.Errorf("hit %+v", hit)
+	}
+}
+
+func TestSearchDocuments_NoProjects(t *testing.T) {
+	repo := &searchRepo{}
+	resp, err := NewService(repo).SearchDocuments(context.Background(), DocumentSearchRequest{Query: "x", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.query != nil || len(resp.Hits) != 0 {
+		t.Errorf("searched without project scope: %v", repo.query)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -49,39 +51,37 @@ func (h *Handler) RegisterRoutes(v1 *gin.RouterGroup) {
 	}
 }
 
+// authRequired resolves the caller from the verified access token set by the
+// /api/v1 auth middleware.
 func authRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		auth := c.GetHeader("Authorization")
-		if strings.TrimSpace(auth) == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing auth header"})
-			return
-		}
-		userIDHeader := strings.TrimSpace(c.GetHeader("X-User-ID"))
-		if userIDHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing X-User-ID header"})
-			return
-		}
-		uid, err := uuid.Parse(userIDHeader)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid X-User-ID header"})
+		uid := middleware.CurrentUserUUID(c)
+		if uid == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 			return
 		}
-		c.Set("settings_user_id", uid)
+		c.Set("settings_user_id", *uid)
 		c.Next()
 	}
 }
 
+// rolePermissions maps platform roles to the settings permissions they hold.
+// Settings only ever act on the caller's own account, so regular users get
+// full self-service access.
+var rolePermissions = map[string][]string{
+	middleware.PlatformRoleUser:              {"settings:read", "settings:write", "settings:api_keys", "settings:integrations", "settings:billing"},
+	middleware.PlatformRoleComplianceOfficer: {"settings:read", "settings:write", "settings:api_keys"},
+	middleware.PlatformRoleAdmin:             {"*"},
+}
+
 func requirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		perms := splitPermissions(c.GetHeader("X-Permissions"))
-		if len(perms) == 0 {
-			perms = splitPermissions(c.GetHeader("X-Scopes"))
-		}
+		perms := rolePermissions[middleware.CurrentRole(c)]
 		if len(perms) == 0 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permissions"})
 			return
 		}
-		if hasPermission(perms, permission) || hasPermission(perms, "*") || hasPermission(perms, "admin") {
+		if hasPermission(perms, permission) || hasPermission(perms, "*") {
 			c.Next()
 			return
 		}
@@ -0,0 +1,34 @@
+package settings
+
+import (
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/requests/datasource"
+	"carbon-scribe/project-portal/project-portal-backend/internal/compliance/retention"
+
+	"gorm.io/gorm"
+)
+
+// NewPrivacySource returns the data source privacy requests use to find,
+// export and erase a user's settings. Profile, notification, API key and
+// integration rows are deleted; subscriptions and invoices are financial
+// records and are kept. Secrets never appear in exports.
+func NewPrivacySource(db *gorm.DB) *datasource.Source {
+	const byUser = "user_id = ?"
+	return datasource.New("settings", db,
+		datasource.Table{Name: UserProfile{}.TableName(), Category: requests.CategoryUserProfile, Match: byUser},
+		datasource.Table{Name: NotificationPreference{}.TableName(), Category: requests.CategoryUserProfile, Match: byUser},
+		datasource.Table{Name: APIKey{}.TableName(), Category: requests.CategoryUserProfile, Match: byUser, Omit: []string{"key_hash"}},
+		datasource.Table{Name: IntegrationConfiguration{}.TableName(), Category: requests.CategoryUserProfile, Match: byUser, Omit: []string{"config_data", "webhook_secret"}},
+		datasource.Table{Name: Subscription{}.TableName(), Category: requests.CategoryFinancialRecs, Match: byUser},
+		datasource.Table{Name: Invoice{}.TableName(), Category: requests.CategoryFinancialRecs, Match: byUser},
+	)
+}
+
+// RetentionTargets returns the settings tables retention policies act on.
+// Invoices are financial records; they can be archived or deleted once
+// their retention period ends but not anonymized.
+func RetentionTargets() []retention.Target {
+	return []retention.Target{
+		{Table: Invoice{}.TableName(), Category: requests.CategoryFinancialRecs, UserColumn: "user_id"},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"carbon-scribe/project-portal/project-portal-backend/internal/compliance"
+	"carbon-scribe/project-portal/project-portal-backend/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,7 +27,7 @@ func Middleware(service *compliance.Service, serviceName string) gin.HandlerFunc
 			HTTPMethod:  c.Request.Method,
 		}
 
-		if userID := c.GetHeader("X-User-ID"); userID != "" {
+		if userID, ok := middleware.CurrentUserID(c); ok {
 			entry.ActorID = userID
 		}
 
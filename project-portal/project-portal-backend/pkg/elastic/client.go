@@ -127,6 +127,93 @@ func (c *Client) IndexDocument(ctx context.Context, indexName string, docID stri
 	return nil
 }
 
+// BulkIndex indexes docs, keyed by document ID, in one request and
+// refreshes the index so they are searchable on return.
+func (c *Client) BulkIndex(ctx context.Context, indexName string, docs map[string]interface{}) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for id, doc := range docs {
+		meta := map[string]interface{}{"index": map[string]interface{}{"_id": id}}
+		if err := enc.Encode(meta); err != nil {
+			return fmt.Errorf("error encoding bulk action: %w", err)
+		}
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("error marshaling document: %w", err)
+		}
+	}
+
+	req := esapi.BulkRequest{
+		Index:   indexName,
+		Body:    &buf,
+		Refresh: "true",
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("error bulk indexing: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error bulk indexing response: %s", res.String())
+	}
+
+	var r struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("error parsing the response body: %w", err)
+	}
+	if r.Errors {
+		for _, item := range r.Items {
+			for _, result := range item {
+				if len(result.Error) > 0 {
+					return fmt.Errorf("error indexing document %s: %s", result.ID, result.Error)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteByQuery removes every document matching query and refreshes the
+// index.
+func (c *Client) DeleteByQuery(ctx context.Context, indexName string, query interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return fmt.Errorf("error encoding query: %w", err)
+	}
+
+	refresh := true
+	req := esapi.DeleteByQueryRequest{
+		Index:     []string{indexName},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+		Refresh:   &refresh,
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("error deleting documents: %w", err)
+	}
+	defer res.Body.Close()
+
+	// A missing index has nothing to delete.
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting documents response: %s", res.String())
+	}
+
+	return nil
+}
+
 // Search performs a search query
 func (c *Client) Search(ctx context.Context, indexName string, query interface{}) (map[string]interface{}, error) {
 	var buf bytes.Buffer
@@ -29,11 +29,10 @@ func NewVault(key []byte) (*Vault, error) {
 }
 
 func (v *Vault) EncryptString(plaintext string) (string, error) {
-	nonce := make([]byte, v.gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+	ciphertext, err := v.Encrypt([]byte(plaintext))
+	if err != nil {
 		return "", err
 	}
-	ciphertext := v.gcm.Seal(nonce, nonce, []byte(plaintext), nil)
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
@@ -42,14 +41,29 @@ func (v *Vault) DecryptString(ciphertext string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	nonceSize := v.gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-	nonce, payload := data[:nonceSize], data[nonceSize:]
-	plain, err := v.gcm.Open(nil, nonce, payload, nil)
+	plain, err := v.Decrypt(data)
 	if err != nil {
 		return "", err
 	}
 	return string(plain), nil
 }
+
+// Encrypt seals plaintext with AES-GCM. The random nonce is prepended to
+// the result.
+func (v *Vault) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, v.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return v.gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens data produced by Encrypt.
+func (v *Vault) Decrypt(data []byte) ([]byte, error) {
+	nonceSize := v.gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, payload := data[:nonceSize], data[nonceSize:]
+	return v.gcm.Open(nil, nonce, payload, nil)
+}
@@ -25,3 +25,25 @@ func TestVaultEncryptDecrypt(t *testing.T) {
 		t.Fatalf("unexpected plaintext: %s", plaintext)
 	}
 }
+
+func TestVaultEncryptBytes(t *testing.T) {
+	v, err := NewVault([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewVault error: %v", err)
+	}
+
+	data := []byte{0x50, 0x4b, 0x03, 0x04, 0x00, 0xff}
+	sealed, err := v.Encrypt(data)
+	if err != nil {
+		t.Fatalf("Encrypt error: %v", err)
+	}
+	plain, err := v.Decrypt(sealed)
+	if err != nil || string(plain) != string(data) {
+		t.Fatalf("Decrypt: %x, %v", plain, err)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := v.Decrypt(sealed); err == nil {
+		t.Fatal("expected tampered ciphertext to fail")
+	}
+}
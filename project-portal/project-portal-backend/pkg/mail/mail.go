@@ -0,0 +1,174 @@
+// Package mail sends transactional email through a pluggable Sender. SMTP is
+// used in deployed environments; the file sink writes .eml files for local
+// development so no mail server is needed.
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Drivers accepted by New.
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+// Message is a single email. HTML is optional; when set the message is sent
+// as multipart/alternative with Text as the plain part.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a Sender.
+type Config struct {
+	Driver       string // smtp or file
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string // where the file sink writes messages
+}
+
+// New builds the Sender named by cfg.Driver. The file sink is the default.
+func New(cfg Config) (Sender, error) {
+	if cfg.From == "" {
+		return nil, errors.New("mail: from address is required")
+	}
+	switch cfg.Driver {
+	case DriverSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("mail: SMTP host is required")
+		}
+		return NewSMTPSender(cfg), nil
+	case DriverFile, "":
+		return NewFileSender(cfg.FileDir, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", cfg.Driver)
+	}
+}
+
+// SMTPSender delivers through an SMTP relay, using STARTTLS when offered.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPSender returns a sender for cfg.SMTPHost. PLAIN auth is used when a
+// username is set.
+func NewSMTPSender(cfg Config) *SMTPSender {
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	s := &SMTPSender{addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)), from: cfg.From}
+	if cfg.SMTPUsername != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return s
+}
+
+// Send delivers msg. smtp.SendMail does not take a context, so cancellation
+// is only checked before dialing.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := render(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.from, msg.To, body); err != nil {
+		return fmt.Errorf("mail: smtp send: %w", err)
+	}
+	return nil
+}
+
+// FileSender writes each message to its own .eml file.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+// NewFileSender writes messages under dir, "tmp/mail" when empty.
+func NewFileSender(dir, from string) *FileSender {
+	if dir == "" {
+		dir = filepath.Join("tmp", "mail")
+	}
+	return &FileSender{dir: dir, from: from}
+}
+
+// Send writes msg to a new file named by its timestamp.
+func (s *FileSender) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	body, err := render(s.from, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("mail: file sink: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000"), randomHex(4))
+	if err := os.WriteFile(filepath.Join(s.dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("mail: file sink: %w", err)
+	}
+	return nil
+}
+
+// render formats msg as an RFC 5322 message.
+func render(from string, msg Message, at time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("mail: no recipients")
+	}
+	for _, addr := range append([]string{from}, msg.To...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return nil, errors.New("mail: invalid address")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		b.WriteString(msg.Text)
+		return []byte(b.String()), nil
+	}
+
+	boundary := "b-" + randomHex(12)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", boundary, msg.Text)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n%s\r\n", boundary, msg.HTML)
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return []byte(b.String()), nil
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSenderWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := New(Config{Driver: DriverFile, From: "portal@example.com", FileDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), Message{To: []string{"a@example.com"}, Subject: "Hello", Text: "body", HTML: "<p>body</p>"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one message, got %d", len(files))
+	}
+	raw, _ := os.ReadFile(files[0])
+	for _, want := range []string{"To: a@example.com", "Subject: Hello", "multipart/alternative", "<p>body</p>"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("message missing %q", want)
+		}
+	}
+}
+
+func TestRenderRejectsHeaderInjection(t *testing.T) {
+	if _, err := render("portal@example.com", Message{To: []string{"a@example.com\r\nBcc: x@example.com"}}, time.Now()); err == nil {
+		t.Fatal("expected an error for a CRLF in the recipient")
+	}
+}
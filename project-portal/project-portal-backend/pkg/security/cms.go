@@ -0,0 +1,445 @@
+package security
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Object identifiers used in CMS signatures and timestamps.
+var (
+	oidData               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidAttrContentType    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidAttrTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+	oidSHA1               = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256    = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// cmsAttribute is a CMS Attribute with a single value.
+type cmsAttribute struct {
+	Type  asn1.ObjectIdentifier
+	Value []byte // DER of the single attribute value
+}
+
+// signedDataParams describes a CMS SignedData with one signer.
+type signedDataParams struct {
+	signer       *Signer
+	contentType  asn1.ObjectIdentifier
+	content      []byte // encapsulated content; nil for a detached signature
+	digest       []byte // SHA-256 of the signed content
+	extraSigned  []cmsAttribute
+	unsignedFunc func(signature []byte) ([]cmsAttribute, error)
+}
+
+// buildSignedData returns a DER ContentInfo wrapping a SignedData signed by
+// p.signer with SHA-256 over the signed attributes. The signer's
+// certificate is bound with an ESS signing-certificate-v2 attribute as
+// PAdES requires; no signing-time attribute is added.
+func buildSignedData(p signedDataParams) ([]byte, error) {
+	cert := p.signer.Certificate
+	certHash := sha256.Sum256(cert.Raw)
+
+	attrs := []cmsAttribute{
+		{Type: oidAttrContentType, Value: mustMarshal(p.contentType)},
+		{Type: oidAttrMessageDigest, Value: mustMarshal(p.digest)},
+		{Type: oidAttrSigningCertV2, Value: essSigningCertificateV2(certHash[:])},
+	}
+	attrs = append(attrs, p.extraSigned...)
+	signedAttrs := encodeAttributeSet(attrs)
+
+	h := sha256.Sum256(signedAttrs)
+	sigAlg, err := signatureAlgorithm(p.signer.Key)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := p.signer.Key.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
+	}
+
+	var unsigned []cmsAttribute
+	if p.unsignedFunc != nil {
+		if unsigned, err = p.unsignedFunc(signature); err != nil {
+			return nil, err
+		}
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(ci *cryptobyte.Builder) {
+		ci.AddASN1ObjectIdentifier(oidSignedData)
+		ci.AddASN1(cbasn1.Tag(0).Constructed().ContextSpecific(), func(explicit *cryptobyte.Builder) {
+			explicit.AddASN1(cbasn1.SEQUENCE, func(sd *cryptobyte.Builder) {
+				sd.AddASN1Int64(1)
+				sd.AddASN1(cbasn1.SET, func(algs *cryptobyte.Builder) {
+					addAlgorithm(algs, oidSHA256, false)
+				})
+				sd.AddASN1(cbasn1.SEQUENCE, func(eci *cryptobyte.Builder) {
+					eci.AddASN1ObjectIdentifier(p.contentType)
+					if p.content != nil {
+						eci.AddASN1(cbasn1.Tag(0).Constructed().ContextSpecific(), func(c *cryptobyte.Builder) {
+							c.AddASN1OctetString(p.content)
+						})
+					}
+				})
+				sd.AddASN1(cbasn1.Tag(0).Constructed().ContextSpecific(), func(certs *cryptobyte.Builder) {
+					certs.AddBytes(cert.Raw)
+					for _, c := range p.signer.Chain {
+						certs.AddBytes(c.Raw)
+					}
+				})
+				sd.AddASN1(cbasn1.SET, func(infos *cryptobyte.Builder) {
+					infos.AddASN1(cbasn1.SEQUENCE, func(si *cryptobyte.Builder) {
+						si.AddASN1Int64(1)
+						si.AddASN1(cbasn1.SEQUENCE, func(ias *cryptobyte.Builder) {
+							ias.AddBytes(cert.RawIssuer)
+							ias.AddASN1BigInt(cert.SerialNumber)
+						})
+						addAlgorithm(si, oidSHA256, false)
+						// Signed attributes are signed as a SET and stored [0] IMPLICIT.
+						si.AddASN1(cbasn1.Tag(0).Constructed().ContextSpecific(), func(a *cryptobyte.Builder) {
+							a.AddBytes(signedAttrs[headerLen(signedAttrs):])
+						})
+						addAlgorithm(si, sigAlg, sigAlg.Equal(oidRSAEncryption))
+						si.AddASN1OctetString(signature)
+						if len(unsigned) > 0 {
+							set := encodeAttributeSet(unsigned)
+							si.AddASN1(cbasn1.Tag(1).Constructed().ContextSpecific(), func(a *cryptobyte.Builder) {
+								a.AddBytes(set[headerLen(set):])
+							})
+						}
+					})
+				})
+			})
+		})
+	})
+	return b.Bytes()
+}
+
+func signatureAlgorithm(key crypto.Signer) (asn1.ObjectIdentifier, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return oidRSAEncryption, nil
+	case *ecdsa.PublicKey:
+		return oidECDSAWithSHA256, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", key.Public())
+	}
+}
+
+func addAlgorithm(b *cryptobyte.Builder, oid asn1.ObjectIdentifier, nullParams bool) {
+	b.AddASN1(cbasn1.SEQUENCE, func(alg *cryptobyte.Builder) {
+		alg.AddASN1ObjectIdentifier(oid)
+		if nullParams {
+			alg.AddASN1NULL()
+		}
+	})
+}
+
+// essSigningCertificateV2 encodes SigningCertificateV2 with one ESSCertIDv2
+// using the default SHA-256 hash algorithm.
+func essSigningCertificateV2(certHash []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(scv2 *cryptobyte.Builder) {
+		scv2.AddASN1(cbasn1.SEQUENCE, func(certs *cryptobyte.Builder) {
+			certs.AddASN1(cbasn1.SEQUENCE, func(id *cryptobyte.Builder) {
+				id.AddASN1OctetString(certHash)
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// encodeAttributeSet DER-encodes attributes as a SET OF Attribute, sorted
+// by encoding as DER requires.
+func encodeAttributeSet(attrs []cmsAttribute) []byte {
+	encoded := make([][]byte, len(attrs))
+	for i, a := range attrs {
+		var b cryptobyte.Builder
+		b.AddASN1(cbasn1.SEQUENCE, func(attr *cryptobyte.Builder) {
+			attr.AddASN1ObjectIdentifier(a.Type)
+			attr.AddASN1(cbasn1.SET, func(vals *cryptobyte.Builder) {
+				vals.AddBytes(a.Value)
+			})
+		})
+		encoded[i] = b.BytesOrPanic()
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SET, func(set *cryptobyte.Builder) {
+		for _, e := range encoded {
+			set.AddBytes(e)
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+// headerLen returns the length of a DER element's tag and length octets.
+func headerLen(der []byte) int {
+	if len(der) < 2 || der[1] < 0x80 {
+		return 2
+	}
+	return 2 + int(der[1]&0x7f)
+}
+
+func mustMarshal(v any) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// ─── Parsing ──────────────────────────────────────────────────────────────────
+
+// parsedSignedData is the part of a CMS SignedData the verifier needs.
+type parsedSignedData struct {
+	contentType  asn1.ObjectIdentifier
+	content      []byte
+	certificates []*x509.Certificate
+	signer       parsedSignerInfo
+}
+
+type parsedSignerInfo struct {
+	issuer        []byte
+	serial        *big.Int
+	signedAttrs   []byte // re-tagged as SET for signature checking
+	attrs         map[string][]byte
+	digestAlg     asn1.ObjectIdentifier
+	sigAlgorithm  asn1.ObjectIdentifier
+	signature     []byte
+	unsignedAttrs map[string][]byte
+}
+
+// parseSignedData decodes a DER ContentInfo holding a SignedData with at
+// least one signer; only the first signer is returned.
+func parseSignedData(der []byte) (*parsedSignedData, error) {
+	in := cryptobyte.String(der)
+	var ci, sd cryptobyte.String
+	var oid asn1.ObjectIdentifier
+	if !in.ReadASN1(&ci, cbasn1.SEQUENCE) || !ci.ReadASN1ObjectIdentifier(&oid) || !oid.Equal(oidSignedData) {
+		return nil, fmt.Errorf("not a CMS SignedData")
+	}
+	var explicit cryptobyte.String
+	if !ci.ReadASN1(&explicit, cbasn1.Tag(0).Constructed().ContextSpecific()) || !explicit.ReadASN1(&sd, cbasn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed SignedData")
+	}
+	out := &parsedSignedData{}
+	var version int64
+	var algs, eci cryptobyte.String
+	if !sd.ReadASN1Integer(&version) || !sd.ReadASN1(&algs, cbasn1.SET) || !sd.ReadASN1(&eci, cbasn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed SignedData header")
+	}
+	if !eci.ReadASN1ObjectIdentifier(&out.contentType) {
+		return nil, fmt.Errorf("malformed encapsulated content")
+	}
+	var econtent cryptobyte.String
+	var hasContent bool
+	if !eci.ReadOptionalASN1(&econtent, &hasContent, cbasn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed encapsulated content")
+	}
+	if hasContent {
+		var octets []byte
+		if !econtent.ReadASN1Bytes(&octets, cbasn1.OCTET_STRING) {
+			return nil, fmt.Errorf("malformed encapsulated content")
+		}
+		out.content = octets
+	}
+
+	var certs cryptobyte.String
+	var hasCerts bool
+	if !sd.ReadOptionalASN1(&certs, &hasCerts, cbasn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed certificates")
+	}
+	for !certs.Empty() {
+		var raw cryptobyte.String
+		if !certs.ReadASN1Element(&raw, cbasn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed certificate")
+		}
+		if c, err := x509.ParseCertificate(raw); err == nil {
+			out.certificates = append(out.certificates, c)
+		}
+	}
+	// Skip optional CRLs [1].
+	var skipped cryptobyte.String
+	sd.ReadOptionalASN1(&skipped, nil, cbasn1.Tag(1).Constructed().ContextSpecific())
+
+	var infos, si cryptobyte.String
+	if !sd.ReadASN1(&infos, cbasn1.SET) || !infos.ReadASN1(&si, cbasn1.SEQUENCE) {
+		return nil, fmt.Errorf("no signer info")
+	}
+	var siVersion int64
+	var ias, digestAlg, sigAlg cryptobyte.String
+	if !si.ReadASN1Integer(&siVersion) || !si.ReadASN1(&ias, cbasn1.SEQUENCE) {
+		return nil, fmt.Errorf("unsupported signer identifier")
+	}
+	var issuer cryptobyte.String
+	out.signer.serial = new(big.Int)
+	if !ias.ReadASN1Element(&issuer, cbasn1.SEQUENCE) || !ias.ReadASN1Integer(out.signer.serial) {
+		return nil, fmt.Errorf("malformed issuer and serial number")
+	}
+	out.signer.issuer = issuer
+	if !si.ReadASN1(&digestAlg, cbasn1.SEQUENCE) || !digestAlg.ReadASN1ObjectIdentifier(&out.signer.digestAlg) {
+		return nil, fmt.Errorf("malformed digest algorithm")
+	}
+	var attrs cryptobyte.String
+	var hasAttrs bool
+	if !si.ReadOptionalASN1(&attrs, &hasAttrs, cbasn1.Tag(0).Constructed().ContextSpecific()) || !hasAttrs {
+		return nil, fmt.Errorf("signer info has no signed attributes")
+	}
+	var set cryptobyte.Builder
+	set.AddASN1(cbasn1.SET, func(b *cryptobyte.Builder) { b.AddBytes(attrs) })
+	out.signer.signedAttrs = set.BytesOrPanic()
+	if out.signer.attrs = parseAttributes(attrs); out.signer.attrs == nil {
+		return nil, fmt.Errorf("malformed signed attributes")
+	}
+	if !si.ReadASN1(&sigAlg, cbasn1.SEQUENCE) || !sigAlg.ReadASN1ObjectIdentifier(&out.signer.sigAlgorithm) {
+		return nil, fmt.Errorf("malformed signature algorithm")
+	}
+	var signature []byte
+	if !si.ReadASN1Bytes(&signature, cbasn1.OCTET_STRING) {
+		return nil, fmt.Errorf("malformed signature value")
+	}
+	out.signer.signature = signature
+	var unsigned cryptobyte.String
+	var hasUnsigned bool
+	if si.ReadOptionalASN1(&unsigned, &hasUnsigned, cbasn1.Tag(1).Constructed().ContextSpecific()) && hasUnsigned {
+		out.signer.unsignedAttrs = parseAttributes(unsigned)
+	}
+	return out, nil
+}
+
+// parseAttributes maps attribute OIDs to the DER of their first value.
+func parseAttributes(s cryptobyte.String) map[string][]byte {
+	out := map[string][]byte{}
+	for !s.Empty() {
+		var attr, vals, val cryptobyte.String
+		var oid asn1.ObjectIdentifier
+		if !s.ReadASN1(&attr, cbasn1.SEQUENCE) || !attr.ReadASN1ObjectIdentifier(&oid) ||
+			!attr.ReadASN1(&vals, cbasn1.SET) || !readAnyElement(&vals, &val) {
+			return nil
+		}
+		out[oid.String()] = val
+	}
+	return out
+}
+
+func readAnyElement(s *cryptobyte.String, out *cryptobyte.String) bool {
+	var tag cbasn1.Tag
+	return s.ReadAnyASN1Element(out, &tag)
+}
+
+// signerCertificate finds the certificate matching the signer identifier.
+func (p *parsedSignedData) signerCertificate() (*x509.Certificate, error) {
+	for _, c := range p.certificates {
+		if bytes.Equal(c.RawIssuer, p.signer.issuer) && c.SerialNumber.Cmp(p.signer.serial) == 0 {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("signer certificate not embedded in signature")
+}
+
+// verifySignerInfo checks the message digest attribute against content
+// and the signature over the signed attributes against cert.
+func (p *parsedSignedData) verifySignerInfo(cert *x509.Certificate, content []byte) error {
+	hash, err := digestHash(p.signer.digestAlg)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(content)
+	var md []byte
+	if _, err := asn1.Unmarshal(p.signer.attrs[oidAttrMessageDigest.String()], &md); err != nil {
+		return fmt.Errorf("missing message digest attribute")
+	}
+	if !bytes.Equal(md, h.Sum(nil)) {
+		return fmt.Errorf("content digest does not match the signed digest; the content was modified after signing")
+	}
+
+	// The signature algorithm follows the key type; RSA signers often
+	// record only rsaEncryption.
+	var alg x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		alg = map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA1: x509.SHA1WithRSA, crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA}[hash]
+	case x509.ECDSA:
+		alg = map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA1: x509.ECDSAWithSHA1, crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512}[hash]
+	default:
+		return fmt.Errorf("unsupported signer key algorithm %s", cert.PublicKeyAlgorithm)
+	}
+	if err := cert.CheckSignature(alg, p.signer.signedAttrs, p.signer.signature); err != nil {
+		return fmt.Errorf("signature value does not verify: %w", err)
+	}
+	return nil
+}
+
+// checkSigningCertificate checks that the signingCertificateV2 attribute
+// binds the signature to cert, as PAdES requires.
+func (p *parsedSignedData) checkSigningCertificate(cert *x509.Certificate) error {
+	attr, ok := p.signer.attrs[oidAttrSigningCertV2.String()]
+	if !ok {
+		return fmt.Errorf("signature has no signing-certificate-v2 attribute")
+	}
+	in := cryptobyte.String(attr)
+	var scv2, certs, id cryptobyte.String
+	if !in.ReadASN1(&scv2, cbasn1.SEQUENCE) || !scv2.ReadASN1(&certs, cbasn1.SEQUENCE) || !certs.ReadASN1(&id, cbasn1.SEQUENCE) {
+		return fmt.Errorf("malformed signing-certificate-v2 attribute")
+	}
+	hash := crypto.SHA256
+	if id.PeekASN1Tag(cbasn1.SEQUENCE) {
+		var alg cryptobyte.String
+		var oid asn1.ObjectIdentifier
+		if !id.ReadASN1(&alg, cbasn1.SEQUENCE) || !alg.ReadASN1ObjectIdentifier(&oid) {
+			return fmt.Errorf("malformed signing-certificate-v2 attribute")
+		}
+		var err error
+		if hash, err = digestHash(oid); err != nil {
+			return err
+		}
+	}
+	var certHash []byte
+	if !id.ReadASN1Bytes(&certHash, cbasn1.OCTET_STRING) {
+		return fmt.Errorf("malformed signing-certificate-v2 attribute")
+	}
+	h := hash.New()
+	h.Write(cert.Raw)
+	if !bytes.Equal(certHash, h.Sum(nil)) {
+		return fmt.Errorf("signing-certificate-v2 attribute names a different certificate")
+	}
+	return nil
+}
+
+func digestHash(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	default:
+		return 0, fmt.Errorf("unsupported digest algorithm %s", oid)
+	}
+}
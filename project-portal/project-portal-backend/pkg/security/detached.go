@@ -0,0 +1,128 @@
+package security
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+)
+
+var oidAttrSigningTime = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+// DetachedSignature describes a detached CMS signature over a file.
+type DetachedSignature struct {
+	SignerName    string     `json:"signer_name"`
+	CertSubject   string     `json:"certificate_subject"`
+	CertIssuer    string     `json:"certificate_issuer"`
+	CertSHA256    string     `json:"certificate_sha256"`
+	SigningTime   time.Time  `json:"signing_time"`
+	TimestampTime *time.Time `json:"timestamp_time,omitempty"`
+	Trusted       bool       `json:"trusted"`               // chain verified to a trust anchor
+	TrustError    string     `json:"trust_error,omitempty"` // why the chain did not verify
+	Chain         []string   `json:"certificate_chain,omitempty"`
+}
+
+// SignDetached returns a DER CMS SignedData over content that does not
+// embed it (a .p7s file), so any CMS tool can check the file offline, e.g.
+// `openssl cms -verify -binary -inform DER -in file.p7s -content file`.
+// The signer's certificate chain is embedded. With tsa set, the signature
+// carries an RFC 3161 timestamp.
+func SignDetached(ctx context.Context, content []byte, signer *Signer, tsa *TSAClient) ([]byte, *DetachedSignature, error) {
+	if signer == nil || signer.Key == nil || signer.Certificate == nil {
+		return nil, nil, fmt.Errorf("%w: signer is incomplete", ErrSignerCertificate)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	cert := signer.Certificate
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, nil, fmt.Errorf("%w: certificate is not valid at %s", ErrSignerCertificate, now.Format(time.RFC3339))
+	}
+
+	digest := sha256.Sum256(content)
+	info := detachedInfo(cert, now)
+	params := signedDataParams{
+		signer:      signer,
+		contentType: oidData,
+		digest:      digest[:],
+		extraSigned: []cmsAttribute{{Type: oidAttrSigningTime, Value: mustMarshal(now)}},
+	}
+	if tsa != nil {
+		params.unsignedFunc = func(signature []byte) ([]cmsAttribute, error) {
+			token, err := tsa.Timestamp(ctx, signature)
+			if err != nil {
+				return nil, err
+			}
+			info.TimestampTime = &token.GenTime
+			return []cmsAttribute{{Type: oidAttrTimeStampToken, Value: token.DER}}, nil
+		}
+	}
+	der, err := buildSignedData(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return der, info, nil
+}
+
+// VerifyDetached checks a detached CMS signature over content. An error
+// means the signature is malformed or content was changed after signing;
+// whether the signer is trusted is reported in the result. The chain is
+// validated against opts.Roots and the system pool at the timestamp time,
+// or now when the signature has no timestamp.
+func VerifyDetached(content, der []byte, opts VerifyOptions) (*DetachedSignature, error) {
+	sd, err := parseSignedData(der)
+	if err != nil {
+		return nil, err
+	}
+	if sd.content != nil {
+		return nil, fmt.Errorf("signature is not detached")
+	}
+	cert, err := sd.signerCertificate()
+	if err != nil {
+		return nil, err
+	}
+	if err := sd.verifySignerInfo(cert, content); err != nil {
+		return nil, err
+	}
+	if err := sd.checkSigningCertificate(cert); err != nil {
+		return nil, err
+	}
+
+	var signingTime time.Time
+	if raw, ok := sd.signer.attrs[oidAttrSigningTime.String()]; ok {
+		_, _ = asn1.Unmarshal(raw, &signingTime)
+	}
+	info := detachedInfo(cert, signingTime.UTC())
+	at := time.Now()
+	if raw, ok := sd.signer.unsignedAttrs[oidAttrTimeStampToken.String()]; ok {
+		token, err := ParseTimestampToken(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !token.covers(sd.signer.signature) {
+			return nil, fmt.Errorf("timestamp token does not cover the signature")
+		}
+		info.TimestampTime, at = &token.GenTime, token.GenTime
+	}
+	chain, err := verifiedChain(cert, sd.certificates, opts, at, x509.ExtKeyUsageAny)
+	if err != nil {
+		info.TrustError = err.Error()
+		return info, nil
+	}
+	info.Trusted = true
+	for _, c := range chain {
+		info.Chain = append(info.Chain, c.Subject.String())
+	}
+	return info, nil
+}
+
+func detachedInfo(cert *x509.Certificate, signingTime time.Time) *DetachedSignature {
+	sum := sha256.Sum256(cert.Raw)
+	return &DetachedSignature{
+		SignerName:  extractCNFromCert(cert),
+		CertSubject: cert.Subject.String(),
+		CertIssuer:  cert.Issuer.String(),
+		CertSHA256:  fmt.Sprintf("%x", sum),
+		SigningTime: signingTime,
+	}
+}
@@ -0,0 +1,40 @@
+package security
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestSignDetached(t *testing.T) {
+	ctx := context.Background()
+	signer := testSigner(t, "Evidence Desk", true)
+	tsa := testSigner(t, "Test TSA", false)
+	genTime := time.Now().UTC().Truncate(time.Second)
+	content := []byte(`{"files":[]}`)
+
+	sig, info, err := SignDetached(ctx, content, signer, testTSA(t, tsa, genTime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SignerName != "Evidence Desk" || info.TimestampTime == nil {
+		t.Errorf("sign info %+v", info)
+	}
+
+	got, err := VerifyDetached(content, sig, VerifyOptions{Roots: []*x509.Certificate{signer.Certificate, tsa.Certificate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Trusted || got.CertSHA256 != info.CertSHA256 || !got.SigningTime.Equal(info.SigningTime) ||
+		got.TimestampTime == nil || !got.TimestampTime.Equal(genTime) {
+		t.Errorf("verify %+v", got)
+	}
+
+	if got, err := VerifyDetached(content, sig, VerifyOptions{}); err != nil || got.Trusted || got.TrustError == "" {
+		t.Errorf("untrusted signer: %+v, %v", got, err)
+	}
+	if _, err := VerifyDetached([]byte(`{"files":[1]}`), sig, VerifyOptions{}); err == nil {
+		t.Error("modified content verified")
+	}
+}
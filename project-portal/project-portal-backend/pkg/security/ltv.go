@@ -0,0 +1,298 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// ValidationData is long-term validation material: the certificates and
+// revocation responses needed to validate signatures after the
+// certificates expire or the responders disappear.
+type ValidationData struct {
+	Certificates []*x509.Certificate
+	OCSPs        [][]byte // DER OCSP responses
+	CRLs         [][]byte // DER CRLs
+}
+
+// documentSecurityStore is the /DSS dictionary of a PDF.
+type documentSecurityStore struct {
+	ValidationData
+	offset int // where the latest DSS was written
+}
+
+// readDSS returns the document security store of pdf, or nil if it has
+// none.
+func readDSS(pdf []byte) (*documentSecurityStore, error) {
+	f, err := openPDF(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := f.dict(f.root, "catalog")
+	if err != nil {
+		return nil, err
+	}
+	v := catalog.Get("DSS")
+	if v == nil {
+		return nil, nil
+	}
+	dss, err := f.dict(v, "DSS")
+	if err != nil {
+		return nil, err
+	}
+	at := f.root
+	if ref, ok := v.(pdfRef); ok {
+		at = ref
+	}
+	out := &documentSecurityStore{}
+	if out.offset, err = f.objectOffset(at); err != nil {
+		return nil, err
+	}
+
+	streams := func(key string) ([][]byte, error) {
+		arr, err := f.resolve(dss.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		var data [][]byte
+		refs, _ := arr.(pdfArray)
+		for _, r := range refs {
+			ref, ok := r.(pdfRef)
+			if !ok {
+				continue
+			}
+			b, err := f.stream(ref)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, b)
+		}
+		return data, nil
+	}
+	certs, err := streams("Certs")
+	if err != nil {
+		return nil, err
+	}
+	for _, der := range certs {
+		if c, err := x509.ParseCertificate(der); err == nil {
+			out.Certificates = append(out.Certificates, c)
+		}
+	}
+	if out.OCSPs, err = streams("OCSPs"); err != nil {
+		return nil, err
+	}
+	if out.CRLs, err = streams("CRLs"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// revocation returns the status of cert from the store's OCSP responses
+// or CRLs, or nil if it holds none for cert.
+func (d *documentSecurityStore) revocation(cert, issuer *x509.Certificate) *RevocationInfo {
+	if d == nil {
+		return nil
+	}
+	for _, der := range d.OCSPs {
+		if info, err := ocspStatus(der, cert, issuer); err == nil {
+			info.Source = RevocationSourceEmbeddedOCSP
+			return info
+		}
+	}
+	for _, der := range d.CRLs {
+		if info, err := crlStatus(der, cert, issuer); err == nil {
+			info.Source = RevocationSourceEmbeddedCRL
+			return info
+		}
+	}
+	return nil
+}
+
+// AddValidationData appends vd to the document security store of pdf in an
+// incremental update, keeping what the store already holds.
+func AddValidationData(pdf []byte, vd ValidationData) ([]byte, error) {
+	f, err := openPDF(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := f.dict(f.root, "catalog")
+	if err != nil {
+		return nil, err
+	}
+	dss := newDict()
+	if v := catalog.Get("DSS"); v != nil {
+		d, err := f.dict(v, "DSS")
+		if err != nil {
+			return nil, err
+		}
+		dss = d.clone()
+	}
+	existing, err := readDSS(pdf)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[[32]byte]bool{}
+	if existing != nil {
+		for _, c := range existing.Certificates {
+			seen[sha256.Sum256(c.Raw)] = true
+		}
+		for _, der := range append(append([][]byte{}, existing.OCSPs...), existing.CRLs...) {
+			seen[sha256.Sum256(der)] = true
+		}
+	}
+
+	u := newPDFUpdate(f)
+	appendStreams := func(key string, items [][]byte) error {
+		arr, err := f.resolve(dss.Get(key))
+		if err != nil {
+			return err
+		}
+		refs, _ := arr.(pdfArray)
+		refs = append(pdfArray{}, refs...)
+		for _, der := range items {
+			sum := sha256.Sum256(der)
+			if seen[sum] {
+				continue
+			}
+			seen[sum] = true
+			ref := u.alloc()
+			u.setRaw(ref, streamObject(newDict(), der))
+			refs = append(refs, ref)
+		}
+		if len(refs) > 0 {
+			dss.Set(key, refs)
+		}
+		return nil
+	}
+	certs := make([][]byte, len(vd.Certificates))
+	for i, c := range vd.Certificates {
+		certs[i] = c.Raw
+	}
+	for _, kv := range []struct {
+		key   string
+		items [][]byte
+	}{{"Certs", certs}, {"OCSPs", vd.OCSPs}, {"CRLs", vd.CRLs}} {
+		if err := appendStreams(kv.key, kv.items); err != nil {
+			return nil, err
+		}
+	}
+
+	dssRef := u.alloc()
+	dss.Set("Type", pdfName("DSS"))
+	u.set(dssRef, dss)
+	catalog = catalog.clone()
+	catalog.Set("DSS", dssRef)
+	u.set(f.root, catalog)
+	out, _ := u.write(pdf, 0)
+	return out, nil
+}
+
+// AddDocumentTimestamp appends an RFC 3161 document timestamp over the
+// whole of pdf. A document timestamp after the document security store
+// raises the signatures before it to PAdES-B-LTA.
+func AddDocumentTimestamp(ctx context.Context, pdf []byte, tsa *TSAClient) ([]byte, *TimestampToken, error) {
+	out, byteRange, err := prepareSignature(pdf, SignOptions{Page: 1}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed, err := byteRangeContent(out, byteRange[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := tsa.Timestamp(ctx, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fillContents(out, byteRange, token.DER); err != nil {
+		return nil, nil, err
+	}
+	return out, token, nil
+}
+
+// AddLongTermValidation embeds the certificate chains of every signature
+// and timestamp in pdf and, using opts.Revocation, their revocation
+// status (PAdES-B-LT). With tsa, the result is sealed by a document
+// timestamp (PAdES-B-LTA).
+func AddLongTermValidation(ctx context.Context, pdf []byte, opts VerifyOptions, tsa *TSAClient) ([]byte, error) {
+	var vd ValidationData
+	var errs []error
+	addChain := func(cert *x509.Certificate, embedded []*x509.Certificate, usage x509.ExtKeyUsage) {
+		chain, err := verifiedChain(cert, embedded, opts, time.Now(), usage)
+		if err != nil {
+			chain = buildChain(cert, embedded, opts)
+		}
+		vd.Certificates = append(vd.Certificates, chain...)
+		if opts.Revocation == nil {
+			return
+		}
+		for i := 0; i+1 < len(chain); i++ {
+			info, err := opts.Revocation.Check(ctx, chain[i], chain[i+1])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			switch info.Source {
+			case RevocationSourceOCSP:
+				vd.OCSPs = append(vd.OCSPs, info.raw)
+			case RevocationSourceCRL:
+				vd.CRLs = append(vd.CRLs, info.raw)
+			}
+		}
+	}
+
+	for _, raw := range extractRawSignatures(pdf) {
+		sd, err := parseSignedData(raw.contents)
+		if err != nil {
+			continue
+		}
+		cert, err := sd.signerCertificate()
+		if err != nil {
+			continue
+		}
+		addChain(cert, sd.certificates, x509.ExtKeyUsageAny)
+		if der, ok := sd.signer.unsignedAttrs[oidAttrTimeStampToken.String()]; ok {
+			if token, err := ParseTimestampToken(der); err == nil {
+				addChain(token.cert, token.signed.certificates, x509.ExtKeyUsageTimeStamping)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("revocation data unavailable: %w", errs[0])
+	}
+
+	out, err := AddValidationData(pdf, vd)
+	if err != nil {
+		return nil, err
+	}
+	if tsa != nil {
+		if out, _, err = AddDocumentTimestamp(ctx, out, tsa); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// buildChain returns cert followed by the embedded certificates that issued
+// it, in order, without checking trust.
+func buildChain(cert *x509.Certificate, embedded []*x509.Certificate, opts VerifyOptions) []*x509.Certificate {
+	chain := []*x509.Certificate{cert}
+	pool := append(append([]*x509.Certificate{}, embedded...), opts.Roots...)
+	for cur := cert; len(chain) < 10 && !bytes.Equal(cur.RawIssuer, cur.RawSubject); {
+		var next *x509.Certificate
+		for _, c := range pool {
+			if bytes.Equal(c.RawSubject, cur.RawIssuer) && cur.CheckSignatureFrom(c) == nil {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+		cur = next
+	}
+	return chain
+}
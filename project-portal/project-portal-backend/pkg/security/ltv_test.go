@@ -0,0 +1,279 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testPKI is a CA with an OCSP responder and CRL endpoint. Serials in
+// revoked are reported as revoked; ocspDown makes the responder fail.
+type testPKI struct {
+	ca       *Signer
+	revoked  map[int64]time.Time
+	ocspDown bool
+	ocspHits atomic.Int32
+	srv      *httptest.Server
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	p := &testPKI{ca: testSigner(t, "Test Registry CA", true), revoked: map[int64]time.Time{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		p.ocspHits.Add(1)
+		if p.ocspDown {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tmpl := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+		if at, ok := p.revoked[req.SerialNumber.Int64()]; ok {
+			tmpl.Status, tmpl.RevokedAt, tmpl.RevocationReason = ocsp.Revoked, at, ocsp.KeyCompromise
+		}
+		der, err := ocsp.CreateResponse(p.ca.Certificate, p.ca.Certificate, tmpl, p.ca.Key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(der)
+	})
+	mux.HandleFunc("/crl", func(w http.ResponseWriter, r *http.Request) {
+		var entries []x509.RevocationListEntry
+		for serial, at := range p.revoked {
+			entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: at})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now().Add(-time.Minute),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: entries,
+		}, p.ca.Certificate, p.ca.Key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(der)
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// issue returns a signer whose certificate is issued by the CA and names
+// its OCSP responder and CRL.
+func (p *testPKI) issue(t *testing.T, cn string, serial int64, notAfter time.Time) *Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		OCSPServer:            []string{p.srv.URL + "/ocsp"},
+		CRLDistributionPoints: []string{p.srv.URL + "/crl"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca.Certificate, key.Public(), p.ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{Key: key, Certificate: cert, Chain: []*x509.Certificate{p.ca.Certificate}}
+}
+
+func TestRevocationChecker(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	good := pki.issue(t, "Good", 10, time.Now().Add(time.Hour))
+	bad := pki.issue(t, "Bad", 11, time.Now().Add(time.Hour))
+	pki.revoked[11] = time.Now().Add(-time.Minute).Truncate(time.Second)
+	rc := NewRevocationChecker()
+
+	info, err := rc.Check(ctx, good.Certificate, pki.ca.Certificate)
+	if err != nil || info.Status != RevocationGood || info.Source != RevocationSourceOCSP || info.Cached {
+		t.Fatalf("good: %+v, %v", info, err)
+	}
+	info, err = rc.Check(ctx, good.Certificate, pki.ca.Certificate)
+	if err != nil || !info.Cached || pki.ocspHits.Load() != 1 {
+		t.Errorf("second check should come from the cache: %+v, %d OCSP requests", info, pki.ocspHits.Load())
+	}
+	info, err = rc.Check(ctx, bad.Certificate, pki.ca.Certificate)
+	if err != nil || info.Status != RevocationRevoked || info.RevokedAt == nil {
+		t.Errorf("revoked: %+v, %v", info, err)
+	}
+
+	// With the responder down the checker falls back to the CRL.
+	pki.ocspDown = true
+	info, err = NewRevocationChecker().Check(ctx, bad.Certificate, pki.ca.Certificate)
+	if err != nil || info.Status != RevocationRevoked || info.Source != RevocationSourceCRL {
+		t.Errorf("CRL fallback: %+v, %v", info, err)
+	}
+}
+
+func TestVerifyRevocation(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	signer := pki.issue(t, "Alice", 20, time.Now().Add(time.Hour))
+	signed, _, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []*x509.Certificate{pki.ca.Certificate}
+
+	tests := []struct {
+		name       string
+		opts       VerifyOptions
+		revoke     bool
+		indication string
+		revocation string
+	}{
+		{"not checked", VerifyOptions{Roots: roots}, false, IndicationPassed, CheckWarning},
+		{"required but not checked", VerifyOptions{Roots: roots, RequireRevocation: true}, false, IndicationIndeterminate, CheckIndeterminate},
+		{"good", VerifyOptions{Roots: roots, Revocation: NewRevocationChecker()}, false, IndicationPassed, CheckPassed},
+		{"revoked", VerifyOptions{Roots: roots, Revocation: NewRevocationChecker()}, true, IndicationFailed, CheckFailed},
+	}
+	for _, tt := range tests {
+		if tt.revoke {
+			pki.revoked[20] = time.Now().Add(-time.Minute)
+		}
+		vr, err := VerifyPDFSignaturesWithOptions(ctx, signed, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := vr.Signatures[0]
+		if sig.Indication != tt.indication || sig.IsValid != (tt.indication == IndicationPassed) {
+			t.Errorf("%s: indication %s (%s), want %s", tt.name, sig.Indication, sig.FailureReason, tt.indication)
+		}
+		if got := checkStatus(sig, "revocation"); got != tt.revocation {
+			t.Errorf("%s: revocation check %s, want %s", tt.name, got, tt.revocation)
+		}
+		if len(sig.CertificateChain) != 2 {
+			t.Errorf("%s: chain %v", tt.name, sig.CertificateChain)
+		}
+	}
+}
+
+func TestLongTermValidation(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	tsaSigner := testSigner(t, "Test TSA", false)
+	tsa := testTSA(t, tsaSigner, time.Now().UTC().Truncate(time.Second))
+	signer := pki.issue(t, "Alice", 30, time.Now().Add(time.Hour))
+	roots := []*x509.Certificate{pki.ca.Certificate, tsaSigner.Certificate}
+
+	signed, _, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{TSA: tsa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lt, err := AddLongTermValidation(ctx, signed, VerifyOptions{Roots: roots, Revocation: NewRevocationChecker()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lta, err := AddLongTermValidation(ctx, signed, VerifyOptions{Roots: roots, Revocation: NewRevocationChecker()}, tsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Offline verification: revocation data must come from the DSS.
+	pki.ocspDown = true
+	tests := []struct {
+		name  string
+		pdf   []byte
+		level string
+		count int
+	}{
+		{"signature timestamp", signed, PAdESLevelBT, 1},
+		{"with DSS", lt, PAdESLevelBLT, 1},
+		{"with DSS and document timestamp", lta, PAdESLevelBLTA, 2},
+	}
+	for _, tt := range tests {
+		vr, err := VerifyPDFSignaturesWithOptions(ctx, tt.pdf, VerifyOptions{Roots: roots, RequireRevocation: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vr.SignedCount != tt.count {
+			t.Fatalf("%s: %d signatures, want %d", tt.name, vr.SignedCount, tt.count)
+		}
+		sig := vr.Signatures[0]
+		if sig.Level != tt.level {
+			t.Errorf("%s: level %s, want %s", tt.name, sig.Level, tt.level)
+		}
+		if tt.level != PAdESLevelBT && (!vr.AllValid || sig.Revocation[0].Source != RevocationSourceEmbeddedOCSP) {
+			t.Errorf("%s: %+v", tt.name, vr.Signatures)
+		}
+	}
+}
+
+func TestValidationAtTimestamp(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	tsaSigner := testSigner(t, "Test TSA", false)
+	tsa := testTSA(t, tsaSigner, time.Now().UTC().Truncate(time.Second))
+	expires := time.Now().Add(1500 * time.Millisecond)
+	signer := pki.issue(t, "Short-lived", 40, expires)
+	roots := []*x509.Certificate{pki.ca.Certificate, tsaSigner.Certificate}
+
+	stamped, _, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{TSA: tsa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Until(expires) + 100*time.Millisecond)
+
+	vr, err := VerifyPDFSignaturesWithOptions(ctx, stamped, VerifyOptions{Roots: roots})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vr.AllValid {
+		t.Errorf("timestamped signature should validate at its timestamp: %+v", vr.Signatures[0])
+	}
+	vr, err = VerifyPDFSignaturesWithOptions(ctx, plain, VerifyOptions{Roots: roots})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := vr.Signatures[0]; sig.Indication != IndicationIndeterminate || checkStatus(sig, "certificate_chain") != CheckIndeterminate {
+		t.Errorf("signature without a timestamp should be indeterminate once expired: %+v", sig)
+	}
+}
+
+func checkStatus(sig SignatureInfo, name string) string {
+	for _, c := range sig.Checks {
+		if c.Name == name {
+			return c.Status
+		}
+	}
+	return ""
+}
@@ -0,0 +1,598 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// PAdES baseline conformance levels.
+const (
+	PAdESLevelBB   = "PAdES-B-B"   // signature only
+	PAdESLevelBT   = "PAdES-B-T"   // signature with an RFC 3161 timestamp
+	PAdESLevelBLT  = "PAdES-B-LT"  // B-T with certificates and revocation data in the DSS
+	PAdESLevelBLTA = "PAdES-B-LTA" // B-LT sealed by a document timestamp
+)
+
+// signatureReserve is the space reserved for the DER CMS signature. It fits
+// an RSA-4096 signature, a short chain and a timestamp token with its own
+// certificates.
+const signatureReserve = 24 * 1024
+
+// ErrSignerCertificate is returned for keys and certificates that cannot
+// be used to sign.
+var ErrSignerCertificate = errors.New("invalid signing certificate")
+
+// Signer is a private key with its certificate and optional chain.
+type Signer struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate
+}
+
+// ParseSignerPEM reads a private key and certificates from PEM. The first
+// certificate matching the key is the signer; the rest form the chain.
+func ParseSignerPEM(data []byte) (*Signer, error) {
+	var key crypto.Signer
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrSignerCertificate, err)
+			}
+			certs = append(certs, c)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			k, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: no private key found", ErrSignerCertificate)
+	}
+	return newSigner(key, certs)
+}
+
+// ParseSignerPKCS12 reads a PKCS#12 (.p12/.pfx) bundle.
+func ParseSignerPKCS12(data []byte, password string) (*Signer, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignerCertificate, err)
+	}
+	var buf bytes.Buffer
+	for _, b := range blocks {
+		// Drop bag attributes; pem.Encode would write them as headers.
+		if err := pem.Encode(&buf, &pem.Block{Type: b.Type, Bytes: b.Bytes}); err != nil {
+			return nil, err
+		}
+	}
+	return ParseSignerPEM(buf.Bytes())
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if k, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if s, ok := k.(crypto.Signer); ok {
+			return s, nil
+		}
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(der); err == nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported private key", ErrSignerCertificate)
+}
+
+func newSigner(key crypto.Signer, certs []*x509.Certificate) (*Signer, error) {
+	if _, err := signatureAlgorithm(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignerCertificate, err)
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported public key", ErrSignerCertificate)
+	}
+	s := &Signer{Key: key}
+	for _, c := range certs {
+		if s.Certificate == nil && pub.Equal(c.PublicKey) {
+			s.Certificate = c
+			continue
+		}
+		s.Chain = append(s.Chain, c)
+	}
+	if s.Certificate == nil {
+		return nil, fmt.Errorf("%w: no certificate matches the private key", ErrSignerCertificate)
+	}
+	return s, nil
+}
+
+// KeyPEM returns the private key as PKCS#8 PEM.
+func (s *Signer) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(s.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// CertificatesPEM returns the signer certificate followed by its chain.
+func (s *Signer) CertificatesPEM() []byte {
+	var buf bytes.Buffer
+	for _, c := range append([]*x509.Certificate{s.Certificate}, s.Chain...) {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return buf.Bytes()
+}
+
+// SignOptions controls the signature dictionary and its visible appearance.
+type SignOptions struct {
+	Name        string
+	Reason      string
+	Location    string
+	ContactInfo string
+	Page        int        // 1-based page for the appearance; defaults to 1
+	Rect        [4]float64 // llx, lly, urx, ury in points; defaults to the bottom left
+	Time        time.Time  // defaults to now
+	TSA         *TSAClient // adds an RFC 3161 timestamp (PAdES-B-T) when set
+}
+
+// SignResult describes an applied signature.
+type SignResult struct {
+	Level         string     `json:"level"`
+	SignerName    string     `json:"signer_name"`
+	CertSubject   string     `json:"certificate_subject"`
+	SigningTime   time.Time  `json:"signing_time"`
+	TimestampTime *time.Time `json:"timestamp_time,omitempty"`
+	ByteRange     [4]int     `json:"byte_range"`
+}
+
+// SignPDF appends a PAdES signature to pdf as an incremental update, so
+// earlier revisions and signatures stay intact. The signature has a
+// visible appearance on opts.Page and, when opts.TSA is set, a signature
+// timestamp.
+func SignPDF(ctx context.Context, pdf []byte, signer *Signer, opts SignOptions) ([]byte, *SignResult, error) {
+	if signer == nil || signer.Key == nil || signer.Certificate == nil {
+		return nil, nil, fmt.Errorf("%w: signer is incomplete", ErrSignerCertificate)
+	}
+	if opts.Time.IsZero() {
+		opts.Time = time.Now()
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.Rect == ([4]float64{}) {
+		opts.Rect = [4]float64{36, 36, 286, 106}
+	}
+	if opts.Name == "" {
+		opts.Name = signer.Certificate.Subject.CommonName
+	}
+	cert := signer.Certificate
+	if opts.Time.Before(cert.NotBefore) || opts.Time.After(cert.NotAfter) {
+		return nil, nil, fmt.Errorf("%w: certificate is not valid at %s", ErrSignerCertificate, opts.Time.UTC().Format(time.RFC3339))
+	}
+
+	out, byteRange, err := prepareSignature(pdf, opts, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed, err := byteRangeContent(out, byteRange[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha256.Sum256(signed)
+
+	result := &SignResult{
+		Level:       PAdESLevelBB,
+		SignerName:  opts.Name,
+		CertSubject: cert.Subject.String(),
+		SigningTime: opts.Time.UTC(),
+		ByteRange:   byteRange,
+	}
+	params := signedDataParams{signer: signer, contentType: oidData, digest: digest[:]}
+	if opts.TSA != nil {
+		params.unsignedFunc = func(signature []byte) ([]cmsAttribute, error) {
+			token, err := opts.TSA.Timestamp(ctx, signature)
+			if err != nil {
+				return nil, err
+			}
+			result.Level, result.TimestampTime = PAdESLevelBT, &token.GenTime
+			return []cmsAttribute{{Type: oidAttrTimeStampToken, Value: token.DER}}, nil
+		}
+	}
+	cms, err := buildSignedData(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fillContents(out, byteRange, cms); err != nil {
+		return nil, nil, err
+	}
+	return out, result, nil
+}
+
+// prepareSignature appends a signature revision to pdf and fixes its
+// /ByteRange. The /Contents placeholder is left for fillContents.
+func prepareSignature(pdf []byte, opts SignOptions, docTimestamp bool) ([]byte, [4]int, error) {
+	out, contentsAt, err := appendSignatureRevision(pdf, opts, docTimestamp)
+	if err != nil {
+		return nil, [4]int{}, err
+	}
+	// ByteRange covers everything except the hex /Contents placeholder.
+	start, end := contentsAt, contentsAt+2*signatureReserve+2
+	byteRange := [4]int{0, start, end, len(out) - end}
+	if err := patchByteRange(out, byteRange); err != nil {
+		return nil, [4]int{}, err
+	}
+	return out, byteRange, nil
+}
+
+// fillContents writes der into the /Contents placeholder.
+func fillContents(out []byte, byteRange [4]int, der []byte) error {
+	if len(der) > signatureReserve {
+		return fmt.Errorf("signature is %d bytes, more than the %d reserved", len(der), signatureReserve)
+	}
+	hex.Encode(out[byteRange[1]+1:], der)
+	return nil
+}
+
+// byteRangePlaceholder reserves room for the final /ByteRange array.
+var byteRangePlaceholder = "[0 0 0 0]" + strings.Repeat(" ", 36)
+
+// appendSignatureRevision writes the signature objects and rewritten page
+// and form dictionaries after the original bytes. A document timestamp
+// gets an invisible field and no signer details. It returns the new file
+// and the offset of the '<' opening the /Contents placeholder.
+func appendSignatureRevision(pdf []byte, opts SignOptions, docTimestamp bool) ([]byte, int, error) {
+	f, err := openPDF(pdf)
+	if err != nil {
+		return nil, 0, err
+	}
+	pageRef, page, err := f.page(opts.Page)
+	if err != nil {
+		return nil, 0, err
+	}
+	catalog, err := f.dict(f.root, "catalog")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	u := newPDFUpdate(f)
+	sigRef, fieldRef := u.alloc(), u.alloc()
+
+	sig := newDict()
+	if docTimestamp {
+		sig.Set("Type", pdfName("DocTimeStamp"))
+		sig.Set("Filter", pdfName("Adobe.PPKLite"))
+		sig.Set("SubFilter", pdfName("ETSI.RFC3161"))
+	} else {
+		sig.Set("Type", pdfName("Sig"))
+		sig.Set("Filter", pdfName("Adobe.PPKLite"))
+		sig.Set("SubFilter", pdfName("ETSI.CAdES.detached"))
+	}
+	sig.Set("ByteRange", pdfRaw(byteRangePlaceholder))
+	sig.Set("Contents", pdfRaw("<"+strings.Repeat("0", 2*signatureReserve)+">"))
+	if !docTimestamp {
+		sig.Set("M", pdfRaw("("+pdfDate(opts.Time)+")"))
+		sig.Set("Name", pdfText(opts.Name))
+		for _, kv := range [][2]string{{"Reason", opts.Reason}, {"Location", opts.Location}, {"ContactInfo", opts.ContactInfo}} {
+			if kv[1] != "" {
+				sig.Set(kv[0], pdfText(kv[1]))
+			}
+		}
+	}
+	u.set(sigRef, sig)
+
+	r := opts.Rect
+	if docTimestamp {
+		r = [4]float64{}
+	}
+	field := newDict()
+	field.Set("Type", pdfName("Annot"))
+	field.Set("Subtype", pdfName("Widget"))
+	field.Set("FT", pdfName("Sig"))
+	field.Set("T", pdfText(fmt.Sprintf("Signature%d", sigRef.Num)))
+	field.Set("V", sigRef)
+	field.Set("F", pdfRaw("132")) // Print | Locked
+	field.Set("Rect", pdfArray{pdfNum(r[0]), pdfNum(r[1]), pdfNum(r[2]), pdfNum(r[3])})
+	field.Set("P", pageRef)
+	if !docTimestamp {
+		fontRef, apRef := u.alloc(), u.alloc()
+		font := newDict()
+		font.Set("Type", pdfName("Font"))
+		font.Set("Subtype", pdfName("Type1"))
+		font.Set("BaseFont", pdfName("Helvetica"))
+		font.Set("Encoding", pdfName("WinAnsiEncoding"))
+		u.set(fontRef, font)
+		u.setRaw(apRef, appearanceStream(opts, fontRef))
+		ap := newDict()
+		ap.Set("N", apRef)
+		field.Set("AP", ap)
+	}
+	u.set(fieldRef, field)
+
+	// Page: add the widget to /Annots.
+	annots, err := f.resolve(page.Get("Annots"))
+	if err != nil {
+		return nil, 0, err
+	}
+	existing, _ := annots.(pdfArray)
+	page = page.clone()
+	page.Set("Annots", append(append(pdfArray{}, existing...), fieldRef))
+	u.set(pageRef, page)
+
+	// Catalog: add the field to /AcroForm, which may be inline or indirect.
+	form := newDict()
+	formRef, formIsRef := catalog.Get("AcroForm").(pdfRef)
+	if v := catalog.Get("AcroForm"); v != nil {
+		d, err := f.dict(v, "AcroForm")
+		if err != nil {
+			return nil, 0, err
+		}
+		form = d.clone()
+	}
+	fields, err := f.resolve(form.Get("Fields"))
+	if err != nil {
+		return nil, 0, err
+	}
+	existing, _ = fields.(pdfArray)
+	form.Set("Fields", append(append(pdfArray{}, existing...), fieldRef))
+	form.Set("SigFlags", pdfRaw("3")) // SignaturesExist | AppendOnly
+	if formIsRef {
+		u.set(formRef, form)
+	} else {
+		catalog = catalog.clone()
+		catalog.Set("AcroForm", form)
+		u.set(f.root, catalog)
+	}
+
+	out, offsets := u.write(pdf, 2*signatureReserve)
+	contentsAt := offsets[sigRef] + bytes.Index(out[offsets[sigRef]:], []byte("/Contents <")) + len("/Contents ")
+	return out, contentsAt, nil
+}
+
+// pdfUpdate collects the objects of an incremental update.
+type pdfUpdate struct {
+	f       *pdfFile
+	size    int
+	objects []pdfUpdateObject
+}
+
+type pdfUpdateObject struct {
+	ref  pdfRef
+	body []byte
+}
+
+func newPDFUpdate(f *pdfFile) *pdfUpdate {
+	return &pdfUpdate{f: f, size: f.size}
+}
+
+// alloc returns a new object number.
+func (u *pdfUpdate) alloc() pdfRef {
+	ref := pdfRef{Num: u.size}
+	u.size++
+	return ref
+}
+
+// set adds a new or replaced object.
+func (u *pdfUpdate) set(ref pdfRef, v pdfObject) {
+	var buf bytes.Buffer
+	writePDFObject(&buf, v)
+	u.setRaw(ref, buf.Bytes())
+}
+
+// setRaw adds an object already serialized, such as a stream.
+func (u *pdfUpdate) setRaw(ref pdfRef, body []byte) {
+	u.objects = append(u.objects, pdfUpdateObject{ref, body})
+}
+
+// write appends the objects, cross-reference section and trailer to pdf.
+// It returns the new file and the offset at which each object starts.
+func (u *pdfUpdate) write(pdf []byte, reserve int) ([]byte, map[pdfRef]int) {
+	out := bytes.NewBuffer(make([]byte, 0, len(pdf)+reserve+8192))
+	out.Write(pdf)
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	at := map[pdfRef]int{}
+	offsets := map[int]int{}
+	gens := map[int]int{}
+	for _, o := range u.objects {
+		at[o.ref] = out.Len()
+		offsets[o.ref.Num], gens[o.ref.Num] = out.Len(), o.ref.Gen
+		fmt.Fprintf(out, "%d %d obj\n", o.ref.Num, o.ref.Gen)
+		out.Write(o.body)
+		out.WriteString("\nendobj\n")
+	}
+
+	f := u.f
+	trailer := newDict()
+	trailer.Set("Root", f.root)
+	if f.info != nil {
+		trailer.Set("Info", f.info)
+	}
+	trailer.Set("ID", trailerID(f.id))
+	trailer.Set("Prev", pdfRaw(strconv.Itoa(f.prevXref)))
+
+	size := u.size
+	xrefAt := out.Len()
+	if f.xrefStream {
+		xrefRef := pdfRef{Num: size}
+		size++
+		offsets[xrefRef.Num], gens[xrefRef.Num] = xrefAt, 0
+		writeXrefStream(out, xrefRef, size, offsets, gens, trailer)
+	} else {
+		trailer.Set("Size", pdfRaw(strconv.Itoa(size)))
+		writeXrefTable(out, offsets, gens, trailer)
+	}
+	fmt.Fprintf(out, "startxref\n%d\n%%%%EOF\n", xrefAt)
+	return out.Bytes(), at
+}
+
+// streamObject serializes a stream with its dictionary.
+func streamObject(d *pdfDict, data []byte) []byte {
+	d.Set("Length", pdfRaw(strconv.Itoa(len(data))))
+	var buf bytes.Buffer
+	writePDFObject(&buf, d)
+	buf.WriteString("\nstream\n")
+	buf.Write(data)
+	buf.WriteString("\nendstream")
+	return buf.Bytes()
+}
+
+// appearanceStream returns the visible signature block as a form XObject.
+func appearanceStream(opts SignOptions, font pdfRef) []byte {
+	w, h := opts.Rect[2]-opts.Rect[0], opts.Rect[3]-opts.Rect[1]
+	lines := []string{"Digitally signed by " + opts.Name, "Date: " + opts.Time.UTC().Format("2006-01-02 15:04:05 MST")}
+	if opts.Reason != "" {
+		lines = append(lines, "Reason: "+opts.Reason)
+	}
+	if opts.Location != "" {
+		lines = append(lines, "Location: "+opts.Location)
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "q 0.16 0.45 0.27 RG 1 w 0.5 0.5 %s %s re S Q\n", pdfNum(w-1), pdfNum(h-1))
+	fmt.Fprintf(&content, "BT /F1 8 Tf 10 TL 6 %s Td\n", pdfNum(h-14))
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("T* ")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", escapePDFLiteral(winAnsi(line)))
+	}
+	content.WriteString("ET")
+
+	resources := newDict()
+	fonts := newDict()
+	fonts.Set("F1", font)
+	resources.Set("Font", fonts)
+	d := newDict()
+	d.Set("Type", pdfName("XObject"))
+	d.Set("Subtype", pdfName("Form"))
+	d.Set("BBox", pdfArray{pdfRaw("0"), pdfRaw("0"), pdfNum(w), pdfNum(h)})
+	d.Set("Resources", resources)
+	return streamObject(d, content.Bytes())
+}
+
+// winAnsi replaces characters Helvetica's WinAnsi encoding lacks.
+func winAnsi(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+func pdfNum(v float64) pdfRaw {
+	return pdfRaw(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// pdfDate formats t as a PDF date string.
+func pdfDate(t time.Time) string {
+	t = t.UTC()
+	return "D:" + t.Format("20060102150405") + "Z"
+}
+
+// trailerID keeps the original file identifier and issues a new one for
+// this revision.
+func trailerID(existing pdfObject) pdfArray {
+	fresh := make([]byte, 16)
+	_, _ = rand.Read(fresh)
+	newID := pdfRaw("<" + hex.EncodeToString(fresh) + ">")
+	if arr, ok := existing.(pdfArray); ok && len(arr) == 2 {
+		return pdfArray{arr[0], newID}
+	}
+	return pdfArray{newID, newID}
+}
+
+// xrefSections groups object numbers into contiguous runs.
+func xrefSections(offsets map[int]int) [][2]int {
+	nums := make([]int, 0, len(offsets))
+	for n := range offsets {
+		nums = append(nums, n)
+	}
+	slices.Sort(nums)
+	var sections [][2]int
+	for _, n := range nums {
+		if l := len(sections); l > 0 && sections[l-1][0]+sections[l-1][1] == n {
+			sections[l-1][1]++
+			continue
+		}
+		sections = append(sections, [2]int{n, 1})
+	}
+	return sections
+}
+
+func writeXrefTable(out *bytes.Buffer, offsets, gens map[int]int, trailer *pdfDict) {
+	out.WriteString("xref\n")
+	for _, s := range xrefSections(offsets) {
+		fmt.Fprintf(out, "%d %d\n", s[0], s[1])
+		for n := s[0]; n < s[0]+s[1]; n++ {
+			fmt.Fprintf(out, "%010d %05d n\r\n", offsets[n], gens[n])
+		}
+	}
+	out.WriteString("trailer\n")
+	writePDFObject(out, trailer)
+	out.WriteString("\n")
+}
+
+// writeXrefStream writes an uncompressed cross-reference stream, used when
+// the original file has one, since such files may have no classic table
+// for a reader to fall back on.
+func writeXrefStream(out *bytes.Buffer, ref pdfRef, size int, offsets, gens map[int]int, trailer *pdfDict) {
+	var index pdfArray
+	var data bytes.Buffer
+	for _, s := range xrefSections(offsets) {
+		index = append(index, pdfRaw(strconv.Itoa(s[0])), pdfRaw(strconv.Itoa(s[1])))
+		for n := s[0]; n < s[0]+s[1]; n++ {
+			off, gen := offsets[n], gens[n]
+			data.Write([]byte{1, byte(off >> 24), byte(off >> 16), byte(off >> 8), byte(off), byte(gen >> 8), byte(gen)})
+		}
+	}
+	d := trailer.clone()
+	d.Set("Type", pdfName("XRef"))
+	d.Set("Size", pdfRaw(strconv.Itoa(size)))
+	d.Set("Index", index)
+	d.Set("W", pdfArray{pdfRaw("1"), pdfRaw("4"), pdfRaw("2")})
+	d.Set("Length", pdfRaw(strconv.Itoa(data.Len())))
+	fmt.Fprintf(out, "%d %d obj\n", ref.Num, ref.Gen)
+	writePDFObject(out, d)
+	out.WriteString("\nstream\n")
+	out.Write(data.Bytes())
+	out.WriteString("\nendstream\nendobj\n")
+}
+
+// patchByteRange writes the final /ByteRange over its placeholder.
+func patchByteRange(out []byte, br [4]int) error {
+	at := bytes.LastIndex(out[:br[1]], []byte(byteRangePlaceholder))
+	if at < 0 {
+		return fmt.Errorf("ByteRange placeholder not found")
+	}
+	value := fmt.Sprintf("[%d %d %d %d]", br[0], br[1], br[2], br[3])
+	if len(value) > len(byteRangePlaceholder) {
+		return fmt.Errorf("ByteRange does not fit its placeholder")
+	}
+	copy(out[at:], value+strings.Repeat(" ", len(byteRangePlaceholder)-len(value)))
+	return nil
+}
@@ -0,0 +1,254 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func testSigner(t *testing.T, cn string, ec bool) *Signer {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	if ec {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Test Registry"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{Key: key, Certificate: cert}
+}
+
+// minimalPDF builds a one-page PDF with a classic cross-reference table.
+func minimalPDF() []byte {
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		"<< /Length 35 >>\nstream\nBT /F1 12 Tf 72 720 Td (Hi) Tj ET\nendstream",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func TestSignPDF(t *testing.T) {
+	ctx := context.Background()
+	for _, ec := range []bool{false, true} {
+		signer := testSigner(t, "Alice Approver", ec)
+		signed, res, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{Reason: "Approved: Verifier review", Location: "Nairobi"})
+		if err != nil {
+			t.Fatalf("ec=%v: %v", ec, err)
+		}
+		if res.Level != PAdESLevelBB {
+			t.Errorf("ec=%v: level = %s", ec, res.Level)
+		}
+
+		// Untrusted until the signer's certificate is a trust anchor.
+		vr, err := VerifyPDFSignatures(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vr.SignedCount != 1 || vr.AllValid {
+			t.Errorf("ec=%v: self-signed certificate should not chain to the system store: %+v", ec, vr.Signatures)
+		}
+
+		vr, err = VerifyPDFSignaturesWithOptions(ctx, signed, VerifyOptions{Roots: []*x509.Certificate{signer.Certificate}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vr.SignedCount != 1 || !vr.AllValid {
+			t.Fatalf("ec=%v: got %+v", ec, vr.Signatures)
+		}
+		sig := vr.Signatures[0]
+		if sig.SignerName != "Alice Approver" || sig.SignerRole != "Approved: Verifier review" || sig.Level != PAdESLevelBB || !sig.CoversWholeDocument {
+			t.Errorf("ec=%v: signature info %+v", ec, sig)
+		}
+
+		// Any change to the signed bytes breaks the signature.
+		tampered := bytes.Replace(signed, []byte("(Hi)"), []byte("(Ho)"), 1)
+		vr, _ = VerifyPDFSignaturesWithOptions(ctx, tampered, VerifyOptions{Roots: []*x509.Certificate{signer.Certificate}})
+		if vr.AllValid {
+			t.Errorf("ec=%v: tampered document verified", ec)
+		}
+	}
+}
+
+func TestSignPDFTwice(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := testSigner(t, "Alice", false), testSigner(t, "Bob", true)
+	once, _, err := SignPDF(ctx, minimalPDF(), alice, SignOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	twice, _, err := SignPDF(ctx, once, bob, SignOptions{Rect: [4]float64{300, 36, 550, 106}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vr, err := VerifyPDFSignaturesWithOptions(ctx, twice, VerifyOptions{Roots: []*x509.Certificate{alice.Certificate, bob.Certificate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vr.SignedCount != 2 || !vr.AllValid {
+		t.Fatalf("got %+v", vr.Signatures)
+	}
+	if vr.Signatures[0].CoversWholeDocument || !vr.Signatures[1].CoversWholeDocument {
+		t.Errorf("only the last signature should cover the whole file: %+v", vr.Signatures)
+	}
+}
+
+func TestSignPDFWithTimestamp(t *testing.T) {
+	ctx := context.Background()
+	tsa := testSigner(t, "Test TSA", false)
+	genTime := time.Now().UTC().Truncate(time.Second)
+	signer := testSigner(t, "Alice", false)
+	signed, res, err := SignPDF(ctx, minimalPDF(), signer, SignOptions{TSA: testTSA(t, tsa, genTime)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Level != PAdESLevelBT || res.TimestampTime == nil || !res.TimestampTime.Equal(genTime) {
+		t.Errorf("sign result %+v", res)
+	}
+
+	tests := []struct {
+		name  string
+		roots []*x509.Certificate
+		level string
+		at    time.Time
+	}{
+		{"trusted TSA", []*x509.Certificate{signer.Certificate, tsa.Certificate}, PAdESLevelBT, genTime},
+		{"untrusted TSA", []*x509.Certificate{signer.Certificate}, PAdESLevelBB, time.Time{}},
+	}
+	for _, tt := range tests {
+		vr, err := VerifyPDFSignaturesWithOptions(ctx, signed, VerifyOptions{Roots: tt.roots})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := vr.Signatures[0]
+		if !vr.AllValid || sig.Level != tt.level || sig.TimestampTime == nil {
+			t.Errorf("%s: got %+v", tt.name, sig)
+		}
+		if !tt.at.IsZero() && !sig.ValidationTime.Equal(tt.at) {
+			t.Errorf("%s: validation time %s, want %s", tt.name, sig.ValidationTime, tt.at)
+		}
+	}
+}
+
+// testTSA serves RFC 3161 tokens signed by tsa with a fixed genTime.
+func testTSA(t *testing.T, tsa *Signer, genTime time.Time) *TSAClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		in := cryptobyte.String(body)
+		var req, imprint, alg cryptobyte.String
+		var version int64
+		var digest []byte
+		if !in.ReadASN1(&req, cbasn1.SEQUENCE) || !req.ReadASN1Integer(&version) || !req.ReadASN1(&imprint, cbasn1.SEQUENCE) ||
+			!imprint.ReadASN1(&alg, cbasn1.SEQUENCE) || !imprint.ReadASN1Bytes(&digest, cbasn1.OCTET_STRING) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		tst := testTSTInfo(digest, genTime)
+		contentDigest := sha256.Sum256(tst)
+		token, err := buildSignedData(signedDataParams{signer: tsa, contentType: oidTSTInfo, content: tst, digest: contentDigest[:]})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var resp cryptobyte.Builder
+		resp.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1(cbasn1.SEQUENCE, func(status *cryptobyte.Builder) { status.AddASN1Int64(0) })
+			b.AddBytes(token)
+		})
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(resp.BytesOrPanic())
+	}))
+	t.Cleanup(srv.Close)
+	return &TSAClient{URL: srv.URL}
+}
+
+func TestParseSignerPEM(t *testing.T) {
+	signer := testSigner(t, "Alice", true)
+	keyPEM, err := signer.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := testSigner(t, "Root", false)
+	bundle := append(append(append([]byte{}, other.CertificatesPEM()...), keyPEM...), signer.CertificatesPEM()...)
+	parsed, err := ParseSignerPEM(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Certificate.Subject.CommonName != "Alice" || len(parsed.Chain) != 1 {
+		t.Errorf("signer %s with %d chain certificate(s)", parsed.Certificate.Subject.CommonName, len(parsed.Chain))
+	}
+	if _, err := ParseSignerPEM(other.CertificatesPEM()); err == nil {
+		t.Error("bundle without a key should be rejected")
+	}
+	if _, err := ParseSignerPEM(append(keyPEM, other.CertificatesPEM()...)); err == nil {
+		t.Error("certificate not matching the key should be rejected")
+	}
+}
+
+func testTSTInfo(digest []byte, genTime time.Time) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(tst *cryptobyte.Builder) {
+		tst.AddASN1Int64(1)
+		tst.AddASN1ObjectIdentifier([]int{1, 3, 6, 1, 4, 1, 0, 1})
+		addMessageImprint(tst, digest)
+		tst.AddASN1Int64(42)
+		tst.AddASN1GeneralizedTime(genTime)
+	})
+	return b.BytesOrPanic()
+}
@@ -0,0 +1,550 @@
+package security
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This file holds the small subset of PDF object syntax needed to append a
+// signature as an incremental update: reading the trailer, resolving the
+// catalog, page and form dictionaries and the streams of the document
+// security store, and writing them back. Objects inside compressed object
+// streams are not supported.
+
+// ErrUnsupportedPDF is returned for PDFs this signer cannot update, such as
+// those whose catalog or pages live in compressed object streams.
+var ErrUnsupportedPDF = errors.New("unsupported PDF structure")
+
+type pdfObject any
+
+// pdfName is a name object without its leading slash.
+type pdfName string
+
+// pdfRef is an indirect reference "num gen R".
+type pdfRef struct{ Num, Gen int }
+
+// pdfRaw keeps numbers, strings, booleans and null exactly as written.
+type pdfRaw string
+
+type pdfArray []pdfObject
+
+// pdfDict keeps keys in their original order so rewritten objects stay
+// recognisable.
+type pdfDict struct {
+	keys []string
+	vals map[string]pdfObject
+}
+
+func newDict() *pdfDict { return &pdfDict{vals: map[string]pdfObject{}} }
+
+func (d *pdfDict) Get(key string) pdfObject { return d.vals[key] }
+
+func (d *pdfDict) Set(key string, v pdfObject) {
+	if _, ok := d.vals[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.vals[key] = v
+}
+
+func (d *pdfDict) clone() *pdfDict {
+	c := newDict()
+	for _, k := range d.keys {
+		c.Set(k, d.vals[k])
+	}
+	return c
+}
+
+// ─── Parsing ──────────────────────────────────────────────────────────────────
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+func (l *pdfLexer) token() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func (l *pdfLexer) parse(depth int) (pdfObject, error) {
+	if depth > 64 {
+		return nil, fmt.Errorf("%w: objects nested too deeply", ErrUnsupportedPDF)
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrUnsupportedPDF)
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(l.token()), nil
+	case bytes.HasPrefix(l.data[l.pos:], []byte("<<")):
+		l.pos += 2
+		d := newDict()
+		for {
+			l.skipSpace()
+			if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+				l.pos += 2
+				return d, nil
+			}
+			key, err := l.parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(pdfName)
+			if !ok {
+				return nil, fmt.Errorf("%w: dictionary key is not a name", ErrUnsupportedPDF)
+			}
+			val, err := l.parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			d.Set(string(name), val)
+		}
+	case c == '<':
+		end := bytes.IndexByte(l.data[l.pos:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated hex string", ErrUnsupportedPDF)
+		}
+		raw := pdfRaw(l.data[l.pos : l.pos+end+1])
+		l.pos += end + 1
+		return raw, nil
+	case c == '(':
+		start, nesting := l.pos, 0
+		for ; l.pos < len(l.data); l.pos++ {
+			switch l.data[l.pos] {
+			case '\\':
+				l.pos++
+			case '(':
+				nesting++
+			case ')':
+				nesting--
+				if nesting == 0 {
+					l.pos++
+					return pdfRaw(l.data[start:l.pos]), nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%w: unterminated string", ErrUnsupportedPDF)
+	case c == '[':
+		l.pos++
+		var arr pdfArray
+		for {
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == ']' {
+				l.pos++
+				return arr, nil
+			}
+			v, err := l.parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	default:
+		tok := l.token()
+		if tok == "" {
+			return nil, fmt.Errorf("%w: unexpected %q", ErrUnsupportedPDF, c)
+		}
+		// "num gen R" is a reference; anything else numeric stays raw.
+		if num, err := strconv.Atoi(tok); err == nil {
+			save := l.pos
+			l.skipSpace()
+			if gen, err := strconv.Atoi(l.token()); err == nil {
+				l.skipSpace()
+				if l.token() == "R" {
+					return pdfRef{Num: num, Gen: gen}, nil
+				}
+			}
+			l.pos = save
+		}
+		return pdfRaw(tok), nil
+	}
+}
+
+// ─── Writing ──────────────────────────────────────────────────────────────────
+
+func writePDFObject(buf *bytes.Buffer, v pdfObject) {
+	switch v := v.(type) {
+	case pdfName:
+		buf.WriteByte('/')
+		buf.WriteString(string(v))
+	case pdfRef:
+		fmt.Fprintf(buf, "%d %d R", v.Num, v.Gen)
+	case pdfRaw:
+		buf.WriteString(string(v))
+	case pdfArray:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writePDFObject(buf, e)
+		}
+		buf.WriteByte(']')
+	case *pdfDict:
+		buf.WriteString("<<")
+		for _, k := range v.keys {
+			buf.WriteString(" /")
+			buf.WriteString(k)
+			buf.WriteByte(' ')
+			writePDFObject(buf, v.vals[k])
+		}
+		buf.WriteString(" >>")
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// pdfText encodes s as a PDF text string: a literal string for ASCII, and
+// UTF-16BE with a byte order mark otherwise.
+func pdfText(s string) pdfRaw {
+	ascii := true
+	for _, r := range s {
+		if r > 126 || (r < 32 && r != '\t') {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return pdfRaw("(" + escapePDFLiteral(s) + ")")
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return pdfRaw(b.String())
+}
+
+// pdfString decodes a literal or hex string object to text.
+func pdfString(v pdfObject) string {
+	raw := string(rawOf(v))
+	var b []byte
+	switch {
+	case strings.HasPrefix(raw, "("):
+		b = unescapePDFLiteral(raw[1 : len(raw)-1])
+	case strings.HasPrefix(raw, "<"):
+		b = hexDecode(raw[1 : len(raw)-1])
+	default:
+		return ""
+	}
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func unescapePDFLiteral(s string) []byte {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			out = append(out, c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\n', '\r':
+			// Line continuation.
+		default:
+			if s[i] >= '0' && s[i] <= '7' {
+				n, j := 0, i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					n = n*8 + int(s[j]-'0')
+				}
+				out = append(out, byte(n))
+				i = j - 1
+				continue
+			}
+			out = append(out, s[i])
+		}
+	}
+	return out
+}
+
+func escapePDFLiteral(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+// ─── Document structure ───────────────────────────────────────────────────────
+
+// pdfFile is a read-only view of an existing PDF.
+type pdfFile struct {
+	data       []byte
+	root       pdfRef
+	size       int
+	prevXref   int
+	xrefStream bool
+	info       pdfObject // optional /Info reference
+	id         pdfObject // optional /ID array
+}
+
+var startxrefRe = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF`)
+
+// openPDF reads the trailer of the latest revision.
+func openPDF(data []byte) (*pdfFile, error) {
+	if !isPDF(data) {
+		return nil, fmt.Errorf("content does not appear to be a valid PDF")
+	}
+	all := startxrefRe.FindAllSubmatch(data, -1)
+	if len(all) == 0 {
+		return nil, fmt.Errorf("%w: startxref not found", ErrUnsupportedPDF)
+	}
+	offset, _ := strconv.Atoi(string(all[len(all)-1][1]))
+	if offset <= 0 || offset >= len(data) {
+		return nil, fmt.Errorf("%w: invalid startxref offset", ErrUnsupportedPDF)
+	}
+	f := &pdfFile{data: data, prevXref: offset}
+
+	var trailer *pdfDict
+	rest := data[offset:]
+	if bytes.HasPrefix(rest, []byte("xref")) {
+		idx := bytes.Index(rest, []byte("trailer"))
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: trailer not found", ErrUnsupportedPDF)
+		}
+		l := &pdfLexer{data: rest, pos: idx + len("trailer")}
+		v, err := l.parse(0)
+		if err != nil {
+			return nil, err
+		}
+		trailer, _ = v.(*pdfDict)
+	} else {
+		// Cross-reference stream: "n g obj << /Type /XRef ... >> stream".
+		l := &pdfLexer{data: rest}
+		l.token()
+		l.skipSpace()
+		l.token()
+		l.skipSpace()
+		if l.token() != "obj" {
+			return nil, fmt.Errorf("%w: startxref does not point at a cross-reference", ErrUnsupportedPDF)
+		}
+		v, err := l.parse(0)
+		if err != nil {
+			return nil, err
+		}
+		trailer, _ = v.(*pdfDict)
+		f.xrefStream = true
+	}
+	if trailer == nil {
+		return nil, fmt.Errorf("%w: trailer is not a dictionary", ErrUnsupportedPDF)
+	}
+	root, ok := trailer.Get("Root").(pdfRef)
+	if !ok {
+		return nil, fmt.Errorf("%w: trailer has no /Root", ErrUnsupportedPDF)
+	}
+	size, err := strconv.Atoi(string(rawOf(trailer.Get("Size"))))
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("%w: trailer has no /Size", ErrUnsupportedPDF)
+	}
+	f.root, f.size = root, size
+	f.info, f.id = trailer.Get("Info"), trailer.Get("ID")
+	return f, nil
+}
+
+func rawOf(v pdfObject) pdfRaw {
+	r, _ := v.(pdfRaw)
+	return r
+}
+
+// object returns the latest definition of an uncompressed indirect object.
+func (f *pdfFile) object(ref pdfRef) (pdfObject, error) {
+	at, err := f.objectOffset(ref)
+	if err != nil {
+		return nil, err
+	}
+	l := &pdfLexer{data: f.data, pos: at}
+	return l.parse(0)
+}
+
+// objectOffset returns the position just after the "obj" keyword of the
+// latest definition of ref.
+func (f *pdfFile) objectOffset(ref pdfRef) (int, error) {
+	re := regexp.MustCompile(fmt.Sprintf(`(?:^|[\s>\]])%d\s+%d\s+obj\b`, ref.Num, ref.Gen))
+	locs := re.FindAllIndex(f.data, -1)
+	if len(locs) == 0 {
+		return 0, fmt.Errorf("%w: object %d %d not found (compressed object streams are not supported)", ErrUnsupportedPDF, ref.Num, ref.Gen)
+	}
+	return locs[len(locs)-1][1], nil
+}
+
+// stream returns the decoded data of a stream object. Only unfiltered and
+// FlateDecode streams without predictors are supported.
+func (f *pdfFile) stream(ref pdfRef) ([]byte, error) {
+	at, err := f.objectOffset(ref)
+	if err != nil {
+		return nil, err
+	}
+	l := &pdfLexer{data: f.data, pos: at}
+	obj, err := l.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := obj.(*pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: object %d is not a stream", ErrUnsupportedPDF, ref.Num)
+	}
+	l.skipSpace()
+	if l.token() != "stream" {
+		return nil, fmt.Errorf("%w: object %d is not a stream", ErrUnsupportedPDF, ref.Num)
+	}
+	// The keyword is followed by CRLF or LF.
+	if bytes.HasPrefix(f.data[l.pos:], []byte("\r\n")) {
+		l.pos += 2
+	} else if l.pos < len(f.data) && f.data[l.pos] == '\n' {
+		l.pos++
+	}
+	lengthObj, err := f.resolve(d.Get("Length"))
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(string(rawOf(lengthObj)))
+	if err != nil || n < 0 || l.pos+n > len(f.data) {
+		return nil, fmt.Errorf("%w: stream %d has an invalid /Length", ErrUnsupportedPDF, ref.Num)
+	}
+	data := f.data[l.pos : l.pos+n]
+
+	filter := d.Get("Filter")
+	if arr, ok := filter.(pdfArray); ok && len(arr) == 1 {
+		filter = arr[0]
+	}
+	switch filter {
+	case nil:
+		return data, nil
+	case pdfName("FlateDecode"):
+		if d.Get("DecodeParms") != nil {
+			return nil, fmt.Errorf("%w: stream %d uses a predictor", ErrUnsupportedPDF, ref.Num)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: stream %d: %v", ErrUnsupportedPDF, ref.Num, err)
+		}
+		defer zr.Close()
+		return io.ReadAll(io.LimitReader(zr, 16<<20))
+	default:
+		return nil, fmt.Errorf("%w: stream %d uses an unsupported filter", ErrUnsupportedPDF, ref.Num)
+	}
+}
+
+func (f *pdfFile) resolve(v pdfObject) (pdfObject, error) {
+	if ref, ok := v.(pdfRef); ok {
+		return f.object(ref)
+	}
+	return v, nil
+}
+
+func (f *pdfFile) dict(v pdfObject, what string) (*pdfDict, error) {
+	obj, err := f.resolve(v)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := obj.(*pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a dictionary", ErrUnsupportedPDF, what)
+	}
+	return d, nil
+}
+
+// page returns the reference and dictionary of the 1-based page n.
+func (f *pdfFile) page(n int) (pdfRef, *pdfDict, error) {
+	catalog, err := f.dict(f.root, "catalog")
+	if err != nil {
+		return pdfRef{}, nil, err
+	}
+	pagesRef, ok := catalog.Get("Pages").(pdfRef)
+	if !ok {
+		return pdfRef{}, nil, fmt.Errorf("%w: catalog has no /Pages", ErrUnsupportedPDF)
+	}
+	remaining := n
+	var walk func(ref pdfRef, depth int) (pdfRef, *pdfDict, error)
+	walk = func(ref pdfRef, depth int) (pdfRef, *pdfDict, error) {
+		if depth > 32 {
+			return pdfRef{}, nil, fmt.Errorf("%w: page tree too deep", ErrUnsupportedPDF)
+		}
+		node, err := f.dict(ref, "page tree node")
+		if err != nil {
+			return pdfRef{}, nil, err
+		}
+		if node.Get("Type") == pdfName("Page") {
+			remaining--
+			if remaining == 0 {
+				return ref, node, nil
+			}
+			return pdfRef{}, nil, nil
+		}
+		kids, err := f.resolve(node.Get("Kids"))
+		if err != nil {
+			return pdfRef{}, nil, err
+		}
+		arr, _ := kids.(pdfArray)
+		for _, k := range arr {
+			kref, ok := k.(pdfRef)
+			if !ok {
+				continue
+			}
+			if r, d, err := walk(kref, depth+1); err != nil || d != nil {
+				return r, d, err
+			}
+		}
+		return pdfRef{}, nil, nil
+	}
+	ref, page, err := walk(pagesRef, 0)
+	if err != nil {
+		return pdfRef{}, nil, err
+	}
+	if page == nil {
+		return pdfRef{}, nil, fmt.Errorf("page %d does not exist", n)
+	}
+	return ref, page, nil
+}
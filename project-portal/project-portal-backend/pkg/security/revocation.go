@@ -0,0 +1,284 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// Revocation statuses.
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+)
+
+// Revocation sources, in the order they are consulted.
+const (
+	RevocationSourceEmbeddedOCSP = "embedded_ocsp" // OCSP response in the document security store
+	RevocationSourceEmbeddedCRL  = "embedded_crl"  // CRL in the document security store
+	RevocationSourceOCSP         = "ocsp"
+	RevocationSourceCRL          = "crl"
+)
+
+// RevocationInfo is the revocation status of one certificate.
+type RevocationInfo struct {
+	Subject    string     `json:"subject"`
+	Status     string     `json:"status"`
+	Source     string     `json:"source,omitempty"`
+	Cached     bool       `json:"cached,omitempty"`
+	ThisUpdate time.Time  `json:"this_update,omitempty"`
+	NextUpdate *time.Time `json:"next_update,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Reason     int        `json:"reason,omitempty"` // RFC 5280 CRLReason
+
+	raw []byte // the OCSP response or CRL the status came from
+}
+
+// RevocationCache stores OCSP responses and CRLs until they expire.
+type RevocationCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Put(ctx context.Context, key string, der []byte, expires time.Time)
+}
+
+// MemoryRevocationCache is an in-process RevocationCache.
+type MemoryRevocationCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	der     []byte
+	expires time.Time
+}
+
+// NewMemoryRevocationCache returns an empty in-process cache.
+func NewMemoryRevocationCache() *MemoryRevocationCache {
+	return &MemoryRevocationCache{entries: map[string]memoryCacheEntry{}}
+}
+
+// Get returns an unexpired entry.
+func (c *MemoryRevocationCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.der, true
+}
+
+// Put stores der until expires.
+func (c *MemoryRevocationCache) Put(_ context.Context, key string, der []byte, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memoryCacheEntry{der: der, expires: expires}
+}
+
+// RevocationChecker fetches certificate status from the OCSP responders
+// and CRL distribution points named in certificates, falling back from
+// OCSP to CRL. Responses are kept in Cache until their nextUpdate, or for
+// DefaultTTL when they have none.
+type RevocationChecker struct {
+	HTTPClient *http.Client
+	Cache      RevocationCache
+	DefaultTTL time.Duration
+}
+
+// NewRevocationChecker returns a checker with an in-process cache.
+func NewRevocationChecker() *RevocationChecker {
+	return &RevocationChecker{Cache: NewMemoryRevocationCache()}
+}
+
+// Check returns the status of cert, issued by issuer, from the network or
+// the cache.
+func (r *RevocationChecker) Check(ctx context.Context, cert, issuer *x509.Certificate) (*RevocationInfo, error) {
+	var errs []error
+	if len(cert.OCSPServer) > 0 {
+		info, err := r.checkOCSP(ctx, cert, issuer)
+		if err == nil {
+			return info, nil
+		}
+		errs = append(errs, err)
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		info, err := r.checkCRL(ctx, url, cert, issuer)
+		if err == nil {
+			return info, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("certificate %q names no OCSP responder or CRL distribution point", cert.Subject.CommonName)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (r *RevocationChecker) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate) (*RevocationInfo, error) {
+	key := "ocsp:" + issuerKeyHash(issuer) + ":" + cert.SerialNumber.Text(16)
+	if der, ok := r.cacheGet(ctx, key); ok {
+		if info, err := ocspStatus(der, cert, issuer); err == nil {
+			info.Source, info.Cached = RevocationSourceOCSP, true
+			return info, nil
+		}
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OCSP request: %w", err)
+	}
+	der, err := r.fetch(ctx, http.MethodPost, cert.OCSPServer[0], "application/ocsp-request", req)
+	if err != nil {
+		return nil, fmt.Errorf("OCSP request failed: %w", err)
+	}
+	info, err := ocspStatus(der, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	info.Source = RevocationSourceOCSP
+	r.cachePut(ctx, key, der, info)
+	return info, nil
+}
+
+func (r *RevocationChecker) checkCRL(ctx context.Context, url string, cert, issuer *x509.Certificate) (*RevocationInfo, error) {
+	key := "crl:" + url
+	if der, ok := r.cacheGet(ctx, key); ok {
+		if info, err := crlStatus(der, cert, issuer); err == nil {
+			info.Source, info.Cached = RevocationSourceCRL, true
+			return info, nil
+		}
+	}
+	der, err := r.fetch(ctx, http.MethodGet, url, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("CRL download failed: %w", err)
+	}
+	info, err := crlStatus(der, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	info.Source = RevocationSourceCRL
+	r.cachePut(ctx, key, info.raw, info)
+	return info, nil
+}
+
+func (r *RevocationChecker) fetch(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	client := r.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+}
+
+func (r *RevocationChecker) cacheGet(ctx context.Context, key string) ([]byte, bool) {
+	if r.Cache == nil {
+		return nil, false
+	}
+	return r.Cache.Get(ctx, key)
+}
+
+func (r *RevocationChecker) cachePut(ctx context.Context, key string, der []byte, info *RevocationInfo) {
+	if r.Cache == nil {
+		return
+	}
+	expires := time.Now().Add(r.defaultTTL())
+	if info.NextUpdate != nil {
+		expires = *info.NextUpdate
+	}
+	if expires.After(time.Now()) {
+		r.Cache.Put(ctx, key, der, expires)
+	}
+}
+
+func (r *RevocationChecker) defaultTTL() time.Duration {
+	if r.DefaultTTL > 0 {
+		return r.DefaultTTL
+	}
+	return time.Hour
+}
+
+// ocspStatus parses an OCSP response for cert and checks the responder's
+// signature.
+func ocspStatus(der []byte, cert, issuer *x509.Certificate) (*RevocationInfo, error) {
+	resp, err := ocsp.ParseResponseForCert(der, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCSP response: %w", err)
+	}
+	info := &RevocationInfo{Subject: cert.Subject.String(), ThisUpdate: resp.ThisUpdate, raw: der}
+	if !resp.NextUpdate.IsZero() {
+		next := resp.NextUpdate
+		info.NextUpdate = &next
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		info.Status = RevocationGood
+	case ocsp.Revoked:
+		at := resp.RevokedAt
+		info.Status, info.RevokedAt, info.Reason = RevocationRevoked, &at, resp.RevocationReason
+	default:
+		info.Status = RevocationUnknown
+	}
+	return info, nil
+}
+
+// crlStatus looks cert up in a DER or PEM CRL signed by issuer.
+func crlStatus(der []byte, cert, issuer *x509.Certificate) (*RevocationInfo, error) {
+	if block, _ := pem.Decode(der); block != nil && block.Type == "X509 CRL" {
+		der = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL: %w", err)
+	}
+	if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+		return nil, fmt.Errorf("CRL is not for this certificate's issuer")
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL signature is invalid: %w", err)
+	}
+	info := &RevocationInfo{Subject: cert.Subject.String(), Status: RevocationGood, ThisUpdate: crl.ThisUpdate, raw: der}
+	if !crl.NextUpdate.IsZero() {
+		next := crl.NextUpdate
+		info.NextUpdate = &next
+	}
+	for _, e := range crl.RevokedCertificateEntries {
+		if e.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			at := e.RevocationTime
+			info.Status, info.RevokedAt, info.Reason = RevocationRevoked, &at, e.ReasonCode
+			break
+		}
+	}
+	return info, nil
+}
+
+func issuerKeyHash(issuer *x509.Certificate) string {
+	h := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(h[:8])
+}
@@ -4,26 +4,82 @@ package security
 
 import (
 	"bytes"
+	"context"
 	"crypto/x509"
 	"encoding/asn1"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// SignatureInfo holds the extracted details of a single PDF digital signature.
+// SignatureInfo holds the extracted details of a single PDF digital
+// signature or document timestamp, with the outcome of each validation
+// check.
 type SignatureInfo struct {
-	SignerName         string    `json:"signer_name"`
-	SignerEmail        string    `json:"signer_email"`
-	SignerRole         string    `json:"signer_role"`
-	CertificateIssuer  string    `json:"certificate_issuer"`
-	CertificateSubject string    `json:"certificate_subject"`
-	SigningTime        time.Time `json:"signing_time"`
-	IsValid            bool      `json:"is_valid"`
-	FailureReason      string    `json:"failure_reason,omitempty"`
-	RawCertPEM         string    `json:"-"` // not exposed in API responses
+	Type                string            `json:"type"` // SignatureTypeSignature or SignatureTypeDocumentTimestamp
+	SignerName          string            `json:"signer_name"`
+	SignerEmail         string            `json:"signer_email"`
+	SignerRole          string            `json:"signer_role"`
+	CertificateIssuer   string            `json:"certificate_issuer"`
+	CertificateSubject  string            `json:"certificate_subject"`
+	CertificateChain    []string          `json:"certificate_chain,omitempty"` // subjects from the signer to the trust anchor
+	SigningTime         time.Time         `json:"signing_time"`
+	SubFilter           string            `json:"sub_filter,omitempty"`
+	Level               string            `json:"level,omitempty"` // PAdES baseline level, when the signature is PAdES
+	TimestampTime       *time.Time        `json:"timestamp_time,omitempty"`
+	TimestampAuthority  string            `json:"timestamp_authority,omitempty"`
+	ValidationTime      time.Time         `json:"validation_time"` // when the chain was validated: the timestamp if trusted, otherwise now
+	CoversWholeDocument bool              `json:"covers_whole_document"`
+	Indication          string            `json:"indication"`
+	Checks              []ValidationCheck `json:"checks"`
+	Revocation          []RevocationInfo  `json:"revocation,omitempty"`
+	IsValid             bool              `json:"is_valid"`
+	FailureReason       string            `json:"failure_reason,omitempty"`
+	RawCertPEM          string            `json:"-"` // not exposed in API responses
+
+	end          int  // end of the revision the signature covers
+	structureOK  bool // byte range, signature value and certificate binding verified
+	pades        bool // ETSI.CAdES.detached with a signing-certificate-v2 attribute
+	existence    bool // a trusted timestamp proves when the signature existed
+	embeddedLTV  bool // the DSS after the signature holds its validation data
+	trustedStamp bool // document timestamp whose authority is trusted
+}
+
+// Signature types.
+const (
+	SignatureTypeSignature         = "signature"
+	SignatureTypeDocumentTimestamp = "document_timestamp"
+)
+
+// Validation indications, after ETSI EN 319 102-1.
+const (
+	IndicationPassed        = "TOTAL-PASSED"
+	IndicationFailed        = "TOTAL-FAILED"
+	IndicationIndeterminate = "INDETERMINATE"
+)
+
+// Validation check statuses.
+const (
+	CheckPassed        = "passed"
+	CheckFailed        = "failed"
+	CheckIndeterminate = "indeterminate"
+	CheckWarning       = "warning"
+	CheckSkipped       = "skipped"
+)
+
+// ValidationCheck is the outcome of one validation step: format,
+// byte_range, signature, signing_certificate, timestamp, certificate_chain
+// or revocation.
+type ValidationCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
 }
 
 // VerificationResult wraps the full list of signature results for a document.
@@ -32,18 +88,43 @@ type VerificationResult struct {
 	Signatures  []SignatureInfo `json:"signatures"`
 	AllValid    bool            `json:"all_valid"`
 	SignedCount int             `json:"signed_count"`
+	HasDSS      bool            `json:"has_dss"` // the document embeds long-term validation data
 	VerifiedAt  time.Time       `json:"verified_at"`
 }
 
+// VerifyOptions adjusts signature verification.
+type VerifyOptions struct {
+	// Roots are trusted in addition to the system trust store.
+	Roots []*x509.Certificate
+	// Revocation fetches OCSP and CRL status for certificates the
+	// document holds no revocation data for. Nil checks embedded data only.
+	Revocation *RevocationChecker
+	// RequireRevocation makes a missing revocation status INDETERMINATE;
+	// otherwise it is reported as a warning.
+	RequireRevocation bool
+}
+
 // VerifyPDFSignatures analyses pdfBytes for embedded digital signatures.
 // It returns a VerificationResult containing one SignatureInfo per signature found.
 //
 // Implementation notes:
-//   - Pure-Go PDF signature detection via byte-pattern scanning of the cross-reference stream.
-//   - Certificate chain validated against the system trust store via crypto/x509.
-//   - Supports PAdES/CMS signatures embedded in /ByteRange + /Contents PDF fields.
+//   - Signature dictionaries are located by parsing the PDF objects that declare /Type /Sig
+//     or /Type /DocTimeStamp.
+//   - The CMS signature is checked against the bytes named by /ByteRange, so any change
+//     to the signed revision invalidates it.
+//   - An embedded RFC 3161 signature timestamp must cover the signature value. When its
+//     authority is trusted, the certificate chain is validated at the timestamp rather
+//     than now, so signatures stay valid after their certificates expire.
+//   - Revocation status comes from the document security store (DSS) and, when
+//     configured, from OCSP responders and CRLs.
 //   - When no digital signatures are found the result is returned with SignedCount=0 and AllValid=true.
 func VerifyPDFSignatures(pdfBytes []byte) (*VerificationResult, error) {
+	return VerifyPDFSignaturesWithOptions(context.Background(), pdfBytes, VerifyOptions{})
+}
+
+// VerifyPDFSignaturesWithOptions is VerifyPDFSignatures with additional
+// trust anchors and revocation checking.
+func VerifyPDFSignaturesWithOptions(ctx context.Context, pdfBytes []byte, opts VerifyOptions) (*VerificationResult, error) {
 	if len(pdfBytes) == 0 {
 		return nil, fmt.Errorf("PDF content is empty")
 	}
@@ -55,10 +136,29 @@ func VerifyPDFSignatures(pdfBytes []byte) (*VerificationResult, error) {
 	result := &VerificationResult{
 		VerifiedAt: time.Now().UTC(),
 	}
+	// A DSS that cannot be read is treated as absent.
+	dss, _ := readDSS(pdfBytes)
+	result.HasDSS = dss != nil
 
+	// Document timestamps first: they can prove when earlier signatures
+	// existed.
+	var stamps []SignatureInfo
 	for _, raw := range rawSigs {
-		info := verifySignatureBlock(raw)
-		result.Signatures = append(result.Signatures, info)
+		if raw.docTimestamp {
+			stamps = append(stamps, verifyDocumentTimestamp(ctx, raw, pdfBytes, opts, dss))
+		}
+	}
+	next := 0
+	for _, raw := range rawSigs {
+		if raw.docTimestamp {
+			result.Signatures = append(result.Signatures, stamps[next])
+			next++
+			continue
+		}
+		result.Signatures = append(result.Signatures, verifySignatureBlock(ctx, raw, pdfBytes, opts, dss, stamps))
+	}
+	for i := range result.Signatures {
+		result.Signatures[i].Level = conformanceLevel(result.Signatures[i], result.Signatures, dss)
 	}
 
 	result.SignedCount = len(result.Signatures)
@@ -66,6 +166,25 @@ func VerifyPDFSignatures(pdfBytes []byte) (*VerificationResult, error) {
 	return result, nil
 }
 
+// conformanceLevel classifies a signature into the PAdES baseline levels.
+func conformanceLevel(info SignatureInfo, all []SignatureInfo, dss *documentSecurityStore) string {
+	if info.Type != SignatureTypeSignature || !info.pades || !info.structureOK {
+		return ""
+	}
+	if !info.existence {
+		return PAdESLevelBB
+	}
+	if !info.embeddedLTV {
+		return PAdESLevelBT
+	}
+	for _, s := range all {
+		if s.Type == SignatureTypeDocumentTimestamp && s.trustedStamp && s.end > dss.offset {
+			return PAdESLevelBLTA
+		}
+	}
+	return PAdESLevelBLT
+}
+
 // --- internal helpers -------------------------------------------------------
 
 // isPDF checks the PDF magic bytes.
@@ -75,105 +194,348 @@ func isPDF(data []byte) bool {
 
 // rawSignatureBlock carries bytes extracted from a /Sig dictionary.
 type rawSignatureBlock struct {
-	contentsHex string // hex-encoded /Contents value (CMS blob)
-	signerName  string // /Name field in /Sig, if present
-	signerRole  string // /Reason field
-	signingTime string // /M field (PDF date string)
+	contents     []byte // decoded /Contents value (CMS blob)
+	byteRange    []int  // /ByteRange offsets and lengths
+	subFilter    string // /SubFilter, e.g. ETSI.CAdES.detached
+	signerName   string // /Name field in /Sig, if present
+	signerRole   string // /Reason field
+	signingTime  string // /M field (PDF date string)
+	docTimestamp bool   // /Type /DocTimeStamp
 }
 
-// extractRawSignatures scans for /Sig dictionaries in the PDF byte stream.
-// This is a heuristic scanner — it does not implement a full PDF parser,
-// but is sufficient to detect common PAdES/CMS signatures in practice.
+var (
+	objHeaderRe = regexp.MustCompile(`(?:^|[\s>\]])\d+\s+\d+\s+obj\b`)
+	sigTypeRe   = regexp.MustCompile(`/Type\s*/(?:Sig|DocTimeStamp)\b`)
+)
+
+// extractRawSignatures finds /Sig and /DocTimeStamp dictionaries in the PDF
+// byte stream. Each /Type marker is resolved to the indirect object
+// containing it, which is parsed either as the signature dictionary itself
+// or as a signature field holding it inline in /V.
 func extractRawSignatures(pdfBytes []byte) []rawSignatureBlock {
 	var blocks []rawSignatureBlock
 
-	// Match /Type /Sig marker to find signature dictionaries.
-	sigMarker := regexp.MustCompile(`/Type\s*/Sig`)
-	nameRe := regexp.MustCompile(`/Name\s*\(([^)]*)\)`)
-	reasonRe := regexp.MustCompile(`/Reason\s*\(([^)]*)\)`)
-	dateRe := regexp.MustCompile(`/M\s*\(([^)]*)\)`)
-	contentsRe := regexp.MustCompile(`/Contents\s*<([0-9A-Fa-f\s]+)>`)
-
-	content := string(pdfBytes)
-	locs := sigMarker.FindAllStringIndex(content, -1)
-
-	for _, loc := range locs {
-		// Take a window around each /Type /Sig match to parse associated fields.
-		start := loc[0]
-		end := start + 4096
-		if end > len(content) {
-			end = len(content)
+	isSig := func(d *pdfDict) bool {
+		t := d.Get("Type")
+		return t == pdfName("Sig") || t == pdfName("DocTimeStamp")
+	}
+	headers := objHeaderRe.FindAllIndex(pdfBytes, -1)
+	seen := map[int]bool{}
+	for _, loc := range sigTypeRe.FindAllIndex(pdfBytes, -1) {
+		// The object header is the last one starting before the marker.
+		i := sort.Search(len(headers), func(i int) bool { return headers[i][0] >= loc[0] }) - 1
+		if i < 0 || seen[i] {
+			continue
+		}
+		seen[i] = true
+		l := &pdfLexer{data: pdfBytes, pos: headers[i][1]}
+		obj, err := l.parse(0)
+		if err != nil {
+			continue
+		}
+		d, ok := obj.(*pdfDict)
+		if !ok {
+			continue
+		}
+		if v, ok := d.Get("V").(*pdfDict); ok && !isSig(d) {
+			d = v
+		}
+		if !isSig(d) {
+			continue
 		}
-		window := content[start:end]
-
-		block := rawSignatureBlock{}
 
-		if m := nameRe.FindStringSubmatch(window); m != nil {
-			block.signerName = decodePDFString(m[1])
+		block := rawSignatureBlock{
+			signerName:   pdfString(d.Get("Name")),
+			signerRole:   pdfString(d.Get("Reason")),
+			signingTime:  pdfString(d.Get("M")),
+			docTimestamp: d.Get("Type") == pdfName("DocTimeStamp"),
 		}
-		if m := reasonRe.FindStringSubmatch(window); m != nil {
-			block.signerRole = decodePDFString(m[1])
+		if sf, ok := d.Get("SubFilter").(pdfName); ok {
+			block.subFilter = string(sf)
 		}
-		if m := dateRe.FindStringSubmatch(window); m != nil {
-			block.signingTime = m[1]
+		if c := string(rawOf(d.Get("Contents"))); strings.HasPrefix(c, "<") {
+			block.contents = hexDecode(strings.Trim(c, "<>"))
 		}
-		if m := contentsRe.FindStringSubmatch(window); m != nil {
-			block.contentsHex = strings.ReplaceAll(m[1], " ", "")
+		if br, ok := d.Get("ByteRange").(pdfArray); ok {
+			for _, v := range br {
+				n, err := strconv.Atoi(string(rawOf(v)))
+				if err != nil {
+					block.byteRange = nil
+					break
+				}
+				block.byteRange = append(block.byteRange, n)
+			}
 		}
 		blocks = append(blocks, block)
 	}
 	return blocks
 }
 
-// verifySignatureBlock validates a single raw signature block.
-func verifySignatureBlock(raw rawSignatureBlock) SignatureInfo {
+// verifySignatureBlock validates a single raw signature block. stamps are
+// the document's verified document timestamps.
+func verifySignatureBlock(ctx context.Context, raw rawSignatureBlock, pdfBytes []byte, opts VerifyOptions, dss *documentSecurityStore, stamps []SignatureInfo) SignatureInfo {
 	info := SignatureInfo{
-		SignerName:  raw.signerName,
-		SignerRole:  raw.signerRole,
-		SigningTime: parsePDFDate(raw.signingTime),
+		Type:           SignatureTypeSignature,
+		SignerName:     raw.signerName,
+		SignerRole:     raw.signerRole,
+		SigningTime:    parsePDFDate(raw.signingTime),
+		SubFilter:      raw.subFilter,
+		ValidationTime: time.Now().UTC(),
 	}
 
-	if raw.contentsHex == "" {
-		info.IsValid = false
-		info.FailureReason = "no /Contents found in signature dictionary"
-		return info
+	if len(raw.contents) == 0 {
+		info.check("format", CheckFailed, "no /Contents found in signature dictionary")
+		return info.finish()
 	}
 
-	// Decode hex-encoded CMS/PKCS#7 blob.
-	cmsBytes := hexDecode(raw.contentsHex)
-	if len(cmsBytes) == 0 {
-		info.IsValid = false
-		info.FailureReason = "could not decode signature contents"
-		return info
+	// Parse the CMS SignedData structure and locate the signer certificate.
+	sd, err := parseSignedData(raw.contents)
+	var cert *x509.Certificate
+	if err == nil {
+		cert, err = sd.signerCertificate()
 	}
-
-	// Try to extract the signer certificate from the CMS SignedData structure.
-	cert, err := extractSignerCertificate(cmsBytes)
 	if err != nil {
-		// Signature bytes present but we cannot parse the certificate.
+		// Signature bytes present but we cannot parse them.
 		// We still record partial info.
-		info.IsValid = false
-		info.FailureReason = fmt.Sprintf("certificate extraction failed: %v", err)
-		return info
+		if c, certErr := extractSignerCertificate(raw.contents); certErr == nil {
+			fillCertificateInfo(&info, c)
+		}
+		info.check("format", CheckFailed, fmt.Sprintf("certificate extraction failed: %v", err))
+		return info.finish()
 	}
+	fillCertificateInfo(&info, cert)
 
+	// The signed bytes are the two ranges around /Contents.
+	signed, err := byteRangeContent(pdfBytes, raw.byteRange)
+	if err != nil {
+		info.check("byte_range", CheckFailed, err.Error())
+		return info.finish()
+	}
+	info.end = raw.byteRange[2] + raw.byteRange[3]
+	info.CoversWholeDocument = info.end == len(pdfBytes)
+	info.check("byte_range", CheckPassed, "")
+	if err := sd.verifySignerInfo(cert, signed); err != nil {
+		info.check("signature", CheckFailed, err.Error())
+		return info.finish()
+	}
+	info.check("signature", CheckPassed, "")
+
+	// PAdES binds the signer certificate with signing-certificate-v2; it
+	// is optional for adbe.pkcs7.detached signatures.
+	if err := sd.checkSigningCertificate(cert); err != nil {
+		if raw.subFilter == "ETSI.CAdES.detached" {
+			info.check("signing_certificate", CheckFailed, err.Error())
+			return info.finish()
+		}
+		info.check("signing_certificate", CheckSkipped, err.Error())
+	} else {
+		info.check("signing_certificate", CheckPassed, "")
+		info.pades = raw.subFilter == "ETSI.CAdES.detached"
+	}
+	info.structureOK = true
+
+	// Proof of existence: the signature timestamp or, failing that, a
+	// later trusted document timestamp. The chain is validated at that
+	// time, so expiry afterwards does not invalidate the signature.
+	if der, ok := sd.signer.unsignedAttrs[oidAttrTimeStampToken.String()]; ok {
+		token, err := ParseTimestampToken(der)
+		if err != nil {
+			info.check("timestamp", CheckFailed, fmt.Sprintf("signature timestamp is invalid: %v", err))
+			return info.finish()
+		}
+		if !token.covers(sd.signer.signature) {
+			info.check("timestamp", CheckFailed, "signature timestamp does not cover this signature")
+			return info.finish()
+		}
+		info.TimestampTime, info.TimestampAuthority = &token.GenTime, token.Authority
+		if _, err := verifiedChain(token.cert, token.signed.certificates, opts, token.GenTime, x509.ExtKeyUsageTimeStamping); err != nil {
+			info.check("timestamp", CheckWarning, fmt.Sprintf("timestamp authority is not trusted, validated at the current time: %v", err))
+		} else {
+			info.check("timestamp", CheckPassed, "")
+			info.existence, info.ValidationTime = true, token.GenTime
+		}
+	}
+	if !info.existence {
+		for _, st := range stamps {
+			if st.trustedStamp && st.end > info.end && st.TimestampTime != nil {
+				info.check("timestamp", CheckPassed, "covered by a document timestamp at "+st.TimestampTime.Format(time.RFC3339))
+				info.existence, info.ValidationTime = true, *st.TimestampTime
+				break
+			}
+		}
+	}
+	if info.TimestampTime == nil && !info.existence {
+		info.check("timestamp", CheckSkipped, "signature has no timestamp; validated at the current time")
+	}
+
+	chain := validateChainAndRevocation(ctx, &info, cert, sd.certificates, opts, dss, x509.ExtKeyUsageAny)
+	if chain != nil && dss != nil && dss.offset > info.end {
+		info.embeddedLTV = slices.ContainsFunc(dss.Certificates, cert.Equal)
+		for _, r := range info.Revocation {
+			if r.Source != RevocationSourceEmbeddedOCSP && r.Source != RevocationSourceEmbeddedCRL {
+				info.embeddedLTV = false
+			}
+		}
+		if len(info.Revocation) < len(chain)-1 {
+			info.embeddedLTV = false
+		}
+	}
+	return info.finish()
+}
+
+// verifyDocumentTimestamp validates an ETSI.RFC3161 document timestamp.
+func verifyDocumentTimestamp(ctx context.Context, raw rawSignatureBlock, pdfBytes []byte, opts VerifyOptions, dss *documentSecurityStore) SignatureInfo {
+	info := SignatureInfo{
+		Type:           SignatureTypeDocumentTimestamp,
+		SubFilter:      raw.subFilter,
+		ValidationTime: time.Now().UTC(),
+	}
+	token, err := ParseTimestampToken(raw.contents)
+	if err != nil {
+		info.check("format", CheckFailed, err.Error())
+		return info.finish()
+	}
+	fillCertificateInfo(&info, token.cert)
+	info.SigningTime = token.GenTime
+	info.TimestampTime, info.TimestampAuthority = &token.GenTime, token.Authority
+
+	signed, err := byteRangeContent(pdfBytes, raw.byteRange)
+	if err != nil {
+		info.check("byte_range", CheckFailed, err.Error())
+		return info.finish()
+	}
+	info.end = raw.byteRange[2] + raw.byteRange[3]
+	info.CoversWholeDocument = info.end == len(pdfBytes)
+	info.check("byte_range", CheckPassed, "")
+	if !token.covers(signed) {
+		info.check("signature", CheckFailed, "document timestamp does not cover the signed revision; the content was modified after timestamping")
+		return info.finish()
+	}
+	info.check("signature", CheckPassed, "")
+	info.structureOK = true
+
+	if validateChainAndRevocation(ctx, &info, token.cert, token.signed.certificates, opts, dss, x509.ExtKeyUsageTimeStamping) != nil {
+		info.trustedStamp = true
+	}
+	return info.finish()
+}
+
+// validateChainAndRevocation validates cert at info.ValidationTime and the
+// revocation status of each certificate in its chain. It returns the
+// chain, or nil if it is not trusted.
+func validateChainAndRevocation(ctx context.Context, info *SignatureInfo, cert *x509.Certificate, embedded []*x509.Certificate, opts VerifyOptions, dss *documentSecurityStore, usage x509.ExtKeyUsage) []*x509.Certificate {
+	if dss != nil {
+		embedded = append(append([]*x509.Certificate{}, embedded...), dss.Certificates...)
+	}
+	chain, err := verifiedChain(cert, embedded, opts, info.ValidationTime, usage)
+	if err != nil {
+		info.check("certificate_chain", CheckIndeterminate, fmt.Sprintf("certificate chain validation failed: %v", err))
+		return nil
+	}
+	for _, c := range chain {
+		info.CertificateChain = append(info.CertificateChain, c.Subject.String())
+	}
+	info.check("certificate_chain", CheckPassed, "")
+
+	if len(chain) == 1 {
+		info.check("revocation", CheckSkipped, "certificate is itself a trust anchor")
+		return chain
+	}
+	status, detail := CheckPassed, ""
+	worse := func(s, d string) {
+		rank := map[string]int{CheckPassed: 0, CheckWarning: 1, CheckIndeterminate: 2, CheckFailed: 3}
+		if rank[s] > rank[status] {
+			status, detail = s, d
+		}
+	}
+	// The trust anchor at the end of the chain is not checked.
+	for i := 0; i+1 < len(chain); i++ {
+		c, issuer := chain[i], chain[i+1]
+		rev := dss.revocation(c, issuer)
+		if rev == nil && opts.Revocation != nil {
+			var err error
+			if rev, err = opts.Revocation.Check(ctx, c, issuer); err != nil {
+				if opts.RequireRevocation {
+					worse(CheckIndeterminate, fmt.Sprintf("revocation status of %q unavailable: %v", c.Subject.CommonName, err))
+				} else {
+					worse(CheckWarning, fmt.Sprintf("revocation status of %q unavailable: %v", c.Subject.CommonName, err))
+				}
+			}
+		} else if rev == nil {
+			if opts.RequireRevocation {
+				worse(CheckIndeterminate, fmt.Sprintf("no revocation data for %q", c.Subject.CommonName))
+			} else {
+				worse(CheckWarning, fmt.Sprintf("revocation of %q not checked", c.Subject.CommonName))
+			}
+		}
+		if rev == nil {
+			continue
+		}
+		info.Revocation = append(info.Revocation, *rev)
+		switch rev.Status {
+		case RevocationRevoked:
+			// A certificate revoked after the proven signing time does not
+			// invalidate the signature.
+			if !rev.RevokedAt.After(info.ValidationTime) {
+				worse(CheckFailed, fmt.Sprintf("certificate %q was revoked at %s", c.Subject.CommonName, rev.RevokedAt.UTC().Format(time.RFC3339)))
+			}
+		case RevocationUnknown:
+			worse(CheckIndeterminate, fmt.Sprintf("revocation status of %q is unknown to its responder", c.Subject.CommonName))
+		}
+	}
+	info.check("revocation", status, detail)
+	if status == CheckFailed {
+		return nil
+	}
+	return chain
+}
+
+// check records the outcome of one validation step.
+func (info *SignatureInfo) check(name, status, detail string) {
+	info.Checks = append(info.Checks, ValidationCheck{Name: name, Status: status, Detail: detail})
+}
+
+// finish derives the indication from the checks: any failure fails the
+// signature, any indeterminate check makes it indeterminate.
+func (info SignatureInfo) finish() SignatureInfo {
+	info.Indication = IndicationPassed
+	for _, c := range info.Checks {
+		switch {
+		case c.Status == CheckFailed:
+			info.Indication, info.FailureReason = IndicationFailed, c.Detail
+		case c.Status == CheckIndeterminate && info.Indication == IndicationPassed:
+			info.Indication, info.FailureReason = IndicationIndeterminate, c.Detail
+		}
+		if info.Indication == IndicationFailed {
+			break
+		}
+	}
+	info.IsValid = info.Indication == IndicationPassed
+	return info
+}
+
+func fillCertificateInfo(info *SignatureInfo, cert *x509.Certificate) {
 	info.CertificateIssuer = cert.Issuer.String()
 	info.CertificateSubject = cert.Subject.String()
 	if info.SignerName == "" {
 		info.SignerName = extractCNFromCert(cert)
 	}
 	info.SignerEmail = extractEmailFromCert(cert)
+	info.RawCertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
 
-	// Validate certificate chain against the system trust store.
-	if err := validateCertChain(cert); err != nil {
-		info.IsValid = false
-		info.FailureReason = fmt.Sprintf("certificate chain validation failed: %v", err)
-		return info
+// byteRangeContent returns the bytes a signature covers. The gap between
+// the two ranges must be exactly the hex /Contents string.
+func byteRangeContent(pdfBytes []byte, br []int) ([]byte, error) {
+	if len(br) != 4 || br[0] != 0 || br[1] <= 0 || br[2] <= br[1] || br[3] < 0 || br[2]+br[3] > len(pdfBytes) {
+		return nil, fmt.Errorf("signature /ByteRange is missing or invalid")
 	}
-
-	// All checks passed.
-	info.IsValid = true
-	return info
+	if pdfBytes[br[1]] != '<' || pdfBytes[br[2]-1] != '>' {
+		return nil, fmt.Errorf("signature /ByteRange does not exclude exactly the signature contents")
+	}
+	signed := make([]byte, 0, br[1]+br[3])
+	signed = append(signed, pdfBytes[:br[1]]...)
+	return append(signed, pdfBytes[br[2]:br[2]+br[3]]...), nil
 }
 
 // extractSignerCertificate pulls the first Certificate from a CMS SignedData blob.
@@ -220,22 +582,38 @@ func tryParseCertSequences(data []byte) (*x509.Certificate, error) {
 	return nil, fmt.Errorf("no certificate SEQUENCE found")
 }
 
-// validateCertChain validates the certificate against the system trust store.
-func validateCertChain(cert *x509.Certificate) error {
+// verifiedChain validates the certificate at the given time against the
+// system trust store and opts.Roots, using the embedded certificates as
+// intermediates, and returns the chain from cert to its trust anchor.
+func verifiedChain(cert *x509.Certificate, embedded []*x509.Certificate, opts VerifyOptions, at time.Time, usage x509.ExtKeyUsage) ([]*x509.Certificate, error) {
 	roots, err := x509.SystemCertPool()
 	if err != nil {
 		// System pool unavailable (e.g. some Linux containers); create empty pool.
 		roots = x509.NewCertPool()
 	}
+	for _, c := range opts.Roots {
+		roots.AddCert(c)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range embedded {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
 
-	opts := x509.VerifyOptions{
-		Roots:       roots,
-		CurrentTime: time.Now(),
-		// KeyUsages: allow any — we're verifying document signing.
+	verifyOpts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{usage}, // document signing certificates rarely carry a PDF-specific EKU
 	}
 
-	_, err = cert.Verify(opts)
-	return err
+	chains, err := cert.Verify(verifyOpts)
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
 }
 
 // --- string helpers ---------------------------------------------------------
@@ -260,12 +638,6 @@ func allSignaturesValid(sigs []SignatureInfo) bool {
 	return true
 }
 
-// decodePDFString handles basic PDF string encoding (strips BOM, handles UTF-16BE).
-func decodePDFString(s string) string {
-	s = strings.TrimSpace(s)
-	return s
-}
-
 // parsePDFDate converts a PDF date string (D:YYYYMMDDHHmmSSOHH'mm') to time.Time.
 func parsePDFDate(s string) time.Time {
 	s = strings.TrimPrefix(s, "D:")
@@ -288,17 +660,15 @@ func parsePDFDate(s string) time.Time {
 
 // hexDecode converts a hex string to bytes, ignoring non-hex characters.
 func hexDecode(h string) []byte {
-	h = strings.ReplaceAll(h, "\n", "")
-	h = strings.ReplaceAll(h, "\r", "")
-	h = strings.TrimSpace(h)
+	h = strings.Map(func(r rune) rune {
+		if ('0' <= r && r <= '9') || ('a' <= r && r <= 'f') || ('A' <= r && r <= 'F') {
+			return r
+		}
+		return -1
+	}, h)
 	if len(h)%2 != 0 {
-		h = "0" + h
-	}
-	out := make([]byte, len(h)/2)
-	for i := 0; i < len(h); i += 2 {
-		var b byte
-		fmt.Sscanf(h[i:i+2], "%02x", &b)
-		out[i/2] = b
+		h += "0"
 	}
+	out, _ := hex.DecodeString(h)
 	return out
 }
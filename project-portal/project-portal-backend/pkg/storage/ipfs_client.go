@@ -43,6 +43,21 @@ func NewIPFSClient(nodeURL string) *IPFSClient {
 // Add streams reader content to the IPFS node and returns the resulting CID.
 // The file is pinned by default (pin=true in the query string).
 func (c *IPFSClient) Add(ctx context.Context, filename string, r io.Reader) (*IPFSAddResult, error) {
+	return c.add(ctx, filename, r, "pin=true&quieter=false")
+}
+
+// ComputeCID asks the node for the CID the content would get, without
+// storing or pinning it. With the node's default chunking it matches the CID
+// returned by Add for the same bytes.
+func (c *IPFSClient) ComputeCID(ctx context.Context, filename string, r io.Reader) (string, error) {
+	result, err := c.add(ctx, filename, r, "only-hash=true&pin=false&quieter=false")
+	if err != nil {
+		return "", err
+	}
+	return result.Hash, nil
+}
+
+func (c *IPFSClient) add(ctx context.Context, filename string, r io.Reader, query string) (*IPFSAddResult, error) {
 	// Build a multipart/form-data body with the file under field "file".
 	var body bytes.Buffer
 	mw := multipart.NewWriter(&body)
@@ -58,7 +73,7 @@ func (c *IPFSClient) Add(ctx context.Context, filename string, r io.Reader) (*IP
 		return nil, fmt.Errorf("ipfs: failed to close multipart writer: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/v0/add?pin=true&quieter=false", c.nodeURL)
+	url := fmt.Sprintf("%s/api/v0/add?%s", c.nodeURL, query)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
 	if err != nil {
 		return nil, fmt.Errorf("ipfs: failed to build request: %w", err)
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore keeps objects as files under a root directory. Download URLs
+// are signed by URLSigner and served by the API.
+type LocalStore struct {
+	root   string
+	signer *URLSigner
+}
+
+// NewLocalStore creates the root directory if needed.
+func NewLocalStore(root string, signer *URLSigner) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("local storage: %w", err)
+	}
+	return &LocalStore{root: root, signer: signer}, nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Upload writes body to a temporary file and renames it into place, so
+// readers never see a partial object.
+func (s *LocalStore) Upload(ctx context.Context, key string, body io.Reader, _ string) (*UploadResult, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return nil, fmt.Errorf("local upload failed for key %q: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("local upload failed for key %q: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, &ctxReader{ctx: ctx, r: body}); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("local upload failed for key %q: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("local upload failed for key %q: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return nil, fmt.Errorf("local upload failed for key %q: %w", key, err)
+	}
+	return &UploadResult{Key: key, Bucket: s.BucketName(), Location: "file://" + p}, nil
+}
+
+// DownloadStream opens the object's file.
+func (s *LocalStore) DownloadStream(_ context.Context, key string) (io.ReadCloser, int64, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// Delete removes the object. Deleting a missing key succeeds, as on S3.
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("local delete failed for key %q: %w", key, err)
+	}
+	return nil
+}
+
+// GeneratePresignedURL returns an API URL that serves the object until expiry.
+func (s *LocalStore) GeneratePresignedURL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+	return s.signer.Sign(key, expiry), nil
+}
+
+// VerifySignedURL implements SignedURLVerifier.
+func (s *LocalStore) VerifySignedURL(key, expires, signature string) error {
+	return s.signer.Verify(key, expires, signature)
+}
+
+// BucketName identifies the backend in stored document records.
+func (s *LocalStore) BucketName() string {
+	return "local"
+}
+
+// ctxReader stops a copy once ctx is cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), NewURLSigner([]byte("secret"), "http://api.test/api/v1/storage/objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "projects/p1/documents/pdd/20260101T000000_plan v2.pdf"
+	if _, err := store.Upload(ctx, key, strings.NewReader("hello"), "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := store.GeneratePresignedURL(ctx, key, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(raw)
+	gotKey := strings.TrimPrefix(u.Path, "/api/v1/storage/objects/")
+	if gotKey != key {
+		t.Fatalf("URL path decodes to %q, want %q", gotKey, key)
+	}
+	if err := store.VerifySignedURL(gotKey, u.Query().Get("expires"), u.Query().Get("signature")); err != nil {
+		t.Fatalf("valid URL rejected: %v", err)
+	}
+	if err := store.VerifySignedURL("projects/p1/other.pdf", u.Query().Get("expires"), u.Query().Get("signature")); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("signature for another key accepted: %v", err)
+	}
+
+	rc, size, err := store.DownloadStream(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "hello" || size != 5 {
+		t.Fatalf("got %q (%d bytes)", body, size)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.DownloadStream(ctx, key); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound after delete, got %v", err)
+	}
+}
+
+func TestURLSignerExpiry(t *testing.T) {
+	s := NewURLSigner([]byte("secret"), "http://api.test")
+	u, _ := url.Parse(s.Sign("a/b.pdf", -time.Minute))
+	if err := s.Verify("a/b.pdf", u.Query().Get("expires"), u.Query().Get("signature")); !errors.Is(err, ErrURLExpired) {
+		t.Fatalf("expected ErrURLExpired, got %v", err)
+	}
+}
+
+func TestCleanKeyRejectsTraversal(t *testing.T) {
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a\\b", "a//b"} {
+		if _, err := cleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("cleanKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps objects in memory. It is meant for tests and throwaway
+// environments; everything is lost on restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+	signer  *URLSigner
+}
+
+// NewMemoryStore returns an empty store whose URLs are signed by signer.
+func NewMemoryStore(signer *URLSigner) *MemoryStore {
+	return &MemoryStore{objects: map[string][]byte{}, signer: signer}
+}
+
+// Upload reads body fully and stores it under key.
+func (s *MemoryStore) Upload(_ context.Context, key string, body io.Reader, _ string) (*UploadResult, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("memory upload failed for key %q: %w", key, err)
+	}
+	s.mu.Lock()
+	s.objects[key] = data
+	s.mu.Unlock()
+	return &UploadResult{Key: key, Bucket: s.BucketName()}, nil
+}
+
+// DownloadStream returns a reader over the stored bytes.
+func (s *MemoryStore) DownloadStream(_ context.Context, key string) (io.ReadCloser, int64, error) {
+	s.mu.RLock()
+	data, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+// Delete removes the object if present.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// GeneratePresignedURL returns an API URL that serves the object until expiry.
+func (s *MemoryStore) GeneratePresignedURL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := cleanKey(key); err != nil {
+		return "", err
+	}
+	return s.signer.Sign(key, expiry), nil
+}
+
+// VerifySignedURL implements SignedURLVerifier.
+func (s *MemoryStore) VerifySignedURL(key, expires, signature string) error {
+	return s.signer.Verify(key, expires, signature)
+}
+
+// BucketName identifies the backend in stored document records.
+func (s *MemoryStore) BucketName() string {
+	return "memory"
+}
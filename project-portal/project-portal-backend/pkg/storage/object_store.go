@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ObjectStore is the storage backend behind document management. S3Client,
+// LocalStore and MemoryStore implement it.
+type ObjectStore interface {
+	Upload(ctx context.Context, key string, body io.Reader, contentType string) (*UploadResult, error)
+	DownloadStream(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	Delete(ctx context.Context, key string) error
+	GeneratePresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	BucketName() string
+}
+
+// SignedURLVerifier is implemented by stores whose download URLs are served
+// by the API itself rather than by the storage provider.
+type SignedURLVerifier interface {
+	VerifySignedURL(key, expires, signature string) error
+}
+
+var (
+	// ErrObjectNotFound is returned when a key does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for keys that are empty or escape the store.
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrURLExpired is returned for signed URLs past their expiry.
+	ErrURLExpired = errors.New("signed URL has expired")
+	// ErrBadSignature is returned for signed URLs that were not issued by
+	// this server.
+	ErrBadSignature = errors.New("invalid URL signature")
+)
+
+// URLSigner issues and checks HMAC-signed, expiring download URLs of the
+// form {baseURL}/{key}?expires={unix}&signature={hex}.
+type URLSigner struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewURLSigner returns a signer for URLs under baseURL, which must be the
+// absolute URL of the route that serves objects.
+func NewURLSigner(secret []byte, baseURL string) *URLSigner {
+	return &URLSigner{secret: secret, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Sign returns a URL for key that is valid for expiry.
+func (s *URLSigner) Sign(key string, expiry time.Duration) string {
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	q := url.Values{"expires": {expires}, "signature": {s.mac(key, expires)}}
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode()
+}
+
+// Verify checks the signature and expiry taken from a signed URL.
+func (s *URLSigner) Verify(key, expires, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(s.mac(key, expires))) {
+		return ErrBadSignature
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if time.Now().Unix() > unix {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+func (s *URLSigner) mac(key, expires string) string {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(key))
+	m.Write([]byte{0})
+	m.Write([]byte(expires))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// cleanKey rejects keys that are absolute or climb out of the store root.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 // S3Config holds the configuration for the S3 client.
@@ -112,6 +114,10 @@ func (s *S3Client) DownloadStream(ctx context.Context, key string) (io.ReadClose
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 	})
+	var noKey *types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return nil, 0, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
 	if err != nil {
 		return nil, 0, fmt.Errorf("s3 get object failed for key %q: %w", key, err)
 	}
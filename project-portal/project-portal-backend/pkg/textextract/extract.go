@@ -0,0 +1,143 @@
+// Package textextract pulls plain text and document properties out of
+// uploaded PDF, DOCX and XLSX files for full-text indexing. Text is returned
+// per page (PDF, and DOCX where Word recorded page breaks) or per sheet
+// (XLSX) so search hits can point at where a match occurred.
+package textextract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Supported formats. They match the documents module's file types.
+const (
+	FormatPDF  = "PDF"
+	FormatDOCX = "DOCX"
+	FormatXLSX = "XLSX"
+)
+
+// MaxTextBytes caps the text kept from one document; the rest is dropped
+// and Result.Truncated is set.
+const MaxTextBytes = 8 << 20
+
+var (
+	// ErrUnsupportedFormat is returned for formats without an extractor.
+	ErrUnsupportedFormat = errors.New("text extraction is not supported for this format")
+	// ErrEncrypted is returned for password-protected files.
+	ErrEncrypted = errors.New("document is encrypted")
+)
+
+// Segment is the text of one page or sheet.
+type Segment struct {
+	Page  int    `json:"page,omitempty"`  // 1-based page number (PDF, DOCX)
+	Sheet string `json:"sheet,omitempty"` // worksheet name (XLSX)
+	Text  string `json:"text"`
+}
+
+// Result is the text and properties of a document.
+type Result struct {
+	Format    string            `json:"format"`
+	Segments  []Segment         `json:"segments"`
+	Metadata  map[string]string `json:"metadata,omitempty"` // title, author, subject, keywords, creator, producer
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// Characters returns the number of characters extracted.
+func (r *Result) Characters() int {
+	n := 0
+	for _, s := range r.Segments {
+		n += len([]rune(s.Text))
+	}
+	return n
+}
+
+// Supported reports whether format has an extractor.
+func Supported(format string) bool {
+	switch strings.ToUpper(format) {
+	case FormatPDF, FormatDOCX, FormatXLSX:
+		return true
+	}
+	return false
+}
+
+// Extract returns the text of data, which is a file in the given format.
+// Segments without text are dropped.
+func Extract(data []byte, format string) (*Result, error) {
+	var (
+		res *Result
+		err error
+	)
+	switch strings.ToUpper(format) {
+	case FormatPDF:
+		res, err = extractPDF(data)
+	case FormatDOCX:
+		res, err = extractDOCX(data)
+	case FormatXLSX:
+		res, err = extractXLSX(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Format = strings.ToUpper(format)
+	res.finish()
+	return res, nil
+}
+
+// finish normalizes whitespace, drops empty segments and enforces
+// MaxTextBytes.
+func (r *Result) finish() {
+	total := 0
+	kept := r.Segments[:0]
+	for _, s := range r.Segments {
+		s.Text = normalizeSpace(s.Text)
+		if s.Text == "" {
+			continue
+		}
+		if total+len(s.Text) > MaxTextBytes {
+			s.Text = strings.ToValidUTF8(s.Text[:MaxTextBytes-total], "")
+			r.Truncated = true
+		}
+		total += len(s.Text)
+		kept = append(kept, s)
+		if r.Truncated {
+			break
+		}
+	}
+	r.Segments = kept
+	for k, v := range r.Metadata {
+		if v = strings.TrimSpace(v); v == "" {
+			delete(r.Metadata, k)
+		} else {
+			r.Metadata[k] = v
+		}
+	}
+}
+
+// normalizeSpace collapses runs of blanks within lines and blank lines
+// between them, and removes control characters.
+func normalizeSpace(s string) string {
+	var b strings.Builder
+	blankLines := 0
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Join(strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsControl(r)
+		}), " ")
+		if line == "" {
+			blankLines++
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+			if blankLines > 0 {
+				b.WriteByte('\n')
+			}
+		}
+		blankLines = 0
+		b.WriteString(line)
+	}
+	return b.String()
+}
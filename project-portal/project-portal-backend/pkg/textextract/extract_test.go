@@ -0,0 +1,153 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+func testPDF(t *testing.T, compress bool, pages ...[]string) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCompression(compress)
+	pdf.SetTitle("Monitoring Report 2026", true)
+	pdf.SetAuthor("Field Team", true)
+	for _, lines := range pages {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "", 12)
+		for _, line := range lines {
+			pdf.Cell(0, 10, line)
+			pdf.Ln(10)
+		}
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`,
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Project Design Document</dc:title><dc:creator>Ana Silva</dc:creator></cp:coreProperties>`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		data := testPDF(t, compress,
+			[]string{"Baseline emissions: 12,400 tCO2e", "Mangrove restoration area"},
+			[]string{"Leakage deduction applied"},
+		)
+		res, err := Extract(data, FormatPDF)
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if len(res.Segments) != 2 {
+			t.Fatalf("compress=%v: %d segments, want 2: %+v", compress, len(res.Segments), res.Segments)
+		}
+		if got := res.Segments[0]; got.Page != 1 || got.Text != "Baseline emissions: 12,400 tCO2e\nMangrove restoration area" {
+			t.Errorf("compress=%v: page 1 = %+v", compress, got)
+		}
+		if got := res.Segments[1]; got.Page != 2 || got.Text != "Leakage deduction applied" {
+			t.Errorf("compress=%v: page 2 = %+v", compress, got)
+		}
+		if res.Metadata["title"] != "Monitoring Report 2026" || res.Metadata["author"] != "Field Team" {
+			t.Errorf("compress=%v: metadata %v", compress, res.Metadata)
+		}
+	}
+}
+
+func TestExtractDOCX(t *testing.T) {
+	body := `<w:p><w:r><w:t>Project Design</w:t></w:r><w:r><w:tab/><w:t>Document</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:br w:type="page"/></w:r></w:p>` +
+		`<w:p><w:r><w:lastRenderedPageBreak/><w:t xml:space="preserve">Additionality </w:t></w:r><w:r><w:t>assessment</w:t></w:r></w:p>`
+	res, err := Extract(testDOCX(t, body), FormatDOCX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{{Page: 1, Text: "Project Design Document"}, {Page: 2, Text: "Additionality assessment"}}
+	if len(res.Segments) != len(want) {
+		t.Fatalf("segments %+v, want %+v", res.Segments, want)
+	}
+	for i := range want {
+		if res.Segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, res.Segments[i], want[i])
+		}
+	}
+	if res.Metadata["title"] != "Project Design Document" || res.Metadata["author"] != "Ana Silva" {
+		t.Errorf("metadata %v", res.Metadata)
+	}
+}
+
+func TestExtractXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	_ = f.SetCellValue("Sheet1", "A1", "Plot")
+	_ = f.SetCellValue("Sheet1", "B1", "Biomass")
+	_ = f.SetCellValue("Sheet1", "A2", "P-07")
+	_ = f.SetCellValue("Sheet1", "B2", 41.5)
+	if _, err := f.NewSheet("Leakage"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetCellValue("Leakage", "A1", "Market leakage 3%")
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Extract(buf.Bytes(), FormatXLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{{Sheet: "Sheet1", Text: "Plot Biomass\nP-07 41.5"}, {Sheet: "Leakage", Text: "Market leakage 3%"}}
+	if len(res.Segments) != len(want) {
+		t.Fatalf("segments %+v, want %+v", res.Segments, want)
+	}
+	for i := range want {
+		if res.Segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, res.Segments[i], want[i])
+		}
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		want   error
+	}{
+		{"unsupported format", []byte("GIF89a"), "IMAGE", ErrUnsupportedFormat},
+		{"encrypted PDF", []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n%%EOF"), FormatPDF, ErrEncrypted},
+		{"encrypted DOCX", append([]byte{0xD0, 0xCF, 0x11, 0xE0}, make([]byte, 60)...), FormatDOCX, ErrEncrypted},
+	}
+	for _, tt := range tests {
+		if _, err := Extract(tt.data, tt.format); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if _, err := Extract([]byte("not a pdf"), FormatPDF); err == nil || !strings.Contains(err.Error(), "not a PDF") {
+		t.Errorf("garbage PDF: %v", err)
+	}
+}
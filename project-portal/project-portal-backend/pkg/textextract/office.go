@@ -0,0 +1,180 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxPartBytes caps how much of one zip part is read.
+const maxPartBytes = 64 << 20
+
+// extractDOCX reads the body of word/document.xml. Pages are split where
+// Word recorded a rendered or explicit page break; documents saved by tools
+// that record neither come back as a single page.
+func extractDOCX(data []byte) (*Result, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		if bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}) {
+			// OLE container: an encrypted OOXML package.
+			return nil, ErrEncrypted
+		}
+		return nil, fmt.Errorf("invalid DOCX: %w", err)
+	}
+	body, err := readZipPart(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Metadata: map[string]string{}}
+	page := &Segment{Page: 1}
+	var text strings.Builder
+	// An explicit break is usually followed by a rendered-break marker at
+	// the start of the next page; count them once.
+	broke := false
+	flush := func() {
+		if broke && strings.TrimSpace(text.String()) == "" {
+			return
+		}
+		page.Text = text.String()
+		res.Segments = append(res.Segments, *page)
+		page = &Segment{Page: page.Page + 1}
+		text.Reset()
+		broke = true
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCX document part: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "cr":
+				text.WriteByte('\n')
+			case "br":
+				if attr(t, "type") == "page" {
+					flush()
+				} else {
+					text.WriteByte('\n')
+				}
+			case "lastRenderedPageBreak":
+				flush()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteByte('\n')
+			case "tc":
+				text.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+				broke = false
+			}
+		}
+	}
+	broke = false
+	flush()
+
+	if core, err := readZipPart(zr, "docProps/core.xml"); err == nil {
+		res.Metadata = coreProperties(core)
+	}
+	return res, nil
+}
+
+// extractXLSX returns the cell values of every worksheet, one row per line.
+func extractXLSX(data []byte) (*Result, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		if bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}) {
+			return nil, ErrEncrypted
+		}
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer f.Close()
+
+	res := &Result{Metadata: map[string]string{}}
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheet, err)
+		}
+		var text strings.Builder
+		for _, row := range rows {
+			text.WriteString(strings.Join(row, "\t"))
+			text.WriteByte('\n')
+		}
+		res.Segments = append(res.Segments, Segment{Sheet: sheet, Text: text.String()})
+	}
+	if props, err := f.GetDocProps(); err == nil {
+		res.Metadata = map[string]string{
+			"title":    props.Title,
+			"author":   props.Creator,
+			"subject":  props.Subject,
+			"keywords": props.Keywords,
+		}
+	}
+	return res, nil
+}
+
+func readZipPart(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxPartBytes))
+	}
+	return nil, fmt.Errorf("package has no %s", name)
+}
+
+// coreProperties reads the Dublin Core properties of an OOXML package.
+func coreProperties(data []byte) map[string]string {
+	var core struct {
+		Title    string `xml:"title"`
+		Creator  string `xml:"creator"`
+		Subject  string `xml:"subject"`
+		Keywords string `xml:"keywords"`
+	}
+	if err := xml.Unmarshal(data, &core); err != nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"title":    core.Title,
+		"author":   core.Creator,
+		"subject":  core.Subject,
+		"keywords": core.Keywords,
+	}
+}
+
+func attr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,1110 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The PDF reader below is deliberately tolerant: it locates objects by
+// scanning for "n g obj" headers rather than trusting the cross-reference
+// table, which is often stale in files that went through several tools,
+// and resolves compressed objects from object streams on demand. Text is
+// recovered from the show operators of page content streams through each
+// font's ToUnicode map or, for simple fonts, its encoding.
+
+const (
+	maxStreamBytes = 64 << 20
+	maxPages       = 10000
+	maxFormDepth   = 8
+)
+
+// ─── Objects ──────────────────────────────────────────────────────────────────
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []any
+	pdfDict    map[string]any
+	pdfRef     struct{ num, gen int }
+)
+
+// pdfStream is a stream object: its dictionary and undecoded data.
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// regular reads a run of regular characters.
+func (l *lexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// next returns the next object, or a pdfKeyword for operators and other
+// bare words. It returns io.EOF at the end of the data.
+func (l *lexer) next(depth int) (any, error) {
+	if depth > 64 {
+		return nil, errors.New("objects nested too deeply")
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(unescapeName(l.regular())), nil
+	case c == '(':
+		return l.literal(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		d := pdfDict{}
+		for {
+			l.skipSpace()
+			if l.pos+1 < len(l.data) && l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+				l.pos += 2
+				return d, nil
+			}
+			k, err := l.next(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(pdfName)
+			if !ok {
+				continue // tolerate junk keys
+			}
+			v, err := l.next(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			d[string(key)] = v
+		}
+	case c == '<':
+		end := bytes.IndexByte(l.data[l.pos:], '>')
+		if end < 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		s := l.data[l.pos+1 : l.pos+end]
+		l.pos += end + 1
+		return decodeHexString(s), nil
+	case c == '[':
+		l.pos++
+		var arr pdfArray
+		for {
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == ']' {
+				l.pos++
+				return arr, nil
+			}
+			v, err := l.next(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+
+	word := l.regular()
+	if n, err := strconv.ParseFloat(word, 64); err == nil {
+		// "num gen R" is a reference.
+		if i, err := strconv.Atoi(word); err == nil && i >= 0 {
+			save := l.pos
+			l.skipSpace()
+			gen := l.regular()
+			l.skipSpace()
+			if g, err := strconv.Atoi(gen); err == nil && l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+				(l.pos+1 == len(l.data) || isSpace(l.data[l.pos+1]) || isDelim(l.data[l.pos+1])) {
+				l.pos++
+				return pdfRef{i, g}, nil
+			}
+			l.pos = save
+		}
+		return n, nil
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "":
+		l.pos++ // stray delimiter
+		return pdfKeyword(""), nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *lexer) literal() pdfString {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func decodeHexString(s []byte) pdfString {
+	var digits []byte
+	for _, c := range s {
+		if !isSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	n, _ := hex.Decode(out, digits)
+	return out[:n]
+}
+
+func unescapeName(s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ─── Document ─────────────────────────────────────────────────────────────────
+
+// compressedLoc is the position of an object inside an object stream.
+type compressedLoc struct{ stream, index int }
+
+type pdfReader struct {
+	data       []byte
+	offsets    map[int]int // object number -> offset after "obj"
+	compressed map[int]compressedLoc
+	objStms    map[int][]any // decoded object streams
+	cache      map[int]any
+}
+
+var (
+	objHeaderRe = regexp.MustCompile(`(?:^|[^0-9])(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+	rootRe      = regexp.MustCompile(`/Root[ \t\r\n\f]*(\d+)[ \t\r\n\f]+(\d+)[ \t\r\n\f]+R`)
+	infoRe      = regexp.MustCompile(`/Info[ \t\r\n\f]*(\d+)[ \t\r\n\f]+(\d+)[ \t\r\n\f]+R`)
+	encryptRe   = regexp.MustCompile(`/Encrypt[ \t\r\n\f]*(?:\d+[ \t\r\n\f]+\d+[ \t\r\n\f]+R|<<)`)
+	objStmRe    = regexp.MustCompile(`/Type[ \t\r\n\f]*/ObjStm\b`)
+	inlineEndRe = regexp.MustCompile(`[ \t\r\n]EI[ \t\r\n]`)
+)
+
+func openReader(data []byte) (*pdfReader, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, errors.New("content is not a PDF")
+	}
+	if encryptRe.Match(data) {
+		return nil, ErrEncrypted
+	}
+	r := &pdfReader{
+		data:       data,
+		offsets:    map[int]int{},
+		compressed: map[int]compressedLoc{},
+		objStms:    map[int][]any{},
+		cache:      map[int]any{},
+	}
+	headers := objHeaderRe.FindAllSubmatchIndex(data, -1)
+	for _, m := range headers {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		r.offsets[num] = m[1] // later revisions replace earlier ones
+	}
+	// Register objects held in object streams that are not defined directly.
+	for _, loc := range objStmRe.FindAllIndex(data, -1) {
+		num := -1
+		for i := len(headers) - 1; i >= 0; i-- {
+			if headers[i][1] <= loc[0] {
+				num, _ = strconv.Atoi(string(data[headers[i][2]:headers[i][3]]))
+				break
+			}
+		}
+		if num < 0 {
+			continue
+		}
+		nums, err := r.objectStreamIndex(num)
+		if err != nil {
+			continue
+		}
+		for i, n := range nums {
+			if _, direct := r.offsets[n]; !direct {
+				r.compressed[n] = compressedLoc{stream: num, index: i}
+			}
+		}
+	}
+	return r, nil
+}
+
+// object returns the value of indirect object num.
+func (r *pdfReader) object(num int) (any, error) {
+	if v, ok := r.cache[num]; ok {
+		return v, nil
+	}
+	var v any
+	if off, ok := r.offsets[num]; ok {
+		l := &lexer{data: r.data, pos: off}
+		obj, err := l.next(0)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %w", num, err)
+		}
+		v = obj
+		if d, ok := obj.(pdfDict); ok {
+			save := l.pos
+			if kw, _ := l.next(0); kw == pdfKeyword("stream") {
+				v = &pdfStream{dict: d, raw: r.streamData(d, l.pos)}
+			} else {
+				l.pos = save
+			}
+		}
+	} else if loc, ok := r.compressed[num]; ok {
+		objs, err := r.objectStream(loc.stream)
+		if err != nil {
+			return nil, err
+		}
+		if loc.index < len(objs) {
+			v = objs[loc.index]
+		}
+	} else {
+		return nil, fmt.Errorf("object %d not found", num)
+	}
+	r.cache[num] = v
+	return v, nil
+}
+
+// streamData returns the raw bytes of a stream whose keyword ends at pos.
+func (r *pdfReader) streamData(d pdfDict, pos int) []byte {
+	if bytes.HasPrefix(r.data[pos:], []byte("\r\n")) {
+		pos += 2
+	} else if pos < len(r.data) && (r.data[pos] == '\n' || r.data[pos] == '\r') {
+		pos++
+	}
+	if n, ok := r.resolve(d["Length"]).(float64); ok && n >= 0 && pos+int(n) <= len(r.data) {
+		end := pos + int(n)
+		rest := bytes.TrimLeft(r.data[end:min(len(r.data), end+32)], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return r.data[pos:end]
+		}
+	}
+	// /Length is missing or wrong: fall back to the endstream keyword.
+	end := bytes.Index(r.data[pos:], []byte("endstream"))
+	if end < 0 {
+		return r.data[pos:]
+	}
+	return bytes.TrimRight(r.data[pos:pos+end], "\r\n")
+}
+
+func (r *pdfReader) resolve(v any) any {
+	for i := 0; i < 16; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		obj, err := r.object(ref.num)
+		if err != nil {
+			return nil
+		}
+		v = obj
+	}
+	return nil
+}
+
+func (r *pdfReader) dict(v any) pdfDict {
+	switch d := r.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+func (r *pdfReader) stream(v any) *pdfStream {
+	s, _ := r.resolve(v).(*pdfStream)
+	return s
+}
+
+// objectStreamIndex returns the object numbers held by object stream num.
+func (r *pdfReader) objectStreamIndex(num int) ([]int, error) {
+	s := r.stream(pdfRef{num, 0})
+	if s == nil {
+		return nil, fmt.Errorf("object %d is not a stream", num)
+	}
+	data, err := r.decode(s)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := s.dict["N"].(float64)
+	l := &lexer{data: data}
+	var nums []int
+	for i := 0; i < int(n); i++ {
+		v, err := l.next(0)
+		if err != nil {
+			return nil, err
+		}
+		objNum, _ := v.(float64)
+		if _, err := l.next(0); err != nil { // offset
+			return nil, err
+		}
+		nums = append(nums, int(objNum))
+	}
+	return nums, nil
+}
+
+// objectStream parses every object in object stream num.
+func (r *pdfReader) objectStream(num int) ([]any, error) {
+	if objs, ok := r.objStms[num]; ok {
+		return objs, nil
+	}
+	s := r.stream(pdfRef{num, 0})
+	if s == nil {
+		return nil, fmt.Errorf("object stream %d not found", num)
+	}
+	data, err := r.decode(s)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := s.dict["N"].(float64)
+	first, _ := s.dict["First"].(float64)
+	l := &lexer{data: data}
+	offsets := make([]int, 0, int(n))
+	for i := 0; i < int(n); i++ {
+		if _, err := l.next(0); err != nil {
+			return nil, err
+		}
+		off, err := l.next(0)
+		if err != nil {
+			return nil, err
+		}
+		o, _ := off.(float64)
+		offsets = append(offsets, int(first)+int(o))
+	}
+	objs := make([]any, len(offsets))
+	for i, off := range offsets {
+		if off < len(data) {
+			objs[i], _ = (&lexer{data: data, pos: off}).next(0)
+		}
+	}
+	r.objStms[num] = objs
+	return objs, nil
+}
+
+// decode applies the stream's filters.
+func (r *pdfReader) decode(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch f := r.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case pdfArray:
+		filters = f
+	}
+	var parms []any
+	switch p := r.resolve(s.dict["DecodeParms"]).(type) {
+	case pdfDict:
+		parms = []any{p}
+	case pdfArray:
+		parms = p
+	}
+	data := s.raw
+	for i, f := range filters {
+		var parm pdfDict
+		if i < len(parms) {
+			parm = r.dict(parms[i])
+		}
+		var err error
+		switch r.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data)
+			if err == nil {
+				data, err = unpredict(data, parm)
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			if end := bytes.IndexByte(data, '>'); end >= 0 {
+				data = data[:end]
+			}
+			data = decodeHexString(data)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+			if end := bytes.Index(data, []byte("~>")); end >= 0 {
+				data = data[:end]
+			}
+			out := make([]byte, len(data))
+			n, _, derr := ascii85.Decode(out, data, true)
+			data, err = out[:n], derr
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data, keeping what was recovered from a
+// truncated or corrupt stream.
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxStreamBytes))
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unpredict reverses PNG predictors (Predictor >= 10), as used by object
+// streams written with DecodeParms.
+func unpredict(data []byte, parm pdfDict) ([]byte, error) {
+	pred, _ := parm["Predictor"].(float64)
+	if pred < 10 {
+		return data, nil
+	}
+	columns := 1
+	if c, ok := parm["Columns"].(float64); ok && c > 0 {
+		columns = int(c)
+	}
+	colors, bpc := 1.0, 8.0
+	if c, ok := parm["Colors"].(float64); ok && c > 0 {
+		colors = c
+	}
+	if b, ok := parm["BitsPerComponent"].(float64); ok && b > 0 {
+		bpc = b
+	}
+	bpp := max(1, int(math.Ceil(colors*bpc/8)))
+	rowLen := int(math.Ceil(float64(columns) * colors * bpc / 8))
+	var out []byte
+	prev := make([]byte, rowLen)
+	for i := 0; i+rowLen+1 <= len(data); i += rowLen + 1 {
+		kind, row := data[i], append([]byte(nil), data[i+1:i+1+rowLen]...)
+		for j := range row {
+			var left, up, upLeft byte
+			if j >= bpp {
+				left, upLeft = row[j-bpp], prev[j-bpp]
+			}
+			up = prev[j]
+			switch kind {
+			case 1:
+				row[j] += left
+			case 2:
+				row[j] += up
+			case 3:
+				row[j] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[j] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// ─── Text ─────────────────────────────────────────────────────────────────────
+
+func extractPDF(data []byte) (*Result, error) {
+	r, err := openReader(data)
+	if err != nil {
+		return nil, err
+	}
+	root := lastRef(rootRe, data)
+	if root == nil {
+		return nil, errors.New("PDF has no document catalog")
+	}
+	catalog := r.dict(*root)
+	if catalog == nil {
+		return nil, errors.New("PDF document catalog is missing")
+	}
+
+	res := &Result{Metadata: map[string]string{}}
+	if info := lastRef(infoRe, data); info != nil {
+		if d := r.dict(*info); d != nil {
+			for key, name := range map[string]string{
+				"Title": "title", "Author": "author", "Subject": "subject",
+				"Keywords": "keywords", "Creator": "creator", "Producer": "producer",
+			} {
+				if s, ok := r.resolve(d[key]).(pdfString); ok {
+					res.Metadata[name] = textString(s)
+				}
+			}
+		}
+	}
+
+	var pages []pdfDict
+	visited := map[pdfRef]bool{}
+	var walk func(node any, inherited pdfDict, depth int)
+	walk = func(node any, inherited pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		d := r.dict(node)
+		if d == nil || depth > 64 || len(pages) >= maxPages {
+			return
+		}
+		resources := inherited
+		if v, ok := d["Resources"]; ok {
+			resources = r.dict(v)
+		}
+		if kids, ok := r.resolve(d["Kids"]).(pdfArray); ok && d["Type"] != pdfName("Page") {
+			for _, k := range kids {
+				walk(k, resources, depth+1)
+			}
+			return
+		}
+		page := pdfDict{}
+		for k, v := range d {
+			page[k] = v
+		}
+		page["Resources"] = resources
+		pages = append(pages, page)
+	}
+	walk(catalog["Pages"], nil, 0)
+
+	for i, page := range pages {
+		var content []byte
+		switch c := r.resolve(page["Contents"]).(type) {
+		case *pdfStream:
+			content, _ = r.decode(c)
+		case pdfArray:
+			for _, part := range c {
+				if s := r.stream(part); s != nil {
+					if b, err := r.decode(s); err == nil {
+						content = append(append(content, b...), '\n')
+					}
+				}
+			}
+		}
+		var text strings.Builder
+		r.showText(&text, content, r.dict(page["Resources"]), 0)
+		res.Segments = append(res.Segments, Segment{Page: i + 1, Text: text.String()})
+	}
+	return res, nil
+}
+
+func lastRef(re *regexp.Regexp, data []byte) *pdfRef {
+	all := re.FindAllSubmatch(data, -1)
+	if len(all) == 0 {
+		return nil
+	}
+	m := all[len(all)-1]
+	num, _ := strconv.Atoi(string(m[1]))
+	gen, _ := strconv.Atoi(string(m[2]))
+	return &pdfRef{num, gen}
+}
+
+// showText interprets a content stream, writing the strings it shows.
+// Lines are broken where the text position moves vertically and words
+// where it moves horizontally or a TJ adjustment leaves a gap.
+func (r *pdfReader) showText(out *strings.Builder, content []byte, resources pdfDict, depth int) {
+	fonts := map[string]*fontDecoder{}
+	fontRes := r.dict(resources["Font"])
+	var font *fontDecoder
+	var y, lastY, leading float64
+	shown, moved := false, false
+
+	show := func(s pdfString) {
+		if font == nil {
+			font = defaultFont
+		}
+		if shown && math.Abs(y-lastY) > 0.5 {
+			out.WriteByte('\n')
+		} else if moved && out.Len() > 0 {
+			out.WriteByte(' ')
+		}
+		out.WriteString(font.decode(s))
+		lastY, shown, moved = y, true, false
+	}
+
+	l := &lexer{data: content}
+	var operands []any
+	for {
+		v, err := l.next(0)
+		if err != nil {
+			break
+		}
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		num := func(i int) float64 {
+			if i < len(operands) {
+				f, _ := operands[i].(float64)
+				return f
+			}
+			return 0
+		}
+		switch op {
+		case "BT":
+			y, moved = 0, true
+		case "Tf":
+			if len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					if fonts[string(name)] == nil {
+						fonts[string(name)] = r.fontDecoder(fontRes[string(name)])
+					}
+					font = fonts[string(name)]
+				}
+			}
+		case "TL":
+			leading = num(0)
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				y += num(1)
+				if op == "TD" {
+					leading = -num(1)
+				}
+				moved = true
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, moved = num(5), true
+			}
+		case "T*":
+			y, moved = y-math.Max(leading, 1), true
+		case "Tj":
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "'", "\"":
+			y, moved = y-math.Max(leading, 1), true
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range arr {
+					switch it := item.(type) {
+					case pdfString:
+						show(it)
+					case float64:
+						if it < -180 {
+							moved = true
+						}
+					}
+				}
+			}
+		case "Do":
+			if depth < maxFormDepth && len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					xobj := r.stream(r.dict(resources["XObject"])[string(name)])
+					if xobj != nil && xobj.dict["Subtype"] == pdfName("Form") {
+						if data, err := r.decode(xobj); err == nil {
+							formRes := resources
+							if d := r.dict(xobj.dict["Resources"]); d != nil {
+								formRes = d
+							}
+							out.WriteByte('\n')
+							r.showText(out, data, formRes, depth+1)
+							shown = false
+						}
+					}
+				}
+			}
+		case "BI":
+			// Inline image: skip the dictionary and the binary data.
+			for {
+				v, err := l.next(0)
+				if err != nil || v == pdfKeyword("ID") {
+					break
+				}
+			}
+			if end := inlineEndRe.FindIndex(content[l.pos:]); end != nil {
+				l.pos += end[1]
+			} else {
+				l.pos = len(content)
+			}
+		}
+		operands = operands[:0]
+	}
+	out.WriteByte('\n')
+}
+
+// ─── Fonts ────────────────────────────────────────────────────────────────────
+
+// fontDecoder maps character codes of one font to Unicode.
+type fontDecoder struct {
+	toUnicode map[string]string
+	ranges    [][2][]byte // codespace ranges of the ToUnicode map
+	twoByte   bool        // composite (Type0) font without usable codespace
+	encoding  [256]rune
+}
+
+var defaultFont = &fontDecoder{encoding: winAnsi}
+
+func (r *pdfReader) fontDecoder(v any) *fontDecoder {
+	d := r.dict(v)
+	if d == nil {
+		return defaultFont
+	}
+	f := &fontDecoder{encoding: winAnsi, twoByte: d["Subtype"] == pdfName("Type0")}
+	switch enc := r.resolve(d["Encoding"]).(type) {
+	case pdfName:
+		if enc == "MacRomanEncoding" {
+			f.encoding = macRoman
+		}
+	case pdfDict:
+		if enc["BaseEncoding"] == pdfName("MacRomanEncoding") {
+			f.encoding = macRoman
+		}
+		if diffs, ok := r.resolve(enc["Differences"]).(pdfArray); ok {
+			code := 0
+			for _, item := range diffs {
+				switch it := item.(type) {
+				case float64:
+					code = int(it)
+				case pdfName:
+					if code >= 0 && code < 256 {
+						if rn := glyphRune(string(it)); rn != 0 {
+							f.encoding[code] = rn
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+	if s := r.stream(d["ToUnicode"]); s != nil {
+		if data, err := r.decode(s); err == nil {
+			f.parseCMap(data)
+		}
+	}
+	return f
+}
+
+// parseCMap reads the codespace ranges and bfchar/bfrange mappings of a
+// ToUnicode CMap.
+func (f *fontDecoder) parseCMap(data []byte) {
+	f.toUnicode = map[string]string{}
+	l := &lexer{data: data}
+	var operands []any
+	for {
+		v, err := l.next(0)
+		if err != nil {
+			return
+		}
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 {
+					f.ranges = append(f.ranges, [2][]byte{lo, hi})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					f.toUnicode[string(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := start; c <= end; c++ {
+						out := append([]rune(nil), base...)
+						out[len(out)-1] += rune(c - start)
+						f.toUnicode[string(codeBytes(c, len(lo)))] = string(out)
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && start+uint32(j) <= end {
+							f.toUnicode[string(codeBytes(start+uint32(j), len(lo)))] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func (f *fontDecoder) decode(s pdfString) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := f.codeLength(s[i:])
+		code := s[i:min(i+n, len(s))]
+		i += n
+		if f.toUnicode != nil {
+			if u, ok := f.toUnicode[string(code)]; ok {
+				b.WriteString(u)
+				continue
+			}
+		}
+		if len(code) == 1 {
+			if rn := f.encoding[code[0]]; rn != 0 {
+				b.WriteRune(rn)
+			}
+		}
+	}
+	return b.String()
+}
+
+// codeLength returns the byte length of the code starting s.
+func (f *fontDecoder) codeLength(s []byte) int {
+	for n := 1; n <= 4 && n <= len(s); n++ {
+		for _, rg := range f.ranges {
+			if len(rg[0]) == n && bytes.Compare(s[:n], rg[0]) >= 0 && bytes.Compare(s[:n], rg[1]) <= 0 {
+				return n
+			}
+		}
+	}
+	if f.twoByte {
+		return 2
+	}
+	return 1
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func codeBytes(v uint32, n int) []byte {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = byte(v)
+		v >>= 8
+	}
+	return out
+}
+
+func utf16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// textString decodes a PDF text string: UTF-16BE with a byte order mark,
+// UTF-8 with one, or PDFDocEncoding (approximated by Windows-1252).
+func textString(s []byte) string {
+	switch {
+	case bytes.HasPrefix(s, []byte{0xFE, 0xFF}):
+		return utf16BE(s[2:])
+	case bytes.HasPrefix(s, []byte{0xEF, 0xBB, 0xBF}):
+		return string(s[3:])
+	}
+	var b strings.Builder
+	for _, c := range s {
+		if rn := winAnsi[c]; rn != 0 {
+			b.WriteRune(rn)
+		}
+	}
+	return b.String()
+}
+
+// glyphRune maps the glyph names commonly used in /Differences arrays.
+func glyphRune(name string) rune {
+	if len(name) == 1 {
+		return rune(name[0])
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if v, err := strconv.ParseUint(name[3:], 16, 32); err == nil {
+			return rune(v)
+		}
+	}
+	if rn, ok := glyphNames[name]; ok {
+		return rn
+	}
+	return 0
+}
+
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$', "percent": '%',
+	"ampersand": '&', "quotesingle": '\'', "parenleft": '(', "parenright": ')', "asterisk": '*',
+	"plus": '+', "comma": ',', "hyphen": '-', "period": '.', "slash": '/', "zero": '0', "one": '1',
+	"two": '2', "three": '3', "four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8',
+	"nine": '9', "colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\', "bracketright": ']',
+	"underscore": '_', "braceleft": '{', "bar": '|', "braceright": '}', "degree": '°',
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…', "fi": 'ﬁ', "fl": 'ﬂ',
+	"copyright": '©', "registered": '®', "trademark": '™', "section": '§', "paragraph": '¶',
+	"Euro": '€', "sterling": '£', "yen": '¥', "multiply": '×', "divide": '÷', "minus": '−',
+	"eacute": 'é', "egrave": 'è', "agrave": 'à', "aacute": 'á', "ccedilla": 'ç', "ntilde": 'ñ',
+	"odieresis": 'ö', "udieresis": 'ü', "adieresis": 'ä', "oacute": 'ó', "uacute": 'ú', "iacute": 'í',
+	"atilde": 'ã', "otilde": 'õ', "ecircumflex": 'ê', "ocircumflex": 'ô', "acircumflex": 'â',
+	"germandbls": 'ß', "twosuperior": '²', "threesuperior": '³', "mu": 'µ', "nbspace": ' ',
+}
+
+// winAnsi is Windows-1252, the encoding of most simple fonts.
+var winAnsi = func() (t [256]rune) {
+	for i := 0x20; i < 0x7F; i++ {
+		t[i] = rune(i)
+	}
+	for i := 0xA0; i <= 0xFF; i++ {
+		t[i] = rune(i)
+	}
+	t['\t'], t['\n'], t['\r'] = '\t', '\n', '\r'
+	for i, rn := range []rune("€\x00‚ƒ„…†‡ˆ‰Š‹Œ\x00Ž\x00\x00‘’“”•–—˜™š›œ\x00žŸ") {
+		t[0x80+i] = rn
+	}
+	return t
+}()
+
+// macRoman covers the accented letters of the Mac OS Roman encoding.
+var macRoman = func() (t [256]rune) {
+	t = winAnsi
+	for i, rn := range []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ") {
+		if 0x80+i < 256 {
+			t[0x80+i] = rn
+		}
+	}
+	return t
+}()